@@ -499,6 +499,28 @@ func (c Client) State() ClientState {
 	}
 }
 
+// Fd returns the file descriptor attached to the capability, if any.
+// A local server may expose a descriptor (see FdHook), and a capability
+// received over an RPC transport that can pass descriptors, such as a
+// Unix domain socket, carries the descriptor the remote vat attached to
+// it.  Fd returns false if c is nil, released, an unresolved promise, or
+// has no descriptor.
+//
+// The descriptor is owned by the capability: the caller must not close
+// it, and must duplicate it to use it after c is released.
+func (c Client) Fd() (fd int, ok bool) {
+	h, resolved, _, finish := c.startCall()
+	defer finish()
+	if h == nil || !resolved {
+		return -1, false
+	}
+	fh, ok := h.(FdHook)
+	if !ok {
+		return -1, false
+	}
+	return fh.Fd()
+}
+
 // A Brand is an opaque value used to identify a capability.
 type Brand struct {
 	Value any
@@ -815,6 +837,15 @@ type ClientHook interface {
 	Shutdown()
 }
 
+// A ClientHook may implement FdHook to attach a file descriptor to its
+// capability.  See Client.Fd.
+type FdHook interface {
+	// Fd returns the capability's file descriptor and true, or false if
+	// it has none.  The descriptor must stay open until the hook is
+	// shut down.
+	Fd() (fd int, ok bool)
+}
+
 // Send is the input to ClientHook.Send.
 type Send struct {
 	// Method must have InterfaceID and MethodID filled in.
@@ -843,6 +874,22 @@ type Recv struct {
 
 	// Returner manages the results.
 	Returner Returner
+
+	// TailCall is set if Returner belongs to the call that made this
+	// one, so that this call's results are that call's results.  See
+	// server.Call.TailCall.  An RPC connection uses this to have the
+	// results sent straight back to the original caller when it is
+	// the same vat that hosts the capability being called.
+	TailCall bool
+
+	// ForwardPipeline, if not nil, delivers the calls pipelined so far
+	// on the results of the call that Returner belongs to to pcall, the
+	// PipelineCaller of this call, and makes later ones go straight to
+	// it.  An RPC connection that returns that call on behalf of this
+	// one calls it before sending the return, so that the calls reach
+	// this one ahead of anything the caller does once it has the
+	// return.
+	ForwardPipeline func(pcall PipelineCaller)
 }
 
 // AllocResults allocates a result struct.  It is the same as calling
@@ -3,6 +3,14 @@ capnpc-go is the Cap'n proto code generator for Go.  It reads a
 CodeGeneratorRequest from stdin and for a file foo.capnp it writes
 foo.capnp.go.  This is usually invoked from `capnp compile -ogo`.
 
+With -pogs, capnpc-go also writes a plain Go struct named FooPogs for
+each struct Foo, with ToCapnp, Insert and FromCapnp methods that copy
+it to and from the generated accessor type without reflection.  Unions
+are represented by a Which field alongside the members' fields.  A
+struct field whose type comes from another schema file refers to that
+file's FooPogs type, so imported files must be generated with -pogs
+too.  It is an error for a schema to have another type named FooPogs.
+
 See https://capnproto.org/otherlang.html#how-to-write-compiler-plugins
 for more details.
 */
@@ -15,6 +23,7 @@ import (
 	"flag"
 	"fmt"
 	"go/format"
+	"go/token"
 	"math"
 	"os"
 	"path/filepath"
@@ -43,6 +52,7 @@ type genoptions struct {
 	promises      bool
 	schemas       bool
 	structStrings bool
+	pogs          bool
 }
 
 type renderer interface {
@@ -191,6 +201,35 @@ func importForNode(n, rel *node) (importSpec, error) {
 	return importSpec{path: n.imp, name: n.pkg}, nil
 }
 
+// customTypeName returns the Go type named by a $Go.customtype
+// annotation on a field of type t, qualified for use in rel's package.
+// The annotation's value is an import path and a type name joined by a
+// dot, like "time.Duration" or "github.com/google/uuid.UUID".  A bare
+// type name refers to a type in rel's package.
+func (g *generator) customTypeName(ref string, t schema.Type, rel *node) (string, error) {
+	switch t.Which() {
+	case schema.Type_Which_bool,
+		schema.Type_Which_int8, schema.Type_Which_int16, schema.Type_Which_int32, schema.Type_Which_int64,
+		schema.Type_Which_uint8, schema.Type_Which_uint16, schema.Type_Which_uint32, schema.Type_Which_uint64,
+		schema.Type_Which_float32, schema.Type_Which_float64,
+		schema.Type_Which_text, schema.Type_Which_data:
+	default:
+		return "", fmt.Errorf("$Go.customtype is not supported on %v fields", t.Which())
+	}
+	path, name := "", ref
+	if i := strings.LastIndex(ref, "."); i > strings.LastIndex(ref, "/") {
+		path, name = ref[:i], ref[i+1:]
+	}
+	if !token.IsIdentifier(name) {
+		return "", fmt.Errorf("$Go.customtype %q does not name a Go type", ref)
+	}
+	if path == "" || path == rel.imp {
+		return name, nil
+	}
+	qname := g.imports.add(importSpec{path: path})
+	return qname + "." + name, nil
+}
+
 func (g *generator) RemoteNodeNew(n, rel *node) (string, error) {
 	ref, err := makeNodeTypeRef(n, rel)
 	if err != nil {
@@ -496,6 +535,12 @@ func (g *generator) defineField(n *node, f field) (err error) {
 		Annotations: ann,
 		FieldType:   ftyp,
 	}
+	if ann.CustomType != "" {
+		params.CustomType, err = g.customTypeName(ann.CustomType, t, n)
+		if err != nil {
+			return err
+		}
+	}
 	switch t.Which() {
 	case schema.Type_Which_void:
 		return g.r.Render(structVoidFieldParams(params))
@@ -553,6 +598,12 @@ func (g *generator) defineField(n *node, f field) (err error) {
 		if err != nil {
 			return err
 		}
+		if params.CustomType != "" {
+			return g.r.Render(structCustomTextFieldParams{
+				structFieldParams: params,
+				Default:           d,
+			})
+		}
 		return g.r.Render(structTextFieldParams{
 			structFieldParams: params,
 			Default:           d,
@@ -563,6 +614,12 @@ func (g *generator) defineField(n *node, f field) (err error) {
 		if err != nil {
 			return err
 		}
+		if params.CustomType != "" {
+			return g.r.Render(structCustomDataFieldParams{
+				structFieldParams: params,
+				Default:           d,
+			})
+		}
 		return g.r.Render(structDataFieldParams{
 			structFieldParams: params,
 			Default:           d,
@@ -843,6 +900,11 @@ func (g *generator) defineStruct(n *node) error {
 			return err
 		}
 	}
+	if g.opts.pogs {
+		if err := g.defineStructPogs(n); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -1144,6 +1206,11 @@ func (g *generator) defineFile() error {
 	if err := g.defineConstNodes(f.nodes); err != nil {
 		return err
 	}
+	if g.opts.pogs {
+		if err := checkPogsNames(f); err != nil {
+			return err
+		}
+	}
 	for _, n := range f.nodes {
 		var err error
 		switch n.Which() {
@@ -1215,6 +1282,7 @@ func main() {
 	flag.BoolVar(&opts.promises, "promises", true, "generate code for promises")
 	flag.BoolVar(&opts.schemas, "schemas", true, "embed schema information in generated code")
 	flag.BoolVar(&opts.structStrings, "structstrings", true, "generate String() methods for structs (-schemas must be true)")
+	flag.BoolVar(&opts.pogs, "pogs", false, "generate plain Go structs with ToCapnp and FromCapnp methods")
 	flag.Parse()
 
 	msg, err := capnp.NewDecoder(os.Stdin).Decode()
@@ -238,6 +238,74 @@ func TestRemoteScope(t *testing.T) {
 	}
 }
 
+func TestCustomTypeName(t *testing.T) {
+	tests := []struct {
+		ref   string
+		which schema.Type_Which
+
+		name    string
+		imports []importSpec
+		err     bool
+	}{
+		{
+			ref:     "time.Duration",
+			which:   schema.Type_Which_int64,
+			name:    "time.Duration",
+			imports: []importSpec{{name: "time", path: "time"}},
+		},
+		{
+			ref:     "github.com/google/uuid.UUID",
+			which:   schema.Type_Which_data,
+			name:    "uuid.UUID",
+			imports: []importSpec{{name: "uuid", path: "github.com/google/uuid"}},
+		},
+		{
+			ref:   "Hostname",
+			which: schema.Type_Which_text,
+			name:  "Hostname",
+		},
+		{
+			ref:   "capnproto.org/go/capnp/v3/capnpc-go/testdata/scopes.Flags",
+			which: schema.Type_Which_uint32,
+			name:  "Flags",
+		},
+		{ref: "example.com/pkg", which: schema.Type_Which_text, err: true},
+		{ref: "time.", which: schema.Type_Which_int64, err: true},
+		{ref: "time.Duration", which: schema.Type_Which_enum, err: true},
+		{ref: "time.Duration", which: schema.Type_Which_list, err: true},
+		{ref: "time.Duration", which: schema.Type_Which_structType, err: true},
+	}
+	req := mustReadGeneratorRequest(t, "scopes.capnp.out")
+	nodes, err := buildNodeMap(req)
+	if err != nil {
+		t.Fatal("buildNodeMap:", err)
+	}
+	rel := nodes[0x84efedc75e99768d] // scopes.fooVar
+	for _, test := range tests {
+		_, seg, _ := capnp.NewMessage(capnp.SingleSegment(nil))
+		typ, _ := schema.NewRootType(seg)
+		capnp.Struct(typ).SetUint16(0, uint16(test.which)) // discriminant
+		g := newGenerator(0xd68755941d99d05e, nodes, genoptions{})
+		name, err := g.customTypeName(test.ref, typ, rel)
+		if test.err {
+			if err == nil {
+				t.Errorf("customTypeName(%q, %v) = %q; want error", test.ref, test.which, name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("customTypeName(%q, %v): %v", test.ref, test.which, err)
+			continue
+		}
+		if name != test.name {
+			t.Errorf("customTypeName(%q, %v) = %q; want %q", test.ref, test.which, name, test.name)
+		}
+		if !hasExactImports(test.imports, g.imports) {
+			t.Errorf("customTypeName(%q, %v); g.imports = %s; want %s", test.ref, test.which, formatImportSpecs(g.imports.usedImports()), formatImportSpecs(test.imports))
+		}
+	}
+}
+
 func hasExactImports(specs []importSpec, imp imports) bool {
 	used := imp.usedImports()
 	if len(used) != len(specs) {
@@ -304,6 +372,8 @@ func TestDefineFile(t *testing.T) {
 		schemas:       true,
 		structStrings: true,
 	}
+	pogsOptions := defaultOptions
+	pogsOptions.pogs = true
 	tests := []struct {
 		fileID uint64
 		fname  string
@@ -335,9 +405,13 @@ func TestDefineFile(t *testing.T) {
 			schemas:       true,
 			structStrings: true,
 		}},
+		{0x832bcc6686a26d56, "aircraft.capnp.out", pogsOptions},
 		{0x83c2b5818e83ab19, "group.capnp.out", defaultOptions},
+		{0x83c2b5818e83ab19, "group.capnp.out", pogsOptions},
 		{0xb312981b2552a250, "rpc.capnp.out", defaultOptions},
+		{0xb312981b2552a250, "rpc.capnp.out", pogsOptions},
 		{0xd68755941d99d05e, "scopes.capnp.out", defaultOptions},
+		{0xd68755941d99d05e, "scopes.capnp.out", pogsOptions},
 		{0xecd50d792c3d9992, "util.capnp.out", defaultOptions},
 	}
 	for _, test := range tests {
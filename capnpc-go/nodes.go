@@ -149,12 +149,13 @@ const (
 )
 
 type annotations struct {
-	Doc       string
-	Package   string
-	Import    string
-	TagType   int
-	CustomTag string
-	Name      string
+	Doc        string
+	Package    string
+	Import     string
+	TagType    int
+	CustomTag  string
+	Name       string
+	CustomType string
 }
 
 func parseAnnotations(list capnp.StructList[schema.Annotation]) *annotations {
@@ -176,6 +177,8 @@ func parseAnnotations(list capnp.StructList[schema.Annotation]) *annotations {
 			ann.TagType = noTag
 		case 0xc2b96012172f8df1: // $name
 			ann.Name, _ = val.Text()
+		case 0xfa10659ae02f2093: // $customtype
+			ann.CustomType, _ = val.Text()
 		}
 	}
 	return ann
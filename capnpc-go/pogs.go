@@ -0,0 +1,368 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"capnproto.org/go/capnp/v3/internal/schema"
+)
+
+// pogsSuffix is appended to the name of a struct's accessor type to
+// name its plain struct.  Cap'n Proto names can't contain underscores,
+// so the plain struct of Foo_Bar can only conflict with a sibling of
+// Bar named BarPogs, which checkPogsNames reports.  A nested type named
+// Pogs, whose accessor type is Foo_Pogs, does not conflict.
+const pogsSuffix = "Pogs"
+
+// pogsName returns the name of the plain Go struct for n.
+func pogsName(n *node) string {
+	return n.Name + pogsSuffix
+}
+
+// checkPogsNames returns an error if the name of the plain struct for
+// a struct in the file f is also the name of another node in f.
+func checkPogsNames(f *node) error {
+	names := make(map[string]*node, len(f.nodes))
+	for _, n := range f.nodes {
+		names[n.Name] = n
+	}
+	for _, n := range f.nodes {
+		if n.Which() != schema.Node_Which_structNode {
+			continue
+		}
+		if m := names[pogsName(n)]; m != nil {
+			return fmt.Errorf("plain struct for %s: name %s conflicts with %s; rename one of them with $Go.name", n, pogsName(n), m)
+		}
+	}
+	return nil
+}
+
+// defineStructPogs generates the plain Go struct for n and its groups,
+// along with the methods that copy it to and from n's accessor type.
+func (g *generator) defineStructPogs(n *node) error {
+	fields := n.codeOrderFields()
+	params := pogsStructParams{
+		G:        g,
+		Node:     n,
+		Name:     pogsName(n),
+		HasUnion: n.StructNode().DiscriminantCount() > 0,
+		IsGroup:  n.StructNode().IsGroup(),
+		Fields:   make([]pogsFieldParams, 0, len(fields)),
+		Union:    make([]pogsFieldParams, 0, len(fields)),
+	}
+	for _, f := range fields {
+		fp, err := g.pogsField(n, f)
+		if err != nil {
+			return fmt.Errorf("plain struct for %s: field %s: %v", n, f.Name, err)
+		}
+		if f.HasDiscriminant() {
+			params.Union = append(params.Union, fp)
+		}
+		if fp.GoType != "" {
+			params.Fields = append(params.Fields, fp)
+		}
+	}
+	if err := g.r.Render(params); err != nil {
+		return fmt.Errorf("plain struct for %s: %v", n, err)
+	}
+	for _, f := range fields {
+		if f.Which() != schema.Field_Which_group {
+			continue
+		}
+		grp, err := g.nodes.mustFind(f.Group().TypeId())
+		if err != nil {
+			return err
+		}
+		if err := g.defineStructPogs(grp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pogsField computes the plain Go field for f and the code that copies
+// it between the plain struct p and the accessor s.
+func (g *generator) pogsField(n *node, f field) (pogsFieldParams, error) {
+	fp := pogsFieldParams{Field: f, GoName: strings.Title(f.Name)}
+	if f.Which() == schema.Field_Which_group {
+		grp, err := g.nodes.mustFind(f.Group().TypeId())
+		if err != nil {
+			return pogsFieldParams{}, err
+		}
+		fp.GoType = pogsName(grp)
+		fp.Extract = fmt.Sprintf("if err := p.%[1]s.FromCapnp(s.%[1]s()); err != nil {\nreturn err\n}\n", fp.GoName)
+		if f.HasDiscriminant() {
+			fp.Insert = fmt.Sprintf("s.Set%s()\n", fp.GoName)
+		}
+		fp.Insert += fmt.Sprintf("if err := p.%[1]s.Insert(s.%[1]s()); err != nil {\nreturn err\n}\n", fp.GoName)
+		return fp, nil
+	}
+
+	t, err := f.Slot().Type()
+	if err != nil {
+		return pogsFieldParams{}, err
+	}
+	fann, _ := f.Annotations()
+	custom := parseAnnotations(fann).CustomType
+	dst := "p." + fp.GoName
+	switch t.Which() {
+	case schema.Type_Which_void:
+		if f.HasDiscriminant() {
+			fp.Insert = fmt.Sprintf("s.Set%s()\n", fp.GoName)
+		}
+		return fp, nil
+
+	case schema.Type_Which_bool,
+		schema.Type_Which_int8, schema.Type_Which_int16, schema.Type_Which_int32, schema.Type_Which_int64,
+		schema.Type_Which_uint8, schema.Type_Which_uint16, schema.Type_Which_uint32, schema.Type_Which_uint64,
+		schema.Type_Which_float32, schema.Type_Which_float64,
+		schema.Type_Which_enum:
+		if custom != "" {
+			fp.GoType, err = g.customTypeName(custom, t, n)
+		} else {
+			fp.GoType, err = g.pogsType(t, n, false)
+		}
+		if err != nil {
+			return pogsFieldParams{}, err
+		}
+		fp.Extract = fmt.Sprintf("%s = s.%s()\n", dst, fp.GoName)
+		fp.Insert = fmt.Sprintf("s.Set%s(%s)\n", fp.GoName, dst)
+
+	case schema.Type_Which_text, schema.Type_Which_data:
+		if custom != "" {
+			fp.GoType, err = g.customTypeName(custom, t, n)
+		} else {
+			fp.GoType, err = g.pogsType(t, n, false)
+		}
+		if err != nil {
+			return pogsFieldParams{}, err
+		}
+		fp.Extract = fmt.Sprintf("if %s, err = s.%s(); err != nil {\nreturn err\n}\n", dst, fp.GoName)
+		fp.Insert = fmt.Sprintf("if err := s.Set%s(%s); err != nil {\nreturn err\n}\n", fp.GoName, dst)
+		fp.NeedErr = true
+
+	case schema.Type_Which_interface:
+		if fp.GoType, err = g.pogsType(t, n, false); err != nil {
+			return pogsFieldParams{}, err
+		}
+		fp.Extract = fmt.Sprintf("%s = s.%s()\n", dst, fp.GoName)
+		fp.Insert = fmt.Sprintf("if err := s.Set%s(%s.AddRef()); err != nil {\nreturn err\n}\n", fp.GoName, dst)
+
+	case schema.Type_Which_anyPointer:
+		fp.GoType = g.Imports().Capnp() + "." + anyPointerTypeName(t)
+		if isAnyCap(t.AnyPointer()) {
+			fp.Extract = fmt.Sprintf("%s = s.%s()\n", dst, fp.GoName)
+			fp.Insert = fmt.Sprintf("if err := s.Set%s(%s.AddRef()); err != nil {\nreturn err\n}\n", fp.GoName, dst)
+			break
+		}
+		fp.Extract = fmt.Sprintf("if %s, err = s.%s(); err != nil {\nreturn err\n}\n", dst, fp.GoName)
+		fp.Insert = fmt.Sprintf("if err := s.Set%s(%s); err != nil {\nreturn err\n}\n", fp.GoName, dst)
+		fp.NeedErr = true
+
+	case schema.Type_Which_structType:
+		if fp.GoType, err = g.pogsType(t, n, false); err != nil {
+			return pogsFieldParams{}, err
+		}
+		fp.Extract = fmt.Sprintf("if v, err := s.%[2]s(); err != nil {\nreturn err\n} else if v.IsValid() {\n"+
+			"%[1]s = new(%[3]s)\nif err := %[1]s.FromCapnp(v); err != nil {\nreturn err\n}\n}\n",
+			dst, fp.GoName, fp.GoType[1:])
+		var b strings.Builder
+		if f.HasDiscriminant() {
+			tn, err := g.RemoteTypeName(t, n)
+			if err != nil {
+				return pogsFieldParams{}, err
+			}
+			pogsInsertNull(&b, dst, fp.GoName, tn)
+		} else {
+			fmt.Fprintf(&b, "if %s != nil {\n", dst)
+		}
+		fmt.Fprintf(&b, "v, err := s.New%[2]s()\nif err != nil {\nreturn err\n}\n"+
+			"if err := %[1]s.Insert(v); err != nil {\nreturn err\n}\n}\n",
+			dst, fp.GoName)
+		fp.Insert = b.String()
+
+	case schema.Type_Which_list:
+		if fp.GoType, err = g.pogsType(t, n, false); err != nil {
+			return pogsFieldParams{}, err
+		}
+		lt, err := g.RemoteTypeName(t, n)
+		if err != nil {
+			return pogsFieldParams{}, err
+		}
+		elem, _ := t.List().ElementType()
+
+		var b strings.Builder
+		fmt.Fprintf(&b, "if l, err := s.%s(); err != nil {\nreturn err\n} else if l.IsValid() {\n", fp.GoName)
+		if err := g.pogsExtractList(&b, elem, n, dst, "l", 0); err != nil {
+			return pogsFieldParams{}, err
+		}
+		b.WriteString("}\n")
+		fp.Extract = b.String()
+
+		b.Reset()
+		if f.HasDiscriminant() {
+			pogsInsertNull(&b, dst, fp.GoName, lt)
+		} else {
+			fmt.Fprintf(&b, "if %s != nil {\n", dst)
+		}
+		fmt.Fprintf(&b, "l, err := s.New%[2]s(int32(len(%[1]s)))\nif err != nil {\nreturn err\n}\n", dst, fp.GoName)
+		if err := g.pogsInsertList(&b, elem, n, dst, "l", 0); err != nil {
+			return pogsFieldParams{}, err
+		}
+		b.WriteString("}\n")
+		fp.Insert = b.String()
+
+	default:
+		return pogsFieldParams{}, fmt.Errorf("unsupported type %v", t.Which())
+	}
+	return fp, nil
+}
+
+// pogsInsertNull writes the start of an if statement that sets the
+// union member name to null when src is nil.  The else branch, which
+// the caller writes, allocates the member.
+func pogsInsertNull(b *strings.Builder, src, name, typ string) {
+	fmt.Fprintf(b, "if %s == nil {\nif err := s.Set%s(%s{}); err != nil {\nreturn err\n}\n} else {\n", src, name, typ)
+}
+
+// pogsExtractList writes code that fills the slice dst from the
+// non-null list l, whose elements have type elem.  depth numbers the
+// variables of nested loops.  The code is always inside the scope of an
+// err variable declared by the statement that obtained l.
+func (g *generator) pogsExtractList(b *strings.Builder, elem schema.Type, rel *node, dst, l string, depth int) error {
+	gt, err := g.pogsType(elem, rel, true)
+	if err != nil {
+		return err
+	}
+	i := pogsVar("i", depth)
+	fmt.Fprintf(b, "%s = make([]%s, %s.Len())\n", dst, gt, l)
+	if elem.Which() == schema.Type_Which_void {
+		return nil
+	}
+	fmt.Fprintf(b, "for %s := range %s {\n", i, dst)
+	e := dst + "[" + i + "]"
+	switch elem.Which() {
+	case schema.Type_Which_text, schema.Type_Which_data, schema.Type_Which_interface, schema.Type_Which_anyPointer:
+		fmt.Fprintf(b, "if %s, err = %s.At(%s); err != nil {\nreturn err\n}\n", e, l, i)
+	case schema.Type_Which_structType:
+		fmt.Fprintf(b, "if err := %s.FromCapnp(%s.At(%s)); err != nil {\nreturn err\n}\n", e, l, i)
+	case schema.Type_Which_list:
+		lt, err := g.RemoteTypeName(elem, rel)
+		if err != nil {
+			return err
+		}
+		inner, _ := elem.List().ElementType()
+		v, ll := pogsVar("p", depth+1), pogsVar("l", depth+1)
+		fmt.Fprintf(b, "if %s, err := %s.At(%s); err != nil {\nreturn err\n} else if %s := %s(%s.List()); %s.IsValid() {\n",
+			v, l, i, ll, lt, v, ll)
+		if err := g.pogsExtractList(b, inner, rel, e, ll, depth+1); err != nil {
+			return err
+		}
+		b.WriteString("}\n")
+	default:
+		fmt.Fprintf(b, "%s = %s.At(%s)\n", e, l, i)
+	}
+	b.WriteString("}\n")
+	return nil
+}
+
+// pogsInsertList writes code that copies the slice src into l, a list
+// of the same length whose elements have type elem.
+func (g *generator) pogsInsertList(b *strings.Builder, elem schema.Type, rel *node, src, l string, depth int) error {
+	if elem.Which() == schema.Type_Which_void {
+		return nil
+	}
+	i := pogsVar("i", depth)
+	fmt.Fprintf(b, "for %s := range %s {\n", i, src)
+	e := src + "[" + i + "]"
+	switch elem.Which() {
+	case schema.Type_Which_text, schema.Type_Which_data, schema.Type_Which_anyPointer:
+		fmt.Fprintf(b, "if err := %s.Set(%s, %s); err != nil {\nreturn err\n}\n", l, i, e)
+	case schema.Type_Which_interface:
+		fmt.Fprintf(b, "if err := %s.Set(%s, %s.AddRef()); err != nil {\nreturn err\n}\n", l, i, e)
+	case schema.Type_Which_structType:
+		fmt.Fprintf(b, "if err := %s.Insert(%s.At(%s)); err != nil {\nreturn err\n}\n", e, l, i)
+	case schema.Type_Which_list:
+		newfunc, err := g.RemoteTypeNew(elem, rel)
+		if err != nil {
+			return err
+		}
+		inner, _ := elem.List().ElementType()
+		ll := pogsVar("l", depth+1)
+		fmt.Fprintf(b, "if %[1]s != nil {\n%[2]s, err := %[3]s(%[4]s.Segment(), int32(len(%[1]s)))\nif err != nil {\nreturn err\n}\n"+
+			"if err := %[4]s.Set(%[5]s, %[2]s.ToPtr()); err != nil {\nreturn err\n}\n",
+			e, ll, newfunc, l, i)
+		if err := g.pogsInsertList(b, inner, rel, e, ll, depth+1); err != nil {
+			return err
+		}
+		b.WriteString("}\n")
+	default:
+		fmt.Fprintf(b, "%s.Set(%s, %s)\n", l, i, e)
+	}
+	b.WriteString("}\n")
+	return nil
+}
+
+// pogsType returns the Go type that holds a value of type t in a plain
+// struct.  Structs are held by pointer unless elem is set, so that a
+// null struct field can be told apart from an empty struct.  List
+// elements are never null.
+func (g *generator) pogsType(t schema.Type, rel *node, elem bool) (string, error) {
+	switch t.Which() {
+	case schema.Type_Which_void:
+		return "struct{}", nil
+	case schema.Type_Which_structType:
+		tn, err := g.RemoteTypeName(t, rel)
+		if err != nil {
+			return "", err
+		}
+		if elem {
+			return tn + pogsSuffix, nil
+		}
+		return "*" + tn + pogsSuffix, nil
+	case schema.Type_Which_list:
+		e, _ := t.List().ElementType()
+		et, err := g.pogsType(e, rel, true)
+		if err != nil {
+			return "", err
+		}
+		return "[]" + et, nil
+	case schema.Type_Which_anyPointer:
+		if elem {
+			// Lists of AnyPointer are PointerLists.
+			return g.Imports().Capnp() + ".Ptr", nil
+		}
+		return g.Imports().Capnp() + "." + anyPointerTypeName(t), nil
+	default:
+		return g.RemoteTypeName(t, rel)
+	}
+}
+
+// anyPointerTypeName returns the name of the type in the capnp package
+// that the accessors for an AnyPointer field use.
+func anyPointerTypeName(t schema.Type) string {
+	ap := t.AnyPointer()
+	if ap.Which() != schema.Type_anyPointer_Which_unconstrained {
+		return "Ptr"
+	}
+	switch ap.Unconstrained().Which() {
+	case schema.Type_anyPointer_unconstrained_Which_struct:
+		return "Struct"
+	case schema.Type_anyPointer_unconstrained_Which_list:
+		return "List"
+	case schema.Type_anyPointer_unconstrained_Which_capability:
+		return "Client"
+	default:
+		return "Ptr"
+	}
+}
+
+// pogsVar returns the name of a variable used at the given depth of
+// nested list loops.
+func pogsVar(name string, depth int) string {
+	if depth == 0 {
+		return name
+	}
+	return name + strconv.Itoa(depth)
+}
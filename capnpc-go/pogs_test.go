@@ -0,0 +1,454 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"go/format"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"capnproto.org/go/capnp/v3"
+	air "capnproto.org/go/capnp/v3/internal/aircraftlib"
+	"capnproto.org/go/capnp/v3/internal/schema"
+)
+
+// TestPogsAircraftlibUpToDate checks that internal/aircraftlib, which
+// the tests below run the generated plain structs of, is what
+// capnpc-go -pogs generates for testdata/aircraft.capnp.out.
+func TestPogsAircraftlibUpToDate(t *testing.T) {
+	data, err := readTestFile("aircraft.capnp.out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err := capnp.Unmarshal(data)
+	if err != nil {
+		t.Fatal("Unmarshal:", err)
+	}
+	req, err := schema.ReadRootCodeGeneratorRequest(msg)
+	if err != nil {
+		t.Fatal("ReadRootCodeGeneratorRequest:", err)
+	}
+	nodes, err := buildNodeMap(req)
+	if err != nil {
+		t.Fatal("buildNodeMap:", err)
+	}
+	g := newGenerator(0x832bcc6686a26d56, nodes, genoptions{
+		promises:      true,
+		schemas:       true,
+		structStrings: true,
+		pogs:          true,
+	})
+	if err := g.defineFile(); err != nil {
+		t.Fatal("defineFile:", err)
+	}
+	src, err := format.Source(g.generate())
+	if err != nil {
+		t.Fatal("format:", err)
+	}
+	want, err := os.ReadFile(filepath.Join("..", "internal", "aircraftlib", "aircraft.capnp.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(src, want) {
+		t.Error("internal/aircraftlib/aircraft.capnp.go differs from the output of capnpc-go -pogs; regenerate it")
+	}
+}
+
+func TestPogsRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		p    air.ZPogs
+	}{
+		{"void", air.ZPogs{Which: air.Z_Which_void}},
+		{"int", air.ZPogs{Which: air.Z_Which_i64, I64: -123}},
+		{"float", air.ZPogs{Which: air.Z_Which_f32, F32: 3.5}},
+		{"bool", air.ZPogs{Which: air.Z_Which_bool, Bool: true}},
+		{"text", air.ZPogs{Which: air.Z_Which_text, Text: "Hello, World!"}},
+		{"data", air.ZPogs{Which: air.Z_Which_blob, Blob: []byte("Hello")}},
+		{"enum", air.ZPogs{Which: air.Z_Which_airport, Airport: air.Airport_lax}},
+		{"nested", air.ZPogs{
+			Which: air.Z_Which_zz,
+			Zz:    &air.ZPogs{Which: air.Z_Which_u8, U8: 42},
+		}},
+		{"struct", air.ZPogs{
+			Which:     air.Z_Which_planebase,
+			Planebase: &air.PlaneBasePogs{Name: "Boeing", Homes: []air.Airport{air.Airport_jfk, air.Airport_lax}, Rating: 100, CanFly: true, Capacity: 100, MaxSpeed: 900},
+		}},
+		{"struct union", air.ZPogs{
+			Which: air.Z_Which_aircraft,
+			Aircraft: &air.AircraftPogs{
+				Which: air.Aircraft_Which_b737,
+				B737:  &air.B737Pogs{Base: &air.PlaneBasePogs{Name: "737"}},
+			},
+		}},
+		{"group", air.ZPogs{Which: air.Z_Which_grp, Grp: air.Z_grpPogs{First: 1, Second: 2}}},
+		{"bool list", air.ZPogs{Which: air.Z_Which_boolvec, Boolvec: []bool{true, false, true}}},
+		{"int list", air.ZPogs{Which: air.Z_Which_i16vec, I16vec: []int16{1, -2, 3}}},
+		{"text list", air.ZPogs{Which: air.Z_Which_textvec, Textvec: []string{"a", "", "c"}}},
+		{"data list", air.ZPogs{Which: air.Z_Which_datavec, Datavec: [][]byte{[]byte("a"), []byte("bc")}}},
+		{"struct list", air.ZPogs{Which: air.Z_Which_zdatevec, Zdatevec: []air.ZdatePogs{{Year: 2004, Month: 12, Day: 7}, {Year: 2005, Month: 1, Day: 1}}}},
+		{"union list", air.ZPogs{Which: air.Z_Which_zvec, Zvec: []air.ZPogs{
+			{Which: air.Z_Which_i64, I64: 1},
+			{Which: air.Z_Which_text, Text: "two"},
+		}}},
+		{"list of lists", air.ZPogs{Which: air.Z_Which_zvecvec, Zvecvec: [][]air.ZPogs{
+			{{Which: air.Z_Which_u16, U16: 1}},
+			{{Which: air.Z_Which_void}, {Which: air.Z_Which_bool, Bool: true}},
+		}}},
+	}
+	for _, test := range tests {
+		_, seg, err := capnp.NewMessage(capnp.SingleSegment(nil))
+		if err != nil {
+			t.Fatal("NewMessage:", err)
+		}
+		z, err := test.p.ToCapnp(seg)
+		if err != nil {
+			t.Errorf("%s: ToCapnp: %v", test.name, err)
+			continue
+		}
+		if z.Which() != test.p.Which {
+			t.Errorf("%s: ToCapnp set union to %v; want %v", test.name, z.Which(), test.p.Which)
+		}
+		var got air.ZPogs
+		if err := got.FromCapnp(z); err != nil {
+			t.Errorf("%s: FromCapnp: %v", test.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.p) {
+			t.Errorf("%s: FromCapnp(ToCapnp(%+v)) = %+v", test.name, test.p, got)
+		}
+	}
+}
+
+func TestPogsRoundTripCaps(t *testing.T) {
+	echo := air.Echo_ServerToClient(echoServer{})
+	defer echo.Release()
+	tests := []struct {
+		name string
+		p    air.ZPogs
+		caps func(air.ZPogs) []capnp.Client
+	}{
+		{
+			"interface",
+			air.ZPogs{Which: air.Z_Which_echo, Echo: echo},
+			func(p air.ZPogs) []capnp.Client { return []capnp.Client{capnp.Client(p.Echo)} },
+		},
+		{
+			"interface list",
+			air.ZPogs{Which: air.Z_Which_echoes, Echoes: []air.Echo{echo, {}, echo}},
+			func(p air.ZPogs) []capnp.Client {
+				caps := make([]capnp.Client, len(p.Echoes))
+				for i, e := range p.Echoes {
+					caps[i] = capnp.Client(e)
+				}
+				return caps
+			},
+		},
+		{
+			"capability",
+			air.ZPogs{Which: air.Z_Which_anyCapability, AnyCapability: capnp.Client(echo)},
+			func(p air.ZPogs) []capnp.Client { return []capnp.Client{p.AnyCapability} },
+		},
+	}
+	for _, test := range tests {
+		msg, seg, err := capnp.NewMessage(capnp.SingleSegment(nil))
+		if err != nil {
+			t.Fatal("NewMessage:", err)
+		}
+		z, err := test.p.ToCapnp(seg)
+		if err != nil {
+			t.Errorf("%s: ToCapnp: %v", test.name, err)
+			msg.Reset(nil)
+			continue
+		}
+		var got air.ZPogs
+		if err := got.FromCapnp(z); err != nil {
+			t.Errorf("%s: FromCapnp: %v", test.name, err)
+			msg.Reset(nil)
+			continue
+		}
+		want, gotCaps := test.caps(test.p), test.caps(got)
+		if len(gotCaps) != len(want) {
+			t.Errorf("%s: FromCapnp returned %d capabilities; want %d", test.name, len(gotCaps), len(want))
+		}
+		for i := 0; i < len(gotCaps) && i < len(want); i++ {
+			if gotCaps[i].IsValid() != want[i].IsValid() || want[i].IsValid() && !gotCaps[i].IsSame(want[i]) {
+				t.Errorf("%s: capability %d = %v; want %v", test.name, i, gotCaps[i], want[i])
+			}
+		}
+
+		// Insert takes its own references, so resetting the message,
+		// which releases its capabilities, leaves echo usable.
+		msg.Reset(nil)
+	}
+
+	ans, release := echo.Echo(context.Background(), func(p air.Echo_echo_Params) error {
+		return p.SetIn("ping")
+	})
+	defer release()
+	res, err := ans.Struct()
+	if err != nil {
+		t.Fatal("echo after releasing messages:", err)
+	}
+	if out, _ := res.Out(); out != "ping" {
+		t.Errorf("echo returned %q; want \"ping\"", out)
+	}
+}
+
+func TestPogsRoundTripCustomType(t *testing.T) {
+	want := air.EndpointPogs{
+		Addr:    netip.MustParseAddrPort("192.0.2.1:8080"),
+		Ip:      netip.MustParseAddr("2001:db8::1"),
+		Timeout: 5 * time.Second,
+	}
+	msg, seg, err := capnp.NewMessage(capnp.SingleSegment(nil))
+	if err != nil {
+		t.Fatal("NewMessage:", err)
+	}
+	e, err := want.ToCapnp(seg)
+	if err != nil {
+		t.Fatal("ToCapnp:", err)
+	}
+	if err := msg.SetRoot(e.ToPtr()); err != nil {
+		t.Fatal("SetRoot:", err)
+	}
+	data, err := msg.Marshal()
+	if err != nil {
+		t.Fatal("Marshal:", err)
+	}
+	msg, err = capnp.Unmarshal(data)
+	if err != nil {
+		t.Fatal("Unmarshal:", err)
+	}
+	e, err = air.ReadRootEndpoint(msg)
+	if err != nil {
+		t.Fatal("ReadRootEndpoint:", err)
+	}
+	var got air.EndpointPogs
+	if err := got.FromCapnp(e); err != nil {
+		t.Fatal("FromCapnp:", err)
+	}
+	if got != want {
+		t.Errorf("FromCapnp(ToCapnp(%+v)) = %+v", want, got)
+	}
+
+	// A zero Endpoint reads as the schema's default timeout.
+	_, seg, _ = capnp.NewMessage(capnp.SingleSegment(nil))
+	e, _ = air.NewEndpoint(seg)
+	if err := got.FromCapnp(e); err != nil {
+		t.Fatal("FromCapnp:", err)
+	}
+	if got.Timeout != 30*time.Second {
+		t.Errorf("FromCapnp(Endpoint{}).Timeout = %v; want 30s", got.Timeout)
+	}
+}
+
+type echoServer struct{}
+
+func (echoServer) Echo(ctx context.Context, call air.Echo_echo) error {
+	in, err := call.Args().In()
+	if err != nil {
+		return err
+	}
+	res, err := call.AllocResults()
+	if err != nil {
+		return err
+	}
+	return res.SetOut(in)
+}
+
+// TestPogsNestedPogsType checks that the plain struct of a struct
+// doesn't conflict with a nested struct named Pogs, and that a
+// sibling struct with the plain struct's name is reported as an error.
+func TestPogsNestedPogsType(t *testing.T) {
+	const (
+		fileID    = 0xc5a3e1c4f2b7d690
+		fooID     = 0xd1f0c6a9e3b2a401
+		pogsID    = 0xd1f0c6a9e3b2a402
+		fooPogsID = 0xd1f0c6a9e3b2a403
+	)
+
+	t.Run("Nested", func(t *testing.T) {
+		// struct Foo {
+		//   struct Pogs { f @0 :Foo; }
+		//   f @0 :Pogs;
+		// }
+		src, err := generatePogsNamesFile(
+			pogsNamesStruct{id: fooID, scope: fileID, name: "Foo", field: pogsID},
+			pogsNamesStruct{id: pogsID, scope: fooID, name: "Pogs", field: fooID},
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, decl := range []string{
+			"type Foo_Pogs capnp.Struct",
+			"type FooPogs struct",
+			"type Foo_PogsPogs struct",
+			"F *Foo_PogsPogs",
+			"F *FooPogs",
+		} {
+			if !bytes.Contains(src, []byte(decl)) {
+				t.Errorf("generated code does not contain %q", decl)
+			}
+		}
+	})
+	t.Run("Conflict", func(t *testing.T) {
+		// struct Foo {}
+		// struct FooPogs {}
+		_, err := generatePogsNamesFile(
+			pogsNamesStruct{id: fooID, scope: fileID, name: "Foo"},
+			pogsNamesStruct{id: fooPogsID, scope: fileID, name: "FooPogs"},
+		)
+		if err == nil {
+			t.Fatal("generating a struct named FooPogs next to Foo succeeded")
+		}
+		if !strings.Contains(err.Error(), "conflicts") {
+			t.Errorf("error = %v; want a name conflict", err)
+		}
+	})
+}
+
+// pogsNamesStruct describes a struct in the schema file built by
+// generatePogsNamesFile.
+type pogsNamesStruct struct {
+	id, scope uint64
+	name      string
+	field     uint64 // if not zero, the struct type of field f
+}
+
+// generatePogsNamesFile generates the plain structs of a schema file
+// that holds structs, with no other declarations, and returns the
+// formatted source.
+func generatePogsNamesFile(structs ...pogsNamesStruct) ([]byte, error) {
+	const fileID = 0xc5a3e1c4f2b7d690
+	const fileName = "pogsnames.capnp"
+
+	_, seg, err := capnp.NewMessage(capnp.SingleSegment(nil))
+	if err != nil {
+		return nil, err
+	}
+	req, err := schema.NewRootCodeGeneratorRequest(seg)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := req.NewNodes(int32(len(structs) + 1))
+	if err != nil {
+		return nil, err
+	}
+	displayNames := map[uint64]string{fileID: fileName}
+	for _, s := range structs {
+		sep := "."
+		if s.scope == fileID {
+			sep = ":"
+		}
+		displayNames[s.id] = displayNames[s.scope] + sep + s.name
+	}
+	setNested := func(n schema.Node, id uint64) error {
+		var nested []pogsNamesStruct
+		for _, s := range structs {
+			if s.scope == id {
+				nested = append(nested, s)
+			}
+		}
+		list, err := n.NewNestedNodes(int32(len(nested)))
+		if err != nil {
+			return err
+		}
+		for i, s := range nested {
+			list.At(i).SetId(s.id)
+			if err := list.At(i).SetName(s.name); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	file := nodes.At(0)
+	file.SetId(fileID)
+	file.SetFile()
+	if err := file.SetDisplayName(fileName); err != nil {
+		return nil, err
+	}
+	anns, err := file.NewAnnotations(2)
+	if err != nil {
+		return nil, err
+	}
+	for i, a := range []struct {
+		id  uint64
+		val string
+	}{
+		{0xbea97f1023792be0, "pogsnames"},                                              // $Go.package
+		{0xe130b601260e44b5, "capnproto.org/go/capnp/v3/capnpc-go/testdata/pogsnames"}, // $Go.import
+	} {
+		anns.At(i).SetId(a.id)
+		v, err := anns.At(i).NewValue()
+		if err != nil {
+			return nil, err
+		}
+		if err := v.SetText(a.val); err != nil {
+			return nil, err
+		}
+	}
+	if err := setNested(file, fileID); err != nil {
+		return nil, err
+	}
+
+	for i, s := range structs {
+		n := nodes.At(i + 1)
+		n.SetId(s.id)
+		n.SetScopeId(s.scope)
+		dn := displayNames[s.id]
+		if err := n.SetDisplayName(dn); err != nil {
+			return nil, err
+		}
+		n.SetDisplayNamePrefixLength(uint32(len(dn) - len(s.name)))
+		if err := setNested(n, s.id); err != nil {
+			return nil, err
+		}
+		n.SetStructNode()
+		sn := n.StructNode()
+		sn.SetPreferredListEncoding(schema.ElementSize_inlineComposite)
+		if s.field == 0 {
+			continue
+		}
+		sn.SetPointerCount(1)
+		fields, err := sn.NewFields(1)
+		if err != nil {
+			return nil, err
+		}
+		f := fields.At(0)
+		if err := f.SetName("f"); err != nil {
+			return nil, err
+		}
+		f.SetDiscriminantValue(schema.Field_noDiscriminant)
+		f.SetSlot()
+		typ, err := f.Slot().NewType()
+		if err != nil {
+			return nil, err
+		}
+		typ.SetStructType()
+		typ.StructType().SetTypeId(s.field)
+		dv, err := f.Slot().NewDefaultValue()
+		if err != nil {
+			return nil, err
+		}
+		if err := dv.SetStructValue(capnp.Ptr{}); err != nil {
+			return nil, err
+		}
+	}
+
+	nm, err := buildNodeMap(req)
+	if err != nil {
+		return nil, err
+	}
+	g := newGenerator(fileID, nm, genoptions{pogs: true})
+	if err := g.defineFile(); err != nil {
+		return nil, err
+	}
+	return format.Source(g.generate())
+}
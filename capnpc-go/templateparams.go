@@ -58,10 +58,17 @@ type structFieldParams struct {
 	Field       field
 	Annotations *annotations
 	FieldType   string
+
+	// CustomType is the Go type named by the field's $Go.customtype
+	// annotation, qualified for use in the generated file.  It is empty
+	// if the field has no such annotation.
+	CustomType string
 }
 
 type (
 	structFloatFieldParams      structUintFieldParams
+	structCustomTextFieldParams structTextFieldParams
+	structCustomDataFieldParams structDataFieldParams
 	structInterfaceFieldParams  structFieldParams
 	structCapabilityFieldParams structFieldParams
 	structVoidFieldParams       structFieldParams
@@ -87,10 +94,24 @@ func (p structUintFieldParams) Offset() uint32 {
 	return p.Field.Slot().Offset() * uint32(p.Bits/8)
 }
 
+func (p structUintFieldParams) ReturnType() string {
+	if p.CustomType != "" {
+		return p.CustomType
+	}
+	return fmt.Sprintf("uint%d", p.Bits)
+}
+
 func (p structFloatFieldParams) Offset() uint32 {
 	return structUintFieldParams(p).Offset()
 }
 
+func (p structFloatFieldParams) ReturnType() string {
+	if p.CustomType != "" {
+		return p.CustomType
+	}
+	return fmt.Sprintf("float%d", p.Bits)
+}
+
 type structIntFieldParams struct {
 	structUintFieldParams
 	EnumName string
@@ -100,6 +121,9 @@ func (p structIntFieldParams) ReturnType() string {
 	if p.EnumName != "" {
 		return p.EnumName
 	}
+	if p.CustomType != "" {
+		return p.CustomType
+	}
 	return fmt.Sprintf("int%d", p.Bits)
 }
 
@@ -249,3 +273,32 @@ func hexdigit(b byte) byte {
 	}
 	return (b - 10) + 'a'
 }
+
+type pogsStructParams struct {
+	G        *generator
+	Node     *node
+	Name     string // name of the plain struct
+	IsGroup  bool
+	HasUnion bool
+	Fields   []pogsFieldParams // fields with a Go representation
+	Union    []pogsFieldParams // members of the struct's union
+}
+
+// NeedErr reports whether FromCapnp assigns to a function-scoped err.
+func (p pogsStructParams) NeedErr() bool {
+	for _, f := range p.Fields {
+		if f.NeedErr {
+			return true
+		}
+	}
+	return false
+}
+
+type pogsFieldParams struct {
+	Field   field
+	GoName  string
+	GoType  string // empty for Void fields
+	Extract string // copies s's field into p
+	Insert  string // copies p's field into s
+	NeedErr bool
+}
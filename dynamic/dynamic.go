@@ -0,0 +1,197 @@
+/*
+Package dynamic provides access to Cap'n Proto structs and lists whose
+types are only known at runtime.  Where generated code has a method for
+each field, a dynamic.Struct looks fields up by name in the struct's
+schema node, which is found through a schemas.Registry.
+
+	s, err := dynamic.Wrap(root, 0xea26e9973bd6a0d9)
+	if err != nil {
+		return err
+	}
+	which, err := s.Which()
+	if err != nil {
+		return err
+	}
+	v, err := s.Get(which)
+
+Field values are passed around as Go values, using the same types as
+pogs:
+
+	Void            struct{}
+	Bool            bool
+	Int8..Int64     int8..int64
+	UInt8..UInt64   uint8..uint64
+	Float32         float32
+	Float64         float64
+	Text            string
+	Data            []byte
+	enum            Enum
+	struct, group   Struct
+	List(T)         List
+	interface       capnp.Client
+	AnyPointer      capnp.Ptr
+
+Set and List.Set are more lenient in what they accept: any Go integer
+that is in range may be stored in an integer field, an enum may be set
+from its name or number, and pointer fields may be set to nil to clear
+them.
+
+A Client calls the methods of an interface by name, with parameters
+and results as Structs.  Methods and MethodsFunc build the other side:
+a []server.Method for server.New that dispatches calls by method name.
+*/
+package dynamic
+
+import (
+	"fmt"
+	"sync"
+
+	"capnproto.org/go/capnp/v3"
+	"capnproto.org/go/capnp/v3/schemas"
+	"capnproto.org/go/capnp/v3/std/capnp/schema"
+)
+
+// A Loader finds schema nodes in a registry.  The zero value uses the
+// default registry.  A Loader is safe to use from multiple goroutines.
+type Loader struct {
+	reg *schemas.Registry
+
+	mu    sync.Mutex
+	nodes map[uint64]schema.Node
+}
+
+// NewLoader returns a Loader that finds nodes in reg.
+func NewLoader(reg *schemas.Registry) *Loader {
+	return &Loader{reg: reg}
+}
+
+var defaultLoader Loader
+
+func (ld *Loader) registry() *schemas.Registry {
+	if ld.reg != nil {
+		return ld.reg
+	}
+	return &schemas.DefaultRegistry
+}
+
+// Node returns the schema node with the given ID.
+func (ld *Loader) Node(id uint64) (schema.Node, error) {
+	ld.mu.Lock()
+	defer ld.mu.Unlock()
+	if n, ok := ld.nodes[id]; ok {
+		return n, nil
+	}
+	data, err := ld.registry().Find(id)
+	if err != nil {
+		return schema.Node{}, err
+	}
+	msg, err := capnp.Unmarshal(data)
+	if err != nil {
+		return schema.Node{}, fmt.Errorf("dynamic: load node %#x: %w", id, err)
+	}
+	req, err := schema.ReadRootCodeGeneratorRequest(msg)
+	if err != nil {
+		return schema.Node{}, fmt.Errorf("dynamic: load node %#x: %w", id, err)
+	}
+	nodes, err := req.Nodes()
+	if err != nil {
+		return schema.Node{}, fmt.Errorf("dynamic: load node %#x: %w", id, err)
+	}
+	if ld.nodes == nil {
+		ld.nodes = make(map[uint64]schema.Node)
+	}
+	for i := 0; i < nodes.Len(); i++ {
+		n := nodes.At(i)
+		ld.nodes[n.Id()] = n
+	}
+	n, ok := ld.nodes[id]
+	if !ok {
+		return schema.Node{}, fmt.Errorf("dynamic: node %#x not found in its schema", id)
+	}
+	return n, nil
+}
+
+// nodeOf returns the node with the given ID, which must be of kind which.
+func (ld *Loader) nodeOf(id uint64, which schema.Node_Which) (schema.Node, error) {
+	n, err := ld.Node(id)
+	if err != nil {
+		return schema.Node{}, err
+	}
+	if n.Which() != which {
+		return schema.Node{}, fmt.Errorf("dynamic: %s is a %v, not a %v", shortDisplayName(n), n.Which(), which)
+	}
+	return n, nil
+}
+
+// NewStruct allocates a new struct of the given type in seg.
+func (ld *Loader) NewStruct(seg *capnp.Segment, typeID uint64) (Struct, error) {
+	n, err := ld.nodeOf(typeID, schema.Node_Which_structNode)
+	if err != nil {
+		return Struct{}, err
+	}
+	s, err := capnp.NewStruct(seg, structSize(n))
+	if err != nil {
+		return Struct{}, err
+	}
+	return Struct{s: s, node: n, ld: ld}, nil
+}
+
+// NewRootStruct allocates a new struct of the given type in seg and
+// sets it as the root of seg's message.
+func (ld *Loader) NewRootStruct(seg *capnp.Segment, typeID uint64) (Struct, error) {
+	n, err := ld.nodeOf(typeID, schema.Node_Which_structNode)
+	if err != nil {
+		return Struct{}, err
+	}
+	s, err := capnp.NewRootStruct(seg, structSize(n))
+	if err != nil {
+		return Struct{}, err
+	}
+	return Struct{s: s, node: n, ld: ld}, nil
+}
+
+// Wrap returns a Struct that reads s as the given type.
+func (ld *Loader) Wrap(s capnp.Struct, typeID uint64) (Struct, error) {
+	n, err := ld.nodeOf(typeID, schema.Node_Which_structNode)
+	if err != nil {
+		return Struct{}, err
+	}
+	return Struct{s: s, node: n, ld: ld}, nil
+}
+
+// WrapList returns a List that reads l as a list of the given element
+// type.
+func (ld *Loader) WrapList(l capnp.List, elem schema.Type) List {
+	return List{l: l, elem: elem, ld: ld}
+}
+
+// NewStruct allocates a new struct of the given type in seg, using the
+// default registry.
+func NewStruct(seg *capnp.Segment, typeID uint64) (Struct, error) {
+	return defaultLoader.NewStruct(seg, typeID)
+}
+
+// NewRootStruct allocates a new struct of the given type in seg and
+// sets it as the root of seg's message, using the default registry.
+func NewRootStruct(seg *capnp.Segment, typeID uint64) (Struct, error) {
+	return defaultLoader.NewRootStruct(seg, typeID)
+}
+
+// Wrap returns a Struct that reads s as the given type, using the
+// default registry.
+func Wrap(s capnp.Struct, typeID uint64) (Struct, error) {
+	return defaultLoader.Wrap(s, typeID)
+}
+
+func structSize(n schema.Node) capnp.ObjectSize {
+	sn := n.StructNode()
+	return capnp.ObjectSize{
+		DataSize:     capnp.Size(sn.DataWordCount()) * 8,
+		PointerCount: sn.PointerCount(),
+	}
+}
+
+func shortDisplayName(n schema.Node) string {
+	dn, _ := n.DisplayName()
+	return dn[n.DisplayNamePrefixLength():]
+}
@@ -0,0 +1,426 @@
+package dynamic_test
+
+import (
+	"bytes"
+	"testing"
+
+	"capnproto.org/go/capnp/v3"
+	"capnproto.org/go/capnp/v3/dynamic"
+	air "capnproto.org/go/capnp/v3/internal/aircraftlib"
+	"capnproto.org/go/capnp/v3/schemas"
+)
+
+func newSegment(t *testing.T) *capnp.Segment {
+	_, seg, err := capnp.NewMessage(capnp.SingleSegment(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return seg
+}
+
+func TestGet(t *testing.T) {
+	seg := newSegment(t)
+	z, err := air.NewRootZ(seg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pb, err := z.NewPlanebase()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pb.SetName("alpha")
+	pb.SetRating(-5)
+	pb.SetCanFly(true)
+	homes, err := pb.NewHomes(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	homes.Set(0, air.Airport_jfk)
+	homes.Set(1, air.Airport_sfo)
+
+	s, err := dynamic.Wrap(capnp.Struct(z), air.Z_TypeID)
+	if err != nil {
+		t.Fatal("Wrap:", err)
+	}
+	which, err := s.Which()
+	if err != nil || which != "planebase" {
+		t.Fatalf("Which() = %q, %v; want \"planebase\", <nil>", which, err)
+	}
+	if _, err := s.Get("text"); err == nil {
+		t.Error("Get of inactive union member succeeded")
+	}
+	if ok, err := s.Has("text"); ok || err != nil {
+		t.Errorf("Has(\"text\") = %t, %v; want false, <nil>", ok, err)
+	}
+	v, err := s.Get("planebase")
+	if err != nil {
+		t.Fatal("Get(\"planebase\"):", err)
+	}
+	base, ok := v.(dynamic.Struct)
+	if !ok {
+		t.Fatalf("Get(\"planebase\") = %T; want dynamic.Struct", v)
+	}
+	if base.TypeID() != air.PlaneBase_TypeID {
+		t.Errorf("planebase.TypeID() = %#x; want %#x", base.TypeID(), uint64(air.PlaneBase_TypeID))
+	}
+	for _, test := range []struct {
+		name string
+		want any
+	}{
+		{"name", "alpha"},
+		{"rating", int64(-5)},
+		{"canFly", true},
+		{"capacity", int64(0)},
+		{"maxSpeed", float64(0)},
+	} {
+		if got, err := base.Get(test.name); err != nil || got != test.want {
+			t.Errorf("planebase.Get(%q) = %#v, %v; want %#v, <nil>", test.name, got, err, test.want)
+		}
+	}
+	v, err = base.Get("homes")
+	if err != nil {
+		t.Fatal("Get(\"homes\"):", err)
+	}
+	l := v.(dynamic.List)
+	if l.Len() != 2 {
+		t.Fatalf("homes.Len() = %d; want 2", l.Len())
+	}
+	for i, want := range []string{"jfk", "sfo"} {
+		e, err := l.At(i)
+		if err != nil {
+			t.Fatalf("homes.At(%d): %v", i, err)
+		}
+		if got := e.(dynamic.Enum).String(); got != want {
+			t.Errorf("homes.At(%d) = %s; want %s", i, got, want)
+		}
+	}
+	if _, err := l.At(2); err == nil {
+		t.Error("homes.At(2) succeeded")
+	}
+}
+
+func TestSet(t *testing.T) {
+	seg := newSegment(t)
+	s, err := dynamic.NewRootStruct(seg, air.Z_TypeID)
+	if err != nil {
+		t.Fatal("NewRootStruct:", err)
+	}
+	z, err := air.ReadRootZ(seg.Message())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		v     any
+		which air.Z_Which
+		check func() any
+		want  any
+	}{
+		{"f64", 3.5, air.Z_Which_f64, func() any { return z.F64() }, 3.5},
+		{"f32", 2, air.Z_Which_f32, func() any { return z.F32() }, float32(2)},
+		{"i8", -128, air.Z_Which_i8, func() any { return z.I8() }, int8(-128)},
+		{"i64", int64(-1 << 62), air.Z_Which_i64, func() any { return z.I64() }, int64(-1 << 62)},
+		{"u16", uint8(200), air.Z_Which_u16, func() any { return z.U16() }, uint16(200)},
+		{"u64", uint64(1<<64 - 1), air.Z_Which_u64, func() any { return z.U64() }, uint64(1<<64 - 1)},
+		{"bool", true, air.Z_Which_bool, func() any { return z.Bool() }, true},
+		{"text", "hello", air.Z_Which_text, func() any { t, _ := z.Text(); return t }, "hello"},
+		{"blob", []byte("hi"), air.Z_Which_blob, func() any { b, _ := z.Blob(); return string(b) }, "hi"},
+		{"airport", "lax", air.Z_Which_airport, func() any { return z.Airport() }, air.Airport_lax},
+		{"airport", 4, air.Z_Which_airport, func() any { return z.Airport() }, air.Airport(4)},
+		{"void", nil, air.Z_Which_void, func() any { return nil }, nil},
+	}
+	for _, test := range tests {
+		if err := s.Set(test.name, test.v); err != nil {
+			t.Errorf("Set(%q, %#v): %v", test.name, test.v, err)
+			continue
+		}
+		if z.Which() != test.which {
+			t.Errorf("after Set(%q, %#v), Which() = %v; want %v", test.name, test.v, z.Which(), test.which)
+			continue
+		}
+		if got := test.check(); got != test.want {
+			t.Errorf("after Set(%q, %#v), field = %#v; want %#v", test.name, test.v, got, test.want)
+		}
+		if got, err := s.Which(); err != nil || got != test.name {
+			t.Errorf("after Set(%q, %#v), dynamic Which() = %q, %v", test.name, test.v, got, err)
+		}
+	}
+
+	errTests := []struct {
+		name string
+		v    any
+	}{
+		{"nope", 1},
+		{"i8", 128},
+		{"u8", -1},
+		{"u32", 1.5},
+		{"bool", 1},
+		{"text", 42},
+		{"airport", "ord"},
+		{"grp", nil},
+		{"zz", "not a struct"},
+		{"planebase", capnp.Struct{}},
+	}
+	for _, test := range errTests {
+		if test.name == "planebase" {
+			// A struct of the wrong type is rejected.
+			other, err := dynamic.NewStruct(seg, air.Zdate_TypeID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			test.v = other
+		}
+		if err := s.Set(test.name, test.v); err == nil {
+			t.Errorf("Set(%q, %#v) succeeded", test.name, test.v)
+		}
+	}
+}
+
+func TestInit(t *testing.T) {
+	seg := newSegment(t)
+	s, err := dynamic.NewRootStruct(seg, air.Z_TypeID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	z, err := air.ReadRootZ(seg.Message())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	grp, err := s.Init("grp")
+	if err != nil {
+		t.Fatal("Init(\"grp\"):", err)
+	}
+	if !grp.IsGroup() {
+		t.Error("grp.IsGroup() = false")
+	}
+	if err := grp.Set("first", uint64(1)); err != nil {
+		t.Fatal(err)
+	}
+	if err := grp.Set("second", 2); err != nil {
+		t.Fatal(err)
+	}
+	if z.Which() != air.Z_Which_grp || z.Grp().First() != 1 || z.Grp().Second() != 2 {
+		t.Errorf("after setting grp, z = %v", z)
+	}
+	if _, err := s.Init("grp"); err != nil {
+		t.Fatal(err)
+	}
+	if z.Grp().First() != 0 || z.Grp().Second() != 0 {
+		t.Errorf("Init(\"grp\") did not clear the group: %v", z)
+	}
+
+	date, err := s.Init("zdate")
+	if err != nil {
+		t.Fatal("Init(\"zdate\"):", err)
+	}
+	if err := date.Set("year", -44); err != nil {
+		t.Fatal(err)
+	}
+	if err := date.Set("month", 3); err != nil {
+		t.Fatal(err)
+	}
+	zd, err := z.Zdate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if zd.Year() != -44 || zd.Month() != 3 {
+		t.Errorf("zdate = %v; want (year = -44, month = 3)", zd)
+	}
+	if _, err := s.Init("f64"); err == nil {
+		t.Error("Init(\"f64\") succeeded")
+	}
+}
+
+func TestLists(t *testing.T) {
+	seg := newSegment(t)
+	s, err := dynamic.NewRootStruct(seg, air.Z_TypeID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	z, err := air.ReadRootZ(seg.Message())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := s.NewList("i32vec", 3)
+	if err != nil {
+		t.Fatal("NewList(\"i32vec\"):", err)
+	}
+	for i, v := range []any{1, int32(-2), uint16(3)} {
+		if err := l.Set(i, v); err != nil {
+			t.Fatalf("i32vec.Set(%d, %#v): %v", i, v, err)
+		}
+	}
+	iv, err := z.I32vec()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if iv.Len() != 3 || iv.At(0) != 1 || iv.At(1) != -2 || iv.At(2) != 3 {
+		t.Errorf("i32vec = %v; want [1, -2, 3]", iv)
+	}
+	if err := l.Set(0, int64(1<<40)); err == nil {
+		t.Error("i32vec.Set(0, 1<<40) succeeded")
+	}
+
+	l, err = s.NewList("zvecvec", 1)
+	if err != nil {
+		t.Fatal("NewList(\"zvecvec\"):", err)
+	}
+	// Build the inner list in another message to check that Set
+	// copies it.
+	other, err := dynamic.NewRootStruct(newSegment(t), air.Z_TypeID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zl, err := other.NewList("zvec", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, text := range []string{"a", "b"} {
+		e, err := zl.At(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := e.(dynamic.Struct).Set("text", text); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := l.Set(0, zl); err != nil {
+		t.Fatal("zvecvec.Set:", err)
+	}
+	zvv, err := z.Zvecvec()
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := zvv.At(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Segment().Message() != seg.Message() {
+		t.Error("zvecvec element was not copied into the message")
+	}
+	zv := air.Z_List(p.List())
+	if zv.Len() != 2 {
+		t.Fatalf("len(zvecvec[0]) = %d; want 2", zv.Len())
+	}
+	for i, want := range []string{"a", "b"} {
+		if got, _ := zv.At(i).Text(); got != want {
+			t.Errorf("zvecvec[0][%d].text = %q; want %q", i, got, want)
+		}
+	}
+
+	v, err := s.Get("zvecvec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := v.(dynamic.List).At(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err = e.(dynamic.List).At(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := e.(dynamic.Struct).Get("text"); got != "b" || err != nil {
+		t.Errorf("zvecvec[0][1].text = %#v, %v; want \"b\", <nil>", got, err)
+	}
+
+	l, err = s.NewList("textvec", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Set(0, "x"); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Set(1, []byte("y")); err != nil {
+		t.Fatal(err)
+	}
+	tv, err := z.Textvec()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a, _ := tv.At(0); a != "x" {
+		t.Errorf("textvec[0] = %q; want \"x\"", a)
+	}
+	if b, _ := tv.At(1); b != "y" {
+		t.Errorf("textvec[1] = %q; want \"y\"", b)
+	}
+	if _, err := s.NewList("f64", 1); err == nil {
+		t.Error("NewList(\"f64\") succeeded")
+	}
+}
+
+func TestDefaults(t *testing.T) {
+	seg := newSegment(t)
+	s, err := dynamic.NewRootStruct(seg, air.Defaults_TypeID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		name string
+		want any
+	}{
+		{"text", "foo"},
+		{"float", float32(3.14)},
+		{"int", int32(-123)},
+		{"uint", uint32(42)},
+	} {
+		if got, err := s.Get(test.name); err != nil || got != test.want {
+			t.Errorf("Get(%q) = %#v, %v; want %#v, <nil>", test.name, got, err, test.want)
+		}
+	}
+	if got, err := s.Get("data"); err != nil || !bytes.Equal(got.([]byte), []byte("bar")) {
+		t.Errorf("Get(\"data\") = %#v, %v; want \"bar\", <nil>", got, err)
+	}
+
+	if err := s.Set("int", 7); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Set("uint", 42); err != nil {
+		t.Fatal(err)
+	}
+	d, err := air.ReadRootDefaults(seg.Message())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Int() != 7 || d.Uint() != 42 {
+		t.Errorf("int, uint = %d, %d; want 7, 42", d.Int(), d.Uint())
+	}
+	if raw := capnp.Struct(d).Uint32(8); raw != 0 {
+		t.Errorf("uint stored as %d; want 0 (XORed with its default)", raw)
+	}
+}
+
+func TestLoader(t *testing.T) {
+	reg := new(schemas.Registry)
+	err := reg.Register(&schemas.Schema{
+		Bytes: schemas.Find(air.Zdate_TypeID),
+		Nodes: []uint64{air.Zdate_TypeID},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ld := dynamic.NewLoader(reg)
+	seg := newSegment(t)
+	s, err := ld.NewStruct(seg, air.Zdate_TypeID)
+	if err != nil {
+		t.Fatal("NewStruct:", err)
+	}
+	if err := s.Set("day", 31); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := s.Get("day"); got != uint8(31) || err != nil {
+		t.Errorf("Get(\"day\") = %#v, %v; want 31, <nil>", got, err)
+	}
+	if _, err := ld.NewStruct(seg, 0x8123456789abcdef); err == nil {
+		t.Error("NewStruct with an unknown type succeeded")
+	}
+	if _, err := ld.NewStruct(seg, air.Airport_TypeID); err == nil {
+		t.Error("NewStruct with an enum type succeeded")
+	}
+	if _, err := s.Which(); err == nil {
+		t.Error("Which() on a struct without a union succeeded")
+	}
+}
@@ -0,0 +1,150 @@
+package dynamic
+
+import (
+	"fmt"
+
+	"capnproto.org/go/capnp/v3"
+	"capnproto.org/go/capnp/v3/std/capnp/schema"
+)
+
+// A List is a Cap'n Proto list paired with its element type.
+type List struct {
+	l    capnp.List
+	elem schema.Type
+	ld   *Loader
+}
+
+// List returns the underlying list.
+func (l List) List() capnp.List {
+	return l.l
+}
+
+// ToPtr converts the list to a generic pointer.
+func (l List) ToPtr() capnp.Ptr {
+	return l.l.ToPtr()
+}
+
+// IsValid reports whether the list is non-null.
+func (l List) IsValid() bool {
+	return l.l.IsValid()
+}
+
+// ElemType returns the type of the list's elements.
+func (l List) ElemType() schema.Type {
+	return l.elem
+}
+
+// Len returns the number of elements in the list.
+func (l List) Len() int {
+	return l.l.Len()
+}
+
+// At returns the i'th element of the list.  The element has the same
+// Go type that Struct.Get would return for a field of the list's
+// element type.
+func (l List) At(i int) (any, error) {
+	if i < 0 || i >= l.Len() {
+		return nil, fmt.Errorf("dynamic: list index %d out of range [0, %d)", i, l.Len())
+	}
+	switch l.elem.Which() {
+	case schema.Type_Which_void:
+		return struct{}{}, nil
+	case schema.Type_Which_text:
+		return capnp.TextList(l.l).At(i)
+	case schema.Type_Which_data:
+		return capnp.DataList(l.l).At(i)
+	case schema.Type_Which_structType:
+		n, err := l.ld.nodeOf(l.elem.StructType().TypeId(), schema.Node_Which_structNode)
+		if err != nil {
+			return nil, err
+		}
+		return Struct{s: l.l.Struct(i), node: n, ld: l.ld}, nil
+	case schema.Type_Which_list, schema.Type_Which_interface, schema.Type_Which_anyPointer:
+		p, err := capnp.PointerList(l.l).At(i)
+		if err != nil {
+			return nil, err
+		}
+		return l.ld.fromPtr(l.elem, p)
+	default:
+		return l.ld.fromBits(l.elem, l.readBits(i))
+	}
+}
+
+// Set sets the i'th element of the list.  Struct elements are copied
+// into the list.
+func (l List) Set(i int, v any) error {
+	if i < 0 || i >= l.Len() {
+		return fmt.Errorf("dynamic: list index %d out of range [0, %d)", i, l.Len())
+	}
+	switch l.elem.Which() {
+	case schema.Type_Which_void:
+		_, err := l.ld.toBits(l.elem, v)
+		if err != nil {
+			return fmt.Errorf("dynamic: set list element %d: %w", i, err)
+		}
+		return nil
+	case schema.Type_Which_structType:
+		var src capnp.Struct
+		switch v := v.(type) {
+		case Struct:
+			if id := l.elem.StructType().TypeId(); v.TypeID() != id || v.IsGroup() {
+				return fmt.Errorf("dynamic: set list element %d: can't use %s as struct @%#x", i, v.name(), id)
+			}
+			src = v.s
+		case capnp.Struct:
+			src = v
+		default:
+			return fmt.Errorf("dynamic: set list element %d: can't use %T as struct", i, v)
+		}
+		return l.l.SetStruct(i, src)
+	case schema.Type_Which_text, schema.Type_Which_data, schema.Type_Which_list,
+		schema.Type_Which_interface, schema.Type_Which_anyPointer:
+		p, err := l.ld.toPtr(l.elem, v, l.l.Segment())
+		if err != nil {
+			return fmt.Errorf("dynamic: set list element %d: %w", i, err)
+		}
+		return capnp.PointerList(l.l).Set(i, p)
+	default:
+		bits, err := l.ld.toBits(l.elem, v)
+		if err != nil {
+			return fmt.Errorf("dynamic: set list element %d: %w", i, err)
+		}
+		l.writeBits(i, bits)
+		return nil
+	}
+}
+
+func (l List) readBits(i int) uint64 {
+	switch l.elem.Which() {
+	case schema.Type_Which_bool:
+		if capnp.BitList(l.l).At(i) {
+			return 1
+		}
+		return 0
+	case schema.Type_Which_int8, schema.Type_Which_uint8:
+		return uint64(capnp.UInt8List(l.l).At(i))
+	case schema.Type_Which_int16, schema.Type_Which_uint16, schema.Type_Which_enum:
+		return uint64(capnp.UInt16List(l.l).At(i))
+	case schema.Type_Which_int32, schema.Type_Which_uint32, schema.Type_Which_float32:
+		return uint64(capnp.UInt32List(l.l).At(i))
+	case schema.Type_Which_int64, schema.Type_Which_uint64, schema.Type_Which_float64:
+		return capnp.UInt64List(l.l).At(i)
+	default:
+		return 0
+	}
+}
+
+func (l List) writeBits(i int, bits uint64) {
+	switch l.elem.Which() {
+	case schema.Type_Which_bool:
+		capnp.BitList(l.l).Set(i, bits != 0)
+	case schema.Type_Which_int8, schema.Type_Which_uint8:
+		capnp.UInt8List(l.l).Set(i, uint8(bits))
+	case schema.Type_Which_int16, schema.Type_Which_uint16, schema.Type_Which_enum:
+		capnp.UInt16List(l.l).Set(i, uint16(bits))
+	case schema.Type_Which_int32, schema.Type_Which_uint32, schema.Type_Which_float32:
+		capnp.UInt32List(l.l).Set(i, uint32(bits))
+	case schema.Type_Which_int64, schema.Type_Which_uint64, schema.Type_Which_float64:
+		capnp.UInt64List(l.l).Set(i, bits)
+	}
+}
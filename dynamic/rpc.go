@@ -0,0 +1,319 @@
+package dynamic
+
+import (
+	"context"
+	"fmt"
+
+	"capnproto.org/go/capnp/v3"
+	"capnproto.org/go/capnp/v3/server"
+	"capnproto.org/go/capnp/v3/std/capnp/schema"
+)
+
+// methodInfo describes a method of an interface or of one of its
+// superclasses.
+type methodInfo struct {
+	method  capnp.Method
+	params  schema.Node
+	results schema.Node
+}
+
+// methods returns the methods of an interface and its superclasses.
+// Methods of an interface come before those of its superclasses, so
+// the first method with a given name is the one that a client of the
+// interface would call.
+func (ld *Loader) methods(interfaceID uint64) ([]methodInfo, error) {
+	var infos []methodInfo
+	seen := make(map[uint64]bool)
+	var add func(id uint64) error
+	add = func(id uint64) error {
+		if seen[id] {
+			return nil
+		}
+		seen[id] = true
+		n, err := ld.nodeOf(id, schema.Node_Which_interface)
+		if err != nil {
+			return err
+		}
+		ifaceName, err := n.DisplayName()
+		if err != nil {
+			return err
+		}
+		ms, err := n.Interface().Methods()
+		if err != nil {
+			return err
+		}
+		for i := 0; i < ms.Len(); i++ {
+			m := ms.At(i)
+			name, err := m.Name()
+			if err != nil {
+				return err
+			}
+			params, err := ld.nodeOf(m.ParamStructType(), schema.Node_Which_structNode)
+			if err != nil {
+				return err
+			}
+			results, err := ld.nodeOf(m.ResultStructType(), schema.Node_Which_structNode)
+			if err != nil {
+				return err
+			}
+			infos = append(infos, methodInfo{
+				method: capnp.Method{
+					InterfaceID:   id,
+					MethodID:      uint16(i),
+					InterfaceName: ifaceName,
+					MethodName:    name,
+				},
+				params:  params,
+				results: results,
+			})
+		}
+		supers, err := n.Interface().Superclasses()
+		if err != nil {
+			return err
+		}
+		for i := 0; i < supers.Len(); i++ {
+			if err := add(supers.At(i).Id()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := add(interfaceID); err != nil {
+		return nil, err
+	}
+	return infos, nil
+}
+
+// A Client calls the methods of an interface by name.
+type Client struct {
+	c       capnp.Client
+	id      uint64
+	methods []methodInfo
+	ld      *Loader
+}
+
+// NewClient returns a Client that calls c as the given interface.
+// The returned Client borrows c's reference.
+func (ld *Loader) NewClient(c capnp.Client, interfaceID uint64) (Client, error) {
+	methods, err := ld.methods(interfaceID)
+	if err != nil {
+		return Client{}, err
+	}
+	return Client{c: c, id: interfaceID, methods: methods, ld: ld}, nil
+}
+
+// NewClient returns a Client that calls c as the given interface,
+// using the default registry.  The returned Client borrows c's
+// reference.
+func NewClient(c capnp.Client, interfaceID uint64) (Client, error) {
+	return defaultLoader.NewClient(c, interfaceID)
+}
+
+// Client returns the underlying client.
+func (c Client) Client() capnp.Client {
+	return c.c
+}
+
+// InterfaceID returns the ID of the interface that c calls.
+func (c Client) InterfaceID() uint64 {
+	return c.id
+}
+
+// Call starts a call to the named method, which may be inherited from
+// a superclass.  params is called with the method's parameter struct
+// to fill it in; it may be nil.  Errors, including an unknown method
+// name, are reported by the returned Future.
+func (c Client) Call(ctx context.Context, method string, params func(Struct) error) (Future, capnp.ReleaseFunc) {
+	for i := range c.methods {
+		m := &c.methods[i]
+		if m.method.MethodName != method {
+			continue
+		}
+		s := capnp.Send{Method: m.method}
+		if params != nil {
+			s.ArgsSize = structSize(m.params)
+			s.PlaceArgs = func(p capnp.Struct) error {
+				return params(Struct{s: p, node: m.params, ld: c.ld})
+			}
+		}
+		ans, release := c.c.SendCall(ctx, s)
+		return Future{Future: ans.Future(), node: m.results, ld: c.ld}, release
+	}
+	err := fmt.Errorf("dynamic: interface @%#x has no method %q", c.id, method)
+	ans := capnp.ErrorAnswer(capnp.Method{InterfaceID: c.id, MethodName: method}, err)
+	return Future{Future: ans.Future()}, func() {}
+}
+
+// A Future is the pending result of a call, or a struct within it.
+type Future struct {
+	*capnp.Future
+	node schema.Node
+	ld   *Loader
+}
+
+// Struct waits until the call returns and returns the struct that the
+// future represents.
+func (f Future) Struct() (Struct, error) {
+	s, err := f.Future.Struct()
+	if err != nil {
+		return Struct{}, err
+	}
+	return Struct{s: s, node: f.node, ld: f.ld}, nil
+}
+
+// pointerField finds the named pointer field of f's struct type.
+func (f Future) pointerField(name string) (schema.Field, schema.Type, error) {
+	if !f.node.IsValid() {
+		// f already carries an error.
+		_, err := f.Future.Struct()
+		return schema.Field{}, schema.Type{}, err
+	}
+	field, err := Struct{node: f.node}.field(name)
+	if err != nil {
+		return schema.Field{}, schema.Type{}, err
+	}
+	if field.Which() != schema.Field_Which_slot {
+		return schema.Field{}, schema.Type{}, fmt.Errorf("dynamic: can't pipeline on group %s.%s", shortDisplayName(f.node), name)
+	}
+	typ, err := field.Slot().Type()
+	if err != nil {
+		return schema.Field{}, schema.Type{}, err
+	}
+	if field.DiscriminantValue() != schema.Field_noDiscriminant {
+		return schema.Field{}, schema.Type{}, fmt.Errorf("dynamic: can't pipeline on union member %s.%s", shortDisplayName(f.node), name)
+	}
+	return field, typ, nil
+}
+
+// Field returns a future for the named struct field, which can be used
+// for promise pipelining.
+func (f Future) Field(name string) Future {
+	field, typ, err := f.pointerField(name)
+	if err == nil && typ.Which() != schema.Type_Which_structType {
+		err = fmt.Errorf("dynamic: %s.%s is not a struct", shortDisplayName(f.node), name)
+	}
+	var n schema.Node
+	if err == nil {
+		n, err = f.ld.nodeOf(typ.StructType().TypeId(), schema.Node_Which_structNode)
+	}
+	if err != nil {
+		return Future{Future: capnp.ErrorAnswer(capnp.Method{}, err).Future()}
+	}
+	return Future{
+		Future: f.Future.Field(uint16(field.Slot().Offset()), nil),
+		node:   n,
+		ld:     f.ld,
+	}
+}
+
+// Client returns a promise for the named interface field, which can be
+// used for promise pipelining.  The returned client borrows f's
+// reference.
+func (f Future) Client(name string) capnp.Client {
+	field, typ, err := f.pointerField(name)
+	if err == nil && typ.Which() != schema.Type_Which_interface {
+		err = fmt.Errorf("dynamic: %s.%s is not an interface", shortDisplayName(f.node), name)
+	}
+	if err != nil {
+		return capnp.ErrorClient(err)
+	}
+	return f.Future.Field(uint16(field.Slot().Offset()), nil).Client()
+}
+
+// A Call is a call received by a server built with Methods or
+// MethodsFunc.  See server.Call for documentation.
+type Call struct {
+	*server.Call
+	info *methodInfo
+	ld   *Loader
+}
+
+// Method returns the method that is being called.
+func (c Call) Method() capnp.Method {
+	return c.info.method
+}
+
+// Args returns the call's arguments.
+func (c Call) Args() Struct {
+	return Struct{s: c.Call.Args(), node: c.info.params, ld: c.ld}
+}
+
+// AllocResults allocates the results struct.
+func (c Call) AllocResults() (Struct, error) {
+	r, err := c.Call.AllocResults(structSize(c.info.results))
+	if err != nil {
+		return Struct{}, err
+	}
+	return Struct{s: r, node: c.info.results, ld: c.ld}, nil
+}
+
+// A MethodFunc implements a method of an interface whose type is only
+// known at runtime.
+type MethodFunc func(ctx context.Context, call Call) error
+
+// Methods returns server methods for the given interface, suitable for
+// server.New.  Calls are dispatched to the entry in impls with the
+// method's name.  Methods inherited from superclasses are included, and
+// methods with no entry in impls are left unimplemented.  It is an
+// error for impls to have an entry that is not a method of the
+// interface.
+func (ld *Loader) Methods(interfaceID uint64, impls map[string]MethodFunc) ([]server.Method, error) {
+	infos, err := ld.methods(interfaceID)
+	if err != nil {
+		return nil, err
+	}
+	found := make(map[string]bool, len(impls))
+	methods := make([]server.Method, 0, len(impls))
+	for i := range infos {
+		info := &infos[i]
+		name := info.method.MethodName
+		impl := impls[name]
+		if impl == nil || found[name] {
+			continue
+		}
+		found[name] = true
+		methods = append(methods, ld.serverMethod(info, impl))
+	}
+	for name := range impls {
+		if !found[name] {
+			return nil, fmt.Errorf("dynamic: interface @%#x has no method %q", interfaceID, name)
+		}
+	}
+	return methods, nil
+}
+
+// MethodsFunc returns server methods for the given interface and its
+// superclasses, suitable for server.New.  Every call is dispatched to
+// impl, which can use Call.Method to tell the methods apart.
+func (ld *Loader) MethodsFunc(interfaceID uint64, impl MethodFunc) ([]server.Method, error) {
+	infos, err := ld.methods(interfaceID)
+	if err != nil {
+		return nil, err
+	}
+	methods := make([]server.Method, 0, len(infos))
+	for i := range infos {
+		methods = append(methods, ld.serverMethod(&infos[i], impl))
+	}
+	return methods, nil
+}
+
+func (ld *Loader) serverMethod(info *methodInfo, impl MethodFunc) server.Method {
+	return server.Method{
+		Method: info.method,
+		Impl: func(ctx context.Context, call *server.Call) error {
+			return impl(ctx, Call{Call: call, info: info, ld: ld})
+		},
+	}
+}
+
+// Methods returns server methods for the given interface, using the
+// default registry.  See Loader.Methods.
+func Methods(interfaceID uint64, impls map[string]MethodFunc) ([]server.Method, error) {
+	return defaultLoader.Methods(interfaceID, impls)
+}
+
+// MethodsFunc returns server methods for the given interface, using the
+// default registry.  See Loader.MethodsFunc.
+func MethodsFunc(interfaceID uint64, impl MethodFunc) ([]server.Method, error) {
+	return defaultLoader.MethodsFunc(interfaceID, impl)
+}
@@ -0,0 +1,194 @@
+package dynamic_test
+
+import (
+	"context"
+	"testing"
+
+	"capnproto.org/go/capnp/v3"
+	"capnproto.org/go/capnp/v3/dynamic"
+	air "capnproto.org/go/capnp/v3/internal/aircraftlib"
+	"capnproto.org/go/capnp/v3/server"
+)
+
+type echoServer struct{}
+
+func (echoServer) Echo(ctx context.Context, call air.Echo_echo) error {
+	in, err := call.Args().In()
+	if err != nil {
+		return err
+	}
+	res, err := call.AllocResults()
+	if err != nil {
+		return err
+	}
+	return res.SetOut(in + in)
+}
+
+func TestClientCall(t *testing.T) {
+	ctx := context.Background()
+	echo := air.Echo_ServerToClient(echoServer{})
+	defer echo.Release()
+
+	c, err := dynamic.NewClient(capnp.Client(echo), air.Echo_TypeID)
+	if err != nil {
+		t.Fatal("NewClient:", err)
+	}
+	f, release := c.Call(ctx, "echo", func(p dynamic.Struct) error {
+		return p.Set("in", "foo")
+	})
+	defer release()
+	res, err := f.Struct()
+	if err != nil {
+		t.Fatal("echo:", err)
+	}
+	if out, err := res.Get("out"); out != "foofoo" || err != nil {
+		t.Errorf("echo(\"foo\").out = %#v, %v; want \"foofoo\", <nil>", out, err)
+	}
+
+	f, release = c.Call(ctx, "shout", nil)
+	defer release()
+	if _, err := f.Struct(); err == nil {
+		t.Error("call to unknown method succeeded")
+	}
+}
+
+func TestServerMethods(t *testing.T) {
+	ctx := context.Background()
+	methods, err := dynamic.Methods(air.Echo_TypeID, map[string]dynamic.MethodFunc{
+		"echo": func(ctx context.Context, call dynamic.Call) error {
+			if m := call.Method(); m.InterfaceID != air.Echo_TypeID || m.MethodID != 0 {
+				t.Errorf("call.Method() = %v; want echo", &m)
+			}
+			in, err := call.Args().Get("in")
+			if err != nil {
+				return err
+			}
+			res, err := call.AllocResults()
+			if err != nil {
+				return err
+			}
+			return res.Set("out", "<"+in.(string)+">")
+		},
+	})
+	if err != nil {
+		t.Fatal("Methods:", err)
+	}
+	echo := air.Echo(capnp.NewClient(server.New(methods, nil, nil)))
+	defer echo.Release()
+
+	f, release := echo.Echo(ctx, func(p air.Echo_echo_Params) error {
+		return p.SetIn("bar")
+	})
+	defer release()
+	res, err := f.Struct()
+	if err != nil {
+		t.Fatal("echo:", err)
+	}
+	if out, err := res.Out(); out != "<bar>" || err != nil {
+		t.Errorf("echo(\"bar\").out = %q, %v; want \"<bar>\", <nil>", out, err)
+	}
+
+	_, err = dynamic.Methods(air.Echo_TypeID, map[string]dynamic.MethodFunc{
+		"shout": func(context.Context, dynamic.Call) error { return nil },
+	})
+	if err == nil {
+		t.Error("Methods with an unknown method name succeeded")
+	}
+
+	// Methods with no entry are unimplemented.
+	methods, err = dynamic.Methods(air.Echo_TypeID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	echo2 := air.Echo(capnp.NewClient(server.New(methods, nil, nil)))
+	defer echo2.Release()
+	f, release = echo2.Echo(ctx, nil)
+	defer release()
+	if _, err := f.Struct(); err == nil {
+		t.Error("call to unimplemented method succeeded")
+	}
+}
+
+func TestInheritanceAndPipelining(t *testing.T) {
+	ctx := context.Background()
+	var (
+		n       uint32
+		methods []server.Method
+		err     error
+	)
+	methods, err = dynamic.MethodsFunc(air.Pipeliner_TypeID, func(ctx context.Context, call dynamic.Call) error {
+		res, err := call.AllocResults()
+		if err != nil {
+			return err
+		}
+		switch m := call.Method(); m.MethodName {
+		case "getNumber":
+			if m.InterfaceID != air.CallSequence_TypeID {
+				t.Errorf("getNumber InterfaceID = %#x; want %#x", m.InterfaceID, uint64(air.CallSequence_TypeID))
+			}
+			n++
+			return res.Set("n", n)
+		case "newPipeliner":
+			return res.Set("pipeliner", capnp.NewClient(server.New(methods, nil, nil)))
+		default:
+			t.Errorf("unexpected call to %v", &m)
+			return nil
+		}
+	})
+	if err != nil {
+		t.Fatal("MethodsFunc:", err)
+	}
+	if len(methods) != 2 {
+		t.Fatalf("len(methods) = %d; want 2", len(methods))
+	}
+	root := capnp.NewClient(server.New(methods, nil, nil))
+	defer root.Release()
+
+	c, err := dynamic.NewClient(root, air.Pipeliner_TypeID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, release := c.Call(ctx, "newPipeliner", nil)
+	defer release()
+
+	// Call the inherited method on the promised capability.
+	p, err := dynamic.NewClient(f.Client("pipeliner"), air.Pipeliner_TypeID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for want := uint32(1); want <= 2; want++ {
+		nf, release := p.Call(ctx, "getNumber", nil)
+		res, err := nf.Struct()
+		if err != nil {
+			t.Fatal("getNumber:", err)
+		}
+		if got, err := res.Get("n"); got != want || err != nil {
+			t.Errorf("getNumber() = %#v, %v; want %d, <nil>", got, err, want)
+		}
+		release()
+	}
+
+	// The generated client can call the dynamic server too.
+	gf, release := air.CallSequence(root).GetNumber(ctx, nil)
+	defer release()
+	res, err := gf.Struct()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.N() != 3 {
+		t.Errorf("getNumber() = %d; want 3", res.N())
+	}
+
+	bad, err := dynamic.NewClient(f.Client("extra"), air.CallSequence_TypeID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bf, release := bad.Call(ctx, "getNumber", nil)
+	defer release()
+	if _, err := bf.Struct(); err == nil {
+		t.Error("pipelining on a non-interface field succeeded")
+	}
+	if _, err := f.Field("pipeliner").Struct(); err == nil {
+		t.Error("Field on an interface field succeeded")
+	}
+}
@@ -0,0 +1,388 @@
+package dynamic
+
+import (
+	"fmt"
+
+	"capnproto.org/go/capnp/v3"
+	"capnproto.org/go/capnp/v3/std/capnp/schema"
+)
+
+// A Struct is a Cap'n Proto struct or group paired with its schema
+// node.  The zero value is a null struct with no type.
+type Struct struct {
+	s    capnp.Struct
+	node schema.Node
+	ld   *Loader
+}
+
+// Struct returns the underlying struct.  For a group, this is the
+// struct that contains it.
+func (s Struct) Struct() capnp.Struct {
+	return s.s
+}
+
+// ToPtr converts the struct to a generic pointer.
+func (s Struct) ToPtr() capnp.Ptr {
+	return s.s.ToPtr()
+}
+
+// IsValid reports whether the struct is non-null.
+func (s Struct) IsValid() bool {
+	return s.s.IsValid()
+}
+
+// Node returns the struct's schema node.
+func (s Struct) Node() schema.Node {
+	return s.node
+}
+
+// TypeID returns the ID of the struct's type.
+func (s Struct) TypeID() uint64 {
+	return s.node.Id()
+}
+
+// IsGroup reports whether s is a group within another struct.
+func (s Struct) IsGroup() bool {
+	return s.node.StructNode().IsGroup()
+}
+
+// Fields returns the struct's fields, in ordinal order.
+func (s Struct) Fields() (schema.Field_List, error) {
+	return s.node.StructNode().Fields()
+}
+
+func (s Struct) name() string {
+	if !s.node.IsValid() {
+		return "untyped struct"
+	}
+	return shortDisplayName(s.node)
+}
+
+func (s Struct) field(name string) (schema.Field, error) {
+	fields, err := s.Fields()
+	if err != nil {
+		return schema.Field{}, err
+	}
+	for i := 0; i < fields.Len(); i++ {
+		f := fields.At(i)
+		if n, _ := f.Name(); n == name {
+			return f, nil
+		}
+	}
+	return schema.Field{}, fmt.Errorf("dynamic: %s has no field %q", s.name(), name)
+}
+
+func (s Struct) discOffset() capnp.DataOffset {
+	return capnp.DataOffset(s.node.StructNode().DiscriminantOffset() * 2)
+}
+
+// isActive reports whether f is not a union member, or is the active
+// member of its union.
+func (s Struct) isActive(f schema.Field) bool {
+	dv := f.DiscriminantValue()
+	return dv == schema.Field_noDiscriminant || s.s.Uint16(s.discOffset()) == dv
+}
+
+func (s Struct) setDiscriminant(f schema.Field) {
+	if dv := f.DiscriminantValue(); dv != schema.Field_noDiscriminant {
+		s.s.SetUint16(s.discOffset(), dv)
+	}
+}
+
+// Which returns the name of the active member of the struct's unnamed
+// union.  It returns the empty string if the discriminant does not
+// match any member in the schema, as happens when reading a message
+// written with a newer version of the schema.
+func (s Struct) Which() (string, error) {
+	if s.node.StructNode().DiscriminantCount() == 0 {
+		return "", fmt.Errorf("dynamic: %s has no union", s.name())
+	}
+	fields, err := s.Fields()
+	if err != nil {
+		return "", err
+	}
+	disc := s.s.Uint16(s.discOffset())
+	for i := 0; i < fields.Len(); i++ {
+		if f := fields.At(i); f.DiscriminantValue() == disc {
+			return f.Name()
+		}
+	}
+	return "", nil
+}
+
+// Has reports whether the named field is set.  Pointer fields are set
+// if they are non-null, union members are set if they are active, and
+// all other fields are always set.
+func (s Struct) Has(name string) (bool, error) {
+	f, err := s.field(name)
+	if err != nil {
+		return false, err
+	}
+	if !s.isActive(f) {
+		return false, nil
+	}
+	if f.Which() == schema.Field_Which_slot {
+		typ, err := f.Slot().Type()
+		if err != nil {
+			return false, err
+		}
+		if isPointer(typ) {
+			return s.s.HasPtr(uint16(f.Slot().Offset())), nil
+		}
+	}
+	return true, nil
+}
+
+// Get returns the value of the named field.  See the package
+// documentation for the Go types that are returned.  It is an error to
+// get a union member that is not active.
+//
+// A null pointer field with a default value returns the default.
+// Structs and lists returned this way are copies, so changes to them
+// are not reflected in s.
+func (s Struct) Get(name string) (any, error) {
+	f, err := s.field(name)
+	if err != nil {
+		return nil, err
+	}
+	if !s.isActive(f) {
+		return nil, fmt.Errorf("dynamic: get %s.%s: not the active union member", s.name(), name)
+	}
+	if f.Which() == schema.Field_Which_group {
+		return s.group(f)
+	}
+	slot := f.Slot()
+	typ, err := slot.Type()
+	if err != nil {
+		return nil, err
+	}
+	def, err := slot.DefaultValue()
+	if err != nil {
+		return nil, err
+	}
+	if !isPointer(typ) {
+		bits := s.readBits(bitSize(typ), slot.Offset()) ^ defaultBits(typ, def)
+		return s.ld.fromBits(typ, bits)
+	}
+	p, err := s.s.Ptr(uint16(slot.Offset()))
+	if err != nil {
+		return nil, err
+	}
+	if !p.IsValid() && def.IsValid() && capnp.Struct(def).HasPtr(0) {
+		if p, err = copyDefault(def); err != nil {
+			return nil, fmt.Errorf("dynamic: get %s.%s: %w", s.name(), name, err)
+		}
+	}
+	return s.ld.fromPtr(typ, p)
+}
+
+// copyDefault copies a field's default pointer into a new message, so
+// that the schema is never modified through the returned pointer.
+func copyDefault(def schema.Value) (capnp.Ptr, error) {
+	dp, err := capnp.Struct(def).Ptr(0)
+	if err != nil {
+		return capnp.Ptr{}, err
+	}
+	msg, _, err := capnp.NewMessage(capnp.SingleSegment(nil))
+	if err != nil {
+		return capnp.Ptr{}, err
+	}
+	if err := msg.SetRoot(dp); err != nil {
+		return capnp.Ptr{}, err
+	}
+	return msg.Root()
+}
+
+func (s Struct) group(f schema.Field) (Struct, error) {
+	n, err := s.ld.nodeOf(f.Group().TypeId(), schema.Node_Which_structNode)
+	if err != nil {
+		return Struct{}, err
+	}
+	return Struct{s: s.s, node: n, ld: s.ld}, nil
+}
+
+// Set sets the value of the named field.  If the field is a union
+// member, Set also makes it the active member.  Groups can't be set;
+// use Init to clear a group and then set its fields.
+//
+// Setting a pointer field to a struct or list in another message copies
+// it into s's message.
+func (s Struct) Set(name string, v any) error {
+	f, err := s.field(name)
+	if err != nil {
+		return err
+	}
+	if f.Which() == schema.Field_Which_group {
+		return fmt.Errorf("dynamic: set %s.%s: field is a group", s.name(), name)
+	}
+	slot := f.Slot()
+	typ, err := slot.Type()
+	if err != nil {
+		return err
+	}
+	if isPointer(typ) {
+		p, err := s.ld.toPtr(typ, v, s.s.Segment())
+		if err != nil {
+			return fmt.Errorf("dynamic: set %s.%s: %w", s.name(), name, err)
+		}
+		s.setDiscriminant(f)
+		return s.s.SetPtr(uint16(slot.Offset()), p)
+	}
+	def, err := slot.DefaultValue()
+	if err != nil {
+		return err
+	}
+	bits, err := s.ld.toBits(typ, v)
+	if err != nil {
+		return fmt.Errorf("dynamic: set %s.%s: %w", s.name(), name, err)
+	}
+	s.setDiscriminant(f)
+	s.writeBits(bitSize(typ), slot.Offset(), bits^defaultBits(typ, def))
+	return nil
+}
+
+// Init sets the named struct field to a newly allocated struct and
+// returns it.  For a group, Init zeroes the group's fields and returns
+// the group.  If the field is a union member, Init also makes it the
+// active member.
+func (s Struct) Init(name string) (Struct, error) {
+	f, err := s.field(name)
+	if err != nil {
+		return Struct{}, err
+	}
+	if f.Which() == schema.Field_Which_group {
+		g, err := s.group(f)
+		if err != nil {
+			return Struct{}, err
+		}
+		s.setDiscriminant(f)
+		if err := g.clear(); err != nil {
+			return Struct{}, err
+		}
+		return g, nil
+	}
+	slot := f.Slot()
+	typ, err := slot.Type()
+	if err != nil {
+		return Struct{}, err
+	}
+	if typ.Which() != schema.Type_Which_structType {
+		return Struct{}, fmt.Errorf("dynamic: init %s.%s: field is a %v, not a struct", s.name(), name, typ.Which())
+	}
+	ns, err := s.ld.NewStruct(s.s.Segment(), typ.StructType().TypeId())
+	if err != nil {
+		return Struct{}, err
+	}
+	s.setDiscriminant(f)
+	if err := s.s.SetPtr(uint16(slot.Offset()), ns.ToPtr()); err != nil {
+		return Struct{}, err
+	}
+	return ns, nil
+}
+
+// NewList sets the named list field to a newly allocated list of n
+// elements and returns it.  If the field is a union member, NewList
+// also makes it the active member.
+func (s Struct) NewList(name string, n int32) (List, error) {
+	f, err := s.field(name)
+	if err != nil {
+		return List{}, err
+	}
+	var typ schema.Type
+	if f.Which() == schema.Field_Which_slot {
+		if typ, err = f.Slot().Type(); err != nil {
+			return List{}, err
+		}
+	}
+	if typ.Which() != schema.Type_Which_list {
+		return List{}, fmt.Errorf("dynamic: new list %s.%s: field is not a list", s.name(), name)
+	}
+	elem, err := typ.List().ElementType()
+	if err != nil {
+		return List{}, err
+	}
+	l, err := s.ld.newList(s.s.Segment(), elem, n)
+	if err != nil {
+		return List{}, err
+	}
+	s.setDiscriminant(f)
+	if err := s.s.SetPtr(uint16(f.Slot().Offset()), l.ToPtr()); err != nil {
+		return List{}, err
+	}
+	return List{l: l, elem: elem, ld: s.ld}, nil
+}
+
+// clear zeroes every field of a group, including its discriminant.
+func (s Struct) clear() error {
+	if s.node.StructNode().DiscriminantCount() > 0 {
+		s.s.SetUint16(s.discOffset(), 0)
+	}
+	fields, err := s.Fields()
+	if err != nil {
+		return err
+	}
+	for i := 0; i < fields.Len(); i++ {
+		f := fields.At(i)
+		if f.Which() == schema.Field_Which_group {
+			g, err := s.group(f)
+			if err != nil {
+				return err
+			}
+			if err := g.clear(); err != nil {
+				return err
+			}
+			continue
+		}
+		typ, err := f.Slot().Type()
+		if err != nil {
+			return err
+		}
+		if isPointer(typ) {
+			if err := s.s.SetPtr(uint16(f.Slot().Offset()), capnp.Ptr{}); err != nil {
+				return err
+			}
+			continue
+		}
+		s.writeBits(bitSize(typ), f.Slot().Offset(), 0)
+	}
+	return nil
+}
+
+// readBits reads a data field of the given size.  off is in units of
+// the field's size.
+func (s Struct) readBits(size, off uint32) uint64 {
+	switch size {
+	case 1:
+		if s.s.Bit(capnp.BitOffset(off)) {
+			return 1
+		}
+		return 0
+	case 8:
+		return uint64(s.s.Uint8(capnp.DataOffset(off)))
+	case 16:
+		return uint64(s.s.Uint16(capnp.DataOffset(off * 2)))
+	case 32:
+		return uint64(s.s.Uint32(capnp.DataOffset(off * 4)))
+	case 64:
+		return s.s.Uint64(capnp.DataOffset(off * 8))
+	default:
+		return 0
+	}
+}
+
+// writeBits writes a data field of the given size.  off is in units of
+// the field's size.
+func (s Struct) writeBits(size, off uint32, bits uint64) {
+	switch size {
+	case 1:
+		s.s.SetBit(capnp.BitOffset(off), bits != 0)
+	case 8:
+		s.s.SetUint8(capnp.DataOffset(off), uint8(bits))
+	case 16:
+		s.s.SetUint16(capnp.DataOffset(off*2), uint16(bits))
+	case 32:
+		s.s.SetUint32(capnp.DataOffset(off*4), uint32(bits))
+	case 64:
+		s.s.SetUint64(capnp.DataOffset(off*8), bits)
+	}
+}
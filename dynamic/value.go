@@ -0,0 +1,479 @@
+package dynamic
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+
+	"capnproto.org/go/capnp/v3"
+	"capnproto.org/go/capnp/v3/std/capnp/schema"
+)
+
+// An Enum is a value of an enum type.
+type Enum struct {
+	node  schema.Node
+	value uint16
+}
+
+// TypeID returns the ID of the enum's type.
+func (e Enum) TypeID() uint64 {
+	return e.node.Id()
+}
+
+// Value returns the enum's numeric value.
+func (e Enum) Value() uint16 {
+	return e.value
+}
+
+// Name returns the name of the enumerant, or the empty string if the
+// value is not in the schema.
+func (e Enum) Name() string {
+	enumerants, err := e.node.Enum().Enumerants()
+	if err != nil || int(e.value) >= enumerants.Len() {
+		return ""
+	}
+	name, _ := enumerants.At(int(e.value)).Name()
+	return name
+}
+
+// String returns the enumerant's name, or its number if the value is
+// not in the schema.
+func (e Enum) String() string {
+	if name := e.Name(); name != "" {
+		return name
+	}
+	return strconv.Itoa(int(e.value))
+}
+
+// isPointer reports whether values of typ are stored in the pointer
+// section.
+func isPointer(typ schema.Type) bool {
+	switch typ.Which() {
+	case schema.Type_Which_text, schema.Type_Which_data,
+		schema.Type_Which_list, schema.Type_Which_structType,
+		schema.Type_Which_interface, schema.Type_Which_anyPointer:
+		return true
+	default:
+		return false
+	}
+}
+
+// bitSize returns the size of a non-pointer type in bits.
+func bitSize(typ schema.Type) uint32 {
+	switch typ.Which() {
+	case schema.Type_Which_bool:
+		return 1
+	case schema.Type_Which_int8, schema.Type_Which_uint8:
+		return 8
+	case schema.Type_Which_int16, schema.Type_Which_uint16, schema.Type_Which_enum:
+		return 16
+	case schema.Type_Which_int32, schema.Type_Which_uint32, schema.Type_Which_float32:
+		return 32
+	case schema.Type_Which_int64, schema.Type_Which_uint64, schema.Type_Which_float64:
+		return 64
+	default:
+		return 0
+	}
+}
+
+// defaultBits returns the bits that a field's default value is XORed
+// with in the data section.
+func defaultBits(typ schema.Type, def schema.Value) uint64 {
+	if !def.IsValid() {
+		return 0
+	}
+	switch typ.Which() {
+	case schema.Type_Which_bool:
+		if def.Which() == schema.Value_Which_bool && def.Bool() {
+			return 1
+		}
+	case schema.Type_Which_int8:
+		if def.Which() == schema.Value_Which_int8 {
+			return uint64(uint8(def.Int8()))
+		}
+	case schema.Type_Which_int16:
+		if def.Which() == schema.Value_Which_int16 {
+			return uint64(uint16(def.Int16()))
+		}
+	case schema.Type_Which_int32:
+		if def.Which() == schema.Value_Which_int32 {
+			return uint64(uint32(def.Int32()))
+		}
+	case schema.Type_Which_int64:
+		if def.Which() == schema.Value_Which_int64 {
+			return uint64(def.Int64())
+		}
+	case schema.Type_Which_uint8:
+		if def.Which() == schema.Value_Which_uint8 {
+			return uint64(def.Uint8())
+		}
+	case schema.Type_Which_uint16:
+		if def.Which() == schema.Value_Which_uint16 {
+			return uint64(def.Uint16())
+		}
+	case schema.Type_Which_uint32:
+		if def.Which() == schema.Value_Which_uint32 {
+			return uint64(def.Uint32())
+		}
+	case schema.Type_Which_uint64:
+		if def.Which() == schema.Value_Which_uint64 {
+			return def.Uint64()
+		}
+	case schema.Type_Which_float32:
+		if def.Which() == schema.Value_Which_float32 {
+			return uint64(math.Float32bits(def.Float32()))
+		}
+	case schema.Type_Which_float64:
+		if def.Which() == schema.Value_Which_float64 {
+			return math.Float64bits(def.Float64())
+		}
+	case schema.Type_Which_enum:
+		if def.Which() == schema.Value_Which_enum {
+			return uint64(def.Enum())
+		}
+	}
+	return 0
+}
+
+// fromBits converts the raw bits of a non-pointer value to its Go
+// representation.
+func (ld *Loader) fromBits(typ schema.Type, bits uint64) (any, error) {
+	switch typ.Which() {
+	case schema.Type_Which_void:
+		return struct{}{}, nil
+	case schema.Type_Which_bool:
+		return bits != 0, nil
+	case schema.Type_Which_int8:
+		return int8(bits), nil
+	case schema.Type_Which_int16:
+		return int16(bits), nil
+	case schema.Type_Which_int32:
+		return int32(bits), nil
+	case schema.Type_Which_int64:
+		return int64(bits), nil
+	case schema.Type_Which_uint8:
+		return uint8(bits), nil
+	case schema.Type_Which_uint16:
+		return uint16(bits), nil
+	case schema.Type_Which_uint32:
+		return uint32(bits), nil
+	case schema.Type_Which_uint64:
+		return bits, nil
+	case schema.Type_Which_float32:
+		return math.Float32frombits(uint32(bits)), nil
+	case schema.Type_Which_float64:
+		return math.Float64frombits(bits), nil
+	case schema.Type_Which_enum:
+		n, err := ld.nodeOf(typ.Enum().TypeId(), schema.Node_Which_enum)
+		if err != nil {
+			return nil, err
+		}
+		return Enum{node: n, value: uint16(bits)}, nil
+	default:
+		return nil, fmt.Errorf("dynamic: %v is not a data type", typ.Which())
+	}
+}
+
+// toBits converts v to the raw bits of a non-pointer value of type typ.
+func (ld *Loader) toBits(typ schema.Type, v any) (uint64, error) {
+	switch typ.Which() {
+	case schema.Type_Which_void:
+		if v != nil && v != (struct{}{}) {
+			return 0, fmt.Errorf("can't use %T as Void", v)
+		}
+		return 0, nil
+	case schema.Type_Which_bool:
+		b, ok := v.(bool)
+		if !ok {
+			return 0, fmt.Errorf("can't use %T as Bool", v)
+		}
+		if b {
+			return 1, nil
+		}
+		return 0, nil
+	case schema.Type_Which_int8, schema.Type_Which_int16,
+		schema.Type_Which_int32, schema.Type_Which_int64:
+		bits := bitSize(typ)
+		i, ok := toInt(v)
+		if !ok || i < -1<<(bits-1) || i > 1<<(bits-1)-1 {
+			return 0, fmt.Errorf("can't use %T(%v) as %v", v, v, typ.Which())
+		}
+		return uint64(i) & (math.MaxUint64 >> (64 - bits)), nil
+	case schema.Type_Which_uint8, schema.Type_Which_uint16,
+		schema.Type_Which_uint32, schema.Type_Which_uint64:
+		u, ok := toUint(v)
+		if !ok || u > math.MaxUint64>>(64-bitSize(typ)) {
+			return 0, fmt.Errorf("can't use %T(%v) as %v", v, v, typ.Which())
+		}
+		return u, nil
+	case schema.Type_Which_float32:
+		f, ok := toFloat(v)
+		if !ok {
+			return 0, fmt.Errorf("can't use %T as Float32", v)
+		}
+		return uint64(math.Float32bits(float32(f))), nil
+	case schema.Type_Which_float64:
+		f, ok := toFloat(v)
+		if !ok {
+			return 0, fmt.Errorf("can't use %T as Float64", v)
+		}
+		return math.Float64bits(f), nil
+	case schema.Type_Which_enum:
+		e, err := ld.toEnum(typ.Enum().TypeId(), v)
+		return uint64(e), err
+	default:
+		return 0, fmt.Errorf("%v is not a data type", typ.Which())
+	}
+}
+
+func (ld *Loader) toEnum(typeID uint64, v any) (uint16, error) {
+	switch v := v.(type) {
+	case Enum:
+		if v.TypeID() != typeID {
+			return 0, fmt.Errorf("can't use %s value as enum @%#x", shortDisplayName(v.node), typeID)
+		}
+		return v.value, nil
+	case string:
+		n, err := ld.nodeOf(typeID, schema.Node_Which_enum)
+		if err != nil {
+			return 0, err
+		}
+		enumerants, err := n.Enum().Enumerants()
+		if err != nil {
+			return 0, err
+		}
+		for i := 0; i < enumerants.Len(); i++ {
+			if name, _ := enumerants.At(i).Name(); name == v {
+				return uint16(i), nil
+			}
+		}
+		return 0, fmt.Errorf("%s has no enumerant %q", shortDisplayName(n), v)
+	default:
+		u, ok := toUint(v)
+		if !ok || u > math.MaxUint16 {
+			return 0, fmt.Errorf("can't use %T(%v) as enum @%#x", v, v, typeID)
+		}
+		return uint16(u), nil
+	}
+}
+
+func toInt(v any) (int64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u := rv.Uint()
+		return int64(u), u <= math.MaxInt64
+	default:
+		return 0, false
+	}
+}
+
+func toUint(v any) (uint64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i := rv.Int()
+		return uint64(i), i >= 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return rv.Uint(), true
+	default:
+		return 0, false
+	}
+}
+
+func toFloat(v any) (float64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(rv.Uint()), true
+	default:
+		return 0, false
+	}
+}
+
+// fromPtr converts a pointer value to its Go representation.
+func (ld *Loader) fromPtr(typ schema.Type, p capnp.Ptr) (any, error) {
+	switch typ.Which() {
+	case schema.Type_Which_text:
+		return p.Text(), nil
+	case schema.Type_Which_data:
+		return p.Data(), nil
+	case schema.Type_Which_list:
+		elem, err := typ.List().ElementType()
+		if err != nil {
+			return nil, err
+		}
+		return List{l: p.List(), elem: elem, ld: ld}, nil
+	case schema.Type_Which_structType:
+		n, err := ld.nodeOf(typ.StructType().TypeId(), schema.Node_Which_structNode)
+		if err != nil {
+			return nil, err
+		}
+		return Struct{s: p.Struct(), node: n, ld: ld}, nil
+	case schema.Type_Which_interface:
+		return p.Interface().Client(), nil
+	case schema.Type_Which_anyPointer:
+		return p, nil
+	default:
+		return nil, fmt.Errorf("dynamic: %v is not a pointer type", typ.Which())
+	}
+}
+
+// toPtr converts v to a pointer value of type typ, allocating in seg if
+// needed.  The returned pointer may be in another message.
+func (ld *Loader) toPtr(typ schema.Type, v any, seg *capnp.Segment) (capnp.Ptr, error) {
+	if v == nil {
+		return capnp.Ptr{}, nil
+	}
+	switch typ.Which() {
+	case schema.Type_Which_text:
+		switch v := v.(type) {
+		case string:
+			t, err := capnp.NewText(seg, v)
+			return t.ToPtr(), err
+		case []byte:
+			t, err := capnp.NewTextFromBytes(seg, v)
+			return t.ToPtr(), err
+		}
+	case schema.Type_Which_data:
+		switch v := v.(type) {
+		case []byte:
+			d, err := capnp.NewData(seg, v)
+			return d.ToPtr(), err
+		case string:
+			d, err := capnp.NewData(seg, []byte(v))
+			return d.ToPtr(), err
+		}
+	case schema.Type_Which_list:
+		switch v := v.(type) {
+		case List:
+			elem, err := typ.List().ElementType()
+			if err != nil {
+				return capnp.Ptr{}, err
+			}
+			if !sameType(elem, v.elem) {
+				return capnp.Ptr{}, fmt.Errorf("can't use List(%v) as List(%v)", v.elem.Which(), elem.Which())
+			}
+			return v.l.ToPtr(), nil
+		case capnp.List:
+			return v.ToPtr(), nil
+		}
+	case schema.Type_Which_structType:
+		switch v := v.(type) {
+		case Struct:
+			if id := typ.StructType().TypeId(); v.TypeID() != id || v.IsGroup() {
+				return capnp.Ptr{}, fmt.Errorf("can't use %s as struct @%#x", v.name(), id)
+			}
+			return v.s.ToPtr(), nil
+		case capnp.Struct:
+			return v.ToPtr(), nil
+		}
+	case schema.Type_Which_interface:
+		if c, ok := v.(capnp.Client); ok {
+			if !c.IsValid() {
+				return capnp.Ptr{}, nil
+			}
+			return capnp.NewInterface(seg, seg.Message().AddCap(c)).ToPtr(), nil
+		}
+	case schema.Type_Which_anyPointer:
+		switch v := v.(type) {
+		case capnp.Ptr:
+			return v, nil
+		case capnp.Struct:
+			return v.ToPtr(), nil
+		case capnp.List:
+			return v.ToPtr(), nil
+		case Struct:
+			return v.s.ToPtr(), nil
+		case List:
+			return v.l.ToPtr(), nil
+		}
+	default:
+		return capnp.Ptr{}, fmt.Errorf("%v is not a pointer type", typ.Which())
+	}
+	return capnp.Ptr{}, fmt.Errorf("can't use %T as %v", v, typ.Which())
+}
+
+// sameType reports whether two types are the same, ignoring brands.
+func sameType(a, b schema.Type) bool {
+	if a.Which() != b.Which() {
+		return false
+	}
+	switch a.Which() {
+	case schema.Type_Which_list:
+		ea, err := a.List().ElementType()
+		if err != nil {
+			return false
+		}
+		eb, err := b.List().ElementType()
+		if err != nil {
+			return false
+		}
+		return sameType(ea, eb)
+	case schema.Type_Which_enum:
+		return a.Enum().TypeId() == b.Enum().TypeId()
+	case schema.Type_Which_structType:
+		return a.StructType().TypeId() == b.StructType().TypeId()
+	case schema.Type_Which_interface:
+		return a.Interface().TypeId() == b.Interface().TypeId()
+	default:
+		return true
+	}
+}
+
+// newList allocates a list of n elements of type elem.
+func (ld *Loader) newList(seg *capnp.Segment, elem schema.Type, n int32) (capnp.List, error) {
+	switch elem.Which() {
+	case schema.Type_Which_void:
+		return capnp.List(capnp.NewVoidList(seg, n)), nil
+	case schema.Type_Which_bool:
+		l, err := capnp.NewBitList(seg, n)
+		return capnp.List(l), err
+	case schema.Type_Which_int8:
+		l, err := capnp.NewInt8List(seg, n)
+		return capnp.List(l), err
+	case schema.Type_Which_uint8:
+		l, err := capnp.NewUInt8List(seg, n)
+		return capnp.List(l), err
+	case schema.Type_Which_int16:
+		l, err := capnp.NewInt16List(seg, n)
+		return capnp.List(l), err
+	case schema.Type_Which_uint16, schema.Type_Which_enum:
+		l, err := capnp.NewUInt16List(seg, n)
+		return capnp.List(l), err
+	case schema.Type_Which_int32:
+		l, err := capnp.NewInt32List(seg, n)
+		return capnp.List(l), err
+	case schema.Type_Which_uint32:
+		l, err := capnp.NewUInt32List(seg, n)
+		return capnp.List(l), err
+	case schema.Type_Which_float32:
+		l, err := capnp.NewFloat32List(seg, n)
+		return capnp.List(l), err
+	case schema.Type_Which_int64:
+		l, err := capnp.NewInt64List(seg, n)
+		return capnp.List(l), err
+	case schema.Type_Which_uint64:
+		l, err := capnp.NewUInt64List(seg, n)
+		return capnp.List(l), err
+	case schema.Type_Which_float64:
+		l, err := capnp.NewFloat64List(seg, n)
+		return capnp.List(l), err
+	case schema.Type_Which_structType:
+		sn, err := ld.nodeOf(elem.StructType().TypeId(), schema.Node_Which_structNode)
+		if err != nil {
+			return capnp.List{}, err
+		}
+		return capnp.NewCompositeList(seg, structSize(sn), n)
+	default:
+		l, err := capnp.NewPointerList(seg, n)
+		return capnp.List(l), err
+	}
+}
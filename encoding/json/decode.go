@@ -0,0 +1,664 @@
+package json
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"math"
+	"strconv"
+
+	"capnproto.org/go/capnp/v3"
+	"capnproto.org/go/capnp/v3/internal/schema"
+)
+
+// unionKey identifies one union in the struct being decoded.  Groups
+// share their parent's struct but have their own union.
+type unionKey struct {
+	s  capnp.Struct
+	si *structInfo
+}
+
+func (c *Codec) decodeStruct(si *structInfo, v *value, s capnp.Struct) error {
+	if h := c.handlers[si.id]; h != nil {
+		return h.DecodeJSON(c, v.raw, s)
+	}
+	if v.kind != objectValue {
+		return v.errorf("expected object for %s, found %v", si.name, v.kind)
+	}
+	// A member may depend on a union tag that appears later in the
+	// object, so members that cannot be decoded yet are retried until
+	// no more progress is made.
+	seen := make(map[unionKey]bool)
+	var retries []objectMember
+	for _, m := range v.members {
+		ok, err := c.decodeMember(si, m.name, m.val, s, seen)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			retries = append(retries, m)
+		}
+	}
+	for len(retries) > 0 {
+		prev := retries
+		retries = nil
+		for _, m := range prev {
+			ok, err := c.decodeMember(si, m.name, m.val, s, seen)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				retries = append(retries, m)
+			}
+		}
+		if len(retries) == len(prev) {
+			break
+		}
+	}
+	return nil
+}
+
+// decodeMember sets the field that an object member refers to.  It
+// returns false if the member must wait for its union's tag.
+func (c *Codec) decodeMember(si *structInfo, name string, v *value, s capnp.Struct, seen map[unionKey]bool) (bool, error) {
+	fn, ok := si.byName[name]
+	if !ok {
+		return true, nil
+	}
+	key := unionKey{s, si}
+	switch fn.kind {
+	case normalName:
+		return true, c.decodeField(fn.field, v, s)
+	case flattenedName:
+		fs, err := fn.field.flattenedInit(s)
+		if err != nil {
+			return false, err
+		}
+		return c.decodeMember(fn.field.flatten, name[fn.prefixLen:], v, fs, seen)
+	case flattenedFromUnionName:
+		if !seen[key] {
+			return false, nil
+		}
+		disc, alts := s.Uint16(si.discOff), fn.alts
+		for fn.field.discVal != disc {
+			if len(alts) == 0 {
+				// Only members of other variants have this name.
+				return true, nil
+			}
+			fn, alts = alts[0], alts[1:]
+		}
+		fs, err := fn.field.flattenedInit(s)
+		if err != nil {
+			return false, err
+		}
+		return c.decodeMember(fn.field.flatten, name[fn.prefixLen:], v, fs, seen)
+	case unionTagName:
+		if v.kind != stringValue {
+			return false, v.errorf("expected string for union tag %s, found %v", name, v.kind)
+		}
+		if fi := si.tagValues[v.s]; fi != nil {
+			if err := c.clearField(fi, s); err != nil {
+				return false, err
+			}
+			seen[key] = true
+		}
+		return true, nil
+	case unionValueName:
+		if !seen[key] {
+			return false, nil
+		}
+		fi := si.unionMember(s)
+		if fi == nil {
+			return true, nil
+		}
+		return true, c.decodeField(fi, v, s)
+	default:
+		return false, v.errorf("unknown member %s", name)
+	}
+}
+
+// flattenedInit returns the struct that holds the fields of a flattened
+// field, allocating it if the field is a null struct pointer.  If the
+// field is a union member, it becomes the active member.
+func (fi *fieldInfo) flattenedInit(s capnp.Struct) (capnp.Struct, error) {
+	if fi.union {
+		s.SetUint16(fi.owner.discOff, fi.discVal)
+	}
+	if !fi.slot {
+		return s, nil
+	}
+	if !s.HasPtr(uint16(fi.off)) {
+		ns, err := capnp.NewStruct(s.Segment(), fi.flatten.size)
+		if err != nil {
+			return capnp.Struct{}, err
+		}
+		if err := s.SetPtr(uint16(fi.off), ns.ToPtr()); err != nil {
+			return capnp.Struct{}, err
+		}
+	}
+	p, err := s.Ptr(uint16(fi.off))
+	return p.Struct(), err
+}
+
+// clearField makes a union member active and resets it to its default.
+func (c *Codec) clearField(fi *fieldInfo, s capnp.Struct) error {
+	if fi.union {
+		s.SetUint16(fi.owner.discOff, fi.discVal)
+	}
+	if !fi.slot {
+		for _, f := range fi.group.fields {
+			if !f.union {
+				if err := c.clearField(f, s); err != nil {
+					return err
+				}
+			}
+		}
+		if m := fi.group.firstMember(); m != nil {
+			return c.clearField(m, s)
+		}
+		return nil
+	}
+	off := fi.off
+	switch fi.typ.Which() {
+	case schema.Type_Which_void:
+	case schema.Type_Which_bool:
+		s.SetBit(capnp.BitOffset(off), false)
+	case schema.Type_Which_int8, schema.Type_Which_uint8:
+		s.SetUint8(capnp.DataOffset(off), 0)
+	case schema.Type_Which_int16, schema.Type_Which_uint16, schema.Type_Which_enum:
+		s.SetUint16(capnp.DataOffset(off*2), 0)
+	case schema.Type_Which_int32, schema.Type_Which_uint32, schema.Type_Which_float32:
+		s.SetUint32(capnp.DataOffset(off*4), 0)
+	case schema.Type_Which_int64, schema.Type_Which_uint64, schema.Type_Which_float64:
+		s.SetUint64(capnp.DataOffset(off*8), 0)
+	default:
+		return s.SetPtr(uint16(off), capnp.Ptr{})
+	}
+	return nil
+}
+
+// firstMember returns the union member with discriminant zero.
+func (si *structInfo) firstMember() *fieldInfo {
+	for _, fi := range si.fields {
+		if fi.union && fi.discVal == 0 {
+			return fi
+		}
+	}
+	return nil
+}
+
+func (c *Codec) decodeField(fi *fieldInfo, v *value, s capnp.Struct) error {
+	if fi.union {
+		s.SetUint16(fi.owner.discOff, fi.discVal)
+	}
+	if !fi.slot {
+		if v.kind != objectValue {
+			return v.errorf("expected object for group %s, found %v", fi.name, v.kind)
+		}
+		return c.decodeStruct(fi.group, v, s)
+	}
+	dv := fi.def
+	off := fi.off
+	switch fi.typ.Which() {
+	case schema.Type_Which_void:
+	case schema.Type_Which_bool:
+		if v.kind != boolValue {
+			return v.errorf("expected boolean, found %v", v.kind)
+		}
+		s.SetBit(capnp.BitOffset(off), v.b != dv.Bool())
+	case schema.Type_Which_int8:
+		i, err := parseInt(v, 8)
+		if err != nil {
+			return err
+		}
+		s.SetUint8(capnp.DataOffset(off), uint8(i)^uint8(dv.Int8()))
+	case schema.Type_Which_int16:
+		i, err := parseInt(v, 16)
+		if err != nil {
+			return err
+		}
+		s.SetUint16(capnp.DataOffset(off*2), uint16(i)^uint16(dv.Int16()))
+	case schema.Type_Which_int32:
+		i, err := parseInt(v, 32)
+		if err != nil {
+			return err
+		}
+		s.SetUint32(capnp.DataOffset(off*4), uint32(i)^uint32(dv.Int32()))
+	case schema.Type_Which_int64:
+		i, err := parseInt(v, 64)
+		if err != nil {
+			return err
+		}
+		s.SetUint64(capnp.DataOffset(off*8), uint64(i)^uint64(dv.Int64()))
+	case schema.Type_Which_uint8:
+		i, err := parseUint(v, 8)
+		if err != nil {
+			return err
+		}
+		s.SetUint8(capnp.DataOffset(off), uint8(i)^dv.Uint8())
+	case schema.Type_Which_uint16:
+		i, err := parseUint(v, 16)
+		if err != nil {
+			return err
+		}
+		s.SetUint16(capnp.DataOffset(off*2), uint16(i)^dv.Uint16())
+	case schema.Type_Which_uint32:
+		i, err := parseUint(v, 32)
+		if err != nil {
+			return err
+		}
+		s.SetUint32(capnp.DataOffset(off*4), uint32(i)^dv.Uint32())
+	case schema.Type_Which_uint64:
+		i, err := parseUint(v, 64)
+		if err != nil {
+			return err
+		}
+		s.SetUint64(capnp.DataOffset(off*8), i^dv.Uint64())
+	case schema.Type_Which_float32:
+		x, err := parseFloat(v, 32)
+		if err != nil {
+			return err
+		}
+		s.SetUint32(capnp.DataOffset(off*4), math.Float32bits(float32(x))^math.Float32bits(dv.Float32()))
+	case schema.Type_Which_float64:
+		x, err := parseFloat(v, 64)
+		if err != nil {
+			return err
+		}
+		s.SetUint64(capnp.DataOffset(off*8), math.Float64bits(x)^math.Float64bits(dv.Float64()))
+	case schema.Type_Which_enum:
+		e, err := c.parseEnum(fi.typ.Enum().TypeId(), v)
+		if err != nil {
+			return err
+		}
+		s.SetUint16(capnp.DataOffset(off*2), e^dv.Enum())
+	case schema.Type_Which_data:
+		if v.kind == nullValue {
+			return s.SetPtr(uint16(off), capnp.Ptr{})
+		}
+		b, err := parseData(v, fi.data)
+		if err != nil {
+			return err
+		}
+		return s.SetData(uint16(off), b)
+	default:
+		p, err := c.decodePtr(fi.typ, v, s.Segment())
+		if err != nil {
+			return err
+		}
+		return s.SetPtr(uint16(off), p)
+	}
+	return nil
+}
+
+// decodePtr returns a pointer value allocated in seg.  A JSON null
+// yields a null pointer.
+func (c *Codec) decodePtr(typ schema.Type, v *value, seg *capnp.Segment) (capnp.Ptr, error) {
+	if v.kind == nullValue {
+		return capnp.Ptr{}, nil
+	}
+	switch typ.Which() {
+	case schema.Type_Which_text:
+		if v.kind != stringValue {
+			return capnp.Ptr{}, v.errorf("expected string, found %v", v.kind)
+		}
+		t, err := capnp.NewText(seg, v.s)
+		return t.ToPtr(), err
+	case schema.Type_Which_data:
+		b, err := parseData(v, dataArray)
+		if err != nil {
+			return capnp.Ptr{}, err
+		}
+		d, err := capnp.NewData(seg, b)
+		return d.ToPtr(), err
+	case schema.Type_Which_structType:
+		si, err := c.structInfo(typ.StructType().TypeId())
+		if err != nil {
+			return capnp.Ptr{}, err
+		}
+		s, err := capnp.NewStruct(seg, si.size)
+		if err != nil {
+			return capnp.Ptr{}, err
+		}
+		if err := c.decodeStruct(si, v, s); err != nil {
+			return capnp.Ptr{}, err
+		}
+		return s.ToPtr(), nil
+	case schema.Type_Which_list:
+		elem, err := typ.List().ElementType()
+		if err != nil {
+			return capnp.Ptr{}, err
+		}
+		return c.decodeList(elem, v, seg)
+	case schema.Type_Which_interface:
+		return capnp.Ptr{}, v.errorf("cannot decode capabilities")
+	case schema.Type_Which_anyPointer:
+		return capnp.Ptr{}, v.errorf("cannot decode AnyPointer")
+	default:
+		return capnp.Ptr{}, v.errorf("unknown type %v", typ.Which())
+	}
+}
+
+func (c *Codec) decodeList(elem schema.Type, v *value, seg *capnp.Segment) (capnp.Ptr, error) {
+	if v.kind != arrayValue {
+		return capnp.Ptr{}, v.errorf("expected array, found %v", v.kind)
+	}
+	n := int32(len(v.elems))
+	switch elem.Which() {
+	case schema.Type_Which_void:
+		return capnp.NewVoidList(seg, n).ToPtr(), nil
+	case schema.Type_Which_bool:
+		l, err := capnp.NewBitList(seg, n)
+		if err != nil {
+			return capnp.Ptr{}, err
+		}
+		for i, e := range v.elems {
+			if e.kind != boolValue {
+				return capnp.Ptr{}, e.errorf("expected boolean, found %v", e.kind)
+			}
+			l.Set(i, e.b)
+		}
+		return l.ToPtr(), nil
+	case schema.Type_Which_int8:
+		l, err := capnp.NewInt8List(seg, n)
+		if err != nil {
+			return capnp.Ptr{}, err
+		}
+		for i, e := range v.elems {
+			x, err := parseInt(e, 8)
+			if err != nil {
+				return capnp.Ptr{}, err
+			}
+			l.Set(i, int8(x))
+		}
+		return l.ToPtr(), nil
+	case schema.Type_Which_int16:
+		l, err := capnp.NewInt16List(seg, n)
+		if err != nil {
+			return capnp.Ptr{}, err
+		}
+		for i, e := range v.elems {
+			x, err := parseInt(e, 16)
+			if err != nil {
+				return capnp.Ptr{}, err
+			}
+			l.Set(i, int16(x))
+		}
+		return l.ToPtr(), nil
+	case schema.Type_Which_int32:
+		l, err := capnp.NewInt32List(seg, n)
+		if err != nil {
+			return capnp.Ptr{}, err
+		}
+		for i, e := range v.elems {
+			x, err := parseInt(e, 32)
+			if err != nil {
+				return capnp.Ptr{}, err
+			}
+			l.Set(i, int32(x))
+		}
+		return l.ToPtr(), nil
+	case schema.Type_Which_int64:
+		l, err := capnp.NewInt64List(seg, n)
+		if err != nil {
+			return capnp.Ptr{}, err
+		}
+		for i, e := range v.elems {
+			x, err := parseInt(e, 64)
+			if err != nil {
+				return capnp.Ptr{}, err
+			}
+			l.Set(i, x)
+		}
+		return l.ToPtr(), nil
+	case schema.Type_Which_uint8:
+		l, err := capnp.NewUInt8List(seg, n)
+		if err != nil {
+			return capnp.Ptr{}, err
+		}
+		for i, e := range v.elems {
+			x, err := parseUint(e, 8)
+			if err != nil {
+				return capnp.Ptr{}, err
+			}
+			l.Set(i, uint8(x))
+		}
+		return l.ToPtr(), nil
+	case schema.Type_Which_uint16:
+		l, err := capnp.NewUInt16List(seg, n)
+		if err != nil {
+			return capnp.Ptr{}, err
+		}
+		for i, e := range v.elems {
+			x, err := parseUint(e, 16)
+			if err != nil {
+				return capnp.Ptr{}, err
+			}
+			l.Set(i, uint16(x))
+		}
+		return l.ToPtr(), nil
+	case schema.Type_Which_uint32:
+		l, err := capnp.NewUInt32List(seg, n)
+		if err != nil {
+			return capnp.Ptr{}, err
+		}
+		for i, e := range v.elems {
+			x, err := parseUint(e, 32)
+			if err != nil {
+				return capnp.Ptr{}, err
+			}
+			l.Set(i, uint32(x))
+		}
+		return l.ToPtr(), nil
+	case schema.Type_Which_uint64:
+		l, err := capnp.NewUInt64List(seg, n)
+		if err != nil {
+			return capnp.Ptr{}, err
+		}
+		for i, e := range v.elems {
+			x, err := parseUint(e, 64)
+			if err != nil {
+				return capnp.Ptr{}, err
+			}
+			l.Set(i, x)
+		}
+		return l.ToPtr(), nil
+	case schema.Type_Which_float32:
+		l, err := capnp.NewFloat32List(seg, n)
+		if err != nil {
+			return capnp.Ptr{}, err
+		}
+		for i, e := range v.elems {
+			x, err := parseFloat(e, 32)
+			if err != nil {
+				return capnp.Ptr{}, err
+			}
+			l.Set(i, float32(x))
+		}
+		return l.ToPtr(), nil
+	case schema.Type_Which_float64:
+		l, err := capnp.NewFloat64List(seg, n)
+		if err != nil {
+			return capnp.Ptr{}, err
+		}
+		for i, e := range v.elems {
+			x, err := parseFloat(e, 64)
+			if err != nil {
+				return capnp.Ptr{}, err
+			}
+			l.Set(i, x)
+		}
+		return l.ToPtr(), nil
+	case schema.Type_Which_enum:
+		l, err := capnp.NewUInt16List(seg, n)
+		if err != nil {
+			return capnp.Ptr{}, err
+		}
+		for i, e := range v.elems {
+			x, err := c.parseEnum(elem.Enum().TypeId(), e)
+			if err != nil {
+				return capnp.Ptr{}, err
+			}
+			l.Set(i, x)
+		}
+		return l.ToPtr(), nil
+	case schema.Type_Which_structType:
+		si, err := c.structInfo(elem.StructType().TypeId())
+		if err != nil {
+			return capnp.Ptr{}, err
+		}
+		l, err := capnp.NewCompositeList(seg, si.size, n)
+		if err != nil {
+			return capnp.Ptr{}, err
+		}
+		for i, e := range v.elems {
+			if err := c.decodeStruct(si, e, l.Struct(i)); err != nil {
+				return capnp.Ptr{}, err
+			}
+		}
+		return l.ToPtr(), nil
+	default:
+		l, err := capnp.NewPointerList(seg, n)
+		if err != nil {
+			return capnp.Ptr{}, err
+		}
+		for i, e := range v.elems {
+			p, err := c.decodePtr(elem, e, seg)
+			if err != nil {
+				return capnp.Ptr{}, err
+			}
+			if err := l.Set(i, p); err != nil {
+				return capnp.Ptr{}, err
+			}
+		}
+		return l.ToPtr(), nil
+	}
+}
+
+// parseEnum returns the value of an enumerant, given by name or number.
+func (c *Codec) parseEnum(typeID uint64, v *value) (uint16, error) {
+	if v.kind == numberValue {
+		x, err := parseUint(v, 16)
+		return uint16(x), err
+	}
+	if v.kind != stringValue {
+		return 0, v.errorf("expected enumerant, found %v", v.kind)
+	}
+	names, err := c.enumNames(typeID)
+	if err != nil {
+		return 0, err
+	}
+	for i, name := range names {
+		if name == v.s {
+			return uint16(i), nil
+		}
+	}
+	return 0, v.errorf("unknown enumerant %q", v.s)
+}
+
+// parseInt accepts an integer as a number or as a decimal string.
+func parseInt(v *value, bitSize int) (int64, error) {
+	switch v.kind {
+	case numberValue:
+		if i, err := strconv.ParseInt(v.s, 10, bitSize); err == nil {
+			return i, nil
+		}
+		// Accept integral numbers written with a fraction or exponent.
+		f, err := strconv.ParseFloat(v.s, 64)
+		lim := math.Ldexp(1, bitSize-1)
+		if err == nil && f == math.Trunc(f) && -lim <= f && f < lim {
+			return int64(f), nil
+		}
+	case stringValue:
+		if i, err := strconv.ParseInt(v.s, 10, bitSize); err == nil {
+			return i, nil
+		}
+	default:
+		return 0, v.errorf("expected integer, found %v", v.kind)
+	}
+	return 0, v.errorf("invalid Int%d %s", bitSize, v.raw)
+}
+
+// parseUint accepts an unsigned integer as a number or as a decimal
+// string.
+func parseUint(v *value, bitSize int) (uint64, error) {
+	switch v.kind {
+	case numberValue:
+		if i, err := strconv.ParseUint(v.s, 10, bitSize); err == nil {
+			return i, nil
+		}
+		f, err := strconv.ParseFloat(v.s, 64)
+		if err == nil && f == math.Trunc(f) && 0 <= f && f < math.Ldexp(1, bitSize) {
+			return uint64(f), nil
+		}
+	case stringValue:
+		if i, err := strconv.ParseUint(v.s, 10, bitSize); err == nil {
+			return i, nil
+		}
+	default:
+		return 0, v.errorf("expected integer, found %v", v.kind)
+	}
+	return 0, v.errorf("invalid UInt%d %s", bitSize, v.raw)
+}
+
+// parseFloat accepts a number, the strings written for infinities and
+// NaN, a numeric string, or null, which is taken to be NaN.
+func parseFloat(v *value, bitSize int) (float64, error) {
+	switch v.kind {
+	case nullValue:
+		return math.NaN(), nil
+	case numberValue, stringValue:
+		switch v.s {
+		case "Infinity":
+			return math.Inf(1), nil
+		case "-Infinity":
+			return math.Inf(-1), nil
+		case "NaN":
+			return math.NaN(), nil
+		}
+		x, err := strconv.ParseFloat(v.s, bitSize)
+		if err != nil {
+			return 0, v.errorf("invalid Float%d %s", bitSize, v.raw)
+		}
+		return x, nil
+	default:
+		return 0, v.errorf("expected number, found %v", v.kind)
+	}
+}
+
+func parseData(v *value, format dataFormat) ([]byte, error) {
+	switch format {
+	case dataBase64:
+		if v.kind != stringValue {
+			return nil, v.errorf("expected base64 string, found %v", v.kind)
+		}
+		b, err := base64.StdEncoding.DecodeString(v.s)
+		if err != nil {
+			if b, err = base64.RawStdEncoding.DecodeString(v.s); err != nil {
+				return nil, v.errorf("invalid base64 data")
+			}
+		}
+		return b, nil
+	case dataHex:
+		if v.kind != stringValue {
+			return nil, v.errorf("expected hex string, found %v", v.kind)
+		}
+		b, err := hex.DecodeString(v.s)
+		if err != nil {
+			return nil, v.errorf("invalid hex data")
+		}
+		return b, nil
+	default:
+		if v.kind != arrayValue {
+			return nil, v.errorf("expected array of bytes, found %v", v.kind)
+		}
+		b := make([]byte, len(v.elems))
+		for i, e := range v.elems {
+			x, err := parseUint(e, 8)
+			if err != nil {
+				return nil, err
+			}
+			b[i] = byte(x)
+		}
+		return b, nil
+	}
+}
@@ -0,0 +1,343 @@
+package json
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strconv"
+
+	"capnproto.org/go/capnp/v3"
+	"capnproto.org/go/capnp/v3/internal/schema"
+)
+
+type encoder struct {
+	buf []byte
+}
+
+// An entry is a struct member to be written, after flattening.
+type entry struct {
+	name  string
+	field *fieldInfo   // nil for a union tag
+	s     capnp.Struct // the struct that holds field
+	tag   string
+}
+
+func (c *Codec) encodeStruct(enc *encoder, si *structInfo, s capnp.Struct) error {
+	if h := c.handlers[si.id]; h != nil {
+		b, err := h.EncodeJSON(c, s)
+		if err != nil {
+			return err
+		}
+		if _, err := parse(b); err != nil {
+			return fmt.Errorf("json: handler for %s: %w", si.name, err)
+		}
+		enc.buf = append(enc.buf, b...)
+		return nil
+	}
+	entries, err := c.gather(si, s, "", nil)
+	if err != nil {
+		return err
+	}
+	enc.buf = append(enc.buf, '{')
+	for i, m := range entries {
+		if i > 0 {
+			enc.buf = append(enc.buf, ',')
+		}
+		enc.buf = appendString(enc.buf, m.name)
+		enc.buf = append(enc.buf, ':')
+		if m.field == nil {
+			enc.buf = appendString(enc.buf, m.tag)
+			continue
+		}
+		if err := c.encodeField(enc, m.field, m.s); err != nil {
+			return err
+		}
+	}
+	enc.buf = append(enc.buf, '}')
+	return nil
+}
+
+// gather appends the members of s to out, descending into flattened
+// fields.  Non-union fields come first, then the union's tag and its
+// active member.
+func (c *Codec) gather(si *structInfo, s capnp.Struct, prefix string, out []entry) ([]entry, error) {
+	for _, fi := range si.fields {
+		if fi.union || !fi.has(s) {
+			continue
+		}
+		if fi.flatten != nil {
+			fs, err := fi.flattened(s)
+			if err != nil {
+				return nil, err
+			}
+			if out, err = c.gather(fi.flatten, fs, prefix+fi.prefix, out); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		out = append(out, entry{name: prefix + fi.name, field: fi, s: s})
+	}
+	fi := si.unionMember(s)
+	if fi == nil {
+		return out, nil
+	}
+	if si.tagName != "" {
+		out = append(out, entry{name: prefix + si.tagName, tag: fi.discName})
+	}
+	switch {
+	case !fi.has(s):
+	case fi.flatten != nil:
+		fs, err := fi.flattened(s)
+		if err != nil {
+			return nil, err
+		}
+		return c.gather(fi.flatten, fs, prefix+fi.prefix, out)
+	case fi.slot && fi.typ.Which() == schema.Type_Which_void && si.tagName != "":
+		// The tag is enough to identify a void member.
+	default:
+		out = append(out, entry{name: prefix + fi.name, field: fi, s: s})
+	}
+	return out, nil
+}
+
+// flattened returns the struct that holds the fields of a flattened
+// field: the same struct for a group, or the pointed-to struct.
+func (fi *fieldInfo) flattened(s capnp.Struct) (capnp.Struct, error) {
+	if !fi.slot {
+		return s, nil
+	}
+	p, err := s.Ptr(uint16(fi.off))
+	return p.Struct(), err
+}
+
+func (c *Codec) encodeField(enc *encoder, fi *fieldInfo, s capnp.Struct) error {
+	if !fi.slot {
+		return c.encodeStruct(enc, fi.group, s)
+	}
+	dv := fi.def
+	off := fi.off
+	switch fi.typ.Which() {
+	case schema.Type_Which_void:
+		enc.buf = append(enc.buf, "null"...)
+	case schema.Type_Which_bool:
+		enc.buf = strconv.AppendBool(enc.buf, s.Bit(capnp.BitOffset(off)) != dv.Bool())
+	case schema.Type_Which_int8:
+		v := s.Uint8(capnp.DataOffset(off)) ^ uint8(dv.Int8())
+		enc.buf = strconv.AppendInt(enc.buf, int64(int8(v)), 10)
+	case schema.Type_Which_int16:
+		v := s.Uint16(capnp.DataOffset(off*2)) ^ uint16(dv.Int16())
+		enc.buf = strconv.AppendInt(enc.buf, int64(int16(v)), 10)
+	case schema.Type_Which_int32:
+		v := s.Uint32(capnp.DataOffset(off*4)) ^ uint32(dv.Int32())
+		enc.buf = strconv.AppendInt(enc.buf, int64(int32(v)), 10)
+	case schema.Type_Which_int64:
+		v := s.Uint64(capnp.DataOffset(off*8)) ^ uint64(dv.Int64())
+		enc.buf = appendString(enc.buf, strconv.FormatInt(int64(v), 10))
+	case schema.Type_Which_uint8:
+		v := s.Uint8(capnp.DataOffset(off)) ^ dv.Uint8()
+		enc.buf = strconv.AppendUint(enc.buf, uint64(v), 10)
+	case schema.Type_Which_uint16:
+		v := s.Uint16(capnp.DataOffset(off*2)) ^ dv.Uint16()
+		enc.buf = strconv.AppendUint(enc.buf, uint64(v), 10)
+	case schema.Type_Which_uint32:
+		v := s.Uint32(capnp.DataOffset(off*4)) ^ dv.Uint32()
+		enc.buf = strconv.AppendUint(enc.buf, uint64(v), 10)
+	case schema.Type_Which_uint64:
+		v := s.Uint64(capnp.DataOffset(off*8)) ^ dv.Uint64()
+		enc.buf = appendString(enc.buf, strconv.FormatUint(v, 10))
+	case schema.Type_Which_float32:
+		v := s.Uint32(capnp.DataOffset(off*4)) ^ math.Float32bits(dv.Float32())
+		enc.buf = appendFloat(enc.buf, float64(math.Float32frombits(v)))
+	case schema.Type_Which_float64:
+		v := s.Uint64(capnp.DataOffset(off*8)) ^ math.Float64bits(dv.Float64())
+		enc.buf = appendFloat(enc.buf, math.Float64frombits(v))
+	case schema.Type_Which_enum:
+		v := s.Uint16(capnp.DataOffset(off*2)) ^ dv.Enum()
+		return c.encodeEnum(enc, fi.typ.Enum().TypeId(), v)
+	case schema.Type_Which_data:
+		p, err := s.Ptr(uint16(off))
+		if err != nil {
+			return err
+		}
+		switch fi.data {
+		case dataBase64:
+			enc.buf = appendString(enc.buf, base64.StdEncoding.EncodeToString(p.Data()))
+		case dataHex:
+			enc.buf = appendString(enc.buf, hex.EncodeToString(p.Data()))
+		default:
+			enc.appendBytes(p.Data())
+		}
+	default:
+		p, err := s.Ptr(uint16(off))
+		if err != nil {
+			return err
+		}
+		return c.encodePtr(enc, fi.typ, p)
+	}
+	return nil
+}
+
+// encodePtr writes a pointer value.  A null pointer is written as the
+// empty value of its type.
+func (c *Codec) encodePtr(enc *encoder, typ schema.Type, p capnp.Ptr) error {
+	switch typ.Which() {
+	case schema.Type_Which_text:
+		enc.buf = appendString(enc.buf, p.Text())
+	case schema.Type_Which_data:
+		enc.appendBytes(p.Data())
+	case schema.Type_Which_structType:
+		si, err := c.structInfo(typ.StructType().TypeId())
+		if err != nil {
+			return err
+		}
+		return c.encodeStruct(enc, si, p.Struct())
+	case schema.Type_Which_list:
+		elem, err := typ.List().ElementType()
+		if err != nil {
+			return err
+		}
+		return c.encodeList(enc, elem, p.List())
+	case schema.Type_Which_interface:
+		return fmt.Errorf("json: cannot encode capabilities")
+	case schema.Type_Which_anyPointer:
+		return fmt.Errorf("json: cannot encode AnyPointer")
+	default:
+		return fmt.Errorf("json: unknown type %v", typ.Which())
+	}
+	return nil
+}
+
+func (c *Codec) encodeList(enc *encoder, elem schema.Type, l capnp.List) error {
+	enc.buf = append(enc.buf, '[')
+	for i := 0; i < l.Len(); i++ {
+		if i > 0 {
+			enc.buf = append(enc.buf, ',')
+		}
+		switch elem.Which() {
+		case schema.Type_Which_void:
+			enc.buf = append(enc.buf, "null"...)
+		case schema.Type_Which_bool:
+			enc.buf = strconv.AppendBool(enc.buf, capnp.BitList(l).At(i))
+		case schema.Type_Which_int8:
+			enc.buf = strconv.AppendInt(enc.buf, int64(capnp.Int8List(l).At(i)), 10)
+		case schema.Type_Which_int16:
+			enc.buf = strconv.AppendInt(enc.buf, int64(capnp.Int16List(l).At(i)), 10)
+		case schema.Type_Which_int32:
+			enc.buf = strconv.AppendInt(enc.buf, int64(capnp.Int32List(l).At(i)), 10)
+		case schema.Type_Which_int64:
+			enc.buf = appendString(enc.buf, strconv.FormatInt(capnp.Int64List(l).At(i), 10))
+		case schema.Type_Which_uint8:
+			enc.buf = strconv.AppendUint(enc.buf, uint64(capnp.UInt8List(l).At(i)), 10)
+		case schema.Type_Which_uint16:
+			enc.buf = strconv.AppendUint(enc.buf, uint64(capnp.UInt16List(l).At(i)), 10)
+		case schema.Type_Which_uint32:
+			enc.buf = strconv.AppendUint(enc.buf, uint64(capnp.UInt32List(l).At(i)), 10)
+		case schema.Type_Which_uint64:
+			enc.buf = appendString(enc.buf, strconv.FormatUint(capnp.UInt64List(l).At(i), 10))
+		case schema.Type_Which_float32:
+			enc.buf = appendFloat(enc.buf, float64(capnp.Float32List(l).At(i)))
+		case schema.Type_Which_float64:
+			enc.buf = appendFloat(enc.buf, capnp.Float64List(l).At(i))
+		case schema.Type_Which_enum:
+			if err := c.encodeEnum(enc, elem.Enum().TypeId(), capnp.UInt16List(l).At(i)); err != nil {
+				return err
+			}
+		case schema.Type_Which_structType:
+			si, err := c.structInfo(elem.StructType().TypeId())
+			if err != nil {
+				return err
+			}
+			if err := c.encodeStruct(enc, si, l.Struct(i)); err != nil {
+				return err
+			}
+		default:
+			p, err := capnp.PointerList(l).At(i)
+			if err != nil {
+				return err
+			}
+			if err := c.encodePtr(enc, elem, p); err != nil {
+				return err
+			}
+		}
+	}
+	enc.buf = append(enc.buf, ']')
+	return nil
+}
+
+// encodeEnum writes an enumerant's name, or its number if it is not in
+// the schema.
+func (c *Codec) encodeEnum(enc *encoder, typeID uint64, v uint16) error {
+	names, err := c.enumNames(typeID)
+	if err != nil {
+		return err
+	}
+	if int(v) >= len(names) {
+		enc.buf = strconv.AppendUint(enc.buf, uint64(v), 10)
+		return nil
+	}
+	enc.buf = appendString(enc.buf, names[v])
+	return nil
+}
+
+func (enc *encoder) appendBytes(b []byte) {
+	enc.buf = append(enc.buf, '[')
+	for i, x := range b {
+		if i > 0 {
+			enc.buf = append(enc.buf, ',')
+		}
+		enc.buf = strconv.AppendUint(enc.buf, uint64(x), 10)
+	}
+	enc.buf = append(enc.buf, ']')
+}
+
+// appendFloat formats f as kj::str(double) does: with 15 significant
+// digits, or 17 if 15 do not round-trip.  JSON has no infinities or NaN,
+// so those are written as strings.
+func appendFloat(b []byte, f float64) []byte {
+	switch {
+	case math.IsInf(f, 1):
+		return appendString(b, "Infinity")
+	case math.IsInf(f, -1):
+		return appendString(b, "-Infinity")
+	case math.IsNaN(f):
+		return appendString(b, "NaN")
+	}
+	out := strconv.AppendFloat(b, f, 'g', 15, 64)
+	if x, _ := strconv.ParseFloat(string(out[len(b):]), 64); x != f {
+		out = strconv.AppendFloat(b, f, 'g', 17, 64)
+	}
+	return out
+}
+
+// appendString appends a JSON string literal.  Only quotes, backslashes
+// and control characters are escaped.
+func appendString(b []byte, s string) []byte {
+	const hexDigits = "0123456789abcdef"
+	b = append(b, '"')
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '"':
+			b = append(b, `\"`...)
+		case '\\':
+			b = append(b, `\\`...)
+		case '\b':
+			b = append(b, `\b`...)
+		case '\f':
+			b = append(b, `\f`...)
+		case '\n':
+			b = append(b, `\n`...)
+		case '\r':
+			b = append(b, `\r`...)
+		case '\t':
+			b = append(b, `\t`...)
+		default:
+			if c < 0x20 {
+				b = append(b, '\\', 'u', '0', '0', hexDigits[c>>4], hexDigits[c&0xf])
+			} else {
+				b = append(b, c)
+			}
+		}
+	}
+	return append(b, '"')
+}
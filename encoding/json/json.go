@@ -0,0 +1,526 @@
+// Package json converts Cap'n Proto structs to and from JSON.
+//
+// The mapping is the same as that of the C++ JsonCodec with annotation
+// handling enabled, so that programs in either language agree on the
+// JSON form of a schema.  Struct fields are written in ordinal order,
+// followed by the active member of the struct's union, if any.  Null
+// pointer fields are omitted.  Data is written as an array of byte
+// values, 64-bit integers as decimal strings, and infinite and NaN
+// floats as the strings "Infinity", "-Infinity" and "NaN".
+// Capabilities and AnyPointer fields cannot be converted.
+//
+// The annotations declared in std/capnp/compat/json.capnp customize the
+// mapping:
+//
+//	$name           renames a field, group, union or enumerant
+//	$flatten        merges the fields of a struct or group into its parent
+//	$discriminator  names a union's active member in a separate tag field
+//	$base64, $hex   write a Data field as a string
+//
+// Unknown object members are ignored when decoding.
+package json
+
+import (
+	"fmt"
+
+	"capnproto.org/go/capnp/v3"
+	"capnproto.org/go/capnp/v3/internal/nodemap"
+	"capnproto.org/go/capnp/v3/internal/schema"
+	"capnproto.org/go/capnp/v3/schemas"
+	jsoncp "capnproto.org/go/capnp/v3/std/capnp/compat/json"
+)
+
+// Marshal returns the JSON encoding of s, a struct of the given type,
+// using the default registry.
+func Marshal(typeID uint64, s capnp.Struct) ([]byte, error) {
+	return new(Codec).Marshal(typeID, s)
+}
+
+// Unmarshal parses the JSON encoding of a struct of the given type,
+// using the default registry, and returns a new struct allocated in seg.
+func Unmarshal(typeID uint64, data []byte, seg *capnp.Segment) (capnp.Struct, error) {
+	return new(Codec).Unmarshal(typeID, data, seg)
+}
+
+// A Codec converts structs to and from JSON.  The zero value is a codec
+// that consults the default registry for schemas.  A Codec caches
+// schema information and must not be used concurrently.
+type Codec struct {
+	nodes    nodemap.Map
+	handlers map[uint64]Handler
+	structs  map[uint64]*structInfo
+	enums    map[uint64][]string
+}
+
+// A Handler encodes and decodes one struct type in place of the
+// default mapping.  Handlers are registered with Codec.AddTypeHandler.
+type Handler interface {
+	// EncodeJSON returns the JSON encoding of s.
+	EncodeJSON(c *Codec, s capnp.Struct) ([]byte, error)
+
+	// DecodeJSON sets the fields of s, a newly allocated struct, from
+	// its JSON encoding.
+	DecodeJSON(c *Codec, data []byte, s capnp.Struct) error
+}
+
+// UseRegistry changes the registry that the codec consults for schemas
+// from the default registry.
+func (c *Codec) UseRegistry(reg *schemas.Registry) {
+	c.nodes.UseRegistry(reg)
+	c.structs = nil
+	c.enums = nil
+}
+
+// AddTypeHandler arranges for values of the struct type typeID to be
+// encoded and decoded by h, wherever they appear.  A flattened field
+// is always converted field by field, so its handler is not consulted.
+func (c *Codec) AddTypeHandler(typeID uint64, h Handler) {
+	if c.handlers == nil {
+		c.handlers = make(map[uint64]Handler)
+	}
+	c.handlers[typeID] = h
+}
+
+// Marshal returns the JSON encoding of s, a struct of the given type.
+func (c *Codec) Marshal(typeID uint64, s capnp.Struct) ([]byte, error) {
+	si, err := c.structInfo(typeID)
+	if err != nil {
+		return nil, err
+	}
+	var enc encoder
+	if err := c.encodeStruct(&enc, si, s); err != nil {
+		return nil, err
+	}
+	return enc.buf, nil
+}
+
+// Unmarshal parses the JSON encoding of a struct of the given type and
+// returns a new struct allocated in seg.  Errors in the input are
+// reported as a *SyntaxError.
+func (c *Codec) Unmarshal(typeID uint64, data []byte, seg *capnp.Segment) (capnp.Struct, error) {
+	v, err := parse(data)
+	if err != nil {
+		return capnp.Struct{}, err
+	}
+	si, err := c.structInfo(typeID)
+	if err != nil {
+		return capnp.Struct{}, err
+	}
+	s, err := capnp.NewStruct(seg, si.size)
+	if err != nil {
+		return capnp.Struct{}, err
+	}
+	if err := c.decodeStruct(si, v, s); err != nil {
+		return capnp.Struct{}, err
+	}
+	return s, nil
+}
+
+// structInfo describes how a struct or group type maps to JSON.  It is
+// the counterpart of the C++ AnnotatedHandler.
+type structInfo struct {
+	id        uint64
+	name      string
+	size      capnp.ObjectSize
+	unions    bool
+	discOff   capnp.DataOffset
+	fields    []*fieldInfo // in ordinal order
+	byName    map[string]fieldName
+	tagName   string // empty if the union has no tag field
+	valueName string
+	tagValues map[string]*fieldInfo
+	loading   bool
+}
+
+type fieldInfo struct {
+	owner    *structInfo
+	index    int
+	name     string // the JSON member name
+	discName string // the name used as the value of a union tag
+	union    bool
+	discVal  uint16
+
+	// Exactly one of slot and group is set.
+	slot  bool
+	typ   schema.Type
+	def   schema.Value
+	off   uint32
+	group *structInfo
+
+	flatten *structInfo // set if the field is flattened into its parent
+	prefix  string
+	data    dataFormat
+}
+
+type dataFormat int
+
+const (
+	dataArray dataFormat = iota
+	dataBase64
+	dataHex
+)
+
+type nameKind int
+
+const (
+	normalName nameKind = iota
+	flattenedName
+	flattenedFromUnionName
+	unionTagName
+	unionValueName
+)
+
+// fieldName is what a JSON member name refers to in a struct.
+type fieldName struct {
+	kind      nameKind
+	field     *fieldInfo
+	prefixLen int
+
+	// alts holds the other union variants that flatten a member with
+	// the same name.
+	alts []fieldName
+}
+
+type discriminator struct {
+	present   bool
+	name      string
+	hasName   bool
+	valueName string
+}
+
+func readDiscriminator(a schema.Annotation) (discriminator, error) {
+	v, err := a.Value()
+	if err != nil {
+		return discriminator{}, err
+	}
+	p, err := v.StructValue()
+	if err != nil {
+		return discriminator{}, err
+	}
+	opts := jsoncp.DiscriminatorOptions(p.Struct())
+	d := discriminator{present: true, hasName: opts.HasName()}
+	if d.name, err = opts.Name(); err != nil {
+		return discriminator{}, err
+	}
+	if d.valueName, err = opts.ValueName(); err != nil {
+		return discriminator{}, err
+	}
+	return d, nil
+}
+
+func (c *Codec) structInfo(id uint64) (*structInfo, error) {
+	if si := c.structs[id]; si != nil {
+		return si, nil
+	}
+	return c.loadStruct(id, discriminator{}, "")
+}
+
+// loadStruct builds the structInfo for a struct or group type.  A group
+// may be given its discriminator and union name by its parent.
+func (c *Codec) loadStruct(id uint64, disc discriminator, unionName string) (*structInfo, error) {
+	if si := c.structs[id]; si != nil {
+		if si.loading {
+			return nil, fmt.Errorf("json: %s is flattened into itself", si.name)
+		}
+		return si, nil
+	}
+	n, err := c.nodes.Find(id)
+	if err != nil {
+		return nil, err
+	}
+	if !n.IsValid() || n.Which() != schema.Node_Which_structNode {
+		return nil, fmt.Errorf("json: cannot find struct type %#x", id)
+	}
+	name, err := n.DisplayName()
+	if err != nil {
+		return nil, err
+	}
+	si := &structInfo{
+		id:   id,
+		name: name,
+		size: capnp.ObjectSize{
+			DataSize:     capnp.Size(n.StructNode().DataWordCount()) * 8,
+			PointerCount: n.StructNode().PointerCount(),
+		},
+		unions:    n.StructNode().DiscriminantCount() > 0,
+		discOff:   capnp.DataOffset(n.StructNode().DiscriminantOffset() * 2),
+		byName:    make(map[string]fieldName),
+		tagValues: make(map[string]*fieldInfo),
+		loading:   true,
+	}
+	if c.structs == nil {
+		c.structs = make(map[uint64]*structInfo)
+	}
+	c.structs[id] = si
+	ok := false
+	defer func() {
+		si.loading = false
+		if !ok {
+			delete(c.structs, id)
+		}
+	}()
+
+	if !disc.present {
+		annos, err := n.Annotations()
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i < annos.Len(); i++ {
+			if annos.At(i).Id() == jsoncp.Discriminator {
+				if disc, err = readDiscriminator(annos.At(i)); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	if disc.present {
+		si.tagName = unionName
+		if disc.hasName {
+			si.tagName = disc.name
+		}
+		if si.tagName != "" {
+			si.byName[si.tagName] = fieldName{kind: unionTagName}
+		}
+		if disc.valueName != "" {
+			si.valueName = disc.valueName
+			si.byName[si.valueName] = fieldName{kind: unionValueName}
+		}
+	}
+
+	fields, err := n.StructNode().Fields()
+	if err != nil {
+		return nil, err
+	}
+	si.fields = make([]*fieldInfo, fields.Len())
+	for i := range si.fields {
+		fi, err := c.loadField(si, fields.At(i), i)
+		if err != nil {
+			return nil, err
+		}
+		si.fields[i] = fi
+	}
+	ok = true
+	return si, nil
+}
+
+func (c *Codec) loadField(si *structInfo, f schema.Field, index int) (*fieldInfo, error) {
+	name, err := f.Name()
+	if err != nil {
+		return nil, err
+	}
+	fi := &fieldInfo{
+		owner:   si,
+		index:   index,
+		name:    name,
+		discVal: f.DiscriminantValue(),
+		union:   f.DiscriminantValue() != schema.Field_noDiscriminant,
+	}
+	switch f.Which() {
+	case schema.Field_Which_slot:
+		fi.slot = true
+		if fi.typ, err = f.Slot().Type(); err != nil {
+			return nil, err
+		}
+		if fi.def, err = f.Slot().DefaultValue(); err != nil {
+			return nil, err
+		}
+		if fi.def.IsValid() && int(fi.typ.Which()) != int(fi.def.Which()) {
+			return nil, fmt.Errorf("json: field %s.%s: default value is a %v, want %v", si.name, name, fi.def.Which(), fi.typ.Which())
+		}
+		fi.off = f.Slot().Offset()
+	case schema.Field_Which_group:
+	default:
+		return nil, fmt.Errorf("json: field %s.%s has unknown kind %v", si.name, name, f.Which())
+	}
+
+	var (
+		flattened  bool
+		subDisc    discriminator
+		isDataType = fi.slot && fi.typ.Which() == schema.Type_Which_data
+	)
+	annos, err := f.Annotations()
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < annos.Len(); i++ {
+		a := annos.At(i)
+		switch a.Id() {
+		case jsoncp.Name:
+			v, err := a.Value()
+			if err != nil {
+				return nil, err
+			}
+			if fi.name, err = v.Text(); err != nil {
+				return nil, err
+			}
+		case jsoncp.Flatten:
+			if fi.slot && fi.typ.Which() != schema.Type_Which_structType {
+				return nil, fmt.Errorf("json: field %s.%s: only struct types can be flattened", si.name, name)
+			}
+			v, err := a.Value()
+			if err != nil {
+				return nil, err
+			}
+			p, err := v.StructValue()
+			if err != nil {
+				return nil, err
+			}
+			if fi.prefix, err = jsoncp.FlattenOptions(p.Struct()).Prefix(); err != nil {
+				return nil, err
+			}
+			flattened = true
+		case jsoncp.Discriminator:
+			if fi.slot {
+				return nil, fmt.Errorf("json: field %s.%s: only unions can have a discriminator", si.name, name)
+			}
+			if subDisc, err = readDiscriminator(a); err != nil {
+				return nil, err
+			}
+		case jsoncp.Base64:
+			if !isDataType {
+				return nil, fmt.Errorf("json: field %s.%s: only Data can be marked for base64 encoding", si.name, name)
+			}
+			fi.data = dataBase64
+		case jsoncp.Hex:
+			if !isDataType {
+				return nil, fmt.Errorf("json: field %s.%s: only Data can be marked for hex encoding", si.name, name)
+			}
+			fi.data = dataHex
+		}
+	}
+
+	if !fi.slot {
+		// Groups are loaded now, even if not flattened, so that they
+		// receive their discriminator.  A flattened group's name may
+		// serve as the name of its union's tag.
+		var unionName string
+		if flattened {
+			unionName = name
+		}
+		if fi.group, err = c.loadStruct(f.Group().TypeId(), subDisc, unionName); err != nil {
+			return nil, err
+		}
+		if flattened {
+			fi.flatten = fi.group
+		}
+	} else if flattened {
+		if fi.flatten, err = c.loadStruct(fi.typ.StructType().TypeId(), discriminator{}, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	if fi.flatten != nil {
+		kind := flattenedName
+		if fi.union {
+			kind = flattenedFromUnionName
+		}
+		for sub := range fi.flatten.byName {
+			key := fi.prefix + sub
+			fn := fieldName{kind: kind, field: fi, prefixLen: len(fi.prefix)}
+			if prev, dup := si.byName[key]; dup {
+				if prev.kind != flattenedFromUnionName || kind != flattenedFromUnionName {
+					return nil, fmt.Errorf("json: %s: flattened members named %q are not mutually exclusive", si.name, key)
+				}
+				prev.alts = append(prev.alts, fn)
+				fn = prev
+			}
+			si.byName[key] = fn
+		}
+	}
+
+	fi.discName = fi.name
+	if !flattened {
+		if fi.union && si.valueName != "" {
+			fi.name = si.valueName
+		} else {
+			if _, dup := si.byName[fi.name]; dup {
+				return nil, fmt.Errorf("json: %s: duplicate member name %q", si.name, fi.name)
+			}
+			si.byName[fi.name] = fieldName{kind: normalName, field: fi}
+		}
+	}
+	if fi.union {
+		si.tagValues[fi.discName] = fi
+	}
+	return fi, nil
+}
+
+// enumNames returns the JSON names of an enum type's enumerants.
+func (c *Codec) enumNames(id uint64) ([]string, error) {
+	if names, ok := c.enums[id]; ok {
+		return names, nil
+	}
+	n, err := c.nodes.Find(id)
+	if err != nil {
+		return nil, err
+	}
+	if !n.IsValid() || n.Which() != schema.Node_Which_enum {
+		return nil, fmt.Errorf("json: cannot find enum type %#x", id)
+	}
+	enums, err := n.Enum().Enumerants()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, enums.Len())
+	for i := range names {
+		e := enums.At(i)
+		if names[i], err = e.Name(); err != nil {
+			return nil, err
+		}
+		annos, err := e.Annotations()
+		if err != nil {
+			return nil, err
+		}
+		for j := 0; j < annos.Len(); j++ {
+			if annos.At(j).Id() != jsoncp.Name {
+				continue
+			}
+			v, err := annos.At(j).Value()
+			if err != nil {
+				return nil, err
+			}
+			if names[i], err = v.Text(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if c.enums == nil {
+		c.enums = make(map[uint64][]string)
+	}
+	c.enums[id] = names
+	return names, nil
+}
+
+// unionMember returns the active member of s's union, or nil if the
+// struct has no union or the discriminant is unknown.
+func (si *structInfo) unionMember(s capnp.Struct) *fieldInfo {
+	if !si.unions {
+		return nil
+	}
+	which := s.Uint16(si.discOff)
+	for _, fi := range si.fields {
+		if fi.union && fi.discVal == which {
+			return fi
+		}
+	}
+	return nil
+}
+
+// has reports whether a field that is not in a union, or is the active
+// union member, should be written.  Only null pointers are omitted.
+func (fi *fieldInfo) has(s capnp.Struct) bool {
+	if !fi.slot {
+		return true
+	}
+	switch fi.typ.Which() {
+	case schema.Type_Which_text,
+		schema.Type_Which_data,
+		schema.Type_Which_list,
+		schema.Type_Which_structType,
+		schema.Type_Which_interface,
+		schema.Type_Which_anyPointer:
+		return s.HasPtr(uint16(fi.off))
+	default:
+		return true
+	}
+}
@@ -0,0 +1,402 @@
+package json_test
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"capnproto.org/go/capnp/v3"
+	"capnproto.org/go/capnp/v3/encoding/json"
+	"capnproto.org/go/capnp/v3/encoding/text"
+	"capnproto.org/go/capnp/v3/schemas"
+)
+
+const (
+	allTypesID    uint64 = 0xd9f29d6a1b9a3a5e
+	annotatedID   uint64 = 0xf0b1a3c5e7d9f2a4
+	annotated2ID  uint64 = 0xe1c2a3b4d5e6f708
+	annotated3ID  uint64 = 0xd2e3f4a5b6c7d809
+	flattenedID   uint64 = 0xc3d4e5f6a7b8c90a
+	dateID        uint64 = 0xa5b6c7d8e9fa0b1c
+	handlerTestID uint64 = 0x96a7b8c9dae0fb2d
+)
+
+func newTestRegistry(t *testing.T) *schemas.Registry {
+	data, err := os.ReadFile(filepath.Join("testdata", "json.capnp.out"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	reg := new(schemas.Registry)
+	err = reg.Register(&schemas.Schema{
+		Bytes: data,
+		Nodes: []uint64{
+			allTypesID,
+			0xa3e4b5c6d7e8f901, // TestEnum
+			annotatedID,
+			annotated2ID,
+			annotated3ID,
+			flattenedID,
+			0xb4c5d6e7f8a9ba0b, // TestJsonAnnotatedEnum
+			dateID,
+			handlerTestID,
+		},
+	})
+	if err != nil {
+		t.Fatalf("Adding to registry: %v", err)
+	}
+	return reg
+}
+
+// fromText builds a struct from its text format.
+func fromText(t *testing.T, reg *schemas.Registry, typeID uint64, s string) capnp.Struct {
+	t.Helper()
+	_, seg, err := capnp.NewMessage(capnp.SingleSegment(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dec := text.NewDecoder(strings.NewReader(s))
+	dec.UseRegistry(reg)
+	st, err := dec.Decode(typeID, seg)
+	if err != nil {
+		t.Fatalf("text.Decode(%q): %v", s, err)
+	}
+	return st
+}
+
+// testRoundTrip checks that in encodes to want, and that want decodes
+// to a struct that encodes to want again.
+func testRoundTrip(t *testing.T, c *json.Codec, typeID uint64, in capnp.Struct, want string) {
+	t.Helper()
+	got, err := c.Marshal(typeID, in)
+	if err != nil {
+		t.Fatal("Marshal:", err)
+	}
+	if string(got) != want {
+		t.Errorf("Marshal =\n%s\nwant\n%s", got, want)
+	}
+	_, seg, err := capnp.NewMessage(capnp.SingleSegment(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := c.Unmarshal(typeID, []byte(want), seg)
+	if err != nil {
+		t.Fatal("Unmarshal:", err)
+	}
+	got, err = c.Marshal(typeID, out)
+	if err != nil {
+		t.Fatal("Marshal(Unmarshal(...)):", err)
+	}
+	if string(got) != want {
+		t.Errorf("Marshal(Unmarshal(...)) =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestAllTypes(t *testing.T) {
+	reg := newTestRegistry(t)
+	c := new(json.Codec)
+	c.UseRegistry(reg)
+
+	tests := []struct {
+		text string
+		json string
+	}{
+		{
+			text: `()`,
+			json: `{"voidField":null,"boolField":false,"int8Field":0,"int16Field":0,"int32Field":0,"int64Field":"0",` +
+				`"uInt8Field":0,"uInt16Field":0,"uInt32Field":0,"uInt64Field":"0","float32Field":0,"float64Field":0,` +
+				`"enumField":"foo"}`,
+		},
+		{
+			text: `(boolField = true, int8Field = -123, int16Field = -12345, int32Field = -12345678,` +
+				` int64Field = -123456789012345, uInt8Field = 234, uInt16Field = 45678, uInt32Field = 3456789012,` +
+				` uInt64Field = 12345678901234567890, float32Field = 1234.5, float64Field = -1.23e47,` +
+				` textField = "foo\n\"bar\"\x01", dataField = "bar", structField = (textField = "nested"),` +
+				` enumField = qux, int32List = [1, -2], textList = ["a", "b"],` +
+				` structList = [(int8Field = 1), (int8Field = 2)], enumList = [bar, baz], dataList = ["hi"],` +
+				` int64List = [9007199254740993], float64List = [0.1, 1e100])`,
+			json: `{"voidField":null,"boolField":true,"int8Field":-123,"int16Field":-12345,"int32Field":-12345678,` +
+				`"int64Field":"-123456789012345","uInt8Field":234,"uInt16Field":45678,"uInt32Field":3456789012,` +
+				`"uInt64Field":"12345678901234567890","float32Field":1234.5,"float64Field":-1.23e+47,` +
+				`"textField":"foo\n\"bar\"\u0001","dataField":[98,97,114],` +
+				`"structField":{"voidField":null,"boolField":false,"int8Field":0,"int16Field":0,"int32Field":0,` +
+				`"int64Field":"0","uInt8Field":0,"uInt16Field":0,"uInt32Field":0,"uInt64Field":"0","float32Field":0,` +
+				`"float64Field":0,"textField":"nested","enumField":"foo"},` +
+				`"enumField":"qux","int32List":[1,-2],"textList":["a","b"],` +
+				`"structList":[{"voidField":null,"boolField":false,"int8Field":1,"int16Field":0,"int32Field":0,` +
+				`"int64Field":"0","uInt8Field":0,"uInt16Field":0,"uInt32Field":0,"uInt64Field":"0","float32Field":0,` +
+				`"float64Field":0,"enumField":"foo"},{"voidField":null,"boolField":false,"int8Field":2,"int16Field":0,` +
+				`"int32Field":0,"int64Field":"0","uInt8Field":0,"uInt16Field":0,"uInt32Field":0,"uInt64Field":"0",` +
+				`"float32Field":0,"float64Field":0,"enumField":"foo"}],` +
+				`"enumList":["bar","baz"],"dataList":[[104,105]],"int64List":["9007199254740993"],` +
+				`"float64List":[0.1,1e+100]}`,
+		},
+		{
+			text: `(float32Field = 3.14, float64Field = inf, float64List = [-inf, nan])`,
+			json: `{"voidField":null,"boolField":false,"int8Field":0,"int16Field":0,"int32Field":0,"int64Field":"0",` +
+				`"uInt8Field":0,"uInt16Field":0,"uInt32Field":0,"uInt64Field":"0","float32Field":3.1400001049041748,` +
+				`"float64Field":"Infinity","enumField":"foo","float64List":["-Infinity","NaN"]}`,
+		},
+	}
+	for _, test := range tests {
+		testRoundTrip(t, c, allTypesID, fromText(t, reg, allTypesID, test.text), test.json)
+	}
+}
+
+// TestAnnotations matches the "basic json annotations" test of the C++
+// JsonCodec.
+func TestAnnotations(t *testing.T) {
+	reg := newTestRegistry(t)
+	c := new(json.Codec)
+	c.UseRegistry(reg)
+
+	in := fromText(t, reg, annotatedID, `(
+		someField = "foo",
+		aGroup = (flatFoo = 123, flatBar = "abc", flatBaz = (hello = true), doubleFlat = (flatQux = "cba")),
+		prefixedGroup = (foo = "this is a long string", bar = 321, baz = (hello = true), morePrefix = (qux = "fed")),
+		aUnion = (bar = (barMember = 789, multiMember = "ghi")),
+		dependency = (foo = "corge"),
+		simpleGroup = (grault = "garply"),
+		enums = [qux, bar, foo, baz],
+		testBase64 = "fred",
+		testHex = "plugh",
+		bUnion = (bar = 678),
+		externalUnion = (bar = (value = "cba")),
+		unionWithVoid = (voidValue = void),
+	)`)
+	const want = `{"names-can_contain!anything Really":"foo",` +
+		`"flatFoo":123,"flatBar":"abc","renamed-flatBaz":{"hello":true},"flatQux":"cba",` +
+		`"pfx.foo":"this is a long string","pfx.renamed-bar":321,"pfx.baz":{"hello":true},"pfx.xfp.qux":"fed",` +
+		`"union-type":"renamed-bar","barMember":789,"multiMember":"ghi",` +
+		`"dependency":{"renamed-foo":"corge"},` +
+		`"simpleGroup":{"renamed-grault":"garply"},` +
+		`"enums":["qux","renamed-bar","foo","renamed-baz"],` +
+		`"testBase64":"ZnJlZA==","testHex":"706c756768",` +
+		`"bUnion":"renamed-bar","bValue":678,` +
+		`"externalUnion":{"type":"bar","value":"cba"},` +
+		`"unionWithVoid":{"type":"voidValue"}}`
+	testRoundTrip(t, c, annotatedID, in, want)
+
+	in = fromText(t, reg, annotatedID, `(
+		aUnion = (foo = (fooMember = "x", multiMember = 5)),
+		bUnion = (foo = "y"),
+		externalUnion = (foo = 7),
+		unionWithVoid = (textValue = "z"),
+	)`)
+	const want2 = `{"flatFoo":0,"renamed-flatBaz":{"hello":false},"pfx.renamed-bar":0,"pfx.baz":{"hello":false},` +
+		`"union-type":"foo","fooMember":"x","multiMember":5,` +
+		`"simpleGroup":{},"bUnion":"foo","bValue":"y",` +
+		`"externalUnion":{"type":"foo","foo":7},` +
+		`"unionWithVoid":{"type":"textValue","textValue":"z"}}`
+	testRoundTrip(t, c, annotatedID, in, want2)
+}
+
+func TestUnmarshalUnionOrder(t *testing.T) {
+	reg := newTestRegistry(t)
+	c := new(json.Codec)
+	c.UseRegistry(reg)
+
+	// The members of a variant may precede the tag that selects it.
+	const in = `{"multiMember":"ghi","bValue":678,"union-type":"renamed-bar","barMember":789,` +
+		`"bUnion":"renamed-bar","unknown":[1,2,3],"externalUnion":{"value":"cba","type":"bar"}}`
+	_, seg, err := capnp.NewMessage(capnp.SingleSegment(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := c.Unmarshal(annotatedID, []byte(in), seg)
+	if err != nil {
+		t.Fatal("Unmarshal:", err)
+	}
+	got, err := c.Marshal(annotatedID, s)
+	if err != nil {
+		t.Fatal("Marshal:", err)
+	}
+	const want = `{"flatFoo":0,"renamed-flatBaz":{"hello":false},"pfx.renamed-bar":0,"pfx.baz":{"hello":false},` +
+		`"union-type":"renamed-bar","barMember":789,"multiMember":"ghi",` +
+		`"simpleGroup":{},"bUnion":"renamed-bar","bValue":678,` +
+		`"externalUnion":{"type":"bar","value":"cba"},` +
+		`"unionWithVoid":{"type":"intValue","intValue":0}}`
+	if string(got) != want {
+		t.Errorf("Marshal(Unmarshal(%s)) =\n%s\nwant\n%s", in, got, want)
+	}
+}
+
+func TestUnmarshalValues(t *testing.T) {
+	reg := newTestRegistry(t)
+	c := new(json.Codec)
+	c.UseRegistry(reg)
+
+	tests := []struct {
+		json string
+		text string
+	}{
+		{`{"int8Field":"-5","int64Field":123,"uInt64Field":"18446744073709551615"}`,
+			`(int8Field = -5, int64Field = 123, uInt64Field = 18446744073709551615)`},
+		{`{"int32Field":1e3,"uInt16Field":2.0}`, `(int32Field = 1000, uInt16Field = 2)`},
+		{`{"float32Field":"-Infinity","float64Field":"1.5"}`, `(float32Field = -inf, float64Field = 1.5)`},
+		{`{"enumField":2,"enumList":["qux",0]}`, `(enumField = baz, enumList = [qux, foo])`},
+		{`{"textField":"é😀\/","dataField":[]}`, `(textField = "\xc3\xa9\xf0\x9f\x98\x80/", dataField = "")`},
+		{`{"textField":null,"structField":null,"int32List":null}`, `()`},
+		{` { "textList" : [ "a" , "b" ] } `, `(textList = ["a", "b"])`},
+	}
+	for _, test := range tests {
+		_, seg, err := capnp.NewMessage(capnp.SingleSegment(nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		s, err := c.Unmarshal(allTypesID, []byte(test.json), seg)
+		if err != nil {
+			t.Errorf("Unmarshal(%s): %v", test.json, err)
+			continue
+		}
+		got, err := c.Marshal(allTypesID, s)
+		if err != nil {
+			t.Errorf("Marshal(Unmarshal(%s)): %v", test.json, err)
+			continue
+		}
+		want, err := c.Marshal(allTypesID, fromText(t, reg, allTypesID, test.text))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != string(want) {
+			t.Errorf("Unmarshal(%s) = %s; want %s", test.json, got, want)
+		}
+	}
+
+	// NaN does not compare equal, so check it separately.
+	_, seg, _ := capnp.NewMessage(capnp.SingleSegment(nil))
+	s, err := c.Unmarshal(allTypesID, []byte(`{"float64Field":null,"float32Field":"NaN"}`), seg)
+	if err != nil {
+		t.Fatal("Unmarshal:", err)
+	}
+	if f := math.Float64frombits(s.Uint64(40)); !math.IsNaN(f) {
+		t.Errorf("float64Field = %v; want NaN", f)
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	reg := newTestRegistry(t)
+	c := new(json.Codec)
+	c.UseRegistry(reg)
+
+	tests := []struct {
+		json   string
+		offset int
+	}{
+		{``, 0},
+		{`{"int8Field":1`, 14},
+		{`{"int8Field":1}x`, 15},
+		{`{"int8Field":128}`, 13},
+		{`{"int8Field":1.5}`, 13},
+		{`{"uInt8Field":-1}`, 14},
+		{`{"boolField":1}`, 13},
+		{`{"textField":5}`, 13},
+		{`{"enumField":"quux"}`, 13},
+		{`{"int32List":[1,"x"]}`, 16},
+		{`{"textField":"\x"}`, 14},
+		{`{"textField":"abc`, 13},
+		{`{"structField":[]}`, 15},
+		{`{"int8Field":01}`, 14},
+		{`[]`, 0},
+		{strings.Repeat(`{"structField":`, 70), 64 * 15},
+	}
+	for _, test := range tests {
+		_, seg, err := capnp.NewMessage(capnp.SingleSegment(nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = c.Unmarshal(allTypesID, []byte(test.json), seg)
+		var se *json.SyntaxError
+		if !errors.As(err, &se) {
+			t.Errorf("Unmarshal(%.40s) = %v; want *SyntaxError", test.json, err)
+			continue
+		}
+		if se.Offset != test.offset {
+			t.Errorf("Unmarshal(%.40s) = %v; want error at offset %d", test.json, err, test.offset)
+		}
+	}
+}
+
+func TestMarshalBase64Unpadded(t *testing.T) {
+	reg := newTestRegistry(t)
+	c := new(json.Codec)
+	c.UseRegistry(reg)
+
+	_, seg, err := capnp.NewMessage(capnp.SingleSegment(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := c.Unmarshal(annotatedID, []byte(`{"testBase64":"ZnJlZA","testHex":"706C756768"}`), seg)
+	if err != nil {
+		t.Fatal("Unmarshal:", err)
+	}
+	got, err := c.Marshal(annotatedID, s)
+	if err != nil {
+		t.Fatal("Marshal:", err)
+	}
+	if want := `"testBase64":"ZnJlZA==","testHex":"706c756768"`; !strings.Contains(string(got), want) {
+		t.Errorf("Marshal = %s; want it to contain %s", got, want)
+	}
+}
+
+// dateHandler converts TestDate to and from an ISO 8601 date string.
+type dateHandler struct{}
+
+func (dateHandler) EncodeJSON(c *json.Codec, s capnp.Struct) ([]byte, error) {
+	return []byte(fmt.Sprintf(`"%04d-%02d-%02d"`, int16(s.Uint16(0)), s.Uint8(2), s.Uint8(3))), nil
+}
+
+func (dateHandler) DecodeJSON(c *json.Codec, data []byte, s capnp.Struct) error {
+	var (
+		year       int16
+		month, day uint8
+	)
+	if _, err := fmt.Sscanf(string(data), `"%04d-%02d-%02d"`, &year, &month, &day); err != nil {
+		return fmt.Errorf("parse date %s: %w", data, err)
+	}
+	s.SetUint16(0, uint16(year))
+	s.SetUint8(2, month)
+	s.SetUint8(3, day)
+	return nil
+}
+
+func TestTypeHandler(t *testing.T) {
+	reg := newTestRegistry(t)
+	c := new(json.Codec)
+	c.UseRegistry(reg)
+	c.AddTypeHandler(dateID, dateHandler{})
+
+	in := fromText(t, reg, handlerTestID, `(
+		name = "launch",
+		date = (year = 1969, month = 7, day = 16),
+		dates = [(year = 2024, month = 2, day = 29), (year = 1, month = 1, day = 1)],
+	)`)
+	const want = `{"name":"launch","date":"1969-07-16","dates":["2024-02-29","0001-01-01"]}`
+	testRoundTrip(t, c, handlerTestID, in, want)
+
+	_, seg, err := capnp.NewMessage(capnp.SingleSegment(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Unmarshal(handlerTestID, []byte(`{"date":"tomorrow"}`), seg); err == nil {
+		t.Error("Unmarshal with invalid date succeeded")
+	}
+}
+
+func TestMarshalDefaultRegistry(t *testing.T) {
+	// Types that are not registered cannot be encoded.
+	_, seg, err := capnp.NewMessage(capnp.SingleSegment(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := capnp.NewStruct(seg, capnp.ObjectSize{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := json.Marshal(allTypesID, s); err == nil {
+		t.Error("Marshal of unregistered type succeeded")
+	}
+}
@@ -0,0 +1,367 @@
+package json
+
+import (
+	"fmt"
+	"strconv"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// A SyntaxError describes malformed JSON, or JSON that does not match
+// the schema being decoded.
+type SyntaxError struct {
+	Offset int // byte offset of the offending value in the input
+	Msg    string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("json: offset %d: %s", e.Offset, e.Msg)
+}
+
+func errorAt(off int, format string, args ...any) error {
+	return &SyntaxError{Offset: off, Msg: fmt.Sprintf(format, args...)}
+}
+
+// maxDepth limits the nesting of arrays and objects, as the C++
+// JsonCodec does by default.
+const maxDepth = 64
+
+type valueKind int
+
+const (
+	nullValue valueKind = iota
+	boolValue
+	numberValue
+	stringValue
+	arrayValue
+	objectValue
+)
+
+func (k valueKind) String() string {
+	switch k {
+	case nullValue:
+		return "null"
+	case boolValue:
+		return "boolean"
+	case numberValue:
+		return "number"
+	case stringValue:
+		return "string"
+	case arrayValue:
+		return "array"
+	case objectValue:
+		return "object"
+	default:
+		return "valueKind(" + strconv.Itoa(int(k)) + ")"
+	}
+}
+
+// A value is a parsed JSON value.  Objects keep their members in input
+// order, since decoding depends on it.
+type value struct {
+	kind    valueKind
+	off     int
+	raw     []byte // the value's text in the input
+	b       bool
+	s       string // string contents, or the text of a number
+	elems   []*value
+	members []objectMember
+}
+
+type objectMember struct {
+	name string
+	val  *value
+}
+
+func (v *value) errorf(format string, args ...any) error {
+	return errorAt(v.off, format, args...)
+}
+
+type parser struct {
+	data []byte
+	pos  int
+}
+
+// parse parses data as a single JSON value.
+func parse(data []byte) (*value, error) {
+	p := &parser{data: data}
+	v, err := p.value(0)
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.data) {
+		return nil, errorAt(p.pos, "unexpected %q after top-level value", p.data[p.pos])
+	}
+	return v, nil
+}
+
+func (p *parser) skipSpace() {
+	for p.pos < len(p.data) {
+		switch p.data[p.pos] {
+		case ' ', '\t', '\n', '\r':
+			p.pos++
+		default:
+			return
+		}
+	}
+}
+
+func (p *parser) value(depth int) (*value, error) {
+	p.skipSpace()
+	if p.pos >= len(p.data) {
+		return nil, errorAt(p.pos, "unexpected end of input")
+	}
+	v := &value{off: p.pos}
+	var err error
+	switch b := p.data[p.pos]; {
+	case b == '{':
+		if depth >= maxDepth {
+			return nil, errorAt(p.pos, "nesting exceeds %d levels", maxDepth)
+		}
+		v.kind = objectValue
+		err = p.object(v, depth+1)
+	case b == '[':
+		if depth >= maxDepth {
+			return nil, errorAt(p.pos, "nesting exceeds %d levels", maxDepth)
+		}
+		v.kind = arrayValue
+		err = p.array(v, depth+1)
+	case b == '"':
+		v.kind = stringValue
+		v.s, err = p.string()
+	case b == '-' || '0' <= b && b <= '9':
+		v.kind = numberValue
+		v.s, err = p.number()
+	case b == 't':
+		v.kind, v.b = boolValue, true
+		err = p.literal("true")
+	case b == 'f':
+		v.kind = boolValue
+		err = p.literal("false")
+	case b == 'n':
+		v.kind = nullValue
+		err = p.literal("null")
+	default:
+		return nil, errorAt(p.pos, "unexpected %q", b)
+	}
+	if err != nil {
+		return nil, err
+	}
+	v.raw = p.data[v.off:p.pos]
+	return v, nil
+}
+
+func (p *parser) literal(lit string) error {
+	if len(p.data)-p.pos < len(lit) || string(p.data[p.pos:p.pos+len(lit)]) != lit {
+		return errorAt(p.pos, "invalid literal")
+	}
+	p.pos += len(lit)
+	return nil
+}
+
+func (p *parser) object(v *value, depth int) error {
+	p.pos++ // '{'
+	p.skipSpace()
+	if p.pos < len(p.data) && p.data[p.pos] == '}' {
+		p.pos++
+		return nil
+	}
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.data) || p.data[p.pos] != '"' {
+			return errorAt(p.pos, "expected member name")
+		}
+		name, err := p.string()
+		if err != nil {
+			return err
+		}
+		p.skipSpace()
+		if p.pos >= len(p.data) || p.data[p.pos] != ':' {
+			return errorAt(p.pos, "expected ':' after member name")
+		}
+		p.pos++
+		val, err := p.value(depth)
+		if err != nil {
+			return err
+		}
+		v.members = append(v.members, objectMember{name: name, val: val})
+		p.skipSpace()
+		if p.pos >= len(p.data) {
+			return errorAt(p.pos, "unterminated object")
+		}
+		switch p.data[p.pos] {
+		case ',':
+			p.pos++
+		case '}':
+			p.pos++
+			return nil
+		default:
+			return errorAt(p.pos, "expected ',' or '}' in object")
+		}
+	}
+}
+
+func (p *parser) array(v *value, depth int) error {
+	p.pos++ // '['
+	p.skipSpace()
+	if p.pos < len(p.data) && p.data[p.pos] == ']' {
+		p.pos++
+		return nil
+	}
+	for {
+		elem, err := p.value(depth)
+		if err != nil {
+			return err
+		}
+		v.elems = append(v.elems, elem)
+		p.skipSpace()
+		if p.pos >= len(p.data) {
+			return errorAt(p.pos, "unterminated array")
+		}
+		switch p.data[p.pos] {
+		case ',':
+			p.pos++
+		case ']':
+			p.pos++
+			return nil
+		default:
+			return errorAt(p.pos, "expected ',' or ']' in array")
+		}
+	}
+}
+
+func (p *parser) number() (string, error) {
+	start := p.pos
+	if p.data[p.pos] == '-' {
+		p.pos++
+	}
+	switch {
+	case p.pos < len(p.data) && p.data[p.pos] == '0':
+		p.pos++
+	case p.pos < len(p.data) && '1' <= p.data[p.pos] && p.data[p.pos] <= '9':
+		p.digits()
+	default:
+		return "", errorAt(start, "invalid number")
+	}
+	if p.pos < len(p.data) && p.data[p.pos] == '.' {
+		p.pos++
+		if p.digits() == 0 {
+			return "", errorAt(start, "invalid number")
+		}
+	}
+	if p.pos < len(p.data) && (p.data[p.pos] == 'e' || p.data[p.pos] == 'E') {
+		p.pos++
+		if p.pos < len(p.data) && (p.data[p.pos] == '+' || p.data[p.pos] == '-') {
+			p.pos++
+		}
+		if p.digits() == 0 {
+			return "", errorAt(start, "invalid number")
+		}
+	}
+	return string(p.data[start:p.pos]), nil
+}
+
+func (p *parser) digits() int {
+	n := 0
+	for p.pos < len(p.data) && '0' <= p.data[p.pos] && p.data[p.pos] <= '9' {
+		p.pos++
+		n++
+	}
+	return n
+}
+
+func (p *parser) string() (string, error) {
+	start := p.pos
+	p.pos++ // '"'
+	var buf []byte
+	for {
+		if p.pos >= len(p.data) {
+			return "", errorAt(start, "unterminated string")
+		}
+		switch b := p.data[p.pos]; {
+		case b == '"':
+			p.pos++
+			return string(buf), nil
+		case b == '\\':
+			if err := p.escape(&buf); err != nil {
+				return "", err
+			}
+		case b < 0x20:
+			return "", errorAt(p.pos, "control character in string")
+		default:
+			buf = append(buf, b)
+			p.pos++
+		}
+	}
+}
+
+func (p *parser) escape(buf *[]byte) error {
+	start := p.pos
+	p.pos++ // '\\'
+	if p.pos >= len(p.data) {
+		return errorAt(start, "unterminated string")
+	}
+	b := p.data[p.pos]
+	p.pos++
+	switch b {
+	case '"', '\\', '/':
+		*buf = append(*buf, b)
+	case 'b':
+		*buf = append(*buf, '\b')
+	case 'f':
+		*buf = append(*buf, '\f')
+	case 'n':
+		*buf = append(*buf, '\n')
+	case 'r':
+		*buf = append(*buf, '\r')
+	case 't':
+		*buf = append(*buf, '\t')
+	case 'u':
+		r, err := p.hex4(start)
+		if err != nil {
+			return err
+		}
+		if utf16.IsSurrogate(r) {
+			// Combine with a following low surrogate, if any.
+			if p.pos+1 < len(p.data) && p.data[p.pos] == '\\' && p.data[p.pos+1] == 'u' {
+				save := p.pos
+				p.pos += 2
+				r2, err := p.hex4(save)
+				if err != nil {
+					return err
+				}
+				if c := utf16.DecodeRune(r, r2); c != utf8.RuneError {
+					r = c
+				} else {
+					p.pos = save
+				}
+			}
+		}
+		*buf = utf8.AppendRune(*buf, r)
+	default:
+		return errorAt(start, "invalid escape %q", "\\"+string(b))
+	}
+	return nil
+}
+
+func (p *parser) hex4(start int) (rune, error) {
+	if len(p.data)-p.pos < 4 {
+		return 0, errorAt(start, "invalid \\u escape")
+	}
+	var r rune
+	for _, b := range p.data[p.pos : p.pos+4] {
+		switch {
+		case '0' <= b && b <= '9':
+			r = r<<4 | rune(b-'0')
+		case 'a' <= b && b <= 'f':
+			r = r<<4 | rune(b-'a'+10)
+		case 'A' <= b && b <= 'F':
+			r = r<<4 | rune(b-'A'+10)
+		default:
+			return 0, errorAt(start, "invalid \\u escape")
+		}
+	}
+	p.pos += 4
+	return r, nil
+}
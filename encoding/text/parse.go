@@ -0,0 +1,539 @@
+package text
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// A SyntaxError describes text that could not be decoded, either
+// because it is malformed or because it does not match the schema.
+type SyntaxError struct {
+	Line   int // 1-based line number
+	Column int // 1-based column, in bytes
+	Msg    string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%d:%d: %s", e.Line, e.Column, e.Msg)
+}
+
+// A position is a location in the input.
+type position struct {
+	line, col int
+}
+
+func (pos position) errorf(format string, args ...any) error {
+	return &SyntaxError{Line: pos.line, Column: pos.col, Msg: fmt.Sprintf(format, args...)}
+}
+
+type tokenKind int
+
+const (
+	eofToken    tokenKind = iota
+	punctToken            // one of ( ) [ ] = ,
+	identToken            // void, true, enumerant names, ...
+	numberToken           // integer or float literal, possibly signed
+	stringToken           // "..."
+	dataToken             // 0x"..."
+	markerToken           // <...>, as written by the Encoder
+)
+
+type token struct {
+	kind tokenKind
+	pos  position
+	text string // decoded bytes of string and data tokens
+}
+
+func (tok token) String() string {
+	switch tok.kind {
+	case eofToken:
+		return "end of input"
+	case stringToken:
+		return "string"
+	case dataToken:
+		return "data literal"
+	default:
+		return fmt.Sprintf("%q", tok.text)
+	}
+}
+
+// A scanner splits its input into tokens.
+type scanner struct {
+	r   *bufio.Reader
+	pos position // position of the next byte
+
+	peeked bool
+	tok    token
+}
+
+func newScanner(r io.Reader) *scanner {
+	return &scanner{
+		r:   bufio.NewReader(r),
+		pos: position{line: 1, col: 1},
+	}
+}
+
+// readByte returns the next byte, or 0 and io.EOF.
+func (s *scanner) readByte() (byte, error) {
+	b, err := s.r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	if b == '\n' {
+		s.pos.line++
+		s.pos.col = 1
+	} else {
+		s.pos.col++
+	}
+	return b, nil
+}
+
+// peekByte returns the next byte without consuming it, or 0 and io.EOF.
+func (s *scanner) peekByte() (byte, error) {
+	p, err := s.r.Peek(1)
+	if err != nil {
+		return 0, err
+	}
+	return p[0], nil
+}
+
+// peek returns the next token without consuming it.
+func (s *scanner) peek() (token, error) {
+	if s.peeked {
+		return s.tok, nil
+	}
+	tok, err := s.scan()
+	if err != nil {
+		return token{}, err
+	}
+	s.tok, s.peeked = tok, true
+	return tok, nil
+}
+
+// next consumes the next token.
+func (s *scanner) next() (token, error) {
+	tok, err := s.peek()
+	s.peeked = false
+	return tok, err
+}
+
+func (s *scanner) scan() (token, error) {
+	if err := s.skipSpace(); err != nil {
+		return token{}, err
+	}
+	pos := s.pos
+	b, err := s.readByte()
+	if err == io.EOF {
+		return token{kind: eofToken, pos: pos}, nil
+	}
+	if err != nil {
+		return token{}, err
+	}
+	switch {
+	case strings.IndexByte("()[]=,", b) >= 0:
+		return token{kind: punctToken, pos: pos, text: string(b)}, nil
+	case b == '"':
+		text, err := s.scanString(pos)
+		return token{kind: stringToken, pos: pos, text: text}, err
+	case b == '<':
+		text, err := s.scanWhile(isMarkerByte)
+		if err != nil {
+			return token{}, err
+		}
+		if c, err := s.readByte(); err != nil || c != '>' {
+			return token{}, pos.errorf("unterminated <...>")
+		}
+		return token{kind: markerToken, pos: pos, text: "<" + text + ">"}, nil
+	case b == '0':
+		if c, _ := s.peekByte(); c == 'x' || c == 'X' {
+			s.readByte()
+			if c, _ := s.peekByte(); c == '"' {
+				s.readByte()
+				text, err := s.scanData(pos)
+				return token{kind: dataToken, pos: pos, text: text}, err
+			}
+			rest, err := s.scanWhile(isWordByte)
+			return token{kind: numberToken, pos: pos, text: "0" + string(c) + rest}, err
+		}
+		fallthrough
+	case isDigit(b) || b == '-' || b == '+':
+		rest, err := s.scanNumber()
+		if err != nil {
+			return token{}, err
+		}
+		text := string(b) + rest
+		if text == "-" || text == "+" {
+			return token{}, pos.errorf("expected number after %q", text)
+		}
+		return token{kind: numberToken, pos: pos, text: text}, nil
+	case isIdentStart(b):
+		rest, err := s.scanWhile(isWordByte)
+		if err != nil {
+			return token{}, err
+		}
+		return token{kind: identToken, pos: pos, text: string(b) + rest}, nil
+	default:
+		return token{}, pos.errorf("unexpected character %q", b)
+	}
+}
+
+// skipSpace skips whitespace and comments.
+func (s *scanner) skipSpace() error {
+	for {
+		b, err := s.peekByte()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		switch {
+		case b == ' ' || b == '\t' || b == '\n' || b == '\r':
+			s.readByte()
+		case b == '#':
+			for b != '\n' {
+				if b, err = s.readByte(); err == io.EOF {
+					return nil
+				} else if err != nil {
+					return err
+				}
+			}
+		default:
+			return nil
+		}
+	}
+}
+
+// scanWhile consumes bytes as long as f reports true for them.
+func (s *scanner) scanWhile(f func(byte) bool) (string, error) {
+	var sb strings.Builder
+	for {
+		b, err := s.peekByte()
+		if err == io.EOF || err == nil && !f(b) {
+			return sb.String(), nil
+		}
+		if err != nil {
+			return "", err
+		}
+		s.readByte()
+		sb.WriteByte(b)
+	}
+}
+
+// scanNumber consumes the rest of a number, which may include an
+// exponent with a sign.
+func (s *scanner) scanNumber() (string, error) {
+	var sb strings.Builder
+	for {
+		b, err := s.peekByte()
+		if err == io.EOF {
+			return sb.String(), nil
+		}
+		if err != nil {
+			return "", err
+		}
+		str := sb.String()
+		isExpSign := (b == '-' || b == '+') && len(str) > 0 &&
+			(str[len(str)-1] == 'e' || str[len(str)-1] == 'E')
+		if !isWordByte(b) && b != '.' && !isExpSign {
+			return str, nil
+		}
+		s.readByte()
+		sb.WriteByte(b)
+	}
+}
+
+// scanString consumes the rest of a string literal, after the opening
+// quote, and returns its contents.
+func (s *scanner) scanString(start position) (string, error) {
+	var sb strings.Builder
+	for {
+		pos := s.pos
+		b, err := s.readByte()
+		if err == io.EOF {
+			return "", start.errorf("unterminated string")
+		}
+		if err != nil {
+			return "", err
+		}
+		switch b {
+		case '"':
+			return sb.String(), nil
+		case '\n':
+			return "", start.errorf("unterminated string")
+		case '\\':
+			c, err := s.readEscape(pos)
+			if err != nil {
+				return "", err
+			}
+			sb.WriteByte(c)
+		default:
+			sb.WriteByte(b)
+		}
+	}
+}
+
+// readEscape consumes an escape sequence, after the backslash.
+func (s *scanner) readEscape(pos position) (byte, error) {
+	b, err := s.readByte()
+	if err != nil {
+		return 0, pos.errorf("unterminated escape sequence")
+	}
+	switch b {
+	case 'a':
+		return '\a', nil
+	case 'b':
+		return '\b', nil
+	case 'f':
+		return '\f', nil
+	case 'n':
+		return '\n', nil
+	case 'r':
+		return '\r', nil
+	case 't':
+		return '\t', nil
+	case 'v':
+		return '\v', nil
+	case '\'', '"', '\\', '?':
+		return b, nil
+	case 'x':
+		var v byte
+		for i := 0; i < 2; i++ {
+			c, err := s.readByte()
+			if err != nil || !isHexDigit(c) {
+				return 0, pos.errorf("invalid \\x escape")
+			}
+			v = v<<4 | hexValue(c)
+		}
+		return v, nil
+	default:
+		if b >= '0' && b <= '7' {
+			v := int(b - '0')
+			for i := 0; i < 2; i++ {
+				c, err := s.peekByte()
+				if err != nil || c < '0' || c > '7' {
+					break
+				}
+				s.readByte()
+				v = v<<3 | int(c-'0')
+			}
+			if v > 0xff {
+				return 0, pos.errorf("octal escape out of range")
+			}
+			return byte(v), nil
+		}
+		return 0, pos.errorf("unknown escape sequence \\%c", b)
+	}
+}
+
+// scanData consumes the rest of a data literal, after 0x", and returns
+// its contents.  Whitespace between hex digits is ignored.
+func (s *scanner) scanData(start position) (string, error) {
+	var sb strings.Builder
+	var (
+		half byte
+		odd  bool
+	)
+	for {
+		pos := s.pos
+		b, err := s.readByte()
+		if err == io.EOF {
+			return "", start.errorf("unterminated data literal")
+		}
+		if err != nil {
+			return "", err
+		}
+		switch {
+		case b == '"':
+			if odd {
+				return "", pos.errorf("odd number of hex digits in data literal")
+			}
+			return sb.String(), nil
+		case b == ' ' || b == '\t' || b == '\n' || b == '\r':
+		case isHexDigit(b):
+			if odd {
+				sb.WriteByte(half<<4 | hexValue(b))
+			} else {
+				half = hexValue(b)
+			}
+			odd = !odd
+		default:
+			return "", pos.errorf("invalid character %q in data literal", b)
+		}
+	}
+}
+
+func isDigit(b byte) bool {
+	return '0' <= b && b <= '9'
+}
+
+func isHexDigit(b byte) bool {
+	return isDigit(b) || 'a' <= b && b <= 'f' || 'A' <= b && b <= 'F'
+}
+
+func hexValue(b byte) byte {
+	switch {
+	case isDigit(b):
+		return b - '0'
+	case 'a' <= b && b <= 'f':
+		return b - 'a' + 10
+	default:
+		return b - 'A' + 10
+	}
+}
+
+func isIdentStart(b byte) bool {
+	return 'a' <= b && b <= 'z' || 'A' <= b && b <= 'Z' || b == '_'
+}
+
+func isWordByte(b byte) bool {
+	return isIdentStart(b) || isDigit(b)
+}
+
+func isMarkerByte(b byte) bool {
+	return b != '>' && b != '\n' && b != '"'
+}
+
+// A value is a parsed, but not yet typed, value.
+type value struct {
+	tok    token    // first token of the value
+	fields []field  // for struct values
+	elems  []*value // for list values
+}
+
+type field struct {
+	name token
+	val  *value
+}
+
+func (v *value) isStruct() bool {
+	return v.tok.kind == punctToken && v.tok.text == "("
+}
+
+func (v *value) isList() bool {
+	return v.tok.kind == punctToken && v.tok.text == "["
+}
+
+// isIdent reports whether v is the given identifier.
+func (v *value) isIdent(name string) bool {
+	return v.tok.kind == identToken && v.tok.text == name
+}
+
+// maxDepth limits the nesting of structs and lists, as a Message's
+// default depth limit does.
+const maxDepth = 64
+
+// parseValue parses a single value, which is nested in depth structs
+// and lists.
+func (s *scanner) parseValue(depth int) (*value, error) {
+	tok, err := s.next()
+	if err != nil {
+		return nil, err
+	}
+	v := &value{tok: tok}
+	switch tok.kind {
+	case eofToken:
+		return nil, tok.pos.errorf("unexpected end of input")
+	case identToken, numberToken, stringToken, dataToken, markerToken:
+		return v, nil
+	}
+	if tok.kind == punctToken && (tok.text == "(" || tok.text == "[") && depth >= maxDepth {
+		return nil, tok.pos.errorf("nesting exceeds %d levels", maxDepth)
+	}
+	switch tok.text {
+	case "(":
+		first := true
+		for {
+			if done, err := s.parseSeparator(")", &first); err != nil || done {
+				return v, err
+			}
+			name, err := s.next()
+			if err != nil {
+				return nil, err
+			}
+			if name.kind != identToken {
+				return nil, name.pos.errorf("expected field name, found %v", name)
+			}
+			if err := s.expect("="); err != nil {
+				return nil, err
+			}
+			val, err := s.parseValue(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			v.fields = append(v.fields, field{name: name, val: val})
+		}
+	case "[":
+		first := true
+		for {
+			if done, err := s.parseSeparator("]", &first); err != nil || done {
+				return v, err
+			}
+			elem, err := s.parseValue(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			v.elems = append(v.elems, elem)
+		}
+	default:
+		return nil, tok.pos.errorf("unexpected %v", tok)
+	}
+}
+
+// parseSeparator consumes the separator before an element of a struct
+// or list, and reports whether the closing token was consumed instead.
+// A trailing comma is permitted.
+func (s *scanner) parseSeparator(close string, first *bool) (done bool, err error) {
+	tok, err := s.peek()
+	if err != nil {
+		return false, err
+	}
+	if tok.kind == punctToken && tok.text == close {
+		s.next()
+		return true, nil
+	}
+	if *first {
+		*first = false
+		return false, nil
+	}
+	if tok.kind != punctToken || tok.text != "," {
+		return false, tok.pos.errorf("expected \",\" or %q, found %v", close, tok)
+	}
+	s.next()
+	tok, err = s.peek()
+	if err != nil {
+		return false, err
+	}
+	if tok.kind == punctToken && tok.text == close {
+		s.next()
+		return true, nil
+	}
+	return false, nil
+}
+
+// expect consumes a punctuation token.
+func (s *scanner) expect(punct string) error {
+	tok, err := s.next()
+	if err != nil {
+		return err
+	}
+	if tok.kind != punctToken || tok.text != punct {
+		return tok.pos.errorf("expected %q, found %v", punct, tok)
+	}
+	return nil
+}
+
+// parseTop parses a top-level value, returning io.EOF if the input is
+// exhausted.
+func (s *scanner) parseTop() (*value, error) {
+	tok, err := s.peek()
+	if err != nil {
+		return nil, err
+	}
+	if tok.kind == eofToken {
+		return nil, io.EOF
+	}
+	return s.parseValue(0)
+}
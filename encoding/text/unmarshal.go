@@ -0,0 +1,616 @@
+package text
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"capnproto.org/go/capnp/v3"
+	"capnproto.org/go/capnp/v3/internal/nodemap"
+	"capnproto.org/go/capnp/v3/internal/schema"
+	"capnproto.org/go/capnp/v3/schemas"
+)
+
+// Unmarshal parses the text representation of a struct, as written by
+// Marshal, and returns a new struct allocated in seg.  Fields that are
+// not present in the text have their default values.
+func Unmarshal(typeID uint64, text string, seg *capnp.Segment) (capnp.Struct, error) {
+	return NewDecoder(strings.NewReader(text)).Decode(typeID, seg)
+}
+
+// UnmarshalList parses the text representation of a struct list, as
+// written by MarshalList, and returns a new list allocated in seg.
+func UnmarshalList(typeID uint64, text string, seg *capnp.Segment) (capnp.List, error) {
+	return NewDecoder(strings.NewReader(text)).DecodeList(typeID, seg)
+}
+
+// A Decoder reads the text format of Cap'n Proto messages from an input
+// stream.  The stream may contain several values, separated by
+// whitespace.  Comments start with '#' and run to the end of the line.
+//
+// Errors in the text are reported as a *SyntaxError.
+type Decoder struct {
+	s     *scanner
+	nodes nodemap.Map
+}
+
+// NewDecoder returns a new decoder that reads from r.  The decoder
+// buffers its input, so it may read past the values it decodes.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{s: newScanner(r)}
+}
+
+// UseRegistry changes the registry that the decoder consults for
+// schemas from the default registry.
+func (dec *Decoder) UseRegistry(reg *schemas.Registry) {
+	dec.nodes.UseRegistry(reg)
+}
+
+// Decode reads the text representation of a struct from the stream and
+// returns a new struct allocated in seg.  At the end of the stream,
+// Decode returns io.EOF.
+func (dec *Decoder) Decode(typeID uint64, seg *capnp.Segment) (capnp.Struct, error) {
+	v, err := dec.s.parseTop()
+	if err != nil {
+		return capnp.Struct{}, err
+	}
+	n, err := dec.findStruct(typeID)
+	if err != nil {
+		return capnp.Struct{}, err
+	}
+	if !v.isStruct() {
+		return capnp.Struct{}, v.tok.pos.errorf("expected struct, found %v", v.tok)
+	}
+	s, err := capnp.NewStruct(seg, structSize(n))
+	if err != nil {
+		return capnp.Struct{}, err
+	}
+	if err := dec.unmarshalStruct(n, s, v); err != nil {
+		return capnp.Struct{}, err
+	}
+	return s, nil
+}
+
+// DecodeList reads the text representation of a struct list from the
+// stream and returns a new list allocated in seg.  At the end of the
+// stream, DecodeList returns io.EOF.
+func (dec *Decoder) DecodeList(typeID uint64, seg *capnp.Segment) (capnp.List, error) {
+	v, err := dec.s.parseTop()
+	if err != nil {
+		return capnp.List{}, err
+	}
+	_, tseg, _ := capnp.NewMessage(capnp.SingleSegment(nil))
+	typ, _ := schema.NewRootType(tseg)
+	typ.SetStructType()
+	typ.StructType().SetTypeId(typeID)
+	p, err := dec.unmarshalList(typ, seg, v)
+	if err != nil {
+		return capnp.List{}, err
+	}
+	return p.List(), nil
+}
+
+func (dec *Decoder) findStruct(typeID uint64) (schema.Node, error) {
+	n, err := dec.nodes.Find(typeID)
+	if err != nil {
+		return schema.Node{}, err
+	}
+	if !n.IsValid() || n.Which() != schema.Node_Which_structNode {
+		return schema.Node{}, fmt.Errorf("cannot find struct type %#x", typeID)
+	}
+	return n, nil
+}
+
+func structSize(n schema.Node) capnp.ObjectSize {
+	return capnp.ObjectSize{
+		DataSize:     capnp.Size(n.StructNode().DataWordCount()) * 8,
+		PointerCount: n.StructNode().PointerCount(),
+	}
+}
+
+// unmarshalStruct sets the fields of s, a struct or group of the type
+// described by n, from a struct value.
+func (dec *Decoder) unmarshalStruct(n schema.Node, s capnp.Struct, v *value) error {
+	list, err := n.StructNode().Fields()
+	if err != nil {
+		return err
+	}
+	fields := make(map[string]schema.Field, list.Len())
+	for i := 0; i < list.Len(); i++ {
+		f := list.At(i)
+		name, err := f.Name()
+		if err != nil {
+			return err
+		}
+		fields[name] = f
+	}
+
+	seen := make(map[string]bool, len(v.fields))
+	var unionField *field
+	for i := range v.fields {
+		fv := &v.fields[i]
+		name := fv.name.text
+		f, ok := fields[name]
+		if !ok {
+			return fv.name.pos.errorf("no field %s in struct", name)
+		}
+		if seen[name] {
+			return fv.name.pos.errorf("field %s set more than once", name)
+		}
+		seen[name] = true
+		if dv := f.DiscriminantValue(); dv != schema.Field_noDiscriminant {
+			if unionField != nil {
+				return fv.name.pos.errorf("field %s conflicts with %s: only one union member may be set", name, unionField.name.text)
+			}
+			unionField = fv
+			s.SetUint16(capnp.DataOffset(n.StructNode().DiscriminantOffset()*2), dv)
+		}
+		switch f.Which() {
+		case schema.Field_Which_slot:
+			if err := dec.unmarshalField(s, f, fv.val); err != nil {
+				return err
+			}
+		case schema.Field_Which_group:
+			gn, err := dec.findStruct(f.Group().TypeId())
+			if err != nil {
+				return err
+			}
+			if !fv.val.isStruct() {
+				return fv.val.tok.pos.errorf("expected group value for field %s, found %v", name, fv.val.tok)
+			}
+			if err := dec.unmarshalStruct(gn, s, fv.val); err != nil {
+				return err
+			}
+		default:
+			return fv.name.pos.errorf("unknown kind of field %s", name)
+		}
+	}
+	return nil
+}
+
+// unmarshalField sets a slot field of s.  Primitive values are stored
+// XORed with the field's default value, as the Encoder expects.
+func (dec *Decoder) unmarshalField(s capnp.Struct, f schema.Field, v *value) error {
+	typ, err := f.Slot().Type()
+	if err != nil {
+		return err
+	}
+	dv, err := f.Slot().DefaultValue()
+	if err != nil {
+		return err
+	}
+	if dv.IsValid() && int(typ.Which()) != int(dv.Which()) {
+		name, _ := f.Name()
+		return fmt.Errorf("unmarshal field %s: default value is a %v, want %v", name, dv.Which(), typ.Which())
+	}
+	off := f.Slot().Offset()
+	switch typ.Which() {
+	case schema.Type_Which_void:
+		if !v.isIdent(voidMarker) {
+			return v.tok.pos.errorf("expected void, found %v", v.tok)
+		}
+	case schema.Type_Which_bool:
+		b, err := parseBool(v)
+		if err != nil {
+			return err
+		}
+		s.SetBit(capnp.BitOffset(off), b != dv.Bool())
+	case schema.Type_Which_int8:
+		i, err := parseInt(v, 8)
+		if err != nil {
+			return err
+		}
+		s.SetUint8(capnp.DataOffset(off), uint8(i)^uint8(dv.Int8()))
+	case schema.Type_Which_int16:
+		i, err := parseInt(v, 16)
+		if err != nil {
+			return err
+		}
+		s.SetUint16(capnp.DataOffset(off*2), uint16(i)^uint16(dv.Int16()))
+	case schema.Type_Which_int32:
+		i, err := parseInt(v, 32)
+		if err != nil {
+			return err
+		}
+		s.SetUint32(capnp.DataOffset(off*4), uint32(i)^uint32(dv.Int32()))
+	case schema.Type_Which_int64:
+		i, err := parseInt(v, 64)
+		if err != nil {
+			return err
+		}
+		s.SetUint64(capnp.DataOffset(off*8), uint64(i)^uint64(dv.Int64()))
+	case schema.Type_Which_uint8:
+		i, err := parseUint(v, 8)
+		if err != nil {
+			return err
+		}
+		s.SetUint8(capnp.DataOffset(off), uint8(i)^dv.Uint8())
+	case schema.Type_Which_uint16:
+		i, err := parseUint(v, 16)
+		if err != nil {
+			return err
+		}
+		s.SetUint16(capnp.DataOffset(off*2), uint16(i)^dv.Uint16())
+	case schema.Type_Which_uint32:
+		i, err := parseUint(v, 32)
+		if err != nil {
+			return err
+		}
+		s.SetUint32(capnp.DataOffset(off*4), uint32(i)^dv.Uint32())
+	case schema.Type_Which_uint64:
+		i, err := parseUint(v, 64)
+		if err != nil {
+			return err
+		}
+		s.SetUint64(capnp.DataOffset(off*8), i^dv.Uint64())
+	case schema.Type_Which_float32:
+		x, err := parseFloat(v, 32)
+		if err != nil {
+			return err
+		}
+		s.SetUint32(capnp.DataOffset(off*4), math.Float32bits(float32(x))^math.Float32bits(dv.Float32()))
+	case schema.Type_Which_float64:
+		x, err := parseFloat(v, 64)
+		if err != nil {
+			return err
+		}
+		s.SetUint64(capnp.DataOffset(off*8), math.Float64bits(x)^math.Float64bits(dv.Float64()))
+	case schema.Type_Which_enum:
+		e, err := dec.parseEnum(typ.Enum().TypeId(), v)
+		if err != nil {
+			return err
+		}
+		s.SetUint16(capnp.DataOffset(off*2), e^dv.Enum())
+	default:
+		p, err := dec.unmarshalPtr(typ, s.Segment(), v)
+		if err != nil {
+			return err
+		}
+		return s.SetPtr(uint16(off), p)
+	}
+	return nil
+}
+
+// unmarshalPtr returns a pointer of the given type, allocated in seg.
+// The null identifier yields a null pointer.
+func (dec *Decoder) unmarshalPtr(typ schema.Type, seg *capnp.Segment, v *value) (capnp.Ptr, error) {
+	if v.isIdent(interfaceNullMarker) {
+		return capnp.Ptr{}, nil
+	}
+	switch typ.Which() {
+	case schema.Type_Which_text:
+		if v.tok.kind != stringToken {
+			return capnp.Ptr{}, v.tok.pos.errorf("expected string, found %v", v.tok)
+		}
+		t, err := capnp.NewText(seg, v.tok.text)
+		return t.ToPtr(), err
+	case schema.Type_Which_data:
+		if v.tok.kind != stringToken && v.tok.kind != dataToken {
+			return capnp.Ptr{}, v.tok.pos.errorf("expected data, found %v", v.tok)
+		}
+		d, err := capnp.NewData(seg, []byte(v.tok.text))
+		return d.ToPtr(), err
+	case schema.Type_Which_structType:
+		n, err := dec.findStruct(typ.StructType().TypeId())
+		if err != nil {
+			return capnp.Ptr{}, err
+		}
+		if !v.isStruct() {
+			return capnp.Ptr{}, v.tok.pos.errorf("expected struct, found %v", v.tok)
+		}
+		s, err := capnp.NewStruct(seg, structSize(n))
+		if err != nil {
+			return capnp.Ptr{}, err
+		}
+		if err := dec.unmarshalStruct(n, s, v); err != nil {
+			return capnp.Ptr{}, err
+		}
+		return s.ToPtr(), nil
+	case schema.Type_Which_list:
+		elem, err := typ.List().ElementType()
+		if err != nil {
+			return capnp.Ptr{}, err
+		}
+		return dec.unmarshalList(elem, seg, v)
+	case schema.Type_Which_interface:
+		return capnp.Ptr{}, v.tok.pos.errorf("cannot decode capability; only null is allowed")
+	case schema.Type_Which_anyPointer:
+		return capnp.Ptr{}, v.tok.pos.errorf("cannot decode opaque pointer; only null is allowed")
+	default:
+		return capnp.Ptr{}, fmt.Errorf("unknown field type %v", typ.Which())
+	}
+}
+
+// unmarshalList returns a list with elements of the given type,
+// allocated in seg.
+func (dec *Decoder) unmarshalList(elem schema.Type, seg *capnp.Segment, v *value) (capnp.Ptr, error) {
+	if v.isIdent(interfaceNullMarker) {
+		return capnp.Ptr{}, nil
+	}
+	if !v.isList() {
+		return capnp.Ptr{}, v.tok.pos.errorf("expected list, found %v", v.tok)
+	}
+	n := int32(len(v.elems))
+	switch elem.Which() {
+	case schema.Type_Which_void:
+		for _, e := range v.elems {
+			if !e.isIdent(voidMarker) {
+				return capnp.Ptr{}, e.tok.pos.errorf("expected void, found %v", e.tok)
+			}
+		}
+		return capnp.NewVoidList(seg, n).ToPtr(), nil
+	case schema.Type_Which_bool:
+		l, err := capnp.NewBitList(seg, n)
+		if err != nil {
+			return capnp.Ptr{}, err
+		}
+		for i, e := range v.elems {
+			b, err := parseBool(e)
+			if err != nil {
+				return capnp.Ptr{}, err
+			}
+			l.Set(i, b)
+		}
+		return l.ToPtr(), nil
+	case schema.Type_Which_int8:
+		l, err := capnp.NewInt8List(seg, n)
+		if err != nil {
+			return capnp.Ptr{}, err
+		}
+		for i, e := range v.elems {
+			x, err := parseInt(e, 8)
+			if err != nil {
+				return capnp.Ptr{}, err
+			}
+			l.Set(i, int8(x))
+		}
+		return l.ToPtr(), nil
+	case schema.Type_Which_int16:
+		l, err := capnp.NewInt16List(seg, n)
+		if err != nil {
+			return capnp.Ptr{}, err
+		}
+		for i, e := range v.elems {
+			x, err := parseInt(e, 16)
+			if err != nil {
+				return capnp.Ptr{}, err
+			}
+			l.Set(i, int16(x))
+		}
+		return l.ToPtr(), nil
+	case schema.Type_Which_int32:
+		l, err := capnp.NewInt32List(seg, n)
+		if err != nil {
+			return capnp.Ptr{}, err
+		}
+		for i, e := range v.elems {
+			x, err := parseInt(e, 32)
+			if err != nil {
+				return capnp.Ptr{}, err
+			}
+			l.Set(i, int32(x))
+		}
+		return l.ToPtr(), nil
+	case schema.Type_Which_int64:
+		l, err := capnp.NewInt64List(seg, n)
+		if err != nil {
+			return capnp.Ptr{}, err
+		}
+		for i, e := range v.elems {
+			x, err := parseInt(e, 64)
+			if err != nil {
+				return capnp.Ptr{}, err
+			}
+			l.Set(i, x)
+		}
+		return l.ToPtr(), nil
+	case schema.Type_Which_uint8:
+		l, err := capnp.NewUInt8List(seg, n)
+		if err != nil {
+			return capnp.Ptr{}, err
+		}
+		for i, e := range v.elems {
+			x, err := parseUint(e, 8)
+			if err != nil {
+				return capnp.Ptr{}, err
+			}
+			l.Set(i, uint8(x))
+		}
+		return l.ToPtr(), nil
+	case schema.Type_Which_uint16:
+		l, err := capnp.NewUInt16List(seg, n)
+		if err != nil {
+			return capnp.Ptr{}, err
+		}
+		for i, e := range v.elems {
+			x, err := parseUint(e, 16)
+			if err != nil {
+				return capnp.Ptr{}, err
+			}
+			l.Set(i, uint16(x))
+		}
+		return l.ToPtr(), nil
+	case schema.Type_Which_uint32:
+		l, err := capnp.NewUInt32List(seg, n)
+		if err != nil {
+			return capnp.Ptr{}, err
+		}
+		for i, e := range v.elems {
+			x, err := parseUint(e, 32)
+			if err != nil {
+				return capnp.Ptr{}, err
+			}
+			l.Set(i, uint32(x))
+		}
+		return l.ToPtr(), nil
+	case schema.Type_Which_uint64:
+		l, err := capnp.NewUInt64List(seg, n)
+		if err != nil {
+			return capnp.Ptr{}, err
+		}
+		for i, e := range v.elems {
+			x, err := parseUint(e, 64)
+			if err != nil {
+				return capnp.Ptr{}, err
+			}
+			l.Set(i, x)
+		}
+		return l.ToPtr(), nil
+	case schema.Type_Which_float32:
+		l, err := capnp.NewFloat32List(seg, n)
+		if err != nil {
+			return capnp.Ptr{}, err
+		}
+		for i, e := range v.elems {
+			x, err := parseFloat(e, 32)
+			if err != nil {
+				return capnp.Ptr{}, err
+			}
+			l.Set(i, float32(x))
+		}
+		return l.ToPtr(), nil
+	case schema.Type_Which_float64:
+		l, err := capnp.NewFloat64List(seg, n)
+		if err != nil {
+			return capnp.Ptr{}, err
+		}
+		for i, e := range v.elems {
+			x, err := parseFloat(e, 64)
+			if err != nil {
+				return capnp.Ptr{}, err
+			}
+			l.Set(i, x)
+		}
+		return l.ToPtr(), nil
+	case schema.Type_Which_enum:
+		l, err := capnp.NewUInt16List(seg, n)
+		if err != nil {
+			return capnp.Ptr{}, err
+		}
+		for i, e := range v.elems {
+			x, err := dec.parseEnum(elem.Enum().TypeId(), e)
+			if err != nil {
+				return capnp.Ptr{}, err
+			}
+			l.Set(i, x)
+		}
+		return l.ToPtr(), nil
+	case schema.Type_Which_structType:
+		sn, err := dec.findStruct(elem.StructType().TypeId())
+		if err != nil {
+			return capnp.Ptr{}, err
+		}
+		l, err := capnp.NewCompositeList(seg, structSize(sn), n)
+		if err != nil {
+			return capnp.Ptr{}, err
+		}
+		for i, e := range v.elems {
+			if !e.isStruct() {
+				return capnp.Ptr{}, e.tok.pos.errorf("expected struct, found %v", e.tok)
+			}
+			if err := dec.unmarshalStruct(sn, l.Struct(i), e); err != nil {
+				return capnp.Ptr{}, err
+			}
+		}
+		return l.ToPtr(), nil
+	default:
+		// Text, data, nested lists, capabilities and opaque pointers are
+		// all stored in a list of pointers.
+		l, err := capnp.NewPointerList(seg, n)
+		if err != nil {
+			return capnp.Ptr{}, err
+		}
+		for i, e := range v.elems {
+			p, err := dec.unmarshalPtr(elem, seg, e)
+			if err != nil {
+				return capnp.Ptr{}, err
+			}
+			if err := l.Set(i, p); err != nil {
+				return capnp.Ptr{}, err
+			}
+		}
+		return l.ToPtr(), nil
+	}
+}
+
+// parseEnum returns the value of an enumerant, given by name or number.
+func (dec *Decoder) parseEnum(typeID uint64, v *value) (uint16, error) {
+	if v.tok.kind == numberToken {
+		x, err := parseUint(v, 16)
+		return uint16(x), err
+	}
+	if v.tok.kind != identToken {
+		return 0, v.tok.pos.errorf("expected enumerant, found %v", v.tok)
+	}
+	n, err := dec.nodes.Find(typeID)
+	if err != nil {
+		return 0, err
+	}
+	if !n.IsValid() || n.Which() != schema.Node_Which_enum {
+		return 0, fmt.Errorf("unmarshaling enum of type @%#x: type is not an enum", typeID)
+	}
+	enums, err := n.Enum().Enumerants()
+	if err != nil {
+		return 0, err
+	}
+	for i := 0; i < enums.Len(); i++ {
+		name, err := enums.At(i).Name()
+		if err != nil {
+			return 0, err
+		}
+		if name == v.tok.text {
+			return uint16(i), nil
+		}
+	}
+	dn, _ := n.DisplayName()
+	return 0, v.tok.pos.errorf("no enumerant %s in %s", v.tok.text, dn)
+}
+
+func parseBool(v *value) (bool, error) {
+	switch {
+	case v.isIdent("true"):
+		return true, nil
+	case v.isIdent("false"):
+		return false, nil
+	default:
+		return false, v.tok.pos.errorf("expected bool, found %v", v.tok)
+	}
+}
+
+func parseInt(v *value, bitSize int) (int64, error) {
+	if v.tok.kind != numberToken {
+		return 0, v.tok.pos.errorf("expected integer, found %v", v.tok)
+	}
+	i, err := strconv.ParseInt(v.tok.text, 0, bitSize)
+	if err != nil {
+		return 0, v.tok.pos.errorf("invalid Int%d %s", bitSize, v.tok.text)
+	}
+	return i, nil
+}
+
+func parseUint(v *value, bitSize int) (uint64, error) {
+	if v.tok.kind != numberToken {
+		return 0, v.tok.pos.errorf("expected integer, found %v", v.tok)
+	}
+	i, err := strconv.ParseUint(v.tok.text, 0, bitSize)
+	if err != nil {
+		return 0, v.tok.pos.errorf("invalid UInt%d %s", bitSize, v.tok.text)
+	}
+	return i, nil
+}
+
+// parseFloat parses a float literal.  As well as numbers, it accepts
+// inf and nan (in any case, with an optional sign), as written by both
+// the Encoder and the capnp tool.
+func parseFloat(v *value, bitSize int) (float64, error) {
+	if v.tok.kind != numberToken && v.tok.kind != identToken {
+		return 0, v.tok.pos.errorf("expected float, found %v", v.tok)
+	}
+	x, err := strconv.ParseFloat(v.tok.text, bitSize)
+	if err != nil {
+		return 0, v.tok.pos.errorf("invalid Float%d %s", bitSize, v.tok.text)
+	}
+	return x, nil
+}
@@ -0,0 +1,275 @@
+package text_test
+
+import (
+	"errors"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"capnproto.org/go/capnp/v3"
+	"capnproto.org/go/capnp/v3/encoding/text"
+	air "capnproto.org/go/capnp/v3/internal/aircraftlib"
+	"capnproto.org/go/capnp/v3/schemas"
+)
+
+const (
+	keyValueTypeID uint64 = 0x8df8bc5abdc060a6
+	valueTypeID    uint64 = 0xd3602730c572a43b
+)
+
+func newTestRegistry(t *testing.T) *schemas.Registry {
+	data, err := os.ReadFile(filepath.Join("testdata", "txt.capnp.out"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	reg := new(schemas.Registry)
+	err = reg.Register(&schemas.Schema{
+		Bytes: data,
+		Nodes: []uint64{
+			keyValueTypeID,
+			valueTypeID,
+		},
+	})
+	if err != nil {
+		t.Fatalf("Adding to registry: %v", err)
+	}
+	return reg
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		typeID uint64
+		text   string
+		want   string
+	}{
+		{keyValueTypeID, `(key = "42", value = (int32 = -123))`, ""},
+		{keyValueTypeID, `(key = "float", value = (float64 = 3.14))`, ""},
+		{keyValueTypeID, `(key = "bool", value = (bool = false))`, ""},
+		{valueTypeID, `(map = [(key = "foo", value = (void = void)), (key = "bar", value = (void = void))])`, ""},
+		{valueTypeID, `(map = [])`, ""},
+		{valueTypeID, `(data = "Hi\xde\xad\xbe\xef\xca\xfe")`, ""},
+		{valueTypeID, `(data = 0x"4869 dead beef cafe")`, `(data = "Hi\xde\xad\xbe\xef\xca\xfe")`},
+		{valueTypeID, `(voidList = [void, void])`, ""},
+		{valueTypeID, `(boolList = [true, false, true, false])`, ""},
+		{valueTypeID, `(int8List = [1, -2, 3])`, ""},
+		{valueTypeID, `(int64List = [1, -2, 3])`, ""},
+		{valueTypeID, `(uint8List = [255, 0, 1])`, ""},
+		{valueTypeID, `(uint64List = [1, 2, 3])`, ""},
+		{valueTypeID, `(float32List = [0.5, 3.14, -2])`, ""},
+		{valueTypeID, `(textList = ["foo", "bar", "baz"])`, ""},
+		{valueTypeID, `(dataList = ["\xde\xad\xbe\xef", "\xca\xfe"])`, ""},
+		{valueTypeID, `(cheese = gouda)`, ""},
+		{valueTypeID, `(cheese = 1)`, `(cheese = gouda)`},
+		{valueTypeID, `(cheeseList = [gouda, cheddar])`, ""},
+		{valueTypeID, `(matrix = [[1, 2, 3], [4, 5, 6]])`, ""},
+		{valueTypeID, `(uint16 = 0xffff)`, `(uint16 = 65535)`},
+		{valueTypeID, `(float64 = -inf)`, `(float64 = -Inf)`},
+		{valueTypeID, `(text = "tab\there\n")`, `(text = "tab\there\n")`},
+		{valueTypeID, "# comment\n(  int16 = -5, # trailing comma\n)", `(int16 = -5)`},
+	}
+
+	reg := newTestRegistry(t)
+	for _, test := range tests {
+		want := test.want
+		if want == "" {
+			want = test.text
+		}
+		_, seg, err := capnp.NewMessage(capnp.SingleSegment(nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		dec := text.NewDecoder(strings.NewReader(test.text))
+		dec.UseRegistry(reg)
+		s, err := dec.Decode(test.typeID, seg)
+		if err != nil {
+			t.Errorf("Decode(%#x, %q): %v", test.typeID, test.text, err)
+			continue
+		}
+		buf := new(strings.Builder)
+		enc := text.NewEncoder(buf)
+		enc.UseRegistry(reg)
+		if err := enc.Encode(test.typeID, s); err != nil {
+			t.Errorf("Encode(Decode(%#x, %q)): %v", test.typeID, test.text, err)
+			continue
+		}
+		if text := buf.String(); text != want {
+			t.Errorf("Encode(Decode(%#x, %q)) = %q; want %q", test.typeID, test.text, text, want)
+		}
+	}
+}
+
+func TestDecodeList(t *testing.T) {
+	const in = `[(key = "foo", value = (void = void)), (key = "bar", value = (void = void))]`
+
+	reg := newTestRegistry(t)
+	_, seg, err := capnp.NewMessage(capnp.SingleSegment(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dec := text.NewDecoder(strings.NewReader(in))
+	dec.UseRegistry(reg)
+	l, err := dec.DecodeList(keyValueTypeID, seg)
+	if err != nil {
+		t.Fatalf("DecodeList(%#x, %q): %v", keyValueTypeID, in, err)
+	}
+	buf := new(strings.Builder)
+	enc := text.NewEncoder(buf)
+	enc.UseRegistry(reg)
+	if err := enc.EncodeList(keyValueTypeID, l); err != nil {
+		t.Fatalf("EncodeList(DecodeList(%#x, %q)): %v", keyValueTypeID, in, err)
+	}
+	if got := buf.String(); got != in {
+		t.Errorf("EncodeList(DecodeList(%#x, %q)) = %q", keyValueTypeID, in, got)
+	}
+}
+
+func TestDecodeStream(t *testing.T) {
+	reg := newTestRegistry(t)
+	dec := text.NewDecoder(strings.NewReader("(int8 = 1)\n(int8 = 2)\n# done\n"))
+	dec.UseRegistry(reg)
+	for i := int8(1); i <= 2; i++ {
+		_, seg, err := capnp.NewMessage(capnp.SingleSegment(nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		s, err := dec.Decode(valueTypeID, seg)
+		if err != nil {
+			t.Fatalf("Decode #%d: %v", i, err)
+		}
+		if got := int8(s.Uint8(2)); got != i {
+			t.Errorf("Decode #%d: int8 = %d; want %d", i, got, i)
+		}
+	}
+	_, seg, _ := capnp.NewMessage(capnp.SingleSegment(nil))
+	if _, err := dec.Decode(valueTypeID, seg); err != io.EOF {
+		t.Errorf("Decode at end of stream: %v; want io.EOF", err)
+	}
+}
+
+func TestUnmarshalGroup(t *testing.T) {
+	_, seg, err := capnp.NewMessage(capnp.SingleSegment(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := text.Unmarshal(air.Z_TypeID, `(grp = (first = 1, second = 2))`, seg)
+	if err != nil {
+		t.Fatal("Unmarshal:", err)
+	}
+	z := air.Z(s)
+	if z.Which() != air.Z_Which_grp {
+		t.Fatalf("z.Which() = %v; want grp", z.Which())
+	}
+	if first, second := z.Grp().First(), z.Grp().Second(); first != 1 || second != 2 {
+		t.Errorf("z.grp = (first = %d, second = %d); want (first = 1, second = 2)", first, second)
+	}
+
+	s, err = text.Unmarshal(air.Z_TypeID, `(zdate = (year = 2024, month = 2, day = 29))`, seg)
+	if err != nil {
+		t.Fatal("Unmarshal:", err)
+	}
+	z = air.Z(s)
+	if z.Which() != air.Z_Which_zdate {
+		t.Fatalf("z.Which() = %v; want zdate", z.Which())
+	}
+	d, err := z.Zdate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Year() != 2024 || d.Month() != 2 || d.Day() != 29 {
+		t.Errorf("z.zdate = %d-%d-%d; want 2024-2-29", d.Year(), d.Month(), d.Day())
+	}
+}
+
+func TestUnmarshalDefaults(t *testing.T) {
+	_, seg, err := capnp.NewMessage(capnp.SingleSegment(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := text.Unmarshal(air.Defaults_TypeID, `()`, seg)
+	if err != nil {
+		t.Fatal("Unmarshal:", err)
+	}
+	d := air.Defaults(s)
+	if text, _ := d.Text(); text != "foo" {
+		t.Errorf("text = %q; want \"foo\"", text)
+	}
+	if d.Float() != 3.14 || d.Int() != -123 || d.Uint() != 42 {
+		t.Errorf("(float, int, uint) = (%v, %d, %d); want (3.14, -123, 42)", d.Float(), d.Int(), d.Uint())
+	}
+
+	s, err = text.Unmarshal(air.Defaults_TypeID, `(text = "bar", float = nan, int = 0, uint = 7)`, seg)
+	if err != nil {
+		t.Fatal("Unmarshal:", err)
+	}
+	d = air.Defaults(s)
+	if text, _ := d.Text(); text != "bar" {
+		t.Errorf("text = %q; want \"bar\"", text)
+	}
+	if !math.IsNaN(float64(d.Float())) || d.Int() != 0 || d.Uint() != 7 {
+		t.Errorf("(float, int, uint) = (%v, %d, %d); want (NaN, 0, 7)", d.Float(), d.Int(), d.Uint())
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	tests := []struct {
+		text   string
+		line   int
+		column int
+	}{
+		{`(int8 = 1`, 1, 10},
+		{`(int8 = 128)`, 1, 9},
+		{`(uint8 = -1)`, 1, 10},
+		{`(nope = 1)`, 1, 2},
+		{"(\n  int8 = 1,\n  int8 = 2)", 3, 3},
+		{"(int8 = 1,\n bool = true)", 2, 2},
+		{`(cheese = brie)`, 1, 11},
+		{`(text = "abc`, 1, 9},
+		{`(text = 42)`, 1, 9},
+		{`(data = 0x"abc")`, 1, 15},
+		{`(boolList = [true, 1])`, 1, 20},
+		{`(int8 = 1) )`, 1, 12},
+	}
+
+	reg := newTestRegistry(t)
+	for _, test := range tests {
+		_, seg, err := capnp.NewMessage(capnp.SingleSegment(nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		dec := text.NewDecoder(strings.NewReader(test.text))
+		dec.UseRegistry(reg)
+		_, err = dec.Decode(valueTypeID, seg)
+		if err == nil {
+			_, err = dec.Decode(valueTypeID, seg)
+		}
+		var se *text.SyntaxError
+		if !errors.As(err, &se) {
+			t.Errorf("Decode(%q) = %v; want *SyntaxError", test.text, err)
+			continue
+		}
+		if se.Line != test.line || se.Column != test.column {
+			t.Errorf("Decode(%q) = %v; want error at %d:%d", test.text, err, test.line, test.column)
+		}
+	}
+}
+
+func TestUnmarshalNesting(t *testing.T) {
+	_, seg, err := capnp.NewMessage(capnp.SingleSegment(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := "(boolList = " + strings.Repeat("[", 3000000)
+	dec := text.NewDecoder(strings.NewReader(input))
+	dec.UseRegistry(newTestRegistry(t))
+	_, err = dec.Decode(valueTypeID, seg)
+	var se *text.SyntaxError
+	if !errors.As(err, &se) {
+		t.Fatalf("Decode(deeply nested) = %v; want *SyntaxError", err)
+	}
+	if se.Line != 1 || se.Column != 76 {
+		t.Errorf("Decode(deeply nested) = %v; want error at 1:76", err)
+	}
+}
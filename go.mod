@@ -1,6 +1,6 @@
 module capnproto.org/go/capnp/v3
 
-go 1.18
+go 1.20
 
 require (
 	github.com/kylelemons/godebug v1.1.0
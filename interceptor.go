@@ -0,0 +1,87 @@
+package capnp
+
+import "context"
+
+// An Interceptor intercepts the calls made on a Client returned by
+// WrapClient, for logging, authorization, retries, metrics and the
+// like.  Either function may be nil, in which case calls pass through
+// unchanged.
+//
+// An interceptor function must call next at most once, and return what
+// it returns or an answer of its own, such as ErrorAnswer.  The answer
+// returned by next supports pipelining, so an interceptor that passes
+// it on keeps pipelining working.  Calls made on capabilities
+// pipelined from an answer are not intercepted, since they are calls
+// on other capabilities.
+type Interceptor struct {
+	// Send intercepts SendCall.
+	Send func(ctx context.Context, s Send, next SendFunc) (*Answer, ReleaseFunc)
+
+	// Recv intercepts RecvCall.  If it does not call next, it must
+	// complete the call with r.Return or r.Reject.
+	Recv func(ctx context.Context, r Recv, next RecvFunc) PipelineCaller
+}
+
+// SendFunc is the signature of Client.SendCall.
+type SendFunc func(ctx context.Context, s Send) (*Answer, ReleaseFunc)
+
+// RecvFunc is the signature of Client.RecvCall.
+type RecvFunc func(ctx context.Context, r Recv) PipelineCaller
+
+// WrapClient returns a Client whose calls pass through interceptors
+// before being made on c.  The first interceptor is the outermost: it
+// sees a call first, and its next function calls the second.
+//
+// WrapClient takes ownership of c: it is released when the returned
+// Client is released.  The returned Client is a different capability
+// than c as far as IsSame is concerned.
+func WrapClient(c Client, interceptors ...Interceptor) Client {
+	if len(interceptors) == 0 || !c.IsValid() {
+		return c
+	}
+	ic := &interceptClient{client: c}
+	ic.send = c.SendCall
+	ic.recv = c.RecvCall
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		if f := interceptors[i].Send; f != nil {
+			next := ic.send
+			ic.send = func(ctx context.Context, s Send) (*Answer, ReleaseFunc) {
+				return f(ctx, s, next)
+			}
+		}
+		if f := interceptors[i].Recv; f != nil {
+			next := ic.recv
+			ic.recv = func(ctx context.Context, r Recv) PipelineCaller {
+				return f(ctx, r, next)
+			}
+		}
+	}
+	return NewClient(ic)
+}
+
+// interceptClient is the ClientHook of a Client returned by WrapClient.
+type interceptClient struct {
+	client Client
+	send   SendFunc
+	recv   RecvFunc
+}
+
+func (ic *interceptClient) Send(ctx context.Context, s Send) (*Answer, ReleaseFunc) {
+	return ic.send(ctx, s)
+}
+
+func (ic *interceptClient) Recv(ctx context.Context, r Recv) PipelineCaller {
+	return ic.recv(ctx, r)
+}
+
+func (ic *interceptClient) Brand() Brand {
+	return Brand{Value: ic}
+}
+
+func (ic *interceptClient) Fd() (int, bool) {
+	return ic.client.Fd()
+}
+
+func (ic *interceptClient) Shutdown() {
+	ic.client.Release()
+}
@@ -0,0 +1,90 @@
+package capnp
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestWrapClient(t *testing.T) {
+	ctx := context.Background()
+	h := new(dummyHook)
+	var log []string
+	logger := func(name string) Interceptor {
+		return Interceptor{
+			Send: func(ctx context.Context, s Send, next SendFunc) (*Answer, ReleaseFunc) {
+				log = append(log, name+" send")
+				return next(ctx, s)
+			},
+			Recv: func(ctx context.Context, r Recv, next RecvFunc) PipelineCaller {
+				log = append(log, name+" recv")
+				return next(ctx, r)
+			},
+		}
+	}
+	c := WrapClient(NewClient(h), logger("outer"), Interceptor{}, logger("inner"))
+
+	ans, finish := c.SendCall(ctx, Send{})
+	if _, err := ans.Struct(); err != nil {
+		t.Error("SendCall:", err)
+	}
+	finish()
+	ret := new(dummyReturner)
+	c.RecvCall(ctx, Recv{
+		ReleaseArgs: func() {},
+		Returner:    ret,
+	})
+	if !ret.returned || ret.err != nil {
+		t.Errorf("RecvCall returned = %t, err = %v; want returned with no error", ret.returned, ret.err)
+	}
+	want := []string{"outer send", "inner send", "outer recv", "inner recv"}
+	if len(log) != len(want) {
+		t.Fatalf("interceptors called %q; want %q", log, want)
+	}
+	for i := range want {
+		if log[i] != want[i] {
+			t.Fatalf("interceptors called %q; want %q", log, want)
+		}
+	}
+	if h.calls != 2 {
+		t.Errorf("hook called %d times; want 2", h.calls)
+	}
+
+	c.Release()
+	if h.shutdowns != 1 {
+		t.Errorf("after releasing wrapped client, hook shut down %d times; want 1", h.shutdowns)
+	}
+}
+
+func TestWrapClient_Reject(t *testing.T) {
+	ctx := context.Background()
+	h := new(dummyHook)
+	errDenied := errors.New("denied")
+	c := WrapClient(NewClient(h), Interceptor{
+		Send: func(ctx context.Context, s Send, next SendFunc) (*Answer, ReleaseFunc) {
+			return ErrorAnswer(s.Method, errDenied), func() {}
+		},
+		Recv: func(ctx context.Context, r Recv, next RecvFunc) PipelineCaller {
+			r.Reject(errDenied)
+			return nil
+		},
+	})
+	defer c.Release()
+
+	ans, finish := c.SendCall(ctx, Send{})
+	if _, err := ans.Struct(); !errors.Is(err, errDenied) {
+		t.Errorf("SendCall error = %v; want %v", err, errDenied)
+	}
+	finish()
+	ret := new(dummyReturner)
+	c.RecvCall(ctx, Recv{
+		ReleaseArgs: func() {},
+		Returner:    ret,
+	})
+	if !errors.Is(ret.err, errDenied) {
+		t.Errorf("RecvCall error = %v; want %v", ret.err, errDenied)
+	}
+	if h.calls != 0 {
+		t.Errorf("hook called %d times; want 0", h.calls)
+	}
+}
@@ -11,7 +11,9 @@ import (
 	context "context"
 	fmt "fmt"
 	math "math"
+	netip "net/netip"
 	strconv "strconv"
+	time "time"
 )
 
 // Constants defined in aircraft.capnp.
@@ -113,6 +115,44 @@ func (f Zdate_Future) Struct() (Zdate, error) {
 	return Zdate(p.Struct()), err
 }
 
+// ZdatePogs is a plain Go representation of Zdate.
+type ZdatePogs struct {
+	Year  int16
+	Month uint8
+	Day   uint8
+}
+
+// ToCapnp allocates a new Zdate in seg and copies p into it.
+func (p *ZdatePogs) ToCapnp(seg *capnp.Segment) (Zdate, error) {
+	s, err := NewZdate(seg)
+	if err != nil {
+		return Zdate{}, err
+	}
+	return s, p.Insert(s)
+}
+
+// Insert copies p into s.  Capabilities in p are not consumed: s gets
+// its own references.
+func (p *ZdatePogs) Insert(s Zdate) error {
+	s.SetYear(p.Year)
+	s.SetMonth(p.Month)
+	s.SetDay(p.Day)
+	return nil
+}
+
+// FromCapnp sets p to the values in s.  Like pogs.Extract, it does not
+// copy everything: Data and AnyPointer fields refer to s's message, and
+// capabilities are borrowed from its capability table, so they are only
+// valid until the message is released.  Use bytes.Clone and AddRef to
+// keep them for longer.
+func (p *ZdatePogs) FromCapnp(s Zdate) error {
+	*p = ZdatePogs{}
+	p.Year = s.Year()
+	p.Month = s.Month()
+	p.Day = s.Day()
+	return nil
+}
+
 type Zdata capnp.Struct
 
 // Zdata_TypeID is the unique identifier for the type Zdata.
@@ -190,6 +230,43 @@ func (f Zdata_Future) Struct() (Zdata, error) {
 	return Zdata(p.Struct()), err
 }
 
+// ZdataPogs is a plain Go representation of Zdata.
+type ZdataPogs struct {
+	Data []byte
+}
+
+// ToCapnp allocates a new Zdata in seg and copies p into it.
+func (p *ZdataPogs) ToCapnp(seg *capnp.Segment) (Zdata, error) {
+	s, err := NewZdata(seg)
+	if err != nil {
+		return Zdata{}, err
+	}
+	return s, p.Insert(s)
+}
+
+// Insert copies p into s.  Capabilities in p are not consumed: s gets
+// its own references.
+func (p *ZdataPogs) Insert(s Zdata) error {
+	if err := s.SetData(p.Data); err != nil {
+		return err
+	}
+	return nil
+}
+
+// FromCapnp sets p to the values in s.  Like pogs.Extract, it does not
+// copy everything: Data and AnyPointer fields refer to s's message, and
+// capabilities are borrowed from its capability table, so they are only
+// valid until the message is released.  Use bytes.Clone and AddRef to
+// keep them for longer.
+func (p *ZdataPogs) FromCapnp(s Zdata) error {
+	var err error
+	*p = ZdataPogs{}
+	if p.Data, err = s.Data(); err != nil {
+		return err
+	}
+	return nil
+}
+
 type Airport uint16
 
 // Airport_TypeID is the unique identifier for the type Airport.
@@ -396,6 +473,73 @@ func (f PlaneBase_Future) Struct() (PlaneBase, error) {
 	return PlaneBase(p.Struct()), err
 }
 
+// PlaneBasePogs is a plain Go representation of PlaneBase.
+type PlaneBasePogs struct {
+	Name     string
+	Homes    []Airport
+	Rating   int64
+	CanFly   bool
+	Capacity int64
+	MaxSpeed float64
+}
+
+// ToCapnp allocates a new PlaneBase in seg and copies p into it.
+func (p *PlaneBasePogs) ToCapnp(seg *capnp.Segment) (PlaneBase, error) {
+	s, err := NewPlaneBase(seg)
+	if err != nil {
+		return PlaneBase{}, err
+	}
+	return s, p.Insert(s)
+}
+
+// Insert copies p into s.  Capabilities in p are not consumed: s gets
+// its own references.
+func (p *PlaneBasePogs) Insert(s PlaneBase) error {
+	if err := s.SetName(p.Name); err != nil {
+		return err
+	}
+	if p.Homes != nil {
+		l, err := s.NewHomes(int32(len(p.Homes)))
+		if err != nil {
+			return err
+		}
+		for i := range p.Homes {
+			l.Set(i, p.Homes[i])
+		}
+	}
+	s.SetRating(p.Rating)
+	s.SetCanFly(p.CanFly)
+	s.SetCapacity(p.Capacity)
+	s.SetMaxSpeed(p.MaxSpeed)
+	return nil
+}
+
+// FromCapnp sets p to the values in s.  Like pogs.Extract, it does not
+// copy everything: Data and AnyPointer fields refer to s's message, and
+// capabilities are borrowed from its capability table, so they are only
+// valid until the message is released.  Use bytes.Clone and AddRef to
+// keep them for longer.
+func (p *PlaneBasePogs) FromCapnp(s PlaneBase) error {
+	var err error
+	*p = PlaneBasePogs{}
+	if p.Name, err = s.Name(); err != nil {
+		return err
+	}
+	if l, err := s.Homes(); err != nil {
+		return err
+	} else if l.IsValid() {
+		p.Homes = make([]Airport, l.Len())
+		for i := range p.Homes {
+			p.Homes[i] = l.At(i)
+		}
+	}
+	p.Rating = s.Rating()
+	p.CanFly = s.CanFly()
+	p.Capacity = s.Capacity()
+	p.MaxSpeed = s.MaxSpeed()
+	return nil
+}
+
 type B737 capnp.Struct
 
 // B737_TypeID is the unique identifier for the type B737.
@@ -487,6 +631,53 @@ func (p B737_Future) Base() PlaneBase_Future {
 	return PlaneBase_Future{Future: p.Future.Field(0, nil)}
 }
 
+// B737Pogs is a plain Go representation of B737.
+type B737Pogs struct {
+	Base *PlaneBasePogs
+}
+
+// ToCapnp allocates a new B737 in seg and copies p into it.
+func (p *B737Pogs) ToCapnp(seg *capnp.Segment) (B737, error) {
+	s, err := NewB737(seg)
+	if err != nil {
+		return B737{}, err
+	}
+	return s, p.Insert(s)
+}
+
+// Insert copies p into s.  Capabilities in p are not consumed: s gets
+// its own references.
+func (p *B737Pogs) Insert(s B737) error {
+	if p.Base != nil {
+		v, err := s.NewBase()
+		if err != nil {
+			return err
+		}
+		if err := p.Base.Insert(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FromCapnp sets p to the values in s.  Like pogs.Extract, it does not
+// copy everything: Data and AnyPointer fields refer to s's message, and
+// capabilities are borrowed from its capability table, so they are only
+// valid until the message is released.  Use bytes.Clone and AddRef to
+// keep them for longer.
+func (p *B737Pogs) FromCapnp(s B737) error {
+	*p = B737Pogs{}
+	if v, err := s.Base(); err != nil {
+		return err
+	} else if v.IsValid() {
+		p.Base = new(PlaneBasePogs)
+		if err := p.Base.FromCapnp(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 type A320 capnp.Struct
 
 // A320_TypeID is the unique identifier for the type A320.
@@ -578,6 +769,53 @@ func (p A320_Future) Base() PlaneBase_Future {
 	return PlaneBase_Future{Future: p.Future.Field(0, nil)}
 }
 
+// A320Pogs is a plain Go representation of A320.
+type A320Pogs struct {
+	Base *PlaneBasePogs
+}
+
+// ToCapnp allocates a new A320 in seg and copies p into it.
+func (p *A320Pogs) ToCapnp(seg *capnp.Segment) (A320, error) {
+	s, err := NewA320(seg)
+	if err != nil {
+		return A320{}, err
+	}
+	return s, p.Insert(s)
+}
+
+// Insert copies p into s.  Capabilities in p are not consumed: s gets
+// its own references.
+func (p *A320Pogs) Insert(s A320) error {
+	if p.Base != nil {
+		v, err := s.NewBase()
+		if err != nil {
+			return err
+		}
+		if err := p.Base.Insert(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FromCapnp sets p to the values in s.  Like pogs.Extract, it does not
+// copy everything: Data and AnyPointer fields refer to s's message, and
+// capabilities are borrowed from its capability table, so they are only
+// valid until the message is released.  Use bytes.Clone and AddRef to
+// keep them for longer.
+func (p *A320Pogs) FromCapnp(s A320) error {
+	*p = A320Pogs{}
+	if v, err := s.Base(); err != nil {
+		return err
+	} else if v.IsValid() {
+		p.Base = new(PlaneBasePogs)
+		if err := p.Base.FromCapnp(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 type F16 capnp.Struct
 
 // F16_TypeID is the unique identifier for the type F16.
@@ -669,6 +907,53 @@ func (p F16_Future) Base() PlaneBase_Future {
 	return PlaneBase_Future{Future: p.Future.Field(0, nil)}
 }
 
+// F16Pogs is a plain Go representation of F16.
+type F16Pogs struct {
+	Base *PlaneBasePogs
+}
+
+// ToCapnp allocates a new F16 in seg and copies p into it.
+func (p *F16Pogs) ToCapnp(seg *capnp.Segment) (F16, error) {
+	s, err := NewF16(seg)
+	if err != nil {
+		return F16{}, err
+	}
+	return s, p.Insert(s)
+}
+
+// Insert copies p into s.  Capabilities in p are not consumed: s gets
+// its own references.
+func (p *F16Pogs) Insert(s F16) error {
+	if p.Base != nil {
+		v, err := s.NewBase()
+		if err != nil {
+			return err
+		}
+		if err := p.Base.Insert(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FromCapnp sets p to the values in s.  Like pogs.Extract, it does not
+// copy everything: Data and AnyPointer fields refer to s's message, and
+// capabilities are borrowed from its capability table, so they are only
+// valid until the message is released.  Use bytes.Clone and AddRef to
+// keep them for longer.
+func (p *F16Pogs) FromCapnp(s F16) error {
+	*p = F16Pogs{}
+	if v, err := s.Base(); err != nil {
+		return err
+	} else if v.IsValid() {
+		p.Base = new(PlaneBasePogs)
+		if err := p.Base.FromCapnp(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 type Regression capnp.Struct
 
 // Regression_TypeID is the unique identifier for the type Regression.
@@ -830,6 +1115,102 @@ func (p Regression_Future) Base() PlaneBase_Future {
 	return PlaneBase_Future{Future: p.Future.Field(0, nil)}
 }
 
+// RegressionPogs is a plain Go representation of Regression.
+type RegressionPogs struct {
+	Base   *PlaneBasePogs
+	B0     float64
+	Beta   []float64
+	Planes []AircraftPogs
+	Ymu    float64
+	Ysd    float64
+}
+
+// ToCapnp allocates a new Regression in seg and copies p into it.
+func (p *RegressionPogs) ToCapnp(seg *capnp.Segment) (Regression, error) {
+	s, err := NewRegression(seg)
+	if err != nil {
+		return Regression{}, err
+	}
+	return s, p.Insert(s)
+}
+
+// Insert copies p into s.  Capabilities in p are not consumed: s gets
+// its own references.
+func (p *RegressionPogs) Insert(s Regression) error {
+	if p.Base != nil {
+		v, err := s.NewBase()
+		if err != nil {
+			return err
+		}
+		if err := p.Base.Insert(v); err != nil {
+			return err
+		}
+	}
+	s.SetB0(p.B0)
+	if p.Beta != nil {
+		l, err := s.NewBeta(int32(len(p.Beta)))
+		if err != nil {
+			return err
+		}
+		for i := range p.Beta {
+			l.Set(i, p.Beta[i])
+		}
+	}
+	if p.Planes != nil {
+		l, err := s.NewPlanes(int32(len(p.Planes)))
+		if err != nil {
+			return err
+		}
+		for i := range p.Planes {
+			if err := p.Planes[i].Insert(l.At(i)); err != nil {
+				return err
+			}
+		}
+	}
+	s.SetYmu(p.Ymu)
+	s.SetYsd(p.Ysd)
+	return nil
+}
+
+// FromCapnp sets p to the values in s.  Like pogs.Extract, it does not
+// copy everything: Data and AnyPointer fields refer to s's message, and
+// capabilities are borrowed from its capability table, so they are only
+// valid until the message is released.  Use bytes.Clone and AddRef to
+// keep them for longer.
+func (p *RegressionPogs) FromCapnp(s Regression) error {
+	*p = RegressionPogs{}
+	if v, err := s.Base(); err != nil {
+		return err
+	} else if v.IsValid() {
+		p.Base = new(PlaneBasePogs)
+		if err := p.Base.FromCapnp(v); err != nil {
+			return err
+		}
+	}
+	p.B0 = s.B0()
+	if l, err := s.Beta(); err != nil {
+		return err
+	} else if l.IsValid() {
+		p.Beta = make([]float64, l.Len())
+		for i := range p.Beta {
+			p.Beta[i] = l.At(i)
+		}
+	}
+	if l, err := s.Planes(); err != nil {
+		return err
+	} else if l.IsValid() {
+		p.Planes = make([]AircraftPogs, l.Len())
+		for i := range p.Planes {
+			if err := p.Planes[i].FromCapnp(l.At(i)); err != nil {
+				return err
+			}
+		}
+	}
+	p.Ymu = s.Ymu()
+	p.Ysd = s.Ysd()
+	return nil
+}
+
 type Aircraft capnp.Struct
 type Aircraft_Which uint16
 
@@ -1032,6 +1413,117 @@ func (p Aircraft_Future) F16() F16_Future {
 	return F16_Future{Future: p.Future.Field(0, nil)}
 }
 
+// AircraftPogs is a plain Go representation of Aircraft.
+type AircraftPogs struct {
+	Which Aircraft_Which
+	B737  *B737Pogs
+	A320  *A320Pogs
+	F16   *F16Pogs
+}
+
+// ToCapnp allocates a new Aircraft in seg and copies p into it.
+func (p *AircraftPogs) ToCapnp(seg *capnp.Segment) (Aircraft, error) {
+	s, err := NewAircraft(seg)
+	if err != nil {
+		return Aircraft{}, err
+	}
+	return s, p.Insert(s)
+}
+
+// Insert copies p into s.  Capabilities in p are not consumed: s gets
+// its own references.
+func (p *AircraftPogs) Insert(s Aircraft) error {
+	switch p.Which {
+	case Aircraft_Which_void:
+		s.SetVoid()
+	case Aircraft_Which_b737:
+		if p.B737 == nil {
+			if err := s.SetB737(B737{}); err != nil {
+				return err
+			}
+		} else {
+			v, err := s.NewB737()
+			if err != nil {
+				return err
+			}
+			if err := p.B737.Insert(v); err != nil {
+				return err
+			}
+		}
+	case Aircraft_Which_a320:
+		if p.A320 == nil {
+			if err := s.SetA320(A320{}); err != nil {
+				return err
+			}
+		} else {
+			v, err := s.NewA320()
+			if err != nil {
+				return err
+			}
+			if err := p.A320.Insert(v); err != nil {
+				return err
+			}
+		}
+	case Aircraft_Which_f16:
+		if p.F16 == nil {
+			if err := s.SetF16(F16{}); err != nil {
+				return err
+			}
+		} else {
+			v, err := s.NewF16()
+			if err != nil {
+				return err
+			}
+			if err := p.F16.Insert(v); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("Aircraft: unknown union member %v", p.Which)
+	}
+	return nil
+}
+
+// FromCapnp sets p to the values in s.  Like pogs.Extract, it does not
+// copy everything: Data and AnyPointer fields refer to s's message, and
+// capabilities are borrowed from its capability table, so they are only
+// valid until the message is released.  Use bytes.Clone and AddRef to
+// keep them for longer.
+func (p *AircraftPogs) FromCapnp(s Aircraft) error {
+	*p = AircraftPogs{}
+	p.Which = s.Which()
+	switch p.Which {
+	case Aircraft_Which_b737:
+		if v, err := s.B737(); err != nil {
+			return err
+		} else if v.IsValid() {
+			p.B737 = new(B737Pogs)
+			if err := p.B737.FromCapnp(v); err != nil {
+				return err
+			}
+		}
+	case Aircraft_Which_a320:
+		if v, err := s.A320(); err != nil {
+			return err
+		} else if v.IsValid() {
+			p.A320 = new(A320Pogs)
+			if err := p.A320.FromCapnp(v); err != nil {
+				return err
+			}
+		}
+	case Aircraft_Which_f16:
+		if v, err := s.F16(); err != nil {
+			return err
+		} else if v.IsValid() {
+			p.F16 = new(F16Pogs)
+			if err := p.F16.FromCapnp(v); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 type Z capnp.Struct
 type Z_grp Z
 type Z_Which uint16
@@ -2522,6 +3014,923 @@ func (p Z_Future) AnyCapability() capnp.Client {
 	return p.Future.Field(0, nil).Client()
 }
 
+// ZPogs is a plain Go representation of Z.
+type ZPogs struct {
+	Which         Z_Which
+	Zz            *ZPogs
+	F64           float64
+	F32           float32
+	I64           int64
+	I32           int32
+	I16           int16
+	I8            int8
+	U64           uint64
+	U32           uint32
+	U16           uint16
+	U8            uint8
+	Bool          bool
+	Text          string
+	Blob          []byte
+	F64vec        []float64
+	F32vec        []float32
+	I64vec        []int64
+	I32vec        []int32
+	I16vec        []int16
+	I8vec         []int8
+	U64vec        []uint64
+	U32vec        []uint32
+	U16vec        []uint16
+	U8vec         []uint8
+	Boolvec       []bool
+	Datavec       [][]byte
+	Textvec       []string
+	Zvec          []ZPogs
+	Zvecvec       [][]ZPogs
+	Zdate         *ZdatePogs
+	Zdata         *ZdataPogs
+	Aircraftvec   []AircraftPogs
+	Aircraft      *AircraftPogs
+	Regression    *RegressionPogs
+	Planebase     *PlaneBasePogs
+	Airport       Airport
+	B737          *B737Pogs
+	A320          *A320Pogs
+	F16           *F16Pogs
+	Zdatevec      []ZdatePogs
+	Zdatavec      []ZdataPogs
+	Grp           Z_grpPogs
+	Echo          Echo
+	Echoes        []Echo
+	AnyPtr        capnp.Ptr
+	AnyStruct     capnp.Struct
+	AnyList       capnp.List
+	AnyCapability capnp.Client
+}
+
+// ToCapnp allocates a new Z in seg and copies p into it.
+func (p *ZPogs) ToCapnp(seg *capnp.Segment) (Z, error) {
+	s, err := NewZ(seg)
+	if err != nil {
+		return Z{}, err
+	}
+	return s, p.Insert(s)
+}
+
+// Insert copies p into s.  Capabilities in p are not consumed: s gets
+// its own references.
+func (p *ZPogs) Insert(s Z) error {
+	switch p.Which {
+	case Z_Which_void:
+		s.SetVoid()
+	case Z_Which_zz:
+		if p.Zz == nil {
+			if err := s.SetZz(Z{}); err != nil {
+				return err
+			}
+		} else {
+			v, err := s.NewZz()
+			if err != nil {
+				return err
+			}
+			if err := p.Zz.Insert(v); err != nil {
+				return err
+			}
+		}
+	case Z_Which_f64:
+		s.SetF64(p.F64)
+	case Z_Which_f32:
+		s.SetF32(p.F32)
+	case Z_Which_i64:
+		s.SetI64(p.I64)
+	case Z_Which_i32:
+		s.SetI32(p.I32)
+	case Z_Which_i16:
+		s.SetI16(p.I16)
+	case Z_Which_i8:
+		s.SetI8(p.I8)
+	case Z_Which_u64:
+		s.SetU64(p.U64)
+	case Z_Which_u32:
+		s.SetU32(p.U32)
+	case Z_Which_u16:
+		s.SetU16(p.U16)
+	case Z_Which_u8:
+		s.SetU8(p.U8)
+	case Z_Which_bool:
+		s.SetBool(p.Bool)
+	case Z_Which_text:
+		if err := s.SetText(p.Text); err != nil {
+			return err
+		}
+	case Z_Which_blob:
+		if err := s.SetBlob(p.Blob); err != nil {
+			return err
+		}
+	case Z_Which_f64vec:
+		if p.F64vec == nil {
+			if err := s.SetF64vec(capnp.Float64List{}); err != nil {
+				return err
+			}
+		} else {
+			l, err := s.NewF64vec(int32(len(p.F64vec)))
+			if err != nil {
+				return err
+			}
+			for i := range p.F64vec {
+				l.Set(i, p.F64vec[i])
+			}
+		}
+	case Z_Which_f32vec:
+		if p.F32vec == nil {
+			if err := s.SetF32vec(capnp.Float32List{}); err != nil {
+				return err
+			}
+		} else {
+			l, err := s.NewF32vec(int32(len(p.F32vec)))
+			if err != nil {
+				return err
+			}
+			for i := range p.F32vec {
+				l.Set(i, p.F32vec[i])
+			}
+		}
+	case Z_Which_i64vec:
+		if p.I64vec == nil {
+			if err := s.SetI64vec(capnp.Int64List{}); err != nil {
+				return err
+			}
+		} else {
+			l, err := s.NewI64vec(int32(len(p.I64vec)))
+			if err != nil {
+				return err
+			}
+			for i := range p.I64vec {
+				l.Set(i, p.I64vec[i])
+			}
+		}
+	case Z_Which_i32vec:
+		if p.I32vec == nil {
+			if err := s.SetI32vec(capnp.Int32List{}); err != nil {
+				return err
+			}
+		} else {
+			l, err := s.NewI32vec(int32(len(p.I32vec)))
+			if err != nil {
+				return err
+			}
+			for i := range p.I32vec {
+				l.Set(i, p.I32vec[i])
+			}
+		}
+	case Z_Which_i16vec:
+		if p.I16vec == nil {
+			if err := s.SetI16vec(capnp.Int16List{}); err != nil {
+				return err
+			}
+		} else {
+			l, err := s.NewI16vec(int32(len(p.I16vec)))
+			if err != nil {
+				return err
+			}
+			for i := range p.I16vec {
+				l.Set(i, p.I16vec[i])
+			}
+		}
+	case Z_Which_i8vec:
+		if p.I8vec == nil {
+			if err := s.SetI8vec(capnp.Int8List{}); err != nil {
+				return err
+			}
+		} else {
+			l, err := s.NewI8vec(int32(len(p.I8vec)))
+			if err != nil {
+				return err
+			}
+			for i := range p.I8vec {
+				l.Set(i, p.I8vec[i])
+			}
+		}
+	case Z_Which_u64vec:
+		if p.U64vec == nil {
+			if err := s.SetU64vec(capnp.UInt64List{}); err != nil {
+				return err
+			}
+		} else {
+			l, err := s.NewU64vec(int32(len(p.U64vec)))
+			if err != nil {
+				return err
+			}
+			for i := range p.U64vec {
+				l.Set(i, p.U64vec[i])
+			}
+		}
+	case Z_Which_u32vec:
+		if p.U32vec == nil {
+			if err := s.SetU32vec(capnp.UInt32List{}); err != nil {
+				return err
+			}
+		} else {
+			l, err := s.NewU32vec(int32(len(p.U32vec)))
+			if err != nil {
+				return err
+			}
+			for i := range p.U32vec {
+				l.Set(i, p.U32vec[i])
+			}
+		}
+	case Z_Which_u16vec:
+		if p.U16vec == nil {
+			if err := s.SetU16vec(capnp.UInt16List{}); err != nil {
+				return err
+			}
+		} else {
+			l, err := s.NewU16vec(int32(len(p.U16vec)))
+			if err != nil {
+				return err
+			}
+			for i := range p.U16vec {
+				l.Set(i, p.U16vec[i])
+			}
+		}
+	case Z_Which_u8vec:
+		if p.U8vec == nil {
+			if err := s.SetU8vec(capnp.UInt8List{}); err != nil {
+				return err
+			}
+		} else {
+			l, err := s.NewU8vec(int32(len(p.U8vec)))
+			if err != nil {
+				return err
+			}
+			for i := range p.U8vec {
+				l.Set(i, p.U8vec[i])
+			}
+		}
+	case Z_Which_boolvec:
+		if p.Boolvec == nil {
+			if err := s.SetBoolvec(capnp.BitList{}); err != nil {
+				return err
+			}
+		} else {
+			l, err := s.NewBoolvec(int32(len(p.Boolvec)))
+			if err != nil {
+				return err
+			}
+			for i := range p.Boolvec {
+				l.Set(i, p.Boolvec[i])
+			}
+		}
+	case Z_Which_datavec:
+		if p.Datavec == nil {
+			if err := s.SetDatavec(capnp.DataList{}); err != nil {
+				return err
+			}
+		} else {
+			l, err := s.NewDatavec(int32(len(p.Datavec)))
+			if err != nil {
+				return err
+			}
+			for i := range p.Datavec {
+				if err := l.Set(i, p.Datavec[i]); err != nil {
+					return err
+				}
+			}
+		}
+	case Z_Which_textvec:
+		if p.Textvec == nil {
+			if err := s.SetTextvec(capnp.TextList{}); err != nil {
+				return err
+			}
+		} else {
+			l, err := s.NewTextvec(int32(len(p.Textvec)))
+			if err != nil {
+				return err
+			}
+			for i := range p.Textvec {
+				if err := l.Set(i, p.Textvec[i]); err != nil {
+					return err
+				}
+			}
+		}
+	case Z_Which_zvec:
+		if p.Zvec == nil {
+			if err := s.SetZvec(Z_List{}); err != nil {
+				return err
+			}
+		} else {
+			l, err := s.NewZvec(int32(len(p.Zvec)))
+			if err != nil {
+				return err
+			}
+			for i := range p.Zvec {
+				if err := p.Zvec[i].Insert(l.At(i)); err != nil {
+					return err
+				}
+			}
+		}
+	case Z_Which_zvecvec:
+		if p.Zvecvec == nil {
+			if err := s.SetZvecvec(capnp.PointerList{}); err != nil {
+				return err
+			}
+		} else {
+			l, err := s.NewZvecvec(int32(len(p.Zvecvec)))
+			if err != nil {
+				return err
+			}
+			for i := range p.Zvecvec {
+				if p.Zvecvec[i] != nil {
+					l1, err := NewZ_List(l.Segment(), int32(len(p.Zvecvec[i])))
+					if err != nil {
+						return err
+					}
+					if err := l.Set(i, l1.ToPtr()); err != nil {
+						return err
+					}
+					for i1 := range p.Zvecvec[i] {
+						if err := p.Zvecvec[i][i1].Insert(l1.At(i1)); err != nil {
+							return err
+						}
+					}
+				}
+			}
+		}
+	case Z_Which_zdate:
+		if p.Zdate == nil {
+			if err := s.SetZdate(Zdate{}); err != nil {
+				return err
+			}
+		} else {
+			v, err := s.NewZdate()
+			if err != nil {
+				return err
+			}
+			if err := p.Zdate.Insert(v); err != nil {
+				return err
+			}
+		}
+	case Z_Which_zdata:
+		if p.Zdata == nil {
+			if err := s.SetZdata(Zdata{}); err != nil {
+				return err
+			}
+		} else {
+			v, err := s.NewZdata()
+			if err != nil {
+				return err
+			}
+			if err := p.Zdata.Insert(v); err != nil {
+				return err
+			}
+		}
+	case Z_Which_aircraftvec:
+		if p.Aircraftvec == nil {
+			if err := s.SetAircraftvec(Aircraft_List{}); err != nil {
+				return err
+			}
+		} else {
+			l, err := s.NewAircraftvec(int32(len(p.Aircraftvec)))
+			if err != nil {
+				return err
+			}
+			for i := range p.Aircraftvec {
+				if err := p.Aircraftvec[i].Insert(l.At(i)); err != nil {
+					return err
+				}
+			}
+		}
+	case Z_Which_aircraft:
+		if p.Aircraft == nil {
+			if err := s.SetAircraft(Aircraft{}); err != nil {
+				return err
+			}
+		} else {
+			v, err := s.NewAircraft()
+			if err != nil {
+				return err
+			}
+			if err := p.Aircraft.Insert(v); err != nil {
+				return err
+			}
+		}
+	case Z_Which_regression:
+		if p.Regression == nil {
+			if err := s.SetRegression(Regression{}); err != nil {
+				return err
+			}
+		} else {
+			v, err := s.NewRegression()
+			if err != nil {
+				return err
+			}
+			if err := p.Regression.Insert(v); err != nil {
+				return err
+			}
+		}
+	case Z_Which_planebase:
+		if p.Planebase == nil {
+			if err := s.SetPlanebase(PlaneBase{}); err != nil {
+				return err
+			}
+		} else {
+			v, err := s.NewPlanebase()
+			if err != nil {
+				return err
+			}
+			if err := p.Planebase.Insert(v); err != nil {
+				return err
+			}
+		}
+	case Z_Which_airport:
+		s.SetAirport(p.Airport)
+	case Z_Which_b737:
+		if p.B737 == nil {
+			if err := s.SetB737(B737{}); err != nil {
+				return err
+			}
+		} else {
+			v, err := s.NewB737()
+			if err != nil {
+				return err
+			}
+			if err := p.B737.Insert(v); err != nil {
+				return err
+			}
+		}
+	case Z_Which_a320:
+		if p.A320 == nil {
+			if err := s.SetA320(A320{}); err != nil {
+				return err
+			}
+		} else {
+			v, err := s.NewA320()
+			if err != nil {
+				return err
+			}
+			if err := p.A320.Insert(v); err != nil {
+				return err
+			}
+		}
+	case Z_Which_f16:
+		if p.F16 == nil {
+			if err := s.SetF16(F16{}); err != nil {
+				return err
+			}
+		} else {
+			v, err := s.NewF16()
+			if err != nil {
+				return err
+			}
+			if err := p.F16.Insert(v); err != nil {
+				return err
+			}
+		}
+	case Z_Which_zdatevec:
+		if p.Zdatevec == nil {
+			if err := s.SetZdatevec(Zdate_List{}); err != nil {
+				return err
+			}
+		} else {
+			l, err := s.NewZdatevec(int32(len(p.Zdatevec)))
+			if err != nil {
+				return err
+			}
+			for i := range p.Zdatevec {
+				if err := p.Zdatevec[i].Insert(l.At(i)); err != nil {
+					return err
+				}
+			}
+		}
+	case Z_Which_zdatavec:
+		if p.Zdatavec == nil {
+			if err := s.SetZdatavec(Zdata_List{}); err != nil {
+				return err
+			}
+		} else {
+			l, err := s.NewZdatavec(int32(len(p.Zdatavec)))
+			if err != nil {
+				return err
+			}
+			for i := range p.Zdatavec {
+				if err := p.Zdatavec[i].Insert(l.At(i)); err != nil {
+					return err
+				}
+			}
+		}
+	case Z_Which_grp:
+		s.SetGrp()
+		if err := p.Grp.Insert(s.Grp()); err != nil {
+			return err
+		}
+	case Z_Which_echo:
+		if err := s.SetEcho(p.Echo.AddRef()); err != nil {
+			return err
+		}
+	case Z_Which_echoes:
+		if p.Echoes == nil {
+			if err := s.SetEchoes(Echo_List{}); err != nil {
+				return err
+			}
+		} else {
+			l, err := s.NewEchoes(int32(len(p.Echoes)))
+			if err != nil {
+				return err
+			}
+			for i := range p.Echoes {
+				if err := l.Set(i, p.Echoes[i].AddRef()); err != nil {
+					return err
+				}
+			}
+		}
+	case Z_Which_anyPtr:
+		if err := s.SetAnyPtr(p.AnyPtr); err != nil {
+			return err
+		}
+	case Z_Which_anyStruct:
+		if err := s.SetAnyStruct(p.AnyStruct); err != nil {
+			return err
+		}
+	case Z_Which_anyList:
+		if err := s.SetAnyList(p.AnyList); err != nil {
+			return err
+		}
+	case Z_Which_anyCapability:
+		if err := s.SetAnyCapability(p.AnyCapability.AddRef()); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("Z: unknown union member %v", p.Which)
+	}
+	return nil
+}
+
+// FromCapnp sets p to the values in s.  Like pogs.Extract, it does not
+// copy everything: Data and AnyPointer fields refer to s's message, and
+// capabilities are borrowed from its capability table, so they are only
+// valid until the message is released.  Use bytes.Clone and AddRef to
+// keep them for longer.
+func (p *ZPogs) FromCapnp(s Z) error {
+	var err error
+	*p = ZPogs{}
+	p.Which = s.Which()
+	switch p.Which {
+	case Z_Which_zz:
+		if v, err := s.Zz(); err != nil {
+			return err
+		} else if v.IsValid() {
+			p.Zz = new(ZPogs)
+			if err := p.Zz.FromCapnp(v); err != nil {
+				return err
+			}
+		}
+	case Z_Which_f64:
+		p.F64 = s.F64()
+	case Z_Which_f32:
+		p.F32 = s.F32()
+	case Z_Which_i64:
+		p.I64 = s.I64()
+	case Z_Which_i32:
+		p.I32 = s.I32()
+	case Z_Which_i16:
+		p.I16 = s.I16()
+	case Z_Which_i8:
+		p.I8 = s.I8()
+	case Z_Which_u64:
+		p.U64 = s.U64()
+	case Z_Which_u32:
+		p.U32 = s.U32()
+	case Z_Which_u16:
+		p.U16 = s.U16()
+	case Z_Which_u8:
+		p.U8 = s.U8()
+	case Z_Which_bool:
+		p.Bool = s.Bool()
+	case Z_Which_text:
+		if p.Text, err = s.Text(); err != nil {
+			return err
+		}
+	case Z_Which_blob:
+		if p.Blob, err = s.Blob(); err != nil {
+			return err
+		}
+	case Z_Which_f64vec:
+		if l, err := s.F64vec(); err != nil {
+			return err
+		} else if l.IsValid() {
+			p.F64vec = make([]float64, l.Len())
+			for i := range p.F64vec {
+				p.F64vec[i] = l.At(i)
+			}
+		}
+	case Z_Which_f32vec:
+		if l, err := s.F32vec(); err != nil {
+			return err
+		} else if l.IsValid() {
+			p.F32vec = make([]float32, l.Len())
+			for i := range p.F32vec {
+				p.F32vec[i] = l.At(i)
+			}
+		}
+	case Z_Which_i64vec:
+		if l, err := s.I64vec(); err != nil {
+			return err
+		} else if l.IsValid() {
+			p.I64vec = make([]int64, l.Len())
+			for i := range p.I64vec {
+				p.I64vec[i] = l.At(i)
+			}
+		}
+	case Z_Which_i32vec:
+		if l, err := s.I32vec(); err != nil {
+			return err
+		} else if l.IsValid() {
+			p.I32vec = make([]int32, l.Len())
+			for i := range p.I32vec {
+				p.I32vec[i] = l.At(i)
+			}
+		}
+	case Z_Which_i16vec:
+		if l, err := s.I16vec(); err != nil {
+			return err
+		} else if l.IsValid() {
+			p.I16vec = make([]int16, l.Len())
+			for i := range p.I16vec {
+				p.I16vec[i] = l.At(i)
+			}
+		}
+	case Z_Which_i8vec:
+		if l, err := s.I8vec(); err != nil {
+			return err
+		} else if l.IsValid() {
+			p.I8vec = make([]int8, l.Len())
+			for i := range p.I8vec {
+				p.I8vec[i] = l.At(i)
+			}
+		}
+	case Z_Which_u64vec:
+		if l, err := s.U64vec(); err != nil {
+			return err
+		} else if l.IsValid() {
+			p.U64vec = make([]uint64, l.Len())
+			for i := range p.U64vec {
+				p.U64vec[i] = l.At(i)
+			}
+		}
+	case Z_Which_u32vec:
+		if l, err := s.U32vec(); err != nil {
+			return err
+		} else if l.IsValid() {
+			p.U32vec = make([]uint32, l.Len())
+			for i := range p.U32vec {
+				p.U32vec[i] = l.At(i)
+			}
+		}
+	case Z_Which_u16vec:
+		if l, err := s.U16vec(); err != nil {
+			return err
+		} else if l.IsValid() {
+			p.U16vec = make([]uint16, l.Len())
+			for i := range p.U16vec {
+				p.U16vec[i] = l.At(i)
+			}
+		}
+	case Z_Which_u8vec:
+		if l, err := s.U8vec(); err != nil {
+			return err
+		} else if l.IsValid() {
+			p.U8vec = make([]uint8, l.Len())
+			for i := range p.U8vec {
+				p.U8vec[i] = l.At(i)
+			}
+		}
+	case Z_Which_boolvec:
+		if l, err := s.Boolvec(); err != nil {
+			return err
+		} else if l.IsValid() {
+			p.Boolvec = make([]bool, l.Len())
+			for i := range p.Boolvec {
+				p.Boolvec[i] = l.At(i)
+			}
+		}
+	case Z_Which_datavec:
+		if l, err := s.Datavec(); err != nil {
+			return err
+		} else if l.IsValid() {
+			p.Datavec = make([][]byte, l.Len())
+			for i := range p.Datavec {
+				if p.Datavec[i], err = l.At(i); err != nil {
+					return err
+				}
+			}
+		}
+	case Z_Which_textvec:
+		if l, err := s.Textvec(); err != nil {
+			return err
+		} else if l.IsValid() {
+			p.Textvec = make([]string, l.Len())
+			for i := range p.Textvec {
+				if p.Textvec[i], err = l.At(i); err != nil {
+					return err
+				}
+			}
+		}
+	case Z_Which_zvec:
+		if l, err := s.Zvec(); err != nil {
+			return err
+		} else if l.IsValid() {
+			p.Zvec = make([]ZPogs, l.Len())
+			for i := range p.Zvec {
+				if err := p.Zvec[i].FromCapnp(l.At(i)); err != nil {
+					return err
+				}
+			}
+		}
+	case Z_Which_zvecvec:
+		if l, err := s.Zvecvec(); err != nil {
+			return err
+		} else if l.IsValid() {
+			p.Zvecvec = make([][]ZPogs, l.Len())
+			for i := range p.Zvecvec {
+				if p1, err := l.At(i); err != nil {
+					return err
+				} else if l1 := Z_List(p1.List()); l1.IsValid() {
+					p.Zvecvec[i] = make([]ZPogs, l1.Len())
+					for i1 := range p.Zvecvec[i] {
+						if err := p.Zvecvec[i][i1].FromCapnp(l1.At(i1)); err != nil {
+							return err
+						}
+					}
+				}
+			}
+		}
+	case Z_Which_zdate:
+		if v, err := s.Zdate(); err != nil {
+			return err
+		} else if v.IsValid() {
+			p.Zdate = new(ZdatePogs)
+			if err := p.Zdate.FromCapnp(v); err != nil {
+				return err
+			}
+		}
+	case Z_Which_zdata:
+		if v, err := s.Zdata(); err != nil {
+			return err
+		} else if v.IsValid() {
+			p.Zdata = new(ZdataPogs)
+			if err := p.Zdata.FromCapnp(v); err != nil {
+				return err
+			}
+		}
+	case Z_Which_aircraftvec:
+		if l, err := s.Aircraftvec(); err != nil {
+			return err
+		} else if l.IsValid() {
+			p.Aircraftvec = make([]AircraftPogs, l.Len())
+			for i := range p.Aircraftvec {
+				if err := p.Aircraftvec[i].FromCapnp(l.At(i)); err != nil {
+					return err
+				}
+			}
+		}
+	case Z_Which_aircraft:
+		if v, err := s.Aircraft(); err != nil {
+			return err
+		} else if v.IsValid() {
+			p.Aircraft = new(AircraftPogs)
+			if err := p.Aircraft.FromCapnp(v); err != nil {
+				return err
+			}
+		}
+	case Z_Which_regression:
+		if v, err := s.Regression(); err != nil {
+			return err
+		} else if v.IsValid() {
+			p.Regression = new(RegressionPogs)
+			if err := p.Regression.FromCapnp(v); err != nil {
+				return err
+			}
+		}
+	case Z_Which_planebase:
+		if v, err := s.Planebase(); err != nil {
+			return err
+		} else if v.IsValid() {
+			p.Planebase = new(PlaneBasePogs)
+			if err := p.Planebase.FromCapnp(v); err != nil {
+				return err
+			}
+		}
+	case Z_Which_airport:
+		p.Airport = s.Airport()
+	case Z_Which_b737:
+		if v, err := s.B737(); err != nil {
+			return err
+		} else if v.IsValid() {
+			p.B737 = new(B737Pogs)
+			if err := p.B737.FromCapnp(v); err != nil {
+				return err
+			}
+		}
+	case Z_Which_a320:
+		if v, err := s.A320(); err != nil {
+			return err
+		} else if v.IsValid() {
+			p.A320 = new(A320Pogs)
+			if err := p.A320.FromCapnp(v); err != nil {
+				return err
+			}
+		}
+	case Z_Which_f16:
+		if v, err := s.F16(); err != nil {
+			return err
+		} else if v.IsValid() {
+			p.F16 = new(F16Pogs)
+			if err := p.F16.FromCapnp(v); err != nil {
+				return err
+			}
+		}
+	case Z_Which_zdatevec:
+		if l, err := s.Zdatevec(); err != nil {
+			return err
+		} else if l.IsValid() {
+			p.Zdatevec = make([]ZdatePogs, l.Len())
+			for i := range p.Zdatevec {
+				if err := p.Zdatevec[i].FromCapnp(l.At(i)); err != nil {
+					return err
+				}
+			}
+		}
+	case Z_Which_zdatavec:
+		if l, err := s.Zdatavec(); err != nil {
+			return err
+		} else if l.IsValid() {
+			p.Zdatavec = make([]ZdataPogs, l.Len())
+			for i := range p.Zdatavec {
+				if err := p.Zdatavec[i].FromCapnp(l.At(i)); err != nil {
+					return err
+				}
+			}
+		}
+	case Z_Which_grp:
+		if err := p.Grp.FromCapnp(s.Grp()); err != nil {
+			return err
+		}
+	case Z_Which_echo:
+		p.Echo = s.Echo()
+	case Z_Which_echoes:
+		if l, err := s.Echoes(); err != nil {
+			return err
+		} else if l.IsValid() {
+			p.Echoes = make([]Echo, l.Len())
+			for i := range p.Echoes {
+				if p.Echoes[i], err = l.At(i); err != nil {
+					return err
+				}
+			}
+		}
+	case Z_Which_anyPtr:
+		if p.AnyPtr, err = s.AnyPtr(); err != nil {
+			return err
+		}
+	case Z_Which_anyStruct:
+		if p.AnyStruct, err = s.AnyStruct(); err != nil {
+			return err
+		}
+	case Z_Which_anyList:
+		if p.AnyList, err = s.AnyList(); err != nil {
+			return err
+		}
+	case Z_Which_anyCapability:
+		p.AnyCapability = s.AnyCapability()
+	}
+	return nil
+}
+
+// Z_grpPogs is a plain Go representation of the Z_grp group.
+type Z_grpPogs struct {
+	First  uint64
+	Second uint64
+}
+
+// Insert copies p into s.  Capabilities in p are not consumed: s gets
+// its own references.
+func (p *Z_grpPogs) Insert(s Z_grp) error {
+	s.SetFirst(p.First)
+	s.SetSecond(p.Second)
+	return nil
+}
+
+// FromCapnp sets p to the values in s.  Like pogs.Extract, it does not
+// copy everything: Data and AnyPointer fields refer to s's message, and
+// capabilities are borrowed from its capability table, so they are only
+// valid until the message is released.  Use bytes.Clone and AddRef to
+// keep them for longer.
+func (p *Z_grpPogs) FromCapnp(s Z_grp) error {
+	*p = Z_grpPogs{}
+	p.First = s.First()
+	p.Second = s.Second()
+	return nil
+}
+
 type Counter capnp.Struct
 
 // Counter_TypeID is the unique identifier for the type Counter.
@@ -2659,6 +4068,86 @@ func (f Counter_Future) Struct() (Counter, error) {
 	return Counter(p.Struct()), err
 }
 
+// CounterPogs is a plain Go representation of Counter.
+type CounterPogs struct {
+	Size     int64
+	Words    string
+	Wordlist []string
+	Bitlist  []bool
+}
+
+// ToCapnp allocates a new Counter in seg and copies p into it.
+func (p *CounterPogs) ToCapnp(seg *capnp.Segment) (Counter, error) {
+	s, err := NewCounter(seg)
+	if err != nil {
+		return Counter{}, err
+	}
+	return s, p.Insert(s)
+}
+
+// Insert copies p into s.  Capabilities in p are not consumed: s gets
+// its own references.
+func (p *CounterPogs) Insert(s Counter) error {
+	s.SetSize(p.Size)
+	if err := s.SetWords(p.Words); err != nil {
+		return err
+	}
+	if p.Wordlist != nil {
+		l, err := s.NewWordlist(int32(len(p.Wordlist)))
+		if err != nil {
+			return err
+		}
+		for i := range p.Wordlist {
+			if err := l.Set(i, p.Wordlist[i]); err != nil {
+				return err
+			}
+		}
+	}
+	if p.Bitlist != nil {
+		l, err := s.NewBitlist(int32(len(p.Bitlist)))
+		if err != nil {
+			return err
+		}
+		for i := range p.Bitlist {
+			l.Set(i, p.Bitlist[i])
+		}
+	}
+	return nil
+}
+
+// FromCapnp sets p to the values in s.  Like pogs.Extract, it does not
+// copy everything: Data and AnyPointer fields refer to s's message, and
+// capabilities are borrowed from its capability table, so they are only
+// valid until the message is released.  Use bytes.Clone and AddRef to
+// keep them for longer.
+func (p *CounterPogs) FromCapnp(s Counter) error {
+	var err error
+	*p = CounterPogs{}
+	p.Size = s.Size()
+	if p.Words, err = s.Words(); err != nil {
+		return err
+	}
+	if l, err := s.Wordlist(); err != nil {
+		return err
+	} else if l.IsValid() {
+		p.Wordlist = make([]string, l.Len())
+		for i := range p.Wordlist {
+			if p.Wordlist[i], err = l.At(i); err != nil {
+				return err
+			}
+		}
+	}
+	if l, err := s.Bitlist(); err != nil {
+		return err
+	} else if l.IsValid() {
+		p.Bitlist = make([]bool, l.Len())
+		for i := range p.Bitlist {
+			p.Bitlist[i] = l.At(i)
+		}
+	}
+	return nil
+}
+
 type Bag capnp.Struct
 
 // Bag_TypeID is the unique identifier for the type Bag.
@@ -2750,6 +4239,53 @@ func (p Bag_Future) Counter() Counter_Future {
 	return Counter_Future{Future: p.Future.Field(0, nil)}
 }
 
+// BagPogs is a plain Go representation of Bag.
+type BagPogs struct {
+	Counter *CounterPogs
+}
+
+// ToCapnp allocates a new Bag in seg and copies p into it.
+func (p *BagPogs) ToCapnp(seg *capnp.Segment) (Bag, error) {
+	s, err := NewBag(seg)
+	if err != nil {
+		return Bag{}, err
+	}
+	return s, p.Insert(s)
+}
+
+// Insert copies p into s.  Capabilities in p are not consumed: s gets
+// its own references.
+func (p *BagPogs) Insert(s Bag) error {
+	if p.Counter != nil {
+		v, err := s.NewCounter()
+		if err != nil {
+			return err
+		}
+		if err := p.Counter.Insert(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FromCapnp sets p to the values in s.  Like pogs.Extract, it does not
+// copy everything: Data and AnyPointer fields refer to s's message, and
+// capabilities are borrowed from its capability table, so they are only
+// valid until the message is released.  Use bytes.Clone and AddRef to
+// keep them for longer.
+func (p *BagPogs) FromCapnp(s Bag) error {
+	*p = BagPogs{}
+	if v, err := s.Counter(); err != nil {
+		return err
+	} else if v.IsValid() {
+		p.Counter = new(CounterPogs)
+		if err := p.Counter.FromCapnp(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 type Zserver capnp.Struct
 
 // Zserver_TypeID is the unique identifier for the type Zserver.
@@ -2838,6 +4374,57 @@ func (f Zserver_Future) Struct() (Zserver, error) {
 	return Zserver(p.Struct()), err
 }
 
+// ZserverPogs is a plain Go representation of Zserver.
+type ZserverPogs struct {
+	Waitingjobs []ZjobPogs
+}
+
+// ToCapnp allocates a new Zserver in seg and copies p into it.
+func (p *ZserverPogs) ToCapnp(seg *capnp.Segment) (Zserver, error) {
+	s, err := NewZserver(seg)
+	if err != nil {
+		return Zserver{}, err
+	}
+	return s, p.Insert(s)
+}
+
+// Insert copies p into s.  Capabilities in p are not consumed: s gets
+// its own references.
+func (p *ZserverPogs) Insert(s Zserver) error {
+	if p.Waitingjobs != nil {
+		l, err := s.NewWaitingjobs(int32(len(p.Waitingjobs)))
+		if err != nil {
+			return err
+		}
+		for i := range p.Waitingjobs {
+			if err := p.Waitingjobs[i].Insert(l.At(i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// FromCapnp sets p to the values in s.  Like pogs.Extract, it does not
+// copy everything: Data and AnyPointer fields refer to s's message, and
+// capabilities are borrowed from its capability table, so they are only
+// valid until the message is released.  Use bytes.Clone and AddRef to
+// keep them for longer.
+func (p *ZserverPogs) FromCapnp(s Zserver) error {
+	*p = ZserverPogs{}
+	if l, err := s.Waitingjobs(); err != nil {
+		return err
+	} else if l.IsValid() {
+		p.Waitingjobs = make([]ZjobPogs, l.Len())
+		for i := range p.Waitingjobs {
+			if err := p.Waitingjobs[i].FromCapnp(l.At(i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 type Zjob capnp.Struct
 
 // Zjob_TypeID is the unique identifier for the type Zjob.
@@ -2944,6 +4531,65 @@ func (f Zjob_Future) Struct() (Zjob, error) {
 	return Zjob(p.Struct()), err
 }
 
+// ZjobPogs is a plain Go representation of Zjob.
+type ZjobPogs struct {
+	Cmd  string
+	Args []string
+}
+
+// ToCapnp allocates a new Zjob in seg and copies p into it.
+func (p *ZjobPogs) ToCapnp(seg *capnp.Segment) (Zjob, error) {
+	s, err := NewZjob(seg)
+	if err != nil {
+		return Zjob{}, err
+	}
+	return s, p.Insert(s)
+}
+
+// Insert copies p into s.  Capabilities in p are not consumed: s gets
+// its own references.
+func (p *ZjobPogs) Insert(s Zjob) error {
+	if err := s.SetCmd(p.Cmd); err != nil {
+		return err
+	}
+	if p.Args != nil {
+		l, err := s.NewArgs(int32(len(p.Args)))
+		if err != nil {
+			return err
+		}
+		for i := range p.Args {
+			if err := l.Set(i, p.Args[i]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// FromCapnp sets p to the values in s.  Like pogs.Extract, it does not
+// copy everything: Data and AnyPointer fields refer to s's message, and
+// capabilities are borrowed from its capability table, so they are only
+// valid until the message is released.  Use bytes.Clone and AddRef to
+// keep them for longer.
+func (p *ZjobPogs) FromCapnp(s Zjob) error {
+	var err error
+	*p = ZjobPogs{}
+	if p.Cmd, err = s.Cmd(); err != nil {
+		return err
+	}
+	if l, err := s.Args(); err != nil {
+		return err
+	} else if l.IsValid() {
+		p.Args = make([]string, l.Len())
+		for i := range p.Args {
+			if p.Args[i], err = l.At(i); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 type VerEmpty capnp.Struct
 
 // VerEmpty_TypeID is the unique identifier for the type VerEmpty.
@@ -3009,6 +4655,35 @@ func (f VerEmpty_Future) Struct() (VerEmpty, error) {
 	return VerEmpty(p.Struct()), err
 }
 
+// VerEmptyPogs is a plain Go representation of VerEmpty.
+type VerEmptyPogs struct {
+}
+
+// ToCapnp allocates a new VerEmpty in seg and copies p into it.
+func (p *VerEmptyPogs) ToCapnp(seg *capnp.Segment) (VerEmpty, error) {
+	s, err := NewVerEmpty(seg)
+	if err != nil {
+		return VerEmpty{}, err
+	}
+	return s, p.Insert(s)
+}
+
+// Insert copies p into s.  Capabilities in p are not consumed: s gets
+// its own references.
+func (p *VerEmptyPogs) Insert(s VerEmpty) error {
+	return nil
+}
+
+// FromCapnp sets p to the values in s.  Like pogs.Extract, it does not
+// copy everything: Data and AnyPointer fields refer to s's message, and
+// capabilities are borrowed from its capability table, so they are only
+// valid until the message is released.  Use bytes.Clone and AddRef to
+// keep them for longer.
+func (p *VerEmptyPogs) FromCapnp(s VerEmpty) error {
+	*p = VerEmptyPogs{}
+	return nil
+}
+
 type VerOneData capnp.Struct
 
 // VerOneData_TypeID is the unique identifier for the type VerOneData.
@@ -3081,9 +4756,41 @@ func (f VerOneData_Future) Struct() (VerOneData, error) {
 	return VerOneData(p.Struct()), err
 }
 
-type VerTwoData capnp.Struct
+// VerOneDataPogs is a plain Go representation of VerOneData.
+type VerOneDataPogs struct {
+	Val int16
+}
 
-// VerTwoData_TypeID is the unique identifier for the type VerTwoData.
+// ToCapnp allocates a new VerOneData in seg and copies p into it.
+func (p *VerOneDataPogs) ToCapnp(seg *capnp.Segment) (VerOneData, error) {
+	s, err := NewVerOneData(seg)
+	if err != nil {
+		return VerOneData{}, err
+	}
+	return s, p.Insert(s)
+}
+
+// Insert copies p into s.  Capabilities in p are not consumed: s gets
+// its own references.
+func (p *VerOneDataPogs) Insert(s VerOneData) error {
+	s.SetVal(p.Val)
+	return nil
+}
+
+// FromCapnp sets p to the values in s.  Like pogs.Extract, it does not
+// copy everything: Data and AnyPointer fields refer to s's message, and
+// capabilities are borrowed from its capability table, so they are only
+// valid until the message is released.  Use bytes.Clone and AddRef to
+// keep them for longer.
+func (p *VerOneDataPogs) FromCapnp(s VerOneData) error {
+	*p = VerOneDataPogs{}
+	p.Val = s.Val()
+	return nil
+}
+
+type VerTwoData capnp.Struct
+
+// VerTwoData_TypeID is the unique identifier for the type VerTwoData.
 const VerTwoData_TypeID = 0xf705dc45c94766fd
 
 func NewVerTwoData(s *capnp.Segment) (VerTwoData, error) {
@@ -3161,6 +4868,41 @@ func (f VerTwoData_Future) Struct() (VerTwoData, error) {
 	return VerTwoData(p.Struct()), err
 }
 
+// VerTwoDataPogs is a plain Go representation of VerTwoData.
+type VerTwoDataPogs struct {
+	Val int16
+	Duo int64
+}
+
+// ToCapnp allocates a new VerTwoData in seg and copies p into it.
+func (p *VerTwoDataPogs) ToCapnp(seg *capnp.Segment) (VerTwoData, error) {
+	s, err := NewVerTwoData(seg)
+	if err != nil {
+		return VerTwoData{}, err
+	}
+	return s, p.Insert(s)
+}
+
+// Insert copies p into s.  Capabilities in p are not consumed: s gets
+// its own references.
+func (p *VerTwoDataPogs) Insert(s VerTwoData) error {
+	s.SetVal(p.Val)
+	s.SetDuo(p.Duo)
+	return nil
+}
+
+// FromCapnp sets p to the values in s.  Like pogs.Extract, it does not
+// copy everything: Data and AnyPointer fields refer to s's message, and
+// capabilities are borrowed from its capability table, so they are only
+// valid until the message is released.  Use bytes.Clone and AddRef to
+// keep them for longer.
+func (p *VerTwoDataPogs) FromCapnp(s VerTwoData) error {
+	*p = VerTwoDataPogs{}
+	p.Val = s.Val()
+	p.Duo = s.Duo()
+	return nil
+}
+
 type VerOnePtr capnp.Struct
 
 // VerOnePtr_TypeID is the unique identifier for the type VerOnePtr.
@@ -3252,6 +4994,53 @@ func (p VerOnePtr_Future) Ptr() VerOneData_Future {
 	return VerOneData_Future{Future: p.Future.Field(0, nil)}
 }
 
+// VerOnePtrPogs is a plain Go representation of VerOnePtr.
+type VerOnePtrPogs struct {
+	Ptr *VerOneDataPogs
+}
+
+// ToCapnp allocates a new VerOnePtr in seg and copies p into it.
+func (p *VerOnePtrPogs) ToCapnp(seg *capnp.Segment) (VerOnePtr, error) {
+	s, err := NewVerOnePtr(seg)
+	if err != nil {
+		return VerOnePtr{}, err
+	}
+	return s, p.Insert(s)
+}
+
+// Insert copies p into s.  Capabilities in p are not consumed: s gets
+// its own references.
+func (p *VerOnePtrPogs) Insert(s VerOnePtr) error {
+	if p.Ptr != nil {
+		v, err := s.NewPtr()
+		if err != nil {
+			return err
+		}
+		if err := p.Ptr.Insert(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FromCapnp sets p to the values in s.  Like pogs.Extract, it does not
+// copy everything: Data and AnyPointer fields refer to s's message, and
+// capabilities are borrowed from its capability table, so they are only
+// valid until the message is released.  Use bytes.Clone and AddRef to
+// keep them for longer.
+func (p *VerOnePtrPogs) FromCapnp(s VerOnePtr) error {
+	*p = VerOnePtrPogs{}
+	if v, err := s.Ptr(); err != nil {
+		return err
+	} else if v.IsValid() {
+		p.Ptr = new(VerOneDataPogs)
+		if err := p.Ptr.FromCapnp(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 type VerTwoPtr capnp.Struct
 
 // VerTwoPtr_TypeID is the unique identifier for the type VerTwoPtr.
@@ -3370,6 +5159,71 @@ func (p VerTwoPtr_Future) Ptr2() VerOneData_Future {
 	return VerOneData_Future{Future: p.Future.Field(1, nil)}
 }
 
+// VerTwoPtrPogs is a plain Go representation of VerTwoPtr.
+type VerTwoPtrPogs struct {
+	Ptr1 *VerOneDataPogs
+	Ptr2 *VerOneDataPogs
+}
+
+// ToCapnp allocates a new VerTwoPtr in seg and copies p into it.
+func (p *VerTwoPtrPogs) ToCapnp(seg *capnp.Segment) (VerTwoPtr, error) {
+	s, err := NewVerTwoPtr(seg)
+	if err != nil {
+		return VerTwoPtr{}, err
+	}
+	return s, p.Insert(s)
+}
+
+// Insert copies p into s.  Capabilities in p are not consumed: s gets
+// its own references.
+func (p *VerTwoPtrPogs) Insert(s VerTwoPtr) error {
+	if p.Ptr1 != nil {
+		v, err := s.NewPtr1()
+		if err != nil {
+			return err
+		}
+		if err := p.Ptr1.Insert(v); err != nil {
+			return err
+		}
+	}
+	if p.Ptr2 != nil {
+		v, err := s.NewPtr2()
+		if err != nil {
+			return err
+		}
+		if err := p.Ptr2.Insert(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FromCapnp sets p to the values in s.  Like pogs.Extract, it does not
+// copy everything: Data and AnyPointer fields refer to s's message, and
+// capabilities are borrowed from its capability table, so they are only
+// valid until the message is released.  Use bytes.Clone and AddRef to
+// keep them for longer.
+func (p *VerTwoPtrPogs) FromCapnp(s VerTwoPtr) error {
+	*p = VerTwoPtrPogs{}
+	if v, err := s.Ptr1(); err != nil {
+		return err
+	} else if v.IsValid() {
+		p.Ptr1 = new(VerOneDataPogs)
+		if err := p.Ptr1.FromCapnp(v); err != nil {
+			return err
+		}
+	}
+	if v, err := s.Ptr2(); err != nil {
+		return err
+	} else if v.IsValid() {
+		p.Ptr2 = new(VerOneDataPogs)
+		if err := p.Ptr2.FromCapnp(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 type VerTwoDataTwoPtr capnp.Struct
 
 // VerTwoDataTwoPtr_TypeID is the unique identifier for the type VerTwoDataTwoPtr.
@@ -3504,6 +5358,77 @@ func (p VerTwoDataTwoPtr_Future) Ptr2() VerOneData_Future {
 	return VerOneData_Future{Future: p.Future.Field(1, nil)}
 }
 
+// VerTwoDataTwoPtrPogs is a plain Go representation of VerTwoDataTwoPtr.
+type VerTwoDataTwoPtrPogs struct {
+	Val  int16
+	Duo  int64
+	Ptr1 *VerOneDataPogs
+	Ptr2 *VerOneDataPogs
+}
+
+// ToCapnp allocates a new VerTwoDataTwoPtr in seg and copies p into it.
+func (p *VerTwoDataTwoPtrPogs) ToCapnp(seg *capnp.Segment) (VerTwoDataTwoPtr, error) {
+	s, err := NewVerTwoDataTwoPtr(seg)
+	if err != nil {
+		return VerTwoDataTwoPtr{}, err
+	}
+	return s, p.Insert(s)
+}
+
+// Insert copies p into s.  Capabilities in p are not consumed: s gets
+// its own references.
+func (p *VerTwoDataTwoPtrPogs) Insert(s VerTwoDataTwoPtr) error {
+	s.SetVal(p.Val)
+	s.SetDuo(p.Duo)
+	if p.Ptr1 != nil {
+		v, err := s.NewPtr1()
+		if err != nil {
+			return err
+		}
+		if err := p.Ptr1.Insert(v); err != nil {
+			return err
+		}
+	}
+	if p.Ptr2 != nil {
+		v, err := s.NewPtr2()
+		if err != nil {
+			return err
+		}
+		if err := p.Ptr2.Insert(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FromCapnp sets p to the values in s.  Like pogs.Extract, it does not
+// copy everything: Data and AnyPointer fields refer to s's message, and
+// capabilities are borrowed from its capability table, so they are only
+// valid until the message is released.  Use bytes.Clone and AddRef to
+// keep them for longer.
+func (p *VerTwoDataTwoPtrPogs) FromCapnp(s VerTwoDataTwoPtr) error {
+	*p = VerTwoDataTwoPtrPogs{}
+	p.Val = s.Val()
+	p.Duo = s.Duo()
+	if v, err := s.Ptr1(); err != nil {
+		return err
+	} else if v.IsValid() {
+		p.Ptr1 = new(VerOneDataPogs)
+		if err := p.Ptr1.FromCapnp(v); err != nil {
+			return err
+		}
+	}
+	if v, err := s.Ptr2(); err != nil {
+		return err
+	} else if v.IsValid() {
+		p.Ptr2 = new(VerOneDataPogs)
+		if err := p.Ptr2.FromCapnp(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 type HoldsVerEmptyList capnp.Struct
 
 // HoldsVerEmptyList_TypeID is the unique identifier for the type HoldsVerEmptyList.
@@ -3592,6 +5517,57 @@ func (f HoldsVerEmptyList_Future) Struct() (HoldsVerEmptyList, error) {
 	return HoldsVerEmptyList(p.Struct()), err
 }
 
+// HoldsVerEmptyListPogs is a plain Go representation of HoldsVerEmptyList.
+type HoldsVerEmptyListPogs struct {
+	Mylist []VerEmptyPogs
+}
+
+// ToCapnp allocates a new HoldsVerEmptyList in seg and copies p into it.
+func (p *HoldsVerEmptyListPogs) ToCapnp(seg *capnp.Segment) (HoldsVerEmptyList, error) {
+	s, err := NewHoldsVerEmptyList(seg)
+	if err != nil {
+		return HoldsVerEmptyList{}, err
+	}
+	return s, p.Insert(s)
+}
+
+// Insert copies p into s.  Capabilities in p are not consumed: s gets
+// its own references.
+func (p *HoldsVerEmptyListPogs) Insert(s HoldsVerEmptyList) error {
+	if p.Mylist != nil {
+		l, err := s.NewMylist(int32(len(p.Mylist)))
+		if err != nil {
+			return err
+		}
+		for i := range p.Mylist {
+			if err := p.Mylist[i].Insert(l.At(i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// FromCapnp sets p to the values in s.  Like pogs.Extract, it does not
+// copy everything: Data and AnyPointer fields refer to s's message, and
+// capabilities are borrowed from its capability table, so they are only
+// valid until the message is released.  Use bytes.Clone and AddRef to
+// keep them for longer.
+func (p *HoldsVerEmptyListPogs) FromCapnp(s HoldsVerEmptyList) error {
+	*p = HoldsVerEmptyListPogs{}
+	if l, err := s.Mylist(); err != nil {
+		return err
+	} else if l.IsValid() {
+		p.Mylist = make([]VerEmptyPogs, l.Len())
+		for i := range p.Mylist {
+			if err := p.Mylist[i].FromCapnp(l.At(i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 type HoldsVerOneDataList capnp.Struct
 
 // HoldsVerOneDataList_TypeID is the unique identifier for the type HoldsVerOneDataList.
@@ -3680,6 +5656,57 @@ func (f HoldsVerOneDataList_Future) Struct() (HoldsVerOneDataList, error) {
 	return HoldsVerOneDataList(p.Struct()), err
 }
 
+// HoldsVerOneDataListPogs is a plain Go representation of HoldsVerOneDataList.
+type HoldsVerOneDataListPogs struct {
+	Mylist []VerOneDataPogs
+}
+
+// ToCapnp allocates a new HoldsVerOneDataList in seg and copies p into it.
+func (p *HoldsVerOneDataListPogs) ToCapnp(seg *capnp.Segment) (HoldsVerOneDataList, error) {
+	s, err := NewHoldsVerOneDataList(seg)
+	if err != nil {
+		return HoldsVerOneDataList{}, err
+	}
+	return s, p.Insert(s)
+}
+
+// Insert copies p into s.  Capabilities in p are not consumed: s gets
+// its own references.
+func (p *HoldsVerOneDataListPogs) Insert(s HoldsVerOneDataList) error {
+	if p.Mylist != nil {
+		l, err := s.NewMylist(int32(len(p.Mylist)))
+		if err != nil {
+			return err
+		}
+		for i := range p.Mylist {
+			if err := p.Mylist[i].Insert(l.At(i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// FromCapnp sets p to the values in s.  Like pogs.Extract, it does not
+// copy everything: Data and AnyPointer fields refer to s's message, and
+// capabilities are borrowed from its capability table, so they are only
+// valid until the message is released.  Use bytes.Clone and AddRef to
+// keep them for longer.
+func (p *HoldsVerOneDataListPogs) FromCapnp(s HoldsVerOneDataList) error {
+	*p = HoldsVerOneDataListPogs{}
+	if l, err := s.Mylist(); err != nil {
+		return err
+	} else if l.IsValid() {
+		p.Mylist = make([]VerOneDataPogs, l.Len())
+		for i := range p.Mylist {
+			if err := p.Mylist[i].FromCapnp(l.At(i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 type HoldsVerTwoDataList capnp.Struct
 
 // HoldsVerTwoDataList_TypeID is the unique identifier for the type HoldsVerTwoDataList.
@@ -3768,6 +5795,57 @@ func (f HoldsVerTwoDataList_Future) Struct() (HoldsVerTwoDataList, error) {
 	return HoldsVerTwoDataList(p.Struct()), err
 }
 
+// HoldsVerTwoDataListPogs is a plain Go representation of HoldsVerTwoDataList.
+type HoldsVerTwoDataListPogs struct {
+	Mylist []VerTwoDataPogs
+}
+
+// ToCapnp allocates a new HoldsVerTwoDataList in seg and copies p into it.
+func (p *HoldsVerTwoDataListPogs) ToCapnp(seg *capnp.Segment) (HoldsVerTwoDataList, error) {
+	s, err := NewHoldsVerTwoDataList(seg)
+	if err != nil {
+		return HoldsVerTwoDataList{}, err
+	}
+	return s, p.Insert(s)
+}
+
+// Insert copies p into s.  Capabilities in p are not consumed: s gets
+// its own references.
+func (p *HoldsVerTwoDataListPogs) Insert(s HoldsVerTwoDataList) error {
+	if p.Mylist != nil {
+		l, err := s.NewMylist(int32(len(p.Mylist)))
+		if err != nil {
+			return err
+		}
+		for i := range p.Mylist {
+			if err := p.Mylist[i].Insert(l.At(i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// FromCapnp sets p to the values in s.  Like pogs.Extract, it does not
+// copy everything: Data and AnyPointer fields refer to s's message, and
+// capabilities are borrowed from its capability table, so they are only
+// valid until the message is released.  Use bytes.Clone and AddRef to
+// keep them for longer.
+func (p *HoldsVerTwoDataListPogs) FromCapnp(s HoldsVerTwoDataList) error {
+	*p = HoldsVerTwoDataListPogs{}
+	if l, err := s.Mylist(); err != nil {
+		return err
+	} else if l.IsValid() {
+		p.Mylist = make([]VerTwoDataPogs, l.Len())
+		for i := range p.Mylist {
+			if err := p.Mylist[i].FromCapnp(l.At(i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 type HoldsVerOnePtrList capnp.Struct
 
 // HoldsVerOnePtrList_TypeID is the unique identifier for the type HoldsVerOnePtrList.
@@ -3856,6 +5934,57 @@ func (f HoldsVerOnePtrList_Future) Struct() (HoldsVerOnePtrList, error) {
 	return HoldsVerOnePtrList(p.Struct()), err
 }
 
+// HoldsVerOnePtrListPogs is a plain Go representation of HoldsVerOnePtrList.
+type HoldsVerOnePtrListPogs struct {
+	Mylist []VerOnePtrPogs
+}
+
+// ToCapnp allocates a new HoldsVerOnePtrList in seg and copies p into it.
+func (p *HoldsVerOnePtrListPogs) ToCapnp(seg *capnp.Segment) (HoldsVerOnePtrList, error) {
+	s, err := NewHoldsVerOnePtrList(seg)
+	if err != nil {
+		return HoldsVerOnePtrList{}, err
+	}
+	return s, p.Insert(s)
+}
+
+// Insert copies p into s.  Capabilities in p are not consumed: s gets
+// its own references.
+func (p *HoldsVerOnePtrListPogs) Insert(s HoldsVerOnePtrList) error {
+	if p.Mylist != nil {
+		l, err := s.NewMylist(int32(len(p.Mylist)))
+		if err != nil {
+			return err
+		}
+		for i := range p.Mylist {
+			if err := p.Mylist[i].Insert(l.At(i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// FromCapnp sets p to the values in s.  Like pogs.Extract, it does not
+// copy everything: Data and AnyPointer fields refer to s's message, and
+// capabilities are borrowed from its capability table, so they are only
+// valid until the message is released.  Use bytes.Clone and AddRef to
+// keep them for longer.
+func (p *HoldsVerOnePtrListPogs) FromCapnp(s HoldsVerOnePtrList) error {
+	*p = HoldsVerOnePtrListPogs{}
+	if l, err := s.Mylist(); err != nil {
+		return err
+	} else if l.IsValid() {
+		p.Mylist = make([]VerOnePtrPogs, l.Len())
+		for i := range p.Mylist {
+			if err := p.Mylist[i].FromCapnp(l.At(i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 type HoldsVerTwoPtrList capnp.Struct
 
 // HoldsVerTwoPtrList_TypeID is the unique identifier for the type HoldsVerTwoPtrList.
@@ -3944,6 +6073,57 @@ func (f HoldsVerTwoPtrList_Future) Struct() (HoldsVerTwoPtrList, error) {
 	return HoldsVerTwoPtrList(p.Struct()), err
 }
 
+// HoldsVerTwoPtrListPogs is a plain Go representation of HoldsVerTwoPtrList.
+type HoldsVerTwoPtrListPogs struct {
+	Mylist []VerTwoPtrPogs
+}
+
+// ToCapnp allocates a new HoldsVerTwoPtrList in seg and copies p into it.
+func (p *HoldsVerTwoPtrListPogs) ToCapnp(seg *capnp.Segment) (HoldsVerTwoPtrList, error) {
+	s, err := NewHoldsVerTwoPtrList(seg)
+	if err != nil {
+		return HoldsVerTwoPtrList{}, err
+	}
+	return s, p.Insert(s)
+}
+
+// Insert copies p into s.  Capabilities in p are not consumed: s gets
+// its own references.
+func (p *HoldsVerTwoPtrListPogs) Insert(s HoldsVerTwoPtrList) error {
+	if p.Mylist != nil {
+		l, err := s.NewMylist(int32(len(p.Mylist)))
+		if err != nil {
+			return err
+		}
+		for i := range p.Mylist {
+			if err := p.Mylist[i].Insert(l.At(i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// FromCapnp sets p to the values in s.  Like pogs.Extract, it does not
+// copy everything: Data and AnyPointer fields refer to s's message, and
+// capabilities are borrowed from its capability table, so they are only
+// valid until the message is released.  Use bytes.Clone and AddRef to
+// keep them for longer.
+func (p *HoldsVerTwoPtrListPogs) FromCapnp(s HoldsVerTwoPtrList) error {
+	*p = HoldsVerTwoPtrListPogs{}
+	if l, err := s.Mylist(); err != nil {
+		return err
+	} else if l.IsValid() {
+		p.Mylist = make([]VerTwoPtrPogs, l.Len())
+		for i := range p.Mylist {
+			if err := p.Mylist[i].FromCapnp(l.At(i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 type HoldsVerTwoTwoList capnp.Struct
 
 // HoldsVerTwoTwoList_TypeID is the unique identifier for the type HoldsVerTwoTwoList.
@@ -4032,6 +6212,57 @@ func (f HoldsVerTwoTwoList_Future) Struct() (HoldsVerTwoTwoList, error) {
 	return HoldsVerTwoTwoList(p.Struct()), err
 }
 
+// HoldsVerTwoTwoListPogs is a plain Go representation of HoldsVerTwoTwoList.
+type HoldsVerTwoTwoListPogs struct {
+	Mylist []VerTwoDataTwoPtrPogs
+}
+
+// ToCapnp allocates a new HoldsVerTwoTwoList in seg and copies p into it.
+func (p *HoldsVerTwoTwoListPogs) ToCapnp(seg *capnp.Segment) (HoldsVerTwoTwoList, error) {
+	s, err := NewHoldsVerTwoTwoList(seg)
+	if err != nil {
+		return HoldsVerTwoTwoList{}, err
+	}
+	return s, p.Insert(s)
+}
+
+// Insert copies p into s.  Capabilities in p are not consumed: s gets
+// its own references.
+func (p *HoldsVerTwoTwoListPogs) Insert(s HoldsVerTwoTwoList) error {
+	if p.Mylist != nil {
+		l, err := s.NewMylist(int32(len(p.Mylist)))
+		if err != nil {
+			return err
+		}
+		for i := range p.Mylist {
+			if err := p.Mylist[i].Insert(l.At(i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// FromCapnp sets p to the values in s.  Like pogs.Extract, it does not
+// copy everything: Data and AnyPointer fields refer to s's message, and
+// capabilities are borrowed from its capability table, so they are only
+// valid until the message is released.  Use bytes.Clone and AddRef to
+// keep them for longer.
+func (p *HoldsVerTwoTwoListPogs) FromCapnp(s HoldsVerTwoTwoList) error {
+	*p = HoldsVerTwoTwoListPogs{}
+	if l, err := s.Mylist(); err != nil {
+		return err
+	} else if l.IsValid() {
+		p.Mylist = make([]VerTwoDataTwoPtrPogs, l.Len())
+		for i := range p.Mylist {
+			if err := p.Mylist[i].FromCapnp(l.At(i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 type HoldsVerTwoTwoPlus capnp.Struct
 
 // HoldsVerTwoTwoPlus_TypeID is the unique identifier for the type HoldsVerTwoTwoPlus.
@@ -4120,6 +6351,57 @@ func (f HoldsVerTwoTwoPlus_Future) Struct() (HoldsVerTwoTwoPlus, error) {
 	return HoldsVerTwoTwoPlus(p.Struct()), err
 }
 
+// HoldsVerTwoTwoPlusPogs is a plain Go representation of HoldsVerTwoTwoPlus.
+type HoldsVerTwoTwoPlusPogs struct {
+	Mylist []VerTwoTwoPlusPogs
+}
+
+// ToCapnp allocates a new HoldsVerTwoTwoPlus in seg and copies p into it.
+func (p *HoldsVerTwoTwoPlusPogs) ToCapnp(seg *capnp.Segment) (HoldsVerTwoTwoPlus, error) {
+	s, err := NewHoldsVerTwoTwoPlus(seg)
+	if err != nil {
+		return HoldsVerTwoTwoPlus{}, err
+	}
+	return s, p.Insert(s)
+}
+
+// Insert copies p into s.  Capabilities in p are not consumed: s gets
+// its own references.
+func (p *HoldsVerTwoTwoPlusPogs) Insert(s HoldsVerTwoTwoPlus) error {
+	if p.Mylist != nil {
+		l, err := s.NewMylist(int32(len(p.Mylist)))
+		if err != nil {
+			return err
+		}
+		for i := range p.Mylist {
+			if err := p.Mylist[i].Insert(l.At(i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// FromCapnp sets p to the values in s.  Like pogs.Extract, it does not
+// copy everything: Data and AnyPointer fields refer to s's message, and
+// capabilities are borrowed from its capability table, so they are only
+// valid until the message is released.  Use bytes.Clone and AddRef to
+// keep them for longer.
+func (p *HoldsVerTwoTwoPlusPogs) FromCapnp(s HoldsVerTwoTwoPlus) error {
+	*p = HoldsVerTwoTwoPlusPogs{}
+	if l, err := s.Mylist(); err != nil {
+		return err
+	} else if l.IsValid() {
+		p.Mylist = make([]VerTwoTwoPlusPogs, l.Len())
+		for i := range p.Mylist {
+			if err := p.Mylist[i].FromCapnp(l.At(i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 type VerTwoTwoPlus capnp.Struct
 
 // VerTwoTwoPlus_TypeID is the unique identifier for the type VerTwoTwoPlus.
@@ -4286,6 +6568,98 @@ func (p VerTwoTwoPlus_Future) Ptr2() VerTwoDataTwoPtr_Future {
 	return VerTwoDataTwoPtr_Future{Future: p.Future.Field(1, nil)}
 }
 
+// VerTwoTwoPlusPogs is a plain Go representation of VerTwoTwoPlus.
+type VerTwoTwoPlusPogs struct {
+	Val  int16
+	Duo  int64
+	Ptr1 *VerTwoDataTwoPtrPogs
+	Ptr2 *VerTwoDataTwoPtrPogs
+	Tre  int64
+	Lst3 []int64
+}
+
+// ToCapnp allocates a new VerTwoTwoPlus in seg and copies p into it.
+func (p *VerTwoTwoPlusPogs) ToCapnp(seg *capnp.Segment) (VerTwoTwoPlus, error) {
+	s, err := NewVerTwoTwoPlus(seg)
+	if err != nil {
+		return VerTwoTwoPlus{}, err
+	}
+	return s, p.Insert(s)
+}
+
+// Insert copies p into s.  Capabilities in p are not consumed: s gets
+// its own references.
+func (p *VerTwoTwoPlusPogs) Insert(s VerTwoTwoPlus) error {
+	s.SetVal(p.Val)
+	s.SetDuo(p.Duo)
+	if p.Ptr1 != nil {
+		v, err := s.NewPtr1()
+		if err != nil {
+			return err
+		}
+		if err := p.Ptr1.Insert(v); err != nil {
+			return err
+		}
+	}
+	if p.Ptr2 != nil {
+		v, err := s.NewPtr2()
+		if err != nil {
+			return err
+		}
+		if err := p.Ptr2.Insert(v); err != nil {
+			return err
+		}
+	}
+	s.SetTre(p.Tre)
+	if p.Lst3 != nil {
+		l, err := s.NewLst3(int32(len(p.Lst3)))
+		if err != nil {
+			return err
+		}
+		for i := range p.Lst3 {
+			l.Set(i, p.Lst3[i])
+		}
+	}
+	return nil
+}
+
+// FromCapnp sets p to the values in s.  Like pogs.Extract, it does not
+// copy everything: Data and AnyPointer fields refer to s's message, and
+// capabilities are borrowed from its capability table, so they are only
+// valid until the message is released.  Use bytes.Clone and AddRef to
+// keep them for longer.
+func (p *VerTwoTwoPlusPogs) FromCapnp(s VerTwoTwoPlus) error {
+	*p = VerTwoTwoPlusPogs{}
+	p.Val = s.Val()
+	p.Duo = s.Duo()
+	if v, err := s.Ptr1(); err != nil {
+		return err
+	} else if v.IsValid() {
+		p.Ptr1 = new(VerTwoDataTwoPtrPogs)
+		if err := p.Ptr1.FromCapnp(v); err != nil {
+			return err
+		}
+	}
+	if v, err := s.Ptr2(); err != nil {
+		return err
+	} else if v.IsValid() {
+		p.Ptr2 = new(VerTwoDataTwoPtrPogs)
+		if err := p.Ptr2.FromCapnp(v); err != nil {
+			return err
+		}
+	}
+	p.Tre = s.Tre()
+	if l, err := s.Lst3(); err != nil {
+		return err
+	} else if l.IsValid() {
+		p.Lst3 = make([]int64, l.Len())
+		for i := range p.Lst3 {
+			p.Lst3[i] = l.At(i)
+		}
+	}
+	return nil
+}
+
 type HoldsText capnp.Struct
 
 // HoldsText_TypeID is the unique identifier for the type HoldsText.
@@ -4415,6 +6789,105 @@ func (f HoldsText_Future) Struct() (HoldsText, error) {
 	return HoldsText(p.Struct()), err
 }
 
+// HoldsTextPogs is a plain Go representation of HoldsText.
+type HoldsTextPogs struct {
+	Txt    string
+	Lst    []string
+	Lstlst [][]string
+}
+
+// ToCapnp allocates a new HoldsText in seg and copies p into it.
+func (p *HoldsTextPogs) ToCapnp(seg *capnp.Segment) (HoldsText, error) {
+	s, err := NewHoldsText(seg)
+	if err != nil {
+		return HoldsText{}, err
+	}
+	return s, p.Insert(s)
+}
+
+// Insert copies p into s.  Capabilities in p are not consumed: s gets
+// its own references.
+func (p *HoldsTextPogs) Insert(s HoldsText) error {
+	if err := s.SetTxt(p.Txt); err != nil {
+		return err
+	}
+	if p.Lst != nil {
+		l, err := s.NewLst(int32(len(p.Lst)))
+		if err != nil {
+			return err
+		}
+		for i := range p.Lst {
+			if err := l.Set(i, p.Lst[i]); err != nil {
+				return err
+			}
+		}
+	}
+	if p.Lstlst != nil {
+		l, err := s.NewLstlst(int32(len(p.Lstlst)))
+		if err != nil {
+			return err
+		}
+		for i := range p.Lstlst {
+			if p.Lstlst[i] != nil {
+				l1, err := capnp.NewTextList(l.Segment(), int32(len(p.Lstlst[i])))
+				if err != nil {
+					return err
+				}
+				if err := l.Set(i, l1.ToPtr()); err != nil {
+					return err
+				}
+				for i1 := range p.Lstlst[i] {
+					if err := l1.Set(i1, p.Lstlst[i][i1]); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// FromCapnp sets p to the values in s.  Like pogs.Extract, it does not
+// copy everything: Data and AnyPointer fields refer to s's message, and
+// capabilities are borrowed from its capability table, so they are only
+// valid until the message is released.  Use bytes.Clone and AddRef to
+// keep them for longer.
+func (p *HoldsTextPogs) FromCapnp(s HoldsText) error {
+	var err error
+	*p = HoldsTextPogs{}
+	if p.Txt, err = s.Txt(); err != nil {
+		return err
+	}
+	if l, err := s.Lst(); err != nil {
+		return err
+	} else if l.IsValid() {
+		p.Lst = make([]string, l.Len())
+		for i := range p.Lst {
+			if p.Lst[i], err = l.At(i); err != nil {
+				return err
+			}
+		}
+	}
+	if l, err := s.Lstlst(); err != nil {
+		return err
+	} else if l.IsValid() {
+		p.Lstlst = make([][]string, l.Len())
+		for i := range p.Lstlst {
+			if p1, err := l.At(i); err != nil {
+				return err
+			} else if l1 := capnp.TextList(p1.List()); l1.IsValid() {
+				p.Lstlst[i] = make([]string, l1.Len())
+				for i1 := range p.Lstlst[i] {
+					if p.Lstlst[i][i1], err = l1.At(i1); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
 type WrapEmpty capnp.Struct
 
 // WrapEmpty_TypeID is the unique identifier for the type WrapEmpty.
@@ -4506,6 +6979,53 @@ func (p WrapEmpty_Future) MightNotBeReallyEmpty() VerEmpty_Future {
 	return VerEmpty_Future{Future: p.Future.Field(0, nil)}
 }
 
+// WrapEmptyPogs is a plain Go representation of WrapEmpty.
+type WrapEmptyPogs struct {
+	MightNotBeReallyEmpty *VerEmptyPogs
+}
+
+// ToCapnp allocates a new WrapEmpty in seg and copies p into it.
+func (p *WrapEmptyPogs) ToCapnp(seg *capnp.Segment) (WrapEmpty, error) {
+	s, err := NewWrapEmpty(seg)
+	if err != nil {
+		return WrapEmpty{}, err
+	}
+	return s, p.Insert(s)
+}
+
+// Insert copies p into s.  Capabilities in p are not consumed: s gets
+// its own references.
+func (p *WrapEmptyPogs) Insert(s WrapEmpty) error {
+	if p.MightNotBeReallyEmpty != nil {
+		v, err := s.NewMightNotBeReallyEmpty()
+		if err != nil {
+			return err
+		}
+		if err := p.MightNotBeReallyEmpty.Insert(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FromCapnp sets p to the values in s.  Like pogs.Extract, it does not
+// copy everything: Data and AnyPointer fields refer to s's message, and
+// capabilities are borrowed from its capability table, so they are only
+// valid until the message is released.  Use bytes.Clone and AddRef to
+// keep them for longer.
+func (p *WrapEmptyPogs) FromCapnp(s WrapEmpty) error {
+	*p = WrapEmptyPogs{}
+	if v, err := s.MightNotBeReallyEmpty(); err != nil {
+		return err
+	} else if v.IsValid() {
+		p.MightNotBeReallyEmpty = new(VerEmptyPogs)
+		if err := p.MightNotBeReallyEmpty.FromCapnp(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 type Wrap2x2 capnp.Struct
 
 // Wrap2x2_TypeID is the unique identifier for the type Wrap2x2.
@@ -4597,6 +7117,53 @@ func (p Wrap2x2_Future) MightNotBeReallyEmpty() VerTwoDataTwoPtr_Future {
 	return VerTwoDataTwoPtr_Future{Future: p.Future.Field(0, nil)}
 }
 
+// Wrap2x2Pogs is a plain Go representation of Wrap2x2.
+type Wrap2x2Pogs struct {
+	MightNotBeReallyEmpty *VerTwoDataTwoPtrPogs
+}
+
+// ToCapnp allocates a new Wrap2x2 in seg and copies p into it.
+func (p *Wrap2x2Pogs) ToCapnp(seg *capnp.Segment) (Wrap2x2, error) {
+	s, err := NewWrap2x2(seg)
+	if err != nil {
+		return Wrap2x2{}, err
+	}
+	return s, p.Insert(s)
+}
+
+// Insert copies p into s.  Capabilities in p are not consumed: s gets
+// its own references.
+func (p *Wrap2x2Pogs) Insert(s Wrap2x2) error {
+	if p.MightNotBeReallyEmpty != nil {
+		v, err := s.NewMightNotBeReallyEmpty()
+		if err != nil {
+			return err
+		}
+		if err := p.MightNotBeReallyEmpty.Insert(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FromCapnp sets p to the values in s.  Like pogs.Extract, it does not
+// copy everything: Data and AnyPointer fields refer to s's message, and
+// capabilities are borrowed from its capability table, so they are only
+// valid until the message is released.  Use bytes.Clone and AddRef to
+// keep them for longer.
+func (p *Wrap2x2Pogs) FromCapnp(s Wrap2x2) error {
+	*p = Wrap2x2Pogs{}
+	if v, err := s.MightNotBeReallyEmpty(); err != nil {
+		return err
+	} else if v.IsValid() {
+		p.MightNotBeReallyEmpty = new(VerTwoDataTwoPtrPogs)
+		if err := p.MightNotBeReallyEmpty.FromCapnp(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 type Wrap2x2plus capnp.Struct
 
 // Wrap2x2plus_TypeID is the unique identifier for the type Wrap2x2plus.
@@ -4688,6 +7255,53 @@ func (p Wrap2x2plus_Future) MightNotBeReallyEmpty() VerTwoTwoPlus_Future {
 	return VerTwoTwoPlus_Future{Future: p.Future.Field(0, nil)}
 }
 
+// Wrap2x2plusPogs is a plain Go representation of Wrap2x2plus.
+type Wrap2x2plusPogs struct {
+	MightNotBeReallyEmpty *VerTwoTwoPlusPogs
+}
+
+// ToCapnp allocates a new Wrap2x2plus in seg and copies p into it.
+func (p *Wrap2x2plusPogs) ToCapnp(seg *capnp.Segment) (Wrap2x2plus, error) {
+	s, err := NewWrap2x2plus(seg)
+	if err != nil {
+		return Wrap2x2plus{}, err
+	}
+	return s, p.Insert(s)
+}
+
+// Insert copies p into s.  Capabilities in p are not consumed: s gets
+// its own references.
+func (p *Wrap2x2plusPogs) Insert(s Wrap2x2plus) error {
+	if p.MightNotBeReallyEmpty != nil {
+		v, err := s.NewMightNotBeReallyEmpty()
+		if err != nil {
+			return err
+		}
+		if err := p.MightNotBeReallyEmpty.Insert(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FromCapnp sets p to the values in s.  Like pogs.Extract, it does not
+// copy everything: Data and AnyPointer fields refer to s's message, and
+// capabilities are borrowed from its capability table, so they are only
+// valid until the message is released.  Use bytes.Clone and AddRef to
+// keep them for longer.
+func (p *Wrap2x2plusPogs) FromCapnp(s Wrap2x2plus) error {
+	*p = Wrap2x2plusPogs{}
+	if v, err := s.MightNotBeReallyEmpty(); err != nil {
+		return err
+	} else if v.IsValid() {
+		p.MightNotBeReallyEmpty = new(VerTwoTwoPlusPogs)
+		if err := p.MightNotBeReallyEmpty.FromCapnp(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 type VoidUnion capnp.Struct
 type VoidUnion_Which uint16
 
@@ -4784,6 +7398,47 @@ func (f VoidUnion_Future) Struct() (VoidUnion, error) {
 	return VoidUnion(p.Struct()), err
 }
 
+// VoidUnionPogs is a plain Go representation of VoidUnion.
+type VoidUnionPogs struct {
+	Which VoidUnion_Which
+}
+
+// ToCapnp allocates a new VoidUnion in seg and copies p into it.
+func (p *VoidUnionPogs) ToCapnp(seg *capnp.Segment) (VoidUnion, error) {
+	s, err := NewVoidUnion(seg)
+	if err != nil {
+		return VoidUnion{}, err
+	}
+	return s, p.Insert(s)
+}
+
+// Insert copies p into s.  Capabilities in p are not consumed: s gets
+// its own references.
+func (p *VoidUnionPogs) Insert(s VoidUnion) error {
+	switch p.Which {
+	case VoidUnion_Which_a:
+		s.SetA()
+	case VoidUnion_Which_b:
+		s.SetB()
+	default:
+		return fmt.Errorf("VoidUnion: unknown union member %v", p.Which)
+	}
+	return nil
+}
+
+// FromCapnp sets p to the values in s.  Like pogs.Extract, it does not
+// copy everything: Data and AnyPointer fields refer to s's message, and
+// capabilities are borrowed from its capability table, so they are only
+// valid until the message is released.  Use bytes.Clone and AddRef to
+// keep them for longer.
+func (p *VoidUnionPogs) FromCapnp(s VoidUnion) error {
+	*p = VoidUnionPogs{}
+	p.Which = s.Which()
+	switch p.Which {
+	}
+	return nil
+}
+
 type Nester1Capn capnp.Struct
 
 // Nester1Capn_TypeID is the unique identifier for the type Nester1Capn.
@@ -4872,6 +7527,57 @@ func (f Nester1Capn_Future) Struct() (Nester1Capn, error) {
 	return Nester1Capn(p.Struct()), err
 }
 
+// Nester1CapnPogs is a plain Go representation of Nester1Capn.
+type Nester1CapnPogs struct {
+	Strs []string
+}
+
+// ToCapnp allocates a new Nester1Capn in seg and copies p into it.
+func (p *Nester1CapnPogs) ToCapnp(seg *capnp.Segment) (Nester1Capn, error) {
+	s, err := NewNester1Capn(seg)
+	if err != nil {
+		return Nester1Capn{}, err
+	}
+	return s, p.Insert(s)
+}
+
+// Insert copies p into s.  Capabilities in p are not consumed: s gets
+// its own references.
+func (p *Nester1CapnPogs) Insert(s Nester1Capn) error {
+	if p.Strs != nil {
+		l, err := s.NewStrs(int32(len(p.Strs)))
+		if err != nil {
+			return err
+		}
+		for i := range p.Strs {
+			if err := l.Set(i, p.Strs[i]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// FromCapnp sets p to the values in s.  Like pogs.Extract, it does not
+// copy everything: Data and AnyPointer fields refer to s's message, and
+// capabilities are borrowed from its capability table, so they are only
+// valid until the message is released.  Use bytes.Clone and AddRef to
+// keep them for longer.
+func (p *Nester1CapnPogs) FromCapnp(s Nester1Capn) error {
+	*p = Nester1CapnPogs{}
+	if l, err := s.Strs(); err != nil {
+		return err
+	} else if l.IsValid() {
+		p.Strs = make([]string, l.Len())
+		for i := range p.Strs {
+			if p.Strs[i], err = l.At(i); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 type RWTestCapn capnp.Struct
 
 // RWTestCapn_TypeID is the unique identifier for the type RWTestCapn.
@@ -4960,6 +7666,75 @@ func (f RWTestCapn_Future) Struct() (RWTestCapn, error) {
 	return RWTestCapn(p.Struct()), err
 }
 
+// RWTestCapnPogs is a plain Go representation of RWTestCapn.
+type RWTestCapnPogs struct {
+	NestMatrix [][]Nester1CapnPogs
+}
+
+// ToCapnp allocates a new RWTestCapn in seg and copies p into it.
+func (p *RWTestCapnPogs) ToCapnp(seg *capnp.Segment) (RWTestCapn, error) {
+	s, err := NewRWTestCapn(seg)
+	if err != nil {
+		return RWTestCapn{}, err
+	}
+	return s, p.Insert(s)
+}
+
+// Insert copies p into s.  Capabilities in p are not consumed: s gets
+// its own references.
+func (p *RWTestCapnPogs) Insert(s RWTestCapn) error {
+	if p.NestMatrix != nil {
+		l, err := s.NewNestMatrix(int32(len(p.NestMatrix)))
+		if err != nil {
+			return err
+		}
+		for i := range p.NestMatrix {
+			if p.NestMatrix[i] != nil {
+				l1, err := NewNester1Capn_List(l.Segment(), int32(len(p.NestMatrix[i])))
+				if err != nil {
+					return err
+				}
+				if err := l.Set(i, l1.ToPtr()); err != nil {
+					return err
+				}
+				for i1 := range p.NestMatrix[i] {
+					if err := p.NestMatrix[i][i1].Insert(l1.At(i1)); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// FromCapnp sets p to the values in s.  Like pogs.Extract, it does not
+// copy everything: Data and AnyPointer fields refer to s's message, and
+// capabilities are borrowed from its capability table, so they are only
+// valid until the message is released.  Use bytes.Clone and AddRef to
+// keep them for longer.
+func (p *RWTestCapnPogs) FromCapnp(s RWTestCapn) error {
+	*p = RWTestCapnPogs{}
+	if l, err := s.NestMatrix(); err != nil {
+		return err
+	} else if l.IsValid() {
+		p.NestMatrix = make([][]Nester1CapnPogs, l.Len())
+		for i := range p.NestMatrix {
+			if p1, err := l.At(i); err != nil {
+				return err
+			} else if l1 := Nester1Capn_List(p1.List()); l1.IsValid() {
+				p.NestMatrix[i] = make([]Nester1CapnPogs, l1.Len())
+				for i1 := range p.NestMatrix[i] {
+					if err := p.NestMatrix[i][i1].FromCapnp(l1.At(i1)); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
 type ListStructCapn capnp.Struct
 
 // ListStructCapn_TypeID is the unique identifier for the type ListStructCapn.
@@ -5048,6 +7823,57 @@ func (f ListStructCapn_Future) Struct() (ListStructCapn, error) {
 	return ListStructCapn(p.Struct()), err
 }
 
+// ListStructCapnPogs is a plain Go representation of ListStructCapn.
+type ListStructCapnPogs struct {
+	Vec []Nester1CapnPogs
+}
+
+// ToCapnp allocates a new ListStructCapn in seg and copies p into it.
+func (p *ListStructCapnPogs) ToCapnp(seg *capnp.Segment) (ListStructCapn, error) {
+	s, err := NewListStructCapn(seg)
+	if err != nil {
+		return ListStructCapn{}, err
+	}
+	return s, p.Insert(s)
+}
+
+// Insert copies p into s.  Capabilities in p are not consumed: s gets
+// its own references.
+func (p *ListStructCapnPogs) Insert(s ListStructCapn) error {
+	if p.Vec != nil {
+		l, err := s.NewVec(int32(len(p.Vec)))
+		if err != nil {
+			return err
+		}
+		for i := range p.Vec {
+			if err := p.Vec[i].Insert(l.At(i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// FromCapnp sets p to the values in s.  Like pogs.Extract, it does not
+// copy everything: Data and AnyPointer fields refer to s's message, and
+// capabilities are borrowed from its capability table, so they are only
+// valid until the message is released.  Use bytes.Clone and AddRef to
+// keep them for longer.
+func (p *ListStructCapnPogs) FromCapnp(s ListStructCapn) error {
+	*p = ListStructCapnPogs{}
+	if l, err := s.Vec(); err != nil {
+		return err
+	} else if l.IsValid() {
+		p.Vec = make([]Nester1CapnPogs, l.Len())
+		for i := range p.Vec {
+			if err := p.Vec[i].FromCapnp(l.At(i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 type Echo capnp.Client
 
 // Echo_TypeID is the unique identifier for the type Echo.
@@ -5282,6 +8108,43 @@ func (f Echo_echo_Params_Future) Struct() (Echo_echo_Params, error) {
 	return Echo_echo_Params(p.Struct()), err
 }
 
+// Echo_echo_ParamsPogs is a plain Go representation of Echo_echo_Params.
+type Echo_echo_ParamsPogs struct {
+	In string
+}
+
+// ToCapnp allocates a new Echo_echo_Params in seg and copies p into it.
+func (p *Echo_echo_ParamsPogs) ToCapnp(seg *capnp.Segment) (Echo_echo_Params, error) {
+	s, err := NewEcho_echo_Params(seg)
+	if err != nil {
+		return Echo_echo_Params{}, err
+	}
+	return s, p.Insert(s)
+}
+
+// Insert copies p into s.  Capabilities in p are not consumed: s gets
+// its own references.
+func (p *Echo_echo_ParamsPogs) Insert(s Echo_echo_Params) error {
+	if err := s.SetIn(p.In); err != nil {
+		return err
+	}
+	return nil
+}
+
+// FromCapnp sets p to the values in s.  Like pogs.Extract, it does not
+// copy everything: Data and AnyPointer fields refer to s's message, and
+// capabilities are borrowed from its capability table, so they are only
+// valid until the message is released.  Use bytes.Clone and AddRef to
+// keep them for longer.
+func (p *Echo_echo_ParamsPogs) FromCapnp(s Echo_echo_Params) error {
+	var err error
+	*p = Echo_echo_ParamsPogs{}
+	if p.In, err = s.In(); err != nil {
+		return err
+	}
+	return nil
+}
+
 type Echo_echo_Results capnp.Struct
 
 // Echo_echo_Results_TypeID is the unique identifier for the type Echo_echo_Results.
@@ -5364,6 +8227,43 @@ func (f Echo_echo_Results_Future) Struct() (Echo_echo_Results, error) {
 	return Echo_echo_Results(p.Struct()), err
 }
 
+// Echo_echo_ResultsPogs is a plain Go representation of Echo_echo_Results.
+type Echo_echo_ResultsPogs struct {
+	Out string
+}
+
+// ToCapnp allocates a new Echo_echo_Results in seg and copies p into it.
+func (p *Echo_echo_ResultsPogs) ToCapnp(seg *capnp.Segment) (Echo_echo_Results, error) {
+	s, err := NewEcho_echo_Results(seg)
+	if err != nil {
+		return Echo_echo_Results{}, err
+	}
+	return s, p.Insert(s)
+}
+
+// Insert copies p into s.  Capabilities in p are not consumed: s gets
+// its own references.
+func (p *Echo_echo_ResultsPogs) Insert(s Echo_echo_Results) error {
+	if err := s.SetOut(p.Out); err != nil {
+		return err
+	}
+	return nil
+}
+
+// FromCapnp sets p to the values in s.  Like pogs.Extract, it does not
+// copy everything: Data and AnyPointer fields refer to s's message, and
+// capabilities are borrowed from its capability table, so they are only
+// valid until the message is released.  Use bytes.Clone and AddRef to
+// keep them for longer.
+func (p *Echo_echo_ResultsPogs) FromCapnp(s Echo_echo_Results) error {
+	var err error
+	*p = Echo_echo_ResultsPogs{}
+	if p.Out, err = s.Out(); err != nil {
+		return err
+	}
+	return nil
+}
+
 type Hoth capnp.Struct
 
 // Hoth_TypeID is the unique identifier for the type Hoth.
@@ -5455,6 +8355,53 @@ func (p Hoth_Future) Base() EchoBase_Future {
 	return EchoBase_Future{Future: p.Future.Field(0, nil)}
 }
 
+// HothPogs is a plain Go representation of Hoth.
+type HothPogs struct {
+	Base *EchoBasePogs
+}
+
+// ToCapnp allocates a new Hoth in seg and copies p into it.
+func (p *HothPogs) ToCapnp(seg *capnp.Segment) (Hoth, error) {
+	s, err := NewHoth(seg)
+	if err != nil {
+		return Hoth{}, err
+	}
+	return s, p.Insert(s)
+}
+
+// Insert copies p into s.  Capabilities in p are not consumed: s gets
+// its own references.
+func (p *HothPogs) Insert(s Hoth) error {
+	if p.Base != nil {
+		v, err := s.NewBase()
+		if err != nil {
+			return err
+		}
+		if err := p.Base.Insert(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FromCapnp sets p to the values in s.  Like pogs.Extract, it does not
+// copy everything: Data and AnyPointer fields refer to s's message, and
+// capabilities are borrowed from its capability table, so they are only
+// valid until the message is released.  Use bytes.Clone and AddRef to
+// keep them for longer.
+func (p *HothPogs) FromCapnp(s Hoth) error {
+	*p = HothPogs{}
+	if v, err := s.Base(); err != nil {
+		return err
+	} else if v.IsValid() {
+		p.Base = new(EchoBasePogs)
+		if err := p.Base.FromCapnp(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 type EchoBase capnp.Struct
 
 // EchoBase_TypeID is the unique identifier for the type EchoBase.
@@ -5540,6 +8487,40 @@ func (p EchoBase_Future) Echo() Echo {
 	return Echo(p.Future.Field(0, nil).Client())
 }
 
+// EchoBasePogs is a plain Go representation of EchoBase.
+type EchoBasePogs struct {
+	Echo Echo
+}
+
+// ToCapnp allocates a new EchoBase in seg and copies p into it.
+func (p *EchoBasePogs) ToCapnp(seg *capnp.Segment) (EchoBase, error) {
+	s, err := NewEchoBase(seg)
+	if err != nil {
+		return EchoBase{}, err
+	}
+	return s, p.Insert(s)
+}
+
+// Insert copies p into s.  Capabilities in p are not consumed: s gets
+// its own references.
+func (p *EchoBasePogs) Insert(s EchoBase) error {
+	if err := s.SetEcho(p.Echo.AddRef()); err != nil {
+		return err
+	}
+	return nil
+}
+
+// FromCapnp sets p to the values in s.  Like pogs.Extract, it does not
+// copy everything: Data and AnyPointer fields refer to s's message, and
+// capabilities are borrowed from its capability table, so they are only
+// valid until the message is released.  Use bytes.Clone and AddRef to
+// keep them for longer.
+func (p *EchoBasePogs) FromCapnp(s EchoBase) error {
+	*p = EchoBasePogs{}
+	p.Echo = s.Echo()
+	return nil
+}
+
 type StackingRoot capnp.Struct
 
 // StackingRoot_TypeID is the unique identifier for the type StackingRoot.
@@ -5662,6 +8643,71 @@ func (p StackingRoot_Future) AWithDefault() StackingA_Future {
 	return StackingA_Future{Future: p.Future.Field(0, x_832bcc6686a26d56[96:128])}
 }
 
+// StackingRootPogs is a plain Go representation of StackingRoot.
+type StackingRootPogs struct {
+	A            *StackingAPogs
+	AWithDefault *StackingAPogs
+}
+
+// ToCapnp allocates a new StackingRoot in seg and copies p into it.
+func (p *StackingRootPogs) ToCapnp(seg *capnp.Segment) (StackingRoot, error) {
+	s, err := NewStackingRoot(seg)
+	if err != nil {
+		return StackingRoot{}, err
+	}
+	return s, p.Insert(s)
+}
+
+// Insert copies p into s.  Capabilities in p are not consumed: s gets
+// its own references.
+func (p *StackingRootPogs) Insert(s StackingRoot) error {
+	if p.A != nil {
+		v, err := s.NewA()
+		if err != nil {
+			return err
+		}
+		if err := p.A.Insert(v); err != nil {
+			return err
+		}
+	}
+	if p.AWithDefault != nil {
+		v, err := s.NewAWithDefault()
+		if err != nil {
+			return err
+		}
+		if err := p.AWithDefault.Insert(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FromCapnp sets p to the values in s.  Like pogs.Extract, it does not
+// copy everything: Data and AnyPointer fields refer to s's message, and
+// capabilities are borrowed from its capability table, so they are only
+// valid until the message is released.  Use bytes.Clone and AddRef to
+// keep them for longer.
+func (p *StackingRootPogs) FromCapnp(s StackingRoot) error {
+	*p = StackingRootPogs{}
+	if v, err := s.A(); err != nil {
+		return err
+	} else if v.IsValid() {
+		p.A = new(StackingAPogs)
+		if err := p.A.FromCapnp(v); err != nil {
+			return err
+		}
+	}
+	if v, err := s.AWithDefault(); err != nil {
+		return err
+	} else if v.IsValid() {
+		p.AWithDefault = new(StackingAPogs)
+		if err := p.AWithDefault.FromCapnp(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 type StackingA capnp.Struct
 
 // StackingA_TypeID is the unique identifier for the type StackingA.
@@ -5761,6 +8807,56 @@ func (p StackingA_Future) B() StackingB_Future {
 	return StackingB_Future{Future: p.Future.Field(0, nil)}
 }
 
+// StackingAPogs is a plain Go representation of StackingA.
+type StackingAPogs struct {
+	Num int32
+	B   *StackingBPogs
+}
+
+// ToCapnp allocates a new StackingA in seg and copies p into it.
+func (p *StackingAPogs) ToCapnp(seg *capnp.Segment) (StackingA, error) {
+	s, err := NewStackingA(seg)
+	if err != nil {
+		return StackingA{}, err
+	}
+	return s, p.Insert(s)
+}
+
+// Insert copies p into s.  Capabilities in p are not consumed: s gets
+// its own references.
+func (p *StackingAPogs) Insert(s StackingA) error {
+	s.SetNum(p.Num)
+	if p.B != nil {
+		v, err := s.NewB()
+		if err != nil {
+			return err
+		}
+		if err := p.B.Insert(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FromCapnp sets p to the values in s.  Like pogs.Extract, it does not
+// copy everything: Data and AnyPointer fields refer to s's message, and
+// capabilities are borrowed from its capability table, so they are only
+// valid until the message is released.  Use bytes.Clone and AddRef to
+// keep them for longer.
+func (p *StackingAPogs) FromCapnp(s StackingA) error {
+	*p = StackingAPogs{}
+	p.Num = s.Num()
+	if v, err := s.B(); err != nil {
+		return err
+	} else if v.IsValid() {
+		p.B = new(StackingBPogs)
+		if err := p.B.FromCapnp(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 type StackingB capnp.Struct
 
 // StackingB_TypeID is the unique identifier for the type StackingB.
@@ -5833,6 +8929,38 @@ func (f StackingB_Future) Struct() (StackingB, error) {
 	return StackingB(p.Struct()), err
 }
 
+// StackingBPogs is a plain Go representation of StackingB.
+type StackingBPogs struct {
+	Num int32
+}
+
+// ToCapnp allocates a new StackingB in seg and copies p into it.
+func (p *StackingBPogs) ToCapnp(seg *capnp.Segment) (StackingB, error) {
+	s, err := NewStackingB(seg)
+	if err != nil {
+		return StackingB{}, err
+	}
+	return s, p.Insert(s)
+}
+
+// Insert copies p into s.  Capabilities in p are not consumed: s gets
+// its own references.
+func (p *StackingBPogs) Insert(s StackingB) error {
+	s.SetNum(p.Num)
+	return nil
+}
+
+// FromCapnp sets p to the values in s.  Like pogs.Extract, it does not
+// copy everything: Data and AnyPointer fields refer to s's message, and
+// capabilities are borrowed from its capability table, so they are only
+// valid until the message is released.  Use bytes.Clone and AddRef to
+// keep them for longer.
+func (p *StackingBPogs) FromCapnp(s StackingB) error {
+	*p = StackingBPogs{}
+	p.Num = s.Num()
+	return nil
+}
+
 type CallSequence capnp.Client
 
 // CallSequence_TypeID is the unique identifier for the type CallSequence.
@@ -6050,6 +9178,35 @@ func (f CallSequence_getNumber_Params_Future) Struct() (CallSequence_getNumber_P
 	return CallSequence_getNumber_Params(p.Struct()), err
 }
 
+// CallSequence_getNumber_ParamsPogs is a plain Go representation of CallSequence_getNumber_Params.
+type CallSequence_getNumber_ParamsPogs struct {
+}
+
+// ToCapnp allocates a new CallSequence_getNumber_Params in seg and copies p into it.
+func (p *CallSequence_getNumber_ParamsPogs) ToCapnp(seg *capnp.Segment) (CallSequence_getNumber_Params, error) {
+	s, err := NewCallSequence_getNumber_Params(seg)
+	if err != nil {
+		return CallSequence_getNumber_Params{}, err
+	}
+	return s, p.Insert(s)
+}
+
+// Insert copies p into s.  Capabilities in p are not consumed: s gets
+// its own references.
+func (p *CallSequence_getNumber_ParamsPogs) Insert(s CallSequence_getNumber_Params) error {
+	return nil
+}
+
+// FromCapnp sets p to the values in s.  Like pogs.Extract, it does not
+// copy everything: Data and AnyPointer fields refer to s's message, and
+// capabilities are borrowed from its capability table, so they are only
+// valid until the message is released.  Use bytes.Clone and AddRef to
+// keep them for longer.
+func (p *CallSequence_getNumber_ParamsPogs) FromCapnp(s CallSequence_getNumber_Params) error {
+	*p = CallSequence_getNumber_ParamsPogs{}
+	return nil
+}
+
 type CallSequence_getNumber_Results capnp.Struct
 
 // CallSequence_getNumber_Results_TypeID is the unique identifier for the type CallSequence_getNumber_Results.
@@ -6122,6 +9279,38 @@ func (f CallSequence_getNumber_Results_Future) Struct() (CallSequence_getNumber_
 	return CallSequence_getNumber_Results(p.Struct()), err
 }
 
+// CallSequence_getNumber_ResultsPogs is a plain Go representation of CallSequence_getNumber_Results.
+type CallSequence_getNumber_ResultsPogs struct {
+	N uint32
+}
+
+// ToCapnp allocates a new CallSequence_getNumber_Results in seg and copies p into it.
+func (p *CallSequence_getNumber_ResultsPogs) ToCapnp(seg *capnp.Segment) (CallSequence_getNumber_Results, error) {
+	s, err := NewCallSequence_getNumber_Results(seg)
+	if err != nil {
+		return CallSequence_getNumber_Results{}, err
+	}
+	return s, p.Insert(s)
+}
+
+// Insert copies p into s.  Capabilities in p are not consumed: s gets
+// its own references.
+func (p *CallSequence_getNumber_ResultsPogs) Insert(s CallSequence_getNumber_Results) error {
+	s.SetN(p.N)
+	return nil
+}
+
+// FromCapnp sets p to the values in s.  Like pogs.Extract, it does not
+// copy everything: Data and AnyPointer fields refer to s's message, and
+// capabilities are borrowed from its capability table, so they are only
+// valid until the message is released.  Use bytes.Clone and AddRef to
+// keep them for longer.
+func (p *CallSequence_getNumber_ResultsPogs) FromCapnp(s CallSequence_getNumber_Results) error {
+	*p = CallSequence_getNumber_ResultsPogs{}
+	p.N = s.N()
+	return nil
+}
+
 type Pipeliner capnp.Client
 
 // Pipeliner_TypeID is the unique identifier for the type Pipeliner.
@@ -6369,6 +9558,35 @@ func (f Pipeliner_newPipeliner_Params_Future) Struct() (Pipeliner_newPipeliner_P
 	return Pipeliner_newPipeliner_Params(p.Struct()), err
 }
 
+// Pipeliner_newPipeliner_ParamsPogs is a plain Go representation of Pipeliner_newPipeliner_Params.
+type Pipeliner_newPipeliner_ParamsPogs struct {
+}
+
+// ToCapnp allocates a new Pipeliner_newPipeliner_Params in seg and copies p into it.
+func (p *Pipeliner_newPipeliner_ParamsPogs) ToCapnp(seg *capnp.Segment) (Pipeliner_newPipeliner_Params, error) {
+	s, err := NewPipeliner_newPipeliner_Params(seg)
+	if err != nil {
+		return Pipeliner_newPipeliner_Params{}, err
+	}
+	return s, p.Insert(s)
+}
+
+// Insert copies p into s.  Capabilities in p are not consumed: s gets
+// its own references.
+func (p *Pipeliner_newPipeliner_ParamsPogs) Insert(s Pipeliner_newPipeliner_Params) error {
+	return nil
+}
+
+// FromCapnp sets p to the values in s.  Like pogs.Extract, it does not
+// copy everything: Data and AnyPointer fields refer to s's message, and
+// capabilities are borrowed from its capability table, so they are only
+// valid until the message is released.  Use bytes.Clone and AddRef to
+// keep them for longer.
+func (p *Pipeliner_newPipeliner_ParamsPogs) FromCapnp(s Pipeliner_newPipeliner_Params) error {
+	*p = Pipeliner_newPipeliner_ParamsPogs{}
+	return nil
+}
+
 type Pipeliner_newPipeliner_Results capnp.Struct
 
 // Pipeliner_newPipeliner_Results_TypeID is the unique identifier for the type Pipeliner_newPipeliner_Results.
@@ -6468,6 +9686,48 @@ func (p Pipeliner_newPipeliner_Results_Future) Pipeliner() Pipeliner {
 	return Pipeliner(p.Future.Field(1, nil).Client())
 }
 
+// Pipeliner_newPipeliner_ResultsPogs is a plain Go representation of Pipeliner_newPipeliner_Results.
+type Pipeliner_newPipeliner_ResultsPogs struct {
+	Extra     capnp.Ptr
+	Pipeliner Pipeliner
+}
+
+// ToCapnp allocates a new Pipeliner_newPipeliner_Results in seg and copies p into it.
+func (p *Pipeliner_newPipeliner_ResultsPogs) ToCapnp(seg *capnp.Segment) (Pipeliner_newPipeliner_Results, error) {
+	s, err := NewPipeliner_newPipeliner_Results(seg)
+	if err != nil {
+		return Pipeliner_newPipeliner_Results{}, err
+	}
+	return s, p.Insert(s)
+}
+
+// Insert copies p into s.  Capabilities in p are not consumed: s gets
+// its own references.
+func (p *Pipeliner_newPipeliner_ResultsPogs) Insert(s Pipeliner_newPipeliner_Results) error {
+	if err := s.SetExtra(p.Extra); err != nil {
+		return err
+	}
+	if err := s.SetPipeliner(p.Pipeliner.AddRef()); err != nil {
+		return err
+	}
+	return nil
+}
+
+// FromCapnp sets p to the values in s.  Like pogs.Extract, it does not
+// copy everything: Data and AnyPointer fields refer to s's message, and
+// capabilities are borrowed from its capability table, so they are only
+// valid until the message is released.  Use bytes.Clone and AddRef to
+// keep them for longer.
+func (p *Pipeliner_newPipeliner_ResultsPogs) FromCapnp(s Pipeliner_newPipeliner_Results) error {
+	var err error
+	*p = Pipeliner_newPipeliner_ResultsPogs{}
+	if p.Extra, err = s.Extra(); err != nil {
+		return err
+	}
+	p.Pipeliner = s.Pipeliner()
+	return nil
+}
+
 type Defaults capnp.Struct
 
 // Defaults_TypeID is the unique identifier for the type Defaults.
@@ -6590,6 +9850,59 @@ func (f Defaults_Future) Struct() (Defaults, error) {
 	return Defaults(p.Struct()), err
 }
 
+// DefaultsPogs is a plain Go representation of Defaults.
+type DefaultsPogs struct {
+	Text  string
+	Data  []byte
+	Float float32
+	Int   int32
+	Uint  uint32
+}
+
+// ToCapnp allocates a new Defaults in seg and copies p into it.
+func (p *DefaultsPogs) ToCapnp(seg *capnp.Segment) (Defaults, error) {
+	s, err := NewDefaults(seg)
+	if err != nil {
+		return Defaults{}, err
+	}
+	return s, p.Insert(s)
+}
+
+// Insert copies p into s.  Capabilities in p are not consumed: s gets
+// its own references.
+func (p *DefaultsPogs) Insert(s Defaults) error {
+	if err := s.SetText(p.Text); err != nil {
+		return err
+	}
+	if err := s.SetData(p.Data); err != nil {
+		return err
+	}
+	s.SetFloat(p.Float)
+	s.SetInt(p.Int)
+	s.SetUint(p.Uint)
+	return nil
+}
+
+// FromCapnp sets p to the values in s.  Like pogs.Extract, it does not
+// copy everything: Data and AnyPointer fields refer to s's message, and
+// capabilities are borrowed from its capability table, so they are only
+// valid until the message is released.  Use bytes.Clone and AddRef to
+// keep them for longer.
+func (p *DefaultsPogs) FromCapnp(s Defaults) error {
+	var err error
+	*p = DefaultsPogs{}
+	if p.Text, err = s.Text(); err != nil {
+		return err
+	}
+	if p.Data, err = s.Data(); err != nil {
+		return err
+	}
+	p.Float = s.Float()
+	p.Int = s.Int()
+	p.Uint = s.Uint()
+	return nil
+}
+
 type BenchmarkA capnp.Struct
 
 // BenchmarkA_TypeID is the unique identifier for the type BenchmarkA.
@@ -6722,6 +10035,62 @@ func (f BenchmarkA_Future) Struct() (BenchmarkA, error) {
 	return BenchmarkA(p.Struct()), err
 }
 
+// BenchmarkAPogs is a plain Go representation of BenchmarkA.
+type BenchmarkAPogs struct {
+	Name     string
+	BirthDay int64
+	Phone    string
+	Siblings int32
+	Spouse   bool
+	Money    float64
+}
+
+// ToCapnp allocates a new BenchmarkA in seg and copies p into it.
+func (p *BenchmarkAPogs) ToCapnp(seg *capnp.Segment) (BenchmarkA, error) {
+	s, err := NewBenchmarkA(seg)
+	if err != nil {
+		return BenchmarkA{}, err
+	}
+	return s, p.Insert(s)
+}
+
+// Insert copies p into s.  Capabilities in p are not consumed: s gets
+// its own references.
+func (p *BenchmarkAPogs) Insert(s BenchmarkA) error {
+	if err := s.SetName(p.Name); err != nil {
+		return err
+	}
+	s.SetBirthDay(p.BirthDay)
+	if err := s.SetPhone(p.Phone); err != nil {
+		return err
+	}
+	s.SetSiblings(p.Siblings)
+	s.SetSpouse(p.Spouse)
+	s.SetMoney(p.Money)
+	return nil
+}
+
+// FromCapnp sets p to the values in s.  Like pogs.Extract, it does not
+// copy everything: Data and AnyPointer fields refer to s's message, and
+// capabilities are borrowed from its capability table, so they are only
+// valid until the message is released.  Use bytes.Clone and AddRef to
+// keep them for longer.
+func (p *BenchmarkAPogs) FromCapnp(s BenchmarkA) error {
+	var err error
+	*p = BenchmarkAPogs{}
+	if p.Name, err = s.Name(); err != nil {
+		return err
+	}
+	p.BirthDay = s.BirthDay()
+	if p.Phone, err = s.Phone(); err != nil {
+		return err
+	}
+	p.Siblings = s.Siblings()
+	p.Spouse = s.Spouse()
+	p.Money = s.Money()
+	return nil
+}
+
 type AllocBenchmark capnp.Struct
 
 // AllocBenchmark_TypeID is the unique identifier for the type AllocBenchmark.
@@ -6810,6 +10179,57 @@ func (f AllocBenchmark_Future) Struct() (AllocBenchmark, error) {
 	return AllocBenchmark(p.Struct()), err
 }
 
+// AllocBenchmarkPogs is a plain Go representation of AllocBenchmark.
+type AllocBenchmarkPogs struct {
+	Fields []AllocBenchmark_FieldPogs
+}
+
+// ToCapnp allocates a new AllocBenchmark in seg and copies p into it.
+func (p *AllocBenchmarkPogs) ToCapnp(seg *capnp.Segment) (AllocBenchmark, error) {
+	s, err := NewAllocBenchmark(seg)
+	if err != nil {
+		return AllocBenchmark{}, err
+	}
+	return s, p.Insert(s)
+}
+
+// Insert copies p into s.  Capabilities in p are not consumed: s gets
+// its own references.
+func (p *AllocBenchmarkPogs) Insert(s AllocBenchmark) error {
+	if p.Fields != nil {
+		l, err := s.NewFields(int32(len(p.Fields)))
+		if err != nil {
+			return err
+		}
+		for i := range p.Fields {
+			if err := p.Fields[i].Insert(l.At(i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// FromCapnp sets p to the values in s.  Like pogs.Extract, it does not
+// copy everything: Data and AnyPointer fields refer to s's message, and
+// capabilities are borrowed from its capability table, so they are only
+// valid until the message is released.  Use bytes.Clone and AddRef to
+// keep them for longer.
+func (p *AllocBenchmarkPogs) FromCapnp(s AllocBenchmark) error {
+	*p = AllocBenchmarkPogs{}
+	if l, err := s.Fields(); err != nil {
+		return err
+	} else if l.IsValid() {
+		p.Fields = make([]AllocBenchmark_FieldPogs, l.Len())
+		for i := range p.Fields {
+			if err := p.Fields[i].FromCapnp(l.At(i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 type AllocBenchmark_Field capnp.Struct
 
 // AllocBenchmark_Field_TypeID is the unique identifier for the type AllocBenchmark_Field.
@@ -6892,301 +10312,521 @@ func (f AllocBenchmark_Field_Future) Struct() (AllocBenchmark_Field, error) {
 	return AllocBenchmark_Field(p.Struct()), err
 }
 
-const schema_832bcc6686a26d56 = "x\xda\xacZ}t\x14U\x96\xbf\xb7\xaa;\x15\x92t" +
-	"\xba+\xaf\x80\x10\x12#\x11\x14\x1a\xc1\x900\x01\x99u" +
-	"\x93`\xa2\xe0\x82\xa6h\x10ue\xa4\x92T\x92\xc6N" +
-	"w\xd3]\x0d\x89\xcaAv`\x04\x8f\xec\xc8QGQ" +
-	"\x99uX\xdc\xf1\x03\\\xf1cW\x18Dq\xc0\x81\xa8" +
-	"\xab\xb2\xf8\x01\xab\xa8\xb8\xa8\xa0\xec\x883\xae\xa0`\xed" +
-	"\xb9\xaf\xbb\xba*\x9dnQ\xce\xfeC\xaa\xdf\xef\xd6}" +
-	"\xf7\xdd\xefw\x8b\xeaVO\x83k\x82g\xee`\x10\xd4" +
-	"\x13\xee<s\xd7\x89coW\xdf<j\x05\xa8^D" +
-	"\xf3\xea\xee\xf5\xbf\xeaxu\xec/\xc1%\x01\xb05\x05" +
-	"}l]\x01=\xad-\xa8\x074\xe7\xbd\xe2\xfb\xc5\xa0" +
-	"m\xd3n\xcb\xa0u\x8bD\xb2\xa5`+\xdb\xc1\x89\x9f" +
-	"/\xf8\x14\xd0|\xf7\xe9\xef\xab\xcf\xab\xff\xe3m {" +
-	"\x9d\xb4(\x01\xd4>[X\x82lW!\x11\xef($" +
-	"\xceSz\x1a\xea\x9ezm\xc4\xca\x0c\xceM\x92\x00\xc0" +
-	"\x8e\x17\xf6\xb1\xd3\x9c\xf8d\xe1b@s\xfd_\x86\xbf" +
-	"\xf3\xcc\x0dCn\x07YAHq\x9cS$  \xbb" +
-	"\xb6\x88\xb8M\x1c5\xfa\xc8\x8e\xaa\xc0?\x82\xec\x15m" +
-	"f\x80\xac\xb7h=[VD\x9c\x96\x14]\xce\x1e\xa6" +
-	"'\xf3\xce\xdd\x95/7\xde\xfc\xc4\xaf3\xe4\x14\x88j" +
-	"u\xd1!\xb6\x96\xd3\xdfSD;\x9b\xb1W\x8f\xaak" +
-	"\xf7\xdc\xd5\x9f\x96\xeb\xeat\xd1K\xcc\xed\x91@4\x8b" +
-	"\xf6\xfdfg\xc9\xe3\xd5w\x83\xecu\xf5\xdb\xfd\xa3\xa2" +
-	">v\x8c\xb8\x05>+\x121\xe0\xf2\x08\x08`\xae\x1e" +
-	"\x91?\xf1\xc4\x92\x17\xee\xce\xa2'\x86\x9e>\xe6\xf1\xd0" +
-	"\xd3 \x0f\x1d\xec\xc6\xf0|\xa5\xfe\xfb\xed\xf7d\xd3\xe9" +
-	"\x18O\x09\xb2\x8b9\xf1\xcf8\xf1\xea\xdb*_\x9e\xb6" +
-	"\xea\xe3{I\xa7B\xe6\xc9t\xcfK\xac\x9b\x88k\x83" +
-	"\x9eJ\x044\xaf\xdd(<p\xef\xdag\xef\xcf&\xc6" +
-	"\xb2\xe2>\xb6\xba\x98\x9eV\x15\x13\xe7u7\xbf\xbfe" +
-	"\xcc;\x93\x1ep\x1a`sq\x01\x19`\x0b'\xd8=" +
-	"\xe7\x90{\xeb\x05\xbf~`\x80\x0a\xf6\x17\xf7\xb1\xc3\xc4" +
-	")\xf0a\xb1\x88\x81/\x8a\xb9\x0a\x12?w\xfd\xca\xac" +
-	"\xa9^\x97\xe9W|\xf3\xf7\x8b\xfb\xd8\x11\xbe\xf9\xe1b" +
-	"\xb2\xc1\xbd\xe7\xbcyQ\xcbI}\x03\xa8\xe5\x88\xc0\x95" +
-	"_\xdb\xe8\x8d\xd1\xe63\xbd\xb4y\xc7\xb4\xce\xaf\xbeg" +
-	"/<\x92\xed$\xdd\xde\x97X\xc2KO\x0b9\xed\xb9" +
-	"\xbb\x87\xf6\xdc\xf0\xc1\x13\x8f\x0f\xf0\x945\xdeCl\x1d" +
-	"'\\\xeb\xbd\x9c\xed\xa2'\xf3\xf8\x92\x99\xfe\xa9s\xde" +
-	"x<\x9b\xf67y\xcb\x90=\xcf_\xd8\xc29o\xf9" +
-	"\xfc\xc9H\xf3\x81\xdb6e\x93\xe2\x88w=;\xcei" +
-	"\x8fqZ9\xda\xfeN\xd8\xbdqs6Z\x8f\xef+" +
-	"6\xccGO\x83}D\xbb\xb4\xee\x17\xcb\xe7M\xfer" +
-	"3\xe9J\xcc\x8c\xc1f\xdf^\xa6\x12q\xedL\xdf\\" +
-	"\xb2j\xbco\xa2\xf9\xc5\xa1s\xfe-\x9b\x0f\xd4n\x91" +
-	"\x05d\xbbd\x1e\x852\x85\xec\x86\x7f\xd9:\xea\xe5\xee" +
-	"\xb1\xff\x0e\xaa\x8c\xa2\xb9\xff\xa1\xb7\x7f~\xef\x91\xf3\x8f" +
-	"\xc2`\x94\x90B\xa0\xe41@vO\x09\x19\xe1\x93\x05" +
-	"\xcb\x8f=\xd7\xfe\xdds \x9f\x83\xe6\x9c\xab\xb7o\xfb" +
-	"\xed\xdf\x1e\xfd\"\xa5\x8a\xe3%U\xc8\x90\xf1\xa0(!" +
-	"\x91kO\xac\xe9\xdb\xfc\xf4\xef\xb7\x82<\xcc2\xd8\x08" +
-	"\xb6\x00\xc1e~\x8b\x87\xaf\xf8\xbb\x17^\xfbC\x12I" +
-	"J\xe5a\xdc\x96\xc3\x18\x0f\xf5\xc2\xaf\xd6\x9f\xd4\x0f\xfc" +
-	")\x9bfz\xd9cl\x19\xdff\x09\xa3m\xc6\xcd\xbc" +
-	"\xf8\xf9\x8f\x1f\xff\xfb=\xd9\x02y\x1d\xebc\x8fr\xda" +
-	"\x879\xdf\xad\xdf|\xf0\xd6\x0d\x8b\xde{%\x9b)Q" +
-	")C&+\\\xf9\x0a1\xbeo\xe7\xa3\x85\x9f\xc8M" +
-	"\xaff\x13\xe2\x12e+k\xe6\xb4\x8d\x9c\xf6\xc3\xf3'" +
-	"\xcf\xff\xf8\xa9\xa7\xb3\xd2&\x94\xf5l\x09\xa7\xed\xe5\xb4" +
-	"\xd3[\x0e\xed?\xf4D\xd3\x7fd5\xe5Z\xe5({" +
-	"\x98\x88k\x7f\xa7pS\xee\xbeuGy\xdf\xd1\x07^" +
-	"\xcf&\xf2\xe9\xc1%\xc8<Cx\xa2\x18B\xac\xb7\x1f" +
-	"Xy|\xc3wuog\x13\xe3\xe2!\xf7\xb3FN" +
-	"{\x09\xa7\xddv\xe5\x89/\xf3\x1b\xd4\xb7\x07\xc4\xc0\xbc" +
-	"!},\xc8\x09\xf5!\x97\xb35C\x86\x02\x98k&" +
-	"\xfd~~\xf8\xf5m\xef\x92\xcc\xaeL-/\x1b\xd2\xc7" +
-	"V\xd3\x0b\xb5\xab\x86p\x99W\xbf\xb2\x7f\xf1\xca\xf9w" +
-	"\xec\xcf&\xc6\xb1\xa1\xeb\xd9\xd7C\xe9\xe9\xf8P\x12\x83" +
-	"-\xfa.\xd8\xd1\xf8\xe6\xfb\xd9\xcc'\x97\xaeg\xc3J" +
-	"y\x10\x94\x92\xf9\xcaw\xb4\xe5\xff\xa6\xcc\x7f0Ss" +
-	"I)J\xf7\xb2\xd5\xa5\\\x8aR.\xc5\xba\xd9s7" +
-	"\xfd\xe1\x89\x96\x83\xd9J\xdc\xfb\xc3\x1ec\x87\x87\xd1\xd3" +
-	"G\xc3\xfe\x15\xd0\xbc\xab\xfa\x91o\xfff\xdfo\x0ff" +
-	"\xd3roY\x01\xb2UeD\xbc\xa2\x8cD\xde\xb4]" +
-	"\x92\xdfzs\xfdG\xd9\x8e\xf7l\xd9V\xf6<\xa7\xdd" +
-	"\xc2i\xb5@m\xc9\xae\xa3{\xb2\xd2~Tv?;" +
-	"\xc2i\x0fs\xda\x13\xd7>\xf4\xcb\x07\xd7\xe7\x1f\xce&" +
-	"\x84{x\x09\xb2\xc1\xc3\xb9V\x86\x13\xf1\xe6?\xcd9" +
-	"\xf8\x84\xef\xca\xc3\x19\xa7kF\xc9E^9\xfc%6" +
-	"\x9dS7\x0f\xa7\x10\x1fq\xd1\x89\xe1\xa7V\xcc#\xd6" +
-	"B?c\x0f+\xdf\xcaF\x94\x13aE9)\xe2\xbd" +
-	"\xbc\x93\xff\xb4|\xe9\xb2L\x19\xb8o.,\xefcK" +
-	"8m/\xa7\xbd\xb6\xab\xec\xcfS>_\xfeI\xb6\xb3" +
-	"5V\x1c`3+\xe8iz\x05/aom\xdf\xb0" +
-	"\xa9l\xe1\xa7\x03J\xc3\xc2\x8a>\xb6\x84\x08\x03=\x15" +
-	"\"\x06\x96W\xf0\xd2\x90NB\xfd-\xdd\x8c\xd2\x04\xda" +
-	"\xbe\xe2v\xb6\x8c\xde\xa9]R\xf1B\x018RQ\x16" +
-	"IF\xf8\xbfb\xe3\xfcC\xa9>\xfaI\x92\xf2\xfcy" +
-	"S\x07m\xba\xeax6Z\xdd\x7f\x80-\xf4\xf3\x8a\xc1" +
-	"i\xef\x1bVr\xfb_\xff\xe1\xb6\xafA.\xb7R\xd8" +
-	":?Oa\xa7;.\xdf\xd3\xfc\x9e\xfb\x9b\x8c\x0c\xcb" +
-	"\x9dk\x95\x7f/\xbb\x87sY\xe3'\xb7]P\x1a\xba" +
-	"\\i2\xbf\xc9\x1a\x0e\xfe\xbd\xec$\xa7\xfd\x9a\xefx" +
-	"p\xc6\xf6\xbbF\x1b\xff|*\x9b\xd3^<v/k" +
-	"\x1e\xcb\xb5;\xb6\x1e\xc6\x99Z0\xd6\x16\xd3:\x0ca" +
-	"|\x9b\x16\x0dG\xa7\x04\x0c\xad\xed\xc6`\xb8s*@" +
-	"\x0b\xa2\xea\x12]\x00.\x04\x90=U\x00j\xbe\x88\xaa" +
-	"\"\xa0\x14Nt\xa3\x0b\x04t\x91w\xa68`\x8a\xc3" +
-	"\xa5\xf5\x91D\xd8\xd0c\xf4\xba/\xfd\xba\xe6\x07P\xaf" +
-	"\x17Q\xed\x12\x10Q\xa1\xf2+\xeb5\x00\xea|\x11\xd5" +
-	"\x90\x80\xb2\x80\x0a\x0a\x00r\xf0\x0a\x00\xb5KDu\xb9" +
-	"\x80\xb2(((\x02\xc8\xcb\xa6\x02\xa8\xb7\x88\xa8\xde'" +
-	"\xa07\x1e\xbcIG7\x08\xe8\x06\xac\\\x1c\x89\xb5\xc7" +
-	"\xb1\x08\x04,\x024\xe9W(\x187\x00\x00\x8b\x01[" +
-	"D\xe4P1\xe0\xd2\xd6\xa0A\x88\xb5\x8c\xc9\xe5\xb4\xf4" +
-	"bJ\xfai\x91P{\xfcj=6{qd\xf6\xe2" +
-	"HK(\x81\xf1\x0c=LI\xe9a\xa4\x80\xf5\xdd\xbd" +
-	"N\x9e>;-\x03\xf6\xe3ni\xf7\xeaH\xb0}N" +
-	"8\x18\x09'\xb5\x9b/\xba\x8aL\x93\xb3\x1dS\x02\xa0" +
-	"\x8e\x14Q\xad\x16\xd0\x83\xdf\x9bI\x0d\x8d\xa3\xd5\xd1\"" +
-	"\xaa\x13\x05D\x0d\xf2\xb0\x15\xf2\x06\x88\xdc\xdc\xd6\x15\x19" +
-	"\xaf\xb7uEF\xb6h1\xad;\x0eNi\xcbl\xab" +
-	"\x89\xc1pZQ\x99Fk\x96\xda\xba\"\xc9\x83\xba\x01" +
-	"\xd2m2Z\xed\x9a,\xfbA\x90\xdd\x92\x97\xf6i\xc0" +
-	"\x16\xc4\x9c\x9e3K\x8aD\x8c\xd4\xe9\x10]\x88(\x8f" +
-	"Y`\x1f\xc3\x972\xfe\xc5t\xb4\x89\"\xaa\x0d\x02\x9a" +
-	"\xda\xdc\xa0\xd1\xd5\xa4w\x80WK\x84\x0c\xf4\xd9m\x1d" +
-	" \xfa\xb8\xb2\xd0\x0f\x80\xda\x00(\x8b\x8e\xf5Xsw" +
-	"\xd4\xe8\x85lB\xb6E\xc2qc\x06\xf7\x10\xe2\x996" +
-	"\\:\xcfs\xc3\x81\x8cC\xe5|\xf4~ \xe5\x0f\xa7" +
-	"\x7f\xca\xb3mrUXo1b?\x18&Q#\x86" +
-	">;\x1c3\x04\xce\xeer3\x82q4~\x82\xcb\xa5" +
-	"\xdb\xb4\x1c.\xd7\xa4w\x90N\xe3\\N%\xc9\x13Q" +
-	"^B\xf1\xd8\x93\x0a3\x1e\x90\x88\xf2\x0aZ\xbcUD" +
-	"\xf5\x0e\x01QPP@\x94WS\x90\xae\x14Q\xbd\x9b" +
-	"\xe2\x11\x15\x14\x11\xe55t\xca;\x92\xf1(\xbb\x04\x85" +
-	"\xcc,\xdfCo\xdf)\xa2\xfa\xa0\x80^C\xef1R" +
-	"\xde\x062VI\x1d\x91\x88\xb7]34\xf4\x80\x80\x1e" +
-	"Z+\x93Z\xb5XeG(\xa2\x19X\x00\xc2\xf1\x82" +
-	"?~=\xad\x01P\x0a\x86\x0d\xca-\xc7]+L\xd3" +
-	"\x04\xf4&h!\x1f\x049\xdf\x9f\xe5xscZ4" +
-	"i\xeeLC<\x05\xa0\xfaDT\xcb\x054\xbb\x83\x9d" +
-	"]\xc6\x95\x11\x03\xa7\xea\xb3t-\x14\xea\xad\xe4\xef\xa0" +
-	"\xcf\xbe\x96\xe50\x8e\x1d\\\xb3\xf48\xd7#\xe4\xb2v" +
-	"$a\x0c\x88\xaf~~\xd7\x1cNt'\xfd\xcek\x17" +
-	"S@\xb7\x17!w85\xa6SEj\xcf1Uv" +
-	"\xa6\xb02\xa93O8\x933\xb6\xa2\xcf\xbe\xa6g\x1c" +
-	"\xd1e%l-\x14\x0a\xe8\x0b\x13z\xb8M\x1f\xdf\xa9" +
-	"\x1bW&\xba[\xf5\xd8\xc8Yz%?\xb0\xf3\xb8%" +
-	"\xf6q1LF\xc1\xfc,\xa2\x93\xce\xa6jq=\xd3" +
-	"\"~\xfbm\x9eGP\xb6\xaf\xdb\x80(ga\x95\x96" +
-	"M\x0a\xb7\xe9v~\xb2j*Z7:Y\x9e\x05\x82" +
-	"<H2-\xf9\x01c\xfd\xd3Tf\xb4]\x15\xd6\x9b" +
-	"4C\x9b\x11\x14\xe3?%\xdc\x9c\xc1\\\x9c%\x91N" +
-	"\x93\"F\xd7\x0f\x9c\xbbU\x8b\xeb\xe8\xb3/\x9a9\x92" +
-	"\x18\xa5\xa8\x80\x11K\xb4U\x1a\x97j\xd1p\x8e\x1c3" +
-	"R@i\x91\xdefK\x97\xeeKr$\x83Yzg" +
-	"L\x8f\xc7\x83\x11\xe4,K\xd3,\xd7\x92\x8cw\x8b\xa8" +
-	">d;\xd5:\xaa\x1d\xf7\x89\xa8np\x94\xe7\xdf\x11" +
-	"\xe1\x83\"\xaa\xcf9\xca\xf3\xb3\xa4\xb1'ET_\xa5" +
-	"t\x80\x0a\xba\x00\xe4=$\xe5N\x11\xd57\x04\x94\xdd" +
-	"\x82\x82n\x00\xf95Z\xdc-\xa2\xba\xcf\xd6E\xfa^" +
-	"\x90\xd4\x85\xd8Z\x8d\x85 `!\xa0\xb7U74\xeb" +
-	"t\x85\xc9\x8a]\x1f\x0dia=n\x9f9\xdd\xc4&" +
-	"\xcf,\xf5v'\xac\xf7\xa5\xdex\xbb\xf5<\xc0\x11\x92" +
-	"\x19\x97|\x80\x0a\xbd\x11\x83\x8c\x86\xa5\xcanX\xe4t" +
-	"\xc7Rew,<AR\xc3B\x1ai\x17Q\x8dZ" +
-	"\x09\x12@\xee\xf6\xa7\xba\x18\x83L\xa4\x85P\x04\x01E" +
-	"@\xa9=\x11\xb1z\x17o\xd4\x88M\x18X\x1fh\xb9" +
-	"\xe6\x07\xca\x86\xe5i\xd7y\xc7w\xc6\xa2\x94\x18\xa8\xa4" +
-	"\xfa)3\xd4\xd8\x99A&\xf1\xc6Rj\x98b\xa7\x86" +
-	"\xca\x8e`,n\xe0 \x10p\x10`}\\o\x8b\x84" +
-	"\xdb\xad\x9f\x03\x14\xd4\x18\x0aE\xda\xa6\xea\xe1\xb6\xaen" +
-	"-v\xe3\xf8\xcb\x82\x92\x1ej\xcf\xf0\xc4V\x00\xb5H" +
-	"D\xb5T@3n\xc4\x82\xe1\xce\xab5\x90B\x09}" +
-	"@&\xb4\xb2MK0\xaa\x87\x82a=6>\xac/" +
-	"N\xff\x18\xd9\xa2y\xa9w\xf9\xb1\xe4\xe9\xe4\xe4\xc8\x8b" +
-	"\xfdNo%\xc6Y\x00\xea\x85\"\xaa\x93\x05\xac\xd4{" +
-	"\x8c\x98\x86% `\x09\xa0\x19M1\x03\x8c\xa1l\xdf" +
-	"d3\x12\x91\xadm\xaa^?\x10\xd8\x8e\xe2\x96\xb5'" +
-	"I9Yf2\xf7g\x13\xdao\x9b\xec,\x9d$K" +
-	"oq6\xd9.}C\xc9\x91\xed\xae\xab\x8f\xeb\xb1E" +
-	"\xc9^?\x8bW\x8c\x16\xd0\\\xac\x05\x8d`\xb8s\x01" +
-	"H\x91VG\xd4\xa6\xaf\xec98O\x95&\xd5N:" +
-	"s\x1e\xcd\xc8\x1d9\x14?{q\xc4\xdb\x12J\xc43" +
-	"r^\x95\x9d\xf3\xd2\xba_We'=+\xc2\xd39" +
-	"\xef\x11G\x84?L\x8b\x0f\x89\xa8n\xb4Z \x00\xf9" +
-	"Qz{\x83\x88\xea\x93\x8e\x9c\xb7\x89(\x1f\x11Q\xdd" +
-	"y\xe6\\\xe0\xec\xe6\x1cf\xceX\x96\x8cX\xfa\x06\xe4" +
-	"\x0d\xc5\x8dZK\xaf\xee3\xdfjZ\x8c\xd8Om1" +
-	"\xd3\xd3\xb1\\\xb6\x12\xb5\xce\x0cvSmS-mK" +
-	"\xde\x07\xd1g\x7f\x04\xc8a\xadt|[\xcd\x1c\xd5z" +
-	"k\x04\x88\xd6\xc4O\x96\x17$k\xbd\x95\x11\xc0K\xef" +
-	"4\xa0\xeaB\xc7X\x16` \x7f*\x1d\xbc3\x81\xdc" +
-	"\xe5\xcfv\x85\x9a\x94+<c\xbb\xc2f\xd2\xd3F\xab" +
-	"\xfc5d\x94\xbfm\x0eW\xd8B\xf7\xd8\xe7\x92V\x97" +
-	"\xddb\xd2\x15v\xd0\xe2\x8b\xc9B\xe9\x0dk\xdd\xe94" +
-	"Y\xd9\x15\xe9\xb6\x8bZ\xbf\xf6\x90\x17\xbd\x98FAd" +
-	"\xd9\xbc\xbeM\x0b_\x16\xea\xe5\x97X\xea\x1d\xdb\xb4\xa8" +
-	"\xd6\x16\xa4\x1e\x18,\x12\xb3[\xeb\x09Du\xbd\x9d\xd6" +
-	"2K\xa0e\xb8F\xa9\xb6\xa6\xfa\xec\x83,\x9d\x05\xa4" +
-	"\x05\x91\xd6\xdcM\xea\xc0\xbc\xd6$\xa0\xd4\xd6\xddn\x1d" +
-	"\xde\xab\xc5:\xe3\x19\xf7\xf5\x01\xb6K\x17\"l\xfc\x11" +
-	"\xad\xcb\x15\x8e\x88\xb5Z\x97\x87k\x1c\x11k\xb5.\x8f" +
-	"^\x91\x0a\xceg\xc8v\xf3\x93\xb6\xebge+\x8c\x9f" +
-	"\xad\xb1\xad\xdc\xcfvfk0ft5iN\xf5W" +
-	"F\xbb\"a\x9b\"\x1el\x0d\x05\xc3\x9dq\xa2H\xb5" +
-	"\xe6\xf5\xf1h$\x11\xd7-\x1bVvG\xc2zoN" +
-	"K\xf1\xf2\xc3\x1b\xe0\xa2\xf4\xc1\x9b\xe9\xe0\x0d\"\xaa3" +
-	"R\xe5\x9e\x16\xa7\x93\x94M\"\xaa-tr1y\xf2" +
-	"\x99d\x8di\"\xaa\xb3\x05\xf4\xf6\xeaZ\xccJA\xb4" +
-	"\xab\xd1\x85y `\x1e%$\xad\xd7z\xce\x99B\xf8" +
-	"\xc5)}\x83\xfe\xb1)\xc4y\xcd\xca\x96B\xe6\xd6\xc7" +
-	"\xb4hMO\xcdY\xdd\xe12\xb2\xe3\x00\xe6\x97\x89\x13" +
-	"\xea\xce\xde\xcb\xb3\\\x1c\xce\"\x87\xa6?\xd5\xe5\xe8\xcc" +
-	"\x1bS\xbf\xad.\xd4\x9a\x0b9\xe7f\xf6\\H\xf7\xdb" +
-	"}\xa8G8m\x0e\xecD=\xe2)3\xd5\x8aV\xd9" +
-	"\xad\xa8wQ$\xd8\x0ey\xde\xd6I\xb5\x93\xd0g\x7f" +
-	"qHU\x1b\xad\xb6\xa6\x9ad\xb5F\xef\xa9j\xd31" +
-	"\xa1\x0e}\xf6\x14:\x87\x96\x1b\xeb\x83\xb1h$\xc6\x95" +
-	"R\x9et;?\xef\xa6\x9a\xab\x00P\x90/\xa1?\xa2" +
-	"\xfc3\xfa\xe3\x92\xc7\xd1\x1f\xb7<\x8a\xfe\xe4\xc9\x15~" +
-	"\x00o8\x12\xd6\xa5\x05\x1d7J!\xadG\x8awD" +
-	"\xa4Pb\x91\xd4\xde\xb1\xd8k\xe8qc\x80\xc2\xb89" +
-	"f\xeb=)?t\x84E\x953,R\x09azU" +
-	"*,\xe6SX\xa4\x92\xf9<2\xd85\xc9\x19\x88d" +
-	"\xa4\xe7\x15(\x85l\xcb\xa5\xd2Q}(n8V\xcf" +
-	"\x90\xab\xe6&\x9d9\x1a\x12\x13\xf1\xb3\xf2h\xe7\x1c\xd1" +
-	"\x97k\x9c\xd0\xa4\x19\xc9B\x961\xbf\xf2\x01\xf8\x92\xb3" +
-	"\xab\x81i$\xa9\xac\x9di\xffb3\xdd~\x80\xc04" +
-	"\xb7\x88\x81\xd9n\x87\x8b1\xd5]\x06\x10\x98A\xc05" +
-	"n\x01+\x84\xd3f2\x8f\xb29\xee*\x80@\x0b!" +
-	"\xd7\x13\"\x9e2\x93\xc9\x94]\xcb\x91\xd9\x84\xcc'\xc4" +
-	"\xf5\x9d\x99\xbc\x0c\xb2y\x1c\xb9\x86\x90vB\xdc\xdf\x9a" +
-	"\xc9\xb4\xca4\x8e\\OH\x17!y'M\x97\x82y" +
-	"\x00L\xe7\xc8|BB\x84H'\xcc|\x85\x0f\xbc\x83" +
-	"\\\xb6vB\xa2\x84\xe4\x7fC\xfb\xe4\x03\xb0n\xfeN" +
-	"\x17!\x06!\x83\xfe\x97\xf6\x19\x04\xc0\x16r$DH" +
-	"\x0f!\x05_\xd3>\x05\x00,\xc1\x91(!\xb7\x10R" +
-	"\xf8W\xda\xa7\x10\x80\xf5\xf2}\x0cBn%\xa4\xe8/" +
-	"f\x83\x82E\x00l\x09W[\x0f!\xcbIm\x9e\xaf" +
-	"L\x05=\x00l\x19\x07n!`%\x01\xc5\xc7M\x05" +
-	"\x8b\x01\xd8\x0a\x0e\xdcJ\xc0\x1d\x04x\xbf4\x15\xf4\x02" +
-	"\xb0U\xee)\x00\x81\xe5\x04<D\x80\xef\xcf\xa6B6" +
-	"d\xeb8p\x1f\x01\xcf\x10 \xff\x8f\xa9\xa0\x0c\xc06" +
-	"s`#\x01\xbb\x09(9f*X\x02\xc0vq\xe0" +
-	"E\x02\xde#\x80}a*\xc8\x00\xd8~\x0e\xec#\xe0" +
-	"K\x02\x94\xcfM\x05\x15\x00v\xcc]\x03\x10\xf8\x8c\x00" +
-	"W\x9e\x80\x9e\xc1GM\x05\x07\x030\xcc\xa37N\x11" +
-	"PJ\xc0\x90#\xa6\x82C\x00\xd8`\x0e\xf8\xf2D\x0c" +
-	"\\H\xc0\xd0\xcfL\x05\x87\x02\xb01\x1c\x18I@\x03" +
-	"\x01\xa5\x9f\x9a\x0a\x96\x02\xb0K\xf2h\x8f\xc9\x04\xcc&" +
-	"\xa0\xfc\x13S\xc1a\xe4by\xa4\x92\x19\x04t\x11P" +
-	"q\xd8T\xb0\x8c,\x9f7\x95,O\xc0\x1d\x04\x9c\xf3" +
-	"\xdf\xa6\x82\xc3IW\x9c\xd5r\x02\xee$\xa0\xf2cS" +
-	"\xc1r\x00\xb6\x9a\x03+\x09\xb8\x9b\x80s\x0f\x99\x0aV" +
-	"\x00\xb05y\xad\x00\x81;\x09\xd8H\xc0\x88\x8fL\x05" +
-	"\xcf\x01`\x8f\xe6]\x01\x10x\x84\x80g\x08\xa8\xfa\xd0" +
-	"T\xb0\x92\xb4\x9bw\x1d@\xe0I\x02\xb6\x11p\xde\x07" +
-	"\xa6\x82\xe7\x02\xb0-y\xb3\x00\x02\xcf\x11\xb03O\xc0" +
-	"\x8a\x91\x07\xc9\x83F\x00\xb0\x1d\\\xdem\x84\xec\xa6W" +
-	"F\xbdo*XE\x06\xe1'|\x91\x80W\x098\xff" +
-	"=S\xc1\xf3\x00\xd8\x1e\x0e\xec$\xe0\x0d\x02.\xf8/" +
-	"S\xc1\x91\x00\xec\xb5<r\xc6\xdd\x04\xec#`\xf4\x01" +
-	"S\xc1Q\x00\xecM.\xef\x1b\x04|A\xc0\x98\xfd\xa6" +
-	"\x82\xe7\x03\xb0#\x1c\xf8\x8c\x00\x97$\xa0g\xd8\xbb\xa6" +
-	"\x82\x17\x90\x09%\x92\xea\x14\x01\xa5\x04\x94\xbdc*8" +
-	"\x9aL\xc8\x01\x9fD&$`\xf8\xdb\xa6\x82c\xc8\x84" +
-	"\x1c\x18I@\x03\x01\xfe\xb7Lt|\xdag\x97HU" +
-	" x\xc6\xee3\x15\xbc\x10\x80\x8d\x92\xe8\x10\xe7\xa6\xf9" +
-	"\\\xf8\x9f\xa6\x82\xe38\x9f)\xfd\xf8\x8c\xdbk*8" +
-	"\x9e\\\x81\x03\x93\x09h\"`\xfc\x9b\xa6\x82\x17\x01\xb0" +
-	"F\x89t\xdb@\xc0\x0c\x02.z\xc3T\xb0\x1a\x80M" +
-	"\xe7\"5\x11\xd0B@\xf5\xeb\xa6\x82\x13(qI1" +
-	"r\x1e\x02\xae\x91\xd2\x95M\xbc\xe9&\xf4\xd9_\xfe\xac" +
-	"\x02V71=\x12\xea\xa8\xad\xc1\x02\x10\xb0\x00P\x0a" +
-	"\xd6M\xb4\x1a6)X[c\xb5fRpB\x9d\xd5" +
-	"!\x89\xc1\xc9(\x80\x80\x02\xa0\x94\xa8\x9bh\x0dK\xa4" +
-	"Dm\x8d5\xff\x94\x12\x13\xeaP\x02\x01%@11" +
-	"\xd9\xea\xa0\xbc\xad\x91H\xc8j\xef\x9c\xf3p\xf4\xb6\x86" +
-	"\"\xad\xd6\xac\xa0\xbe\xa3n\xa2c\x86gM\xb9:j" +
-	"k\x1c\xab\x05\xa9\xd5`?Z\xb7\xb5\xda\x8f\xd6e\xad" +
-	"N\xa8s\xac\x8a\xc9\xd5\xca\xe0d\xc7\xa2\x90\"M\xf4" +
-	"c;\xc8Z\xed\xc76\xdfZ\xed\xc7VJ\xb1M8" +
-	"\xd9\xe6%\x17\xbd7\xf5\x9bM:\x8dR\x0c\xb8\x94P" +
-	"\x07A.\xba\xca\x9b\xa8\xf3\x1dP\xe2\x92\xeb\x1a\xfa\xec" +
-	"\xff\xe7\x91Q+\xa1\xffh4cLh\x93\x01d\xa0" +
-	"\xc4$\x96\x1a\x94\x82\x18\x09\xa3\xcf\xfe\x9f3)\x98\xcf" +
-	" [\xb58`\x96\xceq\xa9\x96l\x842\xaeq^" +
-	"2\xfc\xffC\xdf\xc5\xf5\xb1Hos|\xe3\xcc\xfc~" +
-	"\x95$\xd22\x89\x9c\x9a\xe2\x9fD#\x91\x90CG\xa9" +
-	"O\xa2KS\xafZ\xcb\x9e\xd42y\xb0c9\xd5\xfb" +
-	"H\x9d\xb1h\x8ea~=-\xdb\x97\xda\xfe8\xb9\x92" +
-	"\x16\xeem1b\xe9\x81\x9c\x16\xee\xe5#o@\x03K" +
-	"\\\x08H\xabK\xb50\xbfm`\x89KH\xae\x10\xdd" +
-	"\xa5ZTk\x85\xca`(h\xf4b\x89K\xb4\x90\xcc" +
-	"\xa6\xda\x9a`V\xf2\x9b#\x9f\x0f\xd8\xff\xfd\x08k*" +
-	"/\x0b\xea\xa1\xf6\\-|\x07\x81\x8e\x91U\xfa\xcd\x1c" +
-	"-\xfc\x95z\xdc\xd0c\x13.\xd5\xc4\x01\x03{\xbf\xcd" +
-	"\xd6\x1b7b9/\xbbg\xf8$\x939$\x152G" +
-	"\xd9\xa8\xfd\x98kx\x95\xe3cQ\x8ey\xd4\xc0/\x07" +
-	"sg\xebq\xe3R-\x8a\x99g\xbb.5\xec\x9b," +
-	"\xa0\x19\xd6\xe3\xc6L\xcd\x88\x81\x18\xec\x19\x10\xd8g\xfa" +
-	"8\x91\xfe(\x83\xda\x0f|Su\x08\xfc\x7f\x01\x00\x00" +
-	"\xff\xffX\x17OX"
+// AllocBenchmark_FieldPogs is a plain Go representation of AllocBenchmark_Field.
+type AllocBenchmark_FieldPogs struct {
+	StringValue string
+}
+
+// ToCapnp allocates a new AllocBenchmark_Field in seg and copies p into it.
+func (p *AllocBenchmark_FieldPogs) ToCapnp(seg *capnp.Segment) (AllocBenchmark_Field, error) {
+	s, err := NewAllocBenchmark_Field(seg)
+	if err != nil {
+		return AllocBenchmark_Field{}, err
+	}
+	return s, p.Insert(s)
+}
+
+// Insert copies p into s.  Capabilities in p are not consumed: s gets
+// its own references.
+func (p *AllocBenchmark_FieldPogs) Insert(s AllocBenchmark_Field) error {
+	if err := s.SetStringValue(p.StringValue); err != nil {
+		return err
+	}
+	return nil
+}
+
+// FromCapnp sets p to the values in s.  Like pogs.Extract, it does not
+// copy everything: Data and AnyPointer fields refer to s's message, and
+// capabilities are borrowed from its capability table, so they are only
+// valid until the message is released.  Use bytes.Clone and AddRef to
+// keep them for longer.
+func (p *AllocBenchmark_FieldPogs) FromCapnp(s AllocBenchmark_Field) error {
+	var err error
+	*p = AllocBenchmark_FieldPogs{}
+	if p.StringValue, err = s.StringValue(); err != nil {
+		return err
+	}
+	return nil
+}
+
+type Endpoint capnp.Struct
+
+// Endpoint_TypeID is the unique identifier for the type Endpoint.
+const Endpoint_TypeID = 0xff376f86fe50cfe6
+
+func NewEndpoint(s *capnp.Segment) (Endpoint, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 8, PointerCount: 2})
+	return Endpoint(st), err
+}
+
+func NewRootEndpoint(s *capnp.Segment) (Endpoint, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 8, PointerCount: 2})
+	return Endpoint(st), err
+}
+
+func ReadRootEndpoint(msg *capnp.Message) (Endpoint, error) {
+	root, err := msg.Root()
+	return Endpoint(root.Struct()), err
+}
+
+func (s Endpoint) String() string {
+	str, _ := text.Marshal(0xff376f86fe50cfe6, capnp.Struct(s))
+	return str
+}
+
+func (s Endpoint) EncodeAsPtr(seg *capnp.Segment) capnp.Ptr {
+	return capnp.Struct(s).EncodeAsPtr(seg)
+}
+
+func (Endpoint) DecodeFromPtr(p capnp.Ptr) Endpoint {
+	return Endpoint(capnp.Struct{}.DecodeFromPtr(p))
+}
+
+func (s Endpoint) ToPtr() capnp.Ptr {
+	return capnp.Struct(s).ToPtr()
+}
+func (s Endpoint) IsValid() bool {
+	return capnp.Struct(s).IsValid()
+}
+
+func (s Endpoint) Message() *capnp.Message {
+	return capnp.Struct(s).Message()
+}
+
+func (s Endpoint) Segment() *capnp.Segment {
+	return capnp.Struct(s).Segment()
+}
+func (s Endpoint) Addr() (netip.AddrPort, error) {
+	var v netip.AddrPort
+	p, err := capnp.Struct(s).Ptr(0)
+	if err != nil {
+		return v, err
+	}
+	if !p.IsValid() {
+		return v, nil
+	}
+	err = v.UnmarshalText(p.TextBytes())
+	return v, err
+}
+
+func (s Endpoint) HasAddr() bool {
+	return capnp.Struct(s).HasPtr(0)
+}
+
+func (s Endpoint) AddrBytes() ([]byte, error) {
+	p, err := capnp.Struct(s).Ptr(0)
+	return p.TextBytes(), err
+}
+
+func (s Endpoint) SetAddr(v netip.AddrPort) error {
+	b, err := v.MarshalText()
+	if err != nil {
+		return err
+	}
+	return capnp.Struct(s).SetTextFromBytes(0, b)
+}
+
+func (s Endpoint) Ip() (netip.Addr, error) {
+	var v netip.Addr
+	p, err := capnp.Struct(s).Ptr(1)
+	if err != nil {
+		return v, err
+	}
+	if !p.IsValid() {
+		return v, nil
+	}
+	err = v.UnmarshalBinary(p.Data())
+	return v, err
+}
+
+func (s Endpoint) HasIp() bool {
+	return capnp.Struct(s).HasPtr(1)
+}
+
+func (s Endpoint) SetIp(v netip.Addr) error {
+	b, err := v.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	return capnp.Struct(s).SetData(1, b)
+}
+
+func (s Endpoint) Timeout() time.Duration {
+	return time.Duration(capnp.Struct(s).Uint64(0) ^ 30000000000)
+}
+
+func (s Endpoint) SetTimeout(v time.Duration) {
+	capnp.Struct(s).SetUint64(0, uint64(v)^30000000000)
+}
+
+// Endpoint_List is a list of Endpoint.
+type Endpoint_List = capnp.StructList[Endpoint]
+
+// NewEndpoint creates a new list of Endpoint.
+func NewEndpoint_List(s *capnp.Segment, sz int32) (Endpoint_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 8, PointerCount: 2}, sz)
+	return capnp.StructList[Endpoint](l), err
+}
+
+// Endpoint_Future is a wrapper for a Endpoint promised by a client call.
+type Endpoint_Future struct{ *capnp.Future }
+
+func (f Endpoint_Future) Struct() (Endpoint, error) {
+	p, err := f.Future.Ptr()
+	return Endpoint(p.Struct()), err
+}
+
+// EndpointPogs is a plain Go representation of Endpoint.
+type EndpointPogs struct {
+	Addr    netip.AddrPort
+	Ip      netip.Addr
+	Timeout time.Duration
+}
+
+// ToCapnp allocates a new Endpoint in seg and copies p into it.
+func (p *EndpointPogs) ToCapnp(seg *capnp.Segment) (Endpoint, error) {
+	s, err := NewEndpoint(seg)
+	if err != nil {
+		return Endpoint{}, err
+	}
+	return s, p.Insert(s)
+}
+
+// Insert copies p into s.  Capabilities in p are not consumed: s gets
+// its own references.
+func (p *EndpointPogs) Insert(s Endpoint) error {
+	if err := s.SetAddr(p.Addr); err != nil {
+		return err
+	}
+	if err := s.SetIp(p.Ip); err != nil {
+		return err
+	}
+	s.SetTimeout(p.Timeout)
+	return nil
+}
+
+// FromCapnp sets p to the values in s.  Like pogs.Extract, it does not
+// copy everything: Data and AnyPointer fields refer to s's message, and
+// capabilities are borrowed from its capability table, so they are only
+// valid until the message is released.  Use bytes.Clone and AddRef to
+// keep them for longer.
+func (p *EndpointPogs) FromCapnp(s Endpoint) error {
+	var err error
+	*p = EndpointPogs{}
+	if p.Addr, err = s.Addr(); err != nil {
+		return err
+	}
+	if p.Ip, err = s.Ip(); err != nil {
+		return err
+	}
+	p.Timeout = s.Timeout()
+	return nil
+}
+
+const schema_832bcc6686a26d56 = "x\xda\xacz}x\x14U\x96\xf79U\xdd]!\xa4" +
+	"\xa9\xaeT\x11CHh\x89\xe0@#\x10\x12&|\xcc" +
+	"\xeb\x9b\x04\x13E\x174E@\xd4\x95\x91JRI\x1a" +
+	";\xddMu5$\xa8\x0f\xba\x03\x8a>\xb2#\x8f:" +
+	"~2\xab,\xec\x88\x82\x8a\xca\xae0\x80\xe2\x80\x03Q" +
+	"Wa\xf1\x03VQqQA\xdd\x11g\\Q\xd1\xda" +
+	"\xe7\xdc\xee\xea\xea\xf4\x07(\xcf\xfe\x03\x95\xfb;\xf7\xdc" +
+	"s\xcf9\xf7\x9cs\xcf\xed\xaa\xed\xdez\xd7\x04\xefS" +
+	"\x83\x81SO\xb9=\xd6\xee\x93_\xbcUu\xc3\xc8\xe5" +
+	"\xa0\x8a\x88\xd6\x95\xddkn\xedxu\xcco\xc0%\x00" +
+	"\xc8\xdb\x0b\xfb\xe4\xbd\x85\xf4\xb5\xbb\xb0\x0e\xd0\x9a\xf7\x8a" +
+	"\xef\xd7\x03\xb6M\xbf-\x83\xd6\xcd\x13\xc9\xd1\xc2\xad\xf2" +
+	"\x17\x8c\xf8X\xe1'\x80\xd6;\xcf\xfeXu^\xdd\x9f" +
+	"n\x03IL\xa7E\x01\xa0\xe6\xc3\x81\xc5(\x9f\x18H" +
+	"\xc4_\x0c$\xceS{\xeak\x9fym\xf8\x8a\x0c\xce" +
+	"\x8d\x02\x07 W\x14\xf5\xc9\xa3\x8b\x88xd\xd1b@" +
+	"k\xcd_\x87\xbe\xfd\xdcu%w\x80\xa4 $9\xde" +
+	"R\xc4!\xa0\xbc\xbc\x88\xb8M\x1c9\xea\xd8\xce\xca\x96" +
+	"\x7f\x04I\xe4\x1df\x80\xf2\xba\xa25\xf2F\xc6i}" +
+	"\xd1%\xf2>\xfa\xb2\xee\xda\xe3\x7f\xb9\xe1\x86'\x7f\x9b" +
+	"!'GT[\x8a\x8e\xc8\xbb\x19\xfdN\xb6\xb2e\xbc" +
+	"z\\}`\xef\xdd\xfdi\x99\xaeF{_\x92'x" +
+	"\x05\xe0\xad\xa2\x03\xbf\xdbU\xfcD\xd5= \x89\xae~" +
+	"\xab{\xbd}\xf2\x10\xaf\x00\xd0\xa2xyl\xa9\xf2r" +
+	"\x08`\xad\x1c^0\xf1\xe4M/\xdc\x93CO\xf2X" +
+	"o\x9f<\x85f\xc8\xbf\xf4\xd2\xc6\xae\x0f\xcfW\xea~" +
+	"\xdcqo.\x9d\xce\xf1\x16\xa3\xac3b\x8d\x11\xaf\xbc" +
+	"\xcd\xff\xf2\xf4\xdb?\xba\x8ft\xcae\xeel\x95\xf7%" +
+	"\xf9\x01\"\xae\xb9\xd7\xebG@\xeb\xea\x0d\xdcC\xf7=" +
+	"\xb0\xf9\xc1\\bl\x1c\xd4'o\x19D_\x9b\x07\x11" +
+	"\xe7\xd57\xbc\xb7e\xf4\xdb\x93\x1eJ7\xc0{\x83\x0a" +
+	"\xc9\x00G\x19\xc1\x9e9G\xdc[\x7f\xf1\xdb\x87\xb2T" +
+	"\xe0\x16\xfbdI$\x15\x14\x89<\xb6\x94\x8aL\x05\xf1" +
+	"_\xb9n\xb5\xaa\xabVg\xfa\x15[|\x80\xd8'\x0f" +
+	"\xa6)\xb2$\x92\x0d\xee\x1b\xb6o|\xf3\xb7\xfaZP" +
+	"\xcb\x11\x81)\xbf\xa6[4h\xf1^\x91\x16\xef\x98\xde" +
+	"\xf9\xd5\x8f\xf2\x0b\x8f\xe5\xda\xc9\x03\xe2K\xf2\xa3\x8c\xd9" +
+	"jF{\xee\x9esz\xae{\xff\xc9'\xb2<e\xbb" +
+	"xD\xde\xcb\x08w\x8b\x97\xc8'\xe8\xcb:q\xd3\xcc" +
+	"\xc0\xb49o<\x91K\xfb\x07\xc52\x94\x8f\xb1\x09G" +
+	"\x19\xe7-\x9f=\x1di:t\xdb\xc6\\R\x0c\xf6\xad" +
+	"\x91+|\xf45\xc4G\xb4R\xb4\xfd\xed\xb0{\xc3\xa6" +
+	"\\\xb4S|_\xc9M\x8c\xb6\x81\xd1.\xad\xfd\xf5\xb2" +
+	"y\x93\xbf\xdcD\xba\xe23\xcf\xe0B\xdf~\xf9&\"" +
+	"\xae\xe9\xf5\xcdE@+\xd67\xd1\xfa\xfc\xc8\xb0\x7f\xcd" +
+	"\xe5\x035G%\x0e\xe5\x13\x12;\x85\x12\x1d\xd9\xb5\xff" +
+	"\xb2u\xe4\xcb\xddc\xfe\x0dT\x09y\xeb\xe0#o\xfd" +
+	"\xea\xbec\xe7\x1f\x87\xc1( \x1d\x81\xe2\xc7\x01\xe5\x9d" +
+	"\xc5d\x84\x8f\x17,\xfb\xe2\xf9\xf6\xef\x9f\x07i\x18Z" +
+	"s\xae\xdc\xb1\xed\xf7\xff\xff\xf8\xe7IUT\xc8\x95(" +
+	"\x8f\x95\xd9\xa1\x90I\xe4\x9a\x93\xab\xfa6=\xfb\x87\xad" +
+	" \x0d\xb1\x0d6S^\x80\xe0\xb2\xbe\xc3\xa3\x97\xfd\xdd" +
+	"\x0b\xaf\xfd1\x81$\xa4\x9a\"3[6\xc9\xec\xa8\x0f" +
+	"\xfcj\xcd\xb7\xfa\xa1?\xe7\xd2\xcc:\xf9qy#[" +
+	"f=[f\xec\xcc)\xdb?z\xe2\xef\xf7\xe6:\xc8" +
+	"{\xe5>\xf9MF\xbb\x8f\xf1\xdd\xfa\xcd\xfbo^\xb7" +
+	"\xe8\xddWr\x99r\xacR\x86\xf2\x85\x0aS\xbeB\x8c" +
+	"\xef\xdf\xb5~\xe0\xc7R\xe3\xab\xb9\x84\x08*[\xe5\x85" +
+	"\x8c\xb6\x9b\xd1~p\xfe\xe4\xf9\x1f=\xf3lN\xdaG" +
+	"\x955\xf2zF\xbb\x8e\xd1^\xda|\xe4\xe0\x91'\x1b" +
+	"\xff=\xa7)w+\xc7\xe5}D\\\xf3\x9a\xc2L\xb9" +
+	"\xe7\xe6\x9d\xe5}\xc7\x1fz=\x97\xc8\xa3K\x8aQ\x9e" +
+	"R\xc2\x02E\x09\xb1\xdeqh\xc5\x89\xb5\xdf\xd7\xbe\x95" +
+	"K\x0c\xbd\xe4A\xb9\x9b\xd1\x06\x19\xed\xb6\xcbO~Y" +
+	"P\xaf\xbe\x95u\x06n/\xe9\x93\xefe\x84\xabJ." +
+	"\x91\xb7\x97\x9c\x03`\xad\x9a\xf4\x87\xf9\xe1\xd7\xb7\xbdC" +
+	"2\xbb2\xb5\xbc\xb1\xa4O\xdeB\x13j6\x970\x99" +
+	"W\xberp\xf1\x8a\xf9w\x1e\xcc%\xc6\x90\xd25\xf2" +
+	"\xf0R\xfa\xaa(%1\xe4E\xdf\x07;\x1a\xf6\xbd\x97" +
+	"\xcb|\x17\x96\xae\x91\x9b\x18mC)\x99\xaf|g[" +
+	"\xc1\xef\xca\x02\x8735\x97\x90\xa2t\xbf\xbc\xa5\x94I" +
+	"Q\xca\xa4X={\xee\xc6?>\xd9|8W\x8a\x1b" +
+	"P\xf6\xb8,\x95\xd1\x97\xb7\xec)@\xeb\xee\xaa\xc7\xbe" +
+	"\xfb\x7f\x07~\x7f8\x97\x96\xd7\x95\x15\xa2\xbc\x99\x11o" +
+	"*#\x917\xee\x10\xa47\xf7\xad\xf90\xd7\xf6>," +
+	"\xdb*\x1fc\xb4G\x19\xad\xd6RS\xbc\xfb\xf8\xde\x9c" +
+	"\xb4\xde\xa1\x0f\xca\x83\x87\xd2\x974\x94hO^\xfd\xc8" +
+	"o\x1e^Sp4\x97\x10\x13\x86\x16\xa3\xdc\xc0\x88/" +
+	"d\xc4\x9b\xfe<\xe7\xf0\x93\xbe\xcb\x8ff\xec\xae\x09\x05" +
+	"\x17y\xe5\xd0\x97\xe48\xa3^8\x94\x8e\xf8\xf0\xf1'" +
+	"\x87\x9eZ>\x8fXs\xfd\x8c\xddT\xbeU\x9eYN" +
+	"\x84\x97\x96\x93\"\xde\xf5|\xfbO\xcb\x96\xde\x92)\x03" +
+	"\xf3\xcd\xd5\xe5}\xf2zF\xbb\x8e\xd1^\xddU\xf6\x97" +
+	"\xa9\x9f-\xfb8\xd7\xde\xba+\x0e\xc9\xbd\x15\xf4\x15\xaf" +
+	"`)\xec\xcd\x1dk7\x96-\xfc$+5\xac\xae\xe8" +
+	"\x93\xd7\x13a\xcb\xda\x0a\x1e[\x9e\xae`\xa9!\x15\x84" +
+	"\xfa[\xba\x09\x85\x09\xb4|\xc5\x1d\xf2F\x9aS\xb3\xbe" +
+	"\xe2\x85BH\x0bE9$\x99\x19\xf8J\xbe:p\x0e" +
+	"\xe5\xc7\x00IR^0o\xda\x80\x8dW\x9c\xc8E\xbb" +
+	"*pH^\x1d`\x19\x83\xd1\xde?\xa4\xf8\x8e\xbf\xfd" +
+	"\xc3m_\x83Tn\x87\xb0\xbd\x01\x16\xc2~\xe8\xb8d" +
+	"o\xd3\xbb\xeeo2\",s\xae\xcd\x81\xfd\xf2N\xc6" +
+	"e{\x80\xdcvAi\xe8\x12\xa5\xd1\xfa&\xe7q\x18" +
+	"\xb3_\x1e9\x86\xbe\x86\x8f\xa1\x15\x0f\xcf\xd8q\xf7(" +
+	"\xf3\x9fO\xe5rZ}\xcc~y!\xa3\xedf\xb4\x1f" +
+	"\xbf\xde\xfc\xe3\xad\x91IVf\xfed\xc7a\xe5\x98\x97" +
+	"\x00\xe5\x95c\x9e\x82\xb1\x96\x164\xda\x0c\xad\xc3\xe4\xc6" +
+	"\xb5i\xd1ptj\x8b\xa9\xb5]\x1f\x0cwN\x03h" +
+	"FT]\xbc\x0b\xc0\x85\x00\x92\xb7\x12@-\xe0QU" +
+	"8\x14\xc2\xf1nt\x01\x87.\xc0\x14\x07Lr\xb8\xa8" +
+	".\x12\x0f\x9b\xbaA\xd3}\xa9\xe9Z\x00@\xbd\x96G" +
+	"\xb5\x8bCD\x05iL\xaf\x06P\xe7\xf3\xa8\x868\x94" +
+	"8T\x90\x03\x90\x82\x97\x01\xa8]<\xaa\xcb8\x94x" +
+	"NA\x1e@\xbae\x1a\x80z#\x8f\xea\xfd\x1c\x8a\xb1" +
+	"\xe0\x12\x1d\xdd\xc0\xa1\x1b\xd0\xbf8b\xb4\xc7\xb0\x088" +
+	",\x02\xb4\xe8\xafP0f\x02\x00\x0e\x02l\xe6\x91A" +
+	"\x83\x00\x97\xb6\x06MB\xecaL\x0c\xa7\xa4\xe7\x93\xd2" +
+	"O\x8f\x84\xdacW\xea\xc6\xec\xc5\x91\xd9\x8b#\xcd\xa1" +
+	"8\xc62\xf405\xa9\x87\x11\x1c\xd6u\xf7\xa6\xf3\xf4" +
+	"9\xf1\x1b\xb0\x1fw[\xbbWF\x82\xeds\xc2\xc1H" +
+	"8\xa1\xdd\x02\xdeUdY\x8c\xed\xe8b\x00u\x04\x8f" +
+	"j\x15\x87^\xfc\xd1Jhh,\x8d\x8e\xe2Q\x9d\xc8" +
+	"!j\xe0\xc1V\xf0d\x89\xdc\xd4\xd6\x15\x19\xa7\xb7u" +
+	"EF4k\x86\xd6\x1d\x83ti\xcb\x1c\xab\xf1\xc1p" +
+	"JQ\x99Fk\x12\xda\xba\"\x89\x8d\xba\x01R\xf54" +
+	"\xdau\x9d$\x05\x80\x93\xdc\x82H\xeb\xd4c3b^" +
+	"\xcf\x99%D\"frw\x88.D\x94F/p\xb6" +
+	"\xe1K\x1a\x7f\x0amm\"\x8fj=\x87\x9667h" +
+	"v5\xea\x1d j\xf1\x90\x89>\xa7\xfe\x03D\x1fS" +
+	"\x16\x06\x00P\xcb\x82r\xe8X7\x9a\xba\xa3f/\xe4" +
+	"\x12\xb2-\x12\x8e\x993\x98\x87\x10\xcf\x94\xe1R\x09\x81" +
+	"\x19\x0e$<G*@\xf1}\xa1`(\xfdS\x9ek" +
+	"\x91+\xc2z\xb3i\x9c\xf6\x98DM\x03}\xce\xb9\xcd" +
+	"\x108\xb7\xcb\xcd\x08\xc6\xd0\xfc\x19.\x97\xaa\xe7\xf2\xb8" +
+	"\\\xa3\xdeA:\x8d19\x95\x04OD\xe9&:\x8f" +
+	"=\xc9c\xc6\x0e$\xa2\xb4\x9c\x06o\xe6Q\xbd\x93C" +
+	"\xe4\x14\xe4\x10\xa5\x95tHW\xf0\xa8\xdeC\xe7\x11\x15" +
+	"\xe4\x11\xa5U\xb4\xcb;\x13\xe7Qrq\x0a\x99Y\xba" +
+	"\x97f\xdf\xc5\xa3\xfa0\x87\xa2\xa9\xf7\x98Io\x03\x09" +
+	"+\x85\x8eHDl\xd7L\x0d\xbd\xc0\xa1\x97\xc6\xca\x84" +
+	"V\xcd\xf0w\x84\"\x9a\x89\x85\xc0\x9d(\xfc\xd3\xd7\xd3" +
+	"\xeb\x01\x85`\xd8\xa4\xd8r\xc2\xb5\xdc\xb2,@1N" +
+	"\x03\x05\xc0I\x05\x81\x1c\xdb\x9bkh\xd1\x84\xb93\x0d" +
+	"\xf1\x0c\x80\xea\xe3Q-\xe7\xd0\xea\x0evv\x99\x97G" +
+	"L\x9c\xa6\xcf\xd2\xb5P\xa8\xd7\xcf\xe6\xa0\xcf\xb9\xbf\xe5" +
+	"1\x8es\xb8f\xe91\xa6G\xc8g\xedH\xdc\xcc:" +
+	"_\xfd\xfc\xae)\x1c\xefN\xf8\x9d\xe8d]@\xb7\x88" +
+	"\x90\xff85\xa4BEr\xcd\xd1\x95N\xa4@\xcc\x8e" +
+	"\x13\xe9\xc1\x19[\xd1\xe7\xdc\xe73\xb6\xe8\xb2\x03\xb6\x16" +
+	"\x0a\xb5\xe8\x0b\xe3z\xb8M\x1f\xd7\xa9\x9b\x97\xc7\xbb[" +
+	"uc\xc4,\xdd\xcf6\x9c\xbe\xddbg\xbb\x18&\xa3" +
+	"`A\x0e\xd1Ig\xd3\xb4\x98\x9ei\x91\x803\x9b\xc5" +
+	"\x11\x94\x9c{9 J9X\xa5d\x13\xc2m\xba\x13" +
+	"\x9f\xec\xe4\x8b\xf6\xd5O\x92f\x01'\x0d\x10,[~" +
+	"@\xa3\x7f\x98\xca<mW\x84\xf5F\xcd\xd4f\x04\xf9" +
+	"\xd8\xcf9n\xe9\x87yP\x8e@:]\x88\x98]\xa7" +
+	"\xd9w\xab\x16\xd3\xd1\xe7\xdcH\xf3\x041\x0aQ-\xa6" +
+	"\x11o\xf3\x9b\x17i\xd1p\x9e\x183\x82Ca\x91\xde" +
+	"\xe6H\x97*`\xf2\x04\x83Yz\xa7\xa1\xc7b\xc1\x08" +
+	"2\x96\xa5)\x96\x0f\x90\x8c\xf7\xf0\xa8>\xe28\xd5j" +
+	"\xca\x1d\xf7\xf3\xa8\xaeMK\xcf\x8f\x12\xe1\xc3<\xaa\xcf" +
+	"\xa7\xa5\xe7\xcd\xa4\xb1\xa7yT_\xa5p\x80\x0a\xba\x00" +
+	"\xa4\xbd$\xe5.\x1e\xd578\x94\xdc\x9c\x82n\x00\xe9" +
+	"5\x1a\xdc\xc3\xa3z\xc0\xd1E\xea\x02\x91\xd0\x05\xdfZ" +
+	"\x85\x03\x81\xc3\x81\x80b\xabnj\xf6\xee\x06&2v" +
+	"]4\xa4\x85\xf5\x98\xb3\xe7T\xb5\x9b\xd8\xb3\xd0\xdb\x1d" +
+	"\xb7\xe7\x0b\xbd\xb1v\xfb;\xcb\x11\x12\x11\x97|\x80\x12" +
+	"\xbdi@F\xc1R\xe9\x14,R\xaab\xa9t*\x16" +
+	"\xe4\x92\x05\x0bi\xa4\x9dG5j\x07H\x00\xa9;\x90" +
+	"\xacbL2\x91\x16B\x1e8\xe4\x01\x85\xf6x\xc4\xae" +
+	"]\xc4\xa8iL\xc8\xce\x0f4\\}\x9a\xb4a{\xda" +
+	"5\xe2\xb8N#J\x81\x81Rj\x80\"C\xb5\x13\x19" +
+	"$\x12o\x0c\x85\x86\xa9Nh\xf0w\x04\x8d\x98\x89\x03" +
+	"\x80\xc3\x01\x80u1\xbd-\x12n\xb7\xff\xccRPC" +
+	"(\x14i\x9b\xa6\x87\xdb\xba\xba5\xe3\xfaq\x17\x07\x05" +
+	"=\xd4\x9e\xe1\x89\xad\x00j\x11\x8fj)\x87V\xcc4" +
+	"\x82\xe1\xce+5\x10Bq=+\x12\xda\xd1\xa69\x18" +
+	"\xd5C\xc1\xb0n\x8c\x0b\xeb\x8bS\x7f\x8ch\xd6D\xaa" +
+	"]~*y*8\xa5\xc5\xc5~\xbb\xb7\x03\xe3,\x00" +
+	"\xf5\x02\x1e\xd5\xc9\x1c\xfa\xf5\x1e\xd3\xd0\xb0\x188,\x06" +
+	"\xb4\xa2If\x80\x06J\xce\x957#\x109\xda\xa6\xec" +
+	"u\x9a\x83\x9d\x96\xdcr\xd6$I'\xcb\x0c\xe6\x81\\" +
+	"B\x07\x1c\x93\x9d\xa5\x93\xe4\xa8-\xce&\xda\xa5\xae2" +
+	"y\xa2\xdd5u1\xddX\xa4\x1b\x19,m\xaf\x18\xc5" +
+	"\xa1\xb5X\x0b\x9a\xc1p\xe7\x02\x10\"\xadi\xa76u" +
+	"\xb7\xcf\xc3y\x9a0\xa9f\xd2\x99\xe3hF\xec\xc8\xa3" +
+	"\xf8\xd9\x8b#bs(\x1e\xcb\x88y\x95N\xccK\xe9" +
+	"~u\xa5\x13\xf4\x90\xcb\x88y\x8f\xa5\x9d\xf0u4\xf8" +
+	"\x08\x8f\xea\x06\xbb\x04\x02\x90\xd6\xd3\xec\xb5<\xaaO\xa7" +
+	"\xc5\xbc\x8dD\xf9\x18\x8f\xea\xae3\xc7\x82\xf4j.\xcd" +
+	"\xcc\x19\xc3\x82i\xa4n@b(f\xd6\xd8zu\x9f" +
+	"\xf9V\xd3l\x1a?\xb7\xc4L\xb5\xd1\xf2\xd9\x8a\xd7:" +
+	"3\xd8MsL\xb5\xb4-q\x1fD\x9f\xf3Z\x90\xc7" +
+	"Z\xa9\xf3\x0d\xe0\xe4z\xbbW\x88vkP\x92\x16$" +
+	"r\xbd\x1d\x11@\xa49\xf5\xa8\xba0\xad\x7f\x0b\x90\xcd" +
+	"\x9fR\x07\xabL \x7f\xfas\\\xa1:\xe9\x0a\xcf9" +
+	"\xae\xb0\x89\xf4\xb4\xc1N\x7f\xf5\x19\xe9o[\x9a+l" +
+	"\xa1{\xec\xf3\x09\xabKn>\xe1\x0a;i\xf0\xc5D" +
+	"\xa2\x14\xc3Zw*L\xfa\xbb\"\xddNR\xebW\x1e" +
+	"\xb2\xa4ght\x88l\x9b\xd7\xb5i\xe1\x8bC\xbd\x88" +
+	"\xc0!\x02ZmZTk\x0bR\x0d\x0c6\x89\xd5\xad" +
+	"\xf5\xb4Du\xbd\x9d\xc62S\xa0m\xb8\x06\xa1\xa6\xba" +
+	"\xea\xec\x0fY*\x0a\x08\x0b\"\xad\xf9\x8b\xd4\xec\xb8\xd6" +
+	"\xc8\xa1\xd0\xd6\xddno^\xd4\x8c\xceX\xc6}=\xcb" +
+	"v\xa9D\x84\x0d?\xa1t\xb9,\xed\xc4\xda\xa5\xcb\xba" +
+	"\xea\xb4\x13k\x97.\xeb/K\x1e\xce\xe7\xc8v\xf3\x13" +
+	"\xb6\xebge\xfb\x18o\xaev\xac\xdc\xcfvVk\xd0" +
+	"0\xbb\x1a\xb5t\xf5\xfb\xa3]\x91\xb0C\x11\x0b\xb6\x86" +
+	"\x82\xe1\xce\x18Q$K\xf3\xbaX4\x12\x8f\xe9\xb6\x0d" +
+	"\xfd\xdd\x91\xb0\xde\x9b\xd7R,\xfd\xb0\x02\xb8(\xb5\xf1" +
+	"&\xdax=\x8f\xea\x8cd\xba\xa7\xc1KI\xcaF\x1e" +
+	"\xd5f\xda9\x9f\xd8\xf9L\xb2\xc6t\x1e\xd5\xd9\x1c\x8a" +
+	"\xbd\xbaf\xd8!\x88V5\xbb\xd0\x03\x1cz( i" +
+	"\xbd\xf6w\xde\x10\xc2.N\xa9\x1b\xf4O\x0d!\xe9\xd7" +
+	"\xac\\!dn\x9d\xa1E\xab{\xaa\xcf\xea\x0e\x97\x11" +
+	"\x1d\xb3\x98_\xccO\xa8={/\xcfqq8\x8b\x18" +
+	"\x9az\xd3\xcbS\x997$\xff\xb6\xabP\xbb/\x94\xde" +
+	"7s\xfaBz\xc0\xa9C\xbd\xdc\x0fVv%\xea\xe5" +
+	"OY\xc9R\xb4\xd2)E\xc5E\x91`;x\xc4\xd6" +
+	"I5\x93\xd0\xe7<M$\xb3\x8dVS]\x85>\xa7" +
+	"G\x9f\x18\x16:&\xd4\xa2\xcfiW\xe7\xd1rC]" +
+	"\xd0\x88F\x0c\xa6\x94\xf2\x84\xdb\x05\x88Vj\xaa\x04@" +
+	"N\xba\x90\xfe\xe3\xa5_\xd2\x7f.i,\xfd\xe7\x96F" +
+	"\xd2\x7f\x1e\xa9\"\x00 \x86#a]X\xd0q\xbd\x10" +
+	"\xd2z\x84XGD\x08\xc5\x17\x09\xed\x1d\x8bES\x8f" +
+	"\x99Y\x0ac\xe6\x98\xad\xf7$\xfd0\xedXT\xa6\x1f" +
+	"\x8bd@\xb8\xb42y,\xe6\xd3\xb1H\x06\xf3yd" +
+	"\xb0\xab\x12=\x10\xc1L\xf5+P\x089\x96K\x86\xa3" +
+	"\xbaP\xccL\x1b=C\xac\x9a\x9bp\xe6h\x88\x8f\xc7" +
+	"\xce\xca\xa3\xd3\xfb\x88\xbe|\xed\x84F\xcdL$\xb2\x8c" +
+	"\xfe\x95\x0f\xc0\x97\xe8]e\x87\x91\x84\xb2v\xa5\xfcK" +
+	"\x9e\xe9\x0e\x00\xb4Lw\xf3\xd82\xdb\x9d\xe6b\xb2\xea" +
+	".\x03h\x99A\xc0Un\x0e+\xb8\x1f\xacD\x1c\x95" +
+	"\xe7\xb8+\x01Z\x9a\x09\xb9\x96\x10\xfe\x94\x95\x08\xa6\xf2" +
+	"\xd5\x0c\x99M\xc8|B\\\xdf[\x89\xcb\xa0<\x8f!" +
+	"W\x11\xd2N\x88\xfb;+\x11Ve\x8d!\xd7\x12\xd2" +
+	"E\x88\xe7[\xcb\xa5\xa0\x07@\xd6\x192\x9f\x90\x10!" +
+	"\xc2I\xab@I<\xb11\xd9\xda\x09\x89\x12R\xf0\x0d" +
+	"\xadS@\xddo6\xa7\x8b\x10\x93\x90\x01\xffC\xeb\x0c" +
+	"\xa0\xc7\x0e\x86\x84\x08\xe9!\xa4\xf0kZ\xa7\x90^!" +
+	"\x18\x12%\xe4FB\x06\xfe\x8d\xd6\x19\x08 \xf7\xb2u" +
+	"LBn&\xa4\xe8\xafV\xbd\x82E\x00\xf2MLm" +
+	"=\x84,#\xb5y\xbf\xb2\x14\xf4\x02\xc8\xb70\xe0F" +
+	"\x02V\x100\xe8\x84\xa5\xe0 \x00y9\x03n&\xe0" +
+	"N\x02\xc4/-\x05E\x00\xf9v\xf7T\x80\x96e\x04" +
+	"<B\x80\xef/\x96B6\x94W3\xe0~\x02\x9e#" +
+	"@\xfaoKA\x89\x9e\x9a\x18\xb0\x81\x80=\x04\x14\x7f" +
+	"a)XL/\x84\x0cx\x91\x80w\x09\x90?\xb7\x14" +
+	"\x94\x01\xe4\x83\x0c8@\xc0\x97\x04(\x9fY\x0a*\xf4" +
+	"\xca\xeb\xae\x06h\xf9\x94\x00\x97\x87C\xef\xe0\xe3\x96\x82" +
+	"\x83\x01d\xf4\xd0\x8cS\x04\x94\x12Pr\xccR\xb0\x84" +
+	"^\xaa\x19\xe0\xf3\xf0\xd8r\x01\x01\xe7|j)HO" +
+	"(\xa3\x190\x82\x80z\x02J?\xb1\x14,\xa5G)" +
+	"\x0f\xad1\x99\x80\xd9\x04\x94\x7fl)8\x84\\\xccC" +
+	"*\x99A@\x17\x01\x15G-\x05\xcb\xc8\xf2\x9eid" +
+	"y\x02\xee$`\xd8\x7fY\x0a\x0e%]1V\xcb\x08" +
+	"\xb8\x8b\x00\xffG\x96\x82\xe5\xf4\xa4\xc1\x80\x15\x04\xdcC" +
+	"\xc0\xb9G,\x05+\xe8\xd9\xc6\xd3\x0a\xd0r\x17\x01\x1b" +
+	"\x08\x18\xfe\xa1\xa5\xe00z!\xf6\\\x06\xd0\xf2\x18\x01" +
+	"\xcf\x11P\xf9\x81\xa5\xa0\x9f\xb4\xeb\xb9\x06\xa0\xe5i\x02" +
+	"\xb6\x11p\xde\xfb\x96\x82\xe7\xd2O?<\xb3\x00Z\x9e" +
+	"'`\x97\x87\xc3\x8a\x11\x87\xc9\x83\x86\xd3;8\x93w" +
+	"\x1b!{h\xca\xc8\xf7,\x05+\xc9 l\x87/\x12" +
+	"\xf0*\x01\xe7\xbfk)x\x1e\xbd>3`\x17\x01o" +
+	"\x10\xf0\x8b\xff\xb4\x14\x1c\x01 \xbf\xe6!g\xdcC\xc0" +
+	"\x01\x02F\x1d\xb2\x14\x1cI\xaf\xd4L\xde7\x08\xf8\x9c" +
+	"\x80\xd1\x07-\x05\xcf\xa7\xdf\xd60\xe0S\x02\\\x02\x87" +
+	"\xde!\xefX\x0a\xfe\x82L(\x90T\xa7\x08(%\xa0" +
+	"\xecmK\xc1QdB\x06\xf8\x042!\x01C\xdf\xb2" +
+	"\x14\x1cM&d\xc0\x08\x02\xea\x09\x08\xbcia\xdao" +
+	"\x00\xe4\x0b\x85J\xe0\xbcc\x0eX\x0a^@\xbf\xbd\x11" +
+	"h\x13\xe7\xa6\xf8\\\xf0\x1f\x96\x82c\x19\x9f\xa9\xfd\xf8" +
+	"\x8c\xddo)8\x8e\\\x81\x01\x93\x09h$`\xdc>" +
+	"K\xc1\xf1\xf4\x88+\x90n\xeb\x09\x98A\xc0\xf87," +
+	"\x05\xab\xe8\xe9\x91\x89\xd4H@3\x01U\xaf[\x0a\xd2" +
+	"C\xdfL\xc1 \xe7!\xe0*!\x95\xd9\xf8%K\xd0" +
+	"\xe7<\x11\xda\x09\xacvb\xaa%\xd4QSM\xedf" +
+	",\x04\x14\x82\xb5\x13\xed\x82M\x08\xd6T\xdb\xa5\x99\x10" +
+	"\x9cPkWH|p2r\xc0!\x07(\xc4k'" +
+	"\xda\xcd\x12!^Sm\xf7?\x85\xf8\x84Z\x14\x80C" +
+	"\x01\x90\x8fO\xb6+(\xb15\x12\x09\xd9\xe5]z?" +
+	"\x1c\xc5\xd6P\xa4\xd5\xee\x15\xd4u\xd4NL\xeb\xe1\xd9" +
+	"]\xae\x8e\x9a\xea\xb4\xd1\xc2\xe4h\xb0\x1f\xad\xdb\x1e\xed" +
+	"G\xeb\xb2G'\xd4\xa6\x8d\xf2\x89Q\x7fpr\xda " +
+	"\x97$\x8d\xf7c;\xc0\x1e\xed\xc7\xb6\xc0\x1e\xed\xc7V" +
+	"H\xb2\x8d\xa7\xb3\xf5$\x06\xc5%\xfdz\x93\xe9F\xa1" +
+	"\x079B\xd3\x08\xf2\xd1\xf9\x97P\xe5\x9b\x95\xe2\x12\xe3" +
+	"\xf4\x1e\x94\xfaAHF\xae\x84\xfe\xad\xd1\x8c6\xa1C" +
+	"\x06\x90\x81\x12\x13#\xd9(\x05>\x12F\x9f\xf3\x13\x9b" +
+	"$\xccz\x90\xadZ\x0c0G\xe5\xb8TK\x14B\x19" +
+	"\xd78\x11\xf0\xff\xa4\xeeb\xfaX\xa4\xb7\xa5\xbdqf" +
+	"\xbe_%\x88\xb4L\xa2tM\xb1'\xd1H$\x94\xa6" +
+	"\xa3\xe4\x93\xe8\xd2\xe4T{\xd8\x9b\x1c&\x0fN\x1bN" +
+	"\xd6>B\xa7\x11\xcd\xd3\xcc\xaf\xa3a\xe7R\xdb\x1f'" +
+	"W\xd2\xc2\xbd\xcd\xa6\x91j\xc8i\xe1^\xd6\xf2\x064" +
+	"\xb1\xd8\x85\x804\xbaT\x0b\xb3\xdb\x06\x16\xbb8@\x9b" +
+	"\xee\"-\xaa\xb5\x82?\x18\x0a\x9a\xbdX\xec\xe2m$" +
+	"\xb3\xa8\xb6;\x98~vsd\xfd\x01\xe7wJX\xed" +
+	"\xbf8\xa8\x87\xda\xf3\x95\xf0\x1d\x04\xa6\xb5\xacR3\xf3" +
+	"\x94\xf0\x97\xeb1S7&\\\xa4\xf1Y\x0d\xfb\x80\xc3" +
+	"V\x8c\x99F\xde\xcb\xee\x19\x9ed2\x9b\xa4\\f+" +
+	"\x1b\xb5\x9fr\x0d\xafL{,\xca\xd3\x8f\xca~9\x98" +
+	";[\x8f\xd1[\x04f\xee\xed\x9ad\xb3o2\x87V" +
+	"X\x8f\x9935\xd3\x00>\xd8\x93u\xb0\xcf\xf48\x91" +
+	"z\x94A\xed4o\xaai\x02g?<\x85\xdb\xa3\x91" +
+	"`\xd8\x84~\xb7d\xa9\xc9\xaf\x86xT{\xd2t\x10" +
+	"/\x93\xe2\xfe~}>D\xe9\xd1i\xd2\xa3~\xfb\xc9" +
+	"B\xd4\xda\xdb\x0d\xd5\x85\x9cu\xf7\xb9\xe3?xP\xf7" +
+	"}\x07\xaa\x8b\xc3\x86RL<i>h\x85us|" +
+	"X7\x83\x18\x1d\xd7\xd0\xden4Gx\xc3\x04\xb0\x83" +
+	"<\x1f\x8c\xe6\x98\xad$g/I\xcd\x86\xba\xc4t\x00" +
+	";#,5\x83\xddz$n\x9ef\xbaa\x11\xcd\xb8" +
+	"\xc6\xb8\x01~\xcdd\xbf'HXn\xd8\x86\xf3Ny" +
+	"\x00\xfew\x00\xdf?\xa0|"
 
 func init() {
 	schemas.Register(schema_832bcc6686a26d56,
@@ -7247,7 +10887,8 @@ func init() {
 		0xf58782f48a121998,
 		0xf705dc45c94766fd,
 		0xf7ff4414476c186a,
-		0xfca3742893be4cde)
+		0xfca3742893be4cde,
+		0xff376f86fe50cfe6)
 }
 
 var x_832bcc6686a26d56 = []byte{
@@ -1,3 +1,8 @@
 package aircraftlib
 
-//go:generate capnp compile -I ../../std -ogo aircraft.capnp
+// The code generator request is kept in capnpc-go's testdata, whose
+// tests check that this package is up to date with it and run the
+// plain structs that -pogs generates.
+
+//go:generate sh -c "capnp compile -I ../../std -o- aircraft.capnp > ../../capnpc-go/testdata/aircraft.capnp.out"
+//go:generate sh -c "capnpc-go -pogs < ../../capnpc-go/testdata/aircraft.capnp.out"
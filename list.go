@@ -300,7 +300,7 @@ func (p BitList) Set(i int, v bool) {
 	}
 	bit := BitOffset(i)
 	addr := p.off.addOffset(bit.offset())
-	b := p.seg.slice(addr, 1)
+	b := p.seg.writable(addr, 1)
 	if v {
 		b[0] |= bit.mask()
 	} else {
@@ -560,7 +560,7 @@ func NewText(s *Segment, v string) (UInt8List, error) {
 	if err != nil {
 		return UInt8List{}, err
 	}
-	copy(l.seg.slice(l.off, Size(len(v))), v)
+	copy(l.seg.writable(l.off, Size(len(v))), v)
 	return l, nil
 }
 
@@ -571,7 +571,7 @@ func NewTextFromBytes(s *Segment, v []byte) (UInt8List, error) {
 	if err != nil {
 		return UInt8List{}, err
 	}
-	copy(l.seg.slice(l.off, Size(len(v))), v)
+	copy(l.seg.writable(l.off, Size(len(v))), v)
 	return l, nil
 }
 
@@ -582,7 +582,7 @@ func NewData(s *Segment, v []byte) (UInt8List, error) {
 	if err != nil {
 		return UInt8List{}, err
 	}
-	copy(l.seg.slice(l.off, Size(len(v))), v)
+	copy(l.seg.writable(l.off, Size(len(v))), v)
 	return l, nil
 }
 
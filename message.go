@@ -270,7 +270,13 @@ func (m *Message) segment(id SegmentID) (*Segment, error) {
 	if err != nil {
 		return nil, errorf("load segment %d: %v", id, err)
 	}
+	_, ro := m.Arena.(roMultiSegment)
+	if ro {
+		// Keep allocations out of the segment's spare capacity.
+		data = data[:len(data):len(data)]
+	}
 	s := m.setSegment(id, data)
+	s.readOnly = ro
 	return s, nil
 }
 
@@ -292,6 +298,7 @@ func (m *Message) setSegment(id SegmentID, data []byte) *Segment {
 		}
 	} else if seg := m.segs[id]; seg != nil {
 		seg.data = data
+		seg.readOnly = false
 		return seg
 	}
 	seg := &Segment{
@@ -476,6 +483,38 @@ func (ss roSingleSegment) String() string {
 	return fmt.Sprintf("read-only single-segment arena [len=%d]", len(ss))
 }
 
+// ReadOnlyMultiSegment returns an arena whose segments are the slices
+// in b, which must not be written to, such as slices of a read-only
+// memory mapping.  Allocate always fails, and a Message using the arena
+// copies a segment to the heap the first time it is written to, so
+// setters change the Message's copy rather than b.
+func ReadOnlyMultiSegment(b [][]byte) Arena {
+	return roMultiSegment(b)
+}
+
+// roMultiSegment is a read-only arena of segments that are already
+// loaded.
+type roMultiSegment [][]byte
+
+func (ms roMultiSegment) NumSegments() int64 {
+	return int64(len(ms))
+}
+
+func (ms roMultiSegment) Data(id SegmentID) ([]byte, error) {
+	if int64(id) >= int64(len(ms)) {
+		return nil, errorf("segment %d requested (arena only has %d segments)", id, len(ms))
+	}
+	return ms[id], nil
+}
+
+func (ms roMultiSegment) Allocate(sz Size, segs map[SegmentID]*Segment) (SegmentID, []byte, error) {
+	return 0, nil, errorf("arena is read-only")
+}
+
+func (ms roMultiSegment) String() string {
+	return fmt.Sprintf("read-only multi-segment arena [%d segments]", len(ms))
+}
+
 // MultiSegment is an arena that stores object data across multiple []byte
 // buffers, allocating new buffers of exponentially-increasing size when
 // full. This avoids the potentially-expensive slice copying of SingleSegment.
@@ -529,6 +568,16 @@ var multiSegmentPool = sync.Pool{
 // demuxArena slices b into a multi-segment arena.  It assumes that
 // len(data) >= hdr.totalSize().
 func demuxArena(hdr streamHeader, data []byte) (Arena, error) {
+	segs, err := demuxSegments(hdr, data)
+	if err != nil {
+		return nil, err
+	}
+	return MultiSegment(segs), nil
+}
+
+// demuxSegments slices b into the segments described by hdr.  It
+// assumes that len(data) >= hdr.totalSize().
+func demuxSegments(hdr streamHeader, data []byte) ([][]byte, error) {
 	maxSeg := hdr.maxSegment()
 	if int64(maxSeg) > int64(maxInt-1) {
 		return nil, errorf("number of segments overflows int")
@@ -541,7 +590,7 @@ func demuxArena(hdr streamHeader, data []byte) (Arena, error) {
 		}
 		segs[i], data = data[:sz:sz], data[sz:]
 	}
-	return MultiSegment(segs), nil
+	return segs, nil
 }
 
 func (msa *MultiSegmentArena) NumSegments() int64 {
@@ -785,6 +834,16 @@ func (d *Decoder) ReleaseMessage(m *Message) {
 // copying is performed, so the objects in the returned message read
 // directly from data.
 func Unmarshal(data []byte) (*Message, error) {
+	segs, err := unmarshalSegments(data)
+	if err != nil {
+		return nil, err
+	}
+	return &Message{Arena: MultiSegment(segs)}, nil
+}
+
+// unmarshalSegments slices data, a message in the standard stream
+// encoding, into its segments.
+func unmarshalSegments(data []byte) ([][]byte, error) {
 	if len(data) == 0 {
 		return nil, io.EOF
 	}
@@ -803,11 +862,11 @@ func Unmarshal(data []byte) (*Message, error) {
 	} else if total > uint64(len(data)) {
 		return nil, errorf("unmarshal: short data section")
 	}
-	arena, err := demuxArena(hdr, data)
+	segs, err := demuxSegments(hdr, data)
 	if err != nil {
 		return nil, annotatef(err, "unmarshal")
 	}
-	return &Message{Arena: arena}, nil
+	return segs, nil
 }
 
 // UnmarshalPacked reads a packed serialized stream into a message.
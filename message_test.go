@@ -323,6 +323,30 @@ func TestMultiSegment(t *testing.T) {
 	}
 }
 
+func TestReadOnlyMultiSegment(t *testing.T) {
+	t.Parallel()
+
+	_, seg := NewSingleSegmentMessage(nil)
+	root, err := NewRootStruct(seg, ObjectSize{DataSize: 8, PointerCount: 1})
+	require.NoError(t, err)
+	root.SetUint64(0, 42)
+	orig := append([]byte(nil), seg.Data()...)
+
+	data := append([]byte(nil), orig...)
+	ro := &Message{Arena: ReadOnlyMultiSegment([][]byte{data})}
+	p, err := ro.Root()
+	require.NoError(t, err)
+	st := p.Struct()
+	assert.Equal(t, uint64(42), st.Uint64(0))
+
+	st.SetUint64(0, 7)
+	assert.Equal(t, uint64(7), st.Uint64(0), "value after write")
+	assert.Equal(t, orig, data, "arena data changed by write")
+
+	_, err = NewText(st.Segment(), "hello")
+	assert.Error(t, err, "allocated in read-only arena")
+}
+
 func TestMultiSegmentAllocate(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,30 @@
+package capnp
+
+// A MappedMessage is a message read from a memory-mapped file by Mmap.
+// Its segments are slices of the mapping, so opening it reads no more
+// than the segment table, and the operating system pages in the parts
+// of the file that are visited.  The usual traversal and depth limits
+// apply to reads.  The file is mapped read-only, and the first write
+// to a segment copies it to the heap, so the message's fields can be
+// set without changing the file.  New objects cannot be allocated in
+// the message.
+type MappedMessage struct {
+	*Message
+
+	data []byte // the mapping
+}
+
+// Close unmaps the file.  The message, and any data read from it
+// without copying such as the []byte of a Data field, must not be used
+// afterwards.
+func (m *MappedMessage) Close() error {
+	if m.data == nil {
+		return nil
+	}
+	err := munmap(m.data)
+	m.data = nil
+	if err != nil {
+		return errorf("munmap: %v", err)
+	}
+	return nil
+}
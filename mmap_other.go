@@ -0,0 +1,16 @@
+//go:build !(linux || darwin || freebsd)
+
+package capnp
+
+import "runtime"
+
+// Mmap memory-maps the file at path, which must start with a message
+// in the standard stream encoding as written by Encoder, and returns
+// the message.  It is not supported on this platform.
+func Mmap(path string) (*MappedMessage, error) {
+	return nil, errorf("mmap %s: not supported on %s", path, runtime.GOOS)
+}
+
+func munmap(data []byte) error {
+	return nil
+}
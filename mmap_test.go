@@ -0,0 +1,89 @@
+//go:build linux || darwin || freebsd
+
+package capnp
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMmap(t *testing.T) {
+	t.Parallel()
+
+	texts := []string{strings.Repeat("a", 4096), strings.Repeat("b", 4096)}
+	msg, seg, err := NewMessage(MultiSegment(nil))
+	require.NoError(t, err)
+	root, err := NewRootStruct(seg, ObjectSize{PointerCount: 2})
+	require.NoError(t, err)
+	for i, s := range texts {
+		text, err := NewText(seg, s)
+		require.NoError(t, err)
+		require.NoError(t, root.SetPtr(uint16(i), text.ToPtr()))
+	}
+	path := filepath.Join(t.TempDir(), "msg.bin")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	require.NoError(t, NewEncoder(f).Encode(msg))
+	require.NoError(t, f.Close())
+
+	mm, err := Mmap(path)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), mm.NumSegments())
+	p, err := mm.Root()
+	require.NoError(t, err)
+	for i, s := range texts {
+		pi, err := p.Struct().Ptr(uint16(i))
+		require.NoError(t, err)
+		assert.Equal(t, s, pi.Text())
+	}
+
+	// Reads still count against the traversal limit.
+	mm.ResetReadLimit(64)
+	_, err = p.Struct().Ptr(0)
+	if assert.Error(t, err, "read past traversal limit") {
+		assert.Contains(t, err.Error(), "traversal limit")
+	}
+
+	// Writes go to the message's copy, not the file.
+	mm.ResetReadLimit(1 << 20)
+	before, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NoError(t, p.Struct().SetPtr(0, Ptr{}))
+	pi, err := p.Struct().Ptr(0)
+	require.NoError(t, err)
+	assert.False(t, pi.IsValid(), "pointer cleared")
+	_, err = NewText(p.Struct().Segment(), "c")
+	assert.Error(t, err, "allocation in read-only message")
+	after, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, before, after, "file changed by writing to message")
+
+	require.NoError(t, mm.Close())
+	require.NoError(t, mm.Close(), "second Close")
+}
+
+func TestMmapInvalid(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	for name, data := range map[string][]byte{
+		"empty":     nil,
+		"short":     {0, 0, 0, 0},
+		"truncated": {0, 0, 0, 0, 2, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+		"segments":  {0xff, 0xff, 0, 0, 0, 0, 0, 0},
+	} {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, data, 0o600))
+		if mm, err := Mmap(path); err == nil {
+			mm.Close()
+			t.Errorf("Mmap(%s) succeeded", name)
+		}
+	}
+	_, err := Mmap(filepath.Join(dir, "missing"))
+	assert.Error(t, err, "missing file")
+}
@@ -0,0 +1,51 @@
+//go:build linux || darwin || freebsd
+
+package capnp
+
+import (
+	"os"
+	"syscall"
+)
+
+// Mmap memory-maps the file at path, which must start with a message
+// in the standard stream encoding as written by Encoder, and returns
+// the message.  The file is mapped read-only, and must not be modified
+// or truncated while the message is in use.  The caller must call
+// Close when done with the message.
+func Mmap(path string) (*MappedMessage, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errorf("mmap: %v", err)
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, errorf("mmap: %v", err)
+	}
+	size := fi.Size()
+	if size < int64(wordSize) {
+		return nil, errorf("mmap %s: short header section", path)
+	}
+	if size > int64(maxInt) {
+		return nil, errorf("mmap %s: file too large", path)
+	}
+	data, err := syscall.Mmap(int(f.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, errorf("mmap %s: %v", path, err)
+	}
+	segs, err := unmarshalSegments(data)
+	if err != nil {
+		syscall.Munmap(data)
+		return nil, annotatef(err, "mmap %s", path)
+	}
+	// The mapping is read-only, so the message copies a segment to the
+	// heap the first time it is written to.
+	return &MappedMessage{
+		Message: &Message{Arena: ReadOnlyMultiSegment(segs)},
+		data:    data,
+	}, nil
+}
+
+func munmap(data []byte) error {
+	return syscall.Munmap(data)
+}
@@ -0,0 +1,210 @@
+// Package persistent implements SturdyRefs: references to capabilities
+// that outlive a single connection.
+//
+// A Host saves capabilities that have been wrapped with Host.Wrap: the
+// Persistent.save method stores application data describing the object
+// in a Store under a new random token, and returns the token as the
+// SturdyRef.  Restoring the SturdyRef, possibly on a later connection
+// or after the process restarts, looks the data up and recreates the
+// object with the Host's Factory.
+//
+// SturdyRefs are restored over the wire with the object ID of a
+// Bootstrap message: the host passes RestoreFunc(host) as
+// rpc.Options.Restore, and clients call Restore.
+package persistent // import "capnproto.org/go/capnp/v3/persistent"
+
+import (
+	"context"
+	"crypto/rand"
+
+	"capnproto.org/go/capnp/v3"
+	"capnproto.org/go/capnp/v3/exc"
+	"capnproto.org/go/capnp/v3/rpc"
+	"capnproto.org/go/capnp/v3/server"
+	persistentcp "capnproto.org/go/capnp/v3/std/capnp/persistent"
+)
+
+var persistenterr = exc.Annotator("persistent")
+
+// ErrNotFound is returned by a Store and a Restorer when a SturdyRef
+// does not refer to a saved capability.
+var ErrNotFound = exc.New(exc.Failed, "persistent", "unknown SturdyRef")
+
+// A Restorer restores capabilities from SturdyRefs.
+type Restorer interface {
+	// Restore returns a client for the capability that ref refers to.
+	// The caller is responsible for releasing the returned client.
+	Restore(ctx context.Context, ref []byte) (capnp.Client, error)
+}
+
+// A Factory recreates an object from the data it was wrapped with.
+// The caller is responsible for releasing the returned client.
+type Factory func(ctx context.Context, data []byte) (capnp.Client, error)
+
+// A Host saves and restores capabilities, keeping track of their
+// SturdyRefs in a Store.  It implements Restorer.
+type Host struct {
+	store   Store
+	factory Factory
+}
+
+// NewHost returns a Host that saves SturdyRefs in store and recreates
+// objects with f.
+func NewHost(store Store, f Factory) *Host {
+	return &Host{store: store, factory: f}
+}
+
+// Wrap returns a client for srv that also implements Persistent.save.
+// Saving the client records data in the Host's store; data should
+// contain everything that the Host's Factory needs to recreate the
+// object.  Calls to any other method are delivered to srv.  Wrap
+// "steals" srv: it will be shut down along with the returned client.
+func (h *Host) Wrap(srv *server.Server, data []byte) capnp.Client {
+	return capnp.NewClient(&saver{
+		srv:  srv,
+		save: persistentcp.Persistent_NewServer(saveServer{h: h, data: data}),
+	})
+}
+
+// Restore implements Restorer.  It returns ErrNotFound if ref was not
+// saved by the Host, or has been dropped.
+func (h *Host) Restore(ctx context.Context, ref []byte) (capnp.Client, error) {
+	data, err := h.store.Get(ctx, ref)
+	if err != nil {
+		return capnp.Client{}, err
+	}
+	return h.factory(ctx, data)
+}
+
+// Drop revokes a SturdyRef: it removes it from the store, so that it
+// can no longer be restored.  Live clients for the object are not
+// affected.
+func (h *Host) Drop(ctx context.Context, ref []byte) error {
+	return h.store.Delete(ctx, ref)
+}
+
+// save stores data under a new token, and returns the token.
+func (h *Host) save(ctx context.Context, data []byte) ([]byte, error) {
+	token := make([]byte, tokenSize)
+	if _, err := rand.Read(token); err != nil {
+		return nil, persistenterr.Failedf("generate token: %w", err)
+	}
+	if err := h.store.Put(ctx, token, data); err != nil {
+		return nil, persistenterr.Annotate(err, "save")
+	}
+	return token, nil
+}
+
+// tokenSize is the size in bytes of the tokens generated by a Host.
+const tokenSize = 16
+
+// saveServer implements Persistent.save for a client wrapped by a Host.
+type saveServer struct {
+	h    *Host
+	data []byte
+}
+
+func (s saveServer) Save(ctx context.Context, call persistentcp.Persistent_save) error {
+	if call.Args().HasSealFor() {
+		return persistenterr.Unimplementedf("sealed SturdyRefs are not supported")
+	}
+	token, err := s.h.save(ctx, s.data)
+	if err != nil {
+		return err
+	}
+	res, err := call.AllocResults()
+	if err != nil {
+		return err
+	}
+	ref, err := capnp.NewData(res.Segment(), token)
+	if err != nil {
+		return err
+	}
+	return res.SetSturdyRef(ref.ToPtr())
+}
+
+// saver is the hook of a client returned by Host.Wrap.  It delivers
+// calls to Persistent.save to save, and all other calls to srv.
+type saver struct {
+	srv  *server.Server
+	save *server.Server
+}
+
+func (s *saver) hook(m capnp.Method) *server.Server {
+	if m.InterfaceID == persistentcp.Persistent_TypeID && m.MethodID == 0 {
+		return s.save
+	}
+	return s.srv
+}
+
+func (s *saver) Send(ctx context.Context, call capnp.Send) (*capnp.Answer, capnp.ReleaseFunc) {
+	return s.hook(call.Method).Send(ctx, call)
+}
+
+func (s *saver) Recv(ctx context.Context, call capnp.Recv) capnp.PipelineCaller {
+	return s.hook(call.Method).Recv(ctx, call)
+}
+
+// Brand returns the brand of the wrapped server, so that
+// server.IsServer reports the wrapped server's brand argument.
+func (s *saver) Brand() capnp.Brand {
+	return s.srv.Brand()
+}
+
+func (s *saver) Shutdown() {
+	s.save.Shutdown()
+	s.srv.Shutdown()
+}
+
+// Save saves c, which must implement Persistent.save, and returns its
+// SturdyRef.  The SturdyRef must be a Data pointer, as it is for
+// clients wrapped by a Host.
+func Save(ctx context.Context, c capnp.Client) ([]byte, error) {
+	f, release := persistentcp.Persistent(c).Save(ctx, nil)
+	defer release()
+	res, err := f.Struct()
+	if err != nil {
+		return nil, persistenterr.Annotate(err, "save")
+	}
+	p, err := res.SturdyRef()
+	if err != nil {
+		return nil, persistenterr.Failedf("save: read SturdyRef: %w", err)
+	}
+	if !p.IsValid() {
+		return nil, persistenterr.Failedf("save: null SturdyRef")
+	}
+	ref := p.Data()
+	if ref == nil {
+		return nil, persistenterr.Failedf("save: SturdyRef is not Data")
+	}
+	// The results are released when Save returns.
+	return append([]byte(nil), ref...), nil
+}
+
+// Restore restores the capability that ref refers to from the remote
+// vat of conn, which must have been created with RestoreFunc as its
+// rpc.Options.Restore.  The caller is responsible for releasing the
+// returned client.
+func Restore(ctx context.Context, conn *rpc.Conn, ref []byte) capnp.Client {
+	_, seg, err := capnp.NewMessage(capnp.SingleSegment(nil))
+	if err != nil {
+		return capnp.ErrorClient(persistenterr.Failedf("restore: %w", err))
+	}
+	id, err := capnp.NewData(seg, ref)
+	if err != nil {
+		return capnp.ErrorClient(persistenterr.Failedf("restore: %w", err))
+	}
+	return conn.Restore(ctx, id.ToPtr())
+}
+
+// RestoreFunc returns a function for rpc.Options.Restore that restores
+// the SturdyRefs sent by Restore using r.
+func RestoreFunc(r Restorer) func(context.Context, capnp.Ptr) (capnp.Client, error) {
+	return func(ctx context.Context, objectID capnp.Ptr) (capnp.Client, error) {
+		ref := objectID.Data()
+		if ref == nil {
+			return capnp.Client{}, persistenterr.Failedf("restore: object ID is not Data")
+		}
+		return r.Restore(ctx, ref)
+	}
+}
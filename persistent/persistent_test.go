@@ -0,0 +1,214 @@
+package persistent_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"capnproto.org/go/capnp/v3"
+	air "capnproto.org/go/capnp/v3/internal/aircraftlib"
+	"capnproto.org/go/capnp/v3/persistent"
+	"capnproto.org/go/capnp/v3/rpc"
+	"capnproto.org/go/capnp/v3/rpc/transport"
+	persistentcp "capnproto.org/go/capnp/v3/std/capnp/persistent"
+)
+
+func TestMemoryStore(t *testing.T) {
+	t.Parallel()
+	testStore(t, persistent.NewMemoryStore())
+}
+
+func TestFileStore(t *testing.T) {
+	t.Parallel()
+	s, err := persistent.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal("NewFileStore:", err)
+	}
+	testStore(t, s)
+}
+
+func testStore(t *testing.T, s persistent.Store) {
+	ctx := context.Background()
+	token := []byte{0xde, 0xad, 0xbe, 0xef}
+
+	if _, err := s.Get(ctx, token); !errors.Is(err, persistent.ErrNotFound) {
+		t.Errorf("Get before Put: %v; want ErrNotFound", err)
+	}
+	if err := s.Put(ctx, token, []byte("hello")); err != nil {
+		t.Fatal("Put:", err)
+	}
+	if err := s.Put(ctx, token, []byte("world")); err != nil {
+		t.Fatal("Put:", err)
+	}
+	data, err := s.Get(ctx, token)
+	if err != nil {
+		t.Fatal("Get:", err)
+	}
+	if !bytes.Equal(data, []byte("world")) {
+		t.Errorf("Get = %q; want \"world\"", data)
+	}
+	if err := s.Delete(ctx, token); err != nil {
+		t.Fatal("Delete:", err)
+	}
+	if _, err := s.Get(ctx, token); !errors.Is(err, persistent.ErrNotFound) {
+		t.Errorf("Get after Delete: %v; want ErrNotFound", err)
+	}
+	if err := s.Delete(ctx, token); err != nil {
+		t.Error("Delete of missing token:", err)
+	}
+}
+
+func TestSaveRestore(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	host := persistent.NewHost(persistent.NewMemoryStore(), newCounter)
+	boot := host.Wrap(air.CallSequence_NewServer(&counter{n: 100}), counterData(100))
+
+	// Save the bootstrap capability, then drop the connection.
+	conn := dialHost(t, host, boot)
+	b := conn.Bootstrap(ctx)
+	ref, err := persistent.Save(ctx, b)
+	b.Release()
+	if err != nil {
+		t.Fatal("Save:", err)
+	}
+	if err := conn.Close(); err != nil {
+		t.Error("conn.Close():", err)
+	}
+
+	// Restore it on a new connection.
+	conn = dialHost(t, host, capnp.Client{})
+	defer conn.Close()
+	seq := air.CallSequence(persistent.Restore(ctx, conn, ref))
+	defer seq.Release()
+	checkNumber(ctx, t, seq, 100)
+	checkNumber(ctx, t, seq, 101)
+
+	// Restoring again yields a new object.
+	seq2 := air.CallSequence(persistent.Restore(ctx, conn, ref))
+	defer seq2.Release()
+	checkNumber(ctx, t, seq2, 100)
+
+	// Once dropped, the ref can no longer be restored.
+	if err := host.Drop(ctx, ref); err != nil {
+		t.Fatal("Drop:", err)
+	}
+	seq3 := air.CallSequence(persistent.Restore(ctx, conn, ref))
+	defer seq3.Release()
+	f, release := seq3.GetNumber(ctx, nil)
+	defer release()
+	if _, err := f.Struct(); err == nil {
+		t.Error("call on dropped SturdyRef succeeded")
+	}
+}
+
+func TestRestoreAfterRestart(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	dir := t.TempDir()
+	store, err := persistent.NewFileStore(dir)
+	if err != nil {
+		t.Fatal("NewFileStore:", err)
+	}
+	host := persistent.NewHost(store, newCounter)
+	obj := host.Wrap(air.CallSequence_NewServer(&counter{n: 7}), counterData(7))
+	ref, err := persistent.Save(ctx, obj)
+	obj.Release()
+	if err != nil {
+		t.Fatal("Save:", err)
+	}
+
+	// A new host on the same directory restores the ref.
+	store, err = persistent.NewFileStore(dir)
+	if err != nil {
+		t.Fatal("NewFileStore:", err)
+	}
+	host = persistent.NewHost(store, newCounter)
+	conn := dialHost(t, host, capnp.Client{})
+	defer conn.Close()
+	seq := air.CallSequence(persistent.Restore(ctx, conn, ref))
+	defer seq.Release()
+	checkNumber(ctx, t, seq, 7)
+}
+
+func TestSaveSealed(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	host := persistent.NewHost(persistent.NewMemoryStore(), newCounter)
+	obj := host.Wrap(air.CallSequence_NewServer(new(counter)), counterData(0))
+	defer obj.Release()
+
+	f, release := persistentcp.Persistent(obj).Save(ctx, func(p persistentcp.Persistent_SaveParams) error {
+		owner, err := capnp.NewText(p.Segment(), "alice")
+		if err != nil {
+			return err
+		}
+		return p.SetSealFor(owner.ToPtr())
+	})
+	defer release()
+	if _, err := f.Struct(); err == nil {
+		t.Error("save sealed for an owner succeeded")
+	}
+}
+
+// dialHost connects to a new Conn that restores SturdyRefs with host,
+// and returns the connection to it.
+func dialHost(t *testing.T, host *persistent.Host, boot capnp.Client) *rpc.Conn {
+	p1, p2 := transport.NewPipe(1)
+	hostConn := rpc.NewConn(rpc.NewTransport(p1), &rpc.Options{
+		BootstrapClient: boot,
+		Restore:         persistent.RestoreFunc(host),
+	})
+	t.Cleanup(func() {
+		hostConn.Close()
+	})
+	return rpc.NewConn(rpc.NewTransport(p2), nil)
+}
+
+func checkNumber(ctx context.Context, t *testing.T, seq air.CallSequence, want uint32) {
+	t.Helper()
+	f, release := seq.GetNumber(ctx, nil)
+	defer release()
+	res, err := f.Struct()
+	if err != nil {
+		t.Fatal("getNumber:", err)
+	}
+	if res.N() != want {
+		t.Errorf("getNumber() = %d; want %d", res.N(), want)
+	}
+}
+
+// counter is a CallSequence that counts up from n.  It is recreated from
+// the starting number.
+type counter struct {
+	n uint32
+}
+
+func (c *counter) GetNumber(ctx context.Context, call air.CallSequence_getNumber) error {
+	res, err := call.AllocResults()
+	if err != nil {
+		return err
+	}
+	res.SetN(c.n)
+	c.n++
+	return nil
+}
+
+func counterData(n uint32) []byte {
+	data := make([]byte, 4)
+	binary.LittleEndian.PutUint32(data, n)
+	return data
+}
+
+func newCounter(ctx context.Context, data []byte) (capnp.Client, error) {
+	if len(data) != 4 {
+		return capnp.Client{}, errors.New("bad counter data")
+	}
+	n := binary.LittleEndian.Uint32(data)
+	return capnp.NewClient(air.CallSequence_NewServer(&counter{n: n})), nil
+}
@@ -0,0 +1,154 @@
+package persistent
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+)
+
+// A Store records the data of saved capabilities, keyed by the tokens
+// used as their SturdyRefs.  Its methods may be called concurrently.
+type Store interface {
+	// Put records data under token.
+	Put(ctx context.Context, token, data []byte) error
+
+	// Get returns the data recorded under token, or ErrNotFound.
+	Get(ctx context.Context, token []byte) ([]byte, error)
+
+	// Delete removes token from the store.  Deleting a token that is
+	// not in the store is not an error.
+	Delete(ctx context.Context, token []byte) error
+}
+
+// A MemoryStore is a Store that keeps tokens in memory.  The zero value
+// is an empty store.
+type MemoryStore struct {
+	mu     sync.Mutex
+	tokens map[string][]byte
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return new(MemoryStore)
+}
+
+// Put implements Store.
+func (s *MemoryStore) Put(ctx context.Context, token, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.tokens == nil {
+		s.tokens = make(map[string][]byte)
+	}
+	s.tokens[string(token)] = append([]byte(nil), data...)
+	return nil
+}
+
+// Get implements Store.
+func (s *MemoryStore) Get(ctx context.Context, token []byte) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.tokens[string(token)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return append([]byte(nil), data...), nil
+}
+
+// Delete implements Store.
+func (s *MemoryStore) Delete(ctx context.Context, token []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.tokens, string(token))
+	return nil
+}
+
+// A FileStore is a Store that keeps each token in a file in a
+// directory, so that SturdyRefs survive process restarts.
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore returns a FileStore that keeps tokens in dir, creating
+// the directory if it does not exist.
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, persistenterr.Failedf("file store: %w", err)
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+func (s *FileStore) path(token []byte) string {
+	return filepath.Join(s.dir, hex.EncodeToString(token))
+}
+
+// Put implements Store.  The file is replaced atomically, so that a
+// concurrent Get never observes partially written data, and Put
+// returns once the file and its directory entry are on stable storage,
+// so that a token given out after Put returns survives a crash.
+func (s *FileStore) Put(ctx context.Context, token, data []byte) error {
+	f, err := os.CreateTemp(s.dir, ".tmp-")
+	if err != nil {
+		return persistenterr.Failedf("file store: %w", err)
+	}
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), s.path(token))
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return persistenterr.Failedf("file store: %w", err)
+	}
+	if err := syncDir(s.dir); err != nil {
+		return persistenterr.Failedf("file store: %w", err)
+	}
+	return nil
+}
+
+// syncDir flushes the entries of the directory dir to stable storage.
+// Windows cannot sync a directory, so it does nothing there.
+func syncDir(dir string) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	err = d.Sync()
+	if cerr := d.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// Get implements Store.
+func (s *FileStore) Get(ctx context.Context, token []byte) ([]byte, error) {
+	data, err := os.ReadFile(s.path(token))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, persistenterr.Failedf("file store: %w", err)
+	}
+	return data, nil
+}
+
+// Delete implements Store.
+func (s *FileStore) Delete(ctx context.Context, token []byte) error {
+	err := os.Remove(s.path(token))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return persistenterr.Failedf("file store: %w", err)
+	}
+	return nil
+}
@@ -67,6 +67,60 @@ func BenchmarkInsert(b *testing.B) {
 	}
 }
 
+// BenchmarkExtractGenerated is BenchmarkExtract with the FromCapnp method
+// that capnpc-go -pogs generates instead of reflection.
+func BenchmarkExtractGenerated(b *testing.B) {
+	r := rand.New(rand.NewSource(12345))
+	data := make([][]byte, 1000)
+	for i := range data {
+		a := generateBenchmarkA(r)
+		msg, seg, _ := capnp.NewMessage(capnp.SingleSegment(nil))
+		root, _ := air.NewRootBenchmarkA(seg)
+		a.Insert(root)
+		data[i], _ = msg.Marshal()
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		msg, _ := capnp.Unmarshal(data[r.Intn(len(data))])
+		root, _ := air.ReadRootBenchmarkA(msg)
+		var a air.BenchmarkAPogs
+		a.FromCapnp(root)
+	}
+}
+
+// BenchmarkInsertGenerated is BenchmarkInsert with the Insert method
+// that capnpc-go -pogs generates instead of reflection.
+func BenchmarkInsertGenerated(b *testing.B) {
+	r := rand.New(rand.NewSource(12345))
+	data := make([]*air.BenchmarkAPogs, 1000)
+	for i := range data {
+		data[i] = generateBenchmarkA(r)
+	}
+	arena := make([]byte, 0, 512)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		a := data[r.Intn(len(data))]
+		msg, seg, _ := capnp.NewMessage(capnp.SingleSegment(arena[:0]))
+		root, _ := air.NewRootBenchmarkA(seg)
+		a.Insert(root)
+		msg.Marshal()
+	}
+}
+
+func generateBenchmarkA(r *rand.Rand) *air.BenchmarkAPogs {
+	a := generateA(r)
+	return &air.BenchmarkAPogs{
+		Name:     a.Name,
+		BirthDay: a.BirthDay,
+		Phone:    a.Phone,
+		Siblings: a.Siblings,
+		Spouse:   a.Spouse,
+		Money:    a.Money,
+	}
+}
+
 func randString(r *rand.Rand, n int) string {
 	b := make([]byte, (n+1)/2)
 	// Go 1.6 adds a Rand.Read method, but since we want to be compatible with Go 1.4...
@@ -64,6 +64,13 @@ Note that the unsized int and uint type can't be used: int and float
 types must match in size.  For Data and Text fields using []byte, the
 filled-in byte slice will point to original segment.
 
+Fields with a $Go.customtype annotation may also use the annotated Go
+type, as generated code does.  Bool and numeric fields only need a type
+of the right kind, such as time.Duration for an Int64.  Text fields
+need a type that implements encoding.TextMarshaler and
+encoding.TextUnmarshaler, and Data fields need a type that implements
+encoding.BinaryMarshaler and encoding.BinaryUnmarshaler.
+
 Renaming and Omitting Fields
 
 By default, the Go field name is the same as the Cap'n Proto schema
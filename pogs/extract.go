@@ -1,6 +1,7 @@
 package pogs
 
 import (
+	"encoding"
 	"errors"
 	"fmt"
 	"math"
@@ -30,6 +31,11 @@ var (
 	ptrType    = reflect.TypeOf(capnp.Ptr{})
 	structType = reflect.TypeOf(capnp.Struct{})
 	listType   = reflect.TypeOf(capnp.List{})
+
+	textMarshalerType     = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType   = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	binaryMarshalerType   = reflect.TypeOf((*encoding.BinaryMarshaler)(nil)).Elem()
+	binaryUnmarshalerType = reflect.TypeOf((*encoding.BinaryUnmarshaler)(nil)).Elem()
 )
 
 func (e *extracter) extractStruct(val reflect.Value, typeID uint64, s capnp.Struct) error {
@@ -122,10 +128,18 @@ func (e *extracter) extractField(val reflect.Value, s capnp.Struct, f schema.Fie
 		name, _ := f.NameBytes()
 		return fmt.Errorf("extract field %s: default value is a %v, want %v", name, dv.Which(), typ.Which())
 	}
-	if !isTypeMatch(val.Type(), typ) {
+	custom := hasCustomType(f) && isCustomTypeMatch(val.Type(), typ)
+	if !custom && !isTypeMatch(val.Type(), typ) {
 		name, _ := f.NameBytes()
 		return fmt.Errorf("can't extract field %s of type %v into a Go %v", name, typ.Which(), val.Type())
 	}
+	if custom {
+		if err := extractCustomType(val, s, f, typ, dv); err != nil {
+			name, _ := f.NameBytes()
+			return fmt.Errorf("extract field %s: %v", name, err)
+		}
+		return nil
+	}
 	switch typ.Which() {
 	case schema.Type_Which_bool:
 		v := s.Bit(capnp.BitOffset(f.Slot().Offset()))
@@ -262,6 +276,40 @@ func (e *extracter) extractField(val reflect.Value, s capnp.Struct, f schema.Fie
 	return nil
 }
 
+// extractCustomType copies a Text or Data field into a Go value whose
+// type was matched by isCustomTypeMatch.  Like the generated accessors,
+// it leaves val as the zero value if the field is null and has no
+// default.
+func extractCustomType(val reflect.Value, s capnp.Struct, f schema.Field, typ schema.Type, dv schema.Value) error {
+	p, err := s.Ptr(uint16(f.Slot().Offset()))
+	if err != nil {
+		return err
+	}
+	var b []byte
+	if typ.Which() == schema.Type_Which_text {
+		if b = p.TextBytes(); !p.IsValid() {
+			b, _ = dv.TextBytes()
+		}
+	} else {
+		if b = p.Data(); !p.IsValid() {
+			b, _ = dv.Data()
+		}
+	}
+	v := reflect.New(val.Type())
+	if p.IsValid() || len(b) > 0 {
+		if typ.Which() == schema.Type_Which_text {
+			err = v.Interface().(encoding.TextUnmarshaler).UnmarshalText(b)
+		} else {
+			err = v.Interface().(encoding.BinaryUnmarshaler).UnmarshalBinary(b)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	val.Set(v.Elem())
+	return nil
+}
+
 func (e *extracter) extractList(val reflect.Value, typ schema.Type, l capnp.List) error {
 	vt := val.Type()
 	elem, err := typ.List().ElementType()
@@ -454,3 +502,21 @@ func isTypeMatch(r reflect.Type, s schema.Type) bool {
 	k, ok := typeMap[s.Which()]
 	return ok && k == r.Kind()
 }
+
+// isCustomTypeMatch reports whether r can hold a field of type s that
+// has a $Go.customtype annotation.  Numeric and Bool fields are matched
+// by kind, as usual.  Text fields need a type that implements
+// encoding.TextMarshaler and encoding.TextUnmarshaler, and Data fields
+// need encoding.BinaryMarshaler and encoding.BinaryUnmarshaler, the same
+// interfaces that capnpc-go uses.  Pointer receivers are allowed.
+func isCustomTypeMatch(r reflect.Type, s schema.Type) bool {
+	pr := reflect.PtrTo(r)
+	switch s.Which() {
+	case schema.Type_Which_text:
+		return pr.Implements(textMarshalerType) && pr.Implements(textUnmarshalerType)
+	case schema.Type_Which_data:
+		return pr.Implements(binaryMarshalerType) && pr.Implements(binaryUnmarshalerType)
+	default:
+		return false
+	}
+}
@@ -316,6 +316,20 @@ func hasDiscriminant(n schema.Node) bool {
 	return n.Which() == schema.Node_Which_structNode && n.StructNode().DiscriminantCount() > 0
 }
 
+// customTypeAnnotation is the ID of the $Go.customtype annotation.
+const customTypeAnnotation = 0xfa10659ae02f2093
+
+// hasCustomType reports whether f has a $Go.customtype annotation.
+func hasCustomType(f schema.Field) bool {
+	anns, _ := f.Annotations()
+	for i := 0; i < anns.Len(); i++ {
+		if anns.At(i).Id() == customTypeAnnotation {
+			return true
+		}
+	}
+	return false
+}
+
 func shortDisplayName(n schema.Node) []byte {
 	dn, _ := n.DisplayNameBytes()
 	return dn[n.DisplayNamePrefixLength():]
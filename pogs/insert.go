@@ -1,6 +1,7 @@
 package pogs
 
 import (
+	"encoding"
 	"errors"
 	"fmt"
 	"math"
@@ -103,7 +104,8 @@ func (ins *inserter) insertField(s capnp.Struct, f schema.Field, val reflect.Val
 		name, _ := f.NameBytes()
 		return fmt.Errorf("insert field %s: default value is a %v, want %v", name, dv.Which(), typ.Which())
 	}
-	if !isTypeMatch(val.Type(), typ) {
+	custom := hasCustomType(f) && isCustomTypeMatch(val.Type(), typ)
+	if !custom && !isTypeMatch(val.Type(), typ) {
 		name, _ := f.NameBytes()
 		return fmt.Errorf("can't insert field %s of type Go %v into a %v", name, val.Type(), typ.Which())
 	}
@@ -111,6 +113,13 @@ func (ins *inserter) insertField(s capnp.Struct, f schema.Field, val reflect.Val
 		name, _ := f.NameBytes()
 		return fmt.Errorf("can't insert field %s: allocated struct is too small", name)
 	}
+	if custom {
+		if err := insertCustomType(s, f, typ, dv, val); err != nil {
+			name, _ := f.NameBytes()
+			return fmt.Errorf("insert field %s: %v", name, err)
+		}
+		return nil
+	}
 	switch typ.Which() {
 	case schema.Type_Which_bool:
 		v := val.Bool()
@@ -250,6 +259,33 @@ func (ins *inserter) insertField(s capnp.Struct, f schema.Field, val reflect.Val
 	return nil
 }
 
+// insertCustomType copies a Go value whose type was matched by
+// isCustomTypeMatch into a Text or Data field.
+func insertCustomType(s capnp.Struct, f schema.Field, typ schema.Type, dv schema.Value, val reflect.Value) error {
+	// Copy val so that marshal methods with pointer receivers can be
+	// called on unaddressable values.
+	v := reflect.New(val.Type())
+	v.Elem().Set(val)
+	var b []byte
+	var err error
+	if typ.Which() == schema.Type_Which_text {
+		b, err = v.Interface().(encoding.TextMarshaler).MarshalText()
+	} else {
+		b, err = v.Interface().(encoding.BinaryMarshaler).MarshalBinary()
+	}
+	if err != nil {
+		return err
+	}
+	if b == nil && !isEmptyValue(dv) {
+		b = []byte{}
+	}
+	off := uint16(f.Slot().Offset())
+	if typ.Which() == schema.Type_Which_text {
+		return s.SetTextFromBytes(off, b)
+	}
+	return s.SetData(off, b)
+}
+
 func capPtr(seg *capnp.Segment, val reflect.Value) capnp.Ptr {
 	client := val.Convert(clientType).Interface().(capnp.Client)
 	if !client.IsValid() {
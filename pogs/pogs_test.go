@@ -4,8 +4,10 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"net/netip"
 	"strings"
 	"testing"
+	"time"
 
 	"capnproto.org/go/capnp/v3"
 	air "capnproto.org/go/capnp/v3/internal/aircraftlib"
@@ -977,6 +979,98 @@ func TestExtraFields(t *testing.T) {
 	}
 }
 
+// Endpoint uses the Go types named by the $Go.customtype annotations on
+// air.Endpoint.
+type Endpoint struct {
+	Addr    netip.AddrPort
+	IP      netip.Addr `capnp:"ip"`
+	Timeout time.Duration
+}
+
+func TestExtract_CustomType(t *testing.T) {
+	_, seg, err := capnp.NewMessage(capnp.SingleSegment(nil))
+	if err != nil {
+		t.Fatalf("NewMessage: %v", err)
+	}
+	e, err := air.NewRootEndpoint(seg)
+	if err != nil {
+		t.Fatalf("NewRootEndpoint: %v", err)
+	}
+	out := new(Endpoint)
+	if err := Extract(out, air.Endpoint_TypeID, capnp.Struct(e)); err != nil {
+		t.Fatalf("Extract(%v) error: %v", e, err)
+	}
+	if want := (Endpoint{Timeout: 30 * time.Second}); *out != want {
+		t.Errorf("Extract(%v) = %+v; want %+v", e, *out, want)
+	}
+
+	want := Endpoint{
+		Addr:    netip.MustParseAddrPort("192.0.2.1:8080"),
+		IP:      netip.MustParseAddr("2001:db8::1"),
+		Timeout: 5 * time.Millisecond,
+	}
+	if err := e.SetAddr(want.Addr); err != nil {
+		t.Fatalf("SetAddr: %v", err)
+	}
+	if err := e.SetIp(want.IP); err != nil {
+		t.Fatalf("SetIp: %v", err)
+	}
+	e.SetTimeout(want.Timeout)
+	if err := Extract(out, air.Endpoint_TypeID, capnp.Struct(e)); err != nil {
+		t.Fatalf("Extract(%v) error: %v", e, err)
+	}
+	if *out != want {
+		t.Errorf("Extract(%v) = %+v; want %+v", e, *out, want)
+	}
+
+	if err := capnp.Struct(e).SetText(0, "not an address"); err != nil {
+		t.Fatalf("SetText: %v", err)
+	}
+	if err := Extract(out, air.Endpoint_TypeID, capnp.Struct(e)); err == nil {
+		t.Errorf("Extract(%v) did not return error", e)
+	} else if s := err.Error(); !strings.Contains(s, "addr") {
+		t.Errorf("Extract(%v): %v; want error about addr", e, err)
+	}
+}
+
+func TestInsert_CustomType(t *testing.T) {
+	_, seg, err := capnp.NewMessage(capnp.SingleSegment(nil))
+	if err != nil {
+		t.Fatalf("NewMessage: %v", err)
+	}
+	e, err := air.NewRootEndpoint(seg)
+	if err != nil {
+		t.Fatalf("NewRootEndpoint: %v", err)
+	}
+	in := &Endpoint{
+		Addr:    netip.MustParseAddrPort("[2001:db8::1]:443"),
+		IP:      netip.MustParseAddr("192.0.2.1"),
+		Timeout: time.Minute,
+	}
+	if err := Insert(air.Endpoint_TypeID, capnp.Struct(e), in); err != nil {
+		t.Fatalf("Insert(%+v) error: %v", *in, err)
+	}
+	if addr, err := e.AddrBytes(); err != nil || string(addr) != "[2001:db8::1]:443" {
+		t.Errorf("addr = %q, %v; want \"[2001:db8::1]:443\", <nil>", addr, err)
+	}
+	if ip, err := e.Ip(); err != nil || ip != in.IP {
+		t.Errorf("ip = %v, %v; want %v, <nil>", ip, err, in.IP)
+	}
+	if e.Timeout() != time.Minute {
+		t.Errorf("timeout = %v; want %v", e.Timeout(), time.Minute)
+	}
+
+	// A Go type that doesn't implement the marshaling interfaces can't
+	// be used for an annotated Text field.
+	bad := &struct {
+		Addr    []int
+		Timeout time.Duration
+	}{}
+	if err := Insert(air.Endpoint_TypeID, capnp.Struct(e), bad); err == nil {
+		t.Error("Insert of []int into a $Go.customtype Text field did not return error")
+	}
+}
+
 func zequal(g *Z, c air.Z) (bool, error) {
 	if g.Which != c.Which() {
 		return false, nil
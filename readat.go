@@ -0,0 +1,121 @@
+package capnp
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// A ReaderAtArena is a read-only Arena that reads the segments of a
+// message in the standard stream encoding from an io.ReaderAt.  It
+// reads only the segment table up front, and each segment when the
+// Message first accesses it, so a message much larger than memory can
+// be read as long as the parts of it that are visited are not.  Each
+// segment is read once per Message.
+//
+// Reading a large message usually needs a larger TraverseLimit than the
+// Message's default.
+type ReaderAtArena struct {
+	r   io.ReaderAt
+	off int64 // offset of the message in r
+
+	// offs holds the offset in r of each segment, followed by the end
+	// of the message.
+	offs []int64
+}
+
+// ReaderAtOptions configures NewReaderAtArena.
+type ReaderAtOptions struct {
+	// MaxSegmentSize limits the size in bytes of each segment, since a
+	// segment is read into memory whole.  A segment table that claims a
+	// larger segment is rejected.  If zero, 64 MiB is used.
+	MaxSegmentSize uint64
+}
+
+// NewReaderAtArena reads the segment table of the message that starts
+// at offset off in r.  The returned arena is used by creating a Message
+// with it:
+//
+//	arena, err := capnp.NewReaderAtArena(f, 0, nil)
+//	...
+//	msg := &capnp.Message{Arena: arena}
+//
+// r must not change while the arena is in use.  opts may be nil.
+func NewReaderAtArena(r io.ReaderAt, off int64, opts *ReaderAtOptions) (*ReaderAtArena, error) {
+	maxSize := uint64(defaultDecodeLimit)
+	if opts != nil && opts.MaxSegmentSize > 0 {
+		maxSize = opts.MaxSegmentSize
+	}
+	var first [wordSize]byte
+	if err := readFullAt(r, first[:], off); err != nil {
+		return nil, errorf("read header: %v", err)
+	}
+	maxSeg := SegmentID(binary.LittleEndian.Uint32(first[:]))
+	if maxSeg > maxStreamSegments {
+		return nil, errorf("read header: too many segments")
+	}
+	b := make([]byte, streamHeaderSize(maxSeg))
+	copy(b, first[:])
+	if len(b) > len(first) {
+		if err := readFullAt(r, b[len(first):], off+int64(len(first))); err != nil {
+			return nil, errorf("read header: %v", err)
+		}
+	}
+	hdr := streamHeader{b}
+
+	offs := make([]int64, int(maxSeg)+2)
+	offs[0] = off + int64(len(b))
+	for i := SegmentID(0); i <= maxSeg; i++ {
+		sz, err := hdr.segmentSize(i)
+		if err != nil {
+			return nil, annotatef(err, "read header")
+		}
+		if uint64(sz) > maxSize || int64(sz) > int64(maxInt) {
+			return nil, errorf("read header: segment %d too large", i)
+		}
+		offs[i+1] = offs[i] + int64(sz)
+	}
+	return &ReaderAtArena{r: r, off: off, offs: offs}, nil
+}
+
+// Size returns the size in bytes of the encoded message, including its
+// segment table.  A message that follows it in r starts this many bytes
+// after the offset passed to NewReaderAtArena.
+func (a *ReaderAtArena) Size() int64 {
+	return a.offs[len(a.offs)-1] - a.off
+}
+
+func (a *ReaderAtArena) NumSegments() int64 {
+	return int64(len(a.offs) - 1)
+}
+
+func (a *ReaderAtArena) Data(id SegmentID) ([]byte, error) {
+	if int64(id) >= a.NumSegments() {
+		return nil, errorf("segment %d requested (arena only has %d segments)", id, a.NumSegments())
+	}
+	buf := make([]byte, a.offs[id+1]-a.offs[id])
+	if err := readFullAt(a.r, buf, a.offs[id]); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+func (a *ReaderAtArena) Allocate(sz Size, segs map[SegmentID]*Segment) (SegmentID, []byte, error) {
+	return 0, nil, errorf("arena is read-only")
+}
+
+func (a *ReaderAtArena) String() string {
+	return fmt.Sprintf("read-at arena [%d segments]", a.NumSegments())
+}
+
+// readFullAt reads len(b) bytes from r at off.
+func readFullAt(r io.ReaderAt, b []byte, off int64) error {
+	n, err := r.ReadAt(b, off)
+	if n == len(b) {
+		return nil
+	}
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,128 @@
+package capnp
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReaderAtArena(t *testing.T) {
+	t.Parallel()
+
+	// Two large texts, which land in segments of their own.
+	texts := []string{strings.Repeat("a", 4096), strings.Repeat("b", 4096)}
+	msg, seg, err := NewMessage(MultiSegment(nil))
+	require.NoError(t, err)
+	root, err := NewRootStruct(seg, ObjectSize{PointerCount: 2})
+	require.NoError(t, err)
+	for i, s := range texts {
+		text, err := NewText(seg, s)
+		require.NoError(t, err)
+		require.NoError(t, root.SetPtr(uint16(i), text.ToPtr()))
+	}
+	require.Equal(t, int64(3), msg.NumSegments())
+	data, err := msg.Marshal()
+	require.NoError(t, err)
+
+	// Put another message after it, to check Size.
+	const prefix = 16
+	file := append(make([]byte, prefix), data...)
+	file = append(file, data...)
+	r := &recordingReaderAt{r: bytes.NewReader(file)}
+
+	arena, err := NewReaderAtArena(r, prefix, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(data)), arena.Size())
+	assert.Equal(t, int64(3), arena.NumSegments())
+	header := r.n
+	assert.LessOrEqual(t, header, 16, "read more than the segment table")
+
+	lazy := &Message{Arena: arena}
+	p, err := lazy.Root()
+	require.NoError(t, err)
+	p0, err := p.Struct().Ptr(0)
+	require.NoError(t, err)
+	assert.Equal(t, texts[0], p0.Text())
+	seg2, err := msg.Segment(2)
+	require.NoError(t, err)
+	assert.Less(t, r.n-header, len(data)-len(seg2.Data()), "read the segment of the text that was not accessed")
+
+	p1, err := p.Struct().Ptr(1)
+	require.NoError(t, err)
+	assert.Equal(t, texts[1], p1.Text())
+
+	// The next message.
+	arena2, err := NewReaderAtArena(r, prefix+arena.Size(), nil)
+	require.NoError(t, err)
+	p, err = (&Message{Arena: arena2}).Root()
+	require.NoError(t, err)
+	p1, err = p.Struct().Ptr(1)
+	require.NoError(t, err)
+	assert.Equal(t, texts[1], p1.Text())
+
+	_, _, err = arena.Allocate(8, nil)
+	assert.Error(t, err, "Allocate on read-only arena")
+}
+
+func TestReaderAtArenaTruncated(t *testing.T) {
+	t.Parallel()
+
+	msg, seg, err := NewMessage(SingleSegment(nil))
+	require.NoError(t, err)
+	_, err = NewRootStruct(seg, ObjectSize{DataSize: 64})
+	require.NoError(t, err)
+	data, err := msg.Marshal()
+	require.NoError(t, err)
+
+	_, err = NewReaderAtArena(bytes.NewReader(data[:4]), 0, nil)
+	assert.Error(t, err, "header cut short")
+
+	arena, err := NewReaderAtArena(bytes.NewReader(data[:len(data)-8]), 0, nil)
+	require.NoError(t, err)
+	_, err = (&Message{Arena: arena}).Root()
+	if assert.Error(t, err, "segment cut short") {
+		assert.Contains(t, err.Error(), io.ErrUnexpectedEOF.Error())
+	}
+}
+
+func TestReaderAtArenaMaxSegmentSize(t *testing.T) {
+	t.Parallel()
+
+	// A segment table claiming a 3.9 GiB segment, with nothing after it.
+	huge := []byte{0, 0, 0, 0, 0, 0, 0, 0x1f}
+	_, err := NewReaderAtArena(bytes.NewReader(huge), 0, nil)
+	if assert.Error(t, err, "oversized segment with default limit") {
+		assert.Contains(t, err.Error(), "too large")
+	}
+
+	msg, seg, err := NewMessage(SingleSegment(nil))
+	require.NoError(t, err)
+	_, err = NewRootStruct(seg, ObjectSize{DataSize: 64})
+	require.NoError(t, err)
+	data, err := msg.Marshal()
+	require.NoError(t, err)
+	_, err = NewReaderAtArena(bytes.NewReader(data), 0, &ReaderAtOptions{MaxSegmentSize: 64})
+	assert.Error(t, err, "segment larger than MaxSegmentSize")
+	_, err = NewReaderAtArena(bytes.NewReader(data), 0, &ReaderAtOptions{MaxSegmentSize: 72})
+	assert.NoError(t, err, "segment equal to MaxSegmentSize")
+}
+
+// recordingReaderAt counts the bytes read from an io.ReaderAt.
+type recordingReaderAt struct {
+	r  io.ReaderAt
+	mu sync.Mutex
+	n  int
+}
+
+func (r *recordingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	n, err := r.r.ReadAt(p, off)
+	r.mu.Lock()
+	r.n += n
+	r.mu.Unlock()
+	return n, err
+}
@@ -21,9 +21,24 @@ type answer struct {
 	c  *Conn
 	id answerID
 
-	// cancel cancels the Context used in the received method call.
-	// May be nil.
-	cancel context.CancelFunc
+	// cancel cancels the Context used in the received method call,
+	// with the cause reported by context.Cause.  May be nil.
+	cancel context.CancelCauseFunc
+
+	// trace reports the return of the received call to the Conn's
+	// observer.  May be nil.
+	trace *callTrace
+
+	// provision is set for the answer to a Provide message.  See
+	// handoff.go.
+	provision *provision
+
+	// keepResults is set for the answer to a call with
+	// sendResultsTo.yourself: the results are kept in a local message
+	// for a Return with takeFromOtherQuestion to take, rather than
+	// being sent.  It is not modified after the answer is created.
+	// See tailcall.go.
+	keepResults bool
 
 	// ret is the outgoing Return struct.  ret is valid iff there was no
 	// error creating the message.  If ret is invalid, then this answer
@@ -44,6 +59,10 @@ type answer struct {
 
 	// All fields below are protected by s.c.mu.
 
+	// tail is set if the answer returned with takeFromOtherQuestion,
+	// to the question whose results are the answer's results.
+	tail *question
+
 	// flags is a bitmask of events that have occurred in an answer's
 	// lifetime.
 	flags answerFlags
@@ -77,6 +96,10 @@ const (
 	finishReceived
 	resultsReady
 	releaseResultCapsFlag
+
+	// resultsTaken is set when a Return with takeFromOtherQuestion
+	// takes the results of a keepResults answer.
+	resultsTaken
 )
 
 // flags.Contains(flag) Returns true iff flags contains flag, which must
@@ -116,7 +139,8 @@ func (c *Conn) newReturn() (_ rpccp.Return, sendMsg func(), _ *rc.Releaser, _ er
 	releaser := rc.NewReleaser(2, outMsg.Release)
 
 	return ret, func() {
-		c.lk.sendTx.Send(asyncSend{
+		c.queueSend(asyncSend{
+			msg:     outMsg.Message,
 			send:    outMsg.Send,
 			release: releaser.Decr,
 			onSent: func(err error) {
@@ -144,7 +168,11 @@ func (ans *answer) setPipelineCaller(m capnp.Method, pcall capnp.PipelineCaller)
 // AllocResults allocates the results struct.
 func (ans *answer) AllocResults(sz capnp.ObjectSize) (capnp.Struct, error) {
 	var err error
-	ans.results, err = ans.ret.NewResults()
+	if ans.keepResults {
+		ans.results, err = newLocalPayload()
+	} else {
+		ans.results, err = ans.ret.NewResults()
+	}
 	if err != nil {
 		return capnp.Struct{}, rpcerr.Failedf("alloc results: %w", err)
 	}
@@ -222,9 +250,23 @@ func (ans *answer) Return(e error) {
 func (ans *answer) sendReturn(rl *releaseList) error {
 	ans.pcall = nil
 	ans.flags |= resultsReady
+	ans.trace.done(nil)
 
 	var err error
-	ans.exportRefs, err = ans.c.fillPayloadCapTable(ans.results)
+	if ans.keepResults {
+		// The capabilities in the results stay local.
+		ans.ret.SetResultsSentElsewhere()
+	} else {
+		ans.exportRefs, err = ans.c.fillPayloadCapTable(ans.results)
+	}
+	if exc.IsType(err, exc.Overloaded) {
+		// An export quota was exceeded: send the error instead of the
+		// results, and drop the exports that were already made.
+		_ = ans.c.releaseExportRefs(rl, ans.exportRefs)
+		ans.exportRefs = nil
+		ans.sendException(rl, err)
+		return nil
+	}
 	if err != nil {
 		// We're not going to send the message after all, so don't forget to release it.
 		ans.msgReleaser.Decr()
@@ -256,6 +298,7 @@ func (ans *answer) sendReturn(rl *releaseList) error {
 		}
 	}
 	ans.flags |= returnSent
+	ans.c.signalIdle()
 	if !ans.flags.Contains(finishReceived) {
 		return nil
 	}
@@ -270,6 +313,7 @@ func (ans *answer) sendException(rl *releaseList, ex error) {
 	ans.err = ex
 	ans.pcall = nil
 	ans.flags |= resultsReady
+	ans.trace.done(ex)
 
 	if ans.promise != nil {
 		ans.promise.Reject(ex)
@@ -292,6 +336,7 @@ func (ans *answer) sendException(rl *releaseList, ex error) {
 		}
 	}
 	ans.flags |= returnSent
+	ans.c.signalIdle()
 	if ans.flags.Contains(finishReceived) {
 		// destroy will never return an error because sendException does
 		// create any exports.
@@ -307,6 +352,8 @@ func (ans *answer) sendException(rl *releaseList, ex error) {
 func (ans *answer) destroy(rl *releaseList) error {
 	rl.Add(ans.msgReleaser.Decr)
 	delete(ans.c.lk.answers, ans.id)
+	ans.c.signalIdle()
+	ans.releaseKeptResults(rl)
 	if !ans.flags.Contains(releaseResultCapsFlag) || len(ans.exportRefs) == 0 {
 		return nil
 
@@ -0,0 +1,94 @@
+package rpc
+
+import (
+	"context"
+
+	"capnproto.org/go/capnp/v3/internal/syncutil"
+)
+
+// errDraining answers the calls and bootstraps received while a Conn
+// is draining.
+var errDraining = rpcerr.Disconnected(ErrDraining)
+
+// Drain gracefully shuts down the connection.  It stops accepting new
+// incoming calls and bootstraps, answering them with a disconnected
+// exception wrapping ErrDraining.  Once every call received before then
+// has returned and every outgoing call has received its return, Drain
+// closes the connection as Close does.
+//
+// If ctx is done first, Drain closes the connection right away,
+// canceling the remaining calls, and returns an error wrapping the
+// Context's error.  New outgoing calls may be made while draining, but
+// Drain waits for them too.
+func (c *Conn) Drain(ctx context.Context) error {
+	var (
+		closing bool
+		idle    chan struct{}
+	)
+	syncutil.With(&c.lk, func() {
+		c.lk.draining = true
+		closing = c.lk.closing
+		if c.lk.idle == nil {
+			c.lk.idle = make(chan struct{})
+		}
+		idle = c.lk.idle
+		c.signalIdle()
+	})
+	if closing {
+		<-c.closed
+		return ExcClosed
+	}
+
+	select {
+	case <-idle:
+		// Shutting down also returns every answer, so the connection
+		// may have gone idle because it closed.
+		syncutil.With(&c.lk, func() {
+			closing = c.lk.closing
+		})
+		if closing {
+			<-c.closed
+			return rpcerr.Disconnectedf("connection closed while draining")
+		}
+		return c.Close()
+	case <-c.closed:
+		return rpcerr.Disconnectedf("connection closed while draining")
+	case <-ctx.Done():
+		c.Close()
+		return rpcerr.Failedf("drain: %w", ctx.Err())
+	}
+}
+
+// signalIdle closes c.lk.idle if Drain is waiting for the connection
+// to become idle and it has.  It is called wherever an answer returns
+// or a question leaves the table.  The caller MUST be holding onto
+// c.lk.
+func (c *Conn) signalIdle() {
+	if c.lk.idle == nil || !c.idle() {
+		return
+	}
+	select {
+	case <-c.lk.idle:
+	default:
+		close(c.lk.idle)
+	}
+}
+
+// idle reports whether every answer has been returned and every
+// question has left the table.  The caller MUST be holding onto c.lk.
+func (c *Conn) idle() bool {
+	for _, ans := range c.lk.answers {
+		if !ans.flags.Contains(returnSent) {
+			return false
+		}
+	}
+	for _, q := range c.lk.questions {
+		// A tail call's question stays in the table after its return
+		// until the remote vat has taken its results and finished the
+		// call that took them, so draining waits for that too.
+		if q != nil {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,193 @@
+package rpc_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"capnproto.org/go/capnp/v3"
+	"capnproto.org/go/capnp/v3/rpc"
+	testcp "capnproto.org/go/capnp/v3/rpc/internal/testcapnp"
+)
+
+// TestDrain checks that Drain rejects new calls, lets a call in flight
+// return, and then closes the connection.
+func TestDrain(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	srv := newBlockingPingPong()
+	server, client, pp := newDrainTestConns(t, srv)
+	defer pp.Release()
+
+	blocked, release := pp.EchoNum(ctx, func(p testcp.PingPong_echoNum_Params) error {
+		p.SetN(blockingN)
+		return nil
+	})
+	defer release()
+	<-srv.started
+
+	drained := make(chan error, 1)
+	go func() {
+		drained <- server.Drain(ctx)
+	}()
+
+	// Calls are answered until Drain starts, and then rejected.
+	for {
+		ans, release := pp.EchoNum(ctx, func(p testcp.PingPong_echoNum_Params) error {
+			p.SetN(1)
+			return nil
+		})
+		_, err := ans.Struct()
+		release()
+		if err == nil {
+			continue
+		}
+		if !capnp.IsDisconnected(err) || !strings.Contains(err.Error(), rpc.ErrDraining.Error()) {
+			t.Fatalf("call while draining: %v; want disconnected, %q", err, rpc.ErrDraining)
+		}
+		break
+	}
+	select {
+	case err := <-drained:
+		t.Fatal("Drain returned before call in flight:", err)
+	default:
+	}
+
+	close(srv.unblock)
+	res, err := blocked.Struct()
+	if err != nil {
+		t.Fatal("call in flight:", err)
+	}
+	if res.N() != blockingN {
+		t.Errorf("call in flight returned %d; want %d", res.N(), blockingN)
+	}
+	if err := <-drained; err != nil {
+		t.Error("Drain:", err)
+	}
+	<-client.Done()
+}
+
+// TestDrainDeadline checks that Drain closes the connection when its
+// Context expires before the calls in flight return.
+func TestDrainDeadline(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	srv := newBlockingPingPong()
+	server, client, pp := newDrainTestConns(t, srv)
+	defer pp.Release()
+
+	blocked, release := pp.EchoNum(ctx, func(p testcp.PingPong_echoNum_Params) error {
+		p.SetN(blockingN)
+		return nil
+	})
+	defer release()
+	<-srv.started
+
+	drainCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if err := server.Drain(drainCtx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Drain = %v; want %v", err, context.DeadlineExceeded)
+	}
+	<-server.Done()
+	<-client.Done()
+	if _, err := blocked.Struct(); err == nil {
+		t.Error("call in flight succeeded after connection closed")
+	}
+}
+
+// TestDrainRemoteClose checks that Drain reports that the connection
+// closed when the remote vat closes it before the calls in flight
+// return.
+func TestDrainRemoteClose(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	srv := newBlockingPingPong()
+	server, client, pp := newDrainTestConns(t, srv)
+	defer pp.Release()
+
+	blocked, release := pp.EchoNum(ctx, func(p testcp.PingPong_echoNum_Params) error {
+		p.SetN(blockingN)
+		return nil
+	})
+	defer release()
+	<-srv.started
+
+	drained := make(chan error, 1)
+	go func() {
+		drained <- server.Drain(ctx)
+	}()
+	for {
+		ans, release := pp.EchoNum(ctx, func(p testcp.PingPong_echoNum_Params) error {
+			p.SetN(1)
+			return nil
+		})
+		_, err := ans.Struct()
+		release()
+		if err != nil {
+			break
+		}
+	}
+	client.Close()
+
+	if err := <-drained; !capnp.IsDisconnected(err) {
+		t.Errorf("Drain = %v; want disconnected", err)
+	}
+	if _, err := blocked.Struct(); err == nil {
+		t.Error("call in flight succeeded after connection closed")
+	}
+}
+
+// newDrainTestConns connects a client to a server serving impl, and
+// returns both Conns and the client's bootstrap capability.
+func newDrainTestConns(t *testing.T, impl testcp.PingPong_Server) (server, client *rpc.Conn, pp testcp.PingPong) {
+	server, client = newTestConns(t, &rpc.Options{
+		BootstrapClient: capnp.Client(testcp.PingPong_ServerToClient(impl)),
+		ErrorReporter:   testErrorReporter{tb: t},
+	}, &rpc.Options{
+		ErrorReporter: testErrorReporter{tb: t},
+	})
+	return server, client, testcp.PingPong(client.Bootstrap(context.Background()))
+}
+
+// blockingN is the number that blockingPingPong blocks on.
+const blockingN = 42
+
+// blockingPingPong echoes numbers, except that it blocks a call to
+// echo blockingN until unblock is closed or the call is canceled.
+type blockingPingPong struct {
+	started chan struct{}
+	unblock chan struct{}
+}
+
+func newBlockingPingPong() blockingPingPong {
+	return blockingPingPong{
+		started: make(chan struct{}),
+		unblock: make(chan struct{}),
+	}
+}
+
+func (p blockingPingPong) EchoNum(ctx context.Context, call testcp.PingPong_echoNum) error {
+	n := call.Args().N()
+	if n == blockingN {
+		call.Go()
+		close(p.started)
+		select {
+		case <-p.unblock:
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		}
+	}
+	out, err := call.AllocResults()
+	if err != nil {
+		return err
+	}
+	out.SetN(n)
+	return nil
+}
@@ -14,6 +14,20 @@ var (
 	ErrNotACapability    = errors.New("not a capability")
 	ErrCapTablePopulated = errors.New("capability table already populated")
 
+	// Causes reported by context.Cause when the Context of a received
+	// method call is canceled.  A call canceled because the connection
+	// was closed reports ExcClosed.
+	ErrCallFinished = errors.New("call finished by remote vat")
+	ErrRemoteAbort  = errors.New("remote vat aborted connection")
+
+	// ErrQuotaExceeded is wrapped by the overloaded exceptions reported
+	// when a limit set in Options is exceeded.
+	ErrQuotaExceeded = errors.New("quota exceeded")
+
+	// ErrDraining is wrapped by the disconnected exceptions that answer
+	// calls and bootstraps received while a Conn is draining.
+	ErrDraining = errors.New("connection draining")
+
 	// RPC exceptions
 	ExcClosed = rpcerr.Disconnected(ErrConnClosed)
 )
@@ -2,8 +2,10 @@ package rpc
 
 import (
 	"context"
+	"fmt"
 
 	"capnproto.org/go/capnp/v3"
+	"capnproto.org/go/capnp/v3/exc"
 	"capnproto.org/go/capnp/v3/internal/syncutil"
 	rpccp "capnproto.org/go/capnp/v3/std/capnp/rpc"
 )
@@ -15,6 +17,15 @@ type exportID uint32
 type expent struct {
 	client   capnp.Client
 	wireRefs uint32
+
+	// isPromise is true if the client was unresolved when it was
+	// exported.  A Resolve message will be sent once it resolves.
+	isPromise bool
+
+	// provides lists the Provide messages sent for handoffs of the
+	// client, for which this export is the vine.  They are canceled
+	// once the vine is released.  See handoff.go.
+	provides []*provide
 }
 
 // A key for use in a client's Metadata, whose value is the export
@@ -40,6 +51,23 @@ func (c *Conn) clearExportID(m *capnp.Metadata) {
 	m.Delete(exportIDKey{c})
 }
 
+// unexport removes the export ID from the client's metadata, if the
+// metadata still refers to the given export.  The metadata of an
+// exported promise is replaced by that of its resolution, which may
+// have been exported under a different ID.
+func (c *Conn) unexport(client capnp.Client, id exportID) {
+	metadata := client.State().Metadata
+	if metadata == nil {
+		// Resolved to null.
+		return
+	}
+	syncutil.With(metadata, func() {
+		if id2, ok := c.findExportID(metadata); ok && id2 == id {
+			c.clearExportID(metadata)
+		}
+	})
+}
+
 // findExport returns the export entry with the given ID or nil if
 // couldn't be found. The caller must be holding c.mu
 func (c *Conn) findExport(id exportID) *expent {
@@ -65,10 +93,9 @@ func (c *Conn) releaseExport(id exportID, count uint32) (capnp.Client, error) {
 		client := ent.client
 		c.lk.exports[id] = nil
 		c.lk.exportID.remove(uint32(id))
-		metadata := client.State().Metadata
-		syncutil.With(metadata, func() {
-			c.clearExportID(metadata)
-		})
+		c.lk.numExports--
+		c.unexport(client, id)
+		ent.cancelProvides()
 		return client, nil
 	case count > ent.wireRefs:
 		return capnp.Client{}, rpcerr.Failedf("export ID %d released too many references", id)
@@ -118,6 +145,16 @@ func (c *Conn) sendCap(d rpccp.CapDescriptor, client capnp.Client) (_ exportID,
 		}
 	}
 
+	if ic, ok := bv.(*importClient); ok && !state.IsPromise && c.isIntroducer(ic.c) {
+		info, err := c.network.Introduce(ic.c, c)
+		if err == nil {
+			id, err := c.sendThirdPartyCap(d, client, ic, info)
+			return id, err == nil, err
+		}
+		// Fall back to proxying the capability.
+		c.er.ReportError(rpcerr.Annotate(err, "introduce"))
+	}
+
 	if pc, ok := bv.(capnp.PipelineClient); ok {
 		q, ok := c.getAnswerQuestion(pc.Answer())
 		if ok && q.c == c {
@@ -138,8 +175,6 @@ func (c *Conn) sendCap(d rpccp.CapDescriptor, client capnp.Client) (_ exportID,
 		}
 	}
 
-	// TODO(someday): Check for unresolved client for senderPromise.
-
 	// Default to sender-hosted (export).
 	state.Metadata.Lock()
 	defer state.Metadata.Unlock()
@@ -147,29 +182,109 @@ func (c *Conn) sendCap(d rpccp.CapDescriptor, client capnp.Client) (_ exportID,
 	if ok {
 		ent := c.lk.exports[id]
 		ent.wireRefs++
-		d.SetSenderHosted(uint32(id))
+		if ent.isPromise {
+			d.SetSenderPromise(uint32(id))
+		} else {
+			d.SetSenderHosted(uint32(id))
+		}
+		c.sendFd(d, client)
 		return id, true, nil
 	}
 
 	// Not already present; allocate an export id for it:
+	if err := c.checkExportQuota(); err != nil {
+		return 0, false, err
+	}
 	ee := &expent{
-		client:   client.AddRef(),
-		wireRefs: 1,
+		client:    client.AddRef(),
+		wireRefs:  1,
+		isPromise: state.IsPromise,
 	}
-	id = exportID(c.lk.exportID.next())
+	id = c.addExport(ee)
+	c.setExportID(state.Metadata, id)
+	if !ee.isPromise {
+		d.SetSenderHosted(uint32(id))
+		c.sendFd(d, client)
+		return id, true, nil
+	}
+	d.SetSenderPromise(uint32(id))
+	if c.startTask() {
+		go func() {
+			defer c.tasks.Done()
+			c.resolveExport(id, ee)
+		}()
+	}
+	return id, true, nil
+}
+
+// addExport adds ee to the exports table, returning its ID.  The
+// caller must be holding onto c.mu, and should have checked the export
+// quota with checkExportQuota.
+func (c *Conn) addExport(ee *expent) exportID {
+	id := exportID(c.lk.exportID.next())
 	if int64(id) == int64(len(c.lk.exports)) {
 		c.lk.exports = append(c.lk.exports, ee)
 	} else {
 		c.lk.exports[id] = ee
 	}
-	c.setExportID(state.Metadata, id)
-	d.SetSenderHosted(uint32(id))
-	return id, true, nil
+	c.lk.numExports++
+	return id
+}
+
+// resolveExport waits for an exported promise to resolve and then sends
+// a Resolve message to the remote vat.  If the export is released
+// before the promise resolves, then no Resolve message is sent.
+//
+// The caller MUST NOT hold c.lk.
+func (c *Conn) resolveExport(id exportID, ee *expent) {
+	if err := ee.client.Resolve(c.bgctx); err != nil {
+		// Connection is shutting down.
+		return
+	}
+
+	c.lk.Lock()
+	defer c.lk.Unlock()
+
+	if c.findExport(id) != ee {
+		// Released before the promise resolved.
+		return
+	}
+	ee.isPromise = false
+	// The client's metadata still refers to the promise's export.  Give
+	// the resolution an export of its own, so that the remote vat does
+	// not mistake it for the promise.
+	c.unexport(ee.client, id)
+	c.sendMessage(c.bgctx, func(m rpccp.Message) error {
+		res, err := m.NewResolve()
+		if err != nil {
+			return err
+		}
+		res.SetPromiseId(uint32(id))
+		if e, ok := ee.client.State().Brand.Value.(error); ok {
+			ex, err := res.NewException()
+			if err != nil {
+				return err
+			}
+			ex.SetType(rpccp.Exception_Type(exc.TypeOf(e)))
+			return ex.SetReason(e.Error())
+		}
+		d, err := res.NewCap()
+		if err != nil {
+			return err
+		}
+		_, _, err = c.sendCap(d, ee.client)
+		return err
+	}, func(err error) {
+		if err != nil {
+			c.er.ReportError(fmt.Errorf("send resolve: %w", err))
+		}
+	})
 }
 
 // fillPayloadCapTable adds descriptors of payload's message's
 // capabilities into payload's capability table and returns the
-// reference counts that have been added to the exports table.
+// reference counts that have been added to the exports table.  If an
+// error occurs, the reference counts added before it are returned.
 //
 // The caller must be holding onto c.mu.
 func (c *Conn) fillPayloadCapTable(payload rpccp.Payload) (map[exportID]uint32, error) {
@@ -188,7 +303,7 @@ func (c *Conn) fillPayloadCapTable(payload rpccp.Payload) (map[exportID]uint32,
 	for i, client := range clients {
 		id, isExport, err := c.sendCap(list.At(i), client)
 		if err != nil {
-			return nil, rpcerr.Failedf("Serializing capability: %w", err)
+			return refs, rpcerr.Annotate(err, "serializing capability")
 		}
 		if !isExport {
 			continue
@@ -225,6 +340,7 @@ func (c *Conn) embargo(client capnp.Client) (embargoID, capnp.Client) {
 	}
 	var c2 capnp.Client
 	c2, c.lk.embargoes[id].p = capnp.NewPromisedClient(c.lk.embargoes[id])
+	c.observe(Event{Kind: EventEmbargo, ID: uint32(id)})
 	return id, c2
 }
 
@@ -276,6 +392,10 @@ type senderLoopback struct {
 	id        embargoID
 	question  questionID
 	transform []capnp.PipelineOp
+
+	// accept is set if the capability is a third-party handoff; the
+	// Disembargo has the accept context instead.  See handoff.go.
+	accept bool
 }
 
 func (sl *senderLoopback) buildDisembargo(msg rpccp.Message) error {
@@ -296,7 +416,11 @@ func (sl *senderLoopback) buildDisembargo(msg rpccp.Message) error {
 		return rpcerr.Failedf("build disembargo: %w", err)
 	}
 
-	d.Context().SetSenderLoopback(uint32(sl.id))
+	if sl.accept {
+		d.Context().SetAccept()
+	} else {
+		d.Context().SetSenderLoopback(uint32(sl.id))
+	}
 	pa.SetQuestionId(uint32(sl.question))
 	for i, op := range sl.transform {
 		oplist.At(i).SetGetPointerField(op.Field)
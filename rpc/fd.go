@@ -0,0 +1,54 @@
+package rpc
+
+import (
+	"os"
+
+	"capnproto.org/go/capnp/v3"
+	"capnproto.org/go/capnp/v3/rpc/transport"
+	rpccp "capnproto.org/go/capnp/v3/std/capnp/rpc"
+)
+
+// noFd is the value of CapDescriptor.attachedFd when no file
+// descriptor is attached.
+const noFd = 0xff
+
+// sendFd attaches client's file descriptor, if it has one, to d, which
+// must be in a message returned by c.transport.NewMessage.  Nothing is
+// attached if the transport cannot pass descriptors.  The caller must
+// be holding onto c.lk.
+func (c *Conn) sendFd(d rpccp.CapDescriptor, client capnp.Client) {
+	ft, ok := c.transport.(transport.FdTransport)
+	if !ok {
+		return
+	}
+	fd, ok := client.Fd()
+	if !ok {
+		return
+	}
+	i, err := ft.AttachFd(capnp.Struct(d).Message(), fd)
+	if err != nil {
+		c.er.ReportError(rpcerr.Annotate(err, "attach fd"))
+		return
+	}
+	if i >= noFd {
+		// Unreachable with any real transport, but the index must fit
+		// in the field.
+		return
+	}
+	d.SetAttachedFd(uint8(i))
+}
+
+// recvFd takes ownership of the file descriptor attached to d, or
+// returns nil if there is none.  The caller must be holding onto c.lk.
+func (c *Conn) recvFd(d rpccp.CapDescriptor) *os.File {
+	i := d.AttachedFd()
+	if i == noFd {
+		return nil
+	}
+	ft, ok := c.transport.(transport.FdTransport)
+	if !ok {
+		return nil
+	}
+	f, _ := ft.TakeFd(capnp.Struct(d).Message(), int(i))
+	return f
+}
@@ -0,0 +1,148 @@
+//go:build linux || darwin || freebsd
+
+package rpc_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"capnproto.org/go/capnp/v3"
+	"capnproto.org/go/capnp/v3/rpc"
+	testcp "capnproto.org/go/capnp/v3/rpc/internal/testcapnp"
+	"capnproto.org/go/capnp/v3/rpc/transport"
+)
+
+// fdPingPong is a PingPong server with a file descriptor.
+type fdPingPong struct {
+	pingPongServer
+	fd int
+}
+
+func (s fdPingPong) Fd() (int, bool) {
+	return s.fd, true
+}
+
+// TestSendFd checks that a capability's file descriptor is passed over
+// a Unix domain socket, and closed when the import is released.
+func TestSendFd(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	t1, t2, err := newUnixTransportPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := testcp.PingPong_ServerToClient(fdPingPong{fd: int(w.Fd())})
+	if fd, ok := capnp.Client(srv).Fd(); !ok || fd != int(w.Fd()) {
+		t.Errorf("local Fd() = %d, %t; want %d, true", fd, ok, w.Fd())
+	}
+	conn1 := rpc.NewConn(t1, &rpc.Options{
+		ErrorReporter:   testErrorReporter{tb: t},
+		BootstrapClient: capnp.Client(srv),
+	})
+	defer conn1.Close()
+	conn2 := rpc.NewConn(t2, &rpc.Options{
+		ErrorReporter: testErrorReporter{tb: t},
+	})
+	defer conn2.Close()
+
+	pp := testcp.PingPong(conn2.Bootstrap(ctx))
+	if err := capnp.Client(pp).Resolve(ctx); err != nil {
+		t.Fatal("Resolve:", err)
+	}
+	echoNum(ctx, t, pp, 42)
+	fd, ok := capnp.Client(pp).Fd()
+	if !ok {
+		t.Fatal("imported Fd() = false")
+	}
+	if fd == int(w.Fd()) {
+		t.Error("imported Fd() is the exported descriptor")
+	}
+	if _, err := syscall.Write(fd, []byte("hi")); err != nil {
+		t.Fatal("write to imported descriptor:", err)
+	}
+	buf := make([]byte, 2)
+	if _, err := io.ReadFull(r, buf); err != nil || string(buf) != "hi" {
+		t.Fatalf("read %q, %v; want \"hi\"", buf, err)
+	}
+
+	// Once the exporting side's copy is closed, the pipe ends when the
+	// import is released.
+	w.Close()
+	pp.Release()
+	eof := make(chan error, 1)
+	go func() {
+		_, err := r.Read(buf)
+		eof <- err
+	}()
+	select {
+	case err := <-eof:
+		if err != io.EOF {
+			t.Errorf("read from pipe: %v; want EOF", err)
+		}
+	case <-ctx.Done():
+		t.Error("imported descriptor not closed on release")
+	}
+}
+
+// TestSendFdUnsupported checks that a capability's file descriptor is
+// dropped by transports that cannot pass it.
+func TestSendFdUnsupported(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	p1, p2 := transport.NewPipe(1)
+	srv := testcp.PingPong_ServerToClient(fdPingPong{fd: int(os.Stdin.Fd())})
+	conn1 := rpc.NewConn(rpc.NewTransport(p1), &rpc.Options{
+		ErrorReporter:   testErrorReporter{tb: t},
+		BootstrapClient: capnp.Client(srv),
+	})
+	defer conn1.Close()
+	conn2 := rpc.NewConn(rpc.NewTransport(p2), &rpc.Options{
+		ErrorReporter: testErrorReporter{tb: t},
+	})
+	defer conn2.Close()
+
+	pp := testcp.PingPong(conn2.Bootstrap(ctx))
+	defer pp.Release()
+	echoNum(ctx, t, pp, 42)
+	if fd, ok := capnp.Client(pp).Fd(); ok {
+		t.Errorf("imported Fd() = %d, true; want false", fd)
+	}
+}
+
+func newUnixTransportPair() (t1, t2 transport.Transport, err error) {
+	fds, err := syscall.Socketpair(syscall.AF_UNIX, syscall.SOCK_STREAM, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	var conns [2]*net.UnixConn
+	for i, fd := range fds {
+		f := os.NewFile(uintptr(fd), "socketpair")
+		c, err := net.FileConn(f)
+		f.Close()
+		if err != nil {
+			if i == 1 {
+				conns[0].Close()
+			} else {
+				syscall.Close(fds[1])
+			}
+			return nil, nil, err
+		}
+		conns[i] = c.(*net.UnixConn)
+	}
+	return transport.NewUnix(conns[0]), transport.NewUnix(conns[1]), nil
+}
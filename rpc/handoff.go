@@ -0,0 +1,831 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"capnproto.org/go/capnp/v3"
+	"capnproto.org/go/capnp/v3/exc"
+	"capnproto.org/go/capnp/v3/internal/syncutil"
+	rpccp "capnproto.org/go/capnp/v3/std/capnp/rpc"
+)
+
+/*
+Three-party handoff (level 3)
+
+When vat B sends vat A a capability that B imported from vat C, and both
+connections belong to the same VatNetwork, B introduces A to C instead
+of proxying every call:
+
+ 1. B sends C a Provide message naming the capability and A, and sends
+    A a thirdPartyHosted descriptor.  The descriptor also names a vine:
+    an ordinary export of the capability on the A-B connection, which A
+    falls back to if it cannot reach C.
+ 2. A connects to C and sends an Accept message.  C answers it with the
+    capability once it has received the matching Provide, and then
+    answers the Provide.
+ 3. A releases the vine once it has picked up the capability.
+
+Calls that A made through B before learning about C (calls pipelined on
+an answer, or made on a promise that resolved to the capability) may
+still be in flight.  In that case A sets the embargo flag on its Accept
+and sends B a Disembargo with the accept context.  B forwards it to C,
+after the calls it has forwarded, as a Disembargo with the provide
+context, and C holds the Accept's Return until then.
+
+The connections to B and C are distinct Conns with their own locks.
+Code in this file never holds the locks of two Conns at once: work on
+another connection is handed off to a goroutine, or done after the
+current lock is released.
+*/
+
+// provide is an outgoing Provide message, sent by the introducing vat
+// to the vat hosting a capability.
+type provide struct {
+	c      *Conn    // connection to the vat hosting the capability
+	id     importID // the provided import on c
+	cancel context.CancelFunc
+
+	// sent is closed once the Provide message has been enqueued or has
+	// failed to send.  q is nil if the message was never sent.
+	sent chan struct{}
+	q    *question
+}
+
+// finished reports whether the Provide message has been answered or
+// could not be sent.
+func (p *provide) finished() bool {
+	select {
+	case <-p.sent:
+	default:
+		return false
+	}
+	if p.q == nil {
+		return true
+	}
+	select {
+	case <-p.q.p.Answer().Done():
+		return true
+	default:
+		return false
+	}
+}
+
+// isIntroducer reports whether c can introduce its remote vat to the
+// remote vat of other.
+func (c *Conn) isIntroducer(other *Conn) bool {
+	return c.network != nil && other != c && other.network == c.network
+}
+
+// sendThirdPartyCap writes a thirdPartyHosted descriptor for a client
+// imported over another connection of the same network, and sends a
+// Provide message to the vat hosting it.  The client is exported as
+// the vine.  The caller must be holding onto c.lk.
+func (c *Conn) sendThirdPartyCap(d rpccp.CapDescriptor, client capnp.Client, ic *importClient, info IntroductionInfo) (exportID, error) {
+	tp, err := d.NewThirdPartyHosted()
+	if err != nil {
+		return 0, err
+	}
+	if err := tp.SetId(capnp.Ptr(info.SendToRecipient)); err != nil {
+		return 0, err
+	}
+
+	state := client.State()
+	state.Metadata.Lock()
+	defer state.Metadata.Unlock()
+	id, ok := c.findExportID(state.Metadata)
+	var ee *expent
+	if ok {
+		ee = c.lk.exports[id]
+		ee.wireRefs++
+	} else {
+		if err := c.checkExportQuota(); err != nil {
+			return 0, err
+		}
+		ee = &expent{
+			client:   client.AddRef(),
+			wireRefs: 1,
+		}
+		id = c.addExport(ee)
+		c.setExportID(state.Metadata, id)
+	}
+	tp.SetVineId(uint32(id))
+
+	// Drop the Provide messages of earlier handoffs that are complete.
+	provides := ee.provides[:0]
+	for _, p := range ee.provides {
+		if !p.finished() {
+			provides = append(provides, p)
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &provide{
+		c:      ic.c,
+		id:     ic.id,
+		cancel: cancel,
+		sent:   make(chan struct{}),
+	}
+	ee.provides = append(provides, p)
+	go ic.c.sendProvide(ctx, p, ic.generation, capnp.Ptr(info.SendToProvider))
+	return id, nil
+}
+
+// cancelProvides finishes the Provide messages for which the export
+// is the vine.  The caller must be holding onto c.lk.
+func (ee *expent) cancelProvides() {
+	for _, p := range ee.provides {
+		p.cancel()
+	}
+	ee.provides = nil
+}
+
+// sendProvide sends a Provide message for an import, naming the
+// recipient of a handoff, and waits for the Return.
+//
+// The caller MUST NOT hold c.lk.
+func (c *Conn) sendProvide(ctx context.Context, p *provide, generation uint64, recipient capnp.Ptr) {
+	syncutil.With(&c.lk, func() {
+		defer close(p.sent)
+
+		if !c.startTask() {
+			return
+		}
+		defer c.tasks.Done()
+
+		ent := c.lk.imports[p.id]
+		if ent == nil || ent.generation != generation {
+			// Released concurrently.  The recipient's Accept will fail,
+			// and it will fall back to the vine.
+			return
+		}
+		q := c.newQuestion(capnp.Method{})
+		p.q = q
+
+		c.sendMessage(ctx, func(m rpccp.Message) error {
+			pr, err := m.NewProvide()
+			if err != nil {
+				return err
+			}
+			pr.SetQuestionId(uint32(q.id))
+			tgt, err := pr.NewTarget()
+			if err != nil {
+				return err
+			}
+			tgt.SetImportedCap(uint32(p.id))
+			return pr.SetRecipient(recipient)
+		}, func(err error) {
+			if err != nil {
+				syncutil.With(&c.lk, func() {
+					c.removeQuestion(q.id)
+				})
+				q.p.Reject(exc.Annotate("rpc", "provide", err))
+				syncutil.With(&c.lk, func() {
+					c.lk.questionID.remove(uint32(q.id))
+				})
+				return
+			}
+
+			c.tasks.Add(1)
+			go func() {
+				defer c.tasks.Done()
+				q.handleCancel(ctx)
+			}()
+		})
+	})
+	if p.q == nil {
+		return
+	}
+
+	// The results are empty, so release them as soon as they arrive.
+	<-p.q.p.Answer().Done()
+	p.q.p.ReleaseClients()
+	p.q.release()
+}
+
+// disembargo sends a Disembargo with the provide context to the vat
+// hosting the capability, after the Provide message.
+//
+// The caller MUST NOT hold p.c.lk.
+func (p *provide) disembargo() {
+	<-p.sent
+	if p.finished() {
+		return
+	}
+
+	c := p.c
+	syncutil.With(&c.lk, func() {
+		if !c.startTask() {
+			return
+		}
+		defer c.tasks.Done()
+
+		c.sendMessage(c.bgctx, func(m rpccp.Message) error {
+			d, err := m.NewDisembargo()
+			if err != nil {
+				return err
+			}
+			tgt, err := d.NewTarget()
+			if err != nil {
+				return err
+			}
+			tgt.SetImportedCap(uint32(p.id))
+			d.Context().SetProvide(uint32(p.q.id))
+			return nil
+		}, func(err error) {
+			if err != nil {
+				c.er.ReportError(rpcerr.Annotatef(err, "forward disembargo"))
+			}
+		})
+	})
+}
+
+// A handoff is the hook of a client received in a thirdPartyHosted
+// descriptor.  Calls block until the capability has been picked up
+// from the vat hosting it, or the handoff has fallen back to the vine.
+type handoff struct {
+	c      *Conn // connection to the introducing vat
+	vine   capnp.Client
+	capID  ThirdPartyCapID
+	cancel context.CancelFunc
+
+	// embargo is set if calls may have been made on the capability
+	// through the introducing vat.  Protected by c.lk.
+	embargo bool
+
+	p        *capnp.ClientPromise
+	resolved capnp.Client // set before ready is closed
+	ready    chan struct{}
+}
+
+// recvThirdPartyCap materializes a client for a thirdPartyHosted
+// descriptor.  If c is not part of a VatNetwork, the vine is used
+// directly.
+//
+// The caller must be holding onto c.lk.
+func (c *Conn) recvThirdPartyCap(tp rpccp.ThirdPartyCapDescriptor) capnp.Client {
+	vine := c.addImport(importID(tp.VineId()), false, nil)
+	if c.network == nil || !c.startTask() {
+		return vine
+	}
+	id, err := tp.Id()
+	if err == nil {
+		id, err = copyPtr(id)
+	}
+	if err != nil {
+		c.tasks.Done()
+		c.er.ReportError(rpcerr.Failedf("receive capability: read third party cap ID: %w", err))
+		return vine
+	}
+
+	ctx, cancel := context.WithCancel(c.bgctx)
+	h := &handoff{
+		c:      c,
+		vine:   vine,
+		capID:  ThirdPartyCapID(id),
+		cancel: cancel,
+		ready:  make(chan struct{}),
+	}
+	var client capnp.Client
+	client, h.p = capnp.NewPromisedClient(h)
+	go func() {
+		defer c.tasks.Done()
+		h.run(ctx)
+	}()
+	return client
+}
+
+// run picks up the capability and resolves the handoff.
+//
+// The caller MUST NOT hold h.c.lk.
+func (h *handoff) run(ctx context.Context) {
+	var embargo bool
+	syncutil.With(&h.c.lk, func() {
+		// The message carrying the descriptor has been processed, so
+		// the embargo flag is settled.
+		embargo = h.embargo
+	})
+
+	client, err := h.accept(ctx, embargo)
+	if err != nil {
+		if ctx.Err() == nil {
+			h.c.er.ReportError(rpcerr.Annotate(err, "third party handoff"))
+		}
+		// Fall back to proxying through the introducing vat.
+		client = h.vine
+	} else {
+		h.vine.Release()
+	}
+	h.resolved = client
+	close(h.ready)
+	h.p.Fulfill(client)
+	client.Release()
+}
+
+// accept connects to the vat hosting the capability and picks it up.
+func (h *handoff) accept(ctx context.Context, embargo bool) (capnp.Client, error) {
+	conn, provision, err := h.c.network.DialIntroduced(ctx, h.capID, h.c)
+	if err != nil {
+		return capnp.Client{}, err
+	}
+	client := conn.accept(ctx, provision, embargo)
+	if err := client.Resolve(ctx); err != nil {
+		client.Release()
+		return capnp.Client{}, err
+	}
+	if err, ok := client.State().Brand.Value.(error); ok {
+		client.Release()
+		return capnp.Client{}, err
+	}
+	return client, nil
+}
+
+func (h *handoff) Send(ctx context.Context, s capnp.Send) (*capnp.Answer, capnp.ReleaseFunc) {
+	select {
+	case <-h.ready:
+		return h.resolved.SendCall(ctx, s)
+	case <-ctx.Done():
+		return capnp.ErrorAnswer(s.Method, ctx.Err()), func() {}
+	}
+}
+
+func (h *handoff) Recv(ctx context.Context, r capnp.Recv) capnp.PipelineCaller {
+	select {
+	case <-h.ready:
+		return h.resolved.RecvCall(ctx, r)
+	case <-ctx.Done():
+		r.Reject(ctx.Err())
+		return nil
+	}
+}
+
+func (h *handoff) Brand() capnp.Brand {
+	return capnp.Brand{Value: h}
+}
+
+func (h *handoff) Shutdown() {
+	h.cancel()
+}
+
+// accept sends an Accept message to pick up a capability that a third
+// party has provided to this vat.  Like Bootstrap, it returns a client
+// that resolves once the Return arrives.
+func (c *Conn) accept(ctx context.Context, provision ProvisionID, embargo bool) (ac capnp.Client) {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+
+	if !c.startTask() {
+		return capnp.ErrorClient(rpcerr.Disconnectedf("connection closed"))
+	}
+	defer c.tasks.Done()
+
+	acceptCtx, cancel := context.WithCancel(ctx)
+	q := c.newQuestion(capnp.Method{})
+	ac, q.bootstrapPromise = capnp.NewPromisedClient(bootstrapClient{
+		c:      q.p.Answer().Client().AddRef(),
+		cancel: cancel,
+	})
+
+	c.sendMessage(ctx, func(m rpccp.Message) error {
+		a, err := m.NewAccept()
+		if err != nil {
+			return err
+		}
+		a.SetQuestionId(uint32(q.id))
+		a.SetEmbargo(embargo)
+		return a.SetProvision(capnp.Ptr(provision))
+	}, func(err error) {
+		if err != nil {
+			syncutil.With(&c.lk, func() {
+				c.removeQuestion(q.id)
+			})
+			q.bootstrapPromise.Reject(exc.Annotate("rpc", "accept", err))
+			syncutil.With(&c.lk, func() {
+				c.lk.questionID.remove(uint32(q.id))
+			})
+			return
+		}
+
+		c.tasks.Add(1)
+		go func() {
+			defer c.tasks.Done()
+			q.handleCancel(acceptCtx)
+		}()
+	})
+
+	return
+}
+
+// A provision is a capability that the remote vat of one connection
+// (the introducer) asked this vat to provide to the remote vat of
+// another connection (the recipient).  It is created by a Provide
+// message, or by an Accept message that arrives before the matching
+// Provide.  Such a placeholder only has acceptAns set, and is merged
+// into the provision created by the Provide.
+type provision struct {
+	mu sync.Mutex
+
+	client     capnp.Client // the provided capability
+	provideAns *answer      // answer to the Provide message
+	acceptAns  *answer      // answer to the Accept message; nil until accepted
+
+	embargoed    bool  // the Accept asked for an embargo
+	disembargoed bool  // the introducer sent a Disembargo for the Provide
+	err          error // set if the provision failed or was canceled
+
+	provideReturned, acceptReturned bool
+}
+
+// handleProvide handles a Provide message, waiting for the recipient
+// to connect in a separate goroutine.
+func (c *Conn) handleProvide(ctx context.Context, in rpccp.Provide, release capnp.ReleaseFunc) error {
+	rl := &releaseList{}
+	defer rl.Release()
+
+	id := answerID(in.QuestionId())
+	var (
+		tgt       parsedMessageTarget
+		recipient capnp.Ptr
+		err       error
+	)
+	if t, e := in.Target(); e != nil {
+		err = rpcerr.Failedf("read target: %w", e)
+	} else {
+		err = parseMessageTarget(&tgt, t)
+	}
+	if err == nil {
+		if recipient, err = in.Recipient(); err == nil {
+			recipient, err = copyPtr(recipient)
+		}
+		if err != nil {
+			err = rpcerr.Failedf("read recipient: %w", err)
+		}
+	}
+	release()
+
+	ans := &answer{c: c, id: id}
+	var retErr error
+	ans.ret, ans.sendMsg, ans.msgReleaser, retErr = c.newReturn()
+	if retErr == nil {
+		ans.ret.SetAnswerId(uint32(id))
+		ans.ret.SetReleaseParamCaps(false)
+	}
+
+	c.lk.Lock()
+	defer c.lk.Unlock()
+
+	if c.lk.answers[id] != nil {
+		if retErr == nil {
+			rl.Add(ans.msgReleaser.Decr)
+		}
+		return rpcerr.Failedf("incoming provide: answer ID %d reused", id)
+	}
+	if retErr != nil {
+		retErr = rpcerr.Annotate(retErr, "incoming provide")
+		c.lk.answers[id] = errorAnswer(c, id, retErr)
+		c.er.ReportError(retErr)
+		return nil
+	}
+	reject, abort := c.checkIncomingQuestion()
+	if abort != nil {
+		rl.Add(ans.msgReleaser.Decr)
+		return rpcerr.Annotate(abort, "incoming provide")
+	}
+	c.lk.answers[id] = ans
+	if reject != nil {
+		ans.sendException(rl, reject)
+		return nil
+	}
+	if err != nil {
+		err = rpcerr.Annotate(err, "incoming provide")
+		ans.sendException(rl, err)
+		c.er.ReportError(err)
+		return nil
+	}
+	if c.network == nil {
+		ans.sendException(rl, rpcerr.Unimplementedf("incoming provide: connection is not part of a vat network"))
+		return nil
+	}
+
+	var client capnp.Client
+	switch tgt.which {
+	case rpccp.MessageTarget_Which_importedCap:
+		ent := c.findExport(tgt.importedCap)
+		if ent == nil {
+			err = rpcerr.Failedf("incoming provide: unknown export ID %d", tgt.importedCap)
+			ans.sendException(rl, err)
+			c.er.ReportError(err)
+			return nil
+		}
+		client = ent.client.AddRef()
+	case rpccp.MessageTarget_Which_promisedAnswer:
+		tgtAns := c.lk.answers[tgt.promisedAnswer]
+		if tgtAns == nil || tgtAns.flags.Contains(finishReceived) {
+			err = rpcerr.Failedf("incoming provide: use of unknown or finished answer ID %d for promised answer target", tgt.promisedAnswer)
+			ans.sendException(rl, err)
+			c.er.ReportError(err)
+			return nil
+		}
+		client = c.recvCapReceiverAnswer(tgtAns, tgt.transform)
+	}
+
+	p := &provision{
+		client:     client,
+		provideAns: ans,
+	}
+	ans.provision = p
+	ans.cancel = func(cause error) {
+		// Called while holding c.lk, by a Finish or by shutdown.
+		var err error = exc.New(exc.Failed, "", "provide canceled")
+		if !errors.Is(cause, ErrCallFinished) {
+			err = cause
+		}
+		go p.fail(err)
+	}
+	if !c.startTask() {
+		go p.fail(ExcClosed)
+		return nil
+	}
+	go func() {
+		defer c.tasks.Done()
+		c.offerProvision(p, RecipientID(recipient))
+	}()
+	return nil
+}
+
+// offerProvision waits for the recipient of a Provide message to
+// connect, and then offers it the provision.
+//
+// The caller MUST NOT hold c.lk.
+func (c *Conn) offerProvision(p *provision, recipient RecipientID) {
+	r, provision, err := c.network.AcceptIntroduced(c.bgctx, recipient, c)
+	if err != nil {
+		p.fail(rpcerr.Annotate(err, "accept introduced"))
+		return
+	}
+	key, err := provisionKey(provision)
+	if err != nil {
+		p.fail(rpcerr.Annotate(err, "accept introduced"))
+		return
+	}
+	r.addProvision(key, p)
+}
+
+// addProvision offers a provision to the remote vat, matching it with
+// an Accept message that may already have arrived.
+//
+// The caller MUST NOT hold c.lk.
+func (c *Conn) addProvision(key string, p *provision) {
+	var err error
+	syncutil.With(&c.lk, func() {
+		if c.lk.provisions == nil {
+			err = ExcClosed
+			return
+		}
+		stub := c.lk.provisions[key]
+		if stub == nil {
+			c.lk.provisions[key] = p
+			return
+		}
+		if stub.provideAns != nil {
+			err = rpcerr.Failedf("duplicate provision")
+			return
+		}
+		delete(c.lk.provisions, key)
+		syncutil.With(&p.mu, func() {
+			p.acceptAns = stub.acceptAns
+			p.embargoed = stub.embargoed
+		})
+	})
+	if err != nil {
+		p.fail(err)
+		return
+	}
+	p.settle()
+}
+
+// handleAccept handles an Accept message, answering it once the
+// matching Provide message has arrived.
+func (c *Conn) handleAccept(ctx context.Context, in rpccp.Accept, release capnp.ReleaseFunc) error {
+	rl := &releaseList{}
+	defer rl.Release()
+
+	id := answerID(in.QuestionId())
+	embargo := in.Embargo()
+	var key string
+	ptr, err := in.Provision()
+	if err == nil {
+		key, err = provisionKey(ProvisionID(ptr))
+	}
+	if err != nil {
+		err = rpcerr.Failedf("read provision: %w", err)
+	}
+	release()
+
+	ans := &answer{c: c, id: id}
+	var retErr error
+	ans.ret, ans.sendMsg, ans.msgReleaser, retErr = c.newReturn()
+	if retErr == nil {
+		ans.ret.SetAnswerId(uint32(id))
+		ans.ret.SetReleaseParamCaps(false)
+	}
+
+	var p *provision
+	c.lk.Lock()
+	if c.lk.answers[id] != nil {
+		c.lk.Unlock()
+		if retErr == nil {
+			rl.Add(ans.msgReleaser.Decr)
+		}
+		return rpcerr.Failedf("incoming accept: answer ID %d reused", id)
+	}
+	reject, abort := c.checkIncomingQuestion()
+	if abort != nil {
+		c.lk.Unlock()
+		if retErr == nil {
+			rl.Add(ans.msgReleaser.Decr)
+		}
+		return rpcerr.Annotate(abort, "incoming accept")
+	}
+	switch {
+	case retErr != nil:
+		retErr = rpcerr.Annotate(retErr, "incoming accept")
+		c.lk.answers[id] = errorAnswer(c, id, retErr)
+		c.er.ReportError(retErr)
+	case reject != nil:
+		c.lk.answers[id] = ans
+		ans.sendException(rl, reject)
+	case err != nil:
+		c.lk.answers[id] = ans
+		err = rpcerr.Annotate(err, "incoming accept")
+		ans.sendException(rl, err)
+		c.er.ReportError(err)
+	case c.network == nil:
+		c.lk.answers[id] = ans
+		ans.sendException(rl, rpcerr.Unimplementedf("incoming accept: connection is not part of a vat network"))
+	default:
+		c.lk.answers[id] = ans
+		p = c.lk.provisions[key]
+		switch {
+		case p == nil:
+			// The Provide message has not arrived yet.
+			c.lk.provisions[key] = &provision{
+				acceptAns: ans,
+				embargoed: embargo,
+			}
+		case p.provideAns == nil:
+			p = nil
+			ans.sendException(rl, rpcerr.Failedf("incoming accept: provision already accepted"))
+		default:
+			delete(c.lk.provisions, key)
+			syncutil.With(&p.mu, func() {
+				p.acceptAns = ans
+				p.embargoed = embargo
+			})
+		}
+	}
+	c.lk.Unlock()
+
+	if p != nil {
+		p.settle()
+	}
+	return nil
+}
+
+// disembargo releases the embargo requested by the Accept message.
+//
+// The caller MUST NOT hold the lock of either connection.
+func (p *provision) disembargo() {
+	syncutil.With(&p.mu, func() {
+		p.disembargoed = true
+	})
+	p.settle()
+}
+
+// fail settles the provision with an error.
+//
+// The caller MUST NOT hold the lock of either connection.
+func (p *provision) fail(err error) {
+	syncutil.With(&p.mu, func() {
+		if p.err == nil {
+			p.err = err
+		}
+	})
+	p.settle()
+}
+
+// settle sends the Returns for the Accept and Provide messages once
+// the capability can be handed over, or once the provision has failed.
+//
+// The caller MUST NOT hold the lock of either connection.
+func (p *provision) settle() {
+	p.mu.Lock()
+	ready := p.acceptAns != nil && p.provideAns != nil && (!p.embargoed || p.disembargoed)
+	if p.err == nil && !ready {
+		p.mu.Unlock()
+		return
+	}
+	var acceptAns, provideAns *answer
+	if p.acceptAns != nil && !p.acceptReturned {
+		acceptAns = p.acceptAns
+		p.acceptReturned = true
+	}
+	if p.provideAns != nil && !p.provideReturned {
+		provideAns = p.provideAns
+		p.provideReturned = true
+	}
+	client := p.client
+	p.client = capnp.Client{}
+	err := p.err
+	p.mu.Unlock()
+
+	rl := &releaseList{}
+	defer rl.Release()
+
+	if acceptAns != nil {
+		c := acceptAns.c
+		syncutil.With(&c.lk, func() {
+			if !c.startTask() {
+				rl.Add(client.Release)
+				return
+			}
+			defer c.tasks.Done()
+
+			if err != nil {
+				acceptAns.sendException(rl, err)
+				rl.Add(client.Release)
+				return
+			}
+			if err := acceptAns.setBootstrap(client); err != nil {
+				acceptAns.sendException(rl, err)
+				return
+			}
+			if err := acceptAns.sendReturn(rl); err != nil {
+				c.er.ReportError(rpcerr.Annotate(err, "send accept return"))
+			}
+		})
+	} else {
+		rl.Add(client.Release)
+	}
+
+	if provideAns != nil {
+		c := provideAns.c
+		syncutil.With(&c.lk, func() {
+			if !c.startTask() {
+				return
+			}
+			defer c.tasks.Done()
+
+			if err != nil {
+				provideAns.sendException(rl, err)
+				return
+			}
+			var rerr error
+			if provideAns.results, rerr = provideAns.ret.NewResults(); rerr != nil {
+				provideAns.sendException(rl, rpcerr.Failedf("alloc provide results: %w", rerr))
+				return
+			}
+			if err := provideAns.sendReturn(rl); err != nil {
+				c.er.ReportError(rpcerr.Annotate(err, "send provide return"))
+			}
+		})
+	}
+}
+
+// failProvisions fails the provisions waiting for the remote vat to
+// accept them.  Called by 'shutdown'.  Caller MUST hold c.lk.
+func (c *Conn) failProvisions(rl *releaseList, provisions map[string]*provision) {
+	for _, p := range provisions {
+		p := p
+		rl.Add(func() {
+			p.fail(ExcClosed)
+		})
+	}
+}
+
+// provisionKey returns the key under which a provision is stored: the
+// canonical encoding of its ID.
+func provisionKey(id ProvisionID) (string, error) {
+	s := capnp.Ptr(id).Struct()
+	if !s.IsValid() {
+		return "", rpcerr.Failedf("provision ID is not a struct")
+	}
+	b, err := capnp.Canonicalize(s)
+	if err != nil {
+		return "", rpcerr.Failedf("canonicalize provision ID: %w", err)
+	}
+	return string(b), nil
+}
+
+// copyPtr copies p into a new message, so that it can outlive the
+// message it was received in.
+func copyPtr(p capnp.Ptr) (capnp.Ptr, error) {
+	msg, _, err := capnp.NewMessage(capnp.SingleSegment(nil))
+	if err != nil {
+		return capnp.Ptr{}, err
+	}
+	if err := msg.SetRoot(p); err != nil {
+		return capnp.Ptr{}, err
+	}
+	return msg.Root()
+}
@@ -2,6 +2,7 @@ package rpc
 
 import (
 	"context"
+	"os"
 
 	"capnproto.org/go/capnp/v3"
 	"capnproto.org/go/capnp/v3/internal/syncutil"
@@ -43,47 +44,78 @@ type impent struct {
 	// importClient's generation matches the entry's generation before
 	// removing the entry from the table and sending a release message.
 	generation uint64
+
+	// promise is non-nil if the import is a promise that the remote vat
+	// has not yet resolved.  It is fulfilled (and set to nil) when a
+	// Resolve message is received for the import.
+	promise *capnp.ClientPromise
 }
 
 // addImport returns a client that represents the given import,
 // incrementing the number of references to this import from this vat.
 // This is separate from the reference counting that capnp.Client does.
+// If isPromise is true, then the import was received as a senderPromise
+// and the returned client will resolve once a Resolve message arrives.
+// addImport takes ownership of fd, the file descriptor received with
+// the import, which may be nil.  It is closed if the import already
+// has a client.
 //
 // The caller must be holding onto c.mu.
-func (c *Conn) addImport(id importID) capnp.Client {
+func (c *Conn) addImport(id importID, isPromise bool, fd *os.File) capnp.Client {
 	if ent := c.lk.imports[id]; ent != nil {
 		ent.wireRefs++
 		client, ok := ent.wc.AddRef()
 		if !ok {
 			ent.generation++
-			client = capnp.NewClient(&importClient{
-				c:          c,
-				id:         id,
-				generation: ent.generation,
-			})
+			client, ent.promise = c.newImportClient(id, ent.generation, isPromise, fd)
 			ent.wc = client.WeakRef()
+		} else if fd != nil {
+			fd.Close()
 		}
 		return client
 	}
-	client := capnp.NewClient(&importClient{
-		c:  c,
-		id: id,
-	})
+	client, promise := c.newImportClient(id, 0, isPromise, fd)
 	c.lk.imports[id] = &impent{
 		wc:       client.WeakRef(),
 		wireRefs: 1,
+		promise:  promise,
 	}
 	return client
 }
 
+// newImportClient creates a client for an import.  If isPromise is
+// true, then the client is a promise, and the returned ClientPromise
+// must eventually be resolved.  The client takes ownership of fd.
+func (c *Conn) newImportClient(id importID, generation uint64, isPromise bool, fd *os.File) (capnp.Client, *capnp.ClientPromise) {
+	ic := &importClient{
+		c:          c,
+		id:         id,
+		generation: generation,
+		fd:         fd,
+	}
+	if isPromise {
+		return capnp.NewPromisedClient(ic)
+	}
+	return capnp.NewClient(ic), nil
+}
+
 // An importClient implements capnp.Client for a remote capability.
 type importClient struct {
 	c          *Conn
 	id         importID
 	generation uint64
+
+	// fd is the file descriptor the remote vat attached to the
+	// capability, or nil.  It is closed on Shutdown.
+	fd *os.File
+
+	// shutdown is set after the first call to Shutdown.  A promised
+	// client's hook may be shut down more than once.  Protected by c.lk.
+	shutdown bool
 }
 
 func (ic *importClient) Send(ctx context.Context, s capnp.Send) (*capnp.Answer, capnp.ReleaseFunc) {
+	ic.c.awaitCallMetadata(ctx)
 	ic.c.lk.Lock()
 	defer ic.c.lk.Unlock()
 
@@ -96,16 +128,19 @@ func (ic *importClient) Send(ctx context.Context, s capnp.Send) (*capnp.Answer,
 		return capnp.ErrorAnswer(s.Method, rpcerr.Disconnectedf("send on closed import")), func() {}
 	}
 	q := ic.c.newQuestion(s.Method)
+	ctx, q.trace = ic.c.traceCall(ctx, s.Method, false, uint32(q.id))
 
 	// Send call message.
 	ic.c.sendMessage(ctx, func(m rpccp.Message) error {
-		return ic.c.newImportCallMessage(m, ic.id, q.id, s)
+		return ic.c.newImportCallMessage(m, ic.id, q.id, s, ic.c.outgoingMetadata(ctx))
 	}, func(err error) {
 		if err != nil {
 			syncutil.With(&ic.c.lk, func() {
-				ic.c.lk.questions[q.id] = nil
+				ic.c.removeQuestion(q.id)
 			})
-			q.p.Reject(rpcerr.Failedf("send message: %w", err))
+			err = rpcerr.Failedf("send message: %w", err)
+			q.p.Reject(err)
+			q.trace.done(err)
 			syncutil.With(&ic.c.lk, func() {
 				ic.c.lk.questionID.remove(uint32(q.id))
 			})
@@ -130,7 +165,7 @@ func (ic *importClient) Send(ctx context.Context, s capnp.Send) (*capnp.Answer,
 // newImportCallMessage builds a Call message targeted to an import.
 //
 // The caller MUST hold c.mu.
-func (c *Conn) newImportCallMessage(msg rpccp.Message, imp importID, qid questionID, s capnp.Send) error {
+func (c *Conn) newImportCallMessage(msg rpccp.Message, imp importID, qid questionID, s capnp.Send, md CallMetadataMap) error {
 	call, err := msg.NewCall()
 	if err != nil {
 		return rpcerr.Failedf("build call message: %w", err)
@@ -147,13 +182,10 @@ func (c *Conn) newImportCallMessage(msg rpccp.Message, imp importID, qid questio
 	if err != nil {
 		return rpcerr.Failedf("build call message: %w", err)
 	}
-	args, err := capnp.NewStruct(payload.Segment(), s.ArgsSize)
+	args, err := c.newCallArgs(payload, s.ArgsSize, md)
 	if err != nil {
 		return rpcerr.Failedf("build call message: %w", err)
 	}
-	if err := payload.SetContent(args.ToPtr()); err != nil {
-		return rpcerr.Failedf("build call message: %w", err)
-	}
 
 	if s.PlaceArgs == nil {
 		return nil
@@ -170,6 +202,9 @@ func (c *Conn) newImportCallMessage(msg rpccp.Message, imp importID, qid questio
 }
 
 func (ic *importClient) Recv(ctx context.Context, r capnp.Recv) capnp.PipelineCaller {
+	if ans := ic.c.tailAnswer(r); ans != nil {
+		return ic.tailCall(ctx, ans, r)
+	}
 	ans, finish := ic.Send(ctx, capnp.Send{
 		Method:   r.Method,
 		ArgsSize: r.Args.Size(),
@@ -213,17 +248,32 @@ func (ic *importClient) Brand() capnp.Brand {
 	return capnp.Brand{Value: ic}
 }
 
+func (ic *importClient) Fd() (int, bool) {
+	if ic.fd == nil {
+		return -1, false
+	}
+	return int(ic.fd.Fd()), true
+}
+
 func (ic *importClient) Shutdown() {
 	ic.c.lk.Lock()
 	defer ic.c.lk.Unlock()
 
+	if ic.shutdown {
+		return
+	}
+	ic.shutdown = true
+	if ic.fd != nil {
+		ic.fd.Close()
+	}
+
 	if !ic.c.startTask() {
 		return
 	}
 	defer ic.c.tasks.Done()
 
 	ent := ic.c.lk.imports[ic.id]
-	if ic.generation != ent.generation {
+	if ent == nil || ic.generation != ent.generation {
 		// A new reference was added concurrently with the Shutdown.  See
 		// impent.generation documentation for an explanation.
 		return
@@ -0,0 +1,283 @@
+// Package inproc provides an in-process rpc.VatNetwork.  It connects
+// vats living in the same address space over transport.NewPipe, and
+// is mainly useful for testing three-party handoffs and joins.
+package inproc // import "capnproto.org/go/capnp/v3/rpc/inproc"
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"capnproto.org/go/capnp/v3"
+	"capnproto.org/go/capnp/v3/rpc"
+	"capnproto.org/go/capnp/v3/rpc/transport"
+)
+
+// A Network is a set of vats that can connect to one another.
+type Network struct {
+	mu     sync.Mutex
+	vats   map[VatID]*Vat
+	nextID VatID
+	nonce  uint64
+}
+
+// A VatID identifies a vat on a Network.
+type VatID uint64
+
+// New returns an empty network.
+func New() *Network {
+	return &Network{vats: make(map[VatID]*Vat)}
+}
+
+// NewVat adds a vat to the network.  Every connection to the vat
+// is created with a copy of opts, except that each one is given its
+// own reference to opts.BootstrapClient.  NewVat "steals" this
+// reference: it will release the client when the vat is closed.
+func (n *Network) NewVat(opts *rpc.Options) *Vat {
+	v := &Vat{
+		net:   n,
+		conns: make(map[VatID]*rpc.Conn),
+		peers: make(map[*rpc.Conn]VatID),
+	}
+	if opts != nil {
+		v.opts = *opts
+	}
+	v.bootstrap = v.opts.BootstrapClient
+	v.opts.BootstrapClient = capnp.Client{}
+	v.opts.Network = v
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	v.id = n.nextID
+	n.nextID++
+	n.vats[v.id] = v
+	return v
+}
+
+// A Vat is a vat on a Network.  It implements rpc.VatNetwork for the
+// connections it creates.
+type Vat struct {
+	net       *Network
+	id        VatID
+	opts      rpc.Options
+	bootstrap capnp.Client
+
+	// Protected by net.mu:
+	conns  map[VatID]*rpc.Conn
+	peers  map[*rpc.Conn]VatID
+	joinID uint32
+	closed bool
+}
+
+// ID returns the vat's identifier on its network.
+func (v *Vat) ID() VatID {
+	return v.id
+}
+
+// Dial returns the connection to another vat on the network, creating
+// it if needed.
+func (v *Vat) Dial(id VatID) (*rpc.Conn, error) {
+	n := v.net
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if v.closed {
+		return nil, errors.New("inproc: dial: vat closed")
+	}
+	if c := v.conns[id]; c != nil {
+		return c, nil
+	}
+	remote := n.vats[id]
+	if remote == nil || remote == v {
+		return nil, fmt.Errorf("inproc: dial: no vat with ID %d", id)
+	}
+	p1, p2 := transport.NewPipe(1)
+	c := v.newConn(p1, remote.id)
+	remote.newConn(p2, v.id)
+	return c, nil
+}
+
+// newConn creates a connection to the vat with the given ID.  The
+// caller must be holding onto v.net.mu.
+func (v *Vat) newConn(codec transport.Codec, id VatID) *rpc.Conn {
+	opts := v.opts
+	opts.BootstrapClient = v.bootstrap.AddRef()
+	c := rpc.NewConn(rpc.NewTransport(codec), &opts)
+	v.conns[id] = c
+	v.peers[c] = id
+	go func() {
+		<-c.Done()
+		v.net.mu.Lock()
+		defer v.net.mu.Unlock()
+		if v.conns[id] == c {
+			delete(v.conns, id)
+		}
+		delete(v.peers, c)
+	}()
+	return c
+}
+
+// Close closes the vat's connections, removes it from the network and
+// releases its bootstrap client.
+func (v *Vat) Close() error {
+	n := v.net
+	n.mu.Lock()
+	if v.closed {
+		n.mu.Unlock()
+		return errors.New("inproc: vat already closed")
+	}
+	v.closed = true
+	delete(n.vats, v.id)
+	conns := make([]*rpc.Conn, 0, len(v.conns))
+	for _, c := range v.conns {
+		conns = append(conns, c)
+	}
+	n.mu.Unlock()
+
+	var err error
+	for _, c := range conns {
+		if e := c.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	v.bootstrap.Release()
+	return err
+}
+
+// Introduce implements rpc.VatNetwork.
+func (v *Vat) Introduce(provider, recipient *rpc.Conn) (rpc.IntroductionInfo, error) {
+	n := v.net
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	providerID, ok := v.peers[provider]
+	if !ok {
+		return rpc.IntroductionInfo{}, errors.New("inproc: introduce: unknown provider")
+	}
+	recipientID, ok := v.peers[recipient]
+	if !ok {
+		return rpc.IntroductionInfo{}, errors.New("inproc: introduce: unknown recipient")
+	}
+	n.nonce++
+	capID, err := newID(uint64(providerID), n.nonce)
+	if err != nil {
+		return rpc.IntroductionInfo{}, fmt.Errorf("inproc: introduce: %w", err)
+	}
+	rID, err := newID(uint64(recipientID), n.nonce)
+	if err != nil {
+		return rpc.IntroductionInfo{}, fmt.Errorf("inproc: introduce: %w", err)
+	}
+	return rpc.IntroductionInfo{
+		SendToRecipient: rpc.ThirdPartyCapID(capID),
+		SendToProvider:  rpc.RecipientID(rID),
+	}, nil
+}
+
+// DialIntroduced implements rpc.VatNetwork.
+func (v *Vat) DialIntroduced(ctx context.Context, capID rpc.ThirdPartyCapID, introducedBy *rpc.Conn) (*rpc.Conn, rpc.ProvisionID, error) {
+	provider, nonce, err := readID(capnp.Ptr(capID))
+	if err != nil {
+		return nil, rpc.ProvisionID{}, fmt.Errorf("inproc: dial introduced: %w", err)
+	}
+	return v.introduced(VatID(provider), nonce, introducedBy)
+}
+
+// AcceptIntroduced implements rpc.VatNetwork.  Since every connection
+// on the network is created in both vats at once, it never waits.
+func (v *Vat) AcceptIntroduced(ctx context.Context, recipientID rpc.RecipientID, introducedBy *rpc.Conn) (*rpc.Conn, rpc.ProvisionID, error) {
+	recipient, nonce, err := readID(capnp.Ptr(recipientID))
+	if err != nil {
+		return nil, rpc.ProvisionID{}, fmt.Errorf("inproc: accept introduced: %w", err)
+	}
+	return v.introduced(VatID(recipient), nonce, introducedBy)
+}
+
+// introduced returns the connection to the vat with the given ID and
+// the provision ID of an introduction made by the remote vat of
+// introducedBy.
+func (v *Vat) introduced(id VatID, nonce uint64, introducedBy *rpc.Conn) (*rpc.Conn, rpc.ProvisionID, error) {
+	v.net.mu.Lock()
+	introducer, ok := v.peers[introducedBy]
+	v.net.mu.Unlock()
+	if !ok {
+		return nil, rpc.ProvisionID{}, errors.New("inproc: unknown introducer")
+	}
+	provision, err := newID(uint64(introducer), nonce)
+	if err != nil {
+		return nil, rpc.ProvisionID{}, err
+	}
+	c, err := v.Dial(id)
+	if err != nil {
+		return nil, rpc.ProvisionID{}, err
+	}
+	return c, rpc.ProvisionID(provision), nil
+}
+
+// NewJoinKeyParts implements rpc.VatNetwork.
+func (v *Vat) NewJoinKeyParts(partCount uint16) (uint32, []rpc.JoinKeyPart, error) {
+	v.net.mu.Lock()
+	v.joinID++
+	joinID := v.joinID
+	v.net.mu.Unlock()
+
+	parts := make([]rpc.JoinKeyPart, partCount)
+	for i := range parts {
+		p, err := newID(uint64(v.id), joinKey(joinID, partCount, uint16(i)))
+		if err != nil {
+			return 0, nil, fmt.Errorf("inproc: new join key parts: %w", err)
+		}
+		parts[i] = rpc.JoinKeyPart(p)
+	}
+	return joinID, parts, nil
+}
+
+// AcceptJoinKeyPart implements rpc.VatNetwork.
+func (v *Vat) AcceptJoinKeyPart(ctx context.Context, kp rpc.JoinKeyPart, receivedFrom *rpc.Conn) (*rpc.Conn, rpc.JoinKey, error) {
+	joiner, key, err := readID(capnp.Ptr(kp))
+	if err != nil {
+		return nil, rpc.JoinKey{}, fmt.Errorf("inproc: accept join key part: %w", err)
+	}
+	c, err := v.Dial(VatID(joiner))
+	if err != nil {
+		return nil, rpc.JoinKey{}, err
+	}
+	return c, rpc.JoinKey{
+		ID:        uint32(key >> 32),
+		PartCount: uint16(key >> 16),
+		PartNum:   uint16(key),
+	}, nil
+}
+
+// joinKey packs the place of a part in a join into the second half of
+// a join key part, which takes the place of an introduction's nonce.
+func joinKey(joinID uint32, partCount, partNum uint16) uint64 {
+	return uint64(joinID)<<32 | uint64(partCount)<<16 | uint64(partNum)
+}
+
+// idSize is the size of the struct used for all the kinds of IDs: a
+// vat ID followed by the nonce of the introduction, or by the join key
+// of a join key part.
+var idSize = capnp.ObjectSize{DataSize: 16}
+
+func newID(vat, nonce uint64) (capnp.Ptr, error) {
+	_, seg, err := capnp.NewMessage(capnp.SingleSegment(nil))
+	if err != nil {
+		return capnp.Ptr{}, err
+	}
+	s, err := capnp.NewRootStruct(seg, idSize)
+	if err != nil {
+		return capnp.Ptr{}, err
+	}
+	s.SetUint64(0, vat)
+	s.SetUint64(8, nonce)
+	return s.ToPtr(), nil
+}
+
+func readID(p capnp.Ptr) (vat, nonce uint64, err error) {
+	s := p.Struct()
+	if !s.IsValid() {
+		return 0, 0, errors.New("malformed ID")
+	}
+	return s.Uint64(0), s.Uint64(8), nil
+}
@@ -0,0 +1,3 @@
+package metadatacp
+
+//go:generate capnp compile -I ../../../std -ogo metadata.capnp
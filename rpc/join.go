@@ -0,0 +1,658 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"math"
+
+	"capnproto.org/go/capnp/v3"
+	"capnproto.org/go/capnp/v3/internal/syncutil"
+	rpccp "capnproto.org/go/capnp/v3/std/capnp/rpc"
+	"capnproto.org/go/capnp/v3/std/capnp/rpctwoparty"
+)
+
+/*
+Joins (level 4)
+
+A join checks that several capabilities refer to the same object.  The
+joining vat sends one Join message per capability, over the connection
+that the capability is imported from, and each part is answered with an
+rpctwoparty.JoinResult.
+
+On a connection without a VatNetwork, the joins are those described for
+the two-party network in rpc-twoparty.capnp: all the parts are sent on
+the same connection, and their key parts are rpctwoparty.JoinKeyPart
+structs.  The receiving vat collects the parts, and once it has all of
+them, resolves the targeted capabilities and compares them.  If they
+are not the same, but are all imported from a single other connection,
+then the join is forwarded to that connection as a whole.  Every part
+is answered once the join completes; the first part's result carries
+the joined capability.
+
+On the connections of a VatNetwork, the capabilities may be imported
+from different vats that proxy them, and the key parts are made by the
+network.  A vat that receives a part whose target is imported over
+another connection of the network forwards the part there, and relays
+the answer.  The parts thus meet at the vat hosting the target, which
+uses the network to find its connection to the joining vat and
+collects the parts on it.  Since the other parts may have gone to other
+vats, it answers each part as soon as it arrives with a failed result,
+except for the last one: that part's result says whether the
+capabilities are the same, and carries the joined capability.  A vat
+that only receives some of the parts of a join keeps them until its
+connection to the joining vat closes.
+*/
+
+// Join asks the vats hosting the given clients whether they refer to
+// the same object.  If they do, Join returns a client for that object.
+// The caller is responsible for releasing the returned client.
+//
+// The clients must all be imported over c or, if c belongs to a
+// VatNetwork, over connections of the same network: Join fails for
+// capabilities that are hosted locally.
+func (c *Conn) Join(ctx context.Context, clients ...capnp.Client) (capnp.Client, error) {
+	joined, ok, err := c.join(ctx, clients)
+	if err != nil {
+		return capnp.Client{}, err
+	}
+	if !ok {
+		return capnp.Client{}, rpcerr.Failedf("join: capabilities are not the same")
+	}
+	return joined, nil
+}
+
+// join performs a join on the remote vats.  It reports whether the
+// clients refer to the same object; an error is returned only if the
+// join could not be performed.
+//
+// The caller MUST NOT hold c.lk.
+func (c *Conn) join(ctx context.Context, clients []capnp.Client) (_ capnp.Client, ok bool, _ error) {
+	if len(clients) == 0 {
+		return capnp.Client{}, false, rpcerr.Failedf("join: no capabilities")
+	}
+	if len(clients) > math.MaxUint16 {
+		return capnp.Client{}, false, rpcerr.Failedf("join: too many capabilities")
+	}
+	imports := make([]*importClient, len(clients))
+	for i, client := range clients {
+		if err := client.Resolve(ctx); err != nil {
+			return capnp.Client{}, false, rpcerr.Annotate(err, "join")
+		}
+		ic, ok := client.State().Brand.Value.(*importClient)
+		if !ok || (ic.c != c && !c.isIntroducer(ic.c)) {
+			return capnp.Client{}, false, rpcerr.Failedf("join: capability %d is not imported from this connection or its vat network", i)
+		}
+		imports[i] = ic
+	}
+	if sameImport(imports) {
+		// No need to ask the remote vat.
+		return clients[0].AddRef(), true, nil
+	}
+
+	var (
+		joinID   uint32
+		keyParts []capnp.Ptr
+		err      error
+	)
+	if c.network != nil {
+		var kps []JoinKeyPart
+		joinID, kps, err = c.network.NewJoinKeyParts(uint16(len(imports)))
+		if err == nil && len(kps) != len(imports) {
+			err = errors.New("wrong number of key parts")
+		}
+		for _, kp := range kps {
+			keyParts = append(keyParts, capnp.Ptr(kp))
+		}
+	} else {
+		syncutil.With(&c.lk, func() {
+			joinID = c.lk.joinID
+			c.lk.joinID++
+		})
+		keyParts, err = twoPartyKeyParts(joinID, uint16(len(imports)))
+	}
+	if err != nil {
+		return capnp.Client{}, false, rpcerr.Failedf("join: new key parts: %w", err)
+	}
+
+	qs := make([]*question, 0, len(imports))
+	for i, ic := range imports {
+		var q *question
+		syncutil.With(&ic.c.lk, func() {
+			if !ic.c.startTask() {
+				return
+			}
+			defer ic.c.tasks.Done()
+			q = ic.c.sendJoin(ctx, ic.id, keyParts[i])
+		})
+		if q == nil {
+			// The parts already sent may wait for the missing one
+			// until ctx is done.
+			go releaseJoinQuestions(qs)
+			return capnp.Client{}, false, ExcClosed
+		}
+		qs = append(qs, q)
+	}
+	defer releaseJoinQuestions(qs)
+
+	var (
+		joined    capnp.Client
+		succeeded int
+	)
+	for i, q := range qs {
+		r, client, err := joinResult(q)
+		if err != nil {
+			joined.Release()
+			return capnp.Client{}, false, rpcerr.Annotatef(err, "join: part %d", i)
+		}
+		if r.JoinId() != joinID {
+			joined.Release()
+			return capnp.Client{}, false, rpcerr.Failedf("join: part %d returned result for join %d; want %d", i, r.JoinId(), joinID)
+		}
+		if r.Succeeded() {
+			succeeded++
+		}
+		if client.IsValid() && !joined.IsValid() {
+			joined = client.AddRef()
+		}
+	}
+	// Over a vat network, only the last part to reach the host has
+	// the outcome of the join.
+	if succeeded == 0 || (c.network == nil && succeeded < len(qs)) {
+		joined.Release()
+		return capnp.Client{}, false, nil
+	}
+	if !joined.IsValid() {
+		return capnp.Client{}, false, rpcerr.Failedf("join: no capability returned")
+	}
+	return joined, true, nil
+}
+
+func sameImport(imports []*importClient) bool {
+	for _, ic := range imports[1:] {
+		if ic.c != imports[0].c || ic.id != imports[0].id {
+			return false
+		}
+	}
+	return true
+}
+
+// twoPartyKeyParts returns the key parts of a join sent over a
+// connection without a VatNetwork.
+func twoPartyKeyParts(joinID uint32, partCount uint16) ([]capnp.Ptr, error) {
+	_, seg, err := capnp.NewMessage(capnp.SingleSegment(nil))
+	if err != nil {
+		return nil, err
+	}
+	parts := make([]capnp.Ptr, partCount)
+	for i := range parts {
+		kp, err := rpctwoparty.NewJoinKeyPart(seg)
+		if err != nil {
+			return nil, err
+		}
+		kp.SetJoinId(joinID)
+		kp.SetPartCount(partCount)
+		kp.SetPartNum(uint16(i))
+		parts[i] = kp.ToPtr()
+	}
+	return parts, nil
+}
+
+// sendJoin sends a Join message for one part of a join.
+//
+// The caller MUST hold c.lk.
+func (c *Conn) sendJoin(ctx context.Context, id importID, keyPart capnp.Ptr) *question {
+	q := c.newQuestion(capnp.Method{})
+	c.sendMessage(ctx, func(m rpccp.Message) error {
+		j, err := m.NewJoin()
+		if err != nil {
+			return err
+		}
+		j.SetQuestionId(uint32(q.id))
+		tgt, err := j.NewTarget()
+		if err != nil {
+			return err
+		}
+		tgt.SetImportedCap(uint32(id))
+		return j.SetKeyPart(keyPart)
+	}, func(err error) {
+		if err != nil {
+			syncutil.With(&c.lk, func() {
+				c.removeQuestion(q.id)
+			})
+			q.p.Reject(rpcerr.Failedf("send join: %w", err))
+			syncutil.With(&c.lk, func() {
+				c.lk.questionID.remove(uint32(q.id))
+			})
+			return
+		}
+
+		c.tasks.Add(1)
+		go func() {
+			defer c.tasks.Done()
+			q.handleCancel(ctx)
+		}()
+	})
+	return q
+}
+
+// joinResult waits for the answer to a Join message.  The returned
+// client belongs to q's results.
+func joinResult(q *question) (rpctwoparty.JoinResult, capnp.Client, error) {
+	s, err := q.p.Answer().Struct()
+	if err != nil {
+		return rpctwoparty.JoinResult{}, capnp.Client{}, err
+	}
+	r := rpctwoparty.JoinResult(s)
+	p, err := r.Cap()
+	if err != nil {
+		return rpctwoparty.JoinResult{}, capnp.Client{}, rpcerr.Failedf("read join result: %w", err)
+	}
+	return r, p.Interface().Client(), nil
+}
+
+// releaseJoinQuestions waits for the answers to Join messages and
+// releases them.
+func releaseJoinQuestions(qs []*question) {
+	for _, q := range qs {
+		<-q.p.Answer().Done()
+		q.p.ReleaseClients()
+		q.release()
+	}
+}
+
+// A pendingJoin collects the parts of a join requested by the remote
+// vat.  All fields are protected by Conn.lk.
+type pendingJoin struct {
+	id      uint32
+	parts   []joinPart // indexed by part number
+	pending int        // number of parts not received yet
+}
+
+// A joinPart is a part of a join received in a Join message, perhaps
+// over another connection than the one it is collected on.
+type joinPart struct {
+	ans      *answer      // nil once answered
+	client   capnp.Client // the target of the Join message
+	received bool
+}
+
+// fail answers the parts received so far with an exception, and
+// releases their targets.
+//
+// The caller MUST NOT hold the lock of any connection.
+func (j *pendingJoin) fail(err error) {
+	for _, part := range j.parts {
+		if part.received {
+			part.answer(j.id, false, capnp.Client{}, err)
+		}
+	}
+}
+
+// answer sends the result of the join to the part, or err if it is not
+// nil, unless the part has been answered already, and releases the
+// part's target.  answer takes ownership of result.
+//
+// The caller MUST NOT hold the lock of any connection.
+func (part joinPart) answer(joinID uint32, ok bool, result capnp.Client, err error) {
+	rl := &releaseList{}
+	defer rl.Release()
+	rl.Add(part.client.Release)
+	if part.ans == nil {
+		rl.Add(result.Release)
+		return
+	}
+
+	c := part.ans.c
+	syncutil.With(&c.lk, func() {
+		if !c.startTask() {
+			rl.Add(result.Release)
+			return
+		}
+		defer c.tasks.Done()
+
+		if err != nil {
+			part.ans.sendException(rl, err)
+			rl.Add(result.Release)
+			return
+		}
+		if err := part.ans.setJoinResult(joinID, ok, result); err != nil {
+			part.ans.sendException(rl, err)
+			return
+		}
+		if err := part.ans.sendReturn(rl); err != nil {
+			c.er.ReportError(rpcerr.Annotate(err, "send join return"))
+		}
+	})
+}
+
+// handleJoin handles a Join message.  On a connection without a
+// VatNetwork, the join is performed once all of its parts have been
+// received; otherwise the part is routed toward the vat hosting its
+// target.
+func (c *Conn) handleJoin(ctx context.Context, in rpccp.Join, release capnp.ReleaseFunc) error {
+	rl := &releaseList{}
+	defer rl.Release()
+
+	id := answerID(in.QuestionId())
+	var (
+		tgt     parsedMessageTarget
+		key     JoinKey
+		keyPart capnp.Ptr // the key part of a join over a vat network
+		err     error
+	)
+	if t, e := in.Target(); e != nil {
+		err = rpcerr.Failedf("read target: %w", e)
+	} else {
+		err = parseMessageTarget(&tgt, t)
+	}
+	if err == nil {
+		var p capnp.Ptr
+		if p, err = in.KeyPart(); err != nil {
+			err = rpcerr.Failedf("read key part: %w", err)
+		} else if c.network != nil {
+			if keyPart, err = copyPtr(p); err != nil {
+				err = rpcerr.Failedf("read key part: %w", err)
+			}
+		} else {
+			kp := rpctwoparty.JoinKeyPart(p.Struct())
+			key = JoinKey{ID: kp.JoinId(), PartCount: kp.PartCount(), PartNum: kp.PartNum()}
+			if key.PartNum >= key.PartCount {
+				err = rpcerr.Failedf("invalid key part %d of %d", key.PartNum, key.PartCount)
+			}
+		}
+	}
+	release()
+
+	ans := &answer{c: c, id: id}
+	var retErr error
+	ans.ret, ans.sendMsg, ans.msgReleaser, retErr = c.newReturn()
+	if retErr == nil {
+		ans.ret.SetAnswerId(uint32(id))
+		ans.ret.SetReleaseParamCaps(false)
+	}
+
+	c.lk.Lock()
+	defer c.lk.Unlock()
+
+	if c.lk.answers[id] != nil {
+		if retErr == nil {
+			rl.Add(ans.msgReleaser.Decr)
+		}
+		return rpcerr.Failedf("incoming join: answer ID %d reused", id)
+	}
+	if retErr != nil {
+		retErr = rpcerr.Annotate(retErr, "incoming join")
+		c.lk.answers[id] = errorAnswer(c, id, retErr)
+		c.er.ReportError(retErr)
+		return nil
+	}
+	reject, abort := c.checkIncomingQuestion()
+	if abort != nil {
+		rl.Add(ans.msgReleaser.Decr)
+		return rpcerr.Annotate(abort, "incoming join")
+	}
+	c.lk.answers[id] = ans
+	if reject != nil {
+		ans.sendException(rl, reject)
+		return nil
+	}
+	if err != nil {
+		err = rpcerr.Annotate(err, "incoming join")
+		ans.sendException(rl, err)
+		c.er.ReportError(err)
+		return nil
+	}
+
+	var client capnp.Client
+	switch tgt.which {
+	case rpccp.MessageTarget_Which_importedCap:
+		ent := c.findExport(tgt.importedCap)
+		if ent == nil {
+			err = rpcerr.Failedf("incoming join: unknown export ID %d", tgt.importedCap)
+			ans.sendException(rl, err)
+			c.er.ReportError(err)
+			return nil
+		}
+		client = ent.client.AddRef()
+	case rpccp.MessageTarget_Which_promisedAnswer:
+		tgtAns := c.lk.answers[tgt.promisedAnswer]
+		if tgtAns == nil || tgtAns.flags.Contains(finishReceived) {
+			err = rpcerr.Failedf("incoming join: use of unknown or finished answer ID %d for promised answer target", tgt.promisedAnswer)
+			ans.sendException(rl, err)
+			c.er.ReportError(err)
+			return nil
+		}
+		client = c.recvCapReceiverAnswer(tgtAns, tgt.transform)
+	}
+
+	part := joinPart{ans: ans, client: client, received: true}
+	if c.network == nil {
+		c.addJoinPart(rl, key, part)
+		return nil
+	}
+	if !c.startTask() {
+		ans.sendException(rl, ExcClosed)
+		rl.Add(client.Release)
+		return nil
+	}
+	go func() {
+		defer c.tasks.Done()
+		c.routeJoinPart(part, JoinKeyPart(keyPart))
+	}()
+	return nil
+}
+
+// addJoinPart adds a part to the join requested by the remote vat that
+// it belongs to, and completes the join if it was the last part.  The
+// parts to answer right away are answered by rl.
+//
+// The caller MUST hold c.lk.
+func (c *Conn) addJoinPart(rl *releaseList, key JoinKey, part joinPart) {
+	if c.lk.joins == nil {
+		rl.Add(func() {
+			part.answer(key.ID, false, capnp.Client{}, ExcClosed)
+		})
+		return
+	}
+	j := c.lk.joins[key.ID]
+	if j == nil {
+		j = &pendingJoin{
+			id:      key.ID,
+			parts:   make([]joinPart, key.PartCount),
+			pending: int(key.PartCount),
+		}
+		c.lk.joins[key.ID] = j
+	}
+	if len(j.parts) != int(key.PartCount) || j.parts[key.PartNum].received {
+		// Fail the whole join, so that the remote vat does not wait
+		// for the other parts forever.
+		err := rpcerr.Failedf("incoming join: part %d of %d does not match join %d", key.PartNum, key.PartCount, key.ID)
+		delete(c.lk.joins, key.ID)
+		rl.Add(func() {
+			j.fail(err)
+			part.answer(key.ID, false, capnp.Client{}, err)
+		})
+		return
+	}
+	j.parts[key.PartNum] = part
+	j.pending--
+	if j.pending > 0 {
+		if c.network != nil {
+			// The other parts may never reach this vat.
+			ans := part.ans
+			j.parts[key.PartNum].ans = nil
+			rl.Add(func() {
+				joinPart{ans: ans}.answer(key.ID, false, capnp.Client{}, nil)
+			})
+		}
+		return
+	}
+
+	delete(c.lk.joins, key.ID)
+	if !c.startTask() {
+		rl.Add(func() {
+			j.fail(ExcClosed)
+		})
+		return
+	}
+	go func() {
+		defer c.tasks.Done()
+		c.completeJoin(j)
+	}()
+}
+
+// routeJoinPart forwards a part of a join over a vat network toward
+// the vat hosting its target, or, if it is this vat, collects it on the
+// connection to the vat that started the join.
+//
+// The caller MUST NOT hold c.lk.
+func (c *Conn) routeJoinPart(part joinPart, kp JoinKeyPart) {
+	if err := part.client.Resolve(c.bgctx); err != nil {
+		part.answer(0, false, capnp.Client{}, rpcerr.Annotate(err, "incoming join"))
+		return
+	}
+	if ic, ok := part.client.State().Brand.Value.(*importClient); ok && c.isIntroducer(ic.c) {
+		c.forwardJoinPart(part, ic, kp)
+		return
+	}
+
+	joiner, key, err := c.network.AcceptJoinKeyPart(c.bgctx, kp, c)
+	if err == nil && key.PartNum >= key.PartCount {
+		err = rpcerr.Failedf("invalid key part %d of %d", key.PartNum, key.PartCount)
+	}
+	if err != nil {
+		part.answer(0, false, capnp.Client{}, rpcerr.Annotate(err, "incoming join: accept key part"))
+		return
+	}
+	rl := &releaseList{}
+	defer rl.Release()
+	syncutil.With(&joiner.lk, func() {
+		joiner.addJoinPart(rl, key, part)
+	})
+}
+
+// forwardJoinPart forwards a part of a join to the vat that its target
+// is imported from, and relays the answer.
+//
+// The caller MUST NOT hold c.lk or ic.c.lk.
+func (c *Conn) forwardJoinPart(part joinPart, ic *importClient, kp JoinKeyPart) {
+	var q *question
+	syncutil.With(&ic.c.lk, func() {
+		if !ic.c.startTask() {
+			return
+		}
+		defer ic.c.tasks.Done()
+		q = ic.c.sendJoin(c.bgctx, ic.id, capnp.Ptr(kp))
+	})
+	if q == nil {
+		part.answer(0, false, capnp.Client{}, ExcClosed)
+		return
+	}
+	defer releaseJoinQuestions([]*question{q})
+
+	r, result, err := joinResult(q)
+	if err != nil {
+		part.answer(0, false, capnp.Client{}, rpcerr.Annotate(err, "forward join"))
+		return
+	}
+	part.answer(r.JoinId(), r.Succeeded(), result.AddRef(), nil)
+}
+
+// completeJoin performs a join requested by the remote vat, once all
+// its parts have been received, and answers the parts that are still
+// waiting.  The first of them carries the joined capability.
+//
+// The caller MUST NOT hold the lock of any connection.
+func (c *Conn) completeJoin(j *pendingJoin) {
+	clients := make([]capnp.Client, len(j.parts))
+	for i, part := range j.parts {
+		clients[i] = part.client
+	}
+	joined, ok, err := c.joinClients(c.bgctx, clients)
+	for _, part := range j.parts {
+		var result capnp.Client
+		if part.ans != nil {
+			result, joined = joined, capnp.Client{}
+		}
+		part.answer(j.id, ok, result, err)
+	}
+	joined.Release()
+}
+
+// joinClients checks whether clients refer to the same object.  The
+// returned client is a new reference to the joined object.
+//
+// The caller MUST NOT hold c.lk.
+func (c *Conn) joinClients(ctx context.Context, clients []capnp.Client) (_ capnp.Client, ok bool, _ error) {
+	for _, client := range clients {
+		if err := client.Resolve(ctx); err != nil {
+			return capnp.Client{}, false, rpcerr.Annotate(err, "join")
+		}
+	}
+	same := true
+	for _, client := range clients[1:] {
+		if !client.IsSame(clients[0]) {
+			same = false
+			break
+		}
+	}
+	if same {
+		return clients[0].AddRef(), true, nil
+	}
+
+	// The clients may be proxies for capabilities imported over another
+	// connection, in which case the join is forwarded there as a whole.
+	ic, ok := clients[0].State().Brand.Value.(*importClient)
+	if !ok || ic.c == c {
+		return capnp.Client{}, false, nil
+	}
+	for _, client := range clients[1:] {
+		if ic2, ok := client.State().Brand.Value.(*importClient); !ok || ic2.c != ic.c {
+			return capnp.Client{}, false, nil
+		}
+	}
+	return ic.c.join(ctx, clients)
+}
+
+// setJoinResult fills in the results of an answer to a Join message.
+// setJoinResult "steals" the client.
+func (ans *answer) setJoinResult(joinID uint32, succeeded bool, client capnp.Client) error {
+	if client.IsValid() {
+		// Add the capability to the table early to avoid leaks if
+		// setJoinResult fails.
+		ans.ret.Message().CapTable = []capnp.Client{client}
+	}
+
+	var err error
+	ans.results, err = ans.ret.NewResults()
+	if err != nil {
+		return rpcerr.Failedf("alloc join results: %w", err)
+	}
+	r, err := rpctwoparty.NewJoinResult(ans.results.Segment())
+	if err != nil {
+		return rpcerr.Failedf("alloc join results: %w", err)
+	}
+	r.SetJoinId(joinID)
+	r.SetSucceeded(succeeded)
+	if client.IsValid() {
+		iface := capnp.NewInterface(ans.results.Segment(), 0)
+		if err := r.SetCap(iface.ToPtr()); err != nil {
+			return rpcerr.Failedf("alloc join results: %w", err)
+		}
+	}
+	if err := ans.results.SetContent(r.ToPtr()); err != nil {
+		return rpcerr.Failedf("alloc join results: %w", err)
+	}
+	return nil
+}
+
+// failJoins fails the joins that are still waiting for parts.  Called
+// by 'shutdown'.  Caller MUST hold c.lk.
+func (c *Conn) failJoins(rl *releaseList, joins map[uint32]*pendingJoin) {
+	for _, j := range joins {
+		j := j
+		rl.Add(func() {
+			j.fail(ExcClosed)
+		})
+	}
+}
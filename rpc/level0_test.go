@@ -1446,11 +1446,13 @@ func TestRecvCancel(t *testing.T) {
 	t.Parallel()
 
 	callCancel := make(chan struct{})
+	var cause error
 	retcapShutdown := make(chan struct{})
 	srv := newServer(func(ctx context.Context, call *server.Call) error {
 		// Wait until canceled
 		call.Go()
 		<-ctx.Done()
+		cause = context.Cause(ctx)
 		close(callCancel)
 
 		// Return a capability
@@ -1580,6 +1582,9 @@ func TestRecvCancel(t *testing.T) {
 		}
 	}
 	<-callCancel
+	if !errors.Is(cause, rpc.ErrCallFinished) {
+		t.Errorf("context.Cause(ctx) = %v; want %v", cause, rpc.ErrCallFinished)
+	}
 
 	// 6. Read call return
 	{
@@ -1613,6 +1618,115 @@ func TestRecvCancel(t *testing.T) {
 	}
 }
 
+// TestRecvCancelOnShutdown makes a call, then shuts down the connection
+// before it returns, checking that the call's Context is canceled with
+// a cause that says why.  Level 0 requirement.
+func TestRecvCancelOnShutdown(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Disconnect", func(t *testing.T) {
+		t.Parallel()
+
+		cause := testRecvCancelOnShutdown(t, func(p2 rpc.Transport) {
+			p2.Close()
+		})
+		if !errors.Is(cause, rpc.ErrConnClosed) {
+			t.Errorf("context.Cause(ctx) = %v; want an error wrapping %v", cause, rpc.ErrConnClosed)
+		}
+	})
+	t.Run("Abort", func(t *testing.T) {
+		t.Parallel()
+
+		cause := testRecvCancelOnShutdown(t, func(p2 rpc.Transport) {
+			err := sendMessage(context.Background(), p2, &rpcMessage{
+				Which: rpccp.Message_Which_abort,
+				Abort: &rpcException{
+					Type:   rpccp.Exception_Type_failed,
+					Reason: "over it",
+				},
+			})
+			if err != nil {
+				t.Error(err)
+			}
+		})
+		if !errors.Is(cause, rpc.ErrRemoteAbort) {
+			t.Errorf("context.Cause(ctx) = %v; want %v", cause, rpc.ErrRemoteAbort)
+		}
+		if cause == nil || !strings.Contains(cause.Error(), "over it") {
+			t.Errorf("context.Cause(ctx) = %v; want to contain abort reason", cause)
+		}
+	})
+}
+
+// testRecvCancelOnShutdown makes a call on the bootstrap capability of a
+// connection, calls shutdown once the call has started, and returns the
+// cause with which the call's Context was canceled.
+func testRecvCancelOnShutdown(t *testing.T, shutdown func(p2 rpc.Transport)) error {
+	callStart := make(chan struct{})
+	callCancel := make(chan error, 1)
+	srv := newServer(func(ctx context.Context, call *server.Call) error {
+		close(callStart)
+		<-ctx.Done()
+		callCancel <- context.Cause(ctx)
+		return ctx.Err()
+	}, nil)
+	left, right := transport.NewPipe(1)
+	p1, p2 := rpc.NewTransport(left), rpc.NewTransport(right)
+	defer p2.Close()
+
+	conn := rpc.NewConn(p1, &rpc.Options{
+		BootstrapClient: srv,
+	})
+	defer conn.Close()
+	ctx := context.Background()
+
+	// 1. Write bootstrap and call
+	const bootstrapQID = 54
+	const callQID = 55
+	{
+		err := sendMessage(ctx, p2, &rpcMessage{
+			Which:     rpccp.Message_Which_bootstrap,
+			Bootstrap: &rpcBootstrap{QuestionID: bootstrapQID},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = sendMessage(ctx, p2, &rpcMessage{
+			Which: rpccp.Message_Which_call,
+			Call: &rpcCall{
+				QuestionID: callQID,
+				Target: rpcMessageTarget{
+					Which: rpccp.MessageTarget_Which_promisedAnswer,
+					PromisedAnswer: &rpcPromisedAnswer{
+						QuestionID: bootstrapQID,
+					},
+				},
+				InterfaceID: interfaceID,
+				MethodID:    methodID,
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 2. Shut down the connection once the call has started.
+	select {
+	case <-callStart:
+	case <-time.After(5 * time.Second):
+		t.Fatal("call not started")
+	}
+	shutdown(p2)
+
+	select {
+	case cause := <-callCancel:
+		return cause
+	case <-time.After(5 * time.Second):
+		t.Fatal("call context not canceled")
+		return nil
+	}
+}
+
 // TestSendCancel makes a call, cancels the Context, then checks to
 // see whether a finish message was sent.  Level 0 requirement.
 func TestSendCancel(t *testing.T) {
@@ -1916,6 +2030,24 @@ type errorfer interface {
 	Errorf(string, ...any)
 }
 
+// newTestConns connects a Conn created with serverOpts to one created
+// with clientOpts over a pipe, and closes both when the test ends.
+// Either Options may be nil.
+func newTestConns(t *testing.T, serverOpts, clientOpts *rpc.Options) (server, client *rpc.Conn) {
+	left, right := transport.NewPipe(1)
+	server = rpc.NewConn(rpc.NewTransport(left), serverOpts)
+	client = rpc.NewConn(rpc.NewTransport(right), clientOpts)
+	t.Cleanup(func() {
+		if err := client.Close(); err != nil {
+			t.Error("client.Close():", err)
+		}
+		if err := server.Close(); err != nil {
+			t.Error("server.Close():", err)
+		}
+	})
+	return server, client
+}
+
 func newServer(impl func(context.Context, *server.Call) error, shutdown shutdownFunc) capnp.Client {
 	var methods []server.Method
 	if impl != nil {
@@ -1950,6 +2082,8 @@ type rpcMessage struct {
 	Resolve       *rpcResolve
 	Release       *rpcRelease
 	Disembargo    *rpcDisembargo
+	Provide       *rpcProvide
+	Join          *rpcJoin
 }
 
 func sendMessage(ctx context.Context, t rpc.Transport, msg *rpcMessage) error {
@@ -1997,7 +2131,8 @@ type rpcException struct {
 }
 
 type rpcBootstrap struct {
-	QuestionID uint32 `capnp:"questionId"`
+	QuestionID         uint32    `capnp:"questionId"`
+	DeprecatedObjectID capnp.Ptr `capnp:"deprecatedObjectId"`
 }
 
 type rpcCall struct {
@@ -2041,11 +2176,12 @@ type rpcPayload struct {
 }
 
 type rpcCapDescriptor struct {
-	Which          rpccp.CapDescriptor_Which
-	SenderHosted   uint32
-	SenderPromise  uint32
-	ReceiverHosted uint32
-	ReceiverAnswer *rpcPromisedAnswer
+	Which            rpccp.CapDescriptor_Which
+	SenderHosted     uint32
+	SenderPromise    uint32
+	ReceiverHosted   uint32
+	ReceiverAnswer   *rpcPromisedAnswer
+	ThirdPartyHosted *rpcThirdPartyCapDescriptor
 }
 
 type rpcPromisedAnswer struct {
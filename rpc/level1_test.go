@@ -2,12 +2,18 @@ package rpc_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"capnproto.org/go/capnp/v3"
+	"capnproto.org/go/capnp/v3/exc"
 	"capnproto.org/go/capnp/v3/pogs"
 	"capnproto.org/go/capnp/v3/rpc"
+	testcp "capnproto.org/go/capnp/v3/rpc/internal/testcapnp"
 	"capnproto.org/go/capnp/v3/rpc/transport"
 	"capnproto.org/go/capnp/v3/server"
 	rpccp "capnproto.org/go/capnp/v3/std/capnp/rpc"
@@ -18,7 +24,6 @@ func TestSendDisembargo(t *testing.T) {
 		testSendDisembargo(t, rpccp.Call_sendResultsTo_Which_caller)
 	})
 	t.Run("SendQueuedResultToYourself", func(t *testing.T) {
-		t.Skip("TODO(soon): not implemented")
 		testSendDisembargo(t, rpccp.Call_sendResultsTo_Which_yourself)
 	})
 }
@@ -776,6 +781,385 @@ func TestRecvDisembargo(t *testing.T) {
 	}
 }
 
+// TestSendTailCall exposes a capability that tail-calls its received
+// capability argument, then checks that the call is sent with
+// sendResultsTo.yourself, that the original call returns with
+// takeFromOtherQuestion, and that the tail call is only finished once
+// the original call is.  Level 1 requirement.
+func TestSendTailCall(t *testing.T) {
+	const tailMethodID = methodID + 1
+	srv := newServer(func(ctx context.Context, call *server.Call) error {
+		capPtr, err := call.Args().Ptr(0)
+		if err != nil {
+			return err
+		}
+		return call.TailCall(capPtr.Interface().Client(), capnp.Send{
+			Method: capnp.Method{
+				InterfaceID: interfaceID,
+				MethodID:    tailMethodID,
+			},
+			ArgsSize: capnp.ObjectSize{DataSize: 8},
+			PlaceArgs: func(s capnp.Struct) error {
+				s.SetUint64(0, 42)
+				return nil
+			},
+		})
+	}, nil)
+	left, right := transport.NewPipe(1)
+	p1, p2 := rpc.NewTransport(left), rpc.NewTransport(right)
+
+	conn := rpc.NewConn(p1, &rpc.Options{
+		BootstrapClient: srv,
+		ErrorReporter:   testErrorReporter{tb: t},
+	})
+	defer finishTest(t, conn, p2)
+	ctx := context.Background()
+
+	// 1. Bootstrap.
+	const bootstrapQID = 0
+	{
+		err := sendMessage(ctx, p2, &rpcMessage{
+			Which:     rpccp.Message_Which_bootstrap,
+			Bootstrap: &rpcBootstrap{QuestionID: bootstrapQID},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	bootstrapImportID, err := recvBootstrapReturn(ctx, p2, bootstrapQID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	{
+		err := sendMessage(ctx, p2, &rpcMessage{
+			Which:  rpccp.Message_Which_finish,
+			Finish: &rpcFinish{QuestionID: bootstrapQID},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 2. Call bootstrap with an exported capability.
+	const callQID = 1
+	const exportID = 7
+	{
+		outMsg, err := p2.NewMessage()
+		if err != nil {
+			t.Fatal("p2.NewMessage():", err)
+		}
+		params, err := capnp.NewStruct(outMsg.Message.Segment(), capnp.ObjectSize{PointerCount: 1})
+		if err != nil {
+			outMsg.Release()
+			t.Fatal("capnp.NewStruct:", err)
+		}
+		params.SetPtr(0, capnp.NewInterface(params.Segment(), 0).ToPtr())
+		err = pogs.Insert(rpccp.Message_TypeID, capnp.Struct(outMsg.Message), &rpcMessage{
+			Which: rpccp.Message_Which_call,
+			Call: &rpcCall{
+				QuestionID: callQID,
+				Target: rpcMessageTarget{
+					Which:       rpccp.MessageTarget_Which_importedCap,
+					ImportedCap: bootstrapImportID,
+				},
+				InterfaceID: interfaceID,
+				MethodID:    methodID,
+				Params: rpcPayload{
+					Content: params.ToPtr(),
+					CapTable: []rpcCapDescriptor{{
+						Which:        rpccp.CapDescriptor_Which_senderHosted,
+						SenderHosted: exportID,
+					}},
+				},
+			},
+		})
+		if err != nil {
+			outMsg.Release()
+			t.Fatal("pogs.Insert(p2.NewMessage(), &rpcMessage{...}):", err)
+		}
+		err = outMsg.Send()
+		outMsg.Release()
+		if err != nil {
+			t.Fatal("send():", err)
+		}
+	}
+
+	// 3. Read the tail call, then the return.
+	var tailQID uint32
+	{
+		rmsg, release, err := recvMessage(ctx, p2)
+		if err != nil {
+			t.Fatal("recvMessage(ctx, p2):", err)
+		}
+		if rmsg.Which != rpccp.Message_Which_call {
+			release()
+			t.Fatalf("Received %v message; want call", rmsg.Which)
+		}
+		tailQID = rmsg.Call.QuestionID
+		if rmsg.Call.Target.Which != rpccp.MessageTarget_Which_importedCap || rmsg.Call.Target.ImportedCap != exportID {
+			t.Errorf("call.target = %v %d; want importedCap %d", rmsg.Call.Target.Which, rmsg.Call.Target.ImportedCap, exportID)
+		}
+		if rmsg.Call.InterfaceID != interfaceID || rmsg.Call.MethodID != tailMethodID {
+			t.Errorf("call method = @%#x.%d; want @%#x.%d", rmsg.Call.InterfaceID, rmsg.Call.MethodID, uint64(interfaceID), tailMethodID)
+		}
+		if rmsg.Call.SendResultsTo.Which != rpccp.Call_sendResultsTo_Which_yourself {
+			t.Errorf("call.sendResultsTo which = %v; want yourself", rmsg.Call.SendResultsTo.Which)
+		}
+		if got := rmsg.Call.Params.Content.Struct().Uint64(0); got != 42 {
+			t.Errorf("call params = %d; want 42", got)
+		}
+		release()
+	}
+	for {
+		rmsg, release, err := recvMessage(ctx, p2)
+		if err != nil {
+			t.Fatal("recvMessage(ctx, p2):", err)
+		}
+		if rmsg.Which == rpccp.Message_Which_release {
+			// Release of the capability argument.
+			release()
+			continue
+		}
+		if rmsg.Which != rpccp.Message_Which_return {
+			release()
+			t.Fatalf("Received %v message; want return", rmsg.Which)
+		}
+		if rmsg.Return.AnswerID != callQID {
+			t.Errorf("Received return for answer %d; want %d", rmsg.Return.AnswerID, callQID)
+		}
+		if rmsg.Return.Which != rpccp.Return_Which_takeFromOtherQuestion {
+			t.Errorf("return which = %v; want takeFromOtherQuestion", rmsg.Return.Which)
+		} else if rmsg.Return.TakeFromOtherQuestion != tailQID {
+			t.Errorf("return takeFromOtherQuestion = %d; want %d", rmsg.Return.TakeFromOtherQuestion, tailQID)
+		}
+		release()
+		break
+	}
+
+	// 4. Return the tail call to ourselves.
+	{
+		err := sendMessage(ctx, p2, &rpcMessage{
+			Which: rpccp.Message_Which_return,
+			Return: &rpcReturn{
+				AnswerID: tailQID,
+				Which:    rpccp.Return_Which_resultsSentElsewhere,
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 5. The tail call must not be finished before the original call.
+	// Bootstrap again: any finish sent on receiving the tail call's
+	// return would arrive before the bootstrap's return.
+	const syncQID = 2
+	{
+		err := sendMessage(ctx, p2, &rpcMessage{
+			Which:     rpccp.Message_Which_bootstrap,
+			Bootstrap: &rpcBootstrap{QuestionID: syncQID},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	for {
+		rmsg, release, err := recvMessage(ctx, p2)
+		if err != nil {
+			t.Fatal("recvMessage(ctx, p2):", err)
+		}
+		w := rmsg.Which
+		release()
+		if w == rpccp.Message_Which_release {
+			continue
+		}
+		if w != rpccp.Message_Which_return {
+			t.Fatalf("Received %v message before finishing original call; want return", w)
+		}
+		break
+	}
+	{
+		err := sendMessage(ctx, p2, &rpcMessage{
+			Which:  rpccp.Message_Which_finish,
+			Finish: &rpcFinish{QuestionID: syncQID},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The tail call's question stays in the table until then, which
+	// keeps Drain waiting.
+	if n := conn.Usage().Questions; n != 1 {
+		t.Errorf("conn.Usage().Questions = %d after tail call returned; want 1", n)
+	}
+
+	// 6. Finish the original call, then read the tail call's finish.
+	{
+		err := sendMessage(ctx, p2, &rpcMessage{
+			Which:  rpccp.Message_Which_finish,
+			Finish: &rpcFinish{QuestionID: callQID},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	for {
+		rmsg, release, err := recvMessage(ctx, p2)
+		if err != nil {
+			t.Fatal("recvMessage(ctx, p2):", err)
+		}
+		w := rmsg.Which
+		var qid uint32
+		if rmsg.Finish != nil {
+			qid = rmsg.Finish.QuestionID
+		}
+		release()
+		if w == rpccp.Message_Which_release {
+			continue
+		}
+		if w != rpccp.Message_Which_finish {
+			t.Fatalf("Received %v message; want finish", w)
+		}
+		if qid != tailQID {
+			t.Errorf("Received finish for question %d; want %d", qid, tailQID)
+		}
+		break
+	}
+	for deadline := time.Now().Add(5 * time.Second); conn.Usage().Questions != 0; {
+		if time.Now().After(deadline) {
+			t.Fatal("tail call's question still in the table after its finish")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// TestTailCallEmbargo makes pipelined calls on a call that the remote
+// vat answers with a tail call back to this vat, before the Return with
+// takeFromOtherQuestion arrives, and checks that they are delivered
+// before calls made once the results have been taken.  Level 1
+// requirement.
+func TestTailCallEmbargo(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	rec := new(recordingPingPong)
+	local := &forwardingProvider{pp: testcp.PingPong_ServerToClient(rec)}
+	defer local.pp.Release()
+	tail := &tailCallProvider{
+		unblock:  make(chan struct{}),
+		returned: make(chan struct{}),
+	}
+	var returnOnce sync.Once
+	returnTail := func() {
+		returnOnce.Do(func() { close(tail.returned) })
+	}
+	defer returnTail()
+	const n = 10
+	received := &callWaiter{
+		interfaceID: testcp.PingPong_TypeID,
+		want:        n,
+		done:        make(chan struct{}),
+	}
+	server, client := newTestConns(t, &rpc.Options{
+		BootstrapClient: capnp.Client(testcp.PingPongProvider_ServerToClient(tail)),
+		ErrorReporter:   testErrorReporter{tb: t},
+		Observer:        received,
+	}, &rpc.Options{
+		BootstrapClient: capnp.Client(testcp.PingPongProvider_ServerToClient(local)),
+		ErrorReporter:   testErrorReporter{tb: t},
+	})
+	tail.target = server.Bootstrap(ctx)
+	defer tail.target.Release()
+
+	ppp := testcp.PingPongProvider(client.Bootstrap(ctx))
+	defer ppp.Release()
+	f, release := ppp.PingPong(ctx, nil)
+	defer release()
+
+	pp := f.PingPong()
+	var futures []testcp.PingPong_echoNum_Results_Future
+	for i := int64(0); i < n; i++ {
+		i := i
+		ef, release := pp.EchoNum(ctx, func(p testcp.PingPong_echoNum_Params) error {
+			p.SetN(i)
+			return nil
+		})
+		defer release()
+		futures = append(futures, ef)
+	}
+	// Make the tail call once the remote vat has queued the calls.
+	<-received.done
+	close(tail.unblock)
+
+	if err := capnp.Client(pp).Resolve(ctx); err != nil {
+		t.Fatal("resolve tail call results:", err)
+	}
+	// The method that made the tail call has not returned yet, but the
+	// calls it queued must already be on their way.
+	echoNum(ctx, t, pp, n)
+	returnTail()
+	for i, ef := range futures {
+		res, err := ef.Struct()
+		if err != nil {
+			t.Errorf("pipelined EchoNum(%d): %v", i, err)
+		} else if res.N() != int64(i) {
+			t.Errorf("pipelined EchoNum(%d) = %d", i, res.N())
+		}
+	}
+	rec.checkOrder(t, n+1)
+}
+
+// callWaiter is an Observer that closes done once want calls to
+// interfaceID have been received.
+type callWaiter struct {
+	interfaceID uint64
+
+	mu   sync.Mutex
+	want int
+	done chan struct{}
+}
+
+func (w *callWaiter) Event(rpc.Event) {}
+
+func (w *callWaiter) CallStarted(ctx context.Context, call rpc.CallInfo) context.Context {
+	if !call.Incoming || call.Method.InterfaceID != w.interfaceID {
+		return ctx
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.want--; w.want == 0 {
+		close(w.done)
+	}
+	return ctx
+}
+
+func (w *callWaiter) CallReturned(context.Context, rpc.CallInfo, error, time.Duration) {}
+
+// tailCallProvider answers pingPong with a tail call to the pingPong
+// method of target once unblock is closed, and returns once returned
+// is closed.
+type tailCallProvider struct {
+	target   capnp.Client
+	unblock  chan struct{}
+	returned chan struct{}
+}
+
+func (p *tailCallProvider) PingPong(ctx context.Context, call testcp.PingPongProvider_pingPong) error {
+	<-p.unblock
+	err := call.TailCall(p.target, capnp.Send{
+		Method: capnp.Method{
+			InterfaceID:   testcp.PingPongProvider_TypeID,
+			MethodID:      0,
+			InterfaceName: "test.capnp:PingPongProvider",
+			MethodName:    "pingPong",
+		},
+		ArgsSize: capnp.ObjectSize{},
+	})
+	<-p.returned
+	return err
+}
+
 // TestIssue3 exposes a capability that makes a call to its received
 // capability argument, acks the call, then waits on its return.  In
 // earlier versions of go-capnproto, this would cause a deadlock.
@@ -1032,6 +1416,442 @@ func TestIssue3(t *testing.T) {
 	}
 }
 
+// TestRecvResolveException receives a promise as the bootstrap
+// capability, then receives a Resolve message breaking the promise.
+// The client should resolve, and calls on it should fail with the
+// exception.  Level 1 requirement.
+func TestRecvResolveException(t *testing.T) {
+	left, right := transport.NewPipe(1)
+	p1, p2 := rpc.NewTransport(left), rpc.NewTransport(right)
+
+	conn := rpc.NewConn(p1, &rpc.Options{
+		ErrorReporter: testErrorReporter{tb: t},
+	})
+	defer finishTest(t, conn, p2)
+	ctx := context.Background()
+
+	// 1. Send bootstrap
+	client := conn.Bootstrap(ctx)
+	defer client.Release()
+	var bootQID uint32
+	{
+		msg, release, err := recvMessage(ctx, p2)
+		if err != nil {
+			t.Fatal("recvMessage(ctx, p2):", err)
+		}
+		defer release()
+		if msg.Which != rpccp.Message_Which_bootstrap {
+			t.Fatalf("Received %v message; want bootstrap", msg.Which)
+		}
+		bootQID = msg.Bootstrap.QuestionID
+	}
+
+	// 2. Return a promise
+	const promiseID = 42
+	err := sendBootstrapReturn(ctx, p2, bootQID, rpcCapDescriptor{
+		Which:         rpccp.CapDescriptor_Which_senderPromise,
+		SenderPromise: promiseID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 3. Read bootstrap finish
+	{
+		msg, release, err := recvMessage(ctx, p2)
+		if err != nil {
+			t.Fatal("recvMessage(ctx, p2):", err)
+		}
+		defer release()
+		if msg.Which != rpccp.Message_Which_finish {
+			t.Fatalf("Received %v message; want finish", msg.Which)
+		}
+	}
+
+	// 4. The promise should not resolve on its own.
+	{
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		err := client.Resolve(ctx)
+		cancel()
+		if err == nil {
+			t.Fatal("client resolved before Resolve message was sent")
+		}
+	}
+
+	// 5. Break the promise
+	err = sendMessage(ctx, p2, &rpcMessage{
+		Which: rpccp.Message_Which_resolve,
+		Resolve: &rpcResolve{
+			PromiseID: promiseID,
+			Which:     rpccp.Resolve_Which_exception,
+			Exception: &rpcException{
+				Type:   rpccp.Exception_Type_overloaded,
+				Reason: "promise broken",
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 6. Wait for resolution and make a call
+	{
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		if err := client.Resolve(ctx); err != nil {
+			t.Fatal("client.Resolve:", err)
+		}
+	}
+	ans, release := client.SendCall(ctx, capnp.Send{
+		Method: capnp.Method{
+			InterfaceID: interfaceID,
+			MethodID:    methodID,
+		},
+	})
+	defer release()
+	_, err = ans.Struct()
+	if err == nil {
+		t.Fatal("call on broken promise succeeded")
+	}
+	if !exc.IsType(err, exc.Overloaded) {
+		t.Errorf("call on broken promise error type = %v; want overloaded", exc.TypeOf(err))
+	}
+	if !strings.Contains(err.Error(), "promise broken") {
+		t.Errorf("call on broken promise error = %v; want to contain \"promise broken\"", err)
+	}
+
+	// 7. Read release of the promise
+	{
+		msg, release, err := recvMessage(ctx, p2)
+		if err != nil {
+			t.Fatal("recvMessage(ctx, p2):", err)
+		}
+		defer release()
+		if msg.Which != rpccp.Message_Which_release {
+			t.Fatalf("Received %v message; want release", msg.Which)
+		}
+		if msg.Release.ID != promiseID {
+			t.Errorf("Received release for import %d; want %d", msg.Release.ID, promiseID)
+		}
+	}
+}
+
+// TestRecvResolveLocal exports the bootstrap capability, receives a
+// promise, and then receives a Resolve message resolving the promise to
+// the exported capability.  The Conn should send a disembargo, and once
+// it loops back, the client should refer to the local capability
+// without going over the network.  Level 1 requirement.
+func TestRecvResolveLocal(t *testing.T) {
+	srv := newServer(func(ctx context.Context, call *server.Call) error {
+		res, err := call.AllocResults(capnp.ObjectSize{DataSize: 8})
+		if err != nil {
+			return err
+		}
+		res.SetUint64(0, 0xdeadbeef)
+		return nil
+	}, nil)
+
+	left, right := transport.NewPipe(1)
+	p1, p2 := rpc.NewTransport(left), rpc.NewTransport(right)
+
+	conn := rpc.NewConn(p1, &rpc.Options{
+		BootstrapClient: srv,
+		ErrorReporter:   testErrorReporter{tb: t},
+	})
+	defer finishTest(t, conn, p2)
+	ctx := context.Background()
+
+	// 1. Write bootstrap
+	const bootstrapQID = 54
+	{
+		msg := &rpcMessage{
+			Which:     rpccp.Message_Which_bootstrap,
+			Bootstrap: &rpcBootstrap{QuestionID: bootstrapQID},
+		}
+		if err := sendMessage(ctx, p2, msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 2. Read bootstrap return.
+	bootstrapImportID, err := recvBootstrapReturn(ctx, p2, bootstrapQID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 3. Write bootstrap finish
+	{
+		msg := &rpcMessage{
+			Which: rpccp.Message_Which_finish,
+			Finish: &rpcFinish{
+				QuestionID:        bootstrapQID,
+				ReleaseResultCaps: false,
+			},
+		}
+		if err := sendMessage(ctx, p2, msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 4. Send bootstrap from conn and return a promise.
+	client := conn.Bootstrap(ctx)
+	defer client.Release()
+	const promiseID = 42
+	{
+		msg, release, err := recvMessage(ctx, p2)
+		if err != nil {
+			t.Fatal("recvMessage(ctx, p2):", err)
+		}
+		defer release()
+		if msg.Which != rpccp.Message_Which_bootstrap {
+			t.Fatalf("Received %v message; want bootstrap", msg.Which)
+		}
+		err = sendBootstrapReturn(ctx, p2, msg.Bootstrap.QuestionID, rpcCapDescriptor{
+			Which:         rpccp.CapDescriptor_Which_senderPromise,
+			SenderPromise: promiseID,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	{
+		msg, release, err := recvMessage(ctx, p2)
+		if err != nil {
+			t.Fatal("recvMessage(ctx, p2):", err)
+		}
+		defer release()
+		if msg.Which != rpccp.Message_Which_finish {
+			t.Fatalf("Received %v message; want finish", msg.Which)
+		}
+	}
+
+	// 5. Resolve the promise to the exported bootstrap capability.
+	err = sendMessage(ctx, p2, &rpcMessage{
+		Which: rpccp.Message_Which_resolve,
+		Resolve: &rpcResolve{
+			PromiseID: promiseID,
+			Which:     rpccp.Resolve_Which_cap,
+			Cap: &rpcCapDescriptor{
+				Which:          rpccp.CapDescriptor_Which_receiverHosted,
+				ReceiverHosted: bootstrapImportID,
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 6. Read disembargo and echo it back.
+	{
+		msg, release, err := recvMessage(ctx, p2)
+		if err != nil {
+			t.Fatal("recvMessage(ctx, p2):", err)
+		}
+		defer release()
+		if msg.Which != rpccp.Message_Which_disembargo {
+			t.Fatalf("Received %v message; want disembargo", msg.Which)
+		}
+		d := msg.Disembargo
+		if d.Context.Which != rpccp.Disembargo_context_Which_senderLoopback {
+			t.Fatalf("Received disembargo with %v context; want senderLoopback", d.Context.Which)
+		}
+		if d.Target.Which != rpccp.MessageTarget_Which_importedCap || d.Target.ImportedCap != promiseID {
+			t.Fatalf("Received disembargo targeting %v %d; want importedCap %d", d.Target.Which, d.Target.ImportedCap, promiseID)
+		}
+		err = sendMessage(ctx, p2, &rpcMessage{
+			Which: rpccp.Message_Which_disembargo,
+			Disembargo: &rpcDisembargo{
+				Target: rpcMessageTarget{
+					Which:       rpccp.MessageTarget_Which_importedCap,
+					ImportedCap: bootstrapImportID,
+				},
+				Context: rpcDisembargoContext{
+					Which:            rpccp.Disembargo_context_Which_receiverLoopback,
+					ReceiverLoopback: d.Context.SenderLoopback,
+				},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 7. Read release of the promise.
+	{
+		msg, release, err := recvMessage(ctx, p2)
+		if err != nil {
+			t.Fatal("recvMessage(ctx, p2):", err)
+		}
+		defer release()
+		if msg.Which != rpccp.Message_Which_release {
+			t.Fatalf("Received %v message; want release", msg.Which)
+		}
+		if msg.Release.ID != promiseID {
+			t.Errorf("Received release for import %d; want %d", msg.Release.ID, promiseID)
+		}
+	}
+
+	// 8. Calls should now be delivered locally.
+	ans, release := client.SendCall(ctx, capnp.Send{
+		Method: capnp.Method{
+			InterfaceID: interfaceID,
+			MethodID:    methodID,
+		},
+	})
+	defer release()
+	res, err := ans.Struct()
+	if err != nil {
+		t.Fatal("call:", err)
+	}
+	if res.Uint64(0) != 0xdeadbeef {
+		t.Errorf("call result = %#x; want 0xdeadbeef", res.Uint64(0))
+	}
+	if _, ok := server.IsServer(client.State().Brand); !ok {
+		t.Errorf("client brand = %v; want local server", client.State().Brand)
+	}
+}
+
+// TestSendResolve exports a promise as the bootstrap capability and
+// then resolves it.  The Conn should send a Resolve message describing
+// the resolution.  Level 1 requirement.
+func TestSendResolve(t *testing.T) {
+	t.Run("Capability", func(t *testing.T) {
+		srv := newServer(func(ctx context.Context, call *server.Call) error {
+			return nil
+		}, nil)
+		testSendResolve(t, func(p *capnp.ClientPromise) {
+			p.Fulfill(srv)
+			srv.Release()
+		}, func(t *testing.T, r *rpcResolve) {
+			if r.Which != rpccp.Resolve_Which_cap {
+				t.Fatalf("Received %v resolve; want cap", r.Which)
+			}
+			if r.Cap.Which != rpccp.CapDescriptor_Which_senderHosted {
+				t.Errorf("Received resolve to %v; want senderHosted", r.Cap.Which)
+			}
+		})
+	})
+	t.Run("Exception", func(t *testing.T) {
+		testSendResolve(t, func(p *capnp.ClientPromise) {
+			p.Reject(errors.New("promise broken"))
+		}, func(t *testing.T, r *rpcResolve) {
+			if r.Which != rpccp.Resolve_Which_exception {
+				t.Fatalf("Received %v resolve; want exception", r.Which)
+			}
+			if !strings.Contains(r.Exception.Reason, "promise broken") {
+				t.Errorf("Received resolve with reason %q; want to contain \"promise broken\"", r.Exception.Reason)
+			}
+		})
+	})
+}
+
+func testSendResolve(t *testing.T, resolve func(*capnp.ClientPromise), check func(*testing.T, *rpcResolve)) {
+	boot, promise := capnp.NewPromisedClient(server.New(nil, nil, nil))
+
+	left, right := transport.NewPipe(1)
+	p1, p2 := rpc.NewTransport(left), rpc.NewTransport(right)
+
+	conn := rpc.NewConn(p1, &rpc.Options{
+		BootstrapClient: boot,
+		ErrorReporter:   testErrorReporter{tb: t},
+	})
+	defer finishTest(t, conn, p2)
+	ctx := context.Background()
+
+	// 1. Write bootstrap
+	const bootstrapQID = 54
+	{
+		msg := &rpcMessage{
+			Which:     rpccp.Message_Which_bootstrap,
+			Bootstrap: &rpcBootstrap{QuestionID: bootstrapQID},
+		}
+		if err := sendMessage(ctx, p2, msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 2. Read bootstrap return; should be a promise.
+	var promiseID uint32
+	{
+		msg, release, err := recvMessage(ctx, p2)
+		if err != nil {
+			t.Fatal("recvMessage(ctx, p2):", err)
+		}
+		defer release()
+		if msg.Which != rpccp.Message_Which_return {
+			t.Fatalf("Received %v message; want return", msg.Which)
+		}
+		ctab := msg.Return.Results.CapTable
+		if len(ctab) != 1 {
+			t.Fatalf("bootstrap return capability table has %d entries; want 1", len(ctab))
+		}
+		if ctab[0].Which != rpccp.CapDescriptor_Which_senderPromise {
+			t.Fatalf("bootstrap return capability is %v; want senderPromise", ctab[0].Which)
+		}
+		promiseID = ctab[0].SenderPromise
+	}
+
+	// 3. Resolve the promise locally and read the Resolve message.
+	resolve(promise)
+	{
+		msg, release, err := recvMessage(ctx, p2)
+		if err != nil {
+			t.Fatal("recvMessage(ctx, p2):", err)
+		}
+		defer release()
+		if msg.Which != rpccp.Message_Which_resolve {
+			t.Fatalf("Received %v message; want resolve", msg.Which)
+		}
+		if msg.Resolve.PromiseID != promiseID {
+			t.Errorf("Received resolve for promise %d; want %d", msg.Resolve.PromiseID, promiseID)
+		}
+		check(t, msg.Resolve)
+	}
+
+	// 4. Write bootstrap finish
+	{
+		msg := &rpcMessage{
+			Which: rpccp.Message_Which_finish,
+			Finish: &rpcFinish{
+				QuestionID:        bootstrapQID,
+				ReleaseResultCaps: false,
+			},
+		}
+		if err := sendMessage(ctx, p2, msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// sendBootstrapReturn writes a Return message for a bootstrap question
+// with a single capability.
+func sendBootstrapReturn(ctx context.Context, t rpc.Transport, qid uint32, desc rpcCapDescriptor) error {
+	outMsg, err := t.NewMessage()
+	if err != nil {
+		return fmt.Errorf("send bootstrap return: %v", err)
+	}
+	defer outMsg.Release()
+	iptr := capnp.NewInterface(outMsg.Message.Segment(), 0)
+	err = pogs.Insert(rpccp.Message_TypeID, capnp.Struct(outMsg.Message), &rpcMessage{
+		Which: rpccp.Message_Which_return,
+		Return: &rpcReturn{
+			AnswerID: qid,
+			Which:    rpccp.Return_Which_results,
+			Results: &rpcPayload{
+				Content:  iptr.ToPtr(),
+				CapTable: []rpcCapDescriptor{desc},
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("send bootstrap return: %v", err)
+	}
+	if err := outMsg.Send(); err != nil {
+		return fmt.Errorf("send bootstrap return: %v", err)
+	}
+	return nil
+}
+
 type rpcResolve struct {
 	PromiseID uint32 `capnp:"promiseId"`
 	Which     rpccp.Resolve_Which
@@ -0,0 +1,332 @@
+package rpc_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"capnproto.org/go/capnp/v3"
+	"capnproto.org/go/capnp/v3/rpc"
+	testcp "capnproto.org/go/capnp/v3/rpc/internal/testcapnp"
+	"capnproto.org/go/capnp/v3/rpc/transport"
+	rpccp "capnproto.org/go/capnp/v3/std/capnp/rpc"
+)
+
+// TestRecvBootstrapObjectID sends a Bootstrap message with an object ID
+// to a Conn with Options.Restore.  The Conn should return a promise,
+// and resolve it to the restored capability.  Level 2 requirement.
+func TestRecvBootstrapObjectID(t *testing.T) {
+	t.Parallel()
+
+	srv := testcp.PingPong_ServerToClient(pingPongServer{})
+	defer srv.Release()
+	restored := make(chan string, 1)
+	left, right := transport.NewPipe(1)
+	p1, p2 := rpc.NewTransport(left), rpc.NewTransport(right)
+
+	conn := rpc.NewConn(p1, &rpc.Options{
+		ErrorReporter: testErrorReporter{tb: t},
+		Restore: func(ctx context.Context, objectID capnp.Ptr) (capnp.Client, error) {
+			restored <- objectID.Text()
+			if objectID.Text() != "pingpong" {
+				return capnp.Client{}, errors.New("no such object")
+			}
+			return capnp.Client(srv.AddRef()), nil
+		},
+	})
+	defer finishTest(t, conn, p2)
+	ctx := context.Background()
+
+	// 1. Write bootstrap
+	const bootstrapQID = 54
+	{
+		_, seg, err := capnp.NewMessage(capnp.SingleSegment(nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		id, err := capnp.NewText(seg, "pingpong")
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = sendMessage(ctx, p2, &rpcMessage{
+			Which: rpccp.Message_Which_bootstrap,
+			Bootstrap: &rpcBootstrap{
+				QuestionID:         bootstrapQID,
+				DeprecatedObjectID: id.ToPtr(),
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 2. Read return
+	var promiseID uint32
+	{
+		rmsg, release, err := recvMessage(ctx, p2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer release()
+		if rmsg.Which != rpccp.Message_Which_return {
+			t.Fatalf("Received %v message; want return", rmsg.Which)
+		}
+		if rmsg.Return.AnswerID != bootstrapQID {
+			t.Errorf("return.answerId = %d; want %d", rmsg.Return.AnswerID, bootstrapQID)
+		}
+		if rmsg.Return.Which != rpccp.Return_Which_results {
+			t.Fatalf("return is %v; want results", rmsg.Return.Which)
+		}
+		if len(rmsg.Return.Results.CapTable) != 1 {
+			t.Fatalf("return has %d capabilities; want 1", len(rmsg.Return.Results.CapTable))
+		}
+		desc := rmsg.Return.Results.CapTable[0]
+		if desc.Which != rpccp.CapDescriptor_Which_senderPromise {
+			t.Fatalf("bootstrap capability is %v; want senderPromise", desc.Which)
+		}
+		promiseID = desc.SenderPromise
+	}
+	if id := <-restored; id != "pingpong" {
+		t.Errorf("Restore called with object ID %q; want \"pingpong\"", id)
+	}
+
+	// 3. Read resolve
+	{
+		rmsg, release, err := recvMessage(ctx, p2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer release()
+		if rmsg.Which != rpccp.Message_Which_resolve {
+			t.Fatalf("Received %v message; want resolve", rmsg.Which)
+		}
+		if rmsg.Resolve.PromiseID != promiseID {
+			t.Errorf("resolve.promiseId = %d; want %d", rmsg.Resolve.PromiseID, promiseID)
+		}
+		if rmsg.Resolve.Which != rpccp.Resolve_Which_cap {
+			t.Fatalf("resolve is %v; want cap", rmsg.Resolve.Which)
+		}
+		if rmsg.Resolve.Cap.Which != rpccp.CapDescriptor_Which_senderHosted {
+			t.Errorf("resolve.cap is %v; want senderHosted", rmsg.Resolve.Cap.Which)
+		}
+	}
+
+	// 4. Write finish
+	err := sendMessage(ctx, p2, &rpcMessage{
+		Which:  rpccp.Message_Which_finish,
+		Finish: &rpcFinish{QuestionID: bootstrapQID},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestRecvBootstrapObjectIDWithoutRestore sends a Bootstrap message
+// with an object ID to a Conn without Options.Restore.  The Conn should
+// return an exception rather than its bootstrap capability.
+func TestRecvBootstrapObjectIDWithoutRestore(t *testing.T) {
+	t.Parallel()
+
+	srv := testcp.PingPong_ServerToClient(pingPongServer{})
+	left, right := transport.NewPipe(1)
+	p1, p2 := rpc.NewTransport(left), rpc.NewTransport(right)
+
+	conn := rpc.NewConn(p1, &rpc.Options{
+		BootstrapClient: capnp.Client(srv),
+		ErrorReporter:   testErrorReporter{tb: t},
+	})
+	defer finishTest(t, conn, p2)
+	ctx := context.Background()
+
+	// 1. Write bootstrap
+	const bootstrapQID = 54
+	{
+		_, seg, err := capnp.NewMessage(capnp.SingleSegment(nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		id, err := capnp.NewText(seg, "pingpong")
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = sendMessage(ctx, p2, &rpcMessage{
+			Which: rpccp.Message_Which_bootstrap,
+			Bootstrap: &rpcBootstrap{
+				QuestionID:         bootstrapQID,
+				DeprecatedObjectID: id.ToPtr(),
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 2. Read return
+	{
+		rmsg, release, err := recvMessage(ctx, p2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer release()
+		if rmsg.Which != rpccp.Message_Which_return {
+			t.Fatalf("Received %v message; want return", rmsg.Which)
+		}
+		if rmsg.Return.AnswerID != bootstrapQID {
+			t.Errorf("return.answerId = %d; want %d", rmsg.Return.AnswerID, bootstrapQID)
+		}
+		if rmsg.Return.Which != rpccp.Return_Which_exception {
+			t.Fatalf("return is %v; want exception", rmsg.Return.Which)
+		}
+	}
+
+	// 3. Write finish
+	err := sendMessage(ctx, p2, &rpcMessage{
+		Which:  rpccp.Message_Which_finish,
+		Finish: &rpcFinish{QuestionID: bootstrapQID},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestRecvBootstrapUnreadableObjectID sends a Bootstrap message with an
+// object ID nested too deeply to be read.  The Conn should answer the
+// question with an exception.
+func TestRecvBootstrapUnreadableObjectID(t *testing.T) {
+	t.Parallel()
+
+	left, right := transport.NewPipe(1)
+	p1, p2 := rpc.NewTransport(left), rpc.NewTransport(right)
+
+	conn := rpc.NewConn(p1, &rpc.Options{
+		ErrorReporter: testErrorReporter{tb: t},
+		Restore: func(ctx context.Context, objectID capnp.Ptr) (capnp.Client, error) {
+			t.Error("Restore called with unreadable object ID")
+			return capnp.Client{}, errors.New("no such object")
+		},
+	})
+	defer finishTest(t, conn, p2)
+	ctx := context.Background()
+
+	// 1. Write bootstrap
+	const bootstrapQID = 54
+	{
+		_, seg, err := capnp.NewMessage(capnp.SingleSegment(nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		id, err := capnp.NewStruct(seg, capnp.ObjectSize{PointerCount: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for s, i := id, 0; i < 100; i++ {
+			next, err := capnp.NewStruct(seg, capnp.ObjectSize{PointerCount: 1})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := s.SetPtr(0, next.ToPtr()); err != nil {
+				t.Fatal(err)
+			}
+			s = next
+		}
+		err = sendMessage(ctx, p2, &rpcMessage{
+			Which: rpccp.Message_Which_bootstrap,
+			Bootstrap: &rpcBootstrap{
+				QuestionID:         bootstrapQID,
+				DeprecatedObjectID: id.ToPtr(),
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 2. Read return
+	{
+		rmsg, release, err := recvMessage(ctx, p2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer release()
+		if rmsg.Which != rpccp.Message_Which_return {
+			t.Fatalf("Received %v message; want return", rmsg.Which)
+		}
+		if rmsg.Return.AnswerID != bootstrapQID {
+			t.Errorf("return.answerId = %d; want %d", rmsg.Return.AnswerID, bootstrapQID)
+		}
+		if rmsg.Return.Which != rpccp.Return_Which_exception {
+			t.Fatalf("return is %v; want exception", rmsg.Return.Which)
+		}
+	}
+
+	// 3. Write finish
+	err := sendMessage(ctx, p2, &rpcMessage{
+		Which:  rpccp.Message_Which_finish,
+		Finish: &rpcFinish{QuestionID: bootstrapQID},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestRestoreSlow makes a call on a capability whose Restore has not
+// returned.  The call should be queued without holding up other
+// messages on the connection, and delivered once Restore returns.
+func TestRestoreSlow(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	srv := testcp.PingPong_ServerToClient(pingPongServer{})
+	defer srv.Release()
+	unblock := make(chan struct{})
+	left, right := transport.NewPipe(1)
+	conn1 := rpc.NewConn(rpc.NewTransport(left), &rpc.Options{
+		ErrorReporter:   testErrorReporter{tb: t},
+		BootstrapClient: capnp.Client(srv.AddRef()),
+		Restore: func(ctx context.Context, objectID capnp.Ptr) (capnp.Client, error) {
+			select {
+			case <-unblock:
+				return capnp.Client(srv.AddRef()), nil
+			case <-ctx.Done():
+				return capnp.Client{}, ctx.Err()
+			}
+		},
+	})
+	defer conn1.Close()
+	conn2 := rpc.NewConn(rpc.NewTransport(right), &rpc.Options{
+		ErrorReporter: testErrorReporter{tb: t},
+	})
+	defer conn2.Close()
+
+	_, seg, err := capnp.NewMessage(capnp.SingleSegment(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := capnp.NewText(seg, "pingpong")
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored := testcp.PingPong(conn2.Restore(ctx, id.ToPtr()))
+	defer restored.Release()
+	f, release := restored.EchoNum(ctx, func(p testcp.PingPong_echoNum_Params) error {
+		p.SetN(42)
+		return nil
+	})
+	defer release()
+
+	// A call sent after the queued one must not wait for it.
+	boot := testcp.PingPong(conn2.Bootstrap(ctx))
+	defer boot.Release()
+	echoNum(ctx, t, boot, 7)
+
+	close(unblock)
+	res, err := f.Struct()
+	if err != nil {
+		t.Fatal("EchoNum on restored capability:", err)
+	}
+	if res.N() != 42 {
+		t.Errorf("EchoNum(42) = %d", res.N())
+	}
+}
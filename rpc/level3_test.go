@@ -0,0 +1,482 @@
+package rpc_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"capnproto.org/go/capnp/v3"
+	"capnproto.org/go/capnp/v3/rpc"
+	"capnproto.org/go/capnp/v3/rpc/inproc"
+	testcp "capnproto.org/go/capnp/v3/rpc/internal/testcapnp"
+	"capnproto.org/go/capnp/v3/rpc/transport"
+	rpccp "capnproto.org/go/capnp/v3/std/capnp/rpc"
+)
+
+// newHandoffNetwork creates three vats: C hosts impl, and B hosts a
+// PingPongProvider that hands out C's PingPong.
+func newHandoffNetwork(t *testing.T, impl testcp.PingPong_Server) (a, b, c *inproc.Vat) {
+	net := inproc.New()
+	c = net.NewVat(&rpc.Options{
+		BootstrapClient: capnp.Client(testcp.PingPong_ServerToClient(impl)),
+		ErrorReporter:   testErrorReporter{tb: t},
+	})
+	provider := new(forwardingProvider)
+	b = net.NewVat(&rpc.Options{
+		BootstrapClient: capnp.Client(testcp.PingPongProvider_ServerToClient(provider)),
+		ErrorReporter:   testErrorReporter{tb: t},
+	})
+	a = net.NewVat(&rpc.Options{
+		ErrorReporter: testErrorReporter{tb: t},
+	})
+
+	bc, err := b.Dial(c.ID())
+	if err != nil {
+		t.Fatal("b.Dial(c):", err)
+	}
+	provider.pp = testcp.PingPong(bc.Bootstrap(context.Background()))
+	if err := capnp.Client(provider.pp).Resolve(context.Background()); err != nil {
+		t.Fatal("resolve C's bootstrap:", err)
+	}
+	t.Cleanup(func() {
+		provider.pp.Release()
+		for _, v := range []*inproc.Vat{a, b, c} {
+			if err := v.Close(); err != nil {
+				t.Error("vat.Close():", err)
+			}
+		}
+	})
+	return a, b, c
+}
+
+// forwardingProvider returns a PingPong imported from another vat.
+type forwardingProvider struct {
+	pp testcp.PingPong
+}
+
+func (p *forwardingProvider) PingPong(ctx context.Context, call testcp.PingPongProvider_pingPong) error {
+	res, err := call.AllocResults()
+	if err != nil {
+		return err
+	}
+	return res.SetPingPong(p.pp.AddRef())
+}
+
+func echoNum(ctx context.Context, t *testing.T, pp testcp.PingPong, n int64) {
+	t.Helper()
+	f, release := pp.EchoNum(ctx, func(p testcp.PingPong_echoNum_Params) error {
+		p.SetN(n)
+		return nil
+	})
+	defer release()
+	res, err := f.Struct()
+	if err != nil {
+		t.Fatalf("EchoNum(%d): %v", n, err)
+	}
+	if res.N() != n {
+		t.Errorf("EchoNum(%d) = %d", n, res.N())
+	}
+}
+
+// TestThirdPartyHandoff sends a capability from C to A through B, and
+// checks that A picks it up directly from C.  Level 3 requirement.
+func TestThirdPartyHandoff(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	a, b, _ := newHandoffNetwork(t, pingPongServer{})
+
+	ab, err := a.Dial(b.ID())
+	if err != nil {
+		t.Fatal("a.Dial(b):", err)
+	}
+	ppp := testcp.PingPongProvider(ab.Bootstrap(ctx))
+	f, release := ppp.PingPong(ctx, nil)
+	res, err := f.Struct()
+	if err != nil {
+		t.Fatal("PingPong():", err)
+	}
+	pp := res.PingPong().AddRef()
+	defer pp.Release()
+	release()
+	ppp.Release()
+
+	if err := capnp.Client(pp).Resolve(ctx); err != nil {
+		t.Fatal("resolve handoff:", err)
+	}
+	echoNum(ctx, t, pp, 42)
+
+	// The capability no longer depends on B.
+	if err := ab.Close(); err != nil {
+		t.Fatal("ab.Close():", err)
+	}
+	echoNum(ctx, t, pp, 43)
+}
+
+// TestThirdPartyHandoffEmbargo makes pipelined calls on a capability
+// before it is handed off, and checks that they are delivered before
+// calls made directly to the third party.  Level 3 requirement.
+func TestThirdPartyHandoffEmbargo(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	rec := new(recordingPingPong)
+	a, b, _ := newHandoffNetwork(t, rec)
+
+	ab, err := a.Dial(b.ID())
+	if err != nil {
+		t.Fatal("a.Dial(b):", err)
+	}
+	ppp := testcp.PingPongProvider(ab.Bootstrap(ctx))
+	defer ppp.Release()
+	f, release := ppp.PingPong(ctx, nil)
+	defer release()
+
+	pp := f.PingPong()
+	const n = 10
+	var futures []testcp.PingPong_echoNum_Results_Future
+	for i := int64(0); i < n; i++ {
+		i := i
+		ef, release := pp.EchoNum(ctx, func(p testcp.PingPong_echoNum_Params) error {
+			p.SetN(i)
+			return nil
+		})
+		defer release()
+		futures = append(futures, ef)
+	}
+
+	if err := capnp.Client(pp).Resolve(ctx); err != nil {
+		t.Fatal("resolve handoff:", err)
+	}
+	echoNum(ctx, t, pp, n)
+	for i, ef := range futures {
+		res, err := ef.Struct()
+		if err != nil {
+			t.Errorf("pipelined EchoNum(%d): %v", i, err)
+		} else if res.N() != int64(i) {
+			t.Errorf("pipelined EchoNum(%d) = %d", i, res.N())
+		}
+	}
+	rec.checkOrder(t, n+1)
+}
+
+// TestThirdPartyHandoffResolve exports a promise from B that resolves
+// to a capability imported from C.  Calls made on the promise before
+// it resolves must be delivered before calls made directly to C.
+// Level 3 requirement.
+func TestThirdPartyHandoffResolve(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	net := inproc.New()
+	rec := new(recordingPingPong)
+	c := net.NewVat(&rpc.Options{
+		BootstrapClient: capnp.Client(testcp.PingPong_ServerToClient(rec)),
+		ErrorReporter:   testErrorReporter{tb: t},
+	})
+	// Until the promise resolves, calls on it are forwarded to C by
+	// B's server.
+	fwd := &forwardingPingPong{ready: make(chan struct{})}
+	boot, promise := capnp.NewPromisedClient(testcp.PingPong_NewServer(fwd))
+	b := net.NewVat(&rpc.Options{
+		BootstrapClient: boot,
+		ErrorReporter:   testErrorReporter{tb: t},
+	})
+	a := net.NewVat(&rpc.Options{
+		ErrorReporter: testErrorReporter{tb: t},
+	})
+	defer func() {
+		for _, v := range []*inproc.Vat{a, b, c} {
+			if err := v.Close(); err != nil {
+				t.Error("vat.Close():", err)
+			}
+		}
+	}()
+
+	ab, err := a.Dial(b.ID())
+	if err != nil {
+		t.Fatal("a.Dial(b):", err)
+	}
+	pp := testcp.PingPong(ab.Bootstrap(ctx))
+	defer pp.Release()
+
+	const n = 10
+	var futures []testcp.PingPong_echoNum_Results_Future
+	for i := int64(0); i < n; i++ {
+		i := i
+		ef, release := pp.EchoNum(ctx, func(p testcp.PingPong_echoNum_Params) error {
+			p.SetN(i)
+			return nil
+		})
+		defer release()
+		futures = append(futures, ef)
+	}
+
+	bc, err := b.Dial(c.ID())
+	if err != nil {
+		t.Fatal("b.Dial(c):", err)
+	}
+	cboot := bc.Bootstrap(ctx)
+	if err := cboot.Resolve(ctx); err != nil {
+		t.Fatal("resolve C's bootstrap:", err)
+	}
+	fwd.pp = testcp.PingPong(cboot)
+	close(fwd.ready)
+	promise.Fulfill(cboot)
+	cboot.Release()
+
+	if err := capnp.Client(pp).Resolve(ctx); err != nil {
+		t.Fatal("resolve handoff:", err)
+	}
+	echoNum(ctx, t, pp, n)
+	for i, ef := range futures {
+		res, err := ef.Struct()
+		if err != nil {
+			t.Errorf("EchoNum(%d) on promise: %v", i, err)
+		} else if res.N() != int64(i) {
+			t.Errorf("EchoNum(%d) on promise = %d", i, res.N())
+		}
+	}
+	rec.checkOrder(t, n+1)
+}
+
+// recordingPingPong echoes numbers like pingPongServer, and records
+// them in the order that it receives them.
+type recordingPingPong struct {
+	mu       sync.Mutex
+	received []int64
+}
+
+func (p *recordingPingPong) EchoNum(ctx context.Context, call testcp.PingPong_echoNum) error {
+	p.mu.Lock()
+	p.received = append(p.received, call.Args().N())
+	p.mu.Unlock()
+	return pingPongServer{}.EchoNum(ctx, call)
+}
+
+// checkOrder checks that p received the numbers 0 through n-1 in
+// order.
+func (p *recordingPingPong) checkOrder(t *testing.T, n int) {
+	t.Helper()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.received) != n {
+		t.Fatalf("received %d calls (%v); want %d", len(p.received), p.received, n)
+	}
+	for i, got := range p.received {
+		if got != int64(i) {
+			t.Fatalf("calls received in order %v; want 0 through %d", p.received, n-1)
+		}
+	}
+}
+
+// forwardingPingPong forwards calls to another PingPong once it is
+// ready.
+type forwardingPingPong struct {
+	pp    testcp.PingPong
+	ready chan struct{}
+}
+
+func (p *forwardingPingPong) EchoNum(ctx context.Context, call testcp.PingPong_echoNum) error {
+	<-p.ready
+	// The server is shut down once the promise resolves, which cancels
+	// ctx; the forwarded call must still complete.
+	f, release := p.pp.EchoNum(context.Background(), func(params testcp.PingPong_echoNum_Params) error {
+		params.SetN(call.Args().N())
+		return nil
+	})
+	defer release()
+	res, err := f.Struct()
+	if err != nil {
+		return err
+	}
+	out, err := call.AllocResults()
+	if err != nil {
+		return err
+	}
+	out.SetN(res.N())
+	return nil
+}
+
+// TestRecvThirdPartyHostedWithoutNetwork sends a thirdPartyHosted
+// descriptor to a Conn that is not part of a vat network.  The Conn
+// should use the vine.
+func TestRecvThirdPartyHostedWithoutNetwork(t *testing.T) {
+	t.Parallel()
+
+	left, right := transport.NewPipe(1)
+	p1, p2 := rpc.NewTransport(left), rpc.NewTransport(right)
+
+	conn := rpc.NewConn(p1, &rpc.Options{
+		ErrorReporter: testErrorReporter{tb: t},
+	})
+	defer finishTest(t, conn, p2)
+	ctx := context.Background()
+
+	// 1. Bootstrap, and return a third party capability.
+	client := conn.Bootstrap(ctx)
+	defer client.Release()
+	const vineID = 7
+	{
+		msg, release, err := recvMessage(ctx, p2)
+		if err != nil {
+			t.Fatal("recvMessage(ctx, p2):", err)
+		}
+		defer release()
+		if msg.Which != rpccp.Message_Which_bootstrap {
+			t.Fatalf("Received %v message; want bootstrap", msg.Which)
+		}
+		_, seg, err := capnp.NewMessage(capnp.SingleSegment(nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		id, err := capnp.NewRootStruct(seg, capnp.ObjectSize{DataSize: 8})
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = sendBootstrapReturn(ctx, p2, msg.Bootstrap.QuestionID, rpcCapDescriptor{
+			Which: rpccp.CapDescriptor_Which_thirdPartyHosted,
+			ThirdPartyHosted: &rpcThirdPartyCapDescriptor{
+				ID:     id.ToPtr(),
+				VineID: vineID,
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	{
+		msg, release, err := recvMessage(ctx, p2)
+		if err != nil {
+			t.Fatal("recvMessage(ctx, p2):", err)
+		}
+		defer release()
+		if msg.Which != rpccp.Message_Which_finish {
+			t.Fatalf("Received %v message; want finish", msg.Which)
+		}
+	}
+
+	// 2. Calls should go to the vine.
+	ans, release := client.SendCall(ctx, capnp.Send{
+		Method: capnp.Method{
+			InterfaceID: interfaceID,
+			MethodID:    methodID,
+		},
+	})
+	defer release()
+	{
+		msg, release, err := recvMessage(ctx, p2)
+		if err != nil {
+			t.Fatal("recvMessage(ctx, p2):", err)
+		}
+		defer release()
+		if msg.Which != rpccp.Message_Which_call {
+			t.Fatalf("Received %v message; want call", msg.Which)
+		}
+		if msg.Call.Target.Which != rpccp.MessageTarget_Which_importedCap || msg.Call.Target.ImportedCap != vineID {
+			t.Fatalf("Received call targeting %v %d; want importedCap %d", msg.Call.Target.Which, msg.Call.Target.ImportedCap, vineID)
+		}
+		err = sendMessage(ctx, p2, &rpcMessage{
+			Which: rpccp.Message_Which_return,
+			Return: &rpcReturn{
+				AnswerID: msg.Call.QuestionID,
+				Which:    rpccp.Return_Which_results,
+				Results:  &rpcPayload{},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := ans.Struct(); err != nil {
+		t.Error("call:", err)
+	}
+}
+
+// TestRecvProvideWithoutNetwork sends a Provide message to a Conn that
+// is not part of a vat network.  The Conn should return an exception.
+func TestRecvProvideWithoutNetwork(t *testing.T) {
+	t.Parallel()
+
+	srv := testcp.PingPong_ServerToClient(pingPongServer{})
+	left, right := transport.NewPipe(1)
+	p1, p2 := rpc.NewTransport(left), rpc.NewTransport(right)
+
+	conn := rpc.NewConn(p1, &rpc.Options{
+		BootstrapClient: capnp.Client(srv),
+		ErrorReporter:   testErrorReporter{tb: t},
+	})
+	defer finishTest(t, conn, p2)
+	ctx := context.Background()
+
+	// 1. Bootstrap.
+	const bootstrapQID = 54
+	err := sendMessage(ctx, p2, &rpcMessage{
+		Which:     rpccp.Message_Which_bootstrap,
+		Bootstrap: &rpcBootstrap{QuestionID: bootstrapQID},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bootstrapImportID, err := recvBootstrapReturn(ctx, p2, bootstrapQID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 2. Ask the Conn to provide its bootstrap capability.
+	const provideQID = 55
+	err = sendMessage(ctx, p2, &rpcMessage{
+		Which: rpccp.Message_Which_provide,
+		Provide: &rpcProvide{
+			QuestionID: provideQID,
+			Target: rpcMessageTarget{
+				Which:       rpccp.MessageTarget_Which_importedCap,
+				ImportedCap: bootstrapImportID,
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	{
+		msg, release, err := recvMessage(ctx, p2)
+		if err != nil {
+			t.Fatal("recvMessage(ctx, p2):", err)
+		}
+		defer release()
+		if msg.Which != rpccp.Message_Which_return {
+			t.Fatalf("Received %v message; want return", msg.Which)
+		}
+		if msg.Return.AnswerID != provideQID {
+			t.Errorf("Received return for answer %d; want %d", msg.Return.AnswerID, provideQID)
+		}
+		if msg.Return.Which != rpccp.Return_Which_exception {
+			t.Fatalf("Received %v return; want exception", msg.Return.Which)
+		}
+		if msg.Return.Exception.Type != rpccp.Exception_Type_unimplemented {
+			t.Errorf("Received %v exception; want unimplemented", msg.Return.Exception.Type)
+		}
+	}
+
+	// 3. Finish the questions.
+	for _, qid := range []uint32{bootstrapQID, provideQID} {
+		err := sendMessage(ctx, p2, &rpcMessage{
+			Which:  rpccp.Message_Which_finish,
+			Finish: &rpcFinish{QuestionID: qid},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+type rpcProvide struct {
+	QuestionID uint32 `capnp:"questionId"`
+	Target     rpcMessageTarget
+	Recipient  capnp.Ptr
+}
+
+type rpcThirdPartyCapDescriptor struct {
+	ID     capnp.Ptr `capnp:"id"`
+	VineID uint32    `capnp:"vineId"`
+}
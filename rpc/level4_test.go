@@ -0,0 +1,554 @@
+package rpc_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"capnproto.org/go/capnp/v3"
+	"capnproto.org/go/capnp/v3/pogs"
+	"capnproto.org/go/capnp/v3/rpc"
+	"capnproto.org/go/capnp/v3/rpc/inproc"
+	testcp "capnproto.org/go/capnp/v3/rpc/internal/testcapnp"
+	"capnproto.org/go/capnp/v3/rpc/transport"
+	rpccp "capnproto.org/go/capnp/v3/std/capnp/rpc"
+	"capnproto.org/go/capnp/v3/std/capnp/rpctwoparty"
+)
+
+// TestJoin joins capabilities imported over a two-party connection.
+// Level 4 requirement.
+func TestJoin(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Same", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		srv := new(selfServer)
+		srv.self = testcp.CapArgsTest_ServerToClient(srv)
+		defer srv.self.Release()
+
+		conn := newJoinConn(t, capnp.Client(srv.self.AddRef()))
+		b := testcp.CapArgsTest(conn.Bootstrap(ctx))
+		defer b.Release()
+		f, release := b.Self(ctx, nil)
+		defer release()
+		res, err := f.Struct()
+		if err != nil {
+			t.Fatal("Self():", err)
+		}
+		self := res.Self()
+
+		joined, err := conn.Join(ctx, capnp.Client(b), capnp.Client(self))
+		if err != nil {
+			t.Fatal("conn.Join:", err)
+		}
+		defer joined.Release()
+		if !joined.IsSame(capnp.Client(b)) && !joined.IsSame(capnp.Client(self)) {
+			t.Error("joined capability is not one of the joined capabilities")
+		}
+	})
+	t.Run("Different", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		conn := newJoinConn(t, capnp.Client(testcp.CapArgsTest_ServerToClient(new(selfServer))))
+		b := testcp.CapArgsTest(conn.Bootstrap(ctx))
+		defer b.Release()
+		f, release := b.Self(ctx, nil)
+		defer release()
+		res, err := f.Struct()
+		if err != nil {
+			t.Fatal("Self():", err)
+		}
+
+		joined, err := conn.Join(ctx, capnp.Client(b), capnp.Client(res.Self()))
+		if err == nil {
+			joined.Release()
+			t.Fatal("conn.Join succeeded on different capabilities")
+		}
+		if !strings.Contains(err.Error(), "not the same") {
+			t.Errorf("conn.Join: %v; want \"not the same\" error", err)
+		}
+	})
+	t.Run("NotImported", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		conn := newJoinConn(t, capnp.Client{})
+		local := testcp.CapArgsTest_ServerToClient(new(selfServer))
+		defer local.Release()
+
+		if _, err := conn.Join(ctx, capnp.Client(local)); err == nil {
+			t.Error("conn.Join succeeded on a local capability")
+		}
+	})
+}
+
+// newJoinConn connects to a Conn that exports boot, and returns the
+// connection to it.
+func newJoinConn(t *testing.T, boot capnp.Client) *rpc.Conn {
+	_, conn := newTestConns(t, &rpc.Options{
+		BootstrapClient: boot,
+		ErrorReporter:   testErrorReporter{tb: t},
+	}, &rpc.Options{
+		ErrorReporter: testErrorReporter{tb: t},
+	})
+	return conn
+}
+
+// TestJoinNetwork joins capabilities that a vat received from two
+// other vats, which proxy them for vats of the same network.  The parts
+// of the join should meet at the vat hosting the capabilities.
+// Level 4 requirement.
+func TestJoinNetwork(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Same", func(t *testing.T) {
+		t.Parallel()
+
+		net := inproc.New()
+		host := newJoinHost(t, net)
+		pp := testcp.PingPong_ServerToClient(pingPongServer{})
+		defer pp.Release()
+
+		joined, err := joinProxied(t, net, host, pp, host, pp)
+		if err != nil {
+			t.Fatal("Join:", err)
+		}
+		defer joined.Release()
+		echoNum(context.Background(), t, testcp.PingPong(joined), 42)
+	})
+	t.Run("Different", func(t *testing.T) {
+		t.Parallel()
+
+		net := inproc.New()
+		host := newJoinHost(t, net)
+		pp1 := testcp.PingPong_ServerToClient(pingPongServer{})
+		defer pp1.Release()
+		pp2 := testcp.PingPong_ServerToClient(pingPongServer{})
+		defer pp2.Release()
+
+		joined, err := joinProxied(t, net, host, pp1, host, pp2)
+		if err == nil {
+			joined.Release()
+			t.Fatal("Join succeeded on different capabilities")
+		}
+		if !strings.Contains(err.Error(), "not the same") {
+			t.Errorf("Join: %v; want \"not the same\" error", err)
+		}
+	})
+	t.Run("DifferentHosts", func(t *testing.T) {
+		t.Parallel()
+
+		// The parts never meet.
+		net := inproc.New()
+		host1 := newJoinHost(t, net)
+		host2 := newJoinHost(t, net)
+		pp := testcp.PingPong_ServerToClient(pingPongServer{})
+		defer pp.Release()
+
+		joined, err := joinProxied(t, net, host1, pp, host2, pp)
+		if err == nil {
+			joined.Release()
+			t.Fatal("Join succeeded on capabilities from different hosts")
+		}
+		if !strings.Contains(err.Error(), "not the same") {
+			t.Errorf("Join: %v; want \"not the same\" error", err)
+		}
+	})
+}
+
+// joinProxied has a new vat on net join pp1, hosted by host1, and pp2,
+// hosted by host2, receiving each of them from a vat that proxies it.
+func joinProxied(t *testing.T, net *inproc.Network, host1 *inproc.Vat, pp1 testcp.PingPong, host2 *inproc.Vat, pp2 testcp.PingPong) (capnp.Client, error) {
+	ctx := context.Background()
+	proxies := []*inproc.Vat{
+		newJoinProxy(t, net, host1, pp1),
+		newJoinProxy(t, net, host2, pp2),
+	}
+	a := net.NewVat(&rpc.Options{
+		ErrorReporter: testErrorReporter{tb: t},
+	})
+	t.Cleanup(func() {
+		if err := a.Close(); err != nil {
+			t.Error("a.Close():", err)
+		}
+	})
+
+	var (
+		conn    *rpc.Conn
+		clients []capnp.Client
+	)
+	for _, proxy := range proxies {
+		c, err := a.Dial(proxy.ID())
+		if err != nil {
+			t.Fatal("a.Dial:", err)
+		}
+		if conn == nil {
+			conn = c
+		}
+		provider := testcp.PingPongProvider(c.Bootstrap(ctx))
+		defer provider.Release()
+		f, release := provider.PingPong(ctx, nil)
+		defer release()
+		client := capnp.Client(f.PingPong()).AddRef()
+		defer client.Release()
+		clients = append(clients, client)
+	}
+	return conn.Join(ctx, clients...)
+}
+
+// newJoinHost adds a vat to net that hosts the capabilities proxied by
+// the vats of newJoinProxy.
+func newJoinHost(t *testing.T, net *inproc.Network) *inproc.Vat {
+	host := net.NewVat(&rpc.Options{
+		ErrorReporter: testErrorReporter{tb: t},
+	})
+	t.Cleanup(func() {
+		if err := host.Close(); err != nil {
+			t.Error("host.Close():", err)
+		}
+	})
+	return host
+}
+
+// newJoinProxy adds a vat to net whose bootstrap capability returns pp,
+// imported from host.  The vat connects to host outside of net, so that
+// it cannot introduce the vats it passes pp to to host, and proxies pp
+// for them instead.
+func newJoinProxy(t *testing.T, net *inproc.Network, host *inproc.Vat, pp testcp.PingPong) *inproc.Vat {
+	provider := new(forwardingProvider)
+	proxy := net.NewVat(&rpc.Options{
+		BootstrapClient: capnp.Client(testcp.PingPongProvider_ServerToClient(provider)),
+	})
+	left, right := transport.NewPipe(1)
+	hostConn := rpc.NewConn(rpc.NewTransport(left), &rpc.Options{
+		BootstrapClient: capnp.Client(pp.AddRef()),
+		Network:         host,
+		ErrorReporter:   testErrorReporter{tb: t},
+	})
+	proxyConn := rpc.NewConn(rpc.NewTransport(right), &rpc.Options{
+		Network: proxy,
+	})
+	provider.pp = testcp.PingPong(proxyConn.Bootstrap(context.Background()))
+	t.Cleanup(func() {
+		provider.pp.Release()
+		if err := proxy.Close(); err != nil {
+			t.Error("proxy.Close():", err)
+		}
+		proxyConn.Close()
+		hostConn.Close()
+	})
+	return proxy
+}
+
+// selfServer is a CapArgsTest whose self method returns self, or a
+// new object if self is not set.
+type selfServer struct {
+	self testcp.CapArgsTest
+}
+
+func (s *selfServer) Self(ctx context.Context, call testcp.CapArgsTest_self) error {
+	res, err := call.AllocResults()
+	if err != nil {
+		return err
+	}
+	if s.self.IsValid() {
+		return res.SetSelf(s.self.AddRef())
+	}
+	return res.SetSelf(testcp.CapArgsTest_ServerToClient(new(selfServer)))
+}
+
+func (s *selfServer) Call(ctx context.Context, call testcp.CapArgsTest_call) error {
+	return nil
+}
+
+// TestSendJoin joins two capabilities imported under different IDs.
+// The Conn should send a Join message for each of them, and return the
+// capability from the first part's result.  Level 4 requirement.
+func TestSendJoin(t *testing.T) {
+	t.Parallel()
+
+	left, right := transport.NewPipe(1)
+	p1, p2 := rpc.NewTransport(left), rpc.NewTransport(right)
+
+	conn := rpc.NewConn(p1, &rpc.Options{
+		ErrorReporter: testErrorReporter{tb: t},
+	})
+	defer finishTest(t, conn, p2)
+	ctx := context.Background()
+
+	// 1. Bootstrap twice, exporting the same object under two IDs.
+	exportIDs := []uint32{bootstrapExportID, bootstrapExportID + 1}
+	clients := make([]capnp.Client, len(exportIDs))
+	for i, id := range exportIDs {
+		clients[i] = conn.Bootstrap(ctx)
+		defer clients[i].Release()
+		rmsg, release, err := recvNonFinish(ctx, p2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rmsg.Which != rpccp.Message_Which_bootstrap {
+			release()
+			t.Fatalf("Received %v message; want bootstrap", rmsg.Which)
+		}
+		qid := rmsg.Bootstrap.QuestionID
+		release()
+		err = sendBootstrapReturn(ctx, p2, qid, rpcCapDescriptor{
+			Which:        rpccp.CapDescriptor_Which_senderHosted,
+			SenderHosted: id,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := clients[i].Resolve(ctx); err != nil {
+			t.Fatal("client.Resolve:", err)
+		}
+	}
+
+	// 2. Join the capabilities.
+	type joinResult struct {
+		client capnp.Client
+		err    error
+	}
+	done := make(chan joinResult, 1)
+	go func() {
+		joined, err := conn.Join(ctx, clients...)
+		done <- joinResult{joined, err}
+	}()
+
+	// 3. Read the Join messages.
+	var (
+		joinID uint32
+		qids   []uint32
+	)
+	for len(qids) < len(exportIDs) {
+		rmsg, release, err := recvNonFinish(ctx, p2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rmsg.Which != rpccp.Message_Which_join {
+			release()
+			t.Fatalf("Received %v message; want join", rmsg.Which)
+		}
+		i := len(qids)
+		j := rmsg.Join
+		if j.Target.Which != rpccp.MessageTarget_Which_importedCap || j.Target.ImportedCap != exportIDs[i] {
+			t.Errorf("join %d target = %v %d; want importedCap %d", i, j.Target.Which, j.Target.ImportedCap, exportIDs[i])
+		}
+		kp := rpctwoparty.JoinKeyPart(j.KeyPart.Struct())
+		if i == 0 {
+			joinID = kp.JoinId()
+		} else if kp.JoinId() != joinID {
+			t.Errorf("join %d keyPart.joinId = %d; want %d", i, kp.JoinId(), joinID)
+		}
+		if int(kp.PartCount()) != len(exportIDs) {
+			t.Errorf("join %d keyPart.partCount = %d; want %d", i, kp.PartCount(), len(exportIDs))
+		}
+		if int(kp.PartNum()) != i {
+			t.Errorf("join %d keyPart.partNum = %d; want %d", i, kp.PartNum(), i)
+		}
+		qids = append(qids, j.QuestionID)
+		release()
+	}
+
+	// 4. Return the join results.
+	for i, qid := range qids {
+		outMsg, err := p2.NewMessage()
+		if err != nil {
+			t.Fatal("p2.NewMessage():", err)
+		}
+		seg := outMsg.Message.Segment()
+		r, err := rpctwoparty.NewJoinResult(seg)
+		if err != nil {
+			outMsg.Release()
+			t.Fatal(err)
+		}
+		r.SetJoinId(joinID)
+		r.SetSucceeded(true)
+		var ctab []rpcCapDescriptor
+		if i == 0 {
+			r.SetCap(capnp.NewInterface(seg, 0).ToPtr())
+			ctab = []rpcCapDescriptor{{
+				Which:        rpccp.CapDescriptor_Which_senderHosted,
+				SenderHosted: exportIDs[0],
+			}}
+		}
+		err = pogs.Insert(rpccp.Message_TypeID, capnp.Struct(outMsg.Message), &rpcMessage{
+			Which: rpccp.Message_Which_return,
+			Return: &rpcReturn{
+				AnswerID: qid,
+				Which:    rpccp.Return_Which_results,
+				Results: &rpcPayload{
+					Content:  r.ToPtr(),
+					CapTable: ctab,
+				},
+			},
+		})
+		if err != nil {
+			outMsg.Release()
+			t.Fatal("pogs.Insert(p2.NewMessage(), &rpcMessage{...}):", err)
+		}
+		err = outMsg.Send()
+		outMsg.Release()
+		if err != nil {
+			t.Fatal("send():", err)
+		}
+	}
+
+	// 5. Check the joined capability.
+	res := <-done
+	if res.err != nil {
+		t.Fatal("conn.Join:", res.err)
+	}
+	defer res.client.Release()
+	if !res.client.IsSame(clients[0]) {
+		t.Error("joined capability is not the first part's capability")
+	}
+}
+
+// recvNonFinish receives the next message that is not a Finish.
+func recvNonFinish(ctx context.Context, t rpc.Transport) (*rpcMessage, capnp.ReleaseFunc, error) {
+	for {
+		rmsg, release, err := recvMessage(ctx, t)
+		if err != nil {
+			return nil, nil, fmt.Errorf("receive message: %v", err)
+		}
+		if rmsg.Which != rpccp.Message_Which_finish {
+			return rmsg, release, nil
+		}
+		release()
+	}
+}
+
+// TestRecvJoin sends the parts of a join targeting the bootstrap
+// capability.  The Conn should answer each part once it has received
+// all of them.  Level 4 requirement.
+func TestRecvJoin(t *testing.T) {
+	t.Parallel()
+
+	srv := testcp.PingPong_ServerToClient(pingPongServer{})
+	left, right := transport.NewPipe(1)
+	p1, p2 := rpc.NewTransport(left), rpc.NewTransport(right)
+
+	conn := rpc.NewConn(p1, &rpc.Options{
+		BootstrapClient: capnp.Client(srv),
+		ErrorReporter:   testErrorReporter{tb: t},
+	})
+	defer finishTest(t, conn, p2)
+	ctx := context.Background()
+
+	// 1. Bootstrap.
+	const bootstrapQID = 54
+	err := sendMessage(ctx, p2, &rpcMessage{
+		Which:     rpccp.Message_Which_bootstrap,
+		Bootstrap: &rpcBootstrap{QuestionID: bootstrapQID},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bootstrapImportID, err := recvBootstrapReturn(ctx, p2, bootstrapQID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 2. Join the import and the promised answer.
+	const joinID = 3
+	qids := []uint32{55, 56}
+	targets := []rpcMessageTarget{
+		{
+			Which:       rpccp.MessageTarget_Which_importedCap,
+			ImportedCap: bootstrapImportID,
+		},
+		{
+			Which:          rpccp.MessageTarget_Which_promisedAnswer,
+			PromisedAnswer: &rpcPromisedAnswer{QuestionID: bootstrapQID},
+		},
+	}
+	for i := range qids {
+		_, seg, err := capnp.NewMessage(capnp.SingleSegment(nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		kp, err := rpctwoparty.NewRootJoinKeyPart(seg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		kp.SetJoinId(joinID)
+		kp.SetPartCount(uint16(len(qids)))
+		kp.SetPartNum(uint16(i))
+		err = sendMessage(ctx, p2, &rpcMessage{
+			Which: rpccp.Message_Which_join,
+			Join: &rpcJoin{
+				QuestionID: qids[i],
+				Target:     targets[i],
+				KeyPart:    kp.ToPtr(),
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 3. Read the join results.
+	for i := range qids {
+		msg, release, err := recvMessage(ctx, p2)
+		if err != nil {
+			t.Fatal("recvMessage(ctx, p2):", err)
+		}
+		defer release()
+		if msg.Which != rpccp.Message_Which_return {
+			t.Fatalf("Received %v message; want return", msg.Which)
+		}
+		if msg.Return.AnswerID != qids[i] {
+			t.Errorf("Received return for answer %d; want %d", msg.Return.AnswerID, qids[i])
+		}
+		if msg.Return.Which != rpccp.Return_Which_results {
+			t.Fatalf("Received %v return; want results", msg.Return.Which)
+		}
+		r := rpctwoparty.JoinResult(msg.Return.Results.Content.Struct())
+		if r.JoinId() != joinID {
+			t.Errorf("joinResult.joinId = %d; want %d", r.JoinId(), joinID)
+		}
+		if !r.Succeeded() {
+			t.Error("joinResult.succeeded = false; want true")
+		}
+		p, err := r.Cap()
+		if err != nil {
+			t.Fatal("joinResult.cap:", err)
+		}
+		if i > 0 {
+			if p.IsValid() {
+				t.Errorf("part %d: joinResult.cap is set; want only part 0 to have it", i)
+			}
+			continue
+		}
+		ctab := msg.Return.Results.CapTable
+		if !p.Interface().IsValid() || int(p.Interface().Capability()) >= len(ctab) {
+			t.Fatal("part 0: joinResult.cap is not a capability")
+		}
+		desc := ctab[p.Interface().Capability()]
+		if desc.Which != rpccp.CapDescriptor_Which_senderHosted || desc.SenderHosted != bootstrapImportID {
+			t.Errorf("part 0: joinResult.cap = %v %d; want senderHosted %d", desc.Which, desc.SenderHosted, bootstrapImportID)
+		}
+	}
+
+	// 4. Finish the questions.
+	for _, qid := range append([]uint32{bootstrapQID}, qids...) {
+		err := sendMessage(ctx, p2, &rpcMessage{
+			Which:  rpccp.Message_Which_finish,
+			Finish: &rpcFinish{QuestionID: qid},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+type rpcJoin struct {
+	QuestionID uint32 `capnp:"questionId"`
+	Target     rpcMessageTarget
+	KeyPart    capnp.Ptr
+}
@@ -0,0 +1,277 @@
+package rpc
+
+import (
+	"context"
+	"sort"
+
+	"capnproto.org/go/capnp/v3"
+	"capnproto.org/go/capnp/v3/rpc/internal/metadatacp"
+	rpccp "capnproto.org/go/capnp/v3/std/capnp/rpc"
+)
+
+/*
+Call metadata
+
+The Cap'n Proto RPC protocol has no per-call headers.  A Conn with
+Options.CallMetadata set negotiates them with the remote vat when the
+connection starts, using the side channel that
+internal/metadatacp/metadata.capnp declares:
+
+ 1. Its first messages are a Bootstrap and a call to CallMetadata.hello
+    pipelined on the bootstrap capability.  A vat that does not know
+    about call metadata, or has not opted in, delivers the call to its
+    bootstrap capability, which fails it.
+ 2. Transports deliver messages in order, so the first message other
+    than a Bootstrap received from the remote vat says whether it sent
+    a hello.  If it did, the remote vat reads call metadata: the Conn
+    answers the hello with results, and calls sent to it carry their
+    metadata from then on.  Calls with metadata made before that message
+    is received wait for it.
+
+Each direction switches the encoding of calls at a point that both vats
+see in the same place of the message stream: the Return of the hello
+that the remote vat sent.  Calls sent after it have a metadatacp.Params
+as the content of their params, which holds the call's parameters and
+its metadata; calls sent before it, and all calls between vats that
+have not both opted in, have the call's parameters as usual.  If the
+remote vat did not send a hello, the metadata of outgoing calls is
+dropped, which is reported to the ErrorReporter once.
+
+Incoming calls are delivered with a Context that carries the received
+metadata, so calls that a method makes with that Context pass it on.
+*/
+
+// callMetadataHello is the method that starts the negotiation of call
+// metadata.
+var callMetadataHello = capnp.Method{
+	InterfaceID:   metadatacp.CallMetadata_TypeID,
+	MethodID:      0,
+	InterfaceName: "metadata.capnp:CallMetadata",
+	MethodName:    "hello",
+}
+
+// CallMetadataMap is a set of key-value pairs that accompanies a call
+// from caller to callee, such as a request ID, an auth token or trace
+// context.  A CallMetadataMap must not be modified once it is in a
+// Context.
+type CallMetadataMap map[string]string
+
+type metadataKey struct{}
+
+// WithCallMetadata returns a Context carrying md, merged over any
+// metadata that ctx already carries.  Calls made with the Context over
+// a Conn with Options.CallMetadata set send the metadata to the remote
+// vat, if it has set Options.CallMetadata too.
+func WithCallMetadata(ctx context.Context, md CallMetadataMap) context.Context {
+	if len(md) == 0 {
+		return ctx
+	}
+	old := CallMetadata(ctx)
+	merged := make(CallMetadataMap, len(old)+len(md))
+	for k, v := range old {
+		merged[k] = v
+	}
+	for k, v := range md {
+		merged[k] = v
+	}
+	return context.WithValue(ctx, metadataKey{}, merged)
+}
+
+// CallMetadata returns the metadata that ctx carries, or nil if it
+// carries none.  In a method called over a Conn, this is the metadata
+// that the caller sent.  The returned map must not be modified.
+func CallMetadata(ctx context.Context) CallMetadataMap {
+	md, _ := ctx.Value(metadataKey{}).(CallMetadataMap)
+	return md
+}
+
+// sendCallMetadataHello sends the Bootstrap and hello that start the
+// negotiation of call metadata.  It must be called before c sends
+// anything else.
+//
+// The caller MUST NOT be holding onto c.lk.
+func (c *Conn) sendCallMetadataHello() {
+	boot := c.Bootstrap(c.bgctx)
+	defer boot.Release()
+	ans, release := boot.SendCall(c.bgctx, capnp.Send{Method: callMetadataHello})
+	c.callMetadataHello, _ = c.getAnswerQuestion(ans)
+
+	c.tasks.Add(1)
+	go func() {
+		defer c.tasks.Done()
+		select {
+		case <-ans.Done():
+			release()
+		case <-c.bgctx.Done():
+		}
+	}()
+}
+
+// isCallMetadataHello reports whether call is to CallMetadata.hello,
+// with its results sent to the caller.
+func isCallMetadataHello(call rpccp.Call) bool {
+	return call.InterfaceId() == callMetadataHello.InterfaceID &&
+		call.MethodId() == callMetadataHello.MethodID &&
+		call.SendResultsTo().Which() == rpccp.Call_sendResultsTo_Which_caller
+}
+
+// answerCallMetadataHello answers the hello that the remote vat sent as
+// its first message other than a Bootstrap, and settles that it
+// accepts call metadata.  Calls that c sends from now on carry their
+// metadata.
+//
+// The caller MUST be holding onto c.lk.
+func (c *Conn) answerCallMetadataHello(rl *releaseList, ans *answer) error {
+	if _, err := ans.AllocResults(capnp.ObjectSize{}); err != nil {
+		ans.sendException(rl, err)
+		c.settleCallMetadata(false)
+		return nil
+	}
+	err := ans.sendReturn(rl)
+	c.settleCallMetadata(true)
+	return err
+}
+
+// settleCallMetadata records whether the remote vat accepts call
+// metadata.  It is called by the receive goroutine, holding onto c.lk
+// if accept is true.
+func (c *Conn) settleCallMetadata(accept bool) {
+	if !c.callMetadata || c.callMetadataSettledYet() {
+		return
+	}
+	c.peerCallMetadata = accept
+	close(c.callMetadataSettled)
+}
+
+// callMetadataSettledYet reports whether c knows whether the remote vat
+// accepts call metadata.
+func (c *Conn) callMetadataSettledYet() bool {
+	select {
+	case <-c.callMetadataSettled:
+		return true
+	default:
+		return false
+	}
+}
+
+// awaitCallMetadata waits until c knows whether the remote vat accepts
+// call metadata, if a call made with ctx would send any, or until ctx
+// is done or c shuts down.  It is called before sending a call from
+// outside of the receive goroutine.
+//
+// The caller MUST NOT be holding onto c.lk.
+func (c *Conn) awaitCallMetadata(ctx context.Context) {
+	if !c.callMetadata || len(CallMetadata(ctx)) == 0 {
+		return
+	}
+	select {
+	case <-c.callMetadataSettled:
+	case <-ctx.Done():
+	case <-c.bgctx.Done():
+	}
+}
+
+// outgoingMetadata returns the metadata to send with a call made with
+// ctx, or nil if there is none or the remote vat does not accept it.
+//
+// The caller MUST be holding onto c.lk.
+func (c *Conn) outgoingMetadata(ctx context.Context) CallMetadataMap {
+	if !c.callMetadata {
+		return nil
+	}
+	md := CallMetadata(ctx)
+	if len(md) == 0 {
+		return nil
+	}
+	if !c.callMetadataSettledYet() {
+		// ctx is done or c is shutting down, so the call fails anyway.
+		return nil
+	}
+	if !c.peerCallMetadata {
+		if !c.metadataDropReported.Swap(true) {
+			c.er.ReportError(rpcerr.Failedf("remote vat does not accept call metadata; dropping it"))
+		}
+		return nil
+	}
+	return md
+}
+
+// newCallArgs allocates the parameters of a call in payload.  If the
+// remote vat reads call metadata, they are wrapped in a
+// metadatacp.Params along with md.
+//
+// The caller MUST be holding onto c.lk.
+func (c *Conn) newCallArgs(payload rpccp.Payload, sz capnp.ObjectSize, md CallMetadataMap) (capnp.Struct, error) {
+	if !c.callMetadata || !c.callMetadataSettledYet() || !c.peerCallMetadata {
+		args, err := capnp.NewStruct(payload.Segment(), sz)
+		if err != nil {
+			return capnp.Struct{}, err
+		}
+		return args, payload.SetContent(args.ToPtr())
+	}
+	params, err := metadatacp.NewParams(payload.Segment())
+	if err != nil {
+		return capnp.Struct{}, err
+	}
+	if err := payload.SetContent(params.ToPtr()); err != nil {
+		return capnp.Struct{}, err
+	}
+	args, err := capnp.NewStruct(params.Segment(), sz)
+	if err != nil {
+		return capnp.Struct{}, err
+	}
+	if err := params.SetContent(args.ToPtr()); err != nil {
+		return capnp.Struct{}, err
+	}
+	keys := make([]string, 0, len(md))
+	for k := range md {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	entries, err := params.NewMetadata(int32(len(keys)))
+	if err != nil {
+		return capnp.Struct{}, err
+	}
+	for i, k := range keys {
+		if err := entries.At(i).SetKey(k); err != nil {
+			return capnp.Struct{}, err
+		}
+		if err := entries.At(i).SetValue(md[k]); err != nil {
+			return capnp.Struct{}, err
+		}
+	}
+	return args, nil
+}
+
+// readCallArgs returns the parameters and metadata of an incoming call,
+// given the content of its params.  The metadata is nil if there is
+// none.
+//
+// Only the receive goroutine may call readCallArgs.
+func (c *Conn) readCallArgs(content capnp.Ptr) (capnp.Struct, CallMetadataMap, error) {
+	if !c.peerWrapsCalls {
+		return content.Struct(), nil, nil
+	}
+	params := metadatacp.Params(content.Struct())
+	args, err := params.Content()
+	if err != nil {
+		return capnp.Struct{}, nil, err
+	}
+	entries, err := params.Metadata()
+	if err != nil || entries.Len() == 0 {
+		return args.Struct(), nil, err
+	}
+	md := make(CallMetadataMap, entries.Len())
+	for i := 0; i < entries.Len(); i++ {
+		k, err := entries.At(i).Key()
+		if err != nil {
+			return capnp.Struct{}, nil, err
+		}
+		v, err := entries.At(i).Value()
+		if err != nil {
+			return capnp.Struct{}, nil, err
+		}
+		md[k] = v
+	}
+	return args.Struct(), md, nil
+}
@@ -0,0 +1,368 @@
+package rpc_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"capnproto.org/go/capnp/v3"
+	"capnproto.org/go/capnp/v3/rpc"
+	"capnproto.org/go/capnp/v3/rpc/internal/metadatacp"
+	testcp "capnproto.org/go/capnp/v3/rpc/internal/testcapnp"
+	"capnproto.org/go/capnp/v3/rpc/transport"
+	rpccp "capnproto.org/go/capnp/v3/std/capnp/rpc"
+)
+
+func TestWithCallMetadata(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	if md := rpc.CallMetadata(ctx); md != nil {
+		t.Errorf("CallMetadata(context.Background()) = %v; want nil", md)
+	}
+	ctx = rpc.WithCallMetadata(ctx, rpc.CallMetadataMap{"a": "1", "b": "2"})
+	ctx2 := rpc.WithCallMetadata(ctx, rpc.CallMetadataMap{"b": "3"})
+	if md := rpc.CallMetadata(ctx2); len(md) != 2 || md["a"] != "1" || md["b"] != "3" {
+		t.Errorf("CallMetadata after merge = %v; want map[a:1 b:3]", md)
+	}
+	if md := rpc.CallMetadata(ctx); md["b"] != "2" {
+		t.Errorf("merging modified parent metadata: %v", md)
+	}
+}
+
+// TestCallMetadata makes a call through a vat that forwards it to
+// another vat, and checks that the metadata reaches the last vat.
+func TestCallMetadata(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	got := make(chan rpc.CallMetadataMap, 1)
+	last := newMetadataTestConns(t, mdPingPong{got: got}, true)
+	defer last.Release()
+	first := newMetadataTestConns(t, forwardPingPong{next: last}, true)
+	defer first.Release()
+
+	ctx = rpc.WithCallMetadata(ctx, rpc.CallMetadataMap{
+		"request-id": "abc123",
+		"empty":      "",
+	})
+	ans, release := first.EchoNum(ctx, func(p testcp.PingPong_echoNum_Params) error {
+		p.SetN(42)
+		return nil
+	})
+	defer release()
+	res, err := ans.Struct()
+	if err != nil {
+		t.Fatal("EchoNum:", err)
+	}
+	if res.N() != 42 {
+		t.Errorf("EchoNum(42) = %d", res.N())
+	}
+	md := <-got
+	if len(md) != 2 || md["request-id"] != "abc123" || md["empty"] != "" {
+		t.Errorf("received metadata %v; want map[empty: request-id:abc123]", md)
+	}
+}
+
+// TestCallMetadataDisabled checks that metadata is not delivered
+// unless both vats enable it, and that a vat that enables it reports
+// that it is dropped.
+func TestCallMetadataDisabled(t *testing.T) {
+	t.Parallel()
+
+	ctx := rpc.WithCallMetadata(context.Background(), rpc.CallMetadataMap{"k": "v"})
+	for _, enabled := range []bool{false, true} {
+		got := make(chan rpc.CallMetadataMap, 1)
+		er := &countingErrorReporter{}
+		pp := newMetadataTestConnsWithReporter(t, mdPingPong{got: got}, er, enabled, !enabled)
+		for i := 0; i < 2; i++ {
+			ans, release := pp.EchoNum(ctx, func(p testcp.PingPong_echoNum_Params) error {
+				p.SetN(1)
+				return nil
+			})
+			if _, err := ans.Struct(); err != nil {
+				t.Error("EchoNum:", err)
+			}
+			release()
+			if md := <-got; md != nil {
+				t.Errorf("client enabled = %t, server enabled = %t: received metadata %v; want none", enabled, !enabled, md)
+			}
+		}
+		pp.Release()
+		want := 0
+		if enabled {
+			want = 1
+		}
+		if n := er.count(); n != want {
+			t.Errorf("client enabled = %t: reported %d errors; want %d", enabled, n, want)
+		}
+	}
+}
+
+// TestCallMetadataHelloFailed checks that a vat that enables call
+// metadata starts with a Bootstrap and a hello, and does not send
+// metadata to a remote vat that fails the hello.
+func TestCallMetadataHelloFailed(t *testing.T) {
+	t.Parallel()
+
+	left, right := transport.NewPipe(1)
+	p1, p2 := rpc.NewTransport(left), rpc.NewTransport(right)
+	conn := rpc.NewConn(p1, &rpc.Options{
+		ErrorReporter: testErrorReporter{tb: t},
+		CallMetadata:  true,
+	})
+	defer finishTest(t, conn, p2)
+
+	ctx := context.Background()
+	helloQID := recvCallMetadataHello(t, p2)
+	err := sendMessage(ctx, p2, &rpcMessage{
+		Which: rpccp.Message_Which_return,
+		Return: &rpcReturn{
+			AnswerID: helloQID,
+			Which:    rpccp.Return_Which_exception,
+			Exception: &rpcException{
+				Type:   rpccp.Exception_Type_unimplemented,
+				Reason: "method not implemented",
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx = rpc.WithCallMetadata(ctx, rpc.CallMetadataMap{"k": "v"})
+	pp := testcp.PingPong(conn.Bootstrap(ctx))
+	defer pp.Release()
+	go func() {
+		ans, release := pp.EchoNum(ctx, func(p testcp.PingPong_echoNum_Params) error {
+			p.SetN(42)
+			return nil
+		})
+		defer release()
+		ans.Struct()
+	}()
+
+	call, release := recvCallTo(t, p2, testcp.PingPong_TypeID)
+	defer release()
+	args := call.Params.Content.Struct()
+	if sz := args.Size(); sz != (capnp.ObjectSize{DataSize: 8}) {
+		t.Errorf("call params have size %v; want the echoNum parameters", sz)
+	}
+	if n := testcp.PingPong_echoNum_Params(args).N(); n != 42 {
+		t.Errorf("call params n = %d; want 42", n)
+	}
+}
+
+// TestCallMetadataHello checks that a vat that enables call metadata
+// answers the remote vat's hello, and sends the metadata of the calls
+// it makes afterward.
+func TestCallMetadataHello(t *testing.T) {
+	t.Parallel()
+
+	left, right := transport.NewPipe(1)
+	p1, p2 := rpc.NewTransport(left), rpc.NewTransport(right)
+	conn := rpc.NewConn(p1, &rpc.Options{
+		ErrorReporter: testErrorReporter{tb: t},
+		CallMetadata:  true,
+	})
+	defer finishTest(t, conn, p2)
+
+	ctx := context.Background()
+	recvCallMetadataHello(t, p2)
+	for _, msg := range []*rpcMessage{
+		{
+			Which:     rpccp.Message_Which_bootstrap,
+			Bootstrap: &rpcBootstrap{QuestionID: 0},
+		},
+		{
+			Which: rpccp.Message_Which_call,
+			Call: &rpcCall{
+				QuestionID: 1,
+				Target: rpcMessageTarget{
+					Which:          rpccp.MessageTarget_Which_promisedAnswer,
+					PromisedAnswer: &rpcPromisedAnswer{QuestionID: 0},
+				},
+				InterfaceID: metadatacp.CallMetadata_TypeID,
+				MethodID:    0,
+			},
+		},
+	} {
+		if err := sendMessage(ctx, p2, msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for {
+		rmsg, release, err := recvMessage(ctx, p2)
+		if err != nil {
+			t.Fatal("recvMessage:", err)
+		}
+		release()
+		if rmsg.Which != rpccp.Message_Which_return || rmsg.Return.AnswerID != 1 {
+			continue
+		}
+		if rmsg.Return.Which != rpccp.Return_Which_results {
+			t.Fatalf("hello return which = %v; want results", rmsg.Return.Which)
+		}
+		break
+	}
+
+	ctx = rpc.WithCallMetadata(ctx, rpc.CallMetadataMap{"k": "v"})
+	pp := testcp.PingPong(conn.Bootstrap(ctx))
+	defer pp.Release()
+	go func() {
+		ans, release := pp.EchoNum(ctx, func(p testcp.PingPong_echoNum_Params) error {
+			p.SetN(42)
+			return nil
+		})
+		defer release()
+		ans.Struct()
+	}()
+
+	call, release := recvCallTo(t, p2, testcp.PingPong_TypeID)
+	defer release()
+	params := metadatacp.Params(call.Params.Content.Struct())
+	content, err := params.Content()
+	if err != nil {
+		t.Fatal("read params content:", err)
+	}
+	if n := testcp.PingPong_echoNum_Params(content.Struct()).N(); n != 42 {
+		t.Errorf("call params n = %d; want 42", n)
+	}
+	entries, err := params.Metadata()
+	if err != nil {
+		t.Fatal("read params metadata:", err)
+	}
+	if entries.Len() != 1 {
+		t.Fatalf("call has %d metadata entries; want 1", entries.Len())
+	}
+	k, _ := entries.At(0).Key()
+	v, _ := entries.At(0).Value()
+	if k != "k" || v != "v" {
+		t.Errorf("call metadata entry = %q: %q; want \"k\": \"v\"", k, v)
+	}
+}
+
+// recvCallMetadataHello receives the Bootstrap and hello that a Conn
+// that enables call metadata starts with, and returns the question ID
+// of the hello.
+func recvCallMetadataHello(t *testing.T, p rpc.Transport) uint32 {
+	ctx := context.Background()
+	boot, release, err := recvMessage(ctx, p)
+	if err != nil {
+		t.Fatal("recvMessage:", err)
+	}
+	release()
+	if boot.Which != rpccp.Message_Which_bootstrap {
+		t.Fatalf("first message is a %v; want bootstrap", boot.Which)
+	}
+	hello, release, err := recvMessage(ctx, p)
+	if err != nil {
+		t.Fatal("recvMessage:", err)
+	}
+	release()
+	if hello.Which != rpccp.Message_Which_call {
+		t.Fatalf("second message is a %v; want call", hello.Which)
+	}
+	if hello.Call.InterfaceID != metadatacp.CallMetadata_TypeID || hello.Call.MethodID != 0 {
+		t.Errorf("second message calls @%#x.%d; want CallMetadata.hello", hello.Call.InterfaceID, hello.Call.MethodID)
+	}
+	if pa := hello.Call.Target.PromisedAnswer; pa == nil || pa.QuestionID != boot.Bootstrap.QuestionID {
+		t.Error("hello is not called on the bootstrap capability")
+	}
+	return hello.Call.QuestionID
+}
+
+// recvCallTo receives messages until one is a call to the interface
+// with the given ID, and returns the call.
+func recvCallTo(t *testing.T, p rpc.Transport, interfaceID uint64) (*rpcCall, capnp.ReleaseFunc) {
+	for {
+		rmsg, release, err := recvMessage(context.Background(), p)
+		if err != nil {
+			t.Fatal("recvMessage:", err)
+		}
+		if rmsg.Which == rpccp.Message_Which_call && rmsg.Call.InterfaceID == interfaceID {
+			return rmsg.Call, release
+		}
+		release()
+	}
+}
+
+// newMetadataTestConns connects a client to a server serving impl, and
+// returns the client's bootstrap capability.  The client and server
+// enable call metadata as given by enabled, or both use enabled[0] if
+// there is only one.
+func newMetadataTestConns(t *testing.T, impl testcp.PingPong_Server, enabled ...bool) testcp.PingPong {
+	return newMetadataTestConnsWithReporter(t, impl, testErrorReporter{tb: t}, enabled...)
+}
+
+// newMetadataTestConnsWithReporter is like newMetadataTestConns, but
+// the client reports errors to er.
+func newMetadataTestConnsWithReporter(t *testing.T, impl testcp.PingPong_Server, er rpc.ErrorReporter, enabled ...bool) testcp.PingPong {
+	_, client := newTestConns(t, &rpc.Options{
+		BootstrapClient: capnp.Client(testcp.PingPong_ServerToClient(impl)),
+		ErrorReporter:   testErrorReporter{tb: t},
+		CallMetadata:    enabled[len(enabled)-1],
+	}, &rpc.Options{
+		ErrorReporter: er,
+		CallMetadata:  enabled[0],
+	})
+	return testcp.PingPong(client.Bootstrap(context.Background()))
+}
+
+// mdPingPong echoes numbers and sends the metadata of each call to got.
+type mdPingPong struct {
+	got chan<- rpc.CallMetadataMap
+}
+
+func (p mdPingPong) EchoNum(ctx context.Context, call testcp.PingPong_echoNum) error {
+	p.got <- rpc.CallMetadata(ctx)
+	out, err := call.AllocResults()
+	if err != nil {
+		return err
+	}
+	out.SetN(call.Args().N())
+	return nil
+}
+
+// forwardPingPong forwards calls to next with the Context it is
+// called with.
+type forwardPingPong struct {
+	next testcp.PingPong
+}
+
+func (p forwardPingPong) EchoNum(ctx context.Context, call testcp.PingPong_echoNum) error {
+	n := call.Args().N()
+	ans, release := p.next.EchoNum(ctx, func(p testcp.PingPong_echoNum_Params) error {
+		p.SetN(n)
+		return nil
+	})
+	defer release()
+	res, err := ans.Struct()
+	if err != nil {
+		return err
+	}
+	out, err := call.AllocResults()
+	if err != nil {
+		return err
+	}
+	out.SetN(res.N())
+	return nil
+}
+
+// countingErrorReporter counts the errors reported to it.
+type countingErrorReporter struct {
+	mu sync.Mutex
+	n  int
+}
+
+func (r *countingErrorReporter) ReportError(error) {
+	r.mu.Lock()
+	r.n++
+	r.mu.Unlock()
+}
+
+func (r *countingErrorReporter) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.n
+}
@@ -0,0 +1,376 @@
+// Package metrics provides an rpc.Observer that keeps Prometheus-style
+// counters and histograms about connections, and writes them in the
+// Prometheus text exposition format.
+package metrics // import "capnproto.org/go/capnp/v3/rpc/metrics"
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"capnproto.org/go/capnp/v3/exc"
+	"capnproto.org/go/capnp/v3/rpc"
+)
+
+var _ rpc.Observer = (*Metrics)(nil)
+
+// DefaultBuckets are the upper bounds of the call duration histogram
+// buckets, in seconds.  They are the same as Prometheus's default.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Metrics is an rpc.Observer that counts the events on the connections
+// it observes.  It can be shared by any number of connections, and is
+// an http.Handler that serves the metrics for Prometheus to scrape.
+//
+// Metric names start with "capnp_rpc_".  Besides counters and a
+// histogram of call durations, there are gauges that sum Conn.Usage
+// over the connections that have not shut down.
+type Metrics struct {
+	mu    sync.Mutex
+	conns map[*rpc.Conn]struct{}
+
+	messagesSent     *vec
+	messagesReceived *vec
+	bytesSent        *vec
+	bytesReceived    *vec
+	sendQueue        *vec
+	events           *vec
+	callsStarted     *vec
+	callErrors       *vec
+	callDuration     *histogramVec
+}
+
+// New returns a Metrics with no observations.
+func New() *Metrics {
+	return &Metrics{
+		conns: make(map[*rpc.Conn]struct{}),
+		messagesSent: newVec("capnp_rpc_messages_sent_total", "counter",
+			"Messages sent, by message type.", "type"),
+		messagesReceived: newVec("capnp_rpc_messages_received_total", "counter",
+			"Messages received, by message type.", "type"),
+		bytesSent: newVec("capnp_rpc_message_bytes_sent_total", "counter",
+			"Bytes of messages sent."),
+		bytesReceived: newVec("capnp_rpc_message_bytes_received_total", "counter",
+			"Bytes of messages received."),
+		sendQueue: newVec("capnp_rpc_send_queue_max", "gauge",
+			"Largest number of messages seen waiting in a send queue."),
+		events: newVec("capnp_rpc_events_total", "counter",
+			"Embargoes, disembargoes, finishes and shutdowns, by kind.", "kind"),
+		callsStarted: newVec("capnp_rpc_calls_started_total", "counter",
+			"Calls started, by direction, interface ID and method ID.", "direction", "interface", "method"),
+		callErrors: newVec("capnp_rpc_call_errors_total", "counter",
+			"Calls that returned an exception, by direction, interface ID, method ID and exception type.", "direction", "interface", "method", "type"),
+		callDuration: newHistogramVec("capnp_rpc_call_duration_seconds",
+			"Time from the start of a call to its return, by direction, interface ID and method ID.", DefaultBuckets, "direction", "interface", "method"),
+	}
+}
+
+// Event implements rpc.Observer.
+func (m *Metrics) Event(ev rpc.Event) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.track(ev.Conn, ev.Kind == rpc.EventShutdown)
+	switch ev.Kind {
+	case rpc.EventMessageSent:
+		m.messagesSent.add(1, ev.Message.String())
+		m.bytesSent.add(float64(ev.Size))
+		m.sendQueue.max(float64(ev.SendQueue))
+	case rpc.EventMessageReceived:
+		m.messagesReceived.add(1, ev.Message.String())
+		m.bytesReceived.add(float64(ev.Size))
+	default:
+		m.events.add(1, ev.Kind.String())
+	}
+}
+
+// CallStarted implements rpc.Observer.
+func (m *Metrics) CallStarted(ctx context.Context, call rpc.CallInfo) context.Context {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.track(call.Conn, false)
+	m.callsStarted.add(1, callLabels(call)...)
+	return ctx
+}
+
+// CallReturned implements rpc.Observer.
+func (m *Metrics) CallReturned(ctx context.Context, call rpc.CallInfo, err error, elapsed time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	lvs := callLabels(call)
+	m.callDuration.observe(elapsed.Seconds(), lvs...)
+	if err != nil {
+		m.callErrors.add(1, append(lvs, exc.TypeOf(err).String())...)
+	}
+}
+
+// track adds c to the connections whose usage is reported, or removes
+// it if shutdown is true.  The caller must hold m.mu.
+func (m *Metrics) track(c *rpc.Conn, shutdown bool) {
+	if c == nil {
+		return
+	}
+	if shutdown {
+		delete(m.conns, c)
+		return
+	}
+	if _, ok := m.conns[c]; ok {
+		return
+	}
+	select {
+	case <-c.Done():
+		// An event that raced with shutdown.
+	default:
+		m.conns[c] = struct{}{}
+	}
+}
+
+// callLabels returns the direction, interface and method labels for a
+// call.  IDs are used rather than names, since incoming calls have no
+// names.
+func callLabels(call rpc.CallInfo) []string {
+	dir := "outgoing"
+	if call.Incoming {
+		dir = "incoming"
+	}
+	return []string{
+		dir,
+		"0x" + strconv.FormatUint(call.Method.InterfaceID, 16),
+		strconv.FormatUint(uint64(call.Method.MethodID), 10),
+	}
+}
+
+// ServeHTTP writes the metrics in the Prometheus text format.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.WriteTo(w)
+}
+
+// WriteTo writes the metrics to w in the Prometheus text format.
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	// Conn.Usage acquires the Conn's lock, which may be held while
+	// the Conn calls into m, so it must not be called with m.mu held.
+	m.mu.Lock()
+	conns := make([]*rpc.Conn, 0, len(m.conns))
+	for c := range m.conns {
+		conns = append(conns, c)
+	}
+	m.mu.Unlock()
+	var u rpc.Usage
+	for _, c := range conns {
+		cu := c.Usage()
+		u.Questions += cu.Questions
+		u.Answers += cu.Answers
+		u.Exports += cu.Exports
+		u.Imports += cu.Imports
+		u.Embargoes += cu.Embargoes
+		u.CallBytes += cu.CallBytes
+		u.SendQueue += cu.SendQueue
+	}
+
+	cw := &countingWriter{w: bufio.NewWriter(w)}
+	writeGauge(cw, "capnp_rpc_conns", "Connections that have not shut down.", float64(len(conns)))
+	writeGauge(cw, "capnp_rpc_questions", "Outgoing calls that have not been finished.", float64(u.Questions))
+	writeGauge(cw, "capnp_rpc_answers", "Incoming calls that have not been finished.", float64(u.Answers))
+	writeGauge(cw, "capnp_rpc_exports", "Capabilities exported to remote vats.", float64(u.Exports))
+	writeGauge(cw, "capnp_rpc_imports", "Capabilities imported from remote vats.", float64(u.Imports))
+	writeGauge(cw, "capnp_rpc_embargoes", "Embargoes waiting for a Disembargo.", float64(u.Embargoes))
+	writeGauge(cw, "capnp_rpc_call_bytes", "Bytes of incoming Call messages held by running calls.", float64(u.CallBytes))
+	writeGauge(cw, "capnp_rpc_send_queue", "Messages waiting to be sent.", float64(u.SendQueue))
+
+	m.mu.Lock()
+	m.messagesSent.write(cw)
+	m.messagesReceived.write(cw)
+	m.bytesSent.write(cw)
+	m.bytesReceived.write(cw)
+	m.sendQueue.write(cw)
+	m.events.write(cw)
+	m.callsStarted.write(cw)
+	m.callErrors.write(cw)
+	m.callDuration.write(cw)
+	m.mu.Unlock()
+
+	if cw.err == nil {
+		cw.err = cw.w.Flush()
+	}
+	return cw.n, cw.err
+}
+
+// A vec is a counter or gauge with labels.
+type vec struct {
+	name, typ, help string
+	labels          []string
+	values          map[string]float64 // by formatted label set
+}
+
+func newVec(name, typ, help string, labels ...string) *vec {
+	return &vec{
+		name:   name,
+		typ:    typ,
+		help:   help,
+		labels: labels,
+		values: make(map[string]float64),
+	}
+}
+
+func (v *vec) add(x float64, lvs ...string) {
+	v.values[formatLabels(v.labels, lvs)] += x
+}
+
+func (v *vec) max(x float64, lvs ...string) {
+	k := formatLabels(v.labels, lvs)
+	if old, ok := v.values[k]; !ok || x > old {
+		v.values[k] = x
+	}
+}
+
+func (v *vec) write(w *countingWriter) {
+	writeHeader(w, v.name, v.typ, v.help)
+	if len(v.labels) == 0 && len(v.values) == 0 {
+		w.printf("%s 0\n", v.name)
+		return
+	}
+	for _, k := range sortedKeys(v.values) {
+		w.printf("%s%s %s\n", v.name, k, formatFloat(v.values[k]))
+	}
+}
+
+// A histogramVec is a histogram with labels.
+type histogramVec struct {
+	name, help string
+	buckets    []float64
+	labels     []string
+	values     map[string]*histogram
+}
+
+type histogram struct {
+	counts []uint64 // per bucket, not cumulative; the last is +Inf
+	sum    float64
+	count  uint64
+}
+
+func newHistogramVec(name, help string, buckets []float64, labels ...string) *histogramVec {
+	return &histogramVec{
+		name:    name,
+		help:    help,
+		buckets: buckets,
+		labels:  labels,
+		values:  make(map[string]*histogram),
+	}
+}
+
+func (v *histogramVec) observe(x float64, lvs ...string) {
+	k := formatLabels(v.labels, lvs)
+	h := v.values[k]
+	if h == nil {
+		h = &histogram{counts: make([]uint64, len(v.buckets)+1)}
+		v.values[k] = h
+	}
+	i := sort.SearchFloat64s(v.buckets, x)
+	h.counts[i]++
+	h.sum += x
+	h.count++
+}
+
+func (v *histogramVec) write(w *countingWriter) {
+	writeHeader(w, v.name, "histogram", v.help)
+	keys := make([]string, 0, len(v.values))
+	for k := range v.values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		h := v.values[k]
+		var cum uint64
+		for i, n := range h.counts {
+			cum += n
+			le := "+Inf"
+			if i < len(v.buckets) {
+				le = formatFloat(v.buckets[i])
+			}
+			w.printf("%s_bucket%s %d\n", v.name, addLabel(k, "le", le), cum)
+		}
+		w.printf("%s_sum%s %s\n", v.name, k, formatFloat(h.sum))
+		w.printf("%s_count%s %d\n", v.name, k, h.count)
+	}
+}
+
+func writeHeader(w *countingWriter, name, typ, help string) {
+	w.printf("# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+func writeGauge(w *countingWriter, name, help string, x float64) {
+	writeHeader(w, name, "gauge", help)
+	w.printf("%s %s\n", name, formatFloat(x))
+}
+
+// formatLabels returns a label set in the form {a="x",b="y"}, or the
+// empty string if there are no labels.
+func formatLabels(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(name)
+		sb.WriteString(`="`)
+		sb.WriteString(labelValueEscaper.Replace(values[i]))
+		sb.WriteByte('"')
+	}
+	sb.WriteByte('}')
+	return sb.String()
+}
+
+// addLabel adds a label to a label set returned by formatLabels.
+func addLabel(labels, name, value string) string {
+	l := name + `="` + labelValueEscaper.Replace(value) + `"`
+	if labels == "" {
+		return "{" + l + "}"
+	}
+	return labels[:len(labels)-1] + "," + l + "}"
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatFloat(x float64) string {
+	return strconv.FormatFloat(x, 'g', -1, 64)
+}
+
+func sortedKeys(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// countingWriter counts the bytes written to w and keeps the first
+// error.
+type countingWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func (cw *countingWriter) printf(format string, args ...any) {
+	if cw.err != nil {
+		return
+	}
+	n, err := fmt.Fprintf(cw.w, format, args...)
+	cw.n += int64(n)
+	cw.err = err
+}
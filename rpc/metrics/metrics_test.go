@@ -0,0 +1,77 @@
+package metrics_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"capnproto.org/go/capnp/v3"
+	"capnproto.org/go/capnp/v3/rpc"
+	testcp "capnproto.org/go/capnp/v3/rpc/internal/testcapnp"
+	"capnproto.org/go/capnp/v3/rpc/metrics"
+	"capnproto.org/go/capnp/v3/rpc/transport"
+)
+
+func TestMetrics(t *testing.T) {
+	m := metrics.New()
+	left, right := transport.NewPipe(1)
+	server := rpc.NewConn(rpc.NewTransport(left), &rpc.Options{
+		BootstrapClient: capnp.Client(testcp.PingPong_ServerToClient(pingPongServer{})),
+		Observer:        m,
+	})
+	client := rpc.NewConn(rpc.NewTransport(right), &rpc.Options{
+		Observer: m,
+	})
+	defer func() {
+		client.Close()
+		<-server.Done()
+	}()
+
+	ctx := context.Background()
+	pp := testcp.PingPong(client.Bootstrap(ctx))
+	defer pp.Release()
+	ans, release := pp.EchoNum(ctx, func(p testcp.PingPong_echoNum_Params) error {
+		p.SetN(42)
+		return nil
+	})
+	if _, err := ans.Struct(); err != nil {
+		t.Fatal("EchoNum:", err)
+	}
+	release()
+
+	var buf bytes.Buffer
+	n, err := m.WriteTo(&buf)
+	if err != nil {
+		t.Fatal("WriteTo:", err)
+	}
+	if n != int64(buf.Len()) {
+		t.Errorf("WriteTo returned %d; wrote %d bytes", n, buf.Len())
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"capnp_rpc_conns 2\n",
+		`capnp_rpc_messages_sent_total{type="call"} 1` + "\n",
+		`capnp_rpc_messages_received_total{type="call"} 1` + "\n",
+		`capnp_rpc_calls_started_total{direction="outgoing",interface="0xf004c474c2f8ee7a",method="0"} 1` + "\n",
+		`capnp_rpc_calls_started_total{direction="incoming",interface="0xf004c474c2f8ee7a",method="0"} 1` + "\n",
+		`capnp_rpc_call_duration_seconds_bucket{direction="incoming",interface="0xf004c474c2f8ee7a",method="0",le="+Inf"} 1` + "\n",
+		`capnp_rpc_call_duration_seconds_count{direction="outgoing",interface="0xf004c474c2f8ee7a",method="0"} 1` + "\n",
+		"# TYPE capnp_rpc_call_duration_seconds histogram\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("metrics do not contain %q; got:\n%s", want, out)
+		}
+	}
+}
+
+type pingPongServer struct{}
+
+func (pingPongServer) EchoNum(ctx context.Context, call testcp.PingPong_echoNum) error {
+	out, err := call.AllocResults()
+	if err != nil {
+		return err
+	}
+	out.SetN(call.Args().N())
+	return nil
+}
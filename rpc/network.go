@@ -0,0 +1,100 @@
+package rpc
+
+import (
+	"context"
+
+	"capnproto.org/go/capnp/v3"
+)
+
+// A VatNetwork connects a vat to the other vats of a multi-party
+// network.  Connections created with a VatNetwork in their Options
+// take part in three-party handoffs (level 3 of the protocol): when a
+// capability imported over one connection is sent over another
+// connection of the same network, the receiving vat is introduced to
+// the vat hosting the capability and picks it up directly, instead of
+// having every call proxied through this vat.
+//
+// A VatNetwork also lets joins (level 4 of the protocol) span its
+// connections: the capabilities joined may be imported from different
+// vats, as long as they proxy them for a single vat.  See Conn.Join.
+//
+// The identifiers passed between vats are network-specific, and are
+// opaque to the rpc package; see the ThirdPartyCapId, RecipientId,
+// ProvisionId and JoinKeyPart types in rpc.capnp.
+//
+// A VatNetwork must be comparable: two connections take part in a
+// handoff only if their Options.Network values are equal.
+type VatNetwork interface {
+	// Introduce prepares a handoff of a capability hosted by the vat
+	// at the other end of provider to the vat at the other end of
+	// recipient.  The returned RecipientID is sent to the provider in
+	// a Provide message, and the ThirdPartyCapID is sent to the
+	// recipient in a thirdPartyHosted capability descriptor.
+	//
+	// Introduce is called while a message is being built, so it must
+	// return quickly and must not call methods on either Conn.
+	Introduce(provider, recipient *Conn) (IntroductionInfo, error)
+
+	// DialIntroduced connects to the vat identified by a
+	// ThirdPartyCapID that was received from introducedBy, re-using
+	// any existing connection.  It returns the connection and the
+	// ProvisionID to send in the Accept message.
+	DialIntroduced(ctx context.Context, capID ThirdPartyCapID, introducedBy *Conn) (*Conn, ProvisionID, error)
+
+	// AcceptIntroduced returns the connection to the vat identified by
+	// a RecipientID that was received from introducedBy in a Provide
+	// message, waiting for the recipient to connect if needed.  It also
+	// returns the ProvisionID that the recipient will send in its
+	// Accept message.  Two ProvisionIDs are considered equal if their
+	// canonical encodings are equal.
+	AcceptIntroduced(ctx context.Context, recipientID RecipientID, introducedBy *Conn) (*Conn, ProvisionID, error)
+
+	// NewJoinKeyParts starts a join of partCount capabilities by this
+	// vat.  It returns an ID for the join that is unique among the
+	// joins in progress from this vat, and the key part to send in
+	// each of its Join messages.  The key parts identify this vat, so
+	// that the parts can meet at the vat hosting the capabilities,
+	// whichever vats they are forwarded through.
+	NewJoinKeyParts(partCount uint16) (joinID uint32, parts []JoinKeyPart, err error)
+
+	// AcceptJoinKeyPart reads the key part of a Join message that was
+	// received from receivedFrom, and whose target is hosted by this
+	// vat.  It returns the connection to the vat that started the
+	// join, connecting to it if needed, and the part's place in the
+	// join.  The parts of a join are collected on that connection.
+	AcceptJoinKeyPart(ctx context.Context, kp JoinKeyPart, receivedFrom *Conn) (*Conn, JoinKey, error)
+}
+
+// IntroductionInfo is the result of VatNetwork.Introduce.
+type IntroductionInfo struct {
+	SendToRecipient ThirdPartyCapID
+	SendToProvider  RecipientID
+}
+
+// A ThirdPartyCapID identifies a capability hosted by a third-party
+// vat, along with the means to connect to that vat.
+type ThirdPartyCapID capnp.Ptr
+
+// A RecipientID identifies the vat that is expected to pick up a
+// provided capability.
+type RecipientID capnp.Ptr
+
+// A ProvisionID identifies a provided capability to the vat hosting it.
+// It must be a struct pointer.
+type ProvisionID capnp.Ptr
+
+// A JoinKeyPart identifies one part of a join, and the vat that started
+// it, to the vat hosting the capability being joined.
+type JoinKeyPart capnp.Ptr
+
+// A JoinKey is a JoinKeyPart as read by VatNetwork.AcceptJoinKeyPart.
+type JoinKey struct {
+	// ID is the ID of the join returned by NewJoinKeyParts in the vat
+	// that started it.
+	ID uint32
+
+	// PartCount is the number of parts in the join, and PartNum is the
+	// index of this part, less than PartCount.
+	PartCount uint16
+	PartNum   uint16
+}
@@ -0,0 +1,180 @@
+package rpc
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"capnproto.org/go/capnp/v3"
+	rpccp "capnproto.org/go/capnp/v3/std/capnp/rpc"
+)
+
+// An Observer is notified of events on a Conn, for metrics and tracing.
+// Its methods may be called from any goroutine, sometimes while the
+// Conn is holding its lock: they should be quick to return and must not
+// use the Conn, other than to call Conn.Usage from another goroutine.
+//
+// The rpc/metrics and rpc/tracing packages provide Observers.
+type Observer interface {
+	// Event is called for each Event on a Conn.
+	Event(Event)
+
+	// CallStarted is called when a call is sent to the remote vat or
+	// received from it.  It returns the Context to make the call
+	// with, which must be ctx or derived from it.  For an incoming
+	// call, it is the Context that the call is delivered with.
+	CallStarted(ctx context.Context, call CallInfo) context.Context
+
+	// CallReturned is called once a call that CallStarted was called
+	// for has returned, with the Context that CallStarted returned.
+	// err is the exception that the call returned, if any.
+	CallReturned(ctx context.Context, call CallInfo, err error, elapsed time.Duration)
+}
+
+// EventKind is the kind of an Event.
+type EventKind int
+
+// Kinds of events.
+const (
+	// EventMessageSent is reported just before a message is sent,
+	// with its type and size.  SendQueue is the number of messages
+	// queued behind it.
+	EventMessageSent EventKind = iota
+
+	// EventMessageReceived is reported when a message is received,
+	// with its type and size.
+	EventMessageReceived
+
+	// EventEmbargo is reported when calls to a capability are
+	// embargoed until a Disembargo echoes back.  ID is the embargo ID.
+	EventEmbargo
+
+	// EventDisembargo is reported when an embargo is lifted.  ID is
+	// the embargo ID.
+	EventDisembargo
+
+	// EventFinishSent is reported when a Finish message has been sent
+	// for a question.  ID is the question ID.
+	EventFinishSent
+
+	// EventFinishReceived is reported when a Finish message is
+	// received for an answer.  ID is the answer ID.
+	EventFinishReceived
+
+	// EventShutdown is reported when the Conn has shut down.  Err is
+	// the error that was sent to the remote vat as an abort, if any.
+	EventShutdown
+)
+
+// String returns the kind's name, such as "message_sent".
+func (k EventKind) String() string {
+	switch k {
+	case EventMessageSent:
+		return "message_sent"
+	case EventMessageReceived:
+		return "message_received"
+	case EventEmbargo:
+		return "embargo"
+	case EventDisembargo:
+		return "disembargo"
+	case EventFinishSent:
+		return "finish_sent"
+	case EventFinishReceived:
+		return "finish_received"
+	case EventShutdown:
+		return "shutdown"
+	default:
+		return "unknown"
+	}
+}
+
+// An Event is something that happened on a Conn.  Which fields are
+// set depends on Kind.
+type Event struct {
+	Conn *Conn
+	Kind EventKind
+
+	Message   rpccp.Message_Which // type of message sent or received
+	Size      uint64              // size of message sent or received, in bytes
+	SendQueue int                 // messages waiting to be sent
+	ID        uint32              // question, answer or embargo ID
+	Err       error
+}
+
+// CallInfo describes a call sent to or received from a remote vat.
+type CallInfo struct {
+	Conn     *Conn
+	Method   capnp.Method
+	Incoming bool // whether the call was received from the remote vat
+
+	// ID is the question ID of an outgoing call or the answer ID of
+	// an incoming call.
+	ID uint32
+}
+
+// observe reports ev to c's observer, if any.
+func (c *Conn) observe(ev Event) {
+	if c.observer == nil {
+		return
+	}
+	ev.Conn = c
+	c.observer.Event(ev)
+}
+
+// observeMessage reports a message sent or received to c's observer,
+// if any.
+func (c *Conn) observeMessage(kind EventKind, m rpccp.Message, queued int) {
+	if c.observer == nil || !m.IsValid() {
+		return
+	}
+	size, _ := m.Message().TotalSize()
+	c.observe(Event{
+		Kind:      kind,
+		Message:   m.Which(),
+		Size:      size,
+		SendQueue: queued,
+	})
+}
+
+// A callTrace reports the return of a call to an observer.  A nil
+// *callTrace reports nothing.
+type callTrace struct {
+	obs   Observer
+	ctx   context.Context
+	info  CallInfo
+	start time.Time
+	once  sync.Once
+}
+
+// traceCall reports the start of a call to c's observer, returning the
+// Context to make the call with and a callTrace to report its return.
+// If c has no observer, traceCall returns ctx and nil.
+func (c *Conn) traceCall(ctx context.Context, m capnp.Method, incoming bool, id uint32) (context.Context, *callTrace) {
+	if c.observer == nil {
+		return ctx, nil
+	}
+	info := CallInfo{
+		Conn:     c,
+		Method:   m,
+		Incoming: incoming,
+		ID:       id,
+	}
+	ctx = c.observer.CallStarted(ctx, info)
+	return ctx, &callTrace{
+		obs:   c.observer,
+		ctx:   ctx,
+		info:  info,
+		start: time.Now(),
+	}
+}
+
+// done reports that the call returned err.  Only the first call to done
+// is reported.
+func (t *callTrace) done(err error) {
+	if t == nil {
+		return
+	}
+	t.once.Do(func() {
+		t.obs.CallReturned(t.ctx, t.info, err, time.Since(t.start))
+	})
+}
@@ -0,0 +1,178 @@
+package rpc_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"capnproto.org/go/capnp/v3"
+	"capnproto.org/go/capnp/v3/rpc"
+	testcp "capnproto.org/go/capnp/v3/rpc/internal/testcapnp"
+	"capnproto.org/go/capnp/v3/rpc/transport"
+	rpccp "capnproto.org/go/capnp/v3/std/capnp/rpc"
+)
+
+// TestObserver makes a call between two connections with observers,
+// and checks what each observer was told.
+func TestObserver(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	left, right := transport.NewPipe(1)
+	p1, p2 := rpc.NewTransport(left), rpc.NewTransport(right)
+
+	clientObs := recordingObserver{finished: make(chan struct{}, 8)}
+	serverObs := recordingObserver{finished: make(chan struct{}, 8)}
+	gotCtx := make(chan bool, 1)
+	server := rpc.NewConn(p1, &rpc.Options{
+		BootstrapClient: capnp.Client(testcp.PingPong_ServerToClient(ctxCheckPingPong{
+			got: gotCtx,
+		})),
+		ErrorReporter: testErrorReporter{tb: t},
+		Observer:      &serverObs,
+	})
+	client := rpc.NewConn(p2, &rpc.Options{
+		ErrorReporter: testErrorReporter{tb: t},
+		Observer:      &clientObs,
+	})
+
+	pp := testcp.PingPong(client.Bootstrap(ctx))
+	ans, release := pp.EchoNum(ctx, func(p testcp.PingPong_echoNum_Params) error {
+		p.SetN(42)
+		return nil
+	})
+	if _, err := ans.Struct(); err != nil {
+		t.Fatal("EchoNum:", err)
+	}
+	release()
+	pp.Release()
+	if !<-gotCtx {
+		t.Error("server method was not called with the Context from CallStarted")
+	}
+
+	// Finish is sent after the return is delivered, so wait for it
+	// before closing.
+	for _, obs := range []*recordingObserver{&clientObs, &serverObs} {
+		select {
+		case <-obs.finished:
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for Finish")
+		}
+	}
+
+	if err := client.Close(); err != nil {
+		t.Error("client.Close():", err)
+	}
+	<-server.Done()
+
+	clientObs.mu.Lock()
+	defer clientObs.mu.Unlock()
+	serverObs.mu.Lock()
+	defer serverObs.mu.Unlock()
+
+	if len(clientObs.returned) != 1 || clientObs.returned[0].Incoming || clientObs.returned[0].Conn != client {
+		t.Errorf("client observed returns %+v; want one outgoing call", clientObs.returned)
+	} else if m := clientObs.returned[0].Method; m.InterfaceID != testcp.PingPong_TypeID || m.MethodID != 0 {
+		t.Errorf("client observed call to %v; want PingPong.echoNum", &m)
+	}
+	if len(serverObs.returned) != 1 || !serverObs.returned[0].Incoming || serverObs.returned[0].Conn != server {
+		t.Errorf("server observed returns %+v; want one incoming call", serverObs.returned)
+	}
+	if n := clientObs.count(rpc.EventMessageSent, rpccp.Message_Which_call); n != 1 {
+		t.Errorf("client observed %d calls sent; want 1", n)
+	}
+	if n := serverObs.count(rpc.EventMessageReceived, rpccp.Message_Which_call); n != 1 {
+		t.Errorf("server observed %d calls received; want 1", n)
+	}
+	if n := serverObs.count(rpc.EventMessageSent, rpccp.Message_Which_return); n < 2 {
+		t.Errorf("server observed %d returns sent; want at least 2", n)
+	}
+	if n := clientObs.count(rpc.EventFinishSent, 0); n < 1 {
+		t.Errorf("client observed %d finishes sent; want at least 1", n)
+	}
+	if n := serverObs.count(rpc.EventFinishReceived, 0); n < 1 {
+		t.Errorf("server observed %d finishes received; want at least 1", n)
+	}
+	if n := clientObs.count(rpc.EventShutdown, 0); n != 1 {
+		t.Errorf("client observed %d shutdowns; want 1", n)
+	}
+	for _, ev := range clientObs.events {
+		if ev.Conn != client {
+			t.Errorf("client observed %v event on another Conn", ev.Kind)
+		}
+		if ev.Kind == rpc.EventMessageSent && ev.Size == 0 {
+			t.Errorf("client observed sending %v message of zero size", ev.Message)
+		}
+	}
+}
+
+type ctxKey struct{}
+
+// recordingObserver records the events and returned calls it observes.
+// CallStarted adds ctxKey to the Context.  If finished is not nil, it
+// receives a value for each Finish sent or received, unless it is full.
+type recordingObserver struct {
+	mu       sync.Mutex
+	events   []rpc.Event
+	returned []rpc.CallInfo
+	finished chan struct{}
+}
+
+func (o *recordingObserver) Event(ev rpc.Event) {
+	o.mu.Lock()
+	o.events = append(o.events, ev)
+	o.mu.Unlock()
+	if o.finished != nil && (ev.Kind == rpc.EventFinishSent || ev.Kind == rpc.EventFinishReceived) {
+		select {
+		case o.finished <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (o *recordingObserver) CallStarted(ctx context.Context, call rpc.CallInfo) context.Context {
+	return context.WithValue(ctx, ctxKey{}, true)
+}
+
+func (o *recordingObserver) CallReturned(ctx context.Context, call rpc.CallInfo, err error, elapsed time.Duration) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if ctx.Value(ctxKey{}) == nil {
+		// Not the Context from CallStarted: fail the test's check.
+		call.Conn = nil
+	}
+	o.returned = append(o.returned, call)
+}
+
+// count returns the number of events of kind, and of message type
+// which if kind is a message event.  The caller must hold o.mu.
+func (o *recordingObserver) count(kind rpc.EventKind, which rpccp.Message_Which) int {
+	n := 0
+	for _, ev := range o.events {
+		if ev.Kind != kind {
+			continue
+		}
+		if (kind == rpc.EventMessageSent || kind == rpc.EventMessageReceived) && ev.Message != which {
+			continue
+		}
+		n++
+	}
+	return n
+}
+
+// ctxCheckPingPong echoes numbers and reports whether the Context
+// it was called with came from recordingObserver.CallStarted.
+type ctxCheckPingPong struct {
+	got chan<- bool
+}
+
+func (p ctxCheckPingPong) EchoNum(ctx context.Context, call testcp.PingPong_echoNum) error {
+	p.got <- ctx.Value(ctxKey{}) != nil
+	out, err := call.AllocResults()
+	if err != nil {
+		return err
+	}
+	out.SetN(call.Args().N())
+	return nil
+}
@@ -0,0 +1,64 @@
+package rpc
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+)
+
+// PeerInfo identifies the remote vat of a Conn.
+type PeerInfo struct {
+	// RemoteAddr is the network address of the remote vat, or nil if
+	// it is not known.
+	RemoteAddr net.Addr
+
+	// TLS is the state of the connection if it uses TLS, or nil.
+	TLS *tls.ConnectionState
+
+	// Certificates is the certificate chain that the remote vat
+	// presented during the TLS handshake, leaf first.  It is empty if
+	// the connection does not use TLS or the remote vat presented no
+	// certificate, as with a client when the server does not ask for
+	// one.
+	Certificates []*x509.Certificate
+}
+
+// newPeerInfo returns the PeerInfo of the remote end of conn.  If conn
+// is a *tls.Conn, its handshake must be complete.
+func newPeerInfo(conn net.Conn) *PeerInfo {
+	peer := &PeerInfo{RemoteAddr: conn.RemoteAddr()}
+	if tc, ok := conn.(*tls.Conn); ok {
+		state := tc.ConnectionState()
+		peer.TLS = &state
+		peer.Certificates = state.PeerCertificates
+	}
+	return peer
+}
+
+type peerKey struct{}
+
+// Peer returns the identity of the remote vat that made the call being
+// served with ctx, if its Conn was given one with Options.Peer, as the
+// Conns created by a Server and DialTLS are.
+func Peer(ctx context.Context) (*PeerInfo, bool) {
+	peer, ok := ctx.Value(peerKey{}).(*PeerInfo)
+	return peer, ok
+}
+
+// DialTLS connects to the vat at addr over TLS, as tls.Dial does, and
+// returns a Conn whose Options.Peer identifies the server.  For mutual
+// TLS, config should have the client's certificate.  opts may be nil.
+func DialTLS(ctx context.Context, network, addr string, config *tls.Config, opts *Options) (*Conn, error) {
+	d := &tls.Dialer{Config: config}
+	conn, err := d.DialContext(ctx, network, addr)
+	if err != nil {
+		return nil, rpcerr.Annotate(err, "dial")
+	}
+	var o Options
+	if opts != nil {
+		o = *opts
+	}
+	o.Peer = newPeerInfo(conn)
+	return NewConn(NewStreamTransport(conn), &o), nil
+}
@@ -15,11 +15,17 @@ type question struct {
 	c  *Conn
 	id questionID
 
+	// bootstrapPromise is set for Bootstrap and Accept messages, whose
+	// result is a single capability.
 	bootstrapPromise *capnp.ClientPromise
 
 	p       *capnp.Promise
 	release capnp.ReleaseFunc // written before resolving p
 
+	// trace reports the return of a call to the Conn's observer.
+	// May be nil.
+	trace *callTrace
+
 	// Protected by c.mu:
 
 	flags         questionFlags
@@ -41,6 +47,16 @@ const (
 	// successfully.  It is only valid to query after finishMsgSend is
 	// closed.
 	finishSent
+
+	// resultsSentElsewhere is set for a tail call: a question whose
+	// Call message has sendResultsTo.yourself set.  See tailcall.go.
+	resultsSentElsewhere
+
+	// returnReceived is set when a tail call's Return message has been
+	// received.  The question stays in the table until its Context is
+	// canceled, at which point handleCancel sends the Finish message,
+	// removes the question from the table and retires its ID.
+	returnReceived
 )
 
 // newQuestion adds a new question to c's table.  The caller must be
@@ -62,6 +78,14 @@ func (c *Conn) newQuestion(method capnp.Method) *question {
 	return q
 }
 
+// removeQuestion removes the question with the given ID from c's
+// table, without releasing its ID.  The caller must be holding onto
+// c.lk.
+func (c *Conn) removeQuestion(id questionID) {
+	c.lk.questions[id] = nil
+	c.signalIdle()
+}
+
 func (c *Conn) getAnswerQuestion(ans *capnp.Answer) (*question, bool) {
 	m := ans.Metadata()
 	m.Lock()
@@ -118,14 +142,25 @@ func (q *question) handleCancel(ctx context.Context) {
 		fin.SetReleaseResultCaps(true)
 		return nil
 	}, func(err error) {
-		if err == nil {
-			syncutil.With(&q.c.lk, func() { q.flags |= finishSent })
-		} else if q.c.bgctx.Err() == nil {
+		syncutil.With(&q.c.lk, func() {
+			if q.flags&returnReceived != 0 && int(q.id) < len(q.c.lk.questions) {
+				q.c.removeQuestion(q.id)
+			}
+			if err == nil {
+				q.flags |= finishSent
+				if q.flags&returnReceived != 0 {
+					q.c.lk.questionID.remove(uint32(q.id))
+				}
+				q.c.observe(Event{Kind: EventFinishSent, ID: uint32(q.id)})
+			}
+		})
+		if err != nil && q.c.bgctx.Err() == nil {
 			q.c.er.ReportError(rpcerr.Annotate(err, "send finish"))
 		}
 		close(q.finishMsgSend)
 
 		q.p.Reject(rejectErr)
+		q.trace.done(rejectErr)
 		if q.bootstrapPromise != nil {
 			q.bootstrapPromise.Fulfill(q.p.Answer().Client())
 			q.p.ReleaseClients()
@@ -134,6 +169,7 @@ func (q *question) handleCancel(ctx context.Context) {
 }
 
 func (q *question) PipelineSend(ctx context.Context, transform []capnp.PipelineOp, s capnp.Send) (*capnp.Answer, capnp.ReleaseFunc) {
+	q.c.awaitCallMetadata(ctx)
 	q.c.lk.Lock()
 	defer q.c.lk.Unlock()
 
@@ -150,16 +186,19 @@ func (q *question) PipelineSend(ctx context.Context, transform []capnp.PipelineO
 	// c) the worst that happens is we trade bandwidth for code simplicity.
 	q.mark(transform)
 	q2 := q.c.newQuestion(s.Method)
+	ctx, q2.trace = q.c.traceCall(ctx, s.Method, false, uint32(q2.id))
 
 	// Send call message.
 	q.c.sendMessage(ctx, func(m rpccp.Message) error {
-		return q.c.newPipelineCallMessage(m, q.id, transform, q2.id, s)
+		return q.c.newPipelineCallMessage(m, q.id, transform, q2.id, s, q.c.outgoingMetadata(ctx))
 	}, func(err error) {
 		if err != nil {
 			syncutil.With(&q.c.lk, func() {
-				q.c.lk.questions[q2.id] = nil
+				q.c.removeQuestion(q2.id)
 			})
-			q2.p.Reject(rpcerr.Failedf("send message: %w", err))
+			err = rpcerr.Failedf("send message: %w", err)
+			q2.p.Reject(err)
+			q2.trace.done(err)
 			syncutil.With(&q.c.lk, func() {
 				q.c.lk.questionID.remove(uint32(q2.id))
 			})
@@ -184,7 +223,7 @@ func (q *question) PipelineSend(ctx context.Context, transform []capnp.PipelineO
 // newPipelineCallMessage builds a Call message targeted to a promised answer..
 //
 // The caller MUST hold c.mu.
-func (c *Conn) newPipelineCallMessage(msg rpccp.Message, tgt questionID, transform []capnp.PipelineOp, qid questionID, s capnp.Send) error {
+func (c *Conn) newPipelineCallMessage(msg rpccp.Message, tgt questionID, transform []capnp.PipelineOp, qid questionID, s capnp.Send, md CallMetadataMap) error {
 	call, err := msg.NewCall()
 	if err != nil {
 		return rpcerr.Failedf("build call message: %w", err)
@@ -214,13 +253,10 @@ func (c *Conn) newPipelineCallMessage(msg rpccp.Message, tgt questionID, transfo
 	if err != nil {
 		return rpcerr.Failedf("build call message: %w", err)
 	}
-	args, err := capnp.NewStruct(payload.Segment(), s.ArgsSize)
+	args, err := c.newCallArgs(payload, s.ArgsSize, md)
 	if err != nil {
 		return rpcerr.Failedf("build call message: %w", err)
 	}
-	if err := payload.SetContent(args.ToPtr()); err != nil {
-		return rpcerr.Failedf("build call message: %w", err)
-	}
 
 	if s.PlaceArgs == nil {
 		return nil
@@ -239,6 +275,9 @@ func (c *Conn) newPipelineCallMessage(msg rpccp.Message, tgt questionID, transfo
 }
 
 func (q *question) PipelineRecv(ctx context.Context, transform []capnp.PipelineOp, r capnp.Recv) capnp.PipelineCaller {
+	if ans := q.c.tailAnswer(r); ans != nil {
+		return q.tailCall(ctx, transform, ans, r)
+	}
 	ans, finish := q.PipelineSend(ctx, transform, capnp.Send{
 		Method:   r.Method,
 		ArgsSize: r.Args.Size(),
@@ -285,6 +324,7 @@ func (q *question) Reject(err error) {
 		if q.p != nil {
 			q.p.Reject(err)
 		}
+		q.trace.done(err)
 	}
 }
 
@@ -0,0 +1,146 @@
+package rpc
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"capnproto.org/go/capnp/v3"
+	"capnproto.org/go/capnp/v3/exc"
+)
+
+// quota holds the resource limits of a Conn, as set in Options, along
+// with the usage that is not protected by c.lk.  A zero limit means
+// there is no limit.
+type quota struct {
+	maxIncomingCalls int
+	maxExports       int
+	maxCallBytes     uint64
+	maxMessageSize   uint64
+
+	// callBytes is the total size of the Call messages held by
+	// running calls.  It is only increased by the receive goroutine.
+	callBytes atomic.Uint64
+}
+
+func (q *quota) set(opts *Options) {
+	q.maxIncomingCalls = opts.MaxIncomingCalls
+	q.maxExports = opts.MaxExports
+	q.maxCallBytes = opts.MaxCallBytes
+	q.maxMessageSize = opts.MaxMessageSize
+}
+
+// Usage reports the resources that a Conn is using, for comparison
+// with the limits set in Options.
+type Usage struct {
+	Questions int    // outgoing calls that have not been finished
+	Answers   int    // incoming calls that have not been finished
+	Exports   int    // capabilities exported to the remote vat
+	Imports   int    // capabilities imported from the remote vat
+	Embargoes int    // embargoes waiting for a Disembargo
+	CallBytes uint64 // size of the Call messages held by running calls
+	SendQueue int    // messages waiting to be sent
+}
+
+// Usage returns the resources that c is currently using.
+func (c *Conn) Usage() Usage {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+
+	u := Usage{
+		Answers:   len(c.lk.answers),
+		Exports:   c.lk.numExports,
+		Imports:   len(c.lk.imports),
+		CallBytes: c.quota.callBytes.Load(),
+		SendQueue: int(c.sendQueue.Load()),
+	}
+	for _, q := range c.lk.questions {
+		if q != nil {
+			u.Questions++
+		}
+	}
+	for _, e := range c.lk.embargoes {
+		if e != nil {
+			u.Embargoes++
+		}
+	}
+	return u
+}
+
+// overloadedf returns an overloaded exception wrapping ErrQuotaExceeded.
+func overloadedf(format string, args ...any) error {
+	return rpcerr.New(exc.Overloaded, fmt.Errorf("%w: %s", ErrQuotaExceeded, fmt.Sprintf(format, args...)))
+}
+
+// checkMessageSize returns an error if an incoming message is larger
+// than the limit.  The error aborts the connection.  Transports that
+// implement transport.MessageSizeLimiter reject such a message before
+// reading it, so this only catches the messages of other transports.
+func (c *Conn) checkMessageSize(msg *capnp.Message) error {
+	max := c.quota.maxMessageSize
+	if max == 0 {
+		return nil
+	}
+	size, err := msg.TotalSize()
+	if err != nil {
+		return rpcerr.Failedf("read message: %w", err)
+	}
+	if size > max {
+		return overloadedf("message of %d bytes exceeds limit of %d", size, max)
+	}
+	return nil
+}
+
+// checkIncomingQuestion checks whether another incoming question,
+// such as a call or a bootstrap, is within MaxIncomingCalls.  It
+// returns an error to answer the question with if not, or an error to
+// abort the connection with if the remote vat keeps asking far beyond
+// the limit.
+//
+// The caller must be holding onto c.lk.
+func (c *Conn) checkIncomingQuestion() (reject, abort error) {
+	if max := c.quota.maxIncomingCalls; max > 0 {
+		n := len(c.lk.answers)
+		if n >= 2*max {
+			return nil, overloadedf("%d incoming calls outstanding, limit is %d", n, max)
+		}
+		if n >= max {
+			return overloadedf("%d incoming calls outstanding, limit is %d", n, max), nil
+		}
+	}
+	return nil, nil
+}
+
+// checkIncomingCall checks whether an incoming call of size bytes is
+// within the limits, as checkIncomingQuestion does.
+//
+// The caller must be holding onto c.lk.
+func (c *Conn) checkIncomingCall(size uint64) (reject, abort error) {
+	if reject, abort = c.checkIncomingQuestion(); reject != nil || abort != nil {
+		return reject, abort
+	}
+	if max := c.quota.maxCallBytes; max > 0 {
+		if n := c.quota.callBytes.Load(); n+size > max {
+			return overloadedf("%d bytes of incoming calls held, limit is %d", n+size, max), nil
+		}
+	}
+	return nil, nil
+}
+
+// holdCall counts size bytes of a Call message against the quota until
+// the returned function, which calls release, is called.
+func (q *quota) holdCall(size uint64, release capnp.ReleaseFunc) capnp.ReleaseFunc {
+	q.callBytes.Add(size)
+	return func() {
+		q.callBytes.Add(-size)
+		release()
+	}
+}
+
+// checkExportQuota returns an error if no more capabilities may be
+// exported.  The caller must be holding onto c.lk.
+func (c *Conn) checkExportQuota() error {
+	if max := c.quota.maxExports; max > 0 && c.lk.numExports >= max {
+		return overloadedf("%d capabilities exported, limit is %d", c.lk.numExports, max)
+	}
+	return nil
+}
@@ -0,0 +1,361 @@
+package rpc_test
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"runtime"
+	"testing"
+	"time"
+
+	"capnproto.org/go/capnp/v3"
+	"capnproto.org/go/capnp/v3/rpc"
+	"capnproto.org/go/capnp/v3/rpc/transport"
+	"capnproto.org/go/capnp/v3/server"
+	rpccp "capnproto.org/go/capnp/v3/std/capnp/rpc"
+)
+
+// TestMaxIncomingCalls makes calls on a connection with a limit on
+// incoming calls, and checks that the call over the limit is answered
+// with an overloaded exception.
+func TestMaxIncomingCalls(t *testing.T) {
+	t.Parallel()
+
+	unblock := make(chan struct{})
+	srv := newServer(func(ctx context.Context, call *server.Call) error {
+		<-unblock
+		return nil
+	}, nil)
+	left, right := transport.NewPipe(1)
+	p1, p2 := rpc.NewTransport(left), rpc.NewTransport(right)
+	conn := rpc.NewConn(p1, &rpc.Options{
+		BootstrapClient:  srv,
+		ErrorReporter:    testErrorReporter{tb: t},
+		MaxIncomingCalls: 2,
+	})
+	defer finishTest(t, conn, p2)
+	ctx := context.Background()
+
+	// 1. Bootstrap and make a call, which blocks.  Together they use up
+	// the limit.
+	const bootstrapQID = 1
+	bootstrapQuotaTestConn(ctx, t, p2, bootstrapQID)
+	const callQID = 2
+	sendQuotaTestCall(ctx, t, p2, callQID, bootstrapQID, capnp.Ptr{})
+
+	// 2. Make a call over the limit and check that it is rejected.
+	const overQID = 3
+	sendQuotaTestCall(ctx, t, p2, overQID, bootstrapQID, capnp.Ptr{})
+	checkOverloadedReturn(ctx, t, p2, overQID)
+	if n := conn.Usage().Answers; n != 3 {
+		t.Errorf("conn.Usage().Answers = %d; want 3", n)
+	}
+	finishQuotaTestCall(ctx, t, p2, overQID)
+
+	// 3. Let the first call return.
+	close(unblock)
+	rmsg, release, err := recvMessage(ctx, p2)
+	if err != nil {
+		t.Fatal("recvMessage(ctx, p2):", err)
+	}
+	defer release()
+	if rmsg.Which != rpccp.Message_Which_return || rmsg.Return.AnswerID != callQID {
+		t.Fatalf("Received %v message; want return for answer %d", rmsg.Which, callQID)
+	}
+	if rmsg.Return.Which != rpccp.Return_Which_results {
+		t.Errorf("Return.Which = %v; want results", rmsg.Return.Which)
+	}
+	finishQuotaTestCall(ctx, t, p2, callQID)
+}
+
+// TestMaxIncomingCallsBootstrap checks that bootstraps count against
+// the limit on incoming calls, and that a bootstrap over the limit is
+// answered with an overloaded exception.
+func TestMaxIncomingCallsBootstrap(t *testing.T) {
+	t.Parallel()
+
+	left, right := transport.NewPipe(1)
+	p1, p2 := rpc.NewTransport(left), rpc.NewTransport(right)
+	conn := rpc.NewConn(p1, &rpc.Options{
+		BootstrapClient:  newServer(nil, nil),
+		ErrorReporter:    testErrorReporter{tb: t},
+		MaxIncomingCalls: 2,
+	})
+	defer finishTest(t, conn, p2)
+	ctx := context.Background()
+
+	bootstrapQuotaTestConn(ctx, t, p2, 1)
+	bootstrapQuotaTestConn(ctx, t, p2, 2)
+
+	const overQID = 3
+	err := sendMessage(ctx, p2, &rpcMessage{
+		Which:     rpccp.Message_Which_bootstrap,
+		Bootstrap: &rpcBootstrap{QuestionID: overQID},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkOverloadedReturn(ctx, t, p2, overQID)
+	if n := conn.Usage().Answers; n != 3 {
+		t.Errorf("conn.Usage().Answers = %d; want 3", n)
+	}
+	finishQuotaTestCall(ctx, t, p2, overQID)
+}
+
+// TestMaxCallBytes makes a call that holds onto its parameters on a
+// connection with a limit on held call bytes, and checks that a call
+// with large parameters is answered with an overloaded exception.
+func TestMaxCallBytes(t *testing.T) {
+	t.Parallel()
+
+	unblock := make(chan struct{})
+	srv := newServer(func(ctx context.Context, call *server.Call) error {
+		<-unblock
+		return nil
+	}, nil)
+	left, right := transport.NewPipe(1)
+	p1, p2 := rpc.NewTransport(left), rpc.NewTransport(right)
+	conn := rpc.NewConn(p1, &rpc.Options{
+		BootstrapClient: srv,
+		ErrorReporter:   testErrorReporter{tb: t},
+		MaxCallBytes:    1024,
+	})
+	defer finishTest(t, conn, p2)
+	ctx := context.Background()
+
+	const bootstrapQID = 1
+	bootstrapQuotaTestConn(ctx, t, p2, bootstrapQID)
+	const callQID = 2
+	sendQuotaTestCall(ctx, t, p2, callQID, bootstrapQID, capnp.Ptr{})
+
+	const overQID = 3
+	sendQuotaTestCall(ctx, t, p2, overQID, bootstrapQID, newQuotaTestData(t, 1024))
+	checkOverloadedReturn(ctx, t, p2, overQID)
+	if n := conn.Usage().CallBytes; n == 0 || n > 1024 {
+		t.Errorf("conn.Usage().CallBytes = %d; want in (0, 1024]", n)
+	}
+	finishQuotaTestCall(ctx, t, p2, overQID)
+
+	close(unblock)
+	rmsg, release, err := recvMessage(ctx, p2)
+	if err != nil {
+		t.Fatal("recvMessage(ctx, p2):", err)
+	}
+	defer release()
+	if rmsg.Which != rpccp.Message_Which_return || rmsg.Return.AnswerID != callQID {
+		t.Fatalf("Received %v message; want return for answer %d", rmsg.Which, callQID)
+	}
+	finishQuotaTestCall(ctx, t, p2, callQID)
+}
+
+// TestMaxMessageSize sends a message larger than the connection's
+// limit, and checks that the connection is aborted.
+func TestMaxMessageSize(t *testing.T) {
+	t.Parallel()
+
+	left, right := transport.NewPipe(1)
+	p1, p2 := rpc.NewTransport(left), rpc.NewTransport(right)
+	conn := rpc.NewConn(p1, &rpc.Options{
+		BootstrapClient: newServer(nil, nil),
+		ErrorReporter:   testErrorReporter{tb: t},
+		MaxMessageSize:  1024,
+	})
+	defer p2.Close()
+	ctx := context.Background()
+
+	const bootstrapQID = 1
+	bootstrapQuotaTestConn(ctx, t, p2, bootstrapQID)
+	sendQuotaTestCall(ctx, t, p2, 2, bootstrapQID, newQuotaTestData(t, 1024))
+
+	for {
+		rmsg, release, err := recvMessage(ctx, p2)
+		if err != nil {
+			t.Fatal("recvMessage(ctx, p2):", err)
+		}
+		w := rmsg.Which
+		var ex rpcException
+		if rmsg.Abort != nil {
+			ex = *rmsg.Abort
+		}
+		release()
+		if w == rpccp.Message_Which_release {
+			continue
+		}
+		if w != rpccp.Message_Which_abort {
+			t.Fatalf("Received %v message; want abort", w)
+		}
+		if ex.Type != rpccp.Exception_Type_overloaded {
+			t.Errorf("Abort type = %v; want overloaded", ex.Type)
+		}
+		break
+	}
+	<-conn.Done()
+}
+
+// TestMaxMessageSizeHeader sends the header of a message that claims
+// to be much larger than the connection's limit over a stream
+// transport, and checks that the connection is aborted without
+// allocating space for the message.
+func TestMaxMessageSizeHeader(t *testing.T) {
+	// Not parallel, so that other tests don't add to the allocations.
+
+	const claimed = 32 << 20
+	c1, c2 := net.Pipe()
+	defer c2.Close()
+	conn := rpc.NewConn(rpc.NewStreamTransport(c1), &rpc.Options{
+		ErrorReporter:  testErrorReporter{tb: t},
+		MaxMessageSize: 1024,
+	})
+	defer conn.Close()
+	go io.Copy(io.Discard, c2)
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	var hdr [8]byte
+	binary.LittleEndian.PutUint32(hdr[4:], claimed/8) // one segment
+	if _, err := c2.Write(hdr[:]); err != nil {
+		t.Fatal("write header:", err)
+	}
+	select {
+	case <-conn.Done():
+	case <-time.After(10 * time.Second):
+		t.Fatal("connection still waiting for the message after its header")
+	}
+	runtime.ReadMemStats(&after)
+	if n := after.TotalAlloc - before.TotalAlloc; n >= claimed/2 {
+		t.Errorf("allocated %d bytes after header claiming %d bytes", n, claimed)
+	}
+}
+
+// TestMaxExports makes a call that returns more capabilities than the
+// connection may export, and checks that it returns an overloaded
+// exception instead.
+func TestMaxExports(t *testing.T) {
+	t.Parallel()
+
+	srv := newServer(func(ctx context.Context, call *server.Call) error {
+		res, err := call.AllocResults(capnp.ObjectSize{PointerCount: 1})
+		if err != nil {
+			return err
+		}
+		caps, err := capnp.NewPointerList(res.Segment(), 2)
+		if err != nil {
+			return err
+		}
+		for i := 0; i < caps.Len(); i++ {
+			id := res.Message().AddCap(newServer(nil, nil))
+			if err := caps.Set(i, capnp.NewInterface(res.Segment(), id).ToPtr()); err != nil {
+				return err
+			}
+		}
+		return res.SetPtr(0, caps.ToPtr())
+	}, nil)
+	left, right := transport.NewPipe(1)
+	p1, p2 := rpc.NewTransport(left), rpc.NewTransport(right)
+	conn := rpc.NewConn(p1, &rpc.Options{
+		BootstrapClient: srv,
+		ErrorReporter:   testErrorReporter{tb: t},
+		MaxExports:      2,
+	})
+	defer finishTest(t, conn, p2)
+	ctx := context.Background()
+
+	// The bootstrap capability takes up one export.
+	const bootstrapQID = 1
+	bootstrapQuotaTestConn(ctx, t, p2, bootstrapQID)
+	const callQID = 2
+	sendQuotaTestCall(ctx, t, p2, callQID, bootstrapQID, capnp.Ptr{})
+	checkOverloadedReturn(ctx, t, p2, callQID)
+	if n := conn.Usage().Exports; n != 1 {
+		t.Errorf("conn.Usage().Exports = %d; want 1", n)
+	}
+	finishQuotaTestCall(ctx, t, p2, callQID)
+}
+
+// bootstrapQuotaTestConn sends a Bootstrap message and reads its
+// Return, leaving the answer unfinished.
+func bootstrapQuotaTestConn(ctx context.Context, t *testing.T, p2 rpc.Transport, qid uint32) {
+	err := sendMessage(ctx, p2, &rpcMessage{
+		Which:     rpccp.Message_Which_bootstrap,
+		Bootstrap: &rpcBootstrap{QuestionID: qid},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := recvBootstrapReturn(ctx, p2, qid); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// sendQuotaTestCall sends a Call message to the bootstrap capability
+// with content as its parameters.
+func sendQuotaTestCall(ctx context.Context, t *testing.T, p2 rpc.Transport, qid, bootstrapQID uint32, content capnp.Ptr) {
+	err := sendMessage(ctx, p2, &rpcMessage{
+		Which: rpccp.Message_Which_call,
+		Call: &rpcCall{
+			QuestionID: qid,
+			Target: rpcMessageTarget{
+				Which: rpccp.MessageTarget_Which_promisedAnswer,
+				PromisedAnswer: &rpcPromisedAnswer{
+					QuestionID: bootstrapQID,
+				},
+			},
+			InterfaceID: interfaceID,
+			MethodID:    methodID,
+			Params: rpcPayload{
+				Content: content,
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func finishQuotaTestCall(ctx context.Context, t *testing.T, p2 rpc.Transport, qid uint32) {
+	err := sendMessage(ctx, p2, &rpcMessage{
+		Which: rpccp.Message_Which_finish,
+		Finish: &rpcFinish{
+			QuestionID:        qid,
+			ReleaseResultCaps: true,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// checkOverloadedReturn reads a Return message for qid and checks that
+// it is an overloaded exception.
+func checkOverloadedReturn(ctx context.Context, t *testing.T, p2 rpc.Transport, qid uint32) {
+	rmsg, release, err := recvMessage(ctx, p2)
+	if err != nil {
+		t.Fatal("recvMessage(ctx, p2):", err)
+	}
+	defer release()
+	if rmsg.Which != rpccp.Message_Which_return {
+		t.Fatalf("Received %v message; want return", rmsg.Which)
+	}
+	if rmsg.Return.AnswerID != qid {
+		t.Errorf("Received return for answer %d; want %d", rmsg.Return.AnswerID, qid)
+	}
+	if rmsg.Return.Which != rpccp.Return_Which_exception {
+		t.Fatalf("Return.Which = %v; want exception", rmsg.Return.Which)
+	}
+	if rmsg.Return.Exception.Type != rpccp.Exception_Type_overloaded {
+		t.Errorf("Return.Exception.Type = %v; want overloaded", rmsg.Return.Exception.Type)
+	}
+}
+
+func newQuotaTestData(t *testing.T, n int) capnp.Ptr {
+	_, seg, err := capnp.NewMessage(capnp.SingleSegment(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := capnp.NewData(seg, make([]byte, n))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data.ToPtr()
+}
@@ -0,0 +1,350 @@
+package rpc
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	"capnproto.org/go/capnp/v3"
+	"capnproto.org/go/capnp/v3/internal/syncutil"
+)
+
+// ReconnectState is the state of a ReconnectingClient's connection.
+type ReconnectState int
+
+// Reconnect states.
+const (
+	// StateConnecting means a connection is being dialed and
+	// bootstrapped.  Calls are queued until it is up.
+	StateConnecting ReconnectState = iota
+
+	// StateConnected means calls are being made over a connection.
+	StateConnected
+
+	// StateDisconnected means the last connection attempt failed or
+	// the connection was lost, and the client is waiting to redial.
+	// Calls are queued until the next connection is up.
+	StateDisconnected
+
+	// StateClosed means the client has been closed.  Calls fail.
+	StateClosed
+)
+
+// String returns the state's name.
+func (s ReconnectState) String() string {
+	switch s {
+	case StateConnecting:
+		return "connecting"
+	case StateConnected:
+		return "connected"
+	case StateDisconnected:
+		return "disconnected"
+	case StateClosed:
+		return "closed"
+	default:
+		return "unknown"
+	}
+}
+
+// ReconnectOptions configures a ReconnectingClient.
+type ReconnectOptions struct {
+	// Dial connects to the remote vat.  It must not be nil.
+	Dial func(ctx context.Context) (*Conn, error)
+
+	// Path obtains the capability to call from the remote vat's
+	// bootstrap capability, for instance by calling a method that
+	// returns it.  Path takes ownership of bootstrap.  If Path is nil,
+	// the bootstrap capability is called.
+	Path func(ctx context.Context, bootstrap capnp.Client) (capnp.Client, error)
+
+	// MinBackoff and MaxBackoff bound the time waited before redialing.
+	// The wait starts at MinBackoff and doubles with each failed
+	// attempt or lost connection, up to MaxBackoff.  They default to
+	// 100 milliseconds and 30 seconds.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	// StableAfter is how long a connection must stay up for the wait
+	// to go back to MinBackoff once it is lost, so that a remote vat
+	// that accepts connections and then drops them is not redialed in
+	// a tight loop.  It defaults to 10 seconds.
+	StableAfter time.Duration
+
+	// OnStateChange is called when the state of the client changes,
+	// starting with StateConnecting when the client is created.  err
+	// is the error that caused a change to StateDisconnected, such as
+	// the dial error, the remote vat's abort or the transport error
+	// that ended the connection, and nil otherwise.  OnStateChange is
+	// called from a single goroutine, and the client does not redial
+	// until it returns.
+	OnStateChange func(state ReconnectState, err error)
+}
+
+// A ReconnectingClient is a capability in a remote vat that survives
+// the loss of the connection to it.  When the connection is lost, it
+// redials with backoff, bootstraps the capability again, and sends
+// subsequent calls over the new connection.
+//
+// Calls that were in flight when the connection was lost fail with a
+// disconnected exception, as do calls on capabilities obtained from
+// their results.  Calls made while there is no connection are queued
+// until there is one, and sent over it in order; the caller gets a
+// pending answer right away, on which it may pipeline calls.  A queued
+// call fails if its Context is canceled first.
+type ReconnectingClient struct {
+	opts   ReconnectOptions
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+	client capnp.Client
+
+	mu     sync.Mutex
+	state  ReconnectState
+	target capnp.Client
+
+	// calls holds the calls made while state is StateConnecting or
+	// StateDisconnected.  It is made ready when state changes to
+	// StateConnected or StateClosed, and replaced when it changes back.
+	calls *recvQueue
+}
+
+// NewReconnectingClient returns a ReconnectingClient that starts
+// dialing in the background.
+func NewReconnectingClient(opts ReconnectOptions) *ReconnectingClient {
+	if opts.Dial == nil {
+		panic("NewReconnectingClient: Dial is nil")
+	}
+	if opts.MinBackoff <= 0 {
+		opts.MinBackoff = 100 * time.Millisecond
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = 30 * time.Second
+	}
+	if opts.MaxBackoff < opts.MinBackoff {
+		opts.MaxBackoff = opts.MinBackoff
+	}
+	if opts.StableAfter <= 0 {
+		opts.StableAfter = 10 * time.Second
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	rc := &ReconnectingClient{
+		opts:   opts,
+		ctx:    ctx,
+		cancel: cancel,
+		done:   make(chan struct{}),
+		calls:  new(recvQueue),
+	}
+	rc.client = capnp.NewClient(reconnectHook{rc})
+	go rc.run()
+	return rc
+}
+
+// Client returns a reference to the capability.  Calls on it are made
+// over the current connection.  The caller must release it.
+func (rc *ReconnectingClient) Client() capnp.Client {
+	return rc.client.AddRef()
+}
+
+// State returns the current state of the connection.
+func (rc *ReconnectingClient) State() ReconnectState {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return rc.state
+}
+
+// Close stops redialing and closes the current connection, if any.
+// Queued calls fail.  References returned by Client must still be
+// released.
+//
+// The client keeps redialing until Close is called, even once every
+// reference returned by Client has been released, so Close must always
+// be called.
+func (rc *ReconnectingClient) Close() error {
+	rc.cancel()
+	<-rc.done
+	rc.client.Release()
+	return nil
+}
+
+func (rc *ReconnectingClient) run() {
+	defer close(rc.done)
+	defer rc.setState(StateClosed, nil)
+
+	// The client starts out connecting, so the first setState below
+	// does not report it.
+	if rc.opts.OnStateChange != nil {
+		rc.opts.OnStateChange(StateConnecting, nil)
+	}
+	backoff := rc.opts.MinBackoff
+	for {
+		rc.setState(StateConnecting, nil)
+		conn, target, err := rc.connect()
+		if err != nil {
+			if rc.ctx.Err() != nil {
+				return
+			}
+			rc.setState(StateDisconnected, err)
+		} else {
+			syncutil.With(&rc.mu, func() {
+				rc.target = target
+			})
+			rc.setState(StateConnected, nil)
+			connected := time.Now()
+
+			select {
+			case <-conn.Done():
+			case <-rc.ctx.Done():
+			}
+			syncutil.With(&rc.mu, func() {
+				rc.target = capnp.Client{}
+			})
+			target.Release()
+			if rc.ctx.Err() != nil {
+				conn.Close()
+				return
+			}
+			if time.Since(connected) >= rc.opts.StableAfter {
+				backoff = rc.opts.MinBackoff
+			}
+			var cause error
+			syncutil.With(&conn.lk, func() {
+				cause = conn.disconnectCause()
+			})
+			rc.setState(StateDisconnected, cause)
+		}
+
+		// Wait between half and all of backoff, so that clients that
+		// lost their connections at the same time do not redial in
+		// lockstep.
+		d := backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+		t := time.NewTimer(d)
+		select {
+		case <-t.C:
+		case <-rc.ctx.Done():
+			t.Stop()
+			return
+		}
+		if backoff *= 2; backoff > rc.opts.MaxBackoff {
+			backoff = rc.opts.MaxBackoff
+		}
+	}
+}
+
+// connect dials the remote vat and obtains the capability.
+func (rc *ReconnectingClient) connect() (*Conn, capnp.Client, error) {
+	conn, err := rc.opts.Dial(rc.ctx)
+	if err != nil {
+		return nil, capnp.Client{}, rpcerr.Annotate(err, "dial")
+	}
+	target := conn.Bootstrap(rc.ctx)
+	if rc.opts.Path != nil {
+		target, err = rc.opts.Path(rc.ctx, target)
+	}
+	if err == nil {
+		err = target.Resolve(rc.ctx)
+	}
+	if err == nil {
+		if e, ok := target.State().Brand.Value.(error); ok {
+			err = e
+		}
+	}
+	if err != nil {
+		target.Release()
+		conn.Close()
+		return nil, capnp.Client{}, rpcerr.Annotate(err, "bootstrap")
+	}
+	return conn, target, nil
+}
+
+func (rc *ReconnectingClient) setState(state ReconnectState, err error) {
+	changed := false
+	var queued *recvQueue
+	syncutil.With(&rc.mu, func() {
+		if rc.state == state {
+			return
+		}
+		changed = true
+		waiting := rc.state == StateConnecting || rc.state == StateDisconnected
+		if waiting && (state == StateConnected || state == StateClosed) {
+			queued = rc.calls
+		} else if !waiting && (state == StateConnecting || state == StateDisconnected) {
+			rc.calls = new(recvQueue)
+		}
+		rc.state = state
+	})
+	if queued != nil {
+		queued.ready(rc.recv)
+	}
+	if changed && rc.opts.OnStateChange != nil {
+		rc.opts.OnStateChange(state, err)
+	}
+}
+
+// send sends a call over the current connection, or queues it until
+// there is one, as recv does.  The answer of a queued call is pending
+// until the call is sent and returns, and calls may be pipelined on it
+// in the meantime.
+func (rc *ReconnectingClient) send(ctx context.Context, s capnp.Send) (*capnp.Answer, capnp.ReleaseFunc) {
+	rc.mu.Lock()
+	switch rc.state {
+	case StateConnected:
+		c := rc.target.AddRef()
+		rc.mu.Unlock()
+		defer c.Release()
+		return c.SendCall(ctx, s)
+	case StateClosed:
+		rc.mu.Unlock()
+		return capnp.ErrorAnswer(s.Method, ExcClosed), func() {}
+	}
+	calls := rc.calls
+	rc.mu.Unlock()
+	return calls.send(ctx, nil, s)
+}
+
+// recv delivers a received call over the current connection, or
+// queues it until there is one.  It must not block, since it may be
+// called from the receive goroutine of a Conn that the client is
+// exported over.  A queued call whose Context is canceled
+// fails once the client connects or is closed.
+func (rc *ReconnectingClient) recv(ctx context.Context, _ []capnp.PipelineOp, r capnp.Recv) capnp.PipelineCaller {
+	rc.mu.Lock()
+	switch rc.state {
+	case StateConnected:
+		c := rc.target.AddRef()
+		rc.mu.Unlock()
+		defer c.Release()
+		return c.RecvCall(ctx, r)
+	case StateClosed:
+		rc.mu.Unlock()
+		r.Reject(ExcClosed)
+		return nil
+	}
+	calls := rc.calls
+	rc.mu.Unlock()
+	return calls.recv(ctx, nil, r)
+}
+
+// reconnectHook is the ClientHook of a ReconnectingClient's capability.
+type reconnectHook struct {
+	rc *ReconnectingClient
+}
+
+func (h reconnectHook) Send(ctx context.Context, s capnp.Send) (*capnp.Answer, capnp.ReleaseFunc) {
+	return h.rc.send(ctx, s)
+}
+
+func (h reconnectHook) Recv(ctx context.Context, r capnp.Recv) capnp.PipelineCaller {
+	return h.rc.recv(ctx, nil, r)
+}
+
+func (h reconnectHook) Brand() capnp.Brand {
+	return capnp.Brand{Value: h.rc}
+}
+
+// Shutdown stops the client redialing.  It is called once every
+// reference has been released, which includes the ReconnectingClient's
+// own, released by Close.
+func (h reconnectHook) Shutdown() {
+	h.rc.cancel()
+}
@@ -0,0 +1,345 @@
+package rpc_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"capnproto.org/go/capnp/v3"
+	"capnproto.org/go/capnp/v3/rpc"
+	testcp "capnproto.org/go/capnp/v3/rpc/internal/testcapnp"
+	"capnproto.org/go/capnp/v3/rpc/transport"
+)
+
+func TestReconnectingClient(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	d := &reconnectTestDialer{t: t, fail: 1}
+	states := make(chan rpc.ReconnectState, 16)
+	rc := rpc.NewReconnectingClient(rpc.ReconnectOptions{
+		Dial:       d.dial,
+		MinBackoff: time.Millisecond,
+		MaxBackoff: 10 * time.Millisecond,
+		OnStateChange: func(state rpc.ReconnectState, err error) {
+			states <- state
+		},
+	})
+	pp := testcp.PingPong(rc.Client())
+	defer pp.Release()
+
+	expectStates := func(want ...rpc.ReconnectState) {
+		t.Helper()
+		for _, w := range want {
+			select {
+			case got := <-states:
+				if got != w {
+					t.Fatalf("state changed to %v; want %v", got, w)
+				}
+			case <-ctx.Done():
+				t.Fatalf("waiting for state %v: %v", w, ctx.Err())
+			}
+		}
+	}
+
+	// The first dial fails, and the call waits for the second.
+	echoNum(ctx, t, pp, 1)
+	if got := rc.State(); got != rpc.StateConnected {
+		t.Errorf("rc.State() = %v; want %v", got, rpc.StateConnected)
+	}
+	expectStates(rpc.StateConnecting, rpc.StateDisconnected, rpc.StateConnecting, rpc.StateConnected)
+
+	// Break the connection: once the client notices, calls go over a
+	// new one.
+	d.server().Close()
+	expectStates(rpc.StateDisconnected)
+	echoNum(ctx, t, pp, 2)
+	expectStates(rpc.StateConnecting, rpc.StateConnected)
+	if n := d.dials(); n != 3 {
+		t.Errorf("dialed %d times; want 3", n)
+	}
+
+	if err := rc.Close(); err != nil {
+		t.Error("rc.Close():", err)
+	}
+	expectStates(rpc.StateClosed)
+	ans, release := pp.EchoNum(ctx, nil)
+	if _, err := ans.Struct(); err == nil {
+		t.Error("call after Close succeeded")
+	}
+	release()
+}
+
+func TestReconnectingClient_WaitCanceled(t *testing.T) {
+	t.Parallel()
+
+	rc := rpc.NewReconnectingClient(rpc.ReconnectOptions{
+		Dial: func(context.Context) (*rpc.Conn, error) {
+			return nil, errors.New("unreachable")
+		},
+		MinBackoff: time.Millisecond,
+	})
+	defer rc.Close()
+	pp := testcp.PingPong(rc.Client())
+	defer pp.Release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	ans, release := pp.EchoNum(ctx, nil)
+	defer release()
+	if _, err := ans.Struct(); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("call error = %v; want %v", err, context.DeadlineExceeded)
+	}
+}
+
+// TestReconnectingClient_Exported exports a ReconnectingClient over
+// another Conn, and calls it before it has connected.  The call should
+// wait for the connection without holding up the Conn it came in on.
+func TestReconnectingClient_Exported(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	d := &reconnectTestDialer{t: t}
+	gate := make(chan struct{})
+	rc := rpc.NewReconnectingClient(rpc.ReconnectOptions{
+		Dial: func(ctx context.Context) (*rpc.Conn, error) {
+			select {
+			case <-gate:
+				return d.dial(ctx)
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		},
+		MinBackoff: time.Millisecond,
+	})
+	defer rc.Close()
+
+	left, right := transport.NewPipe(1)
+	conn1 := rpc.NewConn(rpc.NewTransport(left), &rpc.Options{
+		ErrorReporter:   testErrorReporter{tb: t},
+		BootstrapClient: rc.Client(),
+	})
+	defer conn1.Close()
+	conn2 := rpc.NewConn(rpc.NewTransport(right), &rpc.Options{
+		ErrorReporter: testErrorReporter{tb: t},
+	})
+	defer conn2.Close()
+
+	pp := testcp.PingPong(conn2.Bootstrap(ctx))
+	defer pp.Release()
+	f, release := pp.EchoNum(ctx, func(p testcp.PingPong_echoNum_Params) error {
+		p.SetN(42)
+		return nil
+	})
+	defer release()
+
+	// conn1 must still answer messages sent after the waiting call.
+	boot := conn2.Bootstrap(ctx)
+	defer boot.Release()
+	if err := boot.Resolve(ctx); err != nil {
+		t.Fatal("second bootstrap:", err)
+	}
+
+	close(gate)
+	res, err := f.Struct()
+	if err != nil {
+		t.Fatal("EchoNum:", err)
+	}
+	if res.N() != 42 {
+		t.Errorf("EchoNum(42) = %d", res.N())
+	}
+}
+
+// TestReconnectingClient_Queued makes calls before the client has
+// connected.  Each call should return a pending answer right away, and
+// calls pipelined on it should be delivered once the client connects.
+func TestReconnectingClient_Queued(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	local := &forwardingProvider{pp: testcp.PingPong_ServerToClient(pingPongServer{})}
+	defer local.pp.Release()
+	gate := make(chan struct{})
+	rc := rpc.NewReconnectingClient(rpc.ReconnectOptions{
+		Dial: func(ctx context.Context) (*rpc.Conn, error) {
+			select {
+			case <-gate:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+			left, right := transport.NewPipe(1)
+			srv := rpc.NewConn(rpc.NewTransport(left), &rpc.Options{
+				BootstrapClient: capnp.Client(testcp.PingPongProvider_ServerToClient(local)),
+			})
+			go func() {
+				<-ctx.Done()
+				srv.Close()
+			}()
+			return rpc.NewConn(rpc.NewTransport(right), nil), nil
+		},
+		MinBackoff: time.Millisecond,
+	})
+	defer rc.Close()
+	provider := testcp.PingPongProvider(rc.Client())
+	defer provider.Release()
+
+	f, release := provider.PingPong(ctx, nil)
+	defer release()
+	pp := f.PingPong()
+	const n = 3
+	answers := make([]testcp.PingPong_echoNum_Results_Future, n)
+	for i := range answers {
+		i := i
+		var release capnp.ReleaseFunc
+		answers[i], release = pp.EchoNum(ctx, func(p testcp.PingPong_echoNum_Params) error {
+			p.SetN(int64(i))
+			return nil
+		})
+		defer release()
+	}
+	select {
+	case <-f.Done():
+		t.Fatal("call finished before the client connected")
+	default:
+	}
+
+	close(gate)
+	for i, ans := range answers {
+		res, err := ans.Struct()
+		if err != nil {
+			t.Fatalf("EchoNum(%d): %v", i, err)
+		}
+		if res.N() != int64(i) {
+			t.Errorf("EchoNum(%d) = %d", i, res.N())
+		}
+	}
+}
+
+// TestReconnectingClient_Dropped connects to a server that drops each
+// connection as soon as it is up.  The client should report the
+// transport error, and back off as if the dials had failed.
+func TestReconnectingClient_Dropped(t *testing.T) {
+	t.Parallel()
+
+	const (
+		minBackoff = 4 * time.Millisecond
+		maxBackoff = 32 * time.Millisecond
+		drops      = 6
+	)
+	type stateChange struct {
+		state rpc.ReconnectState
+		err   error
+		at    time.Time
+	}
+	d := &reconnectTestDialer{t: t}
+	changes := make(chan stateChange)
+	stop := make(chan struct{})
+	rc := rpc.NewReconnectingClient(rpc.ReconnectOptions{
+		Dial:       d.dial,
+		MinBackoff: minBackoff,
+		MaxBackoff: maxBackoff,
+		OnStateChange: func(state rpc.ReconnectState, err error) {
+			if state == rpc.StateConnected {
+				// Close the transport without telling the client.
+				d.serverTransport().Close()
+			}
+			select {
+			case changes <- stateChange{state, err, time.Now()}:
+			case <-stop:
+			}
+		},
+	})
+	defer rc.Close()
+	defer close(stop)
+
+	next := func(want rpc.ReconnectState) stateChange {
+		t.Helper()
+		select {
+		case c := <-changes:
+			if c.state != want {
+				t.Fatalf("state changed to %v (%v); want %v", c.state, c.err, want)
+			}
+			return c
+		case <-time.After(10 * time.Second):
+			t.Fatalf("waiting for state %v: timed out", want)
+			return stateChange{}
+		}
+	}
+
+	// The client waits at least half the backoff before each redial,
+	// and the backoff doubles with each lost connection.
+	next(rpc.StateConnecting)
+	backoff := minBackoff
+	for i := 0; i < drops; i++ {
+		next(rpc.StateConnected)
+		lost := next(rpc.StateDisconnected)
+		if lost.err == nil {
+			t.Error("disconnected with nil error")
+		} else if lost.err.Error() == rpc.ExcClosed.Error() {
+			t.Errorf("disconnected with %v; want the transport error", lost.err)
+		}
+		redial := next(rpc.StateConnecting)
+		if wait := redial.at.Sub(lost.at); wait < backoff/2 {
+			t.Errorf("drop %d: redialed after %v; want at least %v", i+1, wait, backoff/2)
+		}
+		if backoff *= 2; backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+	if n := d.dials(); n != drops+1 {
+		t.Errorf("dialed %d times; want %d", n, drops+1)
+	}
+}
+
+// reconnectTestDialer connects to a new server Conn each time it is
+// dialed, after failing the first fail dials.
+type reconnectTestDialer struct {
+	t    *testing.T
+	fail int
+
+	mu            sync.Mutex
+	n             int
+	last          *rpc.Conn
+	lastTransport rpc.Transport
+}
+
+func (d *reconnectTestDialer) dial(ctx context.Context) (*rpc.Conn, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.n++
+	if d.n <= d.fail {
+		return nil, errors.New("connection refused")
+	}
+	left, right := transport.NewPipe(1)
+	d.lastTransport = rpc.NewTransport(left)
+	d.last = rpc.NewConn(d.lastTransport, &rpc.Options{
+		BootstrapClient: capnp.Client(testcp.PingPong_ServerToClient(pingPongServer{})),
+	})
+	return rpc.NewConn(rpc.NewTransport(right), nil), nil
+}
+
+func (d *reconnectTestDialer) server() *rpc.Conn {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.last
+}
+
+func (d *reconnectTestDialer) serverTransport() rpc.Transport {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.lastTransport
+}
+
+func (d *reconnectTestDialer) dials() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.n
+}
@@ -0,0 +1,261 @@
+package rpc
+
+import (
+	"context"
+	"sync"
+
+	"capnproto.org/go/capnp/v3"
+)
+
+// A recvQueue holds the calls received for a capability that is not
+// yet available, so that the goroutine delivering them, usually a
+// Conn's receive goroutine, does not wait for it.  Once ready is called,
+// the queued calls are delivered in the order they were received,
+// followed by any received afterward.  The zero value is an empty queue.
+type recvQueue struct {
+	mu      sync.Mutex
+	deliver deliverFunc // nil until ready
+	calls   []queuedRecv
+}
+
+// A deliverFunc delivers a call, with the transform that it was
+// pipelined with, if any.
+type deliverFunc func(ctx context.Context, transform []capnp.PipelineOp, r capnp.Recv) capnp.PipelineCaller
+
+type queuedRecv struct {
+	ctx       context.Context
+	transform []capnp.PipelineOp
+	r         capnp.Recv
+	p         *queuedPipeline
+}
+
+// recv delivers r if the queue is ready, and queues it otherwise.
+func (q *recvQueue) recv(ctx context.Context, transform []capnp.PipelineOp, r capnp.Recv) capnp.PipelineCaller {
+	q.mu.Lock()
+	if deliver := q.deliver; deliver != nil {
+		q.mu.Unlock()
+		return deliver(ctx, transform, r)
+	}
+	p := &queuedPipeline{done: make(chan struct{})}
+	q.calls = append(q.calls, queuedRecv{ctx: ctx, transform: transform, r: r, p: p})
+	q.mu.Unlock()
+	return p
+}
+
+// send queues s as a received call, as recv does, and returns an
+// answer that is resolved once the call is delivered and returns.
+// Calls may be pipelined on the answer in the meantime.  Unlike a
+// call passed to recv, a queued call whose Context is canceled fails
+// right away.
+func (q *recvQueue) send(ctx context.Context, transform []capnp.PipelineOp, s capnp.Send) (*capnp.Answer, capnp.ReleaseFunc) {
+	args, err := newSendArgs(s)
+	if err != nil {
+		return capnp.ErrorAnswer(s.Method, err), func() {}
+	}
+	ret := new(structReturner)
+	r := capnp.Recv{
+		Method: s.Method,
+		Args:   args,
+		ReleaseArgs: func() {
+			if msg := args.Message(); msg != nil {
+				msg.Reset(nil)
+				args = capnp.Struct{}
+			}
+		},
+		Returner: ret,
+	}
+	pcall := q.recv(ctx, transform, r)
+	if p, ok := pcall.(*queuedPipeline); ok {
+		go q.cancelOnDone(ctx, p)
+	}
+	return ret.answer(s.Method, pcall)
+}
+
+// cancelOnDone rejects the queued call whose PipelineCaller is p if ctx
+// is done before the call is delivered.
+func (q *recvQueue) cancelOnDone(ctx context.Context, p *queuedPipeline) {
+	select {
+	case <-ctx.Done():
+	case <-p.done:
+		return
+	}
+	q.mu.Lock()
+	for i, qr := range q.calls {
+		if qr.p != p {
+			continue
+		}
+		q.calls = append(q.calls[:i], q.calls[i+1:]...)
+		q.mu.Unlock()
+		qr.r.Reject(ctx.Err())
+		p.ready(nil)
+		return
+	}
+	q.mu.Unlock()
+}
+
+// ready delivers the queued calls with deliver, which is used for every
+// call received from now on.  It must be called once.  Calls whose
+// Context is done by the time they are delivered are rejected.
+func (q *recvQueue) ready(deliver deliverFunc) {
+	q.mu.Lock()
+	for len(q.calls) > 0 {
+		qr := q.calls[0]
+		q.calls[0] = queuedRecv{}
+		q.calls = q.calls[1:]
+		q.mu.Unlock()
+
+		var pcall capnp.PipelineCaller
+		if err := qr.ctx.Err(); err != nil {
+			qr.r.Reject(err)
+		} else {
+			pcall = deliver(qr.ctx, qr.transform, qr.r)
+		}
+		qr.p.ready(pcall)
+
+		q.mu.Lock()
+	}
+	q.calls = nil
+	q.deliver = deliver
+	q.mu.Unlock()
+}
+
+// A queuedPipeline is the PipelineCaller of a queued call.  Calls
+// pipelined on it are queued in turn until the call is delivered.
+type queuedPipeline struct {
+	calls recvQueue
+	done  chan struct{}
+	pcall capnp.PipelineCaller // set before done is closed; may be nil
+}
+
+func (p *queuedPipeline) ready(pcall capnp.PipelineCaller) {
+	p.pcall = pcall
+	close(p.done)
+	p.calls.ready(func(ctx context.Context, transform []capnp.PipelineOp, r capnp.Recv) capnp.PipelineCaller {
+		if pcall == nil {
+			r.Reject(errNoPipeline)
+			return nil
+		}
+		return pcall.PipelineRecv(ctx, transform, r)
+	})
+}
+
+func (p *queuedPipeline) PipelineSend(ctx context.Context, transform []capnp.PipelineOp, s capnp.Send) (*capnp.Answer, capnp.ReleaseFunc) {
+	select {
+	case <-p.done:
+	default:
+		return p.calls.send(ctx, transform, s)
+	}
+	if p.pcall == nil {
+		return capnp.ErrorAnswer(s.Method, errNoPipeline), func() {}
+	}
+	return p.pcall.PipelineSend(ctx, transform, s)
+}
+
+func (p *queuedPipeline) PipelineRecv(ctx context.Context, transform []capnp.PipelineOp, r capnp.Recv) capnp.PipelineCaller {
+	return p.calls.recv(ctx, transform, r)
+}
+
+var errNoPipeline = rpcerr.Failedf("call does not support pipelining")
+
+// newSendArgs places the arguments of s in a new message.
+func newSendArgs(s capnp.Send) (capnp.Struct, error) {
+	if s.PlaceArgs == nil {
+		return capnp.Struct{}, nil
+	}
+	_, seg, err := capnp.NewMessage(capnp.SingleSegment(nil))
+	if err != nil {
+		return capnp.Struct{}, rpcerr.Failedf("place arguments: %w", err)
+	}
+	args, err := capnp.NewRootStruct(seg, s.ArgsSize)
+	if err != nil {
+		return capnp.Struct{}, rpcerr.Failedf("place arguments: %w", err)
+	}
+	if err := s.PlaceArgs(args); err != nil {
+		args.Message().Reset(nil)
+		return capnp.Struct{}, rpcerr.Failedf("place arguments: %w", err)
+	}
+	return args, nil
+}
+
+// A structReturner is the Returner of a queued call made with
+// recvQueue.send.  It allocates the results in a new message, and
+// resolves the call's answer when the call returns.
+type structReturner struct {
+	mu       sync.Mutex
+	p        *capnp.Promise // set by answer
+	result   capnp.Struct
+	alloced  bool
+	returned bool
+	err      error
+}
+
+func (sr *structReturner) AllocResults(sz capnp.ObjectSize) (capnp.Struct, error) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	if sr.alloced {
+		return capnp.Struct{}, rpcerr.Failedf("multiple calls to AllocResults")
+	}
+	sr.alloced = true
+	_, seg, err := capnp.NewMessage(capnp.SingleSegment(nil))
+	if err != nil {
+		return capnp.Struct{}, rpcerr.Failedf("alloc results: %w", err)
+	}
+	sr.result, err = capnp.NewRootStruct(seg, sz)
+	if err != nil {
+		return capnp.Struct{}, rpcerr.Failedf("alloc results: %w", err)
+	}
+	return sr.result, nil
+}
+
+func (sr *structReturner) Return(e error) {
+	sr.mu.Lock()
+	sr.returned = true
+	p := sr.p
+	var msg *capnp.Message
+	if e != nil {
+		msg = sr.result.Message()
+		sr.result = capnp.Struct{}
+		sr.err = e
+	}
+	sr.mu.Unlock()
+	if msg != nil {
+		msg.Reset(nil)
+	}
+	if p == nil {
+		return
+	}
+	if e != nil {
+		p.Reject(e)
+	} else {
+		p.Fulfill(sr.result.ToPtr())
+	}
+}
+
+// answer returns the call's answer, whose PipelineCaller is pcall.  It
+// must be called once, after the call has been queued or delivered.
+func (sr *structReturner) answer(m capnp.Method, pcall capnp.PipelineCaller) (*capnp.Answer, capnp.ReleaseFunc) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	releaseResult := func() {
+		sr.mu.Lock()
+		msg := sr.result.Message()
+		sr.result = capnp.Struct{}
+		sr.mu.Unlock()
+		if msg != nil {
+			msg.Reset(nil)
+		}
+	}
+	if sr.returned {
+		if sr.err != nil {
+			return capnp.ErrorAnswer(m, sr.err), func() {}
+		}
+		return capnp.ImmediateAnswer(m, sr.result), releaseResult
+	}
+	sr.p = capnp.NewPromise(m, pcall)
+	ans := sr.p.Answer()
+	return ans, func() {
+		<-ans.Done()
+		sr.p.ReleaseClients()
+		releaseResult()
+	}
+}
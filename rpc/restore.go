@@ -0,0 +1,66 @@
+package rpc
+
+import (
+	"context"
+
+	"capnproto.org/go/capnp/v3"
+)
+
+// restoreObject returns a promise for the capability named by the
+// object ID of a Bootstrap message, which is resolved once c.restore
+// returns.
+//
+// The caller must be holding onto c.lk.
+func (c *Conn) restoreObject(objectID capnp.Ptr) capnp.Client {
+	if !c.startTask() {
+		return capnp.ErrorClient(ExcClosed)
+	}
+	r := &restoring{ready: make(chan struct{})}
+	client, p := capnp.NewPromisedClient(r)
+	go func() {
+		defer c.tasks.Done()
+		restored, err := c.restore(c.bgctx, objectID)
+		if err != nil {
+			restored = capnp.ErrorClient(rpcerr.Annotate(err, "restore"))
+		}
+		r.client = restored
+		close(r.ready)
+		r.calls.ready(func(ctx context.Context, _ []capnp.PipelineOp, call capnp.Recv) capnp.PipelineCaller {
+			return restored.RecvCall(ctx, call)
+		})
+		p.Fulfill(restored)
+		restored.Release()
+	}()
+	return client
+}
+
+// restoring is the hook of a capability that is being restored.  Calls
+// are queued until the capability is available.  Received calls are
+// queued without waiting, since they come from the receive goroutine,
+// which c.restore may need in order to make calls of its own.
+type restoring struct {
+	client capnp.Client // set before ready is closed
+	ready  chan struct{}
+	calls  recvQueue
+}
+
+func (r *restoring) Send(ctx context.Context, s capnp.Send) (*capnp.Answer, capnp.ReleaseFunc) {
+	select {
+	case <-r.ready:
+		return r.client.SendCall(ctx, s)
+	case <-ctx.Done():
+		return capnp.ErrorAnswer(s.Method, ctx.Err()), func() {}
+	}
+}
+
+func (r *restoring) Recv(ctx context.Context, call capnp.Recv) capnp.PipelineCaller {
+	return r.calls.recv(ctx, nil, call)
+}
+
+func (r *restoring) Brand() capnp.Brand {
+	return capnp.Brand{}
+}
+
+func (r *restoring) Shutdown() {
+	// The restored client is released by restoreObject.
+}
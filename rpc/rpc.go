@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"golang.org/x/sync/errgroup"
@@ -71,6 +72,30 @@ type Conn struct {
 	bootstrap    capnp.Client
 	er           errReporter
 	abortTimeout time.Duration
+	network      VatNetwork
+	restore      func(context.Context, capnp.Ptr) (capnp.Client, error)
+	quota        quota
+	observer     Observer
+	callMetadata bool
+	peer         *PeerInfo
+	baseCtx      context.Context // values of received calls' Contexts
+
+	// callMetadataSettled is closed once the remote vat's first message
+	// other than a Bootstrap is received, after peerCallMetadata is set
+	// to whether it accepts call metadata.  Only used if callMetadata
+	// is set.  See metadata.go.
+	callMetadataSettled  chan struct{}
+	peerCallMetadata     bool
+	metadataDropReported atomic.Bool
+
+	// callMetadataHello is the question of the hello that c sent, and
+	// peerWrapsCalls is set once it returns results.  Only used by the
+	// receive goroutine.
+	callMetadataHello *question
+	peerWrapsCalls    bool
+
+	// sendQueue is the number of messages waiting in the send queue.
+	sendQueue atomic.Int64
 
 	// bgctx is a Context that is canceled when shutdown starts. Note
 	// that it's parent is context.Background(), so we can rely on this
@@ -100,8 +125,18 @@ type Conn struct {
 		sendTx *spsc.Tx[asyncSend]
 
 		closing  bool               // used to make shutdown() idempotent
+		draining bool               // set by Drain; see drain.go
+		idle     chan struct{}      // closed by signalIdle; see drain.go
 		bgcancel context.CancelFunc // bgcancel cancels bgctx.
 
+		// remoteAbort is set when an Abort message is received, to the
+		// cause reported by calls canceled by the shutdown that follows.
+		remoteAbort error
+
+		// failure is set to the error that stopped the send or receive
+		// goroutine, such as a transport error, if there was one.
+		failure error
+
 		// Tables
 		questions  []*question
 		questionID idgen
@@ -111,6 +146,19 @@ type Conn struct {
 		imports    map[importID]*impent
 		embargoes  []*embargo
 		embargoID  idgen
+
+		// numExports is the number of non-nil entries in exports.
+		numExports int
+
+		// provisions holds the capabilities provided to the remote vat
+		// by third parties, keyed by ProvisionID.  See handoff.go.
+		provisions map[string]*provision
+
+		// joins holds the joins requested by the remote vat that are
+		// waiting for more parts, keyed by join ID.  joinID is the ID
+		// of the next join sent to the remote vat.  See join.go.
+		joins  map[uint32]*pendingJoin
+		joinID uint32
 	}
 }
 
@@ -130,6 +178,73 @@ type Options struct {
 	// before closing the transport.  If zero, then a reasonably short
 	// timeout is used.
 	AbortTimeout time.Duration
+
+	// Network is the VatNetwork that the connection belongs to.  If
+	// nil, then the connection does not take part in three-party
+	// handoffs: capabilities imported from other vats are proxied.
+	// Nor does it take part in joins that span connections.
+	Network VatNetwork
+
+	// Restore is called to obtain the capability named by the object ID
+	// of an incoming Bootstrap message.  It is called in its own
+	// goroutine, with a context that is canceled when the connection
+	// shuts down; calls made on the capability in the meantime are
+	// queued.  If nil, Bootstrap messages with an object ID are answered
+	// with an exception.  See the persistent package.
+	Restore func(ctx context.Context, objectID capnp.Ptr) (capnp.Client, error)
+
+	// MaxIncomingCalls limits the number of calls received from the
+	// remote vat that it has not yet finished, including bootstraps
+	// and the Provide, Accept and Join messages of a VatNetwork.
+	// Calls beyond the limit are answered with an overloaded exception;
+	// if the remote vat keeps calling without finishing until it has
+	// twice the limit outstanding, the connection is aborted.  If zero,
+	// there is no limit.
+	MaxIncomingCalls int
+
+	// MaxExports limits the number of capabilities exported to the
+	// remote vat at once.  Sending a capability that would need a new
+	// export beyond the limit fails with an overloaded exception.  If
+	// zero, there is no limit.
+	MaxExports int
+
+	// MaxCallBytes limits the total size in bytes of the incoming Call
+	// messages whose parameters are held by running calls.  Calls
+	// beyond the limit are answered with an overloaded exception.  If
+	// zero, there is no limit.
+	MaxCallBytes uint64
+
+	// MaxMessageSize limits the size in bytes of any incoming message.
+	// A larger message aborts the connection.  If the transport
+	// implements transport.MessageSizeLimiter, the limit is passed to
+	// it, so that it rejects the message before reading it; otherwise
+	// the message is read and then rejected with an overloaded
+	// exception.  If zero, there is no limit beyond the transport's.
+	MaxMessageSize uint64
+
+	// Observer is notified of events on the Conn, for metrics and
+	// tracing.  If nil, nothing is reported.
+	Observer Observer
+
+	// CallMetadata enables sending the metadata attached to a call's
+	// Context with WithCallMetadata, and delivering received metadata
+	// to CallMetadata.  The Conn negotiates it with the remote vat when
+	// it starts, with a call on the remote vat's bootstrap capability
+	// that internal/metadatacp/metadata.capnp declares.  If the remote
+	// vat does not enable it too, the metadata is dropped, and the
+	// ErrorReporter is told so once.
+	CallMetadata bool
+
+	// Peer identifies the remote vat to the methods it calls: the
+	// Contexts of calls received over the Conn carry it, and Peer
+	// returns it.  If nil, they do not carry one.
+	Peer *PeerInfo
+
+	// BaseContext, if not nil, provides the values that the Contexts
+	// of calls received over the Conn carry, such as a logger or the
+	// Server that accepted the connection.  Its deadline and
+	// cancellation are ignored.
+	BaseContext context.Context
 }
 
 // ErrorReporter can receive errors from a Conn.  ReportError should be quick
@@ -166,15 +281,36 @@ func NewConn(t Transport, opts *Options) *Conn {
 	c.lk.bgcancel = cancel
 	c.lk.answers = make(map[answerID]*answer)
 	c.lk.imports = make(map[importID]*impent)
+	c.lk.provisions = make(map[string]*provision)
+	c.lk.joins = make(map[uint32]*pendingJoin)
 
 	if opts != nil {
 		c.bootstrap = opts.BootstrapClient
 		c.er = errReporter{opts.ErrorReporter}
 		c.abortTimeout = opts.AbortTimeout
+		c.network = opts.Network
+		c.restore = opts.Restore
+		c.quota.set(opts)
+		if l, ok := t.(transport.MessageSizeLimiter); ok && opts.MaxMessageSize > 0 {
+			l.SetMaxMessageSize(opts.MaxMessageSize)
+		}
+		c.observer = opts.Observer
+		c.callMetadata = opts.CallMetadata
+		c.peer = opts.Peer
+		if opts.BaseContext != nil {
+			c.baseCtx = valuesContext{opts.BaseContext}
+		}
+	}
+	if c.baseCtx == nil {
+		c.baseCtx = context.Background()
 	}
 	if c.abortTimeout == 0 {
 		c.abortTimeout = 100 * time.Millisecond
 	}
+	if c.callMetadata {
+		c.callMetadataSettled = make(chan struct{})
+		c.sendCallMetadataHello()
+	}
 
 	// start background tasks
 	g.Go(c.backgroundTask(c.send))
@@ -192,6 +328,11 @@ func NewConn(t Transport, opts *Options) *Conn {
 
 		c.er.ReportError(err) // ignores nil errors
 
+		if err != nil {
+			syncutil.With(&c.lk, func() {
+				c.lk.failure = err
+			})
+		}
 		if err = c.shutdown(err); err != nil {
 			c.er.ReportError(err)
 		}
@@ -219,7 +360,24 @@ func (c *Conn) backgroundTask(f func() error) func() error {
 
 // Bootstrap returns the remote vat's bootstrap interface.  This creates
 // a new client that the caller is responsible for releasing.
-func (c *Conn) Bootstrap(ctx context.Context) (bc capnp.Client) {
+func (c *Conn) Bootstrap(ctx context.Context) capnp.Client {
+	return c.sendBootstrap(ctx, capnp.Ptr{})
+}
+
+// Restore returns the capability named by objectID on the remote vat,
+// by sending a Bootstrap message with the given object ID.  The remote
+// vat must have been created with Options.Restore.  This creates a new
+// client that the caller is responsible for releasing.
+func (c *Conn) Restore(ctx context.Context, objectID capnp.Ptr) capnp.Client {
+	if !objectID.IsValid() {
+		return capnp.ErrorClient(rpcerr.Failedf("restore: null object ID"))
+	}
+	return c.sendBootstrap(ctx, objectID)
+}
+
+// sendBootstrap sends a Bootstrap message with the given object ID,
+// which may be null.
+func (c *Conn) sendBootstrap(ctx context.Context, objectID capnp.Ptr) (bc capnp.Client) {
 	c.lk.Lock()
 	defer c.lk.Unlock()
 
@@ -239,15 +397,19 @@ func (c *Conn) Bootstrap(ctx context.Context) (bc capnp.Client) {
 
 	c.sendMessage(ctx, func(m rpccp.Message) error {
 		boot, err := m.NewBootstrap()
-		if err == nil {
-			boot.SetQuestionId(uint32(q.id))
+		if err != nil {
+			return err
 		}
-		return err
+		boot.SetQuestionId(uint32(q.id))
+		if objectID.IsValid() {
+			return boot.SetDeprecatedObjectId(objectID)
+		}
+		return nil
 
 	}, func(err error) {
 		if err != nil {
 			syncutil.With(&c.lk, func() {
-				c.lk.questions[q.id] = nil
+				c.removeQuestion(q.id)
 			})
 			q.bootstrapPromise.Reject(exc.Annotate("rpc", "bootstrap", err))
 			syncutil.With(&c.lk, func() {
@@ -314,7 +476,7 @@ func (c *Conn) shutdown(abortErr error) (err error) {
 		if !alreadyClosing {
 			c.lk.closing = true
 			c.lk.bgcancel()
-			c.cancelTasks()
+			c.cancelTasks(c.shutdownCause())
 		}
 	})
 
@@ -341,6 +503,7 @@ func (c *Conn) shutdown(abortErr error) (err error) {
 		rl.Release()
 		c.abort(abortErr)
 		close(readyForClose)
+		c.observe(Event{Kind: EventShutdown, Err: abortErr})
 	}
 	<-c.closed
 
@@ -350,14 +513,36 @@ func (c *Conn) shutdown(abortErr error) (err error) {
 // Cancel all tasks and prevent new tasks from being started.
 // Does not wait for tasks to finish shutting down.
 // Called by 'shutdown'.  Callers MUST hold c.lk.
-func (c *Conn) cancelTasks() {
+func (c *Conn) cancelTasks(cause error) {
 	for _, a := range c.lk.answers {
 		if a != nil && a.cancel != nil {
-			a.cancel()
+			a.cancel(cause)
 		}
 	}
 }
 
+// shutdownCause returns the cause of cancellation for calls that are
+// still running when the connection shuts down.  Callers MUST hold c.lk.
+func (c *Conn) shutdownCause() error {
+	if c.lk.remoteAbort != nil {
+		return c.lk.remoteAbort
+	}
+	return ExcClosed
+}
+
+// disconnectCause returns the reason the connection shut down: the
+// remote vat's abort, the error that stopped it, or ExcClosed if it was
+// closed.  Callers MUST hold c.lk.
+func (c *Conn) disconnectCause() error {
+	if c.lk.remoteAbort != nil {
+		return c.lk.remoteAbort
+	}
+	if c.lk.failure != nil {
+		return c.lk.failure
+	}
+	return ExcClosed
+}
+
 // caller MUST NOT hold c.lk
 func (c *Conn) drainQueue() {
 	for {
@@ -366,6 +551,7 @@ func (c *Conn) drainQueue() {
 			break
 		}
 
+		c.sendQueue.Add(-1)
 		pending.Abort(ErrConnClosed)
 	}
 }
@@ -373,22 +559,30 @@ func (c *Conn) drainQueue() {
 // Clear all tables, and arrange for the releaseList to release exported clients
 // and unfinished answers. Called by 'shutdown'.  Caller MUST hold c.lk.
 func (c *Conn) release(rl *releaseList) {
+	imports := c.lk.imports
 	exports := c.lk.exports
 	embargoes := c.lk.embargoes
 	answers := c.lk.answers
 	questions := c.lk.questions
+	provisions := c.lk.provisions
+	joins := c.lk.joins
 	c.lk.imports = nil
 	c.lk.exports = nil
+	c.lk.numExports = 0
 	c.lk.embargoes = nil
 	c.lk.questions = nil
 	c.lk.answers = nil
+	c.lk.provisions = nil
+	c.lk.joins = nil
 
 	c.releaseBootstrap(rl)
+	c.rejectImportPromises(rl, imports)
 	c.releaseExports(rl, exports)
 	c.liftEmbargoes(rl, embargoes)
 	c.releaseAnswers(rl, answers)
 	c.releaseQuestions(rl, questions)
-
+	c.failProvisions(rl, provisions)
+	c.failJoins(rl, joins)
 }
 
 func (c *Conn) releaseBootstrap(rl *releaseList) {
@@ -396,14 +590,23 @@ func (c *Conn) releaseBootstrap(rl *releaseList) {
 	c.bootstrap = capnp.Client{}
 }
 
-func (c *Conn) releaseExports(rl *releaseList, exports []*expent) {
-	for _, e := range exports {
-		if e != nil {
-			metadata := e.client.State().Metadata
-			syncutil.With(metadata, func() {
-				c.clearExportID(metadata)
+func (c *Conn) rejectImportPromises(rl *releaseList, imports map[importID]*impent) {
+	for _, ent := range imports {
+		if ent.promise != nil {
+			p := ent.promise
+			ent.promise = nil
+			rl.Add(func() {
+				p.Reject(ExcClosed)
 			})
+		}
+	}
+}
 
+func (c *Conn) releaseExports(rl *releaseList, exports []*expent) {
+	for i, e := range exports {
+		if e != nil {
+			c.unexport(e.client, exportID(i))
+			e.cancelProvides()
 			rl.Add(e.client.Release)
 		}
 	}
@@ -422,6 +625,9 @@ func (c *Conn) releaseAnswers(rl *releaseList, answers map[answerID]*answer) {
 		if a != nil && a.msgReleaser != nil {
 			rl.Add(a.msgReleaser.Decr)
 		}
+		if a != nil {
+			a.releaseKeptResults(rl)
+		}
 	}
 }
 
@@ -469,6 +675,8 @@ func (c *Conn) send() error {
 			return err
 		}
 
+		queued := c.sendQueue.Add(-1)
+		c.observeMessage(EventMessageSent, async.msg, int(queued))
 		async.Send()
 	}
 }
@@ -486,6 +694,7 @@ func (c *Conn) receive() error {
 	// context:
 	go reader(ctx, incoming, c.transport)
 
+	first := true
 	for {
 		var (
 			recv    rpccp.Message
@@ -504,10 +713,37 @@ func (c *Conn) receive() error {
 		if err != nil {
 			return err
 		}
+		if err := c.checkMessageSize(recv.Message()); err != nil {
+			release()
+			return err
+		}
+		c.observeMessage(EventMessageReceived, recv, 0)
+		if first && recv.Which() != rpccp.Message_Which_bootstrap {
+			// A hello is settled by handleCall, once it is answered.
+			first = false
+			hello := false
+			if recv.Which() == rpccp.Message_Which_call {
+				call, err := recv.Call()
+				hello = err == nil && isCallMetadataHello(call)
+			}
+			if !hello {
+				c.settleCallMetadata(false)
+			}
+		}
 
 		switch recv.Which() {
 		case rpccp.Message_Which_unimplemented:
-			// no-op for now to avoid feedback loop
+			um, err := recv.Unimplemented()
+			if err != nil {
+				release()
+				c.er.ReportError(fmt.Errorf("read unimplemented: %w", err))
+				continue
+			}
+			err = c.handleUnimplemented(um)
+			release()
+			if err != nil {
+				return err
+			}
 
 		case rpccp.Message_Which_abort:
 			defer release()
@@ -525,6 +761,13 @@ func (c *Conn) receive() error {
 			}
 
 			c.er.ReportError(exc.New(exc.Type(e.Type()), "rpc", "remote abort: "+reason))
+			syncutil.With(&c.lk, func() {
+				c.lk.remoteAbort = &exc.Exception{
+					Type:   exc.Type(e.Type()),
+					Prefix: "rpc",
+					Cause:  fmt.Errorf("%w: %s", ErrRemoteAbort, reason),
+				}
+			})
 			return nil
 
 		case rpccp.Message_Which_bootstrap:
@@ -535,8 +778,19 @@ func (c *Conn) receive() error {
 				continue
 			}
 			qid := answerID(bootstrap.QuestionId())
+			var objectID capnp.Ptr
+			if bootstrap.HasDeprecatedObjectId() {
+				objectID, err = bootstrap.DeprecatedObjectId()
+				if err == nil {
+					objectID, err = copyPtr(objectID)
+				}
+			}
 			release()
-			if err := c.handleBootstrap(ctx, qid); err != nil {
+			if err != nil {
+				err = rpcerr.Failedf("read bootstrap object ID: %w", err)
+				c.er.ReportError(err)
+			}
+			if err := c.handleBootstrap(ctx, qid, objectID, err); err != nil {
 				return err
 			}
 
@@ -576,6 +830,19 @@ func (c *Conn) receive() error {
 				return err
 			}
 
+		case rpccp.Message_Which_resolve:
+			res, err := recv.Resolve()
+			if err != nil {
+				release()
+				c.er.ReportError(fmt.Errorf("read resolve: %w", err))
+				continue
+			}
+			err = c.handleResolve(ctx, res)
+			release()
+			if err != nil {
+				return err
+			}
+
 		case rpccp.Message_Which_release:
 			rel, err := recv.Release()
 			if err != nil {
@@ -602,6 +869,39 @@ func (c *Conn) receive() error {
 				return err
 			}
 
+		case rpccp.Message_Which_provide:
+			p, err := recv.Provide()
+			if err != nil {
+				release()
+				c.er.ReportError(fmt.Errorf("read provide: %w", err))
+				continue
+			}
+			if err := c.handleProvide(ctx, p, release); err != nil {
+				return err
+			}
+
+		case rpccp.Message_Which_accept:
+			a, err := recv.Accept()
+			if err != nil {
+				release()
+				c.er.ReportError(fmt.Errorf("read accept: %w", err))
+				continue
+			}
+			if err := c.handleAccept(ctx, a, release); err != nil {
+				return err
+			}
+
+		case rpccp.Message_Which_join:
+			j, err := recv.Join()
+			if err != nil {
+				release()
+				c.er.ReportError(fmt.Errorf("read join: %w", err))
+				continue
+			}
+			if err := c.handleJoin(ctx, j, release); err != nil {
+				return err
+			}
+
 		default:
 			c.er.ReportError(fmt.Errorf("unknown message type %v from remote", recv.Which()))
 			c.sendMessage(ctx, func(m rpccp.Message) error {
@@ -615,7 +915,96 @@ func (c *Conn) receive() error {
 	}
 }
 
-func (c *Conn) handleBootstrap(ctx context.Context, id answerID) error {
+// handleUnimplemented processes a message that the remote vat echoed
+// back as unimplemented.  Most of these are ignored to avoid a feedback
+// loop, but a Resolve must be treated as though the remote vat released
+// the capability the promise resolved to, and a Provide, Accept or Join
+// must be treated as though the remote vat returned an exception.
+func (c *Conn) handleUnimplemented(um rpccp.Message) error {
+	switch um.Which() {
+	case rpccp.Message_Which_resolve:
+		res, err := um.Resolve()
+		if err != nil {
+			return rpcerr.Failedf("incoming unimplemented: read resolve: %w", err)
+		}
+		if res.Which() != rpccp.Resolve_Which_cap {
+			return nil
+		}
+		d, err := res.Cap()
+		if err != nil {
+			return rpcerr.Failedf("incoming unimplemented: read resolve: %w", err)
+		}
+		var id exportID
+		switch d.Which() {
+		case rpccp.CapDescriptor_Which_senderHosted:
+			id = exportID(d.SenderHosted())
+		case rpccp.CapDescriptor_Which_senderPromise:
+			id = exportID(d.SenderPromise())
+		default:
+			return nil
+		}
+		return c.handleRelease(context.Background(), id, 1)
+
+	case rpccp.Message_Which_provide:
+		p, err := um.Provide()
+		if err != nil {
+			return rpcerr.Failedf("incoming unimplemented: read provide: %w", err)
+		}
+		c.rejectUnimplemented(questionID(p.QuestionId()), "provide")
+		return nil
+
+	case rpccp.Message_Which_accept:
+		a, err := um.Accept()
+		if err != nil {
+			return rpcerr.Failedf("incoming unimplemented: read accept: %w", err)
+		}
+		c.rejectUnimplemented(questionID(a.QuestionId()), "accept")
+		return nil
+
+	case rpccp.Message_Which_join:
+		j, err := um.Join()
+		if err != nil {
+			return rpcerr.Failedf("incoming unimplemented: read join: %w", err)
+		}
+		c.rejectUnimplemented(questionID(j.QuestionId()), "join")
+		return nil
+
+	default:
+		// no-op for now to avoid feedback loop
+		return nil
+	}
+}
+
+// rejectUnimplemented rejects a question whose message the remote vat
+// echoed back as unimplemented.  No Finish message is sent, since the
+// remote vat never saw the question.
+func (c *Conn) rejectUnimplemented(qid questionID, what string) {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+
+	if uint32(qid) >= uint32(len(c.lk.questions)) {
+		return
+	}
+	q := c.lk.questions[qid]
+	if q == nil {
+		return
+	}
+	c.removeQuestion(qid)
+	canceled := q.flags&finished != 0
+	q.flags |= finished
+	if canceled {
+		// The Finish message for the question may already be queued;
+		// don't reuse the ID.
+		return
+	}
+	c.lk.questionID.remove(uint32(qid))
+	go q.Reject(rpcerr.Unimplementedf("remote vat does not implement %s", what))
+}
+
+// handleBootstrap answers a Bootstrap message.  If objectErr is not
+// nil, the object ID could not be read, and the question is answered
+// with objectErr.
+func (c *Conn) handleBootstrap(ctx context.Context, id answerID, objectID capnp.Ptr, objectErr error) error {
 	rl := &releaseList{}
 	defer rl.Release()
 
@@ -643,13 +1032,38 @@ func (c *Conn) handleBootstrap(ctx context.Context, id answerID) error {
 			c.er.ReportError(err)
 			return
 		}
+		reject, abort := c.checkIncomingQuestion()
+		if abort != nil {
+			rl.Add(ans.msgReleaser.Decr)
+			err = rpcerr.Annotate(abort, "incoming bootstrap")
+			return
+		}
 
 		c.lk.answers[id] = &ans
-		if !c.bootstrap.IsValid() {
+		var boot capnp.Client
+		switch {
+		case reject != nil:
+			ans.sendException(rl, reject)
+			return
+		case c.lk.draining:
+			ans.sendException(rl, errDraining)
+			return
+		case objectErr != nil:
+			ans.sendException(rl, objectErr)
+			return
+		case objectID.IsValid():
+			if c.restore == nil {
+				ans.sendException(rl, exc.New(exc.Failed, "", "vat does not restore objects by ID"))
+				return
+			}
+			boot = c.restoreObject(objectID)
+		case c.bootstrap.IsValid():
+			boot = c.bootstrap.AddRef()
+		default:
 			ans.sendException(rl, exc.New(exc.Failed, "", "vat does not expose a public/bootstrap interface"))
 			return
 		}
-		if err := ans.setBootstrap(c.bootstrap.AddRef()); err != nil {
+		if err := ans.setBootstrap(boot); err != nil {
 			ans.sendException(rl, err)
 			return
 		}
@@ -663,6 +1077,38 @@ func (c *Conn) handleBootstrap(ctx context.Context, id answerID) error {
 	return err
 }
 
+// newCallContext returns the Context to deliver a received call p
+// with, and sets ans.cancel to cancel it and ans.trace to report its
+// return.  The Context carries the call's metadata and the remote
+// vat's PeerInfo, if any, and is canceled when the remote vat sends a
+// Finish for the call or the connection shuts down, and context.Cause
+// reports which: ErrCallFinished, an error wrapping ErrRemoteAbort, or
+// ExcClosed.
+//
+// The caller MUST be holding onto c.lk.
+func (c *Conn) newCallContext(ans *answer, p *parsedCall) context.Context {
+	// The Context is not derived from c.bgctx, since that would be
+	// canceled without a cause before shutdown gets to cancelTasks.
+	ctx, cancel := context.WithCancelCause(c.baseCtx)
+	ans.cancel = cancel
+	ctx = WithCallMetadata(ctx, p.metadata)
+	if c.peer != nil {
+		ctx = context.WithValue(ctx, peerKey{}, c.peer)
+	}
+	ctx, ans.trace = c.traceCall(ctx, p.method, true, uint32(ans.id))
+	return ctx
+}
+
+// valuesContext is a Context with the values of its parent, but not
+// its deadline or cancellation.
+type valuesContext struct {
+	context.Context
+}
+
+func (valuesContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (valuesContext) Done() <-chan struct{}       { return nil }
+func (valuesContext) Err() error                  { return nil }
+
 func (c *Conn) handleCall(ctx context.Context, call rpccp.Call, releaseCall capnp.ReleaseFunc) error {
 	rl := &releaseList{}
 	defer rl.Release()
@@ -670,8 +1116,8 @@ func (c *Conn) handleCall(ctx context.Context, call rpccp.Call, releaseCall capn
 	id := answerID(call.QuestionId())
 
 	// TODO(3rd-party handshake): support sending results to 3rd party vat
-	if call.SendResultsTo().Which() != rpccp.Call_sendResultsTo_Which_caller {
-		// TODO(someday): handle SendResultsTo.yourself
+	keepResults := call.SendResultsTo().Which() == rpccp.Call_sendResultsTo_Which_yourself
+	if !keepResults && call.SendResultsTo().Which() != rpccp.Call_sendResultsTo_Which_caller {
 		c.er.ReportError(fmt.Errorf("incoming call: results destination is not caller"))
 
 		c.sendMessage(ctx, func(m rpccp.Message) error {
@@ -694,12 +1140,30 @@ func (c *Conn) handleCall(ctx context.Context, call rpccp.Call, releaseCall capn
 		return nil
 	}
 
+	size, err := call.Message().TotalSize()
+	if err != nil {
+		releaseCall()
+		return rpcerr.Failedf("incoming call: %w", err)
+	}
+
 	c.lk.Lock()
 	if c.lk.answers[id] != nil {
 		c.lk.Unlock()
 		releaseCall()
 		return rpcerr.Failedf("incoming call: answer ID %d reused", id)
 	}
+	rejectErr, err := c.checkIncomingCall(size)
+	if err != nil {
+		c.lk.Unlock()
+		releaseCall()
+		return rpcerr.Annotate(err, "incoming call")
+	}
+	if rejectErr == nil && c.lk.draining {
+		rejectErr = errDraining
+	}
+	if rejectErr == nil {
+		releaseCall = c.quota.holdCall(size, releaseCall)
+	}
 
 	var p parsedCall
 	parseErr := c.parseCall(&p, call) // parseCall sets CapTable
@@ -724,11 +1188,24 @@ func (c *Conn) handleCall(ctx context.Context, call rpccp.Call, releaseCall capn
 	ans := &answer{
 		c:           c,
 		id:          id,
+		keepResults: keepResults,
 		ret:         ret,
 		sendMsg:     send,
 		msgReleaser: retReleaser,
 	}
 	c.lk.answers[id] = ans
+	if c.callMetadata && isCallMetadataHello(call) && !c.callMetadataSettledYet() {
+		err := c.answerCallMetadataHello(rl, ans)
+		c.lk.Unlock()
+		releaseCall()
+		return err
+	}
+	if rejectErr != nil {
+		ans.sendException(rl, rpcerr.Annotate(rejectErr, "incoming call"))
+		c.lk.Unlock()
+		releaseCall()
+		return nil
+	}
 	if parseErr != nil {
 		parseErr = rpcerr.Annotate(parseErr, "incoming call")
 		ans.sendException(rl, parseErr)
@@ -758,8 +1235,7 @@ func (c *Conn) handleCall(ctx context.Context, call rpccp.Call, releaseCall capn
 			return rpcerr.Failedf("incoming call: unknown export ID %d", id)
 		}
 		c.tasks.Add(1) // will be finished by answer.Return
-		var callCtx context.Context
-		callCtx, ans.cancel = context.WithCancel(c.bgctx)
+		callCtx := c.newCallContext(ans, &p)
 		c.lk.Unlock()
 		pcall := ent.client.RecvCall(callCtx, capnp.Recv{
 			Args:        p.args,
@@ -783,7 +1259,21 @@ func (c *Conn) handleCall(ctx context.Context, call rpccp.Call, releaseCall capn
 			releaseCall()
 			return rpcerr.Failedf("incoming call: use of unknown or finished answer ID %d for promised answer target", p.target.promisedAnswer)
 		}
-		if tgtAns.flags.Contains(resultsReady) {
+		if tgtAns.tail != nil {
+			// The target answer's results are those of a tail call
+			// that the remote vat is answering; pipeline on it.
+			tgt := tgtAns.tail.p.Answer()
+			c.tasks.Add(1) // will be finished by answer.Return
+			callCtx := c.newCallContext(ans, &p)
+			c.lk.Unlock()
+			pcall := tgt.PipelineRecv(callCtx, p.target.transform, capnp.Recv{
+				Args:        p.args,
+				Method:      p.method,
+				ReleaseArgs: releaseArgs,
+				Returner:    ans,
+			})
+			ans.setPipelineCaller(p.method, pcall)
+		} else if tgtAns.flags.Contains(resultsReady) {
 			if tgtAns.err != nil {
 				ans.sendException(rl, tgtAns.err)
 				c.lk.Unlock()
@@ -822,8 +1312,7 @@ func (c *Conn) handleCall(ctx context.Context, call rpccp.Call, releaseCall capn
 				tgt = tgtAns.results.Message().CapTable[iface.Capability()]
 			}
 			c.tasks.Add(1) // will be finished by answer.Return
-			var callCtx context.Context
-			callCtx, ans.cancel = context.WithCancel(c.bgctx)
+			callCtx := c.newCallContext(ans, &p)
 			c.lk.Unlock()
 			pcall := tgt.RecvCall(callCtx, capnp.Recv{
 				Args:        p.args,
@@ -832,11 +1321,16 @@ func (c *Conn) handleCall(ctx context.Context, call rpccp.Call, releaseCall capn
 				Returner:    ans,
 			})
 			ans.setPipelineCaller(p.method, pcall)
+		} else if tgtAns.pcall == nil {
+			// Not a call, e.g. an Accept waiting for its Provide.
+			ans.sendException(rl, rpcerr.Failedf("incoming call: answer ID %d does not support pipelining", p.target.promisedAnswer))
+			c.lk.Unlock()
+			releaseCall()
+			return nil
 		} else {
 			// Results not ready, use pipeline caller.
 			tgtAns.pcalls.Add(1) // will be finished by answer.Return
-			var callCtx context.Context
-			callCtx, ans.cancel = context.WithCancel(c.bgctx)
+			callCtx := c.newCallContext(ans, &p)
 			tgt := tgtAns.pcall
 			c.tasks.Add(1) // will be finished by answer.Return
 			c.lk.Unlock()
@@ -856,9 +1350,10 @@ func (c *Conn) handleCall(ctx context.Context, call rpccp.Call, releaseCall capn
 }
 
 type parsedCall struct {
-	target parsedMessageTarget
-	method capnp.Method
-	args   capnp.Struct
+	target   parsedMessageTarget
+	method   capnp.Method
+	args     capnp.Struct
+	metadata CallMetadataMap
 }
 
 type parsedMessageTarget struct {
@@ -881,7 +1376,10 @@ func (c *Conn) parseCall(p *parsedCall, call rpccp.Call) error {
 	if err != nil {
 		return rpcerr.Annotate(err, "read params")
 	}
-	p.args = ptr.Struct()
+	p.args, p.metadata, err = c.readCallArgs(ptr)
+	if err != nil {
+		return rpcerr.Failedf("read params: %w", err)
+	}
 	tgt, err := call.Target()
 	if err != nil {
 		return rpcerr.Failedf("read target: %w", err)
@@ -941,17 +1439,32 @@ func (c *Conn) handleReturn(ctx context.Context, ret rpccp.Return, release capnp
 		release()
 		return rpcerr.Failedf("incoming return: question %d does not exist", qid)
 	}
-	// Pop the question from the table.  Receiving the Return message
-	// will always remove the question from the table, because it's the
-	// only time the remote vat will use it.
 	q := c.lk.questions[qid]
-	c.lk.questions[qid] = nil
-	if q == nil {
+	if q == nil || q.flags&returnReceived != 0 {
 		c.lk.Unlock()
 		release()
 		return rpcerr.Failedf("incoming return: question %d does not exist", qid)
 	}
+	if q == c.callMetadataHello && ret.Which() == rpccp.Return_Which_results {
+		// The remote vat wraps the calls it sends from now on.
+		c.peerWrapsCalls = true
+	}
 	canceled := q.flags&finished != 0
+	if !canceled && q.flags&resultsSentElsewhere != 0 && ret.Which() == rpccp.Return_Which_resultsSentElsewhere {
+		// A tail call has returned its results to the remote vat.
+		// Its question stays in the table, open for pipelined calls,
+		// until the remote vat finishes the call that took the
+		// results; see tailcall.go.
+		q.flags |= returnReceived
+		c.lk.Unlock()
+		release()
+		q.trace.done(nil)
+		return nil
+	}
+	// Pop the question from the table.  Receiving the Return message
+	// otherwise always removes the question from the table, because
+	// it's the only time the remote vat will use it.
+	c.removeQuestion(qid)
 	q.flags |= finished
 	if canceled {
 		// Wait for cancelation task to write the Finish message.  If the
@@ -984,7 +1497,7 @@ func (c *Conn) handleReturn(ctx context.Context, ret rpccp.Return, release capnp
 		c.er.ReportError(rpcerr.Annotate(pr.err, "incoming return"))
 	}
 
-	if q.bootstrapPromise == nil && pr.err == nil {
+	if q.bootstrapPromise == nil && pr.err == nil && pr.tail == nil {
 		// The result of the message contains actual data (not just a
 		// client or an error), so we save the ReleaseFunc for later:
 		q.release = release
@@ -993,7 +1506,24 @@ func (c *Conn) handleReturn(ctx context.Context, ret rpccp.Return, release capnp
 	// We're going to potentially block fulfilling some promises so fork
 	// off a goroutine to avoid blocking the receive loop.
 	go func() {
+		if pr.tail != nil {
+			// The results are those of an answer that we are still
+			// holding on to, not the message's.
+			release()
+			release = func() {}
+			var releaseResults capnp.ReleaseFunc
+			pr.result, releaseResults, pr.err = pr.tail.takeResults(pr.tailDone)
+			if pr.err == nil {
+				pr.result, releaseResults, pr.disembargoes, pr.err = c.embargoTakenResults(q, pr.result, releaseResults)
+			}
+			if pr.err == nil && q.bootstrapPromise == nil {
+				q.release = releaseResults
+			} else if releaseResults != nil {
+				defer releaseResults()
+			}
+		}
 		q.p.Resolve(pr.result, pr.err)
+		q.trace.done(pr.err)
 		if q.bootstrapPromise != nil {
 			q.bootstrapPromise.Fulfill(q.p.Answer().Client())
 			q.p.ReleaseClients()
@@ -1039,6 +1569,7 @@ func (c *Conn) handleReturn(ctx context.Context, ret rpccp.Return, release capnp
 				} else {
 					q.flags |= finishSent
 					c.lk.questionID.remove(uint32(qid))
+					c.observe(Event{Kind: EventFinishSent, ID: uint32(qid)})
 				}
 			})
 		})
@@ -1069,7 +1600,22 @@ func (c *Conn) parseReturn(ret rpccp.Return, called [][]capnp.PipelineOp) parsed
 				continue
 			}
 			i := iface.Capability()
-			if int64(i) >= int64(len(mtab)) || !locals.has(uint(i)) || embargoCaps.has(uint(i)) {
+			if int64(i) >= int64(len(mtab)) || embargoCaps.has(uint(i)) {
+				continue
+			}
+			if h, ok := mtab[i].State().Brand.Value.(*handoff); ok && h.c == c {
+				// Calls made on the answer went to the introducing
+				// vat; it must forward a disembargo after them.
+				h.embargo = true
+				embargoCaps.add(uint(i))
+				disembargoes = append(disembargoes, senderLoopback{
+					question:  questionID(ret.AnswerId()),
+					transform: xform,
+					accept:    true,
+				})
+				continue
+			}
+			if !locals.has(uint(i)) {
 				continue
 			}
 			var id embargoID
@@ -1085,6 +1631,20 @@ func (c *Conn) parseReturn(ret rpccp.Return, called [][]capnp.PipelineOp) parsed
 			result:       content,
 			disembargoes: disembargoes,
 		}
+	case rpccp.Return_Which_takeFromOtherQuestion:
+		id := answerID(ret.TakeFromOtherQuestion())
+		tail := c.lk.answers[id]
+		if tail == nil || !tail.keepResults || tail.flags.Contains(resultsTaken) {
+			return parsedReturn{err: rpcerr.Failedf("parse return: cannot take results of answer %d", id), parseFailed: true}
+		}
+		// Calls made on the question are embargoed once the results
+		// are ready; see embargoTakenResults.
+		tail.flags |= resultsTaken
+		pr := parsedReturn{tail: tail}
+		if tail.promise != nil {
+			pr.tailDone = tail.promise.Answer().Done()
+		}
+		return pr
 	case rpccp.Return_Which_exception:
 		e, err := ret.Exception()
 		if err != nil {
@@ -1106,6 +1666,11 @@ type parsedReturn struct {
 	err           error
 	parseFailed   bool
 	unimplemented bool
+
+	// tail is set for a Return with takeFromOtherQuestion, to the
+	// answer whose results to take once tailDone is closed.
+	tail     *answer
+	tailDone <-chan struct{}
 }
 
 func (c *Conn) handleFinish(ctx context.Context, id answerID, releaseResultCaps bool) error {
@@ -1122,11 +1687,12 @@ func (c *Conn) handleFinish(ctx context.Context, id answerID, releaseResultCaps
 		return rpcerr.Failedf("incoming finish: answer ID %d already received finish", id)
 	}
 	ans.flags |= finishReceived
+	c.observe(Event{Kind: EventFinishReceived, ID: uint32(id)})
 	if releaseResultCaps {
 		ans.flags |= releaseResultCapsFlag
 	}
 	if ans.cancel != nil {
-		ans.cancel()
+		ans.cancel(ErrCallFinished)
 	}
 	if !ans.flags.Contains(returnSent) {
 		return nil
@@ -1152,20 +1718,12 @@ func (c *Conn) recvCap(d rpccp.CapDescriptor) (capnp.Client, error) {
 		return capnp.Client{}, nil
 	case rpccp.CapDescriptor_Which_senderHosted:
 		id := importID(d.SenderHosted())
-		return c.addImport(id), nil
+		return c.addImport(id, false, c.recvFd(d)), nil
 	case rpccp.CapDescriptor_Which_senderPromise:
-		// We do the same thing as senderHosted, above. @kentonv suggested this on
-		// issue #2; this lets messages be delivered properly, although it's a bit
-		// of a hack, and as Kenton describes, it has some disadvantages:
-		//
-		// > * Apps sometimes want to wait for promise resolution, and to find out if
-		// >   it resolved to an exception. You won't be able to provide that API. But,
-		// >   usually, it isn't needed.
-		// > * If the promise resolves to a capability hosted on the receiver,
-		// >   messages sent to it will uselessly round-trip over the network
-		// >   rather than being delivered locally.
+		// The client will be resolved when we receive a Resolve message
+		// for the import; see handleResolve.
 		id := importID(d.SenderPromise())
-		return c.addImport(id), nil
+		return c.addImport(id, true, c.recvFd(d)), nil
 	case rpccp.CapDescriptor_Which_receiverHosted:
 		id := exportID(d.ReceiverHosted())
 		ent := c.findExport(id)
@@ -1194,6 +1752,12 @@ func (c *Conn) recvCap(d rpccp.CapDescriptor) (capnp.Client, error) {
 		}
 
 		return c.recvCapReceiverAnswer(ans, transform), nil
+	case rpccp.CapDescriptor_Which_thirdPartyHosted:
+		tp, err := d.ThirdPartyHosted()
+		if err != nil {
+			return capnp.Client{}, rpcerr.Failedf("receive capability: reading third party cap descriptor: %v", err)
+		}
+		return c.recvThirdPartyCap(tp), nil
 	default:
 		return capnp.ErrorClient(rpcerr.Failedf("unknown CapDescriptor type %v", w)), nil
 	}
@@ -1201,9 +1765,17 @@ func (c *Conn) recvCap(d rpccp.CapDescriptor) (capnp.Client, error) {
 
 // Helper for Conn.recvCap(); handles the receiverAnswer case.
 func (c *Conn) recvCapReceiverAnswer(ans *answer, transform []capnp.PipelineOp) capnp.Client {
-	if ans.promise != nil {
+	var pending *capnp.Answer
+	switch {
+	case ans.tail != nil:
+		// The results come from a tail call.
+		pending = ans.tail.p.Answer()
+	case ans.promise != nil:
 		// Still unresolved.
-		future := ans.promise.Answer().Future()
+		pending = ans.promise.Answer()
+	}
+	if pending != nil {
+		future := pending.Future()
 		for _, op := range transform {
 			future = future.Field(op.Field, op.DefaultValue)
 		}
@@ -1246,6 +1818,13 @@ func (c *Conn) isLocalClient(client capnp.Client) bool {
 		return ic.c != c
 	}
 
+	if h, ok := bv.(*handoff); ok {
+		// Calls on a handoff from this connection go through the
+		// introducing vat until the handoff completes; the Accept
+		// message's embargo takes care of ordering.  See handoff.go.
+		return h.c != c
+	}
+
 	if pc, ok := bv.(capnp.PipelineClient); ok {
 		// Same logic re: proxying as with imports:
 		if q, ok := c.getAnswerQuestion(pc.Answer()); ok {
@@ -1309,6 +1888,138 @@ func (c *Conn) recvPayload(payload rpccp.Payload) (_ capnp.Ptr, locals uintSet,
 	return p, locals, nil
 }
 
+func (c *Conn) handleResolve(ctx context.Context, res rpccp.Resolve) error {
+	var (
+		promise    *capnp.ClientPromise
+		client     capnp.Client
+		resolveErr error
+		err        error
+	)
+	id := importID(res.PromiseId())
+
+	syncutil.With(&c.lk, func() {
+		switch res.Which() {
+		case rpccp.Resolve_Which_cap:
+			var d rpccp.CapDescriptor
+			if d, err = res.Cap(); err != nil {
+				err = rpcerr.Failedf("incoming resolve: read cap: %w", err)
+				return
+			}
+			if client, err = c.recvCap(d); err != nil {
+				err = rpcerr.Annotate(err, "incoming resolve")
+				return
+			}
+		case rpccp.Resolve_Which_exception:
+			var e rpccp.Exception
+			if e, err = res.Exception(); err != nil {
+				err = rpcerr.Failedf("incoming resolve: read exception: %w", err)
+				return
+			}
+			var reason string
+			if reason, err = e.Reason(); err != nil {
+				err = rpcerr.Failedf("incoming resolve: read exception: %w", err)
+				return
+			}
+			resolveErr = exc.New(exc.Type(e.Type()), "", reason)
+		default:
+			err = rpcerr.Unimplementedf("incoming resolve: unknown type %v", res.Which())
+			return
+		}
+
+		ent := c.lk.imports[id]
+		if ent == nil {
+			// We released the promise before the Resolve arrived, so
+			// we also release the capability it resolved to (below).
+			return
+		}
+		if ent.promise == nil {
+			err = rpcerr.Failedf("incoming resolve: import ID %d is not a promise", id)
+			return
+		}
+		promise = ent.promise
+		ent.promise = nil
+
+		if resolveErr != nil {
+			return
+		}
+		if h, ok := client.State().Brand.Value.(*handoff); ok && h.c == c {
+			// The promise resolved to a capability hosted by a third
+			// party.  Calls previously made on the promise are on their
+			// way to it through the remote vat, so ask the remote vat to
+			// forward a disembargo after them.
+			h.embargo = true
+			c.sendMessage(ctx, func(m rpccp.Message) error {
+				d, err := m.NewDisembargo()
+				if err != nil {
+					return err
+				}
+				tgt, err := d.NewTarget()
+				if err != nil {
+					return err
+				}
+				tgt.SetImportedCap(uint32(id))
+				d.Context().SetAccept()
+				return nil
+			}, func(err error) {
+				if err != nil {
+					c.er.ReportError(fmt.Errorf("incoming resolve: send disembargo: %w", err))
+				}
+			})
+			return
+		}
+		if !c.isLocalClient(client) {
+			return
+		}
+
+		// The promise resolved to a capability in this vat.  Calls
+		// previously made on the promise are still on their way to the
+		// remote vat, so new calls must wait until a disembargo loops
+		// back to avoid overtaking them.
+		var eid embargoID
+		eid, client = c.embargo(client)
+		c.sendMessage(ctx, func(m rpccp.Message) error {
+			d, err := m.NewDisembargo()
+			if err != nil {
+				return err
+			}
+			tgt, err := d.NewTarget()
+			if err != nil {
+				return err
+			}
+			tgt.SetImportedCap(uint32(id))
+			d.Context().SetSenderLoopback(uint32(eid))
+			return nil
+		}, func(err error) {
+			if err != nil {
+				c.er.ReportError(fmt.Errorf("incoming resolve: send disembargo: %w", err))
+			}
+		})
+	})
+
+	if err != nil {
+		client.Release()
+		return err
+	}
+	if promise == nil {
+		client.Release()
+		return nil
+	}
+
+	// Fulfilling the promise waits on outstanding calls, so don't block
+	// the receive loop.
+	c.tasks.Add(1) // the receive loop is a task, so c can't be done yet
+	go func() {
+		defer c.tasks.Done()
+		if resolveErr != nil {
+			promise.Reject(resolveErr)
+			return
+		}
+		promise.Fulfill(client)
+		client.Release()
+	}()
+	return nil
+}
+
 func (c *Conn) handleRelease(ctx context.Context, id exportID, count uint32) error {
 	var (
 		client capnp.Client
@@ -1351,6 +2062,7 @@ func (c *Conn) handleDisembargo(ctx context.Context, d rpccp.Disembargo, release
 		// TODO(soon): verify target matches the right import.
 		c.lk.embargoes[id] = nil
 		c.lk.embargoID.remove(uint32(id))
+		c.observe(Event{Kind: EventDisembargo, ID: uint32(id)})
 		c.lk.Unlock()
 		e.lift()
 
@@ -1358,50 +2070,21 @@ func (c *Conn) handleDisembargo(ctx context.Context, d rpccp.Disembargo, release
 		var (
 			imp    *importClient
 			client capnp.Client
+			tail   *question
 		)
 
 		syncutil.With(&c.lk, func() {
-			if tgt.which != rpccp.MessageTarget_Which_promisedAnswer {
-				err = rpcerr.Failedf("incoming disembargo: sender loopback: target is not a promised answer")
-				return
-			}
-
-			ans := c.lk.answers[tgt.promisedAnswer]
-			if ans == nil {
-				err = rpcerr.Failedf("incoming disembargo: unknown answer ID %d", tgt.promisedAnswer)
-				return
-			}
-			if !ans.flags.Contains(returnSent) {
-				err = rpcerr.Failedf("incoming disembargo: answer ID %d has not sent return", tgt.promisedAnswer)
-				return
-			}
-
-			if ans.err != nil {
-				err = rpcerr.Failedf("incoming disembargo: answer ID %d returned exception", tgt.promisedAnswer)
-				return
-			}
-
-			var content capnp.Ptr
-			if content, err = ans.results.Content(); err != nil {
-				err = rpcerr.Failedf("incoming disembargo: read answer ID %d: %v", tgt.promisedAnswer, err)
+			if tail = c.tailDisembargoTarget(tgt); tail != nil {
+				c.sendTailDisembargo(ctx, tail, tgt.transform, d.Context().SenderLoopback())
 				return
 			}
-
-			var ptr capnp.Ptr
-			if ptr, err = capnp.Transform(content, tgt.transform); err != nil {
-				err = rpcerr.Failedf("incoming disembargo: read answer ID %d: %v", tgt.promisedAnswer, err)
-				return
-			}
-
-			iface := ptr.Interface()
-			if !iface.IsValid() || int64(iface.Capability()) >= int64(len(ans.results.Message().CapTable)) {
-				err = rpcerr.Failedf("incoming disembargo: sender loopback requested on a capability that is not an import")
-				return
-			}
-
-			client = iface.Client()
+			client, err = c.disembargoTarget(tgt)
 		})
 
+		if tail != nil {
+			release()
+			return nil
+		}
 		if err != nil {
 			release()
 			return err
@@ -1440,6 +2123,58 @@ func (c *Conn) handleDisembargo(ctx context.Context, d rpccp.Disembargo, release
 			})
 		})
 
+	case rpccp.Disembargo_context_Which_accept:
+		defer release()
+
+		// The remote vat accepted a capability that we introduced it
+		// to, with an embargo.  Forward the disembargo to the vat
+		// hosting the capability, after the calls we forwarded.
+		var (
+			client   capnp.Client
+			provides []*provide
+		)
+		syncutil.With(&c.lk, func() {
+			if client, err = c.disembargoTarget(tgt); err != nil {
+				return
+			}
+			state := client.State()
+			if state.Metadata == nil {
+				return
+			}
+			syncutil.With(state.Metadata, func() {
+				if id, ok := c.findExportID(state.Metadata); ok {
+					provides = append(provides, c.lk.exports[id].provides...)
+				}
+			})
+		})
+		client.Release()
+		if err != nil {
+			return err
+		}
+		if len(provides) == 0 {
+			return rpcerr.Failedf("incoming disembargo: accept requested on a capability that was not handed off")
+		}
+		for _, p := range provides {
+			go p.disembargo()
+		}
+
+	case rpccp.Disembargo_context_Which_provide:
+		defer release()
+
+		id := answerID(d.Context().Provide())
+		var p *provision
+		syncutil.With(&c.lk, func() {
+			if ans := c.lk.answers[id]; ans != nil {
+				p = ans.provision
+			}
+		})
+		if p == nil {
+			// The Provide may have been canceled concurrently.
+			c.er.ReportError(rpcerr.Failedf("incoming disembargo: unknown provide ID %d", id))
+			return nil
+		}
+		p.disembargo()
+
 	default:
 		c.er.ReportError(fmt.Errorf("incoming disembargo: context %v not implemented", d.Context().Which()))
 		syncutil.With(&c.lk, func() {
@@ -1459,6 +2194,58 @@ func (c *Conn) handleDisembargo(ctx context.Context, d rpccp.Disembargo, release
 	return nil
 }
 
+// disembargoTarget returns the capability targeted by a Disembargo
+// message that must be forwarded: either an export that resolved, or
+// a capability in the results of an answer.
+//
+// The caller must be holding onto c.lk.
+func (c *Conn) disembargoTarget(tgt parsedMessageTarget) (capnp.Client, error) {
+	if tgt.which == rpccp.MessageTarget_Which_importedCap {
+		// The remote vat is disembargoing a promise that we
+		// exported and then resolved.
+		ent := c.findExport(tgt.importedCap)
+		if ent == nil {
+			return capnp.Client{}, rpcerr.Failedf("incoming disembargo: unknown export ID %d", tgt.importedCap)
+		}
+		if ent.isPromise {
+			return capnp.Client{}, rpcerr.Failedf("incoming disembargo: export ID %d has not resolved", tgt.importedCap)
+		}
+		return ent.client.AddRef(), nil
+	}
+	if tgt.which != rpccp.MessageTarget_Which_promisedAnswer {
+		return capnp.Client{}, rpcerr.Failedf("incoming disembargo: target is not a promised answer")
+	}
+
+	ans := c.lk.answers[tgt.promisedAnswer]
+	if ans == nil {
+		return capnp.Client{}, rpcerr.Failedf("incoming disembargo: unknown answer ID %d", tgt.promisedAnswer)
+	}
+	if !ans.flags.Contains(returnSent) {
+		return capnp.Client{}, rpcerr.Failedf("incoming disembargo: answer ID %d has not sent return", tgt.promisedAnswer)
+	}
+
+	if ans.err != nil {
+		return capnp.Client{}, rpcerr.Failedf("incoming disembargo: answer ID %d returned exception", tgt.promisedAnswer)
+	}
+
+	content, err := ans.results.Content()
+	if err != nil {
+		return capnp.Client{}, rpcerr.Failedf("incoming disembargo: read answer ID %d: %v", tgt.promisedAnswer, err)
+	}
+
+	ptr, err := capnp.Transform(content, tgt.transform)
+	if err != nil {
+		return capnp.Client{}, rpcerr.Failedf("incoming disembargo: read answer ID %d: %v", tgt.promisedAnswer, err)
+	}
+
+	iface := ptr.Interface()
+	if !iface.IsValid() || int64(iface.Capability()) >= int64(len(ans.results.Message().CapTable)) {
+		return capnp.Client{}, rpcerr.Failedf("incoming disembargo: disembargo requested on a capability that is not an import")
+	}
+
+	return iface.Client().AddRef(), nil
+}
+
 // startTask increments c.tasks if c is not shutting down.
 // It returns whether c.tasks was incremented.
 //
@@ -1509,13 +2296,20 @@ func (c *Conn) sendMessage(ctx context.Context, build func(rpccp.Message) error,
 		return oldSend()
 	}
 
-	c.lk.sendTx.Send(asyncSend{
+	c.queueSend(asyncSend{
+		msg:     outMsg.Message,
 		release: release,
 		send:    send,
 		onSent:  onSent,
 	})
 }
 
+// queueSend adds as to the send queue.  The caller MUST hold c.lk.
+func (c *Conn) queueSend(as asyncSend) {
+	c.sendQueue.Add(1)
+	c.lk.sendTx.Send(as)
+}
+
 // reader reads messages from the transport in a loop, and send them down the
 // 'in' channel, until the context is canceled or an error occurs. The first
 // time RecvMessage() returns an error, its results will still be sent on the
@@ -1545,6 +2339,7 @@ type incomingMessage struct {
 }
 
 type asyncSend struct {
+	msg     rpccp.Message // the message to send, for observers; may be invalid
 	send    func() error
 	onSent  func(error)
 	release capnp.ReleaseFunc
@@ -2,6 +2,13 @@ package rpc_test
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
 	"net"
 	"testing"
 	"time"
@@ -133,3 +140,130 @@ func TestListenAndServe(t *testing.T) {
 		assert.ErrorIs(t, err, net.ErrClosed)
 	}
 }
+
+// peerPingPong is a PingPong server that reports the peer of each call.
+type peerPingPong struct {
+	peers chan *rpc.PeerInfo
+}
+
+func (s peerPingPong) EchoNum(ctx context.Context, call testcp.PingPong_echoNum) error {
+	peer, _ := rpc.Peer(ctx)
+	s.peers <- peer
+	return pingPongServer{}.EchoNum(ctx, call)
+}
+
+// TestServeMutualTLS checks that a Server authenticates clients with
+// TLS, hands out bootstrap capabilities by identity and delivers the
+// peer to calls.
+func TestServeMutualTLS(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	ca := newTestCert(t, "ca", nil)
+	serverCert := newTestCert(t, "server", &ca)
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.Leaf)
+
+	peers := make(chan *rpc.PeerInfo, 1)
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	errChannel := make(chan error)
+	go func() {
+		srv := &rpc.Server{
+			TLSConfig: &tls.Config{
+				Certificates: []tls.Certificate{serverCert},
+				ClientAuth:   tls.RequireAndVerifyClientCert,
+				ClientCAs:    pool,
+			},
+			BootstrapFunc: func(peer rpc.PeerInfo) capnp.Client {
+				if peer.Certificates[0].Subject.CommonName != "alice" {
+					return capnp.Client{}
+				}
+				return capnp.Client(testcp.PingPong_ServerToClient(peerPingPong{peers}))
+			},
+		}
+		errChannel <- srv.Serve(lis)
+	}()
+
+	dial := func(name string) *rpc.Conn {
+		config := &tls.Config{RootCAs: pool}
+		if name != "" {
+			config.Certificates = []tls.Certificate{newTestCert(t, name, &ca)}
+		}
+		conn, err := rpc.DialTLS(ctx, "tcp", lis.Addr().String(), config, nil)
+		if err != nil {
+			t.Fatal("DialTLS:", err)
+		}
+		return conn
+	}
+
+	// alice is served the PingPong, and its calls see her certificate.
+	conn := dial("alice")
+	pp := testcp.PingPong(conn.Bootstrap(ctx))
+	echoNum(ctx, t, pp, 42)
+	peer := <-peers
+	if assert.NotNil(t, peer, "call has no peer") {
+		assert.Equal(t, "alice", peer.Certificates[0].Subject.CommonName)
+		assert.NotNil(t, peer.TLS)
+		assert.NotNil(t, peer.RemoteAddr)
+	}
+	pp.Release()
+	conn.Close()
+
+	// bob is authenticated, but given no capability, and a client
+	// without a certificate is turned away.
+	for _, name := range []string{"bob", ""} {
+		conn = dial(name)
+		pp = testcp.PingPong(conn.Bootstrap(ctx))
+		ans, release := pp.EchoNum(ctx, nil)
+		_, err := ans.Struct()
+		assert.Error(t, err, "call by %q succeeded", name)
+		release()
+		pp.Release()
+		conn.Close()
+	}
+
+	assert.NoError(t, lis.Close())
+	assert.ErrorIs(t, <-errChannel, net.ErrClosed)
+}
+
+// newTestCert returns a certificate for name signed by parent, or a
+// self-signed certificate authority if parent is nil.
+func newTestCert(t *testing.T, name string, parent *tls.Certificate) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+	}
+	issuer, signer := tmpl, any(key)
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		tmpl.KeyUsage |= x509.KeyUsageCertSign
+	} else {
+		issuer, signer = parent.Leaf, parent.PrivateKey
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, issuer, &key.PublicKey, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{
+		Certificate: [][]byte{der},
+		PrivateKey:  key,
+		Leaf:        leaf,
+	}
+}
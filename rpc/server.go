@@ -0,0 +1,369 @@
+package rpc
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"capnproto.org/go/capnp/v3"
+	"capnproto.org/go/capnp/v3/internal/syncutil"
+)
+
+// ErrServerClosed is returned by Server.Serve after a call to Shutdown
+// or Close.
+var ErrServerClosed = errors.New("rpc: server closed")
+
+// ConnState is the state of a Conn created by a Server, as reported to
+// Server.ConnState.
+type ConnState int
+
+// Conn states.
+const (
+	// ConnNew means the Conn has just been created, and is receiving
+	// messages.
+	ConnNew ConnState = iota
+
+	// ConnDraining means the Server is shutting down, and the Conn is
+	// draining: see Conn.Drain.
+	ConnDraining
+
+	// ConnClosed means the Conn has shut down.
+	ConnClosed
+)
+
+// String returns the state's name.
+func (s ConnState) String() string {
+	switch s {
+	case ConnNew:
+		return "new"
+	case ConnDraining:
+		return "draining"
+	case ConnClosed:
+		return "closed"
+	default:
+		return "unknown"
+	}
+}
+
+// A Server serves Cap'n Proto RPC to the connections accepted from one
+// or more listeners, like Serve, and keeps track of the Conns it
+// creates so that it can limit their number and shut them down.  The
+// fields must not be changed once Serve is called.
+type Server struct {
+	// Bootstrap is the capability returned to remote vats that ask for
+	// one, if BootstrapFunc is nil.  The Server takes ownership of it
+	// and releases it in Shutdown or Close.
+	Bootstrap capnp.Client
+
+	// BootstrapFunc, if not nil, returns the bootstrap capability for a
+	// connection from peer.  The Conn takes ownership of the returned
+	// client, which may be null to give peer no capability.
+	// BootstrapFunc is called from its own goroutine for each
+	// connection, after the TLS handshake.
+	BootstrapFunc func(peer PeerInfo) capnp.Client
+
+	// TLSConfig, if not nil, makes connections use TLS with this
+	// configuration.  Connections whose handshake fails are closed.
+	TLSConfig *tls.Config
+
+	// HandshakeTimeout limits the time a TLS handshake may take.  If
+	// zero, 10 seconds is used.
+	HandshakeTimeout time.Duration
+
+	// MaxConns limits the number of connections open at once.  Once it
+	// is reached, Serve stops accepting connections until one closes.
+	// If zero, there is no limit.
+	MaxConns int
+
+	// ConnContext, if not nil, returns the Context whose values the
+	// Contexts of calls received on conn carry, derived from ctx, which
+	// is context.Background().
+	ConnContext func(ctx context.Context, conn net.Conn) context.Context
+
+	// ConnOptions, if not nil, is called with the Options for the Conn
+	// that serves a connection from peer before the Conn is created,
+	// and may change them, for instance to set an ErrorReporter or
+	// limits.  opts has BootstrapClient, Peer and BaseContext set.
+	ConnOptions func(peer PeerInfo, opts *Options)
+
+	// ConnState, if not nil, is called when a Conn changes state, in
+	// the order ConnNew, ConnDraining if the Server is shut down, and
+	// ConnClosed.  It is called from the goroutine that made the change
+	// with the Server's lock held, so it should be quick to return and
+	// must not call the Server's methods.
+	ConnState func(*Conn, ConnState)
+
+	mu        sync.Mutex
+	listeners map[*net.Listener]struct{}
+	conns     map[*Conn]struct{}
+	pending   map[net.Conn]struct{} // accepted, but without a Conn yet
+	closed    bool
+	done      chan struct{} // closed when closed is set
+	slots     chan struct{} // used by MaxConns; one per open connection
+	wg        sync.WaitGroup
+}
+
+// init initializes s's tracking state.  The caller MUST be holding
+// onto s.mu.
+func (s *Server) init() {
+	if s.done != nil {
+		return
+	}
+	s.listeners = make(map[*net.Listener]struct{})
+	s.conns = make(map[*Conn]struct{})
+	s.pending = make(map[net.Conn]struct{})
+	s.done = make(chan struct{})
+	if s.MaxConns > 0 {
+		s.slots = make(chan struct{}, s.MaxConns)
+	}
+}
+
+// Serve accepts connections from lis and serves each with a new Conn,
+// until lis fails or the Server is shut down.  It closes lis before it
+// returns.  After Shutdown or Close, it returns ErrServerClosed;
+// otherwise it returns the error from lis.Accept.  Serve may be called
+// for several listeners at once.
+func (s *Server) Serve(lis net.Listener) error {
+	if s.TLSConfig != nil {
+		lis = tls.NewListener(lis, s.TLSConfig)
+	}
+	var closed bool
+	syncutil.With(&s.mu, func() {
+		s.init()
+		closed = s.closed
+		if !closed {
+			s.listeners[&lis] = struct{}{}
+		}
+	})
+	if closed {
+		lis.Close()
+		return ErrServerClosed
+	}
+	if s.BootstrapFunc == nil && !s.Bootstrap.IsValid() {
+		syncutil.With(&s.mu, func() {
+			delete(s.listeners, &lis)
+		})
+		lis.Close()
+		return errors.New("bootstrap client is not valid")
+	}
+	defer syncutil.With(&s.mu, func() {
+		delete(s.listeners, &lis)
+	})
+	defer lis.Close()
+
+	for {
+		if s.slots != nil {
+			select {
+			case s.slots <- struct{}{}:
+			case <-s.done:
+				return ErrServerClosed
+			}
+		}
+		conn, err := lis.Accept()
+		if err != nil {
+			if s.slots != nil {
+				<-s.slots
+			}
+			select {
+			case <-s.done:
+				return ErrServerClosed
+			default:
+				return err
+			}
+		}
+
+		// The Conn takes ownership of the bootstrap client, so take a
+		// reference now, before Shutdown or Serve can release it.
+		var (
+			boot capnp.Client
+			ok   bool
+		)
+		syncutil.With(&s.mu, func() {
+			if ok = !s.closed; ok {
+				if s.BootstrapFunc == nil {
+					boot = s.Bootstrap.AddRef()
+				}
+				s.pending[conn] = struct{}{}
+				s.wg.Add(1)
+			}
+		})
+		if !ok {
+			conn.Close()
+			if s.slots != nil {
+				<-s.slots
+			}
+			return ErrServerClosed
+		}
+		go s.serveConn(conn, boot)
+	}
+}
+
+// ListenAndServe opens a listener on the given address, as net.Listen
+// does, and serves connections from it as Serve does, until ctx is done
+// or the Server is shut down.
+func (s *Server) ListenAndServe(ctx context.Context, network, addr string) error {
+	lis, err := net.Listen(network, addr)
+	if err != nil {
+		return err
+	}
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			lis.Close()
+		case <-done:
+		}
+	}()
+	return s.Serve(lis)
+}
+
+// serveConn completes conn's TLS handshake, if it has one, and serves
+// it with a new Conn.  It takes ownership of boot.
+func (s *Server) serveConn(conn net.Conn, boot capnp.Client) {
+	defer s.wg.Done()
+	c, ok := s.newConn(conn, boot)
+	syncutil.With(&s.mu, func() {
+		delete(s.pending, conn)
+	})
+	if !ok {
+		conn.Close()
+		if s.slots != nil {
+			<-s.slots
+		}
+		return
+	}
+
+	<-c.Done()
+	syncutil.With(&s.mu, func() {
+		delete(s.conns, c)
+		s.setState(c, ConnClosed)
+	})
+	if s.slots != nil {
+		<-s.slots
+	}
+}
+
+// newConn creates the Conn that serves conn, and reports whether it
+// did; it does not if the handshake fails or the Server is closed.  It
+// takes ownership of boot.
+func (s *Server) newConn(conn net.Conn, boot capnp.Client) (*Conn, bool) {
+	if tc, ok := conn.(*tls.Conn); ok {
+		timeout := s.HandshakeTimeout
+		if timeout <= 0 {
+			timeout = 10 * time.Second
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		err := tc.HandshakeContext(ctx)
+		cancel()
+		if err != nil {
+			boot.Release()
+			return nil, false
+		}
+	}
+	peer := newPeerInfo(conn)
+	if s.BootstrapFunc != nil {
+		boot = s.BootstrapFunc(*peer)
+	}
+	ctx := context.Background()
+	if s.ConnContext != nil {
+		ctx = s.ConnContext(ctx, conn)
+	}
+	opts := &Options{
+		BootstrapClient: boot,
+		Peer:            peer,
+		BaseContext:     ctx,
+	}
+	if s.ConnOptions != nil {
+		s.ConnOptions(*peer, opts)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		opts.BootstrapClient.Release()
+		return nil, false
+	}
+	c := NewConn(NewStreamTransport(conn), opts)
+	s.setState(c, ConnNew)
+	s.conns[c] = struct{}{}
+	return c, true
+}
+
+// setState reports a change of c's state.  The caller MUST be holding
+// onto s.mu, so that the changes are reported in order.
+func (s *Server) setState(c *Conn, state ConnState) {
+	if s.ConnState != nil {
+		s.ConnState(c, state)
+	}
+}
+
+// Shutdown gracefully shuts down the Server.  It closes the listeners,
+// so that Serve returns ErrServerClosed, and drains every Conn: see
+// Conn.Drain.  Once every Conn has closed, it releases the Bootstrap
+// client and returns the first error that draining a Conn returned,
+// other than for Conns that were already closing.  If ctx is done
+// first, the remaining Conns are closed right away, and the error wraps
+// the Context's error.
+func (s *Server) Shutdown(ctx context.Context) error {
+	conns := s.close(true)
+
+	errs := make(chan error, len(conns))
+	for _, c := range conns {
+		go func(c *Conn) {
+			errs <- c.Drain(ctx)
+		}(c)
+	}
+	var err error
+	for range conns {
+		if e := <-errs; e != nil && !errors.Is(e, ExcClosed) && err == nil {
+			err = e
+		}
+	}
+	s.wg.Wait()
+	s.Bootstrap.Release()
+	return err
+}
+
+// Close closes the Server's listeners and Conns right away, canceling
+// the calls in progress, and releases the Bootstrap client.  Serve
+// returns ErrServerClosed.
+func (s *Server) Close() error {
+	conns := s.close(false)
+	for _, c := range conns {
+		c.Close()
+	}
+	s.wg.Wait()
+	s.Bootstrap.Release()
+	return nil
+}
+
+// close marks s closed, closes its listeners and the connections that
+// do not have a Conn yet, and returns its Conns.  If draining is true,
+// it reports that the Conns are draining.
+func (s *Server) close(draining bool) []*Conn {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.init()
+	if !s.closed {
+		s.closed = true
+		close(s.done)
+	}
+	for lis := range s.listeners {
+		(*lis).Close()
+	}
+	for conn := range s.pending {
+		conn.Close()
+	}
+	conns := make([]*Conn, 0, len(s.conns))
+	for c := range s.conns {
+		conns = append(conns, c)
+		if draining {
+			s.setState(c, ConnDraining)
+		}
+	}
+	return conns
+}
@@ -0,0 +1,346 @@
+package rpc_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"capnproto.org/go/capnp/v3"
+	"capnproto.org/go/capnp/v3/rpc"
+	testcp "capnproto.org/go/capnp/v3/rpc/internal/testcapnp"
+)
+
+// TestServerShutdown checks that Shutdown stops Serve, lets a call in
+// flight return, and reports each Conn's states.
+func TestServerShutdown(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	impl := newBlockingPingPong()
+	boot := capnp.Client(testcp.PingPong_ServerToClient(impl))
+	var states stateRecorder
+	srv := &rpc.Server{
+		Bootstrap: boot,
+		ConnState: states.record,
+	}
+	lis, served := startServer(t, srv)
+
+	client, pp := dialServer(t, lis)
+	defer client.Close()
+	defer pp.Release()
+	blocked, release := pp.EchoNum(ctx, func(p testcp.PingPong_echoNum_Params) error {
+		p.SetN(blockingN)
+		return nil
+	})
+	defer release()
+	<-impl.started
+
+	shutdown := make(chan error, 1)
+	go func() {
+		shutdown <- srv.Shutdown(ctx)
+	}()
+	if err := <-served; !errors.Is(err, rpc.ErrServerClosed) {
+		t.Errorf("Serve = %v; want %v", err, rpc.ErrServerClosed)
+	}
+	if _, err := net.Dial("tcp", lis.Addr().String()); err == nil {
+		t.Error("dial after Shutdown succeeded")
+	}
+	select {
+	case err := <-shutdown:
+		t.Fatal("Shutdown returned before call in flight:", err)
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	close(impl.unblock)
+	if _, err := blocked.Struct(); err != nil {
+		t.Error("call in flight:", err)
+	}
+	if err := <-shutdown; err != nil {
+		t.Error("Shutdown:", err)
+	}
+	if got, want := states.get(), []rpc.ConnState{rpc.ConnNew, rpc.ConnDraining, rpc.ConnClosed}; !equalStates(got, want) {
+		t.Errorf("states = %v; want %v", got, want)
+	}
+	if boot.IsValid() {
+		t.Error("Shutdown did not release the bootstrap client")
+	}
+}
+
+// TestServerShutdownDeadline checks that Shutdown closes the Conns when
+// its Context expires before their calls return.
+func TestServerShutdownDeadline(t *testing.T) {
+	t.Parallel()
+
+	impl := newBlockingPingPong()
+	srv := &rpc.Server{
+		Bootstrap: capnp.Client(testcp.PingPong_ServerToClient(impl)),
+	}
+	lis, served := startServer(t, srv)
+
+	client, pp := dialServer(t, lis)
+	defer client.Close()
+	defer pp.Release()
+	blocked, release := pp.EchoNum(context.Background(), func(p testcp.PingPong_echoNum_Params) error {
+		p.SetN(blockingN)
+		return nil
+	})
+	defer release()
+	<-impl.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := srv.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Shutdown = %v; want %v", err, context.DeadlineExceeded)
+	}
+	<-served
+	<-client.Done()
+	if _, err := blocked.Struct(); err == nil {
+		t.Error("call in flight succeeded after Shutdown")
+	}
+}
+
+// TestServerShutdownDisconnected checks that Shutdown returns the error
+// from draining a Conn that closes before its calls return.
+func TestServerShutdownDisconnected(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	impl := newBlockingPingPong()
+	srv := &rpc.Server{
+		Bootstrap: capnp.Client(testcp.PingPong_ServerToClient(impl)),
+	}
+	lis, served := startServer(t, srv)
+
+	client, pp := dialServer(t, lis)
+	defer pp.Release()
+	blocked, release := pp.EchoNum(ctx, func(p testcp.PingPong_echoNum_Params) error {
+		p.SetN(blockingN)
+		return nil
+	})
+	defer release()
+	<-impl.started
+
+	shutdown := make(chan error, 1)
+	go func() {
+		shutdown <- srv.Shutdown(ctx)
+	}()
+	<-served
+	// Once the Conn rejects new calls, it is draining.
+	for {
+		ans, release := pp.EchoNum(ctx, func(p testcp.PingPong_echoNum_Params) error {
+			p.SetN(1)
+			return nil
+		})
+		_, err := ans.Struct()
+		release()
+		if err != nil {
+			if !strings.Contains(err.Error(), rpc.ErrDraining.Error()) {
+				t.Fatal("call while draining:", err)
+			}
+			break
+		}
+	}
+	client.Close()
+
+	if err := <-shutdown; !capnp.IsDisconnected(err) {
+		t.Errorf("Shutdown = %v; want disconnected", err)
+	}
+	if _, err := blocked.Struct(); err == nil {
+		t.Error("call in flight succeeded after its Conn closed")
+	}
+}
+
+// TestServerClose checks that Close closes the Conns right away.
+func TestServerClose(t *testing.T) {
+	t.Parallel()
+
+	impl := newBlockingPingPong()
+	srv := &rpc.Server{
+		Bootstrap: capnp.Client(testcp.PingPong_ServerToClient(impl)),
+	}
+	lis, served := startServer(t, srv)
+
+	client, pp := dialServer(t, lis)
+	defer pp.Release()
+	blocked, release := pp.EchoNum(context.Background(), func(p testcp.PingPong_echoNum_Params) error {
+		p.SetN(blockingN)
+		return nil
+	})
+	defer release()
+	<-impl.started
+
+	if err := srv.Close(); err != nil {
+		t.Error("Close:", err)
+	}
+	if err := <-served; !errors.Is(err, rpc.ErrServerClosed) {
+		t.Errorf("Serve = %v; want %v", err, rpc.ErrServerClosed)
+	}
+	<-client.Done()
+	if _, err := blocked.Struct(); err == nil {
+		t.Error("call in flight succeeded after Close")
+	}
+	if err := srv.Serve(lis); !errors.Is(err, rpc.ErrServerClosed) {
+		t.Errorf("Serve after Close = %v; want %v", err, rpc.ErrServerClosed)
+	}
+}
+
+// TestServerMaxConns checks that a Server with MaxConns does not serve
+// more connections than that at once.
+func TestServerMaxConns(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	srv := &rpc.Server{
+		Bootstrap: capnp.Client(testcp.PingPong_ServerToClient(pingPongServer{})),
+		MaxConns:  1,
+	}
+	lis, _ := startServer(t, srv)
+	defer srv.Close()
+
+	client1, pp1 := dialServer(t, lis)
+	defer pp1.Release()
+	echoNum(ctx, t, pp1, 1)
+
+	client2, pp2 := dialServer(t, lis)
+	defer client2.Close()
+	defer pp2.Release()
+	done := make(chan error, 1)
+	go func() {
+		ans, release := pp2.EchoNum(ctx, func(p testcp.PingPong_echoNum_Params) error {
+			p.SetN(2)
+			return nil
+		})
+		defer release()
+		_, err := ans.Struct()
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		t.Fatal("second connection served while first was open:", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	client1.Close()
+	if err := <-done; err != nil {
+		t.Error("call on second connection:", err)
+	}
+}
+
+// TestServerConnHooks checks that ConnContext's values reach the
+// Contexts of calls, and that ConnOptions can change a Conn's Options.
+func TestServerConnHooks(t *testing.T) {
+	t.Parallel()
+
+	type key struct{}
+	got := make(chan context.Context, 1)
+	peers := make(chan string, 1)
+	srv := &rpc.Server{
+		Bootstrap: capnp.Client(testcp.PingPong_ServerToClient(ctxPingPong{got: got})),
+		ConnContext: func(ctx context.Context, conn net.Conn) context.Context {
+			return context.WithValue(ctx, key{}, conn.RemoteAddr().String())
+		},
+		ConnOptions: func(peer rpc.PeerInfo, opts *rpc.Options) {
+			peers <- peer.RemoteAddr.String()
+			opts.CallMetadata = true
+		},
+	}
+	lis, _ := startServer(t, srv)
+	defer srv.Close()
+
+	client, pp := dialServer(t, lis, &rpc.Options{CallMetadata: true})
+	defer client.Close()
+	defer pp.Release()
+	ctx := rpc.WithCallMetadata(context.Background(), rpc.CallMetadataMap{"k": "v"})
+	echoNum(ctx, t, pp, 1)
+
+	callCtx := <-got
+	addr, _ := callCtx.Value(key{}).(string)
+	if addr == "" {
+		t.Error("call Context does not carry ConnContext's value")
+	}
+	if peer := <-peers; peer != addr {
+		t.Errorf("ConnOptions called for %s; want %s", peer, addr)
+	}
+	if md := rpc.CallMetadata(callCtx); md["k"] != "v" {
+		t.Errorf("call metadata = %v; want map[k:v] (ConnOptions enables it)", md)
+	}
+}
+
+// startServer serves srv on a new TCP listener, and returns the
+// listener and a channel that receives Serve's result.
+func startServer(t *testing.T, srv *rpc.Server) (net.Listener, <-chan error) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	served := make(chan error, 1)
+	go func() {
+		served <- srv.Serve(lis)
+	}()
+	return lis, served
+}
+
+// dialServer connects to lis and returns the Conn, created with opts if
+// given, and its bootstrap capability.
+func dialServer(t *testing.T, lis net.Listener, opts ...*rpc.Options) (*rpc.Conn, testcp.PingPong) {
+	conn, err := net.Dial("tcp", lis.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var o *rpc.Options
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	c := rpc.NewConn(rpc.NewStreamTransport(conn), o)
+	return c, testcp.PingPong(c.Bootstrap(context.Background()))
+}
+
+type stateRecorder struct {
+	mu     sync.Mutex
+	states []rpc.ConnState
+}
+
+func (r *stateRecorder) record(_ *rpc.Conn, state rpc.ConnState) {
+	r.mu.Lock()
+	r.states = append(r.states, state)
+	r.mu.Unlock()
+}
+
+func (r *stateRecorder) get() []rpc.ConnState {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]rpc.ConnState(nil), r.states...)
+}
+
+func equalStates(a, b []rpc.ConnState) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// ctxPingPong echoes numbers and sends the Context of each call to got.
+type ctxPingPong struct {
+	got chan<- context.Context
+}
+
+func (p ctxPingPong) EchoNum(ctx context.Context, call testcp.PingPong_echoNum) error {
+	p.got <- ctx
+	out, err := call.AllocResults()
+	if err != nil {
+		return err
+	}
+	out.SetN(call.Args().N())
+	return nil
+}
@@ -0,0 +1,399 @@
+package rpc
+
+import (
+	"context"
+
+	"capnproto.org/go/capnp/v3"
+	"capnproto.org/go/capnp/v3/internal/syncutil"
+	rpccp "capnproto.org/go/capnp/v3/std/capnp/rpc"
+)
+
+/*
+Tail calls
+
+A method implementation can end a call by making another call whose
+results become its results (see server.Call.TailCall).  Usually the
+results are copied from the tail call's answer into the original one,
+but when the remote vat made the original call and also hosts the tail
+call's target, the results can stay where they are:
+
+ 1. Vat B sends the tail call to vat A with sendResultsTo.yourself.
+ 2. Vat B returns the original call with takeFromOtherQuestion set to
+    the tail call's question ID, without waiting for the tail call.
+ 3. Vat A answers the tail call, keeping the results in a local
+    message instead of sending them (see answer.keepResults), and
+    sends a Return with resultsSentElsewhere.
+ 4. Vat A resolves its original question with the kept results, then
+    sends a Finish for it.
+ 5. Vat B receives the Finish for the original call, which cancels its
+    Context, and only then sends the Finish for the tail call.  Until
+    then, pipelined calls that were sent to vat B on the original call
+    are forwarded to the tail call.
+
+Calls that vat A pipelined on the original call before step 4 reach the
+tail call's results through vat B, so vat A embargoes the capabilities
+they were made on, like it does for results that point back to it (see
+embargoTakenResults).  Vat B forwards the calls it has queued for the
+original call to the tail call before step 2 (see capnp.Recv), so it can
+loop the Disembargo back to vat A as soon as it receives it, behind the
+calls it forwarded.
+*/
+
+// tailAnswer returns the answer that r is a tail call for, if r can be
+// sent to c's remote vat with sendResultsTo.yourself: the answer must
+// be to a call that the remote vat made on c.
+func (c *Conn) tailAnswer(r capnp.Recv) *answer {
+	if !r.TailCall {
+		return nil
+	}
+	ans, ok := r.Returner.(*answer)
+	if !ok || ans.c != c || ans.keepResults {
+		return nil
+	}
+	return ans
+}
+
+// tailCall sends r to the import as a tail call for ans.
+func (ic *importClient) tailCall(ctx context.Context, ans *answer, r capnp.Recv) capnp.PipelineCaller {
+	var (
+		q   *question
+		err error
+	)
+	syncutil.With(&ic.c.lk, func() {
+		if !ic.c.startTask() {
+			err = ExcClosed
+			return
+		}
+		defer ic.c.tasks.Done()
+		ent := ic.c.lk.imports[ic.id]
+		if ent == nil || ic.generation != ent.generation {
+			err = rpcerr.Disconnectedf("send on closed import")
+			return
+		}
+		q, err = ic.c.sendTailCall(ctx, r, func(m rpccp.Message, qid questionID, s capnp.Send) error {
+			return ic.c.newImportCallMessage(m, ic.id, qid, s, ic.c.outgoingMetadata(ctx))
+		})
+	})
+	if err != nil {
+		r.Reject(err)
+		return nil
+	}
+	if r.ForwardPipeline != nil {
+		r.ForwardPipeline(q.p.Answer())
+	}
+	ans.returnTail(q)
+	return q.p.Answer()
+}
+
+// tailCall sends r to the promised answer as a tail call for ans.
+func (q *question) tailCall(ctx context.Context, transform []capnp.PipelineOp, ans *answer, r capnp.Recv) capnp.PipelineCaller {
+	var (
+		q2  *question
+		err error
+	)
+	syncutil.With(&q.c.lk, func() {
+		if !q.c.startTask() {
+			err = ExcClosed
+			return
+		}
+		defer q.c.tasks.Done()
+		q.mark(transform)
+		q2, err = q.c.sendTailCall(ctx, r, func(m rpccp.Message, qid questionID, s capnp.Send) error {
+			return q.c.newPipelineCallMessage(m, q.id, transform, qid, s, q.c.outgoingMetadata(ctx))
+		})
+	})
+	if err != nil {
+		r.Reject(err)
+		return nil
+	}
+	if r.ForwardPipeline != nil {
+		r.ForwardPipeline(q2.p.Answer())
+	}
+	ans.returnTail(q2)
+	return q2.p.Answer()
+}
+
+// sendTailCall adds a question for r and sends a Call message for it
+// with sendResultsTo.yourself set.  newCall builds the rest of the
+// message.  The question is canceled when ctx is.
+//
+// The caller MUST hold c.lk.
+func (c *Conn) sendTailCall(ctx context.Context, r capnp.Recv, newCall func(rpccp.Message, questionID, capnp.Send) error) (*question, error) {
+	q := c.newQuestion(r.Method)
+	q.flags |= resultsSentElsewhere
+	ctx, q.trace = c.traceCall(ctx, r.Method, false, uint32(q.id))
+	built := false
+	c.sendMessage(ctx, func(m rpccp.Message) error {
+		err := newCall(m, q.id, capnp.Send{
+			Method:   r.Method,
+			ArgsSize: r.Args.Size(),
+			PlaceArgs: func(s capnp.Struct) error {
+				return s.CopyFrom(r.Args)
+			},
+		})
+		if err != nil {
+			return err
+		}
+		call, err := m.Call()
+		if err != nil {
+			return rpcerr.Failedf("build call message: %w", err)
+		}
+		call.SendResultsTo().SetYourself()
+		built = true
+		return nil
+	}, func(err error) {
+		if err != nil {
+			syncutil.With(&c.lk, func() {
+				c.removeQuestion(q.id)
+			})
+			err = rpcerr.Failedf("send message: %w", err)
+			q.p.Reject(err)
+			q.trace.done(err)
+			syncutil.With(&c.lk, func() {
+				c.lk.questionID.remove(uint32(q.id))
+			})
+			return
+		}
+
+		c.tasks.Add(1)
+		go func() {
+			defer c.tasks.Done()
+			q.handleCancel(ctx)
+		}()
+	})
+	r.ReleaseArgs()
+	if !built {
+		// The message won't be sent; onSent cleans up the question.
+		return nil, rpcerr.Failedf("tail call: could not build call message")
+	}
+	return q, nil
+}
+
+// returnTail sends the Return message for a call whose results are to
+// be taken from q, a tail call sent to the remote vat.
+//
+// The caller MUST NOT hold ans.c.lk.
+func (ans *answer) returnTail(q *question) {
+	rl := &releaseList{}
+	defer rl.Release()
+
+	defer ans.pcalls.Wait()
+
+	syncutil.With(&ans.c.lk, func() {
+		ans.sendTailReturn(rl, q)
+	})
+	ans.c.tasks.Done() // added by handleCall
+}
+
+// sendTailReturn sends a Return message with takeFromOtherQuestion set
+// to q's ID.
+//
+// The caller MUST be holding onto ans.c.lk.
+func (ans *answer) sendTailReturn(rl *releaseList, q *question) {
+	ans.tail = q
+	ans.pcall = nil
+	ans.flags |= resultsReady
+	ans.trace.done(nil)
+
+	if ans.promise != nil {
+		// Capabilities already taken from the answer by the remote vat
+		// have been pipelining on pcall, which forwarded its calls to
+		// the tail call.
+		ans.promise.Reject(rpcerr.Failedf("results were taken from a tail call"))
+		ans.promise = nil
+	}
+
+	select {
+	case <-ans.c.bgctx.Done():
+		ans.msgReleaser.Decr()
+	default:
+		ans.ret.SetTakeFromOtherQuestion(uint32(q.id))
+		ans.sendMsg()
+	}
+	ans.flags |= returnSent
+	ans.c.signalIdle()
+	if ans.flags.Contains(finishReceived) {
+		// destroy will never return an error because the Return
+		// message has no capabilities.
+		_ = ans.destroy(rl)
+	}
+}
+
+// takeResults waits until done is closed, then returns the results of
+// a keepResults answer and a function that releases them.  done may be
+// nil if the answer's results were ready when they were taken.
+//
+// The caller MUST NOT hold ans.c.lk.
+func (ans *answer) takeResults(done <-chan struct{}) (capnp.Ptr, capnp.ReleaseFunc, error) {
+	if done != nil {
+		select {
+		case <-done:
+		case <-ans.c.bgctx.Done():
+			return capnp.Ptr{}, nil, ExcClosed
+		}
+	}
+
+	ans.c.lk.Lock()
+	defer ans.c.lk.Unlock()
+
+	if !ans.flags.Contains(resultsReady) {
+		return capnp.Ptr{}, nil, rpcerr.Failedf("take results of answer %d: not returned", ans.id)
+	}
+	if ans.err != nil {
+		return capnp.Ptr{}, nil, ans.err
+	}
+	msg := ans.results.Message()
+	release := func() {
+		if msg != nil {
+			msg.Reset(nil)
+		}
+	}
+	content, err := ans.results.Content()
+	if err != nil {
+		release()
+		return capnp.Ptr{}, nil, rpcerr.Failedf("take results of answer %d: %w", ans.id, err)
+	}
+	return content, release, nil
+}
+
+// embargoTakenResults embargoes the capabilities that calls were
+// pipelined on in the results that a Return with takeFromOtherQuestion
+// took for q.  Those calls went to the remote vat, which forwards them
+// to the tail call, so calls made on q's results once it resolves must
+// wait for them.  The tail call's answer delivers the forwarded calls
+// from the same results, so the capabilities are embargoed in a copy of
+// them, which embargoTakenResults returns along with a function that
+// releases both and the Disembargo messages to send.
+//
+// The caller MUST NOT hold c.lk.
+func (c *Conn) embargoTakenResults(q *question, content capnp.Ptr, release capnp.ReleaseFunc) (capnp.Ptr, capnp.ReleaseFunc, []senderLoopback, error) {
+	var called bool
+	syncutil.With(&c.lk, func() {
+		called = len(q.called) > 0
+	})
+	if !called {
+		return content, release, nil, nil
+	}
+
+	msg, seg, err := capnp.NewMessage(capnp.SingleSegment(nil))
+	if err != nil {
+		release()
+		return capnp.Ptr{}, nil, nil, rpcerr.Failedf("copy taken results: %w", err)
+	}
+	releaseAll := func() {
+		msg.Reset(nil)
+		release()
+	}
+	results, err := capnp.NewRootStruct(seg, content.Struct().Size())
+	if err == nil {
+		err = results.CopyFrom(content.Struct())
+	}
+	if err != nil {
+		releaseAll()
+		return capnp.Ptr{}, nil, nil, rpcerr.Failedf("copy taken results: %w", err)
+	}
+
+	var disembargoes []senderLoopback
+	syncutil.With(&c.lk, func() {
+		var embargoCaps uintSet
+		for _, xform := range q.called {
+			p, _ := capnp.Transform(results.ToPtr(), xform)
+			iface := p.Interface()
+			if !iface.IsValid() {
+				continue
+			}
+			i := iface.Capability()
+			if int64(i) >= int64(len(msg.CapTable)) || embargoCaps.has(uint(i)) {
+				continue
+			}
+			var id embargoID
+			id, msg.CapTable[i] = c.embargo(msg.CapTable[i])
+			embargoCaps.add(uint(i))
+			disembargoes = append(disembargoes, senderLoopback{
+				id:        id,
+				question:  q.id,
+				transform: xform,
+			})
+		}
+	})
+	return results.ToPtr(), releaseAll, disembargoes, nil
+}
+
+// tailDisembargoTarget returns the tail call whose results an answer
+// targeted by a Disembargo were taken from, or nil if the target is not
+// such an answer.
+//
+// The caller MUST be holding onto c.lk.
+func (c *Conn) tailDisembargoTarget(tgt parsedMessageTarget) *question {
+	if tgt.which != rpccp.MessageTarget_Which_promisedAnswer {
+		return nil
+	}
+	ans := c.lk.answers[tgt.promisedAnswer]
+	if ans == nil || !ans.flags.Contains(returnSent) {
+		return nil
+	}
+	return ans.tail
+}
+
+// sendTailDisembargo loops a sender loopback Disembargo that targets
+// the results of an answer taken from q back to the remote vat, with
+// the capability's place in q's results as its target.  The calls that
+// were pipelined on the answer have been forwarded to q ahead of it.
+//
+// The caller MUST be holding onto c.lk.
+func (c *Conn) sendTailDisembargo(ctx context.Context, q *question, transform []capnp.PipelineOp, id uint32) {
+	c.sendMessage(ctx, func(m rpccp.Message) error {
+		d, err := m.NewDisembargo()
+		if err != nil {
+			return err
+		}
+		tgt, err := d.NewTarget()
+		if err != nil {
+			return err
+		}
+		pa, err := tgt.NewPromisedAnswer()
+		if err != nil {
+			return err
+		}
+		oplist, err := pa.NewTransform(int32(len(transform)))
+		if err != nil {
+			return err
+		}
+		pa.SetQuestionId(uint32(q.id))
+		for i, op := range transform {
+			oplist.At(i).SetGetPointerField(op.Field)
+		}
+		d.Context().SetReceiverLoopback(id)
+		return nil
+	}, func(err error) {
+		if err != nil {
+			c.er.ReportError(rpcerr.Annotatef(err, "incoming disembargo: send receiver loopback"))
+		}
+	})
+}
+
+// releaseKeptResults arranges for rl to release the results of a
+// keepResults answer, unless a Return with takeFromOtherQuestion took
+// them.
+//
+// The caller MUST be holding onto ans.c.lk.
+func (ans *answer) releaseKeptResults(rl *releaseList) {
+	if !ans.keepResults || ans.flags.Contains(resultsTaken) {
+		return
+	}
+	if msg := ans.results.Message(); msg != nil {
+		rl.Add(func() {
+			msg.Reset(nil)
+		})
+	}
+}
+
+// newLocalPayload returns a Payload in a new message, for the results
+// of a keepResults answer.
+func newLocalPayload() (rpccp.Payload, error) {
+	_, seg, err := capnp.NewMessage(capnp.SingleSegment(nil))
+	if err != nil {
+		return rpccp.Payload{}, err
+	}
+	return rpccp.NewRootPayload(seg)
+}
@@ -0,0 +1,111 @@
+// Package tracing provides an rpc.Observer that records a span for each
+// call made or received over a connection.
+//
+// The Tracer and Span interfaces are a subset of OpenTelemetry's
+// trace.Tracer and trace.Span, so that adapting them takes a few lines:
+//
+//	type otelTracer struct{ t trace.Tracer }
+//
+//	func (o otelTracer) Start(ctx context.Context, name string, kind tracing.SpanKind) (context.Context, tracing.Span) {
+//		sk := trace.SpanKindClient
+//		if kind == tracing.SpanKindServer {
+//			sk = trace.SpanKindServer
+//		}
+//		ctx, span := o.t.Start(ctx, name, trace.WithSpanKind(sk))
+//		return ctx, otelSpan{span}
+//	}
+//
+// where otelSpan forwards SetAttribute to span.SetAttributes, and
+// RecordError and End to the methods of the same names.
+package tracing // import "capnproto.org/go/capnp/v3/rpc/tracing"
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"capnproto.org/go/capnp/v3/exc"
+	"capnproto.org/go/capnp/v3/rpc"
+)
+
+// SpanKind is the role of a span in a call.
+type SpanKind int
+
+// Span kinds.
+const (
+	SpanKindClient SpanKind = iota // an outgoing call
+	SpanKindServer                 // an incoming call
+)
+
+// A Tracer starts spans.
+type Tracer interface {
+	// Start starts a span as a child of any span in ctx, and returns
+	// a Context containing the new span.
+	Start(ctx context.Context, name string, kind SpanKind) (context.Context, Span)
+}
+
+// A Span records the work done for a single call.
+type Span interface {
+	SetAttribute(key string, value any)
+	RecordError(err error)
+	End()
+}
+
+// Observer is an rpc.Observer that starts a span when a call starts and
+// ends it when the call returns.  Outgoing calls are made with the
+// Context containing their span, and incoming calls are delivered with
+// it, so the spans of calls that a method makes are children of the
+// method's span.  Attribute names follow OpenTelemetry's conventions for
+// RPC spans.
+type Observer struct {
+	Tracer Tracer
+}
+
+var _ rpc.Observer = Observer{}
+
+type spanKey struct{}
+
+// Event implements rpc.Observer.  It does nothing.
+func (Observer) Event(rpc.Event) {}
+
+// CallStarted implements rpc.Observer.
+func (o Observer) CallStarted(ctx context.Context, call rpc.CallInfo) context.Context {
+	kind := SpanKindClient
+	if call.Incoming {
+		kind = SpanKindServer
+	}
+	ctx, span := o.Tracer.Start(ctx, call.Method.String(), kind)
+	span.SetAttribute("rpc.system", "capnp")
+	span.SetAttribute("rpc.service", interfaceName(call))
+	span.SetAttribute("rpc.method", methodName(call))
+	span.SetAttribute("rpc.capnp.interface_id", "0x"+strconv.FormatUint(call.Method.InterfaceID, 16))
+	span.SetAttribute("rpc.capnp.method_id", int(call.Method.MethodID))
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// CallReturned implements rpc.Observer.
+func (o Observer) CallReturned(ctx context.Context, call rpc.CallInfo, err error, elapsed time.Duration) {
+	span, ok := ctx.Value(spanKey{}).(Span)
+	if !ok {
+		return
+	}
+	if err != nil {
+		span.SetAttribute("rpc.capnp.exception_type", exc.TypeOf(err).String())
+		span.RecordError(err)
+	}
+	span.End()
+}
+
+func interfaceName(call rpc.CallInfo) string {
+	if call.Method.InterfaceName != "" {
+		return call.Method.InterfaceName
+	}
+	return "@0x" + strconv.FormatUint(call.Method.InterfaceID, 16)
+}
+
+func methodName(call rpc.CallInfo) string {
+	if call.Method.MethodName != "" {
+		return call.Method.MethodName
+	}
+	return "@" + strconv.FormatUint(uint64(call.Method.MethodID), 10)
+}
@@ -0,0 +1,128 @@
+package tracing_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"capnproto.org/go/capnp/v3"
+	"capnproto.org/go/capnp/v3/rpc"
+	testcp "capnproto.org/go/capnp/v3/rpc/internal/testcapnp"
+	"capnproto.org/go/capnp/v3/rpc/tracing"
+	"capnproto.org/go/capnp/v3/rpc/transport"
+)
+
+func TestObserver(t *testing.T) {
+	tr := new(testTracer)
+	left, right := transport.NewPipe(1)
+	server := rpc.NewConn(rpc.NewTransport(left), &rpc.Options{
+		BootstrapClient: capnp.Client(testcp.PingPong_ServerToClient(pingPongServer{})),
+		Observer:        tracing.Observer{Tracer: tr},
+	})
+	client := rpc.NewConn(rpc.NewTransport(right), &rpc.Options{
+		Observer: tracing.Observer{Tracer: tr},
+	})
+	defer func() {
+		client.Close()
+		<-server.Done()
+	}()
+
+	ctx, root := tr.Start(context.Background(), "root", tracing.SpanKindClient)
+	pp := testcp.PingPong(client.Bootstrap(ctx))
+	defer pp.Release()
+	ans, release := pp.EchoNum(ctx, nil)
+	if _, err := ans.Struct(); err != nil {
+		t.Fatal("EchoNum:", err)
+	}
+	release()
+	root.End()
+
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	var clientSpan, serverSpan *testSpan
+	for _, s := range tr.spans {
+		switch s.kind {
+		case tracing.SpanKindClient:
+			if s.name != "root" {
+				clientSpan = s
+			}
+		case tracing.SpanKindServer:
+			serverSpan = s
+		}
+	}
+	if clientSpan == nil || serverSpan == nil {
+		t.Fatalf("spans = %v; want a client and a server span", tr.spans)
+	}
+	if clientSpan.parent != root.(*testSpan) {
+		t.Error("client span is not a child of the caller's span")
+	}
+	if !clientSpan.ended || !serverSpan.ended {
+		t.Error("call spans not ended")
+	}
+	if got := clientSpan.attrs["rpc.system"]; got != "capnp" {
+		t.Errorf("rpc.system = %v; want capnp", got)
+	}
+	if got := clientSpan.attrs["rpc.method"]; got != "echoNum" {
+		t.Errorf("client rpc.method = %v; want echoNum", got)
+	}
+	if got := serverSpan.attrs["rpc.capnp.method_id"]; got != 0 {
+		t.Errorf("server rpc.capnp.method_id = %v; want 0", got)
+	}
+}
+
+type testTracer struct {
+	mu    sync.Mutex
+	spans []*testSpan
+}
+
+type spanKey struct{}
+
+func (tr *testTracer) Start(ctx context.Context, name string, kind tracing.SpanKind) (context.Context, tracing.Span) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	parent, _ := ctx.Value(spanKey{}).(*testSpan)
+	s := &testSpan{
+		tr:     tr,
+		name:   name,
+		kind:   kind,
+		parent: parent,
+		attrs:  make(map[string]any),
+	}
+	tr.spans = append(tr.spans, s)
+	return context.WithValue(ctx, spanKey{}, s), s
+}
+
+type testSpan struct {
+	tr     *testTracer
+	name   string
+	kind   tracing.SpanKind
+	parent *testSpan
+	attrs  map[string]any
+	err    error
+	ended  bool
+}
+
+func (s *testSpan) SetAttribute(key string, value any) {
+	s.tr.mu.Lock()
+	defer s.tr.mu.Unlock()
+	s.attrs[key] = value
+}
+
+func (s *testSpan) RecordError(err error) {
+	s.tr.mu.Lock()
+	defer s.tr.mu.Unlock()
+	s.err = err
+}
+
+func (s *testSpan) End() {
+	s.tr.mu.Lock()
+	defer s.tr.mu.Unlock()
+	s.ended = true
+}
+
+type pingPongServer struct{}
+
+func (pingPongServer) EchoNum(ctx context.Context, call testcp.PingPong_echoNum) error {
+	_, err := call.AllocResults()
+	return err
+}
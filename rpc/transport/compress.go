@@ -0,0 +1,270 @@
+package transport
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync/atomic"
+
+	capnp "capnproto.org/go/capnp/v3"
+	"capnproto.org/go/capnp/v3/exp/bufferpool"
+)
+
+/*
+Compressed stream encoding
+
+A stream codec with StreamOptions.Compression set frames its messages
+so that it can compress them.  Each side starts by sending a hello
+that says which compression algorithms it can decode, and then sends
+each message in a frame:
+
+	hello:   "capnpz" | version (uint8) | algorithms (uint8 bit set)
+	frame:   algorithm (uint32) | payload length (uint32) | payload
+
+A frame's algorithm is compressNone, in which case the payload is the
+message in the standard stream encoding, or one that the receiver
+listed in its hello.  A side sends uncompressed frames until it has
+received the other's hello.
+
+The only algorithm is DEFLATE.  Its frames carry the output of a single
+compressor that runs for the life of the stream, sync-flushed after
+each message, so each message is compressed using the messages before
+it as a dictionary.  Frames must be decoded in order for the same
+reason.  Other algorithms, such as zstd, would need a dependency outside
+the standard library; the hello's bit set and the frame's algorithm
+leave room for them.  All integers are little-endian.
+*/
+
+const (
+	compressMagic   = "capnpz"
+	compressVersion = 0
+
+	compressNone    = 0
+	compressDeflate = 1
+
+	// Bits in the hello's algorithms.
+	compressDeflateBit = 1 << (compressDeflate - 1)
+
+	// maxCompressedFrame limits the payload of an incoming compressed
+	// frame.  The message it decompresses to is limited by the
+	// Decoder.
+	maxCompressedFrame = 1 << 28
+
+	defaultCompressThreshold = 1024
+)
+
+// CompressionOptions configures the compression of a stream codec.
+// See StreamOptions.
+type CompressionOptions struct {
+	// Threshold is the smallest encoded message size in bytes that is
+	// compressed.  Smaller messages are sent as they are, since they
+	// are not worth the compressor's time or the frame's overhead.  If
+	// zero, 1024 is used.
+	Threshold int
+
+	// Level is the compress/flate level to compress with, from
+	// flate.BestSpeed to flate.BestCompression, or flate.HuffmanOnly.
+	// If zero, flate.DefaultCompression is used.
+	Level int
+}
+
+// streamCompressor frames the messages of a streamCodec, compressing
+// the large ones.
+type streamCompressor struct {
+	rwc       io.ReadWriteCloser
+	threshold int
+
+	// peerDeflate is set once the remote side's hello says it can
+	// decode DEFLATE frames.
+	peerDeflate atomic.Bool
+
+	// Encoding state; Encode is not called concurrently.
+	helloSent bool
+	zw        *flate.Writer
+	zbuf      bytes.Buffer
+
+	// Decoding state.
+	helloRecvd bool
+	r          *bufio.Reader
+	lr         io.LimitedReader // the payload of an uncompressed frame
+	feed       frameFeed        // the payloads of DEFLATE frames
+	zr         io.ReadCloser
+	zDec       *capnp.Decoder
+}
+
+func newStreamCompressor(rwc io.ReadWriteCloser, opts CompressionOptions) (*streamCompressor, error) {
+	if opts.Threshold <= 0 {
+		opts.Threshold = defaultCompressThreshold
+	}
+	if opts.Level == 0 {
+		opts.Level = flate.DefaultCompression
+	}
+	zw, err := flate.NewWriter(nil, opts.Level)
+	if err != nil {
+		return nil, err
+	}
+
+	z := &streamCompressor{
+		rwc:       rwc,
+		threshold: opts.Threshold,
+		zw:        zw,
+		r:         bufio.NewReader(rwc),
+	}
+	z.zw.Reset(&z.zbuf)
+	z.lr.R = z.r
+	z.zr = flate.NewReader(&z.feed)
+	z.zDec = capnp.NewDecoder(z.zr)
+	z.zDec.SetBufferPool(&bufferpool.Default)
+	return z, nil
+}
+
+func (z *streamCompressor) encode(msg *capnp.Message) error {
+	var bufs net.Buffers
+	if !z.helloSent {
+		z.helloSent = true
+		bufs = append(bufs, compressHello(compressDeflateBit))
+	}
+	data, err := msg.Marshal()
+	if err != nil {
+		return err
+	}
+
+	var hdr [8]byte
+	if len(data) < z.threshold || !z.peerDeflate.Load() {
+		binary.LittleEndian.PutUint32(hdr[:], compressNone)
+	} else {
+		// The data must be sent once it is written to the compressor,
+		// even if it did not shrink, since the remote side's
+		// decompressor needs it to stay in step.
+		z.zbuf.Reset()
+		if _, err := z.zw.Write(data); err != nil {
+			return err
+		}
+		if err := z.zw.Flush(); err != nil {
+			return err
+		}
+		binary.LittleEndian.PutUint32(hdr[:], compressDeflate)
+		data = z.zbuf.Bytes()
+	}
+	if uint64(len(data)) > uint64(^uint32(0)) {
+		return errors.New("message too large")
+	}
+	binary.LittleEndian.PutUint32(hdr[4:], uint32(len(data)))
+	bufs = append(bufs, hdr[:], data)
+	_, err = bufs.WriteTo(z.rwc)
+	return err
+}
+
+// decode reads the next frame.  The payload of an uncompressed frame
+// is decoded by raw, which must read from &z.lr.
+func (z *streamCompressor) decode(raw *capnp.Decoder) (*capnp.Message, error) {
+	if !z.helloRecvd {
+		if err := z.recvHello(); err != nil {
+			return nil, err
+		}
+	}
+	var hdr [8]byte
+	if _, err := io.ReadFull(z.r, hdr[:]); err != nil {
+		return nil, err
+	}
+	alg := binary.LittleEndian.Uint32(hdr[:])
+	n := binary.LittleEndian.Uint32(hdr[4:])
+	switch alg {
+	case compressNone:
+		z.lr.N = int64(n)
+		msg, err := raw.Decode()
+		if err != nil {
+			return nil, err
+		}
+		if z.lr.N != 0 {
+			raw.ReleaseMessage(msg)
+			return nil, errors.New("frame length does not match message")
+		}
+		return msg, nil
+	case compressDeflate:
+		if n > maxCompressedFrame {
+			return nil, errors.New("compressed frame too large")
+		}
+		if err := z.feed.fill(z.r, int(n)); err != nil {
+			return nil, err
+		}
+		msg, err := z.zDec.Decode()
+		if err != nil {
+			return nil, fmt.Errorf("decompress: %w", err)
+		}
+		return msg, nil
+	default:
+		return nil, fmt.Errorf("frame compressed with unknown algorithm %d", alg)
+	}
+}
+
+func (z *streamCompressor) recvHello() error {
+	var hello [8]byte
+	if _, err := io.ReadFull(z.r, hello[:]); err != nil {
+		return err
+	}
+	if string(hello[:len(compressMagic)]) != compressMagic {
+		return errors.New("remote side is not a compressed stream")
+	}
+	if hello[6] != compressVersion {
+		return fmt.Errorf("unsupported compressed stream version %d", hello[6])
+	}
+	z.helloRecvd = true
+	z.peerDeflate.Store(hello[7]&compressDeflateBit != 0)
+	return nil
+}
+
+func compressHello(algorithms byte) []byte {
+	hello := make([]byte, 0, 8)
+	hello = append(hello, compressMagic...)
+	return append(hello, compressVersion, algorithms)
+}
+
+// frameFeed holds the payloads of the DEFLATE frames received and not
+// yet read by the decompressor.  The decompressor reads no further than
+// the sync flush at the end of each frame, so running out of data means
+// that a frame was corrupt.
+type frameFeed struct {
+	buf []byte
+	off int
+}
+
+// fill appends the next n bytes from r.
+func (f *frameFeed) fill(r io.Reader, n int) error {
+	if f.off > 0 {
+		f.buf = f.buf[:copy(f.buf, f.buf[f.off:])]
+		f.off = 0
+	}
+	m := len(f.buf)
+	if cap(f.buf)-m < n {
+		buf := make([]byte, m, m+n)
+		copy(buf, f.buf)
+		f.buf = buf
+	}
+	f.buf = f.buf[:m+n]
+	_, err := io.ReadFull(r, f.buf[m:])
+	return err
+}
+
+func (f *frameFeed) Read(p []byte) (int, error) {
+	if f.off == len(f.buf) {
+		return 0, io.ErrUnexpectedEOF
+	}
+	n := copy(p, f.buf[f.off:])
+	f.off += n
+	return n, nil
+}
+
+func (f *frameFeed) ReadByte() (byte, error) {
+	if f.off == len(f.buf) {
+		return 0, io.ErrUnexpectedEOF
+	}
+	b := f.buf[f.off]
+	f.off++
+	return b, nil
+}
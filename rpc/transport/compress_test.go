@@ -0,0 +1,151 @@
+package transport
+
+import (
+	"io"
+	"math/rand"
+	"net"
+	"testing"
+
+	rpccp "capnproto.org/go/capnp/v3/std/capnp/rpc"
+)
+
+// TestCompressedStream checks that large messages are compressed, with
+// earlier messages as a dictionary, and small ones are not.
+func TestCompressedStream(t *testing.T) {
+	t.Parallel()
+
+	c1, c2 := net.Pipe()
+	w1 := &countingConn{Conn: c1}
+	t1, err := newCompressedStream(w1, &CompressionOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t2, err := newCompressedStream(c2, &CompressionOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer t1.Close()
+	defer t2.Close()
+
+	// t1 compresses once it has t2's hello, which comes with t2's
+	// first message.
+	if _, err := sendAbort(t2, "hello"); err != nil {
+		t.Fatal("send hello:", err)
+	}
+	if reason, err := recvAbort(t1); err != nil || reason != "hello" {
+		t.Fatalf("received %q, %v; want \"hello\"", reason, err)
+	}
+
+	// Random letters compress somewhat on their own, and to almost
+	// nothing the second time.
+	rng := rand.New(rand.NewSource(1))
+	letters := make([]byte, 16<<10)
+	for i := range letters {
+		letters[i] = 'a' + byte(rng.Intn(26))
+	}
+	large := string(letters)
+	var sizes []int64
+	for _, reason := range []string{"small", large, large + "!"} {
+		before := w1.n
+		sent, err := sendAbort(t1, reason)
+		if err != nil {
+			t.Fatal("send:", err)
+		}
+		got, err := recvAbort(t2)
+		if err != nil {
+			t.Fatal("receive:", err)
+		}
+		<-sent
+		if got != reason {
+			t.Errorf("received reason of %d bytes; want %d bytes", len(got), len(reason))
+		}
+		sizes = append(sizes, w1.n-before)
+	}
+	if sizes[0] < int64(len("small")) {
+		t.Errorf("small message took %d bytes", sizes[0])
+	}
+	if sizes[1] > int64(len(large))*3/4 {
+		t.Errorf("large message took %d bytes; want at most %d", sizes[1], len(large)*3/4)
+	}
+	if sizes[2] > sizes[1]/10 {
+		t.Errorf("repeated large message took %d bytes, first took %d; want at most a tenth", sizes[2], sizes[1])
+	}
+}
+
+func TestStreamCodecInvalidOptions(t *testing.T) {
+	t.Parallel()
+
+	c1, c2 := net.Pipe()
+	defer c1.Close()
+	defer c2.Close()
+	if _, err := newCompressedStream(c1, &CompressionOptions{Level: 42}); err == nil {
+		t.Error("NewStreamCodec with compression level 42 succeeded")
+	}
+	opts := &StreamOptions{Packed: true, Compression: &CompressionOptions{}}
+	if _, err := NewStreamCodec(c1, opts); err == nil {
+		t.Error("NewStreamCodec with packing and compression succeeded")
+	}
+}
+
+// newCompressedStream returns a stream transport that compresses
+// messages as configured by opts.
+func newCompressedStream(rwc io.ReadWriteCloser, opts *CompressionOptions) (Transport, error) {
+	c, err := NewStreamCodec(rwc, &StreamOptions{Compression: opts})
+	if err != nil {
+		return nil, err
+	}
+	return New(c), nil
+}
+
+// sendAbort sends an abort message with reason from a new goroutine,
+// since a net.Pipe write blocks until it is read.  The returned channel
+// is closed when it has been sent.
+func sendAbort(tr Transport, reason string) (<-chan struct{}, error) {
+	out, err := tr.NewMessage()
+	if err != nil {
+		return nil, err
+	}
+	abort, err := out.Message.NewAbort()
+	if err == nil {
+		err = abort.SetReason(reason)
+	}
+	if err != nil {
+		out.Release()
+		return nil, err
+	}
+	sent := make(chan struct{})
+	go func() {
+		defer close(sent)
+		out.Send()
+		out.Release()
+	}()
+	return sent, nil
+}
+
+func recvAbort(tr Transport) (string, error) {
+	in, err := tr.RecvMessage()
+	if err != nil {
+		return "", err
+	}
+	defer in.Release()
+	if in.Message.Which() != rpccp.Message_Which_abort {
+		return "", io.ErrUnexpectedEOF
+	}
+	e, err := in.Message.Abort()
+	if err != nil {
+		return "", err
+	}
+	return e.Reason()
+}
+
+// countingConn counts the bytes written to a net.Conn.
+type countingConn struct {
+	net.Conn
+	n int64
+}
+
+func (c *countingConn) Write(p []byte) (int, error) {
+	n, err := c.Conn.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,136 @@
+package transport
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	capnp "capnproto.org/go/capnp/v3"
+	"capnproto.org/go/capnp/v3/packed"
+)
+
+// A MessageConn sends and receives discrete messages, such as
+// WebSocket messages, QUIC streams or NATS messages.  It is safe to
+// call WriteMessage and ReadMessage concurrently.
+type MessageConn interface {
+	// WriteMessage sends data as one message.  It must not retain data
+	// after it returns.
+	WriteMessage(data []byte) error
+
+	// ReadMessage receives the next message.  The caller owns the
+	// returned slice.
+	ReadMessage() ([]byte, error)
+
+	// Close closes the connection, interrupting any outstanding calls
+	// to WriteMessage or ReadMessage.
+	Close() error
+}
+
+// defaultMaxMessageSize is the default limit on the size of a message
+// received by a MessageCodec, the same as capnp.Decoder's.
+const defaultMaxMessageSize = 64 << 20
+
+// A MessageCodec is a Codec that sends each Cap'n Proto message as one
+// message of a MessageConn.  The segment table of each message it
+// receives must describe exactly the bytes received.
+type MessageCodec struct {
+	conn   MessageConn
+	packed bool
+
+	// MaxMessageSize limits the size in bytes of an incoming message,
+	// after unpacking.  If zero, 64 MiB is used.
+	MaxMessageSize uint64
+}
+
+// NewMessageCodec returns a Codec that sends messages over conn in
+// the standard encoding.
+func NewMessageCodec(conn MessageConn) *MessageCodec {
+	return &MessageCodec{conn: conn}
+}
+
+// NewPackedMessageCodec returns a Codec that sends messages over conn
+// in the packed encoding.
+func NewPackedMessageCodec(conn MessageConn) *MessageCodec {
+	return &MessageCodec{conn: conn, packed: true}
+}
+
+// SetMaxMessageSize sets c.MaxMessageSize.  The MessageConn has
+// already received a message by the time its size is checked, so the
+// limit bounds the memory used to unpack and decode it.
+func (c *MessageCodec) SetMaxMessageSize(size uint64) {
+	c.MaxMessageSize = size
+}
+
+func (c *MessageCodec) Encode(msg *capnp.Message) error {
+	var data []byte
+	var err error
+	if c.packed {
+		data, err = msg.MarshalPacked()
+	} else {
+		data, err = msg.Marshal()
+	}
+	if err != nil {
+		return err
+	}
+	return c.conn.WriteMessage(data)
+}
+
+func (c *MessageCodec) Decode() (*capnp.Message, error) {
+	data, err := c.conn.ReadMessage()
+	if err != nil {
+		return nil, err
+	}
+	maxSize := c.MaxMessageSize
+	if maxSize == 0 {
+		maxSize = defaultMaxMessageSize
+	}
+	if c.packed {
+		// Unpack incrementally, since a small packed message can
+		// expand to a huge one.
+		r := packed.NewReader(bufio.NewReader(bytes.NewReader(data)))
+		data, err = io.ReadAll(io.LimitReader(r, int64(maxSize)+1))
+		if err != nil {
+			return nil, fmt.Errorf("unpack: %w", err)
+		}
+	}
+	if uint64(len(data)) > maxSize {
+		return nil, errors.New("message too large")
+	}
+	if err := checkSegmentTable(data); err != nil {
+		return nil, err
+	}
+	return capnp.Unmarshal(data)
+}
+
+// checkSegmentTable checks that the segment table at the start of data
+// accounts for all of data.  capnp.Unmarshal checks that it does not
+// describe more.
+func checkSegmentTable(data []byte) error {
+	if len(data) < 8 {
+		return errors.New("message shorter than segment table")
+	}
+	n := uint64(binary.LittleEndian.Uint32(data)) + 1
+	hdrSize := (4 + 4*n + 7) &^ 7
+	if uint64(len(data)) < hdrSize {
+		return errors.New("message shorter than segment table")
+	}
+	total := hdrSize
+	for i := uint64(0); i < n; i++ {
+		total += 8 * uint64(binary.LittleEndian.Uint32(data[4+4*i:]))
+	}
+	if total != uint64(len(data)) {
+		return fmt.Errorf("segment table describes %d bytes; message has %d", total, len(data))
+	}
+	return nil
+}
+
+// ReleaseMessage does nothing: the messages returned by Decode are
+// left to the garbage collector.
+func (c *MessageCodec) ReleaseMessage(*capnp.Message) {}
+
+func (c *MessageCodec) Close() error {
+	return c.conn.Close()
+}
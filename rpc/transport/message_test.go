@@ -0,0 +1,111 @@
+package transport
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestMessageCodec(t *testing.T) {
+	t.Run("Unpacked", func(t *testing.T) {
+		t.Parallel()
+
+		testTransport(t, func() (t1, t2 Transport, err error) {
+			c1, c2 := newChanMessageConnPair()
+			return New(NewMessageCodec(c1)), New(NewMessageCodec(c2)), nil
+		})
+	})
+
+	t.Run("Packed", func(t *testing.T) {
+		t.Parallel()
+
+		testTransport(t, func() (t1, t2 Transport, err error) {
+			c1, c2 := newChanMessageConnPair()
+			return New(NewPackedMessageCodec(c1)), New(NewPackedMessageCodec(c2)), nil
+		})
+	})
+}
+
+// TestMessageCodecInvalid checks that malformed and oversized messages
+// are rejected.
+func TestMessageCodecInvalid(t *testing.T) {
+	t.Parallel()
+
+	// A message with one segment of one word.
+	valid := make([]byte, 16)
+	binary.LittleEndian.PutUint32(valid[4:], 1)
+
+	tests := []struct {
+		name   string
+		packed bool
+		data   []byte
+		err    string
+	}{
+		{name: "Empty", data: nil, err: "shorter than segment table"},
+		{name: "ShortTable", data: []byte{1, 0, 0, 0, 1, 0, 0, 0}, err: "shorter than segment table"},
+		{name: "Trailing", data: append(append([]byte(nil), valid...), make([]byte, 8)...), err: "segment table describes 16 bytes; message has 24"},
+		{name: "Truncated", data: valid[:8], err: "segment table describes 16 bytes; message has 8"},
+		{name: "TooLarge", data: make([]byte, 2048), err: "too large"},
+		// A packed run of zero words that unpacks to far more than
+		// the limit.
+		{name: "PackedBomb", packed: true, data: bytes.Repeat([]byte{0, 255}, 64), err: "too large"},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			c1, c2 := newChanMessageConnPair()
+			defer c1.Close()
+			codec := NewMessageCodec(c2)
+			if test.packed {
+				codec = NewPackedMessageCodec(c2)
+			}
+			codec.MaxMessageSize = 1024
+			go c1.WriteMessage(test.data)
+			_, err := codec.Decode()
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("Decode() error = %v; want %q", err, test.err)
+			}
+		})
+	}
+}
+
+// chanMessageConn is a MessageConn that sends messages over buffered
+// channels.
+type chanMessageConn struct {
+	in, out chan []byte
+	done    chan struct{}
+	once    *sync.Once
+}
+
+func newChanMessageConnPair() (c1, c2 *chanMessageConn) {
+	ab, ba := make(chan []byte, 16), make(chan []byte, 16)
+	done, once := make(chan struct{}), new(sync.Once)
+	return &chanMessageConn{in: ba, out: ab, done: done, once: once},
+		&chanMessageConn{in: ab, out: ba, done: done, once: once}
+}
+
+func (c *chanMessageConn) WriteMessage(data []byte) error {
+	select {
+	case c.out <- append([]byte(nil), data...):
+		return nil
+	case <-c.done:
+		return net.ErrClosed
+	}
+}
+
+func (c *chanMessageConn) ReadMessage() ([]byte, error) {
+	select {
+	case data := <-c.in:
+		return data, nil
+	case <-c.done:
+		return nil, net.ErrClosed
+	}
+}
+
+func (c *chanMessageConn) Close() error {
+	c.once.Do(func() { close(c.done) })
+	return nil
+}
@@ -0,0 +1,513 @@
+// Package mux carries many Cap'n Proto RPC transports over one byte
+// stream, so that the Conns to several bootstrap services of a remote
+// host can share a single socket.
+//
+// Each side of the byte stream creates a Session, one with Client and
+// the other with Server.  Either side opens a Stream with Session.Open,
+// which the other side receives from Session.Accept.  A Stream is an
+// io.ReadWriteCloser with flow control of its own, so a Stream whose
+// reader falls behind does not hold up the others, and its Codec method
+// returns a transport.Codec for use with transport.New:
+//
+//	s, err := sess.Open(ctx)
+//	...
+//	conn := rpc.NewConn(transport.New(s.Codec()), nil)
+package mux
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+/*
+Multiplexed stream encoding
+
+Each side starts by sending a hello, and then sends frames:
+
+	hello:   "capnpmx" | version (uint8)
+	frame:   type (uint8) | 3 zero bytes | stream ID (uint32) | payload length (uint32) | payload
+
+The side created with Client numbers the streams it opens with odd IDs
+and the other with even IDs, each starting from the lowest and never
+reusing one.  The frame types are:
+
+	open     Opens a stream.  The payload is the opener's receive window.
+	accept   Accepts an opened stream.  The payload is the acceptor's
+	         receive window.
+	reject   Refuses an opened stream, which is then closed.
+	data     The payload is the next bytes of the stream.
+	window   The payload is the number of bytes by which to grow the
+	         receiver's send window.
+	close    The sender will send no more data on the stream, and
+	         discards any it receives.
+
+A side may send as many data bytes on a stream as the window that the
+other side gave it, which grows as the other side's reader consumes
+them, so frames for other streams are never held up behind a stream
+whose reader is slow.  A side may send data on a stream that it opened
+as soon as it has received the accept.  A stream is gone once each side
+has both sent and received a close, or a reject; window and close frames
+for streams that are gone are ignored.  Any other violation of the
+protocol ends the session.  All integers are little-endian.
+*/
+
+const (
+	muxMagic   = "capnpmx"
+	muxVersion = 0
+
+	frameHeaderSize = 12
+
+	frameOpen   = 1
+	frameAccept = 2
+	frameReject = 3
+	frameData   = 4
+	frameWindow = 5
+	frameClose  = 6
+
+	defaultWindow        = 256 << 10
+	defaultMaxFrameSize  = 16 << 10
+	defaultAcceptBacklog = 16
+)
+
+var (
+	// ErrSessionClosed is returned by the methods of a Session and its
+	// Streams after the Session is closed.
+	ErrSessionClosed = errors.New("mux: session closed")
+
+	// ErrStreamClosed is returned by the methods of a Stream after it
+	// is closed.
+	ErrStreamClosed = errors.New("mux: stream closed")
+
+	// ErrRejected is returned by Session.Open when the remote side
+	// refuses the stream because too many are waiting to be accepted.
+	ErrRejected = errors.New("mux: stream rejected")
+)
+
+// Options configures a Session.
+type Options struct {
+	// Window is the number of bytes that the remote side may send on
+	// each stream before the stream's reader consumes them.  If zero,
+	// 256 KiB is used.
+	Window uint32
+
+	// MaxFrameSize limits the data sent in each frame, so that a
+	// large message on one stream does not delay the others by long.
+	// If zero, 16 KiB is used.
+	MaxFrameSize uint32
+
+	// AcceptBacklog is the number of streams opened by the remote side
+	// that may wait for Accept.  Streams opened beyond it are
+	// rejected.  If zero, 16 is used.
+	AcceptBacklog int
+}
+
+// A Session multiplexes Streams over a byte stream.  It is safe to use
+// from multiple goroutines.
+type Session struct {
+	rwc          io.ReadWriteCloser
+	window       uint32
+	maxFrameSize uint32
+	accepts      chan *Stream
+
+	// wmu serializes writes to rwc.  It is acquired before mu.
+	wmu sync.Mutex
+
+	mu       sync.Mutex
+	streams  map[uint32]*Stream
+	nextID   uint32 // of the next stream opened by this side
+	remoteID uint32 // of the last stream opened by the remote side
+	ctrl     []frame
+	ctrlCond *sync.Cond // signaled when ctrl grows or the session ends
+	err      error      // set when the session ends
+	done     chan struct{}
+}
+
+// A frame is a control frame waiting to be sent.
+type frame struct {
+	typ     uint8
+	id      uint32
+	payload uint32 // unused by reject and close
+}
+
+// Client creates a Session on rwc for the side that numbers its streams
+// with odd IDs.  The other side must use Server.  opts may be nil.
+// Closing the Session closes rwc.
+//
+// rwc's Close method must interrupt any outstanding IO, and it must be
+// safe to call rwc.Read and rwc.Write concurrently.
+func Client(rwc io.ReadWriteCloser, opts *Options) *Session {
+	return newSession(rwc, opts, 1)
+}
+
+// Server creates a Session on rwc for the side that numbers its streams
+// with even IDs.  The other side must use Client.
+//
+// See: Client.
+func Server(rwc io.ReadWriteCloser, opts *Options) *Session {
+	return newSession(rwc, opts, 2)
+}
+
+func newSession(rwc io.ReadWriteCloser, opts *Options, firstID uint32) *Session {
+	var o Options
+	if opts != nil {
+		o = *opts
+	}
+	if o.Window == 0 {
+		o.Window = defaultWindow
+	}
+	if o.MaxFrameSize == 0 {
+		o.MaxFrameSize = defaultMaxFrameSize
+	}
+	if o.AcceptBacklog <= 0 {
+		o.AcceptBacklog = defaultAcceptBacklog
+	}
+	s := &Session{
+		rwc:          rwc,
+		window:       o.Window,
+		maxFrameSize: o.MaxFrameSize,
+		accepts:      make(chan *Stream, o.AcceptBacklog),
+		streams:      make(map[uint32]*Stream),
+		nextID:       firstID,
+		done:         make(chan struct{}),
+	}
+	s.ctrlCond = sync.NewCond(&s.mu)
+	go s.send()
+	go s.receive()
+	return s
+}
+
+// Open opens a new Stream and waits for the remote side to accept it.
+// If ctx is done first, the Stream is closed and Open returns the
+// Context's error.
+func (s *Session) Open(ctx context.Context) (*Stream, error) {
+	s.mu.Lock()
+	if s.err != nil {
+		err := s.err
+		s.mu.Unlock()
+		return nil, err
+	}
+	st := s.newStream(s.nextID, 0)
+	st.opened = make(chan struct{})
+	s.nextID += 2
+	s.streams[st.id] = st
+	s.queue(frame{typ: frameOpen, id: st.id, payload: s.window})
+	s.mu.Unlock()
+
+	select {
+	case <-st.opened:
+	case <-ctx.Done():
+		st.Close()
+		return nil, ctx.Err()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if st.openErr != nil {
+		return nil, st.openErr
+	}
+	return st, nil
+}
+
+// Accept waits for the remote side to open a Stream and returns it.
+func (s *Session) Accept(ctx context.Context) (*Stream, error) {
+	select {
+	case st := <-s.accepts:
+		return st, nil
+	case <-s.done:
+		return nil, s.Err()
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Close closes the Session, its Streams and the underlying byte
+// stream.
+func (s *Session) Close() error {
+	s.mu.Lock()
+	closed := s.err != nil
+	s.mu.Unlock()
+	if closed {
+		return ErrSessionClosed
+	}
+	return s.fail(ErrSessionClosed)
+}
+
+// Done returns a channel that is closed once the Session has ended,
+// because it was closed or because of an error.
+func (s *Session) Done() <-chan struct{} {
+	return s.done
+}
+
+// Err returns the error that ended the Session, or nil if it has not
+// ended.
+func (s *Session) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// fail ends the session with err, if it has not ended already, and
+// returns the error from closing the byte stream.
+func (s *Session) fail(err error) error {
+	s.mu.Lock()
+	if s.err != nil {
+		s.mu.Unlock()
+		return nil
+	}
+	s.err = err
+	for _, st := range s.streams {
+		if st.opened != nil && st.openErr == nil {
+			select {
+			case <-st.opened:
+			default:
+				st.openErr = err
+				close(st.opened)
+			}
+		}
+		st.cond.Broadcast()
+	}
+	s.ctrlCond.Broadcast()
+	close(s.done)
+	s.mu.Unlock()
+	return s.rwc.Close()
+}
+
+// queue adds a control frame to be sent by the send goroutine.  The
+// caller MUST be holding onto s.mu.
+func (s *Session) queue(f frame) {
+	s.ctrl = append(s.ctrl, f)
+	s.ctrlCond.Signal()
+}
+
+// send writes the hello and then the queued control frames, until the
+// session ends.  Control frames are written from their own goroutine
+// so that the receive goroutine never waits on a write.
+func (s *Session) send() {
+	s.wmu.Lock()
+	_, err := s.rwc.Write(append([]byte(muxMagic), muxVersion))
+	s.wmu.Unlock()
+	if err != nil {
+		s.fail(fmt.Errorf("mux: send hello: %w", err))
+		return
+	}
+
+	var buf []byte
+	for {
+		s.wmu.Lock()
+		s.mu.Lock()
+		for len(s.ctrl) == 0 && s.err == nil {
+			// Let data be written while waiting.
+			s.wmu.Unlock()
+			s.ctrlCond.Wait()
+			s.mu.Unlock()
+			s.wmu.Lock()
+			s.mu.Lock()
+		}
+		if s.err != nil {
+			s.mu.Unlock()
+			s.wmu.Unlock()
+			return
+		}
+		buf = buf[:0]
+		for _, f := range s.ctrl {
+			switch f.typ {
+			case frameOpen, frameAccept, frameWindow:
+				buf = appendHeader(buf, f.typ, f.id, 4)
+				buf = binary.LittleEndian.AppendUint32(buf, f.payload)
+			default:
+				buf = appendHeader(buf, f.typ, f.id, 0)
+			}
+		}
+		s.ctrl = s.ctrl[:0]
+		s.mu.Unlock()
+		_, err := s.rwc.Write(buf)
+		s.wmu.Unlock()
+		if err != nil {
+			s.fail(fmt.Errorf("mux: send: %w", err))
+			return
+		}
+	}
+}
+
+// writeData writes a data frame.  The caller MUST be holding onto
+// s.wmu.
+func (s *Session) writeData(id uint32, p []byte) error {
+	var hdr [frameHeaderSize]byte
+	appendHeader(hdr[:0], frameData, id, uint32(len(p)))
+	if _, err := s.rwc.Write(hdr[:]); err != nil {
+		return err
+	}
+	_, err := s.rwc.Write(p)
+	return err
+}
+
+func appendHeader(b []byte, typ uint8, id, n uint32) []byte {
+	b = append(b, typ, 0, 0, 0)
+	b = binary.LittleEndian.AppendUint32(b, id)
+	return binary.LittleEndian.AppendUint32(b, n)
+}
+
+// receive reads frames and dispatches them until the session ends.
+func (s *Session) receive() {
+	var hello [len(muxMagic) + 1]byte
+	if _, err := io.ReadFull(s.rwc, hello[:]); err != nil {
+		s.fail(fmt.Errorf("mux: receive hello: %w", err))
+		return
+	}
+	if string(hello[:len(muxMagic)]) != muxMagic {
+		s.fail(errors.New("mux: remote side is not a multiplexed stream"))
+		return
+	}
+	if v := hello[len(muxMagic)]; v != muxVersion {
+		s.fail(fmt.Errorf("mux: unsupported version %d", v))
+		return
+	}
+
+	var hdr [frameHeaderSize]byte
+	for {
+		if _, err := io.ReadFull(s.rwc, hdr[:]); err != nil {
+			s.fail(fmt.Errorf("mux: receive: %w", err))
+			return
+		}
+		typ := hdr[0]
+		id := binary.LittleEndian.Uint32(hdr[4:])
+		n := binary.LittleEndian.Uint32(hdr[8:])
+		var err error
+		switch typ {
+		case frameOpen, frameAccept, frameWindow:
+			var v uint32
+			if v, err = s.readUint32(n); err == nil {
+				err = s.handleControl(typ, id, v)
+			}
+		case frameReject, frameClose:
+			if n != 0 {
+				err = fmt.Errorf("mux: frame type %d has %d-byte payload", typ, n)
+			} else {
+				err = s.handleControl(typ, id, 0)
+			}
+		case frameData:
+			err = s.handleData(id, n)
+		default:
+			err = fmt.Errorf("mux: unknown frame type %d", typ)
+		}
+		if err != nil {
+			s.fail(err)
+			return
+		}
+	}
+}
+
+func (s *Session) readUint32(n uint32) (uint32, error) {
+	if n != 4 {
+		return 0, fmt.Errorf("mux: %d-byte payload; want 4", n)
+	}
+	var b [4]byte
+	if _, err := io.ReadFull(s.rwc, b[:]); err != nil {
+		return 0, fmt.Errorf("mux: receive: %w", err)
+	}
+	return binary.LittleEndian.Uint32(b[:]), nil
+}
+
+// handleControl processes a frame other than data.
+func (s *Session) handleControl(typ uint8, id, v uint32) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if typ == frameOpen {
+		return s.handleOpen(id, v)
+	}
+	st := s.streams[id]
+	if st == nil {
+		if typ == frameWindow || typ == frameClose {
+			// Sent before the remote side received our close or reject.
+			return nil
+		}
+		return fmt.Errorf("mux: frame type %d for unknown stream %d", typ, id)
+	}
+	switch typ {
+	case frameAccept, frameReject:
+		if st.opened == nil || st.remoteOpened {
+			return fmt.Errorf("mux: unexpected frame type %d for stream %d", typ, id)
+		}
+		st.remoteOpened = true
+		if typ == frameReject {
+			st.openErr = ErrRejected
+			st.remoteClosed = true
+			st.localClosed = true
+			delete(s.streams, id)
+		} else {
+			st.sendWindow = v
+		}
+		close(st.opened)
+	case frameWindow:
+		if uint64(st.sendWindow)+uint64(v) > 1<<32-1 {
+			return fmt.Errorf("mux: window of stream %d overflows", id)
+		}
+		st.sendWindow += v
+	case frameClose:
+		if st.remoteClosed {
+			return fmt.Errorf("mux: stream %d closed twice", id)
+		}
+		st.remoteClosed = true
+		if st.localClosed {
+			delete(s.streams, id)
+		}
+	}
+	st.cond.Broadcast()
+	return nil
+}
+
+// handleOpen processes an open frame.  The caller MUST be holding onto
+// s.mu.
+func (s *Session) handleOpen(id, window uint32) error {
+	if id%2 == s.nextID%2 || id <= s.remoteID {
+		return fmt.Errorf("mux: remote side opened stream %d out of turn", id)
+	}
+	s.remoteID = id
+	st := s.newStream(id, window)
+	st.remoteOpened = true
+	select {
+	case s.accepts <- st:
+		s.streams[id] = st
+		s.queue(frame{typ: frameAccept, id: id, payload: s.window})
+	default:
+		s.queue(frame{typ: frameReject, id: id})
+	}
+	return nil
+}
+
+// handleData reads the payload of a data frame into its stream.
+func (s *Session) handleData(id, n uint32) error {
+	s.mu.Lock()
+	st := s.streams[id]
+	switch {
+	case st == nil || !st.remoteOpened || st.remoteClosed:
+		s.mu.Unlock()
+		return fmt.Errorf("mux: data for stream %d, which is not open", id)
+	case n > st.recvWindow:
+		s.mu.Unlock()
+		return fmt.Errorf("mux: data for stream %d exceeds its window", id)
+	}
+	st.recvWindow -= n
+	discard := st.localClosed
+	s.mu.Unlock()
+
+	// Only this goroutine appends to st.buf, and the window bounds it,
+	// so read into a separate slice and append it under the lock.
+	data := make([]byte, n)
+	if _, err := io.ReadFull(s.rwc, data); err != nil {
+		return fmt.Errorf("mux: receive: %w", err)
+	}
+	if discard {
+		return nil
+	}
+	s.mu.Lock()
+	if !st.localClosed {
+		st.buf = append(st.buf, data...)
+		st.cond.Broadcast()
+	}
+	s.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,282 @@
+package mux
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"capnproto.org/go/capnp/v3"
+	"capnproto.org/go/capnp/v3/rpc"
+	testcp "capnproto.org/go/capnp/v3/rpc/internal/testcapnp"
+	"capnproto.org/go/capnp/v3/rpc/transport"
+)
+
+// TestRPC checks that several Conns can run over the Streams of one
+// Session at once.
+func TestRPC(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	client, server := newSessions(nil, nil)
+	defer client.Close()
+	defer server.Close()
+
+	go func() {
+		for {
+			st, err := server.Accept(ctx)
+			if err != nil {
+				return
+			}
+			rpc.NewConn(transport.New(st.Codec()), &rpc.Options{
+				BootstrapClient: capnp.Client(testcp.PingPong_ServerToClient(pingPongServer{})),
+			})
+		}
+	}()
+
+	const nconns = 4
+	var wg sync.WaitGroup
+	for i := 0; i < nconns; i++ {
+		st, err := client.Open(ctx)
+		if err != nil {
+			t.Fatal("Open:", err)
+		}
+		conn := rpc.NewConn(transport.New(st.Codec()), nil)
+		defer conn.Close()
+		pp := testcp.PingPong(conn.Bootstrap(ctx))
+		defer pp.Release()
+
+		wg.Add(1)
+		go func(base int64) {
+			defer wg.Done()
+			for n := base; n < base+20; n++ {
+				ans, release := pp.EchoNum(ctx, func(p testcp.PingPong_echoNum_Params) error {
+					p.SetN(n)
+					return nil
+				})
+				res, err := ans.Struct()
+				if err != nil {
+					t.Error("EchoNum:", err)
+				} else if res.N() != n {
+					t.Errorf("EchoNum(%d) = %d", n, res.N())
+				}
+				release()
+			}
+		}(int64(i) * 100)
+	}
+	wg.Wait()
+}
+
+// TestFlowControl checks that a Stream whose reader has stalled does not
+// hold up the other Streams, and that its writer resumes once the
+// reader does.
+func TestFlowControl(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	opts := &Options{Window: 1024, MaxFrameSize: 256}
+	client, server := newSessions(opts, opts)
+	defer client.Close()
+	defer server.Close()
+
+	busy, busyPeer := openPair(ctx, t, client, server)
+	idle, idlePeer := openPair(ctx, t, client, server)
+
+	data := bytes.Repeat([]byte("0123456789abcdef"), 1024)
+	wrote := make(chan error, 1)
+	go func() {
+		_, err := busy.Write(data)
+		wrote <- err
+	}()
+
+	// busy's writer fills its window, and idle still gets through.
+	for i := 0; i < 10; i++ {
+		msg := []byte{byte(i)}
+		if _, err := idle.Write(msg); err != nil {
+			t.Fatal("Write:", err)
+		}
+		got := make([]byte, 1)
+		if _, err := io.ReadFull(idlePeer, got); err != nil {
+			t.Fatal("Read:", err)
+		}
+		if got[0] != msg[0] {
+			t.Fatalf("Read = %d; want %d", got[0], msg[0])
+		}
+	}
+	select {
+	case err := <-wrote:
+		t.Fatalf("Write of %d bytes returned before the reader read (err = %v)", len(data), err)
+	default:
+	}
+
+	got := make([]byte, len(data))
+	if _, err := io.ReadFull(busyPeer, got); err != nil {
+		t.Fatal("Read:", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Error("data read differs from data written")
+	}
+	if err := <-wrote; err != nil {
+		t.Error("Write:", err)
+	}
+}
+
+// TestStreamClose checks the close handshake.
+func TestStreamClose(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	client, server := newSessions(nil, nil)
+	defer client.Close()
+	defer server.Close()
+
+	st, peer := openPair(ctx, t, client, server)
+	if _, err := st.Write([]byte("bye")); err != nil {
+		t.Fatal("Write:", err)
+	}
+	if err := st.Close(); err != nil {
+		t.Fatal("Close:", err)
+	}
+	if err := st.Close(); !errors.Is(err, ErrStreamClosed) {
+		t.Errorf("second Close = %v; want %v", err, ErrStreamClosed)
+	}
+	if _, err := st.Read(make([]byte, 1)); !errors.Is(err, ErrStreamClosed) {
+		t.Errorf("Read after Close = %v; want %v", err, ErrStreamClosed)
+	}
+
+	got, err := io.ReadAll(peer)
+	if err != nil {
+		t.Error("ReadAll:", err)
+	}
+	if string(got) != "bye" {
+		t.Errorf("ReadAll = %q; want \"bye\"", got)
+	}
+	if _, err := peer.Write([]byte("x")); !errors.Is(err, ErrStreamClosed) {
+		t.Errorf("Write after remote Close = %v; want %v", err, ErrStreamClosed)
+	}
+	if err := peer.Close(); err != nil {
+		t.Error("Close:", err)
+	}
+
+	for _, s := range []*Session{client, server} {
+		waitFor(t, func() bool {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			return len(s.streams) == 0
+		})
+	}
+}
+
+// TestReject checks that streams opened beyond the accept backlog are
+// rejected.
+func TestReject(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	client, server := newSessions(nil, &Options{AcceptBacklog: 1})
+	defer client.Close()
+	defer server.Close()
+
+	if _, err := client.Open(ctx); err != nil {
+		t.Fatal("Open:", err)
+	}
+	if _, err := client.Open(ctx); !errors.Is(err, ErrRejected) {
+		t.Errorf("Open beyond backlog = %v; want %v", err, ErrRejected)
+	}
+	if _, err := server.Accept(ctx); err != nil {
+		t.Fatal("Accept:", err)
+	}
+	// Accept made room for another.
+	openPair(ctx, t, client, server)
+}
+
+// TestSessionClose checks that closing a Session ends its Streams and
+// the remote Session.
+func TestSessionClose(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	client, server := newSessions(nil, nil)
+	defer server.Close()
+
+	st, peer := openPair(ctx, t, client, server)
+	read := make(chan error, 1)
+	go func() {
+		_, err := peer.Read(make([]byte, 1))
+		read <- err
+	}()
+
+	if err := client.Close(); err != nil {
+		t.Error("Close:", err)
+	}
+	if err := client.Close(); !errors.Is(err, ErrSessionClosed) {
+		t.Errorf("second Close = %v; want %v", err, ErrSessionClosed)
+	}
+	if _, err := st.Write([]byte("x")); !errors.Is(err, ErrSessionClosed) {
+		t.Errorf("Write = %v; want %v", err, ErrSessionClosed)
+	}
+	if _, err := client.Open(ctx); !errors.Is(err, ErrSessionClosed) {
+		t.Errorf("Open = %v; want %v", err, ErrSessionClosed)
+	}
+
+	if err := <-read; err == nil {
+		t.Error("remote Read succeeded after Session closed")
+	}
+	<-server.Done()
+	if _, err := server.Accept(ctx); err == nil {
+		t.Error("remote Accept succeeded after Session closed")
+	}
+}
+
+func newSessions(clientOpts, serverOpts *Options) (client, server *Session) {
+	c1, c2 := net.Pipe()
+	return Client(c1, clientOpts), Server(c2, serverOpts)
+}
+
+// openPair opens a Stream on client and accepts it on server.
+func openPair(ctx context.Context, t *testing.T, client, server *Session) (st, peer *Stream) {
+	t.Helper()
+	st, err := client.Open(ctx)
+	if err != nil {
+		t.Fatal("Open:", err)
+	}
+	peer, err = server.Accept(ctx)
+	if err != nil {
+		t.Fatal("Accept:", err)
+	}
+	if peer.ID() != st.ID() {
+		t.Fatalf("accepted stream %d; want %d", peer.ID(), st.ID())
+	}
+	return st, peer
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+type pingPongServer struct{}
+
+func (pingPongServer) EchoNum(ctx context.Context, call testcp.PingPong_echoNum) error {
+	out, err := call.AllocResults()
+	if err != nil {
+		return err
+	}
+	out.SetN(call.Args().N())
+	return nil
+}
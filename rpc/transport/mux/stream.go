@@ -0,0 +1,189 @@
+package mux
+
+import (
+	"io"
+	"sync"
+
+	"capnproto.org/go/capnp/v3"
+	"capnproto.org/go/capnp/v3/exp/bufferpool"
+	"capnproto.org/go/capnp/v3/rpc/transport"
+)
+
+// A Stream is one of the logical byte streams of a Session.  Read and
+// Write may be called concurrently with each other, and Close
+// interrupts both, so a Stream meets the requirements of
+// transport.NewStream.
+type Stream struct {
+	s    *Session
+	id   uint32
+	cond *sync.Cond // uses s.mu; signaled when any field below changes
+
+	opened  chan struct{} // closed when an Open's answer arrives; nil for accepted streams
+	openErr error         // set before opened is closed
+
+	remoteOpened bool // accept received, or open received
+	localClosed  bool // close sent
+	remoteClosed bool // close or reject received
+
+	sendWindow uint32 // bytes that may be written
+	recvWindow uint32 // bytes the remote side may send
+	consumed   uint32 // bytes read and not yet returned to recvWindow
+	buf        []byte // received and not yet read
+}
+
+// newStream returns a Stream of s with the given send window.  The
+// caller MUST be holding onto s.mu.
+func (s *Session) newStream(id, sendWindow uint32) *Stream {
+	return &Stream{
+		s:          s,
+		id:         id,
+		cond:       sync.NewCond(&s.mu),
+		sendWindow: sendWindow,
+		recvWindow: s.window,
+	}
+}
+
+// ID returns the Stream's ID, which is unique within its Session.
+func (st *Stream) ID() uint32 {
+	return st.id
+}
+
+// Codec returns a Codec that encodes messages onto the Stream, for use
+// with transport.New.  Closing the Codec closes the Stream.
+func (st *Stream) Codec() transport.Codec {
+	c := &streamCodec{
+		Decoder: capnp.NewDecoder(st),
+		Encoder: capnp.NewEncoder(st),
+		Closer:  st,
+	}
+	c.SetBufferPool(&bufferpool.Default)
+	return c
+}
+
+type streamCodec struct {
+	*capnp.Decoder
+	*capnp.Encoder
+	io.Closer
+}
+
+func (c *streamCodec) SetMaxMessageSize(size uint64) {
+	c.Decoder.MaxMessageSize = size
+}
+
+// Read reads the data received on the Stream.  It returns io.EOF once
+// the remote side has closed the Stream and its data has been read.
+func (st *Stream) Read(p []byte) (int, error) {
+	s := st.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for len(st.buf) == 0 && !st.localClosed && !st.remoteClosed && s.err == nil {
+		st.cond.Wait()
+	}
+	switch {
+	case st.localClosed:
+		return 0, ErrStreamClosed
+	case len(st.buf) > 0:
+	case st.remoteClosed:
+		return 0, io.EOF
+	default:
+		return 0, s.err
+	}
+
+	n := copy(p, st.buf)
+	st.buf = st.buf[n:]
+	if len(st.buf) == 0 {
+		st.buf = nil
+	}
+	st.consumed += uint32(n)
+	if st.consumed >= s.window/2 && !st.remoteClosed && s.err == nil {
+		st.recvWindow += st.consumed
+		s.queue(frame{typ: frameWindow, id: st.id, payload: st.consumed})
+		st.consumed = 0
+	}
+	return n, nil
+}
+
+// Write writes p to the Stream, waiting for the remote side to give it
+// enough window.
+func (st *Stream) Write(p []byte) (int, error) {
+	s := st.s
+	written := 0
+	for len(p) > 0 {
+		s.mu.Lock()
+		for (!st.remoteOpened || st.sendWindow == 0) && !st.localClosed && !st.remoteClosed && s.err == nil {
+			st.cond.Wait()
+		}
+		if err := st.writeErr(); err != nil {
+			s.mu.Unlock()
+			return written, err
+		}
+		n := uint32(len(p))
+		if n > st.sendWindow {
+			n = st.sendWindow
+		}
+		if n > s.maxFrameSize {
+			n = s.maxFrameSize
+		}
+		st.sendWindow -= n
+		s.mu.Unlock()
+
+		s.wmu.Lock()
+		// Check again, so that no data follows the Stream's close frame,
+		// which is written under wmu.
+		s.mu.Lock()
+		err := st.writeErr()
+		s.mu.Unlock()
+		if err == nil {
+			if err = s.writeData(st.id, p[:n]); err != nil {
+				s.wmu.Unlock()
+				s.fail(err)
+				return written, err
+			}
+		}
+		s.wmu.Unlock()
+		if err != nil {
+			return written, err
+		}
+		written += int(n)
+		p = p[n:]
+	}
+	return written, nil
+}
+
+// writeErr returns the error that prevents writing to the Stream, if
+// any.  The caller MUST be holding onto st.s.mu.
+func (st *Stream) writeErr() error {
+	switch {
+	case st.localClosed:
+		return ErrStreamClosed
+	case st.s.err != nil:
+		return st.s.err
+	case st.openErr != nil:
+		return st.openErr
+	case st.remoteClosed:
+		return ErrStreamClosed
+	}
+	return nil
+}
+
+// Close closes the Stream, discarding any data not yet read, and tells
+// the remote side, whose reads return io.EOF once they have read the
+// data written before.
+func (st *Stream) Close() error {
+	s := st.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if st.localClosed {
+		return ErrStreamClosed
+	}
+	st.localClosed = true
+	st.buf = nil
+	if s.err == nil {
+		s.queue(frame{typ: frameClose, id: st.id})
+	}
+	if st.remoteClosed {
+		delete(s.streams, st.id)
+	}
+	st.cond.Broadcast()
+	return nil
+}
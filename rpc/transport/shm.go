@@ -0,0 +1,499 @@
+//go:build linux || darwin || freebsd
+
+package transport
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"syscall"
+
+	capnp "capnproto.org/go/capnp/v3"
+	rpccp "capnproto.org/go/capnp/v3/std/capnp/rpc"
+)
+
+/*
+Shared memory transport
+
+Each side of a shared memory transport creates a file on a
+memory-backed file system, maps it into memory, and sends its
+descriptor to the other side over a Unix domain socket, which maps it
+read-only.  Messages are built directly in the sender's region by
+shmArena, and the socket carries only small frames that say where
+their segments are (the "doorbell"):
+
+	hello:   "capnpshm" | region size (uint64)            sent with the descriptor
+	message: 1 (uint32) | n (uint32) | n × (offset, length (uint64))
+	release: 2 (uint32) | n (uint32) | n × offset (uint64)
+
+A segment that did not fit in the region has an offset of
+shmInline, and its bytes follow the message frame.  The receiver reads
+segments in place and sends a release frame when the message is
+released.  The sender reuses their space once it has received the
+release frame and released its own message, whichever comes last.
+All integers are little-endian.
+*/
+
+const (
+	shmMagic = "capnpshm"
+
+	shmFrameMessage = 1
+	shmFrameRelease = 2
+
+	// shmInline is the offset of a segment sent inline.
+	shmInline = ^uint64(0)
+
+	// Limits on incoming frames.
+	maxShmSegments   = 1 << 12
+	maxShmInlineSize = 1 << 26
+
+	defaultShmSize = 16 << 20
+)
+
+// SharedMemoryOptions configures a shared memory transport.
+type SharedMemoryOptions struct {
+	// Size is the size in bytes of the shared memory region that holds
+	// the messages sent by this side.  Messages that do not fit are
+	// copied through the socket instead.  If zero, 16 MiB is used.
+	Size int
+
+	// Dir is the directory in which to create the file that backs the
+	// region.  It should be on a memory-backed file system.  If empty,
+	// /dev/shm is used if it exists, and os.TempDir() otherwise.
+	Dir string
+}
+
+// NewSharedMemory creates a transport that exchanges messages with a
+// process on the same host through shared memory, using conn to pass
+// the shared memory's file descriptors and to signal when messages are
+// sent and released.  Both processes must call NewSharedMemory on
+// their ends of the connection.  Closing the transport closes conn.
+//
+// Outgoing messages are allocated in shared memory, and incoming
+// messages are read where the remote process wrote them, so a message
+// is not copied between processes.  The remote process must be trusted
+// not to modify a message after sending it.  Writing to a received
+// message copies the segment written to.  A release of space that the
+// remote process was not sent is a connection error.
+func NewSharedMemory(conn *net.UnixConn, opts *SharedMemoryOptions) (Transport, error) {
+	var o SharedMemoryOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Size <= 0 {
+		o.Size = defaultShmSize
+	}
+	page := os.Getpagesize()
+	o.Size = (o.Size + page - 1) / page * page
+	if o.Dir == "" {
+		o.Dir = os.TempDir()
+		if fi, err := os.Stat("/dev/shm"); err == nil && fi.IsDir() {
+			o.Dir = "/dev/shm"
+		}
+	}
+
+	local, err := sendShmRegion(conn, o.Dir, o.Size)
+	if err != nil {
+		return nil, shmerr("handshake", err)
+	}
+	remote, err := recvShmRegion(conn)
+	if err != nil {
+		syscall.Munmap(local)
+		return nil, shmerr("handshake", err)
+	}
+	return &shmTransport{
+		conn:   conn,
+		r:      bufio.NewReader(conn),
+		ring:   newShmRing(int64(len(local))),
+		local:  local,
+		remote: remote,
+		refs:   1,
+	}, nil
+}
+
+// sendShmRegion creates and maps a region of size bytes, and sends its
+// descriptor over conn.
+func sendShmRegion(conn *net.UnixConn, dir string, size int) ([]byte, error) {
+	f, err := os.CreateTemp(dir, "capnp-shm-")
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if err := os.Remove(f.Name()); err != nil {
+		return nil, err
+	}
+	if err := f.Truncate(int64(size)); err != nil {
+		return nil, err
+	}
+	fd := int(f.Fd())
+	region, err := syscall.Mmap(fd, 0, size, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, fmt.Errorf("mmap: %w", err)
+	}
+
+	var hello [16]byte
+	copy(hello[:8], shmMagic)
+	binary.LittleEndian.PutUint64(hello[8:], uint64(size))
+	if _, _, err := conn.WriteMsgUnix(hello[:], syscall.UnixRights(fd), nil); err != nil {
+		syscall.Munmap(region)
+		return nil, err
+	}
+	return region, nil
+}
+
+// recvShmRegion receives the descriptor of the remote side's region
+// from conn and maps it read-only.
+func recvShmRegion(conn *net.UnixConn) ([]byte, error) {
+	var hello [16]byte
+	oob := make([]byte, syscall.CmsgSpace(4))
+	n, oobn, _, _, err := conn.ReadMsgUnix(hello[:], oob)
+	if err != nil {
+		return nil, err
+	}
+	cmsgs, err := syscall.ParseSocketControlMessage(oob[:oobn])
+	if err != nil {
+		return nil, err
+	}
+	var fds []int
+	for i := range cmsgs {
+		rights, err := syscall.ParseUnixRights(&cmsgs[i])
+		if err == nil {
+			fds = append(fds, rights...)
+		}
+	}
+	defer func() {
+		for _, fd := range fds {
+			syscall.Close(fd)
+		}
+	}()
+	if len(fds) != 1 {
+		return nil, fmt.Errorf("received %d file descriptors; want 1", len(fds))
+	}
+	if _, err := io.ReadFull(conn, hello[n:]); err != nil {
+		return nil, err
+	}
+	if string(hello[:8]) != shmMagic {
+		return nil, errors.New("remote is not a shared memory transport")
+	}
+	size := binary.LittleEndian.Uint64(hello[8:])
+	var st syscall.Stat_t
+	if err := syscall.Fstat(fds[0], &st); err != nil {
+		return nil, err
+	}
+	if size == 0 || size > uint64(maxInt) || uint64(st.Size) < size {
+		return nil, fmt.Errorf("invalid region size %d", size)
+	}
+	region, err := syscall.Mmap(fds[0], 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, fmt.Errorf("mmap: %w", err)
+	}
+	return region, nil
+}
+
+type shmTransport struct {
+	conn   *net.UnixConn
+	r      *bufio.Reader // only used by RecvMessage
+	max    uint64        // limit on the size of a received message, or 0
+	ring   *shmRing
+	local  []byte // messages sent by this side
+	remote []byte // messages sent by the remote side
+
+	wmu sync.Mutex // serializes writes to conn
+
+	mu     sync.Mutex
+	refs   int // messages not released, plus one until Close
+	closed bool
+}
+
+// NewMessage allocates a new message in shared memory.
+//
+// It is safe to call NewMessage concurrently with RecvMessage.
+func (t *shmTransport) NewMessage() (OutgoingMessage, error) {
+	if !t.ref() {
+		return OutgoingMessage{}, shmerr("new message", net.ErrClosed)
+	}
+	arena := &shmArena{ring: t.ring, region: t.local}
+	msg, seg, err := capnp.NewMessage(arena)
+	if err == nil {
+		var rmsg rpccp.Message
+		rmsg, err = rpccp.NewRootMessage(seg)
+		if err == nil {
+			return t.outgoing(msg, arena, rmsg), nil
+		}
+	}
+	arena.free()
+	t.unref()
+	return OutgoingMessage{}, shmerr("new message", err)
+}
+
+func (t *shmTransport) outgoing(msg *capnp.Message, arena *shmArena, rmsg rpccp.Message) OutgoingMessage {
+	released := false
+	send := func() error {
+		if released {
+			panic("Tried to send() a message that was already released.")
+		}
+		if err := t.send(msg, arena); err != nil {
+			return shmerr("send", err)
+		}
+		return nil
+	}
+	release := func() {
+		if released {
+			return
+		}
+		released = true
+		msg.Reset(nil)
+		arena.free()
+		t.unref()
+	}
+	return OutgoingMessage{
+		Message: rmsg,
+		Send:    send,
+		Release: release,
+	}
+}
+
+// send writes a message frame for msg, followed by its segments that
+// are not in shared memory.  The segments in shared memory are held by
+// the remote side until it sends a release frame, so they are freed
+// once both the remote side and the local message have released them.
+func (t *shmTransport) send(msg *capnp.Message, arena *shmArena) error {
+	frame := make([]byte, 8+16*len(arena.segs))
+	binary.LittleEndian.PutUint32(frame, shmFrameMessage)
+	binary.LittleEndian.PutUint32(frame[4:], uint32(len(arena.segs)))
+	bufs := net.Buffers{frame}
+	for i, off := range arena.offs {
+		seg, err := msg.Segment(capnp.SegmentID(i))
+		if err != nil {
+			return err
+		}
+		data := seg.Data()
+		desc := frame[8+16*i:]
+		if off >= 0 {
+			binary.LittleEndian.PutUint64(desc, uint64(off))
+		} else {
+			binary.LittleEndian.PutUint64(desc, shmInline)
+			bufs = append(bufs, data)
+		}
+		binary.LittleEndian.PutUint64(desc[8:], uint64(len(data)))
+	}
+
+	// Record the send first, since the remote side may release the
+	// segments as soon as it has the frame.  If the write fails, the
+	// remote side may have the frame, but then the connection is
+	// broken and the region is going away anyway.
+	arena.send()
+	t.wmu.Lock()
+	_, err := bufs.WriteTo(t.conn)
+	t.wmu.Unlock()
+	return err
+}
+
+// sendRelease tells the remote side that the segments at offs are no
+// longer in use.  Errors are ignored: if the connection is broken, the
+// region is going away anyway.
+func (t *shmTransport) sendRelease(offs []int64) {
+	if len(offs) == 0 {
+		return
+	}
+	frame := make([]byte, 8+8*len(offs))
+	binary.LittleEndian.PutUint32(frame, shmFrameRelease)
+	binary.LittleEndian.PutUint32(frame[4:], uint32(len(offs)))
+	for i, off := range offs {
+		binary.LittleEndian.PutUint64(frame[8+8*i:], uint64(off))
+	}
+
+	t.wmu.Lock()
+	defer t.wmu.Unlock()
+	t.conn.Write(frame)
+}
+
+// RecvMessage waits for the next message from the remote side.  The
+// message is read from shared memory until it is released.
+//
+// It is safe to call RecvMessage concurrently with NewMessage.
+func (t *shmTransport) RecvMessage() (IncomingMessage, error) {
+	for {
+		var hdr [8]byte
+		if _, err := io.ReadFull(t.r, hdr[:]); err != nil {
+			return IncomingMessage{}, shmerr("receive", err)
+		}
+		kind := binary.LittleEndian.Uint32(hdr[:])
+		n := int(binary.LittleEndian.Uint32(hdr[4:]))
+		switch kind {
+		case shmFrameRelease:
+			if err := t.recvRelease(n); err != nil {
+				return IncomingMessage{}, shmerr("receive", err)
+			}
+		case shmFrameMessage:
+			msg, err := t.recvMessage(n)
+			if err != nil {
+				return IncomingMessage{}, shmerr("receive", err)
+			}
+			return msg, nil
+		default:
+			return IncomingMessage{}, shmerr("receive", fmt.Errorf("unknown frame type %d", kind))
+		}
+	}
+}
+
+func (t *shmTransport) recvRelease(n int) error {
+	if n > maxShmSegments {
+		return fmt.Errorf("release of %d segments exceeds limit", n)
+	}
+	buf := make([]byte, 8*n)
+	if _, err := io.ReadFull(t.r, buf); err != nil {
+		return err
+	}
+	for i := 0; i < n; i++ {
+		if err := t.ring.release(int64(binary.LittleEndian.Uint64(buf[8*i:]))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *shmTransport) recvMessage(n int) (IncomingMessage, error) {
+	if n == 0 || n > maxShmSegments {
+		return IncomingMessage{}, fmt.Errorf("message of %d segments", n)
+	}
+	descs := make([]byte, 16*n)
+	if _, err := io.ReadFull(t.r, descs); err != nil {
+		return IncomingMessage{}, err
+	}
+
+	// The reference keeps the remote region mapped while its segments
+	// are sliced, as well as until the message is released.
+	if !t.ref() {
+		return IncomingMessage{}, net.ErrClosed
+	}
+	msg, offs, err := t.readSegments(n, descs)
+	if err != nil {
+		t.unref()
+		return IncomingMessage{}, err
+	}
+	rmsg, err := rpccp.ReadRootMessage(msg)
+	if err != nil {
+		t.sendRelease(offs)
+		t.unref()
+		return IncomingMessage{}, err
+	}
+	released := false
+	release := func() {
+		if released {
+			return
+		}
+		released = true
+		msg.Reset(nil)
+		t.sendRelease(offs)
+		t.unref()
+	}
+	return IncomingMessage{
+		Message: rmsg,
+		Release: release,
+	}, nil
+}
+
+// readSegments locates the n segments described by descs in the remote
+// region, and reads the ones sent inline.  It returns a message that
+// uses them along with the offsets of the segments in shared memory.
+// The caller must hold a reference.
+func (t *shmTransport) readSegments(n int, descs []byte) (*capnp.Message, []int64, error) {
+	segs := make([][]byte, n)
+	var offs []int64
+	inline := 0
+	var total uint64
+	for i := range segs {
+		off := binary.LittleEndian.Uint64(descs[16*i:])
+		sz := binary.LittleEndian.Uint64(descs[16*i+8:])
+		if sz%8 != 0 {
+			return nil, nil, fmt.Errorf("segment %d has size %d, not a multiple of 8", i, sz)
+		}
+		if total += sz; t.max > 0 && (sz > t.max || total > t.max) {
+			return nil, nil, errors.New("message too large")
+		}
+		if off == shmInline {
+			if inline += int(sz); sz > maxShmInlineSize || inline > maxShmInlineSize {
+				return nil, nil, errors.New("inline segments exceed size limit")
+			}
+			continue
+		}
+		if off%8 != 0 || off > uint64(len(t.remote)) || sz > uint64(len(t.remote))-off {
+			return nil, nil, fmt.Errorf("segment %d out of bounds of shared memory", i)
+		}
+		segs[i] = t.remote[off : off+sz : off+sz]
+		offs = append(offs, int64(off))
+	}
+	for i := range segs {
+		if binary.LittleEndian.Uint64(descs[16*i:]) != shmInline {
+			continue
+		}
+		segs[i] = make([]byte, binary.LittleEndian.Uint64(descs[16*i+8:]))
+		if _, err := io.ReadFull(t.r, segs[i]); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// The remote side must not change the segments until they are
+	// released, but they are mapped read-only, so writes to the message
+	// go to a copy.
+	return &capnp.Message{Arena: capnp.ReadOnlyMultiSegment(segs)}, offs, nil
+}
+
+// SetMaxMessageSize limits the size of the messages received,
+// including their segments in shared memory.
+func (t *shmTransport) SetMaxMessageSize(size uint64) {
+	t.max = size
+}
+
+// Close closes the connection.  The shared memory is unmapped once
+// every message has been released.
+func (t *shmTransport) Close() error {
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return transporterr.Disconnectedf("already closed").Annotate("", "shm transport")
+	}
+	t.closed = true
+	t.mu.Unlock()
+
+	err := t.conn.Close()
+	t.unref()
+	if err != nil {
+		return shmerr("close", err)
+	}
+	return nil
+}
+
+// ref counts a new message against unmapping the shared memory.  It
+// returns false if the transport is closed.
+func (t *shmTransport) ref() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return false
+	}
+	t.refs++
+	return true
+}
+
+func (t *shmTransport) unref() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.refs--; t.refs == 0 {
+		syscall.Munmap(t.local)
+		syscall.Munmap(t.remote)
+		t.local, t.remote = nil, nil
+	}
+}
+
+func shmerr(op string, err error) error {
+	return transporterr.Annotate(fmt.Errorf("%s: %w", op, err), "shm transport")
+}
+
+const maxInt = int(^uint(0) >> 1)
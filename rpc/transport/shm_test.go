@@ -0,0 +1,349 @@
+//go:build linux || darwin || freebsd
+
+package transport
+
+import (
+	"bytes"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+
+	capnp "capnproto.org/go/capnp/v3"
+	rpccp "capnproto.org/go/capnp/v3/std/capnp/rpc"
+)
+
+func TestSharedMemoryTransport(t *testing.T) {
+	t.Parallel()
+
+	testTransport(t, func() (t1, t2 Transport, err error) {
+		return newSharedMemoryPair(t, nil)
+	})
+}
+
+// TestSharedMemoryLargeMessage sends messages larger than the shared
+// memory region, which are sent inline, and checks that the space used
+// by messages is reclaimed.
+func TestSharedMemoryLargeMessage(t *testing.T) {
+	t.Parallel()
+
+	t1, t2, err := newSharedMemoryPair(t, &SharedMemoryOptions{Size: 1 << 16})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer t1.Close()
+	defer t2.Close()
+
+	for _, size := range []int{1 << 10, 1 << 17, 1 << 14, 1 << 14, 1 << 14, 1 << 14, 1 << 14} {
+		payload := bytes.Repeat([]byte{byte(size)}, size)
+		out, err := t1.NewMessage()
+		if err != nil {
+			t.Fatal("NewMessage:", err)
+		}
+		abort, err := out.Message.NewAbort()
+		if err != nil {
+			t.Fatal("NewAbort:", err)
+		}
+		if err := abort.SetReason(string(payload)); err != nil {
+			t.Fatal("SetReason:", err)
+		}
+		if err := out.Send(); err != nil {
+			t.Fatal("Send:", err)
+		}
+		out.Release()
+
+		in, err := t2.RecvMessage()
+		if err != nil {
+			t.Fatal("RecvMessage:", err)
+		}
+		if in.Message.Which() != rpccp.Message_Which_abort {
+			t.Fatalf("received %v message; want abort", in.Message.Which())
+		}
+		e, _ := in.Message.Abort()
+		reason, _ := e.Reason()
+		if reason != string(payload) {
+			t.Errorf("received reason of %d bytes; want %d bytes", len(reason), size)
+		}
+		in.Release()
+	}
+
+	// Receive the release frames, which t1 handles while waiting for
+	// a message.
+	out, err := t2.NewMessage()
+	if err != nil {
+		t.Fatal("NewMessage:", err)
+	}
+	out.Message.NewBootstrap()
+	if err := out.Send(); err != nil {
+		t.Fatal("Send:", err)
+	}
+	out.Release()
+	in, err := t1.RecvMessage()
+	if err != nil {
+		t.Fatal("RecvMessage:", err)
+	}
+	in.Release()
+
+	ring := t1.(*shmTransport).ring
+	ring.mu.Lock()
+	defer ring.mu.Unlock()
+	if len(ring.live) != 0 || ring.head != ring.tail {
+		t.Errorf("after releasing all messages, %d chunks live and %d bytes in use", len(ring.live), ring.head-ring.tail)
+	}
+}
+
+// TestSharedMemoryWriteReceived checks that a received message, which
+// is mapped read-only, can be written to without faulting and without
+// changing the sender's copy.
+func TestSharedMemoryWriteReceived(t *testing.T) {
+	t.Parallel()
+
+	t1, t2, err := newSharedMemoryPair(t, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer t1.Close()
+	defer t2.Close()
+
+	out, err := t1.NewMessage()
+	if err != nil {
+		t.Fatal("NewMessage:", err)
+	}
+	bs, err := out.Message.NewBootstrap()
+	if err != nil {
+		t.Fatal("NewBootstrap:", err)
+	}
+	bs.SetQuestionId(1)
+	if err := out.Send(); err != nil {
+		t.Fatal("Send:", err)
+	}
+	defer out.Release()
+
+	in, err := t2.RecvMessage()
+	if err != nil {
+		t.Fatal("RecvMessage:", err)
+	}
+	defer in.Release()
+	got, err := in.Message.Bootstrap()
+	if err != nil {
+		t.Fatal("Bootstrap:", err)
+	}
+	got.SetQuestionId(2)
+	if id := got.QuestionId(); id != 2 {
+		t.Errorf("received message question ID = %d after setting to 2", id)
+	}
+	if id := bs.QuestionId(); id != 1 {
+		t.Errorf("sent message question ID = %d after receiver set it; want 1", id)
+	}
+}
+
+// TestSharedMemoryRemoteReleaseFirst checks that a sent message that
+// the remote side releases first keeps its space until it is released
+// locally too.
+func TestSharedMemoryRemoteReleaseFirst(t *testing.T) {
+	t.Parallel()
+
+	t1, t2, err := newSharedMemoryPair(t, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer t1.Close()
+	defer t2.Close()
+
+	out, err := t1.NewMessage()
+	if err != nil {
+		t.Fatal("NewMessage:", err)
+	}
+	bs, err := out.Message.NewBootstrap()
+	if err != nil {
+		t.Fatal("NewBootstrap:", err)
+	}
+	bs.SetQuestionId(1)
+	if err := out.Send(); err != nil {
+		t.Fatal("Send:", err)
+	}
+	in, err := t2.RecvMessage()
+	if err != nil {
+		t.Fatal("RecvMessage:", err)
+	}
+	in.Release()
+
+	// Have t1 handle the release frame, then allocate and fill another
+	// message, which must not reuse out's space.
+	reply, err := t2.NewMessage()
+	if err != nil {
+		t.Fatal("NewMessage:", err)
+	}
+	reply.Message.NewBootstrap()
+	if err := reply.Send(); err != nil {
+		t.Fatal("Send:", err)
+	}
+	reply.Release()
+	in, err = t1.RecvMessage()
+	if err != nil {
+		t.Fatal("RecvMessage:", err)
+	}
+	in.Release()
+	next, err := t1.NewMessage()
+	if err != nil {
+		t.Fatal("NewMessage:", err)
+	}
+	nbs, err := next.Message.NewBootstrap()
+	if err != nil {
+		t.Fatal("NewBootstrap:", err)
+	}
+	nbs.SetQuestionId(2)
+	if id := bs.QuestionId(); id != 1 {
+		t.Errorf("sent message question ID = %d after remote release; want 1", id)
+	}
+	next.Release()
+	out.Release()
+
+	ring := t1.(*shmTransport).ring
+	ring.mu.Lock()
+	defer ring.mu.Unlock()
+	if len(ring.live) != 0 || ring.head != ring.tail {
+		t.Errorf("after releasing all messages, %d chunks live and %d bytes in use", len(ring.live), ring.head-ring.tail)
+	}
+}
+
+// TestSharedMemoryReleaseUnknown checks that a release frame naming a
+// chunk that the remote side does not hold is a connection error.
+func TestSharedMemoryReleaseUnknown(t *testing.T) {
+	t.Parallel()
+
+	t1, t2, err := newSharedMemoryPair(t, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer t1.Close()
+	defer t2.Close()
+
+	out, err := t1.NewMessage()
+	if err != nil {
+		t.Fatal("NewMessage:", err)
+	}
+	defer out.Release()
+	off := out.Message.Segment().Message().Arena.(*shmArena).offs[0]
+	t2.(*shmTransport).sendRelease([]int64{off})
+	if _, err := t1.RecvMessage(); err == nil {
+		t.Fatal("RecvMessage succeeded after release of unsent chunk")
+	}
+	ring := t1.(*shmTransport).ring
+	ring.mu.Lock()
+	defer ring.mu.Unlock()
+	if len(ring.live) != 1 {
+		t.Errorf("%d chunks live after release of unsent chunk; want 1", len(ring.live))
+	}
+}
+
+func TestShmRing(t *testing.T) {
+	t.Parallel()
+
+	r := newShmRing(64)
+	a, ok := r.alloc(24)
+	if !ok || a != 0 {
+		t.Fatalf("alloc(24) = %d, %t; want 0, true", a, ok)
+	}
+	b, ok := r.alloc(24)
+	if !ok || b != 24 {
+		t.Fatalf("alloc(24) = %d, %t; want 24, true", b, ok)
+	}
+	if _, ok := r.alloc(24); ok {
+		t.Fatal("alloc(24) succeeded with 16 bytes free")
+	}
+
+	// Freeing b first doesn't reclaim space until a is freed.
+	r.free(b)
+	if _, ok := r.alloc(16); !ok {
+		t.Fatal("alloc(16) failed with 16 bytes free at the end")
+	}
+	if _, ok := r.alloc(8); ok {
+		t.Fatal("alloc(8) succeeded with ring full")
+	}
+	r.free(a)
+	c, ok := r.alloc(40)
+	if !ok || c != 0 {
+		t.Fatalf("alloc(40) = %d, %t; want 0, true", c, ok)
+	}
+	if _, ok := r.alloc(16); ok {
+		t.Fatal("alloc(16) succeeded with 8 bytes free")
+	}
+	if err := r.release(c + 8); err == nil {
+		t.Error("release of unallocated offset succeeded")
+	}
+
+	// A sent chunk is freed once both the allocator and the remote
+	// side release it, and the remote side can't release it twice.
+	r = newShmRing(64)
+	d, _ := r.alloc(64)
+	if err := r.release(d); err == nil {
+		t.Error("release of unsent chunk succeeded")
+	}
+	r.send(d)
+	r.free(d)
+	if _, ok := r.alloc(8); ok {
+		t.Fatal("alloc(8) succeeded while chunk is held by the remote side")
+	}
+	if err := r.release(d); err != nil {
+		t.Fatal("release of sent chunk:", err)
+	}
+	if err := r.release(d); err == nil {
+		t.Error("second release of sent chunk succeeded")
+	}
+	if _, ok := r.alloc(64); !ok {
+		t.Fatal("alloc(64) failed after last reference freed")
+	}
+}
+
+// newSharedMemoryPair returns a pair of shared memory transports
+// connected by a socket pair.
+func newSharedMemoryPair(t *testing.T, opts *SharedMemoryOptions) (t1, t2 Transport, err error) {
+	fds, err := syscall.Socketpair(syscall.AF_UNIX, syscall.SOCK_STREAM, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	c1, err := unixConnFromFD(fds[0])
+	if err != nil {
+		syscall.Close(fds[1])
+		return nil, nil, err
+	}
+	c2, err := unixConnFromFD(fds[1])
+	if err != nil {
+		c1.Close()
+		return nil, nil, err
+	}
+
+	type result struct {
+		t   Transport
+		err error
+	}
+	ch := make(chan result)
+	go func() {
+		t, err := NewSharedMemory(c2, opts)
+		ch <- result{t, err}
+	}()
+	t1, err = NewSharedMemory(c1, opts)
+	r := <-ch
+	if err != nil || r.err != nil {
+		c1.Close()
+		c2.Close()
+		if err == nil {
+			err = r.err
+		}
+		return nil, nil, err
+	}
+	return t1, r.t, nil
+}
+
+func unixConnFromFD(fd int) (*net.UnixConn, error) {
+	f := os.NewFile(uintptr(fd), "socketpair")
+	defer f.Close()
+	c, err := net.FileConn(f)
+	if err != nil {
+		return nil, err
+	}
+	return c.(*net.UnixConn), nil
+}
+
+var _ capnp.Arena = (*shmArena)(nil)
@@ -0,0 +1,203 @@
+package transport
+
+import (
+	"fmt"
+	"sync"
+
+	capnp "capnproto.org/go/capnp/v3"
+)
+
+// shmRing allocates chunks of a shared memory region in FIFO order.
+// A chunk is held by the message that allocated it until that message
+// is released, and by the remote side once for each time it was sent
+// until the remote side releases it.  It is freed once nothing holds
+// it.  Chunks may be freed in any order, but their space is reclaimed
+// in the order they were allocated, so a chunk that is held for a long
+// time holds up reuse of the space allocated after it.
+type shmRing struct {
+	mu   sync.Mutex
+	size int64
+
+	// head and tail count the bytes ever allocated and reclaimed,
+	// including the padding skipped when an allocation would wrap.
+	head, tail int64
+
+	chunks []*shmChunk         // unreclaimed chunks, in allocation order
+	live   map[int64]*shmChunk // unfreed chunks, keyed by offset
+}
+
+type shmChunk struct {
+	end    int64 // value of head after the chunk was allocated
+	local  bool  // held by the message that allocated it
+	remote int   // times sent and not yet released by the remote side
+}
+
+func (c *shmChunk) freed() bool {
+	return !c.local && c.remote == 0
+}
+
+func newShmRing(size int64) *shmRing {
+	return &shmRing{
+		size: size,
+		live: make(map[int64]*shmChunk),
+	}
+}
+
+// alloc reserves n bytes and returns their offset in the region.  It
+// returns false if there is not enough contiguous free space.
+func (r *shmRing) alloc(n int64) (int64, bool) {
+	n = (n + 7) &^ 7
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if n <= 0 || n > r.size {
+		return 0, false
+	}
+	off := r.head % r.size
+	var pad int64
+	if off+n > r.size {
+		pad, off = r.size-off, 0
+	}
+	if r.head+pad+n-r.tail > r.size {
+		return 0, false
+	}
+	r.head += pad + n
+	c := &shmChunk{end: r.head, local: true}
+	r.chunks = append(r.chunks, c)
+	r.live[off] = c
+	return off, true
+}
+
+// send records that the chunk at off was sent to the remote side,
+// which must release it.
+func (r *shmRing) send(off int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.live[off].remote++
+}
+
+// free releases the chunk at off on behalf of the message that
+// allocated it.
+func (r *shmRing) free(off int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c := r.live[off]
+	c.local = false
+	r.reclaim(off, c)
+}
+
+// release releases the chunk at off on behalf of the remote side.  It
+// returns an error if the remote side does not hold the chunk, so that
+// a peer cannot free space that is still in use.
+func (r *shmRing) release(off int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c := r.live[off]
+	if c == nil || c.remote == 0 {
+		return fmt.Errorf("release of unknown chunk at offset %d", off)
+	}
+	c.remote--
+	r.reclaim(off, c)
+	return nil
+}
+
+// reclaim frees c, the chunk at off, if nothing holds it, along with
+// the space of the freed chunks at the start of r.chunks.  The caller
+// must be holding onto r.mu.
+func (r *shmRing) reclaim(off int64, c *shmChunk) {
+	if !c.freed() {
+		return
+	}
+	delete(r.live, off)
+	for len(r.chunks) > 0 && r.chunks[0].freed() {
+		r.tail = r.chunks[0].end
+		r.chunks[0] = nil
+		r.chunks = r.chunks[1:]
+	}
+}
+
+// minShmSegment is the smallest segment that shmArena allocates.
+const minShmSegment = 1024
+
+// shmArena is a capnp.Arena that allocates segments in a shared memory
+// region, falling back to the heap when the region is full.
+type shmArena struct {
+	ring   *shmRing
+	region []byte
+
+	segs [][]byte
+	offs []int64 // offset of each segment in region, or -1 if on the heap
+}
+
+func (a *shmArena) NumSegments() int64 {
+	return int64(len(a.segs))
+}
+
+func (a *shmArena) Data(id capnp.SegmentID) ([]byte, error) {
+	if int64(id) >= int64(len(a.segs)) {
+		return nil, fmt.Errorf("segment %d requested (arena only has %d segments)", id, len(a.segs))
+	}
+	return a.segs[id], nil
+}
+
+func (a *shmArena) Allocate(minsz capnp.Size, segs map[capnp.SegmentID]*capnp.Segment) (capnp.SegmentID, []byte, error) {
+	var total int64
+	for i := range a.segs {
+		id := capnp.SegmentID(i)
+		data := a.segs[i]
+		if s := segs[id]; s != nil {
+			data = s.Data()
+		}
+		if i == len(a.segs)-1 && int64(minsz) <= int64(cap(data)-len(data)) {
+			return id, data, nil
+		}
+		total += int64(cap(data))
+	}
+
+	// Grow geometrically, as MultiSegmentArena does.
+	n := int64(minsz)
+	if n < total {
+		n = total
+	}
+	if n < minShmSegment {
+		n = minShmSegment
+	}
+	n = (n + 7) &^ 7
+	id := capnp.SegmentID(len(a.segs))
+	var data []byte
+	if off, ok := a.ring.alloc(n); ok {
+		data = a.region[off : off : off+n]
+		a.offs = append(a.offs, off)
+	} else {
+		data = make([]byte, 0, n)
+		a.offs = append(a.offs, -1)
+	}
+	a.segs = append(a.segs, data)
+	return id, data, nil
+}
+
+// send records that the arena's segments in the shared memory region
+// were sent to the remote side.
+func (a *shmArena) send() {
+	for _, off := range a.offs {
+		if off >= 0 {
+			a.ring.send(off)
+		}
+	}
+}
+
+// free releases the arena's segments in the shared memory region.
+func (a *shmArena) free() {
+	for _, off := range a.offs {
+		if off >= 0 {
+			a.ring.free(off)
+		}
+	}
+}
+
+func (a *shmArena) String() string {
+	return fmt.Sprintf("shared memory arena [%d segments]", len(a.segs))
+}
@@ -7,8 +7,10 @@
 package transport
 
 import (
+	"errors"
 	"fmt"
 	"io"
+	"os"
 
 	capnp "capnproto.org/go/capnp/v3"
 	"capnproto.org/go/capnp/v3/exp/bufferpool"
@@ -55,6 +57,37 @@ type Transport interface {
 	Close() error
 }
 
+// An FdTransport is a Transport that can send file descriptors along
+// with messages, such as one over a Unix domain socket.  Each message
+// carries a list of descriptors, which CapDescriptors in the message
+// refer to by index.
+type FdTransport interface {
+	Transport
+
+	// AttachFd adds fd to the descriptors sent with msg, which must
+	// have been returned by NewMessage and not yet sent, and returns its
+	// index in the list.  The transport does not take ownership of fd,
+	// which must stay open until the message is sent or released.
+	AttachFd(msg *capnp.Message, fd int) (index int, err error)
+
+	// TakeFd takes ownership of the descriptor at index in the list
+	// received with msg, which must have been returned by RecvMessage
+	// and not yet released.  It returns false if there is no such
+	// descriptor or it was already taken.  Descriptors that are not
+	// taken are closed when the message is released.
+	TakeFd(msg *capnp.Message, index int) (*os.File, bool)
+}
+
+// A MessageSizeLimiter is a Transport or Codec that can limit the size
+// of the messages it receives, rejecting a larger message from the
+// size in its header before allocating space for it.
+type MessageSizeLimiter interface {
+	// SetMaxMessageSize limits the size in bytes of the messages
+	// received after it returns.  It must not be called concurrently
+	// with RecvMessage or Decode.
+	SetMaxMessageSize(size uint64)
+}
+
 type OutgoingMessage struct {
 	Message rpccp.Message
 	Send    func() error
@@ -107,6 +140,57 @@ func NewPackedStream(rwc io.ReadWriteCloser) Transport {
 	return New(newStreamCodec(rwc, packedEncoding{}))
 }
 
+// StreamOptions configures the encoding of a stream codec.  Both ends
+// of a stream must use the same options.
+type StreamOptions struct {
+	// Packed selects the packed encoding, which removes zero bytes
+	// from messages.
+	Packed bool
+
+	// Compression, if not nil, compresses the messages that are at
+	// least Compression.Threshold bytes with DEFLATE, using the
+	// messages before them on the stream as a dictionary.  Unlike
+	// packing, this shrinks messages with repetitive text and data,
+	// at some CPU cost.  Both ends agree on a compression algorithm
+	// when the stream starts.  It cannot be combined with Packed,
+	// since DEFLATE removes zero bytes too.
+	Compression *CompressionOptions
+}
+
+// NewStreamCodec returns a Codec that reads and writes to rwc in the
+// encoding selected by opts, which may be nil.  It returns an error if
+// opts is invalid.  Closing the codec will close rwc.
+//
+// rwc's Close method must interrupt any outstanding IO, and it must be
+// safe to call rwc.Read and rwc.Write concurrently.
+func NewStreamCodec(rwc io.ReadWriteCloser, opts *StreamOptions) (Codec, error) {
+	var o StreamOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Compression == nil {
+		f := streamEncoding(basicEncoding{})
+		if o.Packed {
+			f = packedEncoding{}
+		}
+		return newStreamCodec(rwc, f), nil
+	}
+	if o.Packed {
+		return nil, errors.New("stream codec: packing and compression cannot be combined")
+	}
+	z, err := newStreamCompressor(rwc, *o.Compression)
+	if err != nil {
+		return nil, fmt.Errorf("stream codec: %w", err)
+	}
+	c := &streamCodec{
+		Decoder: capnp.NewDecoder(&z.lr),
+		Closer:  rwc,
+		z:       z,
+	}
+	c.SetBufferPool(&bufferpool.Default)
+	return c, nil
+}
+
 // NewMessage allocates a new message to be sent.
 //
 // It is safe to call NewMessage concurrently with RecvMessage.
@@ -177,6 +261,14 @@ func (s *transport) RecvMessage() (IncomingMessage, error) {
 	}, nil
 }
 
+// SetMaxMessageSize limits the size of the messages received, if the
+// codec implements MessageSizeLimiter.
+func (s *transport) SetMaxMessageSize(size uint64) {
+	if l, ok := s.c.(MessageSizeLimiter); ok {
+		l.SetMaxMessageSize(size)
+	}
+}
+
 // Close closes the underlying ReadWriteCloser.  It is not safe to call
 // Close concurrently with any other operations on the transport.
 func (s *transport) Close() error {
@@ -193,8 +285,10 @@ func (s *transport) Close() error {
 
 type streamCodec struct {
 	*capnp.Decoder
-	*capnp.Encoder
+	*capnp.Encoder // nil if z is not
 	io.Closer
+
+	z *streamCompressor // nil unless compressing
 }
 
 func newStreamCodec(rwc io.ReadWriteCloser, f streamEncoding) *streamCodec {
@@ -207,6 +301,27 @@ func newStreamCodec(rwc io.ReadWriteCloser, f streamEncoding) *streamCodec {
 	return ret
 }
 
+func (c *streamCodec) Encode(msg *capnp.Message) error {
+	if c.z != nil {
+		return c.z.encode(msg)
+	}
+	return c.Encoder.Encode(msg)
+}
+
+func (c *streamCodec) Decode() (*capnp.Message, error) {
+	if c.z != nil {
+		return c.z.decode(c.Decoder)
+	}
+	return c.Decoder.Decode()
+}
+
+func (c *streamCodec) SetMaxMessageSize(size uint64) {
+	c.Decoder.MaxMessageSize = size
+	if c.z != nil {
+		c.z.zDec.MaxMessageSize = size
+	}
+}
+
 type streamEncoding interface {
 	NewEncoder(io.Writer) *capnp.Encoder
 	NewDecoder(io.Reader) *capnp.Decoder
@@ -186,6 +186,19 @@ func TestTCPStreamTransport(t *testing.T) {
 
 		testTCPStreamTransport(t, NewPackedStream)
 	})
+
+	t.Run("Compressed", func(t *testing.T) {
+		t.Parallel()
+
+		testTCPStreamTransport(t, func(rwc io.ReadWriteCloser) Transport {
+			tr, err := newCompressedStream(rwc, &CompressionOptions{Threshold: 1})
+			if err != nil {
+				// Only returned for an invalid level.
+				panic(err)
+			}
+			return tr
+		})
+	})
 }
 
 func testTCPStreamTransport(t *testing.T, newTransport func(io.ReadWriteCloser) Transport) {
@@ -0,0 +1,329 @@
+//go:build linux || darwin || freebsd
+
+package transport
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"syscall"
+
+	capnp "capnproto.org/go/capnp/v3"
+	"capnproto.org/go/capnp/v3/exp/bufferpool"
+	rpccp "capnproto.org/go/capnp/v3/std/capnp/rpc"
+)
+
+/*
+Unix domain socket transport
+
+Each message is preceded by a frame that says how many descriptors
+were sent with it:
+
+	frame:   descriptor count (uint32) | 0 (uint32)
+	message: the message in the standard stream encoding
+
+The descriptors are sent as SCM_RIGHTS ancillary data with the frame.
+A socket delivers them no later than the frame's first byte, so the
+receiver queues every descriptor it reads and takes each message's
+from the front of the queue.  All integers are little-endian.
+*/
+
+// maxUnixFds is the most descriptors that can be sent with a message.
+// It is Linux's limit for a single sendmsg call.
+const maxUnixFds = 253
+
+// NewUnix creates a transport that exchanges messages over a Unix
+// domain socket, sending the file descriptors attached to capabilities
+// along with the messages that refer to them.  Closing the transport
+// closes conn.
+func NewUnix(conn *net.UnixConn) FdTransport {
+	t := &unixTransport{
+		conn: conn,
+		enc:  capnp.NewEncoder(conn),
+		out:  make(map[*capnp.Message][]int),
+		in:   make(map[*capnp.Message][]*os.File),
+	}
+	t.r = bufio.NewReader(&unixReader{
+		t:   t,
+		oob: make([]byte, syscall.CmsgSpace(maxUnixFds*4)),
+	})
+	t.dec = capnp.NewDecoder(t.r)
+	t.dec.SetBufferPool(&bufferpool.Default)
+	return t
+}
+
+type unixTransport struct {
+	conn *net.UnixConn
+	r    *bufio.Reader
+	dec  *capnp.Decoder
+
+	wmu sync.Mutex // serializes writes to conn
+	enc *capnp.Encoder
+
+	mu     sync.Mutex
+	out    map[*capnp.Message][]int      // descriptors attached to unsent messages
+	in     map[*capnp.Message][]*os.File // descriptors received with unreleased messages
+	queue  []*os.File                    // descriptors read but not yet claimed by a message
+	closed bool
+}
+
+// NewMessage allocates a new message to be sent.
+//
+// It is safe to call NewMessage concurrently with RecvMessage.
+func (t *unixTransport) NewMessage() (OutgoingMessage, error) {
+	arena := capnp.MultiSegment(nil)
+	msg, seg, err := capnp.NewMessage(arena)
+	if err != nil {
+		return OutgoingMessage{}, unixerr("new message", err)
+	}
+	rmsg, err := rpccp.NewRootMessage(seg)
+	if err != nil {
+		return OutgoingMessage{}, unixerr("new message", err)
+	}
+
+	released := false
+	send := func() error {
+		if released {
+			panic("Tried to send() a message that was already released.")
+		}
+		if err := t.send(msg); err != nil {
+			return unixerr("send", err)
+		}
+		return nil
+	}
+	release := func() {
+		if released {
+			return
+		}
+		released = true
+		t.mu.Lock()
+		delete(t.out, msg)
+		t.mu.Unlock()
+		msg.Reset(nil)
+		arena.Release()
+	}
+	return OutgoingMessage{
+		Message: rmsg,
+		Send:    send,
+		Release: release,
+	}, nil
+}
+
+// AttachFd adds fd to the descriptors sent with msg.
+func (t *unixTransport) AttachFd(msg *capnp.Message, fd int) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	fds := t.out[msg]
+	if len(fds) >= maxUnixFds {
+		return 0, unixerr("attach fd", fmt.Errorf("more than %d descriptors in a message", maxUnixFds))
+	}
+	t.out[msg] = append(fds, fd)
+	return len(fds), nil
+}
+
+// send writes a frame with msg's descriptors, followed by msg.
+func (t *unixTransport) send(msg *capnp.Message) error {
+	t.mu.Lock()
+	fds := t.out[msg]
+	t.mu.Unlock()
+
+	var frame [8]byte
+	binary.LittleEndian.PutUint32(frame[:], uint32(len(fds)))
+	var oob []byte
+	if len(fds) > 0 {
+		oob = syscall.UnixRights(fds...)
+	}
+
+	t.wmu.Lock()
+	defer t.wmu.Unlock()
+	n, _, err := t.conn.WriteMsgUnix(frame[:], oob, nil)
+	if err != nil {
+		return err
+	}
+	if n < len(frame) {
+		if _, err := t.conn.Write(frame[n:]); err != nil {
+			return err
+		}
+	}
+	return t.enc.Encode(msg)
+}
+
+// RecvMessage reads the next message and the descriptors sent with it.
+//
+// It is safe to call RecvMessage concurrently with NewMessage.
+func (t *unixTransport) RecvMessage() (IncomingMessage, error) {
+	var frame [8]byte
+	if _, err := io.ReadFull(t.r, frame[:]); err != nil {
+		return IncomingMessage{}, unixerr("receive", err)
+	}
+	n := binary.LittleEndian.Uint32(frame[:])
+	if n > maxUnixFds {
+		return IncomingMessage{}, unixerr("receive", fmt.Errorf("message has %d descriptors", n))
+	}
+	fds, err := t.claimFds(int(n))
+	if err != nil {
+		return IncomingMessage{}, unixerr("receive", err)
+	}
+	msg, err := t.dec.Decode()
+	if err == nil {
+		var rmsg rpccp.Message
+		rmsg, err = rpccp.ReadRootMessage(msg)
+		if err == nil {
+			return t.incoming(msg, rmsg, fds), nil
+		}
+	}
+	closeFiles(fds)
+	return IncomingMessage{}, unixerr("receive", err)
+}
+
+// SetMaxMessageSize limits the size of the messages received.
+func (t *unixTransport) SetMaxMessageSize(size uint64) {
+	t.dec.MaxMessageSize = size
+}
+
+// claimFds removes the first n descriptors from the queue.
+func (t *unixTransport) claimFds(n int) ([]*os.File, error) {
+	if n == 0 {
+		return nil, nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.queue) < n {
+		return nil, errors.New("descriptors missing from message")
+	}
+	fds := make([]*os.File, n)
+	copy(fds, t.queue)
+	t.queue = t.queue[n:]
+	return fds, nil
+}
+
+func (t *unixTransport) incoming(msg *capnp.Message, rmsg rpccp.Message, fds []*os.File) IncomingMessage {
+	if len(fds) > 0 {
+		t.mu.Lock()
+		t.in[msg] = fds
+		t.mu.Unlock()
+	}
+	released := false
+	release := func() {
+		if released {
+			return
+		}
+		released = true
+		t.mu.Lock()
+		fds := t.in[msg]
+		delete(t.in, msg)
+		t.mu.Unlock()
+		closeFiles(fds)
+		msg.Reset(nil)
+		t.dec.ReleaseMessage(msg)
+	}
+	return IncomingMessage{
+		Message: rmsg,
+		Release: release,
+	}
+}
+
+// TakeFd takes ownership of a descriptor received with msg.
+func (t *unixTransport) TakeFd(msg *capnp.Message, index int) (*os.File, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	fds := t.in[msg]
+	if index < 0 || index >= len(fds) || fds[index] == nil {
+		return nil, false
+	}
+	f := fds[index]
+	fds[index] = nil
+	return f, true
+}
+
+// Close closes the socket, and the descriptors received that have not
+// been claimed by a message.  It is not safe to call Close concurrently
+// with any other operations on the transport.
+func (t *unixTransport) Close() error {
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return transporterr.Disconnectedf("already closed").Annotate("", "unix transport")
+	}
+	t.closed = true
+	t.mu.Unlock()
+
+	err := t.conn.Close()
+	t.mu.Lock()
+	closeFiles(t.queue)
+	t.queue = nil
+	t.mu.Unlock()
+	if err != nil {
+		return unixerr("close", err)
+	}
+	return nil
+}
+
+// unixReader reads from the transport's socket, queueing the
+// descriptors received with the data.
+type unixReader struct {
+	t   *unixTransport
+	oob []byte
+}
+
+func (r *unixReader) Read(p []byte) (int, error) {
+	n, oobn, flags, _, err := r.t.conn.ReadMsgUnix(p, r.oob)
+	if n < 0 {
+		n = 0
+	}
+	if oobn > 0 {
+		fds, perr := parseUnixRights(r.oob[:oobn])
+		r.t.mu.Lock()
+		if r.t.closed {
+			closeFiles(fds)
+		} else {
+			r.t.queue = append(r.t.queue, fds...)
+		}
+		r.t.mu.Unlock()
+		if perr != nil && err == nil {
+			err = perr
+		}
+	}
+	if flags&syscall.MSG_CTRUNC != 0 && err == nil {
+		err = errors.New("descriptors truncated")
+	}
+	return n, err
+}
+
+// parseUnixRights returns the descriptors in the SCM_RIGHTS control
+// messages in oob.
+func parseUnixRights(oob []byte) ([]*os.File, error) {
+	cmsgs, err := syscall.ParseSocketControlMessage(oob)
+	if err != nil {
+		return nil, err
+	}
+	var files []*os.File
+	for i := range cmsgs {
+		fds, err := syscall.ParseUnixRights(&cmsgs[i])
+		if err != nil {
+			continue
+		}
+		for _, fd := range fds {
+			syscall.CloseOnExec(fd)
+			files = append(files, os.NewFile(uintptr(fd), "capnp-fd"))
+		}
+	}
+	return files, nil
+}
+
+func closeFiles(files []*os.File) {
+	for _, f := range files {
+		if f != nil {
+			f.Close()
+		}
+	}
+}
+
+func unixerr(op string, err error) error {
+	return transporterr.Annotate(fmt.Errorf("%s: %w", op, err), "unix transport")
+}
@@ -0,0 +1,111 @@
+//go:build linux || darwin || freebsd
+
+package transport
+
+import (
+	"io"
+	"os"
+	"syscall"
+	"testing"
+)
+
+func TestUnixTransport(t *testing.T) {
+	t.Parallel()
+
+	testTransport(t, func() (t1, t2 Transport, err error) {
+		return newUnixPair()
+	})
+}
+
+// TestUnixTransportFds sends descriptors with messages, and checks that
+// each message gets its own and that untaken ones are closed.
+func TestUnixTransportFds(t *testing.T) {
+	t.Parallel()
+
+	t1, t2, err := newUnixPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer t1.Close()
+	defer t2.Close()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	for i, data := range []string{"a", "bc"} {
+		out, err := t1.NewMessage()
+		if err != nil {
+			t.Fatal("NewMessage:", err)
+		}
+		out.Message.NewBootstrap()
+		for j := 0; j < i+1; j++ {
+			idx, err := t1.AttachFd(out.Message.Message(), int(w.Fd()))
+			if err != nil {
+				t.Fatal("AttachFd:", err)
+			}
+			if idx != j {
+				t.Errorf("AttachFd #%d returned index %d", j, idx)
+			}
+		}
+		if err := out.Send(); err != nil {
+			t.Fatal("Send:", err)
+		}
+		out.Release()
+
+		in, err := t2.RecvMessage()
+		if err != nil {
+			t.Fatal("RecvMessage:", err)
+		}
+		msg := in.Message.Message()
+		f, ok := t2.TakeFd(msg, i)
+		if !ok {
+			t.Fatalf("message #%d: TakeFd(%d) = false", i, i)
+		}
+		if _, ok := t2.TakeFd(msg, i); ok {
+			t.Errorf("message #%d: TakeFd(%d) succeeded twice", i, i)
+		}
+		if _, ok := t2.TakeFd(msg, i+1); ok {
+			t.Errorf("message #%d: TakeFd(%d) succeeded past end", i, i+1)
+		}
+		in.Release()
+
+		if _, err := f.WriteString(data); err != nil {
+			t.Error("write to received descriptor:", err)
+		}
+		f.Close()
+		buf := make([]byte, len(data))
+		if _, err := io.ReadFull(r, buf); err != nil || string(buf) != data {
+			t.Errorf("read %q, %v; want %q", buf, err, data)
+		}
+	}
+
+	// Every copy of the write end has been closed, so the pipe is at
+	// its end.
+	w.Close()
+	if n, err := r.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("read %d bytes, %v from pipe; want EOF", n, err)
+	}
+}
+
+// newUnixPair returns a pair of Unix transports connected by a socket
+// pair.
+func newUnixPair() (t1, t2 FdTransport, err error) {
+	fds, err := syscall.Socketpair(syscall.AF_UNIX, syscall.SOCK_STREAM, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	c1, err := unixConnFromFD(fds[0])
+	if err != nil {
+		syscall.Close(fds[1])
+		return nil, nil, err
+	}
+	c2, err := unixConnFromFD(fds[1])
+	if err != nil {
+		c1.Close()
+		return nil, nil, err
+	}
+	return NewUnix(c1), NewUnix(c2), nil
+}
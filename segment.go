@@ -14,6 +14,10 @@ type Segment struct {
 	msg  *Message
 	id   SegmentID
 	data []byte
+
+	// readOnly is set if data must not be written to.  The first write
+	// to the segment replaces data with a copy.
+	readOnly bool
 }
 
 // Message returns the message that contains s.
@@ -49,6 +53,28 @@ func (s *Segment) slice(base address, sz Size) []byte {
 	return s.data[base:base.addSizeUnchecked(sz)]
 }
 
+// writable returns the segment of data from base to base+sz for
+// writing, copying the segment's data first if it is read-only.
+// It panics if the slice is out of bounds.
+//
+// writable is on the path of every write, so it is kept small enough
+// to inline, and the copy is left to copyOnWrite.
+func (s *Segment) writable(base address, sz Size) []byte {
+	if s.readOnly {
+		s.copyOnWrite()
+	}
+	return s.slice(base, sz)
+}
+
+// copyOnWrite replaces the data of a read-only segment with a copy
+// that may be written to.
+func (s *Segment) copyOnWrite() {
+	data := make([]byte, len(s.data))
+	copy(data, s.data)
+	s.data = data
+	s.readOnly = false
+}
+
 func (s *Segment) readUint8(addr address) uint8 {
 	return s.slice(addr, 1)[0]
 }
@@ -70,19 +96,19 @@ func (s *Segment) readRawPointer(addr address) rawPointer {
 }
 
 func (s *Segment) writeUint8(addr address, val uint8) {
-	s.slice(addr, 1)[0] = val
+	s.writable(addr, 1)[0] = val
 }
 
 func (s *Segment) writeUint16(addr address, val uint16) {
-	binary.LittleEndian.PutUint16(s.slice(addr, 2), val)
+	binary.LittleEndian.PutUint16(s.writable(addr, 2), val)
 }
 
 func (s *Segment) writeUint32(addr address, val uint32) {
-	binary.LittleEndian.PutUint32(s.slice(addr, 4), val)
+	binary.LittleEndian.PutUint32(s.writable(addr, 4), val)
 }
 
 func (s *Segment) writeUint64(addr address, val uint64) {
-	binary.LittleEndian.PutUint64(s.slice(addr, 8), val)
+	binary.LittleEndian.PutUint64(s.writable(addr, 8), val)
 }
 
 func (s *Segment) writeRawPointer(addr address, val rawPointer) {
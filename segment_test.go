@@ -846,3 +846,15 @@ func catchPanic(f func()) (err error) {
 	f()
 	return nil
 }
+
+// BenchmarkSegmentWrite measures the writes that every setter makes,
+// which check whether the segment must be copied first.
+func BenchmarkSegmentWrite(b *testing.B) {
+	seg := &Segment{data: make([]byte, 4096)}
+	b.SetBytes(int64(len(seg.data)))
+	for i := 0; i < b.N; i++ {
+		for addr := address(0); addr < address(len(seg.data)); addr += 8 {
+			seg.writeUint64(addr, uint64(addr))
+		}
+	}
+}
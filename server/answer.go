@@ -58,6 +58,23 @@ func newAnswerQueue(m capnp.Method) *answerQueue {
 // struct.  After fulfill returns, pipeline calls will be immediately
 // delivered instead of being queued.
 func (aq *answerQueue) fulfill(s capnp.Struct) {
+	aq.drain(capnp.ImmediateAnswer(aq.method, s).PipelineRecv)
+}
+
+// forward empties the queue, delivering the method calls to pcall,
+// the PipelineCaller of a tail call.  After forward returns, pipeline
+// calls will be immediately delivered to pcall.  If pcall is nil, the
+// calls are rejected.
+func (aq *answerQueue) forward(pcall capnp.PipelineCaller) {
+	if pcall == nil {
+		aq.reject(errorf("tail call for %v does not support pipelining", &aq.method))
+		return
+	}
+	aq.drain(pcall.PipelineRecv)
+}
+
+// drain empties the queue, delivering the method calls to deliver.
+func (aq *answerQueue) drain(deliver func(context.Context, []capnp.PipelineOp, capnp.Recv) capnp.PipelineCaller) {
 	// Enter draining state.
 	aq.mu.Lock()
 	q := aq.q
@@ -68,7 +85,7 @@ func (aq *answerQueue) fulfill(s capnp.Struct) {
 	for i := range aq.bases {
 		aq.bases[i].ready = ready
 	}
-	aq.bases[0].recv = capnp.ImmediateAnswer(aq.method, s).PipelineRecv
+	aq.bases[0].recv = deliver
 	close(aq.draining)
 	aq.mu.Unlock()
 
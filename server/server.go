@@ -14,6 +14,10 @@ import (
 )
 
 // A Method describes a single capability method on a server object.
+//
+// Impl is called with a Context that is canceled when the caller
+// cancels the call or the server shuts down.  For calls received over
+// an rpc.Conn, context.Cause reports why, e.g. rpc.ErrCallFinished.
 type Method struct {
 	capnp.Method
 	Impl func(context.Context, *Call) error
@@ -31,9 +35,22 @@ type Call struct {
 	alloced bool
 	results capnp.Struct
 
+	// tail is set by TailCall, and tailPcall is the PipelineCaller
+	// returned by the tail call.  forwarded is set once the calls
+	// pipelined on this call have been forwarded to the tail call.
+	tail      bool
+	tailPcall capnp.PipelineCaller
+	forwarded bool
+
 	acked bool
 }
 
+// Method returns the method being called, with the names from the
+// server's Method if it has them.
+func (c *Call) Method() capnp.Method {
+	return c.method.Method
+}
+
 // Args returns the call's arguments.  Args is not safe to
 // reference after a method implementation returns.  Args is safe to
 // call and read from multiple goroutines.
@@ -47,12 +64,57 @@ func (c *Call) AllocResults(sz capnp.ObjectSize) (capnp.Struct, error) {
 	if c.alloced {
 		return capnp.Struct{}, newError("multiple calls to AllocResults")
 	}
+	if c.tail {
+		return capnp.Struct{}, newError("AllocResults called after TailCall")
+	}
 	var err error
 	c.alloced = true
 	c.results, err = c.recv.Returner.AllocResults(sz)
 	return c.results, err
 }
 
+// TailCall makes a call to target whose results become the results of
+// this call, rather than being copied into results allocated by
+// AllocResults.  If target is hosted by the vat that made this call,
+// the results are sent straight back to it without passing through
+// this vat.  The call is made with the Context that this call was
+// received with, so it is canceled along with this call.
+//
+// TailCall must be called at most once, and not after AllocResults.
+// Once it returns nil, the method's return value is ignored: the call
+// returns when the tail call does.  Pipelined calls made on this
+// call's results are delivered to the tail call's results.
+func (c *Call) TailCall(target capnp.Client, s capnp.Send) error {
+	if c.alloced {
+		return newError("TailCall called after AllocResults")
+	}
+	if c.tail {
+		return newError("multiple calls to TailCall")
+	}
+	args, err := sendArgsToStruct(s)
+	if err != nil {
+		return err
+	}
+	c.tail = true
+	c.tailPcall = target.RecvCall(c.ctx, capnp.Recv{
+		Method: s.Method,
+		Args:   args,
+		ReleaseArgs: func() {
+			if msg := args.Message(); msg != nil {
+				msg.Reset(nil)
+				args = capnp.Struct{}
+			}
+		},
+		Returner: c.recv.Returner,
+		TailCall: true,
+		ForwardPipeline: func(pcall capnp.PipelineCaller) {
+			c.aq.forward(pcall)
+			c.forwarded = true
+		},
+	})
+	return nil
+}
+
 // Go is a function that is called to unblock future calls; by default
 // a server only accepts one method call at a time, waiting until
 // the method returns before servicing the next method in the queue.
@@ -84,6 +146,12 @@ type Server struct {
 	brand    any
 	shutdown Shutdowner
 
+	// invoke calls a method's Impl through the interceptors.
+	invoke func(context.Context, *Call) error
+
+	// fd is the file descriptor set by WithFd, or -1.
+	fd int
+
 	// Cancels handleCallsCtx
 	cancelHandleCalls context.CancelFunc
 
@@ -102,11 +170,50 @@ type Server struct {
 	callQueue *mpsc.Queue[*Call]
 }
 
+// An Option configures a Server.
+type Option func(*Server)
+
+// An Interceptor intercepts the calls to a Server's methods.  It is
+// called on the goroutine that would call the method's Impl, and must
+// call next at most once to run the rest of the chain and then Impl.
+// Returning an error without calling next fails the call with it.
+// Pipelined calls on the call's results are queued until the call
+// returns, whether or not the interceptor calls next.
+type Interceptor func(ctx context.Context, call *Call, next func(context.Context, *Call) error) error
+
+// WithInterceptors adds interceptors that see each call before the
+// method's Impl runs.  The first interceptor is the outermost: it sees
+// a call first, and its next function calls the second.
+func WithInterceptors(interceptors ...Interceptor) Option {
+	return func(srv *Server) {
+		for i := len(interceptors) - 1; i >= 0; i-- {
+			f, next := interceptors[i], srv.invoke
+			srv.invoke = func(ctx context.Context, c *Call) error {
+				return f(ctx, c, next)
+			}
+		}
+	}
+}
+
+// WithFd attaches a file descriptor to the server's capability, which
+// Client.Fd returns and an RPC connection over a transport that can
+// pass descriptors sends along with the capability.  The server does not
+// take ownership of fd: it must stay open until the server is shut
+// down, for instance by closing it in the Shutdowner.
+//
+// Without WithFd, a server whose brand implements capnp.FdHook uses its
+// descriptor.
+func WithFd(fd int) Option {
+	return func(srv *Server) {
+		srv.fd = fd
+	}
+}
+
 // New returns a client hook that makes calls to a set of methods.
 // If shutdown is nil then the server's shutdown is a no-op.  The server
 // guarantees message delivery order by blocking each call on the
 // return of the previous call or a call to Call.Go.
-func New(methods []Method, brand any, shutdown Shutdowner) *Server {
+func New(methods []Method, brand any, shutdown Shutdowner, opts ...Option) *Server {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	srv := &Server{
@@ -116,9 +223,14 @@ func New(methods []Method, brand any, shutdown Shutdowner) *Server {
 		callQueue:         mpsc.New[*Call](),
 		cancelHandleCalls: cancel,
 		handleCallsCtx:    ctx,
+		invoke:            invokeImpl,
+		fd:                -1,
 	}
 	copy(srv.methods, methods)
 	sort.Sort(srv.methods)
+	for _, opt := range opts {
+		opt(srv)
+	}
 	go srv.handleCalls(ctx)
 	return srv
 }
@@ -203,9 +315,16 @@ func (srv *Server) handleCalls(ctx context.Context) {
 func (srv *Server) handleCall(ctx context.Context, c *Call) {
 	defer srv.wg.Done()
 
-	err := c.method.Impl(ctx, c)
+	err := srv.invoke(ctx, c)
 
 	c.recv.ReleaseArgs()
+	if c.tail {
+		// The tail call returns on our behalf.
+		if !c.forwarded {
+			c.aq.forward(c.tailPcall)
+		}
+		return
+	}
 	if err == nil {
 		c.aq.fulfill(c.results)
 	} else {
@@ -214,6 +333,10 @@ func (srv *Server) handleCall(ctx context.Context, c *Call) {
 	c.recv.Returner.Return(err)
 }
 
+func invokeImpl(ctx context.Context, c *Call) error {
+	return c.method.Impl(ctx, c)
+}
+
 func (srv *Server) start(ctx context.Context, m *Method, r capnp.Recv) capnp.PipelineCaller {
 	srv.wg.Add(1)
 
@@ -228,6 +351,18 @@ func (srv *Server) start(ctx context.Context, m *Method, r capnp.Recv) capnp.Pip
 	return aq
 }
 
+// Fd returns the file descriptor attached to the server's capability.
+// It implements capnp.FdHook.
+func (srv *Server) Fd() (int, bool) {
+	if srv.fd >= 0 {
+		return srv.fd, true
+	}
+	if fh, ok := srv.brand.(capnp.FdHook); ok {
+		return fh.Fd()
+	}
+	return -1, false
+}
+
 // Brand returns a value that will match IsServer.
 func (srv *Server) Brand() capnp.Brand {
 	return capnp.Brand{Value: serverBrand{srv.brand}}
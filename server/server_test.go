@@ -344,3 +344,222 @@ func (c *callSeqBlockN) GetNumber(ctx context.Context, p air.CallSequence_getNum
 		return ctx.Err()
 	}
 }
+
+// tailEchoImpl answers calls to Echo by tail-calling another Echo.
+type tailEchoImpl struct {
+	target air.Echo
+}
+
+func (e tailEchoImpl) Echo(ctx context.Context, call air.Echo_echo) error {
+	in, err := call.Args().In()
+	if err != nil {
+		return err
+	}
+	return call.TailCall(capnp.Client(e.target), capnp.Send{
+		Method: capnp.Method{
+			InterfaceID:   air.Echo_TypeID,
+			MethodID:      0,
+			InterfaceName: "aircraft.capnp:Echo",
+			MethodName:    "echo",
+		},
+		ArgsSize: capnp.ObjectSize{PointerCount: 1},
+		PlaceArgs: func(s capnp.Struct) error {
+			return air.Echo_echo_Params(s).SetIn("<" + in + ">")
+		},
+	})
+}
+
+// tailPipeliner answers calls to NewPipeliner by tail-calling another
+// Pipeliner.
+type tailPipeliner struct {
+	callSeq
+	target air.Pipeliner
+}
+
+func (p *tailPipeliner) NewPipeliner(ctx context.Context, call air.Pipeliner_newPipeliner) error {
+	return call.TailCall(capnp.Client(p.target), capnp.Send{
+		Method: capnp.Method{
+			InterfaceID:   air.Pipeliner_TypeID,
+			MethodID:      0,
+			InterfaceName: "aircraft.capnp:Pipeliner",
+			MethodName:    "newPipeliner",
+		},
+	})
+}
+
+func TestTailCall(t *testing.T) {
+	t.Run("Results", func(t *testing.T) {
+		echo := air.Echo_ServerToClient(tailEchoImpl{target: air.Echo_ServerToClient(echoImpl{})})
+		defer echo.Release()
+
+		ans, finish := echo.Echo(context.Background(), func(p air.Echo_echo_Params) error {
+			return p.SetIn("foo")
+		})
+		defer finish()
+		result, err := ans.Struct()
+		if err != nil {
+			t.Fatalf("echo.Echo() error: %v", err)
+		}
+		if out, err := result.Out(); err != nil {
+			t.Errorf("echo.Echo() error: %v", err)
+		} else if out != "<foo><foo>" {
+			t.Errorf("echo.Echo() = %q; want %q", out, "<foo><foo>")
+		}
+	})
+	t.Run("Error", func(t *testing.T) {
+		echo := air.Echo_ServerToClient(tailEchoImpl{target: air.Echo_ServerToClient(errorEchoImpl{})})
+		defer echo.Release()
+
+		ans, finish := echo.Echo(context.Background(), nil)
+		defer finish()
+		if _, err := ans.Struct(); err == nil || !strings.Contains(err.Error(), "reverb stopped") {
+			t.Errorf("echo.Echo() error = %v; want \"reverb stopped\"", err)
+		}
+	})
+	t.Run("Pipeline", func(t *testing.T) {
+		wait := make(chan struct{})
+		p := air.Pipeliner_ServerToClient(&tailPipeliner{
+			target: air.Pipeliner_ServerToClient(&pipeliner{
+				factory: func(ctx context.Context) (*pipeliner, error) {
+					select {
+					case <-wait:
+						return new(pipeliner), nil
+					case <-ctx.Done():
+						return nil, ctx.Err()
+					}
+				},
+			}),
+		})
+		defer p.Release()
+
+		ctx := context.Background()
+		baseAns, finish := p.NewPipeliner(ctx, nil)
+		defer finish()
+		ans1, finish := baseAns.Pipeliner().GetNumber(ctx, nil)
+		defer finish()
+		close(wait)
+
+		result1, err := ans1.Struct()
+		if err != nil {
+			t.Errorf("GetNumber(): %v", err)
+		} else if result1.N() != 0 {
+			t.Errorf("GetNumber() = %d; want 0", result1.N())
+		}
+	})
+	t.Run("AfterAllocResults", func(t *testing.T) {
+		target := air.Echo_ServerToClient(echoImpl{})
+		defer target.Release()
+
+		var tailErr error
+		echo := air.Echo_ServerToClient(echoFunc(func(ctx context.Context, call air.Echo_echo) error {
+			if _, err := call.AllocResults(); err != nil {
+				return err
+			}
+			tailErr = call.TailCall(capnp.Client(target), capnp.Send{})
+			return nil
+		}))
+		defer echo.Release()
+
+		ans, finish := echo.Echo(context.Background(), nil)
+		defer finish()
+		if _, err := ans.Struct(); err != nil {
+			t.Errorf("echo.Echo() error: %v", err)
+		}
+		if tailErr == nil {
+			t.Error("TailCall after AllocResults succeeded")
+		}
+	})
+}
+
+type echoFunc func(context.Context, air.Echo_echo) error
+
+func (f echoFunc) Echo(ctx context.Context, call air.Echo_echo) error {
+	return f(ctx, call)
+}
+
+func TestInterceptors(t *testing.T) {
+	ctx := context.Background()
+	var (
+		mu  sync.Mutex
+		log []string
+	)
+	record := func(s string) {
+		mu.Lock()
+		defer mu.Unlock()
+		log = append(log, s)
+	}
+	srv := server.New(air.Pipeliner_Methods(nil, &pipeliner{
+		factory: func(context.Context) (*pipeliner, error) {
+			return new(pipeliner), nil
+		},
+	}), nil, nil, server.WithInterceptors(
+		func(ctx context.Context, call *server.Call, next func(context.Context, *server.Call) error) error {
+			record("server " + call.Method().MethodName)
+			return next(ctx, call)
+		},
+	))
+	p := air.Pipeliner(capnp.WrapClient(capnp.NewClient(srv), capnp.Interceptor{
+		Send: func(ctx context.Context, s capnp.Send, next capnp.SendFunc) (*capnp.Answer, capnp.ReleaseFunc) {
+			record("client " + s.Method.MethodName)
+			return next(ctx, s)
+		},
+	}))
+	defer p.Release()
+
+	baseAns, finish := p.NewPipeliner(ctx, nil)
+	defer finish()
+	ans, finish := baseAns.Pipeliner().GetNumber(ctx, nil)
+	defer finish()
+	result, err := ans.Struct()
+	if err != nil {
+		t.Fatal("GetNumber():", err)
+	}
+	if result.N() != 0 {
+		t.Errorf("GetNumber() = %d; want 0", result.N())
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{"client newPipeliner", "server newPipeliner"}
+	if len(log) != len(want) || log[0] != want[0] || log[1] != want[1] {
+		t.Errorf("interceptors called %q; want %q", log, want)
+	}
+}
+
+func TestInterceptorReject(t *testing.T) {
+	ctx := context.Background()
+	called := false
+	echo := air.Echo(capnp.NewClient(server.New(air.Echo_Methods(nil, echoFunc(func(context.Context, air.Echo_echo) error {
+		called = true
+		return nil
+	})), nil, nil, server.WithInterceptors(
+		func(ctx context.Context, call *server.Call, next func(context.Context, *server.Call) error) error {
+			return errors.New("denied")
+		},
+	))))
+	defer echo.Release()
+
+	ans, finish := echo.Echo(ctx, nil)
+	defer finish()
+	if _, err := ans.Struct(); err == nil || !strings.Contains(err.Error(), "denied") {
+		t.Errorf("Echo() error = %v; want \"denied\"", err)
+	}
+	if called {
+		t.Error("method called despite interceptor rejecting call")
+	}
+}
+
+func TestFd(t *testing.T) {
+	impl := echoFunc(func(context.Context, air.Echo_echo) error { return nil })
+	c := capnp.NewClient(server.New(air.Echo_Methods(nil, impl), impl, nil, server.WithFd(7)))
+	defer c.Release()
+	if fd, ok := c.Fd(); !ok || fd != 7 {
+		t.Errorf("Fd() = %d, %t; want 7, true", fd, ok)
+	}
+
+	c2 := capnp.NewClient(server.New(air.Echo_Methods(nil, impl), impl, nil))
+	defer c2.Release()
+	if fd, ok := c2.Fd(); ok {
+		t.Errorf("Fd() without WithFd = %d, true; want false", fd)
+	}
+}
@@ -312,7 +312,7 @@ func copyStruct(dst, src Struct) error {
 
 	// data section:
 	srcData := src.seg.slice(src.off, src.size.DataSize)
-	dstData := dst.seg.slice(dst.off, dst.size.DataSize)
+	dstData := dst.seg.writable(dst.off, dst.size.DataSize)
 	copyCount := copy(dstData, srcData)
 	dstData = dstData[copyCount:]
 	for j := range dstData {